@@ -80,7 +80,7 @@ export function ForceChart({ punches, threshold = THRESHOLD_DEFAULT }: Props) {
         </p>
         {/* Legend */}
         <div style={styles.legend}>
-          {(['straight', 'hook', 'uppercut'] as const).map(t => (
+          {(['jab', 'cross', 'hook', 'uppercut'] as const).map(t => (
             <span key={t} style={styles.legendItem}>
               <span style={{ ...styles.legendDot, background: TYPE_COLORS[t] }} />
               {t}
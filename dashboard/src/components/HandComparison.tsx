@@ -7,7 +7,7 @@ interface Props {
   right: HandState
 }
 
-const PUNCH_TYPES = ['straight', 'hook', 'uppercut']
+const PUNCH_TYPES = ['jab', 'cross', 'hook', 'uppercut']
 
 export function HandComparison({ left, right }: Props) {
   const totalPunches = left.punch_count + right.punch_count
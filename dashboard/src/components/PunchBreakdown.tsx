@@ -1,13 +1,14 @@
 import React from 'react'
 
 export const TYPE_COLORS: Record<string, string> = {
-  straight: '#3b82f6',
+  jab:      '#3b82f6',
+  cross:    '#22c55e',
   hook:     '#f59e0b',
   uppercut: '#a855f7',
   unknown:  '#444',
 }
 
-const PUNCH_TYPES = ['straight', 'hook', 'uppercut']
+const PUNCH_TYPES = ['jab', 'cross', 'hook', 'uppercut']
 
 interface Props {
   left:  Record<string, number>
@@ -6,7 +6,7 @@ interface Props {
   breakdown: Record<string, number>
 }
 
-const PUNCH_TYPES = ['straight', 'hook', 'uppercut', 'unknown']
+const PUNCH_TYPES = ['jab', 'cross', 'hook', 'uppercut', 'unknown']
 
 export function PunchPieChart({ breakdown }: Props) {
   const data = PUNCH_TYPES
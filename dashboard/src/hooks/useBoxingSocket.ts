@@ -1,5 +1,5 @@
 import { useEffect, useRef, useState, useCallback } from 'react'
-import { SessionState, defaultSession, AppPhase } from '../types'
+import { SessionState, SparringState, defaultSession, AppPhase } from '../types'
 
 const WS_URL = '/ws'            // proxied by Vite in dev, direct in prod
 const RECONNECT_DELAY_MS = 2000 // retry after 2s on disconnect
@@ -8,6 +8,7 @@ interface UseBoxingSocket {
   // Server state
   state: SessionState
   connected: boolean
+  sparring: SparringState | null  // both athletes in sparring mode, else null
   
   // Local UI state
   phase: AppPhase
@@ -28,6 +29,7 @@ interface UseBoxingSocket {
 export function useBoxingSocket(): UseBoxingSocket {
   const [state, setState] = useState<SessionState>(defaultSession)
   const [connected, setConnected] = useState(false)
+  const [sparring, setSparring] = useState<SparringState | null>(null)
   const [phase, setPhase] = useState<AppPhase>('pre')
   const [roundDuration, setRoundDuration] = useState(180) // 3 minutes default
   const [finalState, setFinalState] = useState<SessionState | null>(null)
@@ -56,8 +58,16 @@ export function useBoxingSocket(): UseBoxingSocket {
 
     ws.onmessage = (evt) => {
       try {
-        const data = JSON.parse(evt.data) as SessionState
-        setState(data)
+        const data = JSON.parse(evt.data)
+        // In sparring mode the dashboard follows athlete a
+        if (data.type === 'sparring') {
+          setSparring(data as SparringState)
+          setState(data.a as SessionState)
+          return
+        }
+        // Typed event messages (alerts, etc.) are not state snapshots
+        if (typeof data.type === 'string') return
+        setState(data as SessionState)
       } catch (e) {
         console.warn('[WS] Failed to parse message:', evt.data)
       }
@@ -98,7 +108,7 @@ export function useBoxingSocket(): UseBoxingSocket {
 
   const startSession = useCallback(async () => {
     try {
-      await fetch('/api/session/start', { method: 'POST' })
+      await fetch('/api/v1/session/start', { method: 'POST' })
       setPhase('live')
       setFinalState(null)
     } catch (e) {
@@ -108,7 +118,7 @@ export function useBoxingSocket(): UseBoxingSocket {
 
   const pauseSession = useCallback(async () => {
     try {
-      await fetch('/api/session/pause', { method: 'POST' })
+      await fetch('/api/v1/session/pause', { method: 'POST' })
     } catch (e) {
       console.error('[API] session/pause failed:', e)
     }
@@ -116,7 +126,7 @@ export function useBoxingSocket(): UseBoxingSocket {
 
   const resumeSession = useCallback(async () => {
     try {
-      await fetch('/api/session/resume', { method: 'POST' })
+      await fetch('/api/v1/session/resume', { method: 'POST' })
     } catch (e) {
       console.error('[API] session/resume failed:', e)
     }
@@ -128,7 +138,7 @@ export function useBoxingSocket(): UseBoxingSocket {
     setPhase('post')
     
     try {
-      await fetch('/api/session/stop', { method: 'POST' })
+      await fetch('/api/v1/session/stop', { method: 'POST' })
     } catch (e) {
       console.error('[API] session/stop failed:', e)
     }
@@ -139,7 +149,7 @@ export function useBoxingSocket(): UseBoxingSocket {
     setFinalState(null)
     
     try {
-      await fetch('/api/session/reset', { method: 'POST' })
+      await fetch('/api/v1/session/reset', { method: 'POST' })
     } catch (e) {
       console.error('[API] session/reset failed:', e)
     }
@@ -148,6 +158,7 @@ export function useBoxingSocket(): UseBoxingSocket {
   return {
     state,
     connected,
+    sparring,
     phase,
     roundDuration,
     setRoundDuration,
@@ -1,24 +1,63 @@
 // Types mirror the Go structs broadcast over WebSocket.
 
+// Approach of a punch in the world frame (heading unknown without a compass)
+export interface Trajectory {
+  horizontal: number // m/s
+  vertical: number   // m/s, positive upward
+  elevation: number  // degrees above horizontal
+}
+
 export interface PunchEvent {
+  id: number         // unique in the session, increasing from 1
   hand: string       // "left" | "right"
-  type: string       // "straight" | "hook" | "uppercut" | "unknown"
+  type: string       // "jab" | "cross" | "hook" | "uppercut" | "unknown"
   force: number      // m/s²
   rotation_z: number // peak °/s
+  rfd: number        // rate of force development, m/s³
+  duration_ms: number // acceleration phase, onset to peak
+  retraction: number // peak return speed after impact, m/s
+  trajectory: Trajectory
+  contact: boolean   // hit the bag rather than the air
   ts: number         // ESP32 millis
   count: number      // punch number in session
 }
 
+// Punch rate over sliding windows (punches per minute)
+export interface RollingRate {
+  ppm_10s: number
+  ppm_30s: number
+  ppm_60s: number
+}
+
+export interface FatigueStats {
+  index: number              // 0 (fresh) to 100 (spent)
+  force_decline: number      // % drop in average force
+  ppm_decline: number        // % drop in punch rate
+  interval_increase: number  // % growth in time between punches
+}
+
+export interface SpectrumStats {
+  dominant_hz: number    // strongest rhythm frequency (0 = idle)
+  consistency: number    // 0-1, share of rhythm power at the dominant frequency
+  ringing_share: number  // 0-1, share of power above 12 Hz
+}
+
 export interface HandState {
   connected: boolean
   calibrated: boolean
   battery: number
+  charging: boolean
   packet_loss: number
+  corrupt_packets: number
   punch_count: number
+  suppressed: number         // detections dropped as shock from the other hand
+  contact_punches: number    // punches that hit the bag
+  air_punches: number        // shadowboxing punches
   punch_breakdown: Record<string, number>
   max_force: number
   avg_force: number
   ppm: number
+  rolling_ppm: RollingRate   // current effort over sliding windows
   recent_punches: PunchEvent[]
   current_accel: [number, number, number]  // X, Y, Z in m/s²
   current_gyro: [number, number, number]   // X, Y, Z in °/s
@@ -27,6 +66,22 @@ export interface HandState {
   gravity_ref: [number, number, number]    // Gravity vector in sensor frame
   glove_orientation: string                // "palm_down", "palm_up", etc.
   up_axis: number                          // 0=X, 1=Y, 2=Z
+  orientation: [number, number, number, number]  // quaternion w, x, y, z (sensor → world)
+  // Detection threshold
+  threshold: number                        // m/s² - active punch threshold
+  auto_threshold: boolean                  // true while the threshold is being learned
+  // Stance and guard
+  lead: boolean                            // this hand leads
+  guard_dropped: boolean                   // glove held away from the guard position
+  guard_drops: number
+  guard_down_sec: number
+  // Output fall-off over the session
+  fatigue: FatigueStats
+  // Frequency content of recent motion (optional FFT stage)
+  spectrum: SpectrumStats
+  // Explosiveness: rate of force development (m/s³)
+  avg_rfd: number
+  rfd_by_type: Record<string, number>
 }
 
 export interface CombinedStats {
@@ -34,8 +89,29 @@ export interface CombinedStats {
   avg_force: number
   max_force: number
   ppm: number
+  rolling_ppm: RollingRate
+  contact_punches: number  // punches that hit the bag
+  air_punches: number      // shadowboxing punches
   pps: number              // punches per second
   intensity_score: number  // gamified score
+  // Combinations across both hands
+  combos: number
+  longest_combo: number
+  combo_breakdown: Record<string, number>  // e.g. "jab-cross" → count
+  // High-output bursts
+  flurries: number
+  longest_flurry: number   // punches
+  max_burst_rate: number   // punches per second
+  balance: BalanceStats
+}
+
+// Left/right symmetry (ratios are left over right)
+export interface BalanceStats {
+  punch_ratio: number
+  force_ratio: number
+  left_share: number               // 0-1
+  by_type: Record<string, number>  // left share per punch type, 0-1
+  lagging: string                  // 'left' | 'right' | '' when balanced
 }
 
 export interface SessionState {
@@ -45,6 +121,61 @@ export interface SessionState {
   right: HandState
   combined: CombinedStats
   paused: boolean
+  stance: string   // 'orthodox' | 'southpaw' | '' (configured, else inferred)
+  heart_rate: number  // BPM from a heart-rate strap (0 = none)
+  hr_stats: HeartRateStats
+}
+
+// Two athletes side by side in sparring mode (ratios are a over b)
+export interface SparringComparison {
+  punch_share_a: number     // 0-1
+  punch_diff: number
+  force_ratio: number
+  ppm_diff: number
+  intensity_diff: number
+  leader: string            // 'a' | 'b' | '' when level
+  hardest_puncher: string   // 'a' | 'b' | ''
+}
+
+export interface SparringState {
+  type: 'sparring'
+  a: SessionState
+  b: SessionState
+  comparison: SparringComparison
+}
+
+// Heart rate vs punch output for one round
+export interface RoundStats {
+  round: number  // 1-based
+  avg_bpm: number
+  max_bpm: number
+  punches: number
+  avg_force: number
+}
+
+export interface HeartRateStats {
+  max_hr: number              // max heart rate the zones derive from
+  zone: number                // current zone 1-5 (0 = below zone 1 or no reading)
+  avg_bpm: number
+  peak_bpm: number
+  time_in_zone_sec: number[]  // zones 1-5
+  rounds: RoundStats[]
+  output_correlation: number  // round avg BPM vs punches, -1 to 1
+}
+
+// GET /api/v1/session/timeline — downsampled acceleration magnitude
+export interface TimelineBucket {
+  t: number    // bucket start, ms since session start
+  min: number
+  avg: number
+  max: number
+  n: number    // samples in the bucket
+}
+
+export interface Timeline {
+  resolution_ms: number
+  left: TimelineBucket[]
+  right: TimelineBucket[]
 }
 
 // App phase for UI routing
@@ -61,12 +192,18 @@ export const defaultHandState: HandState = {
   connected: false,
   calibrated: false,
   battery: 0,
+  charging: false,
   packet_loss: 0,
+  corrupt_packets: 0,
   punch_count: 0,
+  suppressed: 0,
+  contact_punches: 0,
+  air_punches: 0,
   punch_breakdown: {},
   max_force: 0,
   avg_force: 0,
   ppm: 0,
+  rolling_ppm: { ppm_10s: 0, ppm_30s: 0, ppm_60s: 0 },
   recent_punches: [],
   current_accel: [0, 0, 0],
   current_gyro: [0, 0, 0],
@@ -74,6 +211,17 @@ export const defaultHandState: HandState = {
   gravity_ref: [0, 0, 0],
   glove_orientation: '',
   up_axis: 0,
+  orientation: [1, 0, 0, 0],
+  threshold: 25,
+  auto_threshold: false,
+  lead: false,
+  guard_dropped: false,
+  guard_drops: 0,
+  guard_down_sec: 0,
+  fatigue: { index: 0, force_decline: 0, ppm_decline: 0, interval_increase: 0 },
+  spectrum: { dominant_hz: 0, consistency: 0, ringing_share: 0 },
+  avg_rfd: 0,
+  rfd_by_type: {},
 }
 
 export const defaultSession: SessionState = {
@@ -81,8 +229,28 @@ export const defaultSession: SessionState = {
   elapsed_sec: 0,
   left: { ...defaultHandState },
   right: { ...defaultHandState },
-  combined: { total_punches: 0, avg_force: 0, max_force: 0, ppm: 0, pps: 0, intensity_score: 0 },
+  combined: {
+    total_punches: 0,
+    avg_force: 0,
+    max_force: 0,
+    ppm: 0,
+    rolling_ppm: { ppm_10s: 0, ppm_30s: 0, ppm_60s: 0 },
+    contact_punches: 0,
+    air_punches: 0,
+    pps: 0,
+    intensity_score: 0,
+    combos: 0,
+    longest_combo: 0,
+    combo_breakdown: {},
+    flurries: 0,
+    longest_flurry: 0,
+    max_burst_rate: 0,
+    balance: { punch_ratio: 0, force_ratio: 0, left_share: 0, by_type: {}, lagging: '' },
+  },
   paused: false,
+  stance: '',
+  heart_rate: 0,
+  hr_stats: { max_hr: 190, zone: 0, avg_bpm: 0, peak_bpm: 0, time_in_zone_sec: [0, 0, 0, 0, 0], rounds: [], output_correlation: 0 },
 }
 
 // Helper: estimate battery life remaining (rough estimate: ~2 hours at 100%)
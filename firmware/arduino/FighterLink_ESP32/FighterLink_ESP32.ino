@@ -63,6 +63,12 @@
 #define BLE_CHAR_BATTERY_UUID   "00001236-0000-1000-8000-00805f9b34fb"
 #define BLE_CHAR_DEVICE_UUID    "00001237-0000-1000-8000-00805f9b34fb"
 
+// Device info: [hand_id, fw_major, fw_minor, fw_patch, hw_rev]
+#define FIRMWARE_VERSION_MAJOR  1
+#define FIRMWARE_VERSION_MINOR  0
+#define FIRMWARE_VERSION_PATCH  0
+#define HARDWARE_REV            0  // ESP32 DevKit breadboard build
+
 // Device name based on hand
 #if HAND_ID == 0
   #define BLE_DEVICE_NAME "FighterLink_L"
@@ -263,13 +269,17 @@ void setupBLE() {
     uint8_t initBattery = 100;  // Hardcoded for now
     pBatteryChar->setValue(&initBattery, 1);
     
-    // Create Device Info Characteristic (READ only - returns hand ID)
+    // Create Device Info Characteristic (READ only - hand ID and versions)
     pDeviceChar = pService->createCharacteristic(
         BLE_CHAR_DEVICE_UUID,
         BLECharacteristic::PROPERTY_READ
     );
-    uint8_t handId = HAND_ID;
-    pDeviceChar->setValue(&handId, 1);
+    uint8_t deviceInfo[5] = {
+        HAND_ID,
+        FIRMWARE_VERSION_MAJOR, FIRMWARE_VERSION_MINOR, FIRMWARE_VERSION_PATCH,
+        HARDWARE_REV
+    };
+    pDeviceChar->setValue(deviceInfo, sizeof(deviceInfo));
     
     // Start the service
     pService->start();
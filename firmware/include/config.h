@@ -18,13 +18,39 @@
 #define BLE_SERVICE_UUID        "00001234-0000-1000-8000-00805f9b34fb"
 #define BLE_CHAR_SENSOR_UUID    "00001235-0000-1000-8000-00805f9b34fb"  // NOTIFY
 #define BLE_CHAR_BATTERY_UUID   "00001236-0000-1000-8000-00805f9b34fb"  // READ, NOTIFY
-#define BLE_CHAR_DEVICE_UUID    "00001237-0000-1000-8000-00805f9b34fb"  // READ (hand ID)
+#define BLE_CHAR_DEVICE_UUID    "00001237-0000-1000-8000-00805f9b34fb"  // READ (hand ID, versions)
+#define BLE_CHAR_COMMAND_UUID   "00001238-0000-1000-8000-00805f9b34fb"  // WRITE (feedback commands)
+
+// Command characteristic opcodes
+#define CMD_BUZZ        0x01    // [0x01, duration_ms (uint16 LE), intensity]
+#define CMD_LED         0x02    // [0x02, r, g, b, duration_ms (uint16 LE)]
+#define CMD_CLEAR_BONDS 0x03    // [0x03] forget the bonded server
+
+// ─── BLE Security ────────────────────────────────────────────────────────────
+// BLE_BONDING 1 requires an encrypted, passkey-authenticated link for every
+// characteristic. The first server to bond with BLE_PASSKEY becomes the
+// registered server; other centrals are disconnected until CMD_CLEAR_BONDS.
+// The server must be started with the same BLE_PASSKEY.
+#define BLE_BONDING     0
+#define BLE_PASSKEY     123456  // 6 digits, change per installation
+
+// ─── Device Info ─────────────────────────────────────────────────────────────
+// Reported by the device info characteristic as
+// [hand_id, fw_major, fw_minor, fw_patch, hw_rev]
+#define FIRMWARE_VERSION_MAJOR  1
+#define FIRMWARE_VERSION_MINOR  0
+#define FIRMWARE_VERSION_PATCH  0
+#define HARDWARE_REV            1
+
+// Second set of gloves for sparring mode: GLOVE_SET "2" advertises as
+// "FighterLink_L2" / "FighterLink_R2" (the server's athlete b)
+#define GLOVE_SET ""
 
 // Device names based on hand
 #if HAND_ID == 0
-    #define BLE_DEVICE_NAME "FighterLink_L"
+    #define BLE_DEVICE_NAME "FighterLink_L" GLOVE_SET
 #else
-    #define BLE_DEVICE_NAME "FighterLink_R"
+    #define BLE_DEVICE_NAME "FighterLink_R" GLOVE_SET
 #endif
 
 // ─── Pin Definitions (XIAO ESP32C3) ──────────────────────────────────────────
@@ -35,10 +61,33 @@
 // Status LED (onboard)
 #define PIN_LED         10  // GPIO10 - Onboard LED (active LOW on XIAO)
 
+// Haptic motor driver (optional)
+#define PIN_HAPTIC      4   // GPIO4 - Vibration motor driver (PWM)
+
 // Battery voltage monitoring (optional)
 #define PIN_VBAT        2   // GPIO2 - ADC for battery voltage
 #define PIN_VCHARGE     3   // GPIO3 - Charging detection (5V from pogo pins)
 
+// ─── BLE Throughput ──────────────────────────────────────────────────────────
+// Larger MTU lets several samples share one notification (v3 packets).
+// SAMPLES_PER_NOTIFY 1 sends one sample per notification (plain 20-byte v1
+// packets with PACKET_CRC 0, below); raise it (and lower
+// SAMPLE_RATE_MS to 5 for 200Hz) once the server negotiates a larger MTU.
+#define BLE_MTU                 247
+#define SAMPLES_PER_NOTIFY      1
+
+// PACKET_CRC 1 sends v2/v3 packets ending in a CRC16 so the server can drop
+// samples corrupted on the radio link (costs 2 bytes per notification, and
+// single samples need the negotiated MTU as they no longer fit 20 bytes).
+// Once a glove has sent a checksummed packet the server drops any without
+// one, so leave it on unless the server predates checksums.
+#define PACKET_CRC              1
+
+// SERIAL_STREAM 1 also writes every packet to the USB serial port, SLIP
+// framed behind the hand byte, for wired bench testing (server
+// SERIAL_PORTS). Samples stream even without a BLE connection.
+#define SERIAL_STREAM           0
+
 // ─── Timing Constants ────────────────────────────────────────────────────────
 #define SAMPLE_RATE_MS          10      // 10ms = 100Hz sensor sampling
 #define BLE_NOTIFY_INTERVAL_MS  10      // Send BLE notification every 10ms
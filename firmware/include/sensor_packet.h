@@ -9,6 +9,7 @@
 #ifndef SENSOR_PACKET_H
 #define SENSOR_PACKET_H
 
+#include <stddef.h>
 #include <stdint.h>
 
 /**
@@ -50,4 +51,68 @@ struct __attribute__((packed)) SensorPacket {
 // Compile-time size check
 static_assert(sizeof(SensorPacket) == 20, "SensorPacket must be exactly 20 bytes");
 
+/**
+ * v2 packet (22-36 bytes, requires a negotiated ATT MTU above 23)
+ *
+ * Field      | Offset | Size | Type     | Notes
+ * -----------|--------|------|----------|-------------------------------
+ * version    | 0      | 1    | uint8    | PACKET_VERSION_2
+ * content    | 1      | 1    | uint8    | which optional fields follow
+ * body       | 2      | 20   | packet   | SensorPacket as above
+ * quat       | 22     | 8    | int16[4] | w, x, y, z ÷10000 (bit 0)
+ * linAcc     | +0     | 6    | int16[3] | m/s² * 100, sensor frame (bit 1)
+ * crc        | +0     | 2    | uint16   | crc16() of all prior bytes, header included (bit 2)
+ *
+ * Optional fields appear in content-bit order and are omitted when their
+ * bit is clear. v1 packets stay exactly 20 bytes with no version byte.
+ */
+#define PACKET_VERSION_2        2
+#define CONTENT_QUATERNION      (1 << 0)
+#define CONTENT_LINEAR_ACCEL    (1 << 1)
+#define CONTENT_CRC             (1 << 2)
+
+struct __attribute__((packed)) SensorPacketV2Header {
+    uint8_t version;     // PACKET_VERSION_2
+    uint8_t content;     // CONTENT_* bits
+};
+
+/**
+ * v3 batched packet (several samples per notification)
+ *
+ * Field      | Offset | Size | Type     | Notes
+ * -----------|--------|------|----------|-------------------------------
+ * version    | 0      | 1    | uint8    | PACKET_VERSION_3
+ * content    | 1      | 1    | uint8    | optional fields of every sample
+ * count      | 2      | 1    | uint8    | number of samples that follow
+ * samples    | 3      | n    | sample[] | each a SensorPacket followed by
+ *            |        |      |          | the optional v2 fields in content
+ *            |        |      |          | order
+ * crc        | +0     | 2    | uint16   | crc16() of all prior bytes, header included (bit 2)
+ *
+ * With a 247-byte ATT MTU a notification holds up to 12 bare samples, so
+ * 200Hz sampling needs only 50 notifications per second.
+ */
+#define PACKET_VERSION_3        3
+
+struct __attribute__((packed)) SensorPacketV3Header {
+    uint8_t version;     // PACKET_VERSION_3
+    uint8_t content;     // CONTENT_* bits
+    uint8_t count;       // samples in this notification
+};
+
+/**
+ * CRC-16/CCITT-FALSE (poly 0x1021, init 0xFFFF), sent little-endian.
+ * Must match ble.CRC16 on the server.
+ */
+static inline uint16_t crc16(const uint8_t* data, size_t len) {
+    uint16_t crc = 0xFFFF;
+    for (size_t i = 0; i < len; i++) {
+        crc ^= (uint16_t)data[i] << 8;
+        for (int b = 0; b < 8; b++) {
+            crc = (crc & 0x8000) ? (crc << 1) ^ 0x1021 : crc << 1;
+        }
+    }
+    return crc;
+}
+
 #endif // SENSOR_PACKET_H
@@ -19,6 +19,10 @@
 #include <BLEServer.h>
 #include <BLEUtils.h>
 #include <BLE2902.h>
+#if BLE_BONDING
+#include <BLESecurity.h>
+#include <esp_gap_ble_api.h>
+#endif
 #include <MPU6050_light.h>
 
 #include "config.h"
@@ -31,6 +35,7 @@ BLEServer* g_pServer = nullptr;
 BLECharacteristic* g_pSensorChar = nullptr;
 BLECharacteristic* g_pBatteryChar = nullptr;
 BLECharacteristic* g_pDeviceChar = nullptr;
+BLECharacteristic* g_pCommandChar = nullptr;
 
 // ─── Global State ────────────────────────────────────────────────────────────
 volatile bool g_deviceConnected = false;
@@ -43,9 +48,75 @@ uint32_t g_lastLedToggle = 0;
 bool g_ledState = false;
 bool g_isCalibrated = false;
 
+// Feedback commands (0 = inactive)
+uint32_t g_buzzUntil = 0;
+uint32_t g_ledOverrideUntil = 0;
+
+// ─── BLE Security ────────────────────────────────────────────────────────────
+#if BLE_BONDING
+// bondCount returns the number of bonded centrals.
+int bondCount() {
+    return esp_ble_get_bond_device_num();
+}
+
+// isBonded checks whether a central's address is in the bond list.
+bool isBonded(const esp_bd_addr_t addr) {
+    int count = bondCount();
+    if (count <= 0) {
+        return false;
+    }
+    esp_ble_bond_dev_t* list = (esp_ble_bond_dev_t*)malloc(sizeof(esp_ble_bond_dev_t) * count);
+    if (list == nullptr) {
+        return false;
+    }
+    esp_ble_get_bond_device_list(&count, list);
+    bool found = false;
+    for (int i = 0; i < count && !found; i++) {
+        found = memcmp(list[i].bd_addr, addr, sizeof(esp_bd_addr_t)) == 0;
+    }
+    free(list);
+    return found;
+}
+
+// clearBonds forgets every bonded central.
+void clearBonds() {
+    int count = bondCount();
+    if (count <= 0) {
+        return;
+    }
+    esp_ble_bond_dev_t* list = (esp_ble_bond_dev_t*)malloc(sizeof(esp_ble_bond_dev_t) * count);
+    if (list == nullptr) {
+        return;
+    }
+    esp_ble_get_bond_device_list(&count, list);
+    for (int i = 0; i < count; i++) {
+        esp_ble_remove_bond_device(list[i].bd_addr);
+    }
+    free(list);
+    Serial.println("BLE: Bonds cleared");
+}
+
+// secure requires an encrypted, authenticated link to access a characteristic.
+void secure(BLECharacteristic* pChar) {
+    pChar->setAccessPermissions(ESP_GATT_PERM_READ_ENC_MITM | ESP_GATT_PERM_WRITE_ENC_MITM);
+    BLEDescriptor* pCCCD = pChar->getDescriptorByUUID(BLEUUID((uint16_t)0x2902));
+    if (pCCCD != nullptr) {
+        pCCCD->setAccessPermissions(ESP_GATT_PERM_READ_ENC_MITM | ESP_GATT_PERM_WRITE_ENC_MITM);
+    }
+}
+#endif
+
 // ─── BLE Callbacks ───────────────────────────────────────────────────────────
 class ServerCallbacks : public BLEServerCallbacks {
-    void onConnect(BLEServer* pServer) override {
+    void onConnect(BLEServer* pServer, esp_ble_gatts_cb_param_t* param) override {
+#if BLE_BONDING
+        // Once bonded, only the registered server may connect
+        if (bondCount() > 0 && !isBonded(param->connect.remote_bda)) {
+            Serial.println("BLE: Rejected connection from unbonded central");
+            pServer->disconnect(param->connect.conn_id);
+            return;
+        }
+#endif
         g_deviceConnected = true;
         Serial.println("BLE: Client connected");
     }
@@ -56,6 +127,48 @@ class ServerCallbacks : public BLEServerCallbacks {
     }
 };
 
+// ─── Feedback Commands ───────────────────────────────────────────────────────
+void setLed(bool on);
+
+class CommandCallbacks : public BLECharacteristicCallbacks {
+    void onWrite(BLECharacteristic* pChar) override {
+        std::string value = pChar->getValue();
+        const uint8_t* data = (const uint8_t*)value.data();
+        uint32_t now = millis();
+
+        if (value.length() >= 4 && data[0] == CMD_BUZZ) {
+            uint16_t durationMs = data[1] | (data[2] << 8);
+            analogWrite(PIN_HAPTIC, data[3]);
+            g_buzzUntil = (now + durationMs) | 1;  // never 0 while active
+            Serial.printf("Command: buzz %dms at %d\n", durationMs, data[3]);
+        } else if (value.length() >= 6 && data[0] == CMD_LED) {
+            // The onboard LED is single-colour: any non-zero channel lights it
+            uint16_t durationMs = data[4] | (data[5] << 8);
+            setLed(data[1] || data[2] || data[3]);
+            g_ledOverrideUntil = (now + durationMs) | 1;
+            Serial.printf("Command: LED #%02x%02x%02x %dms\n", data[1], data[2], data[3], durationMs);
+#if BLE_BONDING
+        } else if (value.length() >= 1 && data[0] == CMD_CLEAR_BONDS) {
+            clearBonds();
+#endif
+        } else {
+            Serial.printf("Command: ignored %d-byte command\n", value.length());
+        }
+    }
+};
+
+// updateFeedback ends buzzes and LED overrides once their duration is up.
+void updateFeedback(uint32_t now) {
+    if (g_buzzUntil != 0 && (int32_t)(now - g_buzzUntil) >= 0) {
+        analogWrite(PIN_HAPTIC, 0);
+        g_buzzUntil = 0;
+    }
+    if (g_ledOverrideUntil != 0 && (int32_t)(now - g_ledOverrideUntil) >= 0) {
+        g_ledOverrideUntil = 0;
+        setLed(g_deviceConnected);  // Back to the connection indicator
+    }
+}
+
 // ─── LED Control ─────────────────────────────────────────────────────────────
 void setLed(bool on) {
     // XIAO ESP32C3 onboard LED is active LOW
@@ -112,6 +225,15 @@ void setupBLE() {
     
     // Initialize BLE with device name
     BLEDevice::init(BLE_DEVICE_NAME);
+    BLEDevice::setMTU(BLE_MTU);  // Accept a large MTU for batched packets
+
+#if BLE_BONDING
+    // Passkey pairing with bonding: the server enters BLE_PASSKEY
+    BLEDevice::setEncryptionLevel(ESP_BLE_SEC_ENCRYPT_MITM);
+    BLESecurity* pSecurity = new BLESecurity();
+    pSecurity->setStaticPIN(BLE_PASSKEY);
+    Serial.printf("BLE: Bonding required (%d bonded server)\n", bondCount());
+#endif
     
     // Create BLE Server
     g_pServer = BLEDevice::createServer();
@@ -136,13 +258,33 @@ void setupBLE() {
     uint8_t initBattery = readBatteryLevel();
     g_pBatteryChar->setValue(&initBattery, 1);
     
-    // Create Device Info Characteristic (READ only - returns hand ID)
+    // Create Device Info Characteristic (READ only - hand ID and versions)
     g_pDeviceChar = pService->createCharacteristic(
         BLE_CHAR_DEVICE_UUID,
         BLECharacteristic::PROPERTY_READ
     );
-    uint8_t handId = HAND_ID;
-    g_pDeviceChar->setValue(&handId, 1);
+    uint8_t deviceInfo[5] = {
+        HAND_ID,
+        FIRMWARE_VERSION_MAJOR, FIRMWARE_VERSION_MINOR, FIRMWARE_VERSION_PATCH,
+        HARDWARE_REV
+    };
+    g_pDeviceChar->setValue(deviceInfo, sizeof(deviceInfo));
+
+    // Create Command Characteristic (WRITE - haptic and LED feedback).
+    // Also takes writes without response, the only kind the server's macOS
+    // backend can send.
+    g_pCommandChar = pService->createCharacteristic(
+        BLE_CHAR_COMMAND_UUID,
+        BLECharacteristic::PROPERTY_WRITE | BLECharacteristic::PROPERTY_WRITE_NR
+    );
+    g_pCommandChar->setCallbacks(new CommandCallbacks());
+
+#if BLE_BONDING
+    secure(g_pSensorChar);
+    secure(g_pBatteryChar);
+    secure(g_pDeviceChar);
+    secure(g_pCommandChar);
+#endif
     
     // Start the service
     pService->start();
@@ -189,6 +331,40 @@ bool setupMPU() {
     return true;
 }
 
+// ─── Serial Stream ───────────────────────────────────────────────────────────
+#if SERIAL_STREAM
+// Write a packet as one SLIP frame (RFC 1055) led by the hand byte. END
+// bytes on both sides keep log lines out of the frame.
+void writeSerialFrame(const uint8_t* data, size_t len) {
+    const uint8_t SLIP_END = 0xC0, SLIP_ESC = 0xDB;
+    Serial.write(SLIP_END);
+    Serial.write((uint8_t)HAND_ID);
+    for (size_t i = 0; i < len; i++) {
+        if (data[i] == SLIP_END) {
+            Serial.write(SLIP_ESC);
+            Serial.write(0xDC);
+        } else if (data[i] == SLIP_ESC) {
+            Serial.write(SLIP_ESC);
+            Serial.write(0xDD);
+        } else {
+            Serial.write(data[i]);
+        }
+    }
+    Serial.write(SLIP_END);
+}
+#endif
+
+// Send a packet to the server over BLE and, with SERIAL_STREAM, over USB
+void publishPacket(uint8_t* data, size_t len) {
+    if (g_deviceConnected) {
+        g_pSensorChar->setValue(data, len);
+        g_pSensorChar->notify();
+    }
+#if SERIAL_STREAM
+    writeSerialFrame(data, len);
+#endif
+}
+
 // ─── Send Sensor Data ────────────────────────────────────────────────────────
 void sendSensorData() {
     // Update MPU6050 readings
@@ -224,9 +400,43 @@ void sendSensorData() {
         packet.flags |= FLAG_CALIBRATED;
     }
     
-    // Send via BLE notification
-    g_pSensorChar->setValue((uint8_t*)&packet, sizeof(SensorPacket));
-    g_pSensorChar->notify();
+#if SAMPLES_PER_NOTIFY > 1
+    // Batch samples into one v3 notification
+    static uint8_t batch[sizeof(SensorPacketV3Header) + SAMPLES_PER_NOTIFY * sizeof(SensorPacket) + 2];
+    static uint8_t batchCount = 0;
+    memcpy(batch + sizeof(SensorPacketV3Header) + batchCount * sizeof(SensorPacket),
+           &packet, sizeof(SensorPacket));
+    if (++batchCount < SAMPLES_PER_NOTIFY) {
+        return;
+    }
+    SensorPacketV3Header* header = (SensorPacketV3Header*)batch;
+    header->version = PACKET_VERSION_3;
+    header->content = PACKET_CRC ? CONTENT_CRC : 0;
+    header->count = batchCount;
+    size_t len = sizeof(SensorPacketV3Header) + batchCount * sizeof(SensorPacket);
+#if PACKET_CRC
+    uint16_t crc = crc16(batch, len);
+    batch[len++] = crc & 0xFF;
+    batch[len++] = crc >> 8;
+#endif
+    publishPacket(batch, len);
+    batchCount = 0;
+#elif PACKET_CRC
+    // Single sample as a v2 packet with a CRC trailer
+    uint8_t buf[sizeof(SensorPacketV2Header) + sizeof(SensorPacket) + 2];
+    SensorPacketV2Header* header = (SensorPacketV2Header*)buf;
+    header->version = PACKET_VERSION_2;
+    header->content = CONTENT_CRC;
+    memcpy(buf + sizeof(SensorPacketV2Header), &packet, sizeof(SensorPacket));
+    size_t len = sizeof(SensorPacketV2Header) + sizeof(SensorPacket);
+    uint16_t crc = crc16(buf, len);
+    buf[len++] = crc & 0xFF;
+    buf[len++] = crc >> 8;
+    publishPacket(buf, len);
+#else
+    // Single sample as a plain v1 packet
+    publishPacket((uint8_t*)&packet, sizeof(SensorPacket));
+#endif
 }
 
 // ─── Update Battery Characteristic ───────────────────────────────────────────
@@ -252,6 +462,10 @@ void setup() {
     pinMode(PIN_LED, OUTPUT);
     setLed(false);
     
+    // Initialize haptic motor (off)
+    pinMode(PIN_HAPTIC, OUTPUT);
+    analogWrite(PIN_HAPTIC, 0);
+
     // Initialize battery monitoring pins
     pinMode(PIN_VBAT, INPUT);
     pinMode(PIN_VCHARGE, INPUT);
@@ -316,18 +530,24 @@ void loop() {
         g_oldDeviceConnected = false;
     }
     
-    // When connected: stream sensor data at 100Hz
-    if (g_deviceConnected) {
+    // End finished buzzes and LED overrides (also after a disconnect)
+    updateFeedback(now);
+    
+    // When connected (or wired with SERIAL_STREAM): stream sensor data at 100Hz
+    if (g_deviceConnected || SERIAL_STREAM) {
         if (now - g_lastSampleTime >= SAMPLE_RATE_MS) {
             sendSensorData();
             g_lastSampleTime = now;
         }
-        
+    }
+
+    if (g_deviceConnected) {
         // Update battery level periodically
         if (now - g_lastBatteryTime >= BATTERY_UPDATE_MS) {
             updateBattery();
             g_lastBatteryTime = now;
         }
+
     } else {
         // When not connected: fast blink to show advertising
         blinkLed(LED_BLINK_FAST_MS);
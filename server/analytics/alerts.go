@@ -0,0 +1,289 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"boxing-analytics/validation"
+)
+
+// Alert rule actions
+const (
+	AlertActionWS      = "ws"      // broadcast an alert event to WebSocket clients
+	AlertActionWebhook = "webhook" // POST the alert to WebhookURL
+	AlertActionBuzz    = "buzz"    // buzz the glove of the rule's hand, both for combined rules
+)
+
+// ErrAlertNotFound is returned when an alert rule ID does not exist.
+var ErrAlertNotFound = errors.New("alert rule not found")
+
+// AlertRule fires an action when a metric stays past a value for a duration.
+type AlertRule struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name,omitempty"`
+	Metric      string  `json:"metric"`     // e.g. "ppm", "avg_force", "battery"
+	Hand        string  `json:"hand"`       // "left", "right", or "combined"
+	Comparator  string  `json:"comparator"` // "<", "<=", ">", ">="
+	Value       float64 `json:"value"`
+	DurationSec float64 `json:"duration_sec"`    // how long the condition must hold
+	Phase       string  `json:"phase,omitempty"` // only in this program phase: "round" (work) or "rest"; "" = always
+	Action      string  `json:"action"`          // "ws", "webhook", or "buzz"
+	WebhookURL  string  `json:"webhook_url,omitempty"`
+}
+
+// AlertEvent is the payload of an "alert" event.
+type AlertEvent struct {
+	Rule  AlertRule `json:"rule"`
+	Value float64   `json:"value"` // metric value when the rule fired
+}
+
+// alertTracker holds the evaluation state of one rule.
+type alertTracker struct {
+	rule  AlertRule
+	since time.Time // when the condition started holding (zero = not holding)
+	fired bool      // fired since the condition last started holding
+}
+
+// Validate checks that the rule references a known metric, hand, comparator,
+// phase and action, returning validation.Errors listing every invalid field.
+func (r *AlertRule) Validate() error {
+	var errs validation.Errors
+	_, perHand := handMetrics[r.Metric]
+	_, combined := combinedMetrics[r.Metric]
+	if !perHand && !combined {
+		errs.Add("metric", fmt.Errorf("unknown metric %q", r.Metric))
+	}
+	switch r.Hand {
+	case "left", "right":
+		if combined && !perHand {
+			errs.Add("metric", fmt.Errorf("metric %q is not available per hand", r.Metric))
+		}
+	case "combined":
+		if perHand && !combined {
+			errs.Add("metric", fmt.Errorf("metric %q is not available combined", r.Metric))
+		}
+	default:
+		errs.Add("hand", fmt.Errorf("invalid hand %q: must be 'left', 'right', or 'combined'", r.Hand))
+	}
+	switch r.Comparator {
+	case "<", "<=", ">", ">=":
+	default:
+		errs.Add("comparator", fmt.Errorf("invalid comparator %q", r.Comparator))
+	}
+	if r.DurationSec < 0 {
+		errs.Add("duration_sec", fmt.Errorf("duration_sec must not be negative"))
+	}
+	switch r.Phase {
+	case "", PhaseRound, PhaseRest:
+	default:
+		errs.Add("phase", fmt.Errorf("invalid phase %q: must be '%s' or '%s'", r.Phase, PhaseRound, PhaseRest))
+	}
+	switch r.Action {
+	case AlertActionWS, AlertActionBuzz:
+	case AlertActionWebhook:
+		if r.WebhookURL == "" {
+			errs.Add("webhook_url", fmt.Errorf("webhook action requires webhook_url"))
+			break
+		}
+		u, err := url.Parse(r.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.Add("webhook_url", fmt.Errorf("invalid webhook_url %q: must be an http or https URL", r.WebhookURL))
+		}
+	default:
+		errs.Add("action", fmt.Errorf("invalid action %q", r.Action))
+	}
+	return errs.Err()
+}
+
+// handMetrics maps per-hand metric names to their value in a HandState.
+var handMetrics = map[string]func(h *HandState) float64{
+	"ppm":             func(h *HandState) float64 { return h.PunchesPerMin },
+	"ppm_10s":         func(h *HandState) float64 { return h.RollingPPM.PPM10s },
+	"ppm_30s":         func(h *HandState) float64 { return h.RollingPPM.PPM30s },
+	"ppm_60s":         func(h *HandState) float64 { return h.RollingPPM.PPM60s },
+	"avg_force":       func(h *HandState) float64 { return h.AvgForce },
+	"max_force":       func(h *HandState) float64 { return h.MaxForce },
+	"punch_count":     func(h *HandState) float64 { return float64(h.PunchCount) },
+	"battery":         func(h *HandState) float64 { return float64(h.Battery) },
+	"packet_loss":     func(h *HandState) float64 { return h.PacketLoss },
+	"corrupt_packets": func(h *HandState) float64 { return float64(h.CorruptPackets) },
+}
+
+// combinedMetrics maps combined metric names to their value in CombinedStats.
+var combinedMetrics = map[string]func(c *CombinedStats) float64{
+	"ppm":             func(c *CombinedStats) float64 { return c.PunchesPerMin },
+	"pps":             func(c *CombinedStats) float64 { return c.PunchesPerSec },
+	"ppm_10s":         func(c *CombinedStats) float64 { return c.RollingPPM.PPM10s },
+	"ppm_30s":         func(c *CombinedStats) float64 { return c.RollingPPM.PPM30s },
+	"ppm_60s":         func(c *CombinedStats) float64 { return c.RollingPPM.PPM60s },
+	"avg_force":       func(c *CombinedStats) float64 { return c.AvgForce },
+	"max_force":       func(c *CombinedStats) float64 { return c.MaxForce },
+	"punch_count":     func(c *CombinedStats) float64 { return float64(c.TotalPunches) },
+	"intensity_score": func(c *CombinedStats) float64 { return float64(c.IntensityScore) },
+}
+
+// metricValue reads a rule's metric from a state snapshot.
+func metricValue(state *SessionState, rule *AlertRule) float64 {
+	switch rule.Hand {
+	case "left":
+		return handMetrics[rule.Metric](state.Left)
+	case "right":
+		return handMetrics[rule.Metric](state.Right)
+	default:
+		return combinedMetrics[rule.Metric](&state.Combined)
+	}
+}
+
+// compare applies a rule comparator.
+func compare(value float64, comparator string, threshold float64) bool {
+	switch comparator {
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	}
+	return false
+}
+
+// ─── Rule Management ─────────────────────────────────────────────────────────
+
+// AddAlertRule validates and registers a rule, assigning it an ID.
+func (a *Analyzer) AddAlertRule(rule AlertRule) (AlertRule, error) {
+	if err := rule.Validate(); err != nil {
+		return AlertRule{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.nextAlertID++
+	rule.ID = a.nextAlertID
+	a.alerts = append(a.alerts, &alertTracker{rule: rule})
+	return rule, nil
+}
+
+// UpdateAlertRule replaces an existing rule, resetting its evaluation state.
+func (a *Analyzer) UpdateAlertRule(id int, rule AlertRule) (AlertRule, error) {
+	if err := rule.Validate(); err != nil {
+		return AlertRule{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, t := range a.alerts {
+		if t.rule.ID == id {
+			rule.ID = id
+			*t = alertTracker{rule: rule}
+			return rule, nil
+		}
+	}
+	return AlertRule{}, ErrAlertNotFound
+}
+
+// DeleteAlertRule removes a rule.
+func (a *Analyzer) DeleteAlertRule(id int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, t := range a.alerts {
+		if t.rule.ID == id {
+			a.alerts = append(a.alerts[:i], a.alerts[i+1:]...)
+			return nil
+		}
+	}
+	return ErrAlertNotFound
+}
+
+// GetAlertRule returns a single rule by ID.
+func (a *Analyzer) GetAlertRule(id int) (AlertRule, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, t := range a.alerts {
+		if t.rule.ID == id {
+			return t.rule, nil
+		}
+	}
+	return AlertRule{}, ErrAlertNotFound
+}
+
+// AlertRules returns all registered rules.
+func (a *Analyzer) AlertRules() []AlertRule {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	rules := make([]AlertRule, 0, len(a.alerts))
+	for _, t := range a.alerts {
+		rules = append(rules, t.rule)
+	}
+	return rules
+}
+
+// LoadAlertRules replaces all rules, keeping their IDs (e.g. when restoring
+// rules saved on disk). Invalid rules are skipped.
+func (a *Analyzer) LoadAlertRules(rules []AlertRule) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.alerts = nil
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			continue
+		}
+		a.alerts = append(a.alerts, &alertTracker{rule: rule})
+		if rule.ID > a.nextAlertID {
+			a.nextAlertID = rule.ID
+		}
+	}
+}
+
+// evaluateAlertsLocked checks every rule against the current state and emits
+// an "alert" event for rules whose condition has held for their duration.
+// Rules are only evaluated while a session is running, and rules with a
+// phase only while the session's program is in that phase.
+// Must be called with a.mu held.
+func (a *Analyzer) evaluateAlertsLocked(now time.Time) {
+	if len(a.alerts) == 0 {
+		return
+	}
+
+	running := a.active && !a.paused
+	var state *SessionState
+	if running {
+		state = a.buildStateLocked()
+	}
+	phase := ""
+	if p := a.program; p != nil && p.running {
+		phase = p.phase
+	}
+
+	for _, t := range a.alerts {
+		if !running || (t.rule.Phase != "" && t.rule.Phase != phase) {
+			t.since = time.Time{}
+			t.fired = false
+			continue
+		}
+
+		value := metricValue(state, &t.rule)
+		if !compare(value, t.rule.Comparator, t.rule.Value) {
+			t.since = time.Time{}
+			t.fired = false
+			continue
+		}
+
+		if t.since.IsZero() {
+			t.since = now
+		}
+		if !t.fired && now.Sub(t.since).Seconds() >= t.rule.DurationSec {
+			t.fired = true
+			a.emitLocked("alert", t.rule.Hand, AlertEvent{Rule: t.rule, Value: value})
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlertRuleValidate(t *testing.T) {
+	valid := AlertRule{Metric: "ppm", Hand: "right", Comparator: "<", Value: 20, DurationSec: 30, Action: AlertActionWS}
+	tests := []struct {
+		name   string
+		change func(r *AlertRule)
+		err    string // substring of the error, "" = valid
+	}{
+		{name: "valid", change: func(r *AlertRule) {}},
+		{name: "combined buzz", change: func(r *AlertRule) { r.Hand, r.Action = "combined", AlertActionBuzz }},
+		{name: "work phase", change: func(r *AlertRule) { r.Phase = PhaseRound }},
+		{name: "unknown phase", change: func(r *AlertRule) { r.Phase = "work" }, err: "phase"},
+		{name: "unknown metric", change: func(r *AlertRule) { r.Metric = "speed" }, err: "metric"},
+		{name: "combined only metric", change: func(r *AlertRule) { r.Metric = "pps" }, err: "metric"},
+		{name: "unknown hand", change: func(r *AlertRule) { r.Hand = "both" }, err: "hand"},
+		{name: "unknown comparator", change: func(r *AlertRule) { r.Comparator = "==" }, err: "comparator"},
+		{name: "negative duration", change: func(r *AlertRule) { r.DurationSec = -1 }, err: "duration_sec"},
+		{name: "webhook", change: func(r *AlertRule) { r.Action, r.WebhookURL = AlertActionWebhook, "https://example.com/hook" }},
+		{name: "webhook without url", change: func(r *AlertRule) { r.Action = AlertActionWebhook }, err: "webhook_url"},
+		{name: "webhook not http", change: func(r *AlertRule) { r.Action, r.WebhookURL = AlertActionWebhook, "file:///etc/passwd" }, err: "webhook_url"},
+		{name: "webhook without host", change: func(r *AlertRule) { r.Action, r.WebhookURL = AlertActionWebhook, "http://" }, err: "webhook_url"},
+		{name: "unknown action", change: func(r *AlertRule) { r.Action = "email" }, err: "action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid
+			tt.change(&rule)
+			err := rule.Validate()
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("Validate() = %v, want an error about %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestAlertRulePhase(t *testing.T) {
+	a := NewAnalyzer()
+	a.StartSession()
+	program := Program{ID: "p", Rounds: []ProgramRound{{Drill: "jab", DurationSec: 60, RestSec: 30}, {Drill: "cross", DurationSec: 60}}}
+	if err := a.StartProgram(program); err != nil {
+		t.Fatal(err)
+	}
+	// No punches: ppm stays below any positive value
+	for _, phase := range []string{"", PhaseRound, PhaseRest} {
+		if _, err := a.AddAlertRule(AlertRule{Metric: "ppm", Hand: "combined", Comparator: "<", Value: 20, Action: AlertActionWS, Phase: phase}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fired := func(now time.Time) map[string]bool {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.evaluateAlertsLocked(now)
+		out := make(map[string]bool)
+		for _, tr := range a.alerts {
+			out[tr.rule.Phase] = tr.fired
+		}
+		return out
+	}
+
+	now := time.Now()
+	if got := fired(now); !got[""] || !got[PhaseRound] || got[PhaseRest] {
+		t.Errorf("during the round fired = %v, want the always and round rules", got)
+	}
+
+	a.mu.Lock()
+	a.advanceProgramLocked(a.program.lastTick.Add(61 * time.Second))
+	phase := a.program.phase
+	a.mu.Unlock()
+	if phase != PhaseRest {
+		t.Fatalf("program phase %q after the first round, want %q", phase, PhaseRest)
+	}
+	if got := fired(now.Add(61 * time.Second)); !got[""] || got[PhaseRound] || !got[PhaseRest] {
+		t.Errorf("during the rest fired = %v, want the always and rest rules", got)
+	}
+}
@@ -3,16 +3,18 @@ package analytics
 
 import (
 	"math"
+	"sort"
 	"sync"
 	"time"
 
 	"boxing-analytics/ble"
+	"boxing-analytics/ingest"
 )
 
 // ─── Constants ───────────────────────────────────────────────────────────────
 
 const (
-	// Punch detection thresholds
+	// Punch detection thresholds (heavy bag, see DetectionProfile)
 	punchThreshold = 25.0 // m/s² - acceleration above gravity for punch detection
 	debounceMS     = 300  // milliseconds between valid punches
 
@@ -22,8 +24,9 @@ const (
 	straightGyroMax    = 150.0 // °/s - max rotation for straight punch
 
 	// Stats tracking
-	maxRecentPunches = 50  // punches kept in history
-	rollingBufSize   = 500 // 5 seconds at 100Hz
+	maxRecentPunches  = 50    // punches kept in history
+	maxSessionPunches = 50000 // punches of a session kept for Punches, hours of nonstop work
+	rollingBufSize    = 500   // 5 seconds at 100Hz
 
 	// Calibration constants
 	calibrationDuration   = 3.0 // seconds of stillness required
@@ -32,6 +35,12 @@ const (
 	stillnessAccelThresh  = 0.5 // m/s² - max acceleration variance to be "still"
 	stillnessGyroThresh   = 5.0 // °/s - max gyro variance to be "still"
 	calibrationBufferSize = 50  // samples for variance calculation
+
+	// Adaptive threshold constants
+	autoThresholdPunches = 10   // punches observed before the threshold is fixed
+	autoThresholdFloor   = 12.0 // m/s² - detection threshold while observing
+	autoThresholdCeil    = 60.0 // m/s² - upper bound for a learned threshold
+	autoThresholdRatio   = 0.6  // learned threshold as a fraction of the median punch
 )
 
 // ─── Types ───────────────────────────────────────────────────────────────────
@@ -41,19 +50,43 @@ type PunchType string
 
 const (
 	PunchStraight PunchType = "straight"
+	PunchJab      PunchType = "jab"   // lead-hand straight
+	PunchCross    PunchType = "cross" // rear-hand straight
 	PunchHook     PunchType = "hook"
 	PunchUppercut PunchType = "uppercut"
 	PunchUnknown  PunchType = "unknown"
 )
 
+// Athlete stances
+const (
+	StanceOrthodox = "orthodox" // left hand leads
+	StanceSouthpaw = "southpaw" // right hand leads
+)
+
+// Trajectory is the approach of a punch in the world frame, from the velocity
+// gained between onset and peak. Heading is unknown without a compass, so
+// only the horizontal and vertical split is reported.
+type Trajectory struct {
+	Horizontal float64 `json:"horizontal"` // m/s
+	Vertical   float64 `json:"vertical"`   // m/s, positive upward
+	Elevation  float64 `json:"elevation"`  // degrees above horizontal (-90 to 90)
+}
+
 // PunchEvent represents a detected punch.
 type PunchEvent struct {
-	Hand      string    `json:"hand"`
-	Type      PunchType `json:"type"`
-	Force     float64   `json:"force"`      // m/s²
-	RotationZ float64   `json:"rotation_z"` // peak °/s
-	Timestamp int64     `json:"ts"`         // device timestamp
-	Count     int       `json:"count"`      // punch number in session
+	ID         int        `json:"id"` // unique in the session, increasing from 1 in the order punches land
+	Hand       string     `json:"hand"`
+	Type       PunchType  `json:"type"`
+	Force      float64    `json:"force"`           // m/s²
+	RotationZ  float64    `json:"rotation_z"`      // peak °/s
+	RFD        float64    `json:"rfd"`             // rate of force development, m/s³
+	DurationMS int64      `json:"duration_ms"`     // acceleration phase, onset to peak
+	Retraction float64    `json:"retraction"`      // peak return speed after impact, m/s
+	Power      float64    `json:"power,omitempty"` // estimated force on impact, N (needs the athlete's weight)
+	Trajectory Trajectory `json:"trajectory"`      // approach direction of the swing
+	Contact    bool       `json:"contact"`         // hit the bag rather than the air
+	Timestamp  int64      `json:"ts"`              // device timestamp
+	Count      int        `json:"count"`           // punch number in session
 }
 
 // HandState holds analytics for one hand.
@@ -61,12 +94,22 @@ type HandState struct {
 	Connected      bool           `json:"connected"`
 	Calibrated     bool           `json:"calibrated"`
 	Battery        uint8          `json:"battery"`
+	Charging       bool           `json:"charging"`
 	PacketLoss     float64        `json:"packet_loss"`
+	CorruptPackets int            `json:"corrupt_packets"` // packets dropped for a bad checksum or size
 	PunchCount     int            `json:"punch_count"`
+	Suppressed     int            `json:"suppressed"`      // detections dropped as shock from the other hand
+	Blocks         int            `json:"blocks"`          // detections that were blocks, in defense drills
+	ContactCount   int            `json:"contact_punches"` // punches that hit the bag
+	AirCount       int            `json:"air_punches"`     // shadowboxing punches
 	PunchBreakdown map[string]int `json:"punch_breakdown"`
 	MaxForce       float64        `json:"max_force"`
 	AvgForce       float64        `json:"avg_force"`
+	MaxPower       float64        `json:"max_power,omitempty"` // estimated, N
+	AvgPower       float64        `json:"avg_power,omitempty"`
+	ForceHistogram []ForceBucket  `json:"force_histogram"` // punches per force range
 	PunchesPerMin  float64        `json:"ppm"`
+	RollingPPM     RollingRate    `json:"rolling_ppm"` // current effort over sliding windows
 	RecentPunches  []PunchEvent   `json:"recent_punches"`
 	// Current sensor values (for logging/debugging)
 	CurrentAccel [3]float64 `json:"current_accel"` // X, Y, Z in m/s²
@@ -77,14 +120,58 @@ type HandState struct {
 	GravityRef          [3]float64 `json:"gravity_ref"`          // Gravity vector in sensor frame
 	GloveOrientation    string     `json:"glove_orientation"`    // "palm_down", "palm_up", etc.
 	UpAxis              int        `json:"up_axis"`              // 0=X, 1=Y, 2=Z - which axis points up
+	Orientation         Quaternion `json:"orientation"`          // sensor → world rotation from fusion
+
+	// Detection threshold state
+	Threshold     float64 `json:"threshold"`      // m/s² - active punch detection threshold
+	AutoThreshold bool    `json:"auto_threshold"` // true while the threshold is being learned
+
+	// Output fall-off over the session
+	Fatigue FatigueStats `json:"fatigue"`
+
+	// Time between consecutive punches of this hand
+	Rhythm RhythmStats `json:"rhythm"`
+
+	// Stance and guard
+	Lead         bool    `json:"lead"`           // this hand leads in the inferred or configured stance
+	GuardDropped bool    `json:"guard_dropped"`  // glove held away from the guard position
+	GuardDrops   int     `json:"guard_drops"`    // guard drops this session
+	GuardDownSec float64 `json:"guard_down_sec"` // total time with the guard down
+
+	// Frequency content of recent motion (optional FFT stage)
+	Spectrum SpectrumStats `json:"spectrum"`
+
+	// Explosiveness: rate of force development (m/s³)
+	AvgRFD    float64            `json:"avg_rfd"`
+	RFDByType map[string]float64 `json:"rfd_by_type"`
 
 	// Internal state
 	forceSum          float64      // sum of all punch forces
 	lastPunchTS       int64        // last punch timestamp (device)
 	lastPunchTime     time.Time    // last punch time (local)
+	punchTimes        rateWindow   // local punch times for the rolling rate
+	lastPeakAt        time.Time    // local time of the last punch's peak
+	lastPeakForce     float64      // force of the last punch
+	straightCount     int          // straights thrown, for stance inference
+	straightForceSum  float64      // sum of straight forces, for stance inference
+	guardTiltTS       int64        // device time the glove tilted out of guard (0 = in guard)
+	guardDownTS       int64        // device time the current guard drop began
 	calibrationBuffer [][6]float64 // rolling buffer for stillness detection [ax,ay,az,gx,gy,gz]
 	stillnessCounter  int          // consecutive "still" samples
 	serverCalibrated  bool         // true when server has captured gravity reference
+	autoPeaks         []float64    // punch forces observed while learning the threshold
+	fatigue           fatigueTracker
+	rhythm            rhythmTracker
+	forceCounts       []int              // punches per force bucket
+	fusion            orientationFilter  // Madgwick filter tracking glove orientation
+	timeline          timelineTracker    // magnitude buckets for charting
+	heatmap           heatmapTracker     // punches per interval of the session
+	spectrumBuf       []float64          // recent magnitudes for the FFT stage
+	motion            []motionSample     // recent samples for punch onset/peak analysis
+	pending           *pendingPunch      // punch whose peak is still being captured
+	rfdSum            float64            // sum of all punch RFDs
+	rfdByTypeSum      map[string]float64 // sum of punch RFDs per type
+	batteryGauge      bool               // Battery comes from the battery characteristic, not packets
 }
 
 // CombinedStats holds aggregated stats from both hands.
@@ -92,19 +179,52 @@ type CombinedStats struct {
 	TotalPunches   int     `json:"total_punches"`
 	AvgForce       float64 `json:"avg_force"`
 	MaxForce       float64 `json:"max_force"`
+	AvgPower       float64 `json:"avg_power,omitempty"` // estimated, N
+	MaxPower       float64 `json:"max_power,omitempty"`
 	PunchesPerMin  float64 `json:"ppm"`
 	PunchesPerSec  float64 `json:"pps"`             // Real-time punch rate
 	IntensityScore int     `json:"intensity_score"` // Gamified score: (punches * avgForce) / minutes
+
+	RollingPPM RollingRate `json:"rolling_ppm"` // current effort over sliding windows
+
+	ContactPunches int `json:"contact_punches"` // punches that hit the bag
+	AirPunches     int `json:"air_punches"`     // shadowboxing punches
+
+	// Combinations across both hands
+	Combos         int            `json:"combos"`
+	LongestCombo   int            `json:"longest_combo"`
+	ComboBreakdown map[string]int `json:"combo_breakdown"` // sequence → count
+
+	// High-output bursts across both hands
+	Flurries      int     `json:"flurries"`
+	LongestFlurry int     `json:"longest_flurry"` // punches
+	MaxBurstRate  float64 `json:"max_burst_rate"` // punches per second
+
+	Balance BalanceStats `json:"balance"` // left/right symmetry
+	Rhythm  RhythmStats  `json:"rhythm"`  // time between punches of either hand
+
+	// Punches of both hands per force range
+	ForceHistogram []ForceBucket `json:"force_histogram"`
 }
 
 // SessionState is the full state broadcast to WebSocket clients.
 type SessionState struct {
-	Active     bool          `json:"active"`
-	ElapsedSec float64       `json:"elapsed_sec"`
-	Left       *HandState    `json:"left"`
-	Right      *HandState    `json:"right"`
-	Combined   CombinedStats `json:"combined"`
-	Paused     bool          `json:"paused"` // true if a glove disconnected
+	Active      bool           `json:"active"`
+	ElapsedSec  float64        `json:"elapsed_sec"`
+	Left        *HandState     `json:"left"`
+	Right       *HandState     `json:"right"`
+	Combined    CombinedStats  `json:"combined"`
+	Paused      bool           `json:"paused"`       // true if a glove disconnected
+	Stance      string         `json:"stance"`       // configured stance, else inferred ("" = unknown)
+	SessionType string         `json:"session_type"` // detection profile in use (SessionHeavyBag, ...)
+	Defense     DefenseStats   `json:"defense"`      // defensive moves, in defense drills
+	Reaction    *ReactionStats `json:"reaction"`     // reaction-time drill, nil = none ran
+	Program     *ProgramStats  `json:"program"`      // workout program, nil = none ran
+	HeartRate   int            `json:"heart_rate"`   // BPM from the heart-rate strap (0 = none)
+	HRStats     HeartRateStats `json:"hr_stats"`
+
+	EffectiveMass float64 `json:"effective_mass,omitempty"` // kg behind the athlete's punches, 0 = unknown
+	Units         *Units  `json:"units,omitempty"`          // what values are in, nil = m/s², N and kg (see InUnits)
 }
 
 // StateHandler is called when session state changes.
@@ -121,14 +241,79 @@ type Analyzer struct {
 	paused    bool
 	startedAt time.Time
 	onState   StateHandler
+	onEvent   EventHandler
+	onRaw     RawHandler
+	journal   Journal // nil = no crash recovery
+
+	// Alert rules evaluated on every tick
+	alerts      []*alertTracker
+	nextAlertID int
+
+	// Adaptive thresholds
+	athlete       string                      // current athlete ID ("" = anonymous)
+	thresholds    map[thresholdKey][2]float64 // learned thresholds, indexed by hand
+	thresholdsVer int                         // bumped as thresholds change, see LearnedThresholds
+	autoThreshold bool                        // learn thresholds at session start when none are known
+
+	// Session types
+	profiles      map[string]DetectionProfile // detection profile per session type
+	sessionType   string                      // type of new sessions
+	detectionType string                      // type of the current session
+	detection     DetectionProfile            // its profile
+	nextInfo      SessionInfo                 // metadata of new sessions
+	info          SessionInfo                 // metadata of the current session
+
+	// Athlete profile
+	skill         string  // skill level ("" = unknown)
+	effectiveMass float64 // kg behind a punch for power estimates (0 = unknown)
+
+	// Jab/cross split
+	stance         string // athlete stance ("" = unknown)
+	inferredStance string // stance inferred from which hand leads
+
+	classifier Classifier // punch type classifier, nil = the session type's heuristic
+	spectrum   bool       // run the optional FFT stage
+
+	combos   *comboTracker  // cross-hand combination correlator
+	flurries flurryTracker  // cross-hand burst clustering
+	defense  defenseTracker // slips, rolls and blocks in defense drills
+	rhythm   rhythmTracker  // intervals between punches of either hand
+
+	reaction *reactionDrill // reaction-time drill of the session, nil = none
+	program  *programRun    // workout program of the session, nil = none
+
+	// Left/right balance warnings
+	balanceMinShare float64 // minimum share of punches per hand (0 = off)
+	lagging         string  // hand currently below the minimum share
+
+	forceEdges []float64 // force histogram bucket edges, ascending
+
+	// Battery warnings
+	batteryThresholds []int          // percentages that raise "battery_low", highest first
+	batteryWarned     map[string]int // lowest threshold warned about per hand
+
+	punches []PunchEvent // every punch of the session, both hands, in order
+
+	// Heart rate
+	heartRate    int              // latest BPM from the heart-rate strap (0 = none)
+	maxHeartRate int              // current athlete's max HR (0 = DefaultMaxHeartRate)
+	roundLength  time.Duration    // round length for HR vs output
+	hr           heartRateTracker // session trace, zones and rounds
 }
 
 // NewAnalyzer creates a new Analyzer instance.
 func NewAnalyzer() *Analyzer {
-	return &Analyzer{
-		left:  newHandState(),
-		right: newHandState(),
+	a := &Analyzer{
+		thresholds:        make(map[thresholdKey][2]float64),
+		profiles:          DefaultDetectionProfiles(),
+		sessionType:       DefaultSessionType,
+		balanceMinShare:   DefaultBalanceMinShare,
+		batteryThresholds: DefaultBatteryThresholds,
+		forceEdges:        DefaultForceBuckets,
+		roundLength:       DefaultRoundLength,
 	}
+	a.resetStatsLocked()
+	return a
 }
 
 // newHandState creates an initialized HandState.
@@ -136,7 +321,23 @@ func newHandState() *HandState {
 	return &HandState{
 		PunchBreakdown: make(map[string]int),
 		RecentPunches:  make([]PunchEvent, 0, maxRecentPunches),
+		Threshold:      punchThreshold,
+		Orientation:    identityQuaternion,
+		RFDByType:      make(map[string]float64),
+		rfdByTypeSum:   make(map[string]float64),
+	}
+}
+
+// keepLink carries over the state of the glove's link, which outlives a
+// session, from prev (nil for a new analyzer).
+func (h *HandState) keepLink(prev *HandState) *HandState {
+	if prev != nil {
+		h.Connected = prev.Connected
+		h.Battery = prev.Battery
+		h.Charging = prev.Charging
+		h.batteryGauge = prev.batteryGauge
 	}
+	return h
 }
 
 // SetStateHandler sets the callback for state changes.
@@ -151,11 +352,13 @@ func (a *Analyzer) StartSession() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	a.left = newHandState()
-	a.right = newHandState()
+	a.resetStatsLocked()
 	a.active = true
 	a.paused = false
 	a.startedAt = time.Now()
+	if a.journal != nil {
+		a.journal.SessionStarted(a.startedAt, a.athlete, a.detectionType, a.info)
+	}
 
 	a.broadcastLocked()
 }
@@ -165,14 +368,39 @@ func (a *Analyzer) ResetSession() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	a.left = newHandState()
-	a.right = newHandState()
+	a.resetStatsLocked()
 	a.active = false
 	a.paused = false
+	if a.journal != nil {
+		a.journal.SessionEnded()
+	}
 
 	a.broadcastLocked()
 }
 
+// resetStatsLocked clears all per-session statistics for both hands.
+// Must be called with a.mu held.
+func (a *Analyzer) resetStatsLocked() {
+	a.left = newHandState().keepLink(a.left)
+	a.right = newHandState().keepLink(a.right)
+	a.combos = newComboTracker()
+	a.flurries = flurryTracker{}
+	a.defense = defenseTracker{}
+	a.rhythm = rhythmTracker{}
+	a.stopReactionLocked()
+	a.reaction = nil
+	a.stopProgramLocked()
+	a.program = nil
+	a.punches = nil
+	a.lagging = ""
+	a.inferredStance = ""
+	a.hr = heartRateTracker{}
+	a.detectionType = a.sessionType
+	a.detection = a.profiles[a.sessionType]
+	a.info = a.nextInfo
+	a.applyThresholdsLocked()
+}
+
 // PauseSession pauses the session (e.g., when a glove disconnects).
 func (a *Analyzer) PauseSession() {
 	a.mu.Lock()
@@ -180,6 +408,9 @@ func (a *Analyzer) PauseSession() {
 
 	if a.active {
 		a.paused = true
+		if a.journal != nil {
+			a.journal.SessionPaused(true)
+		}
 		a.broadcastLocked()
 	}
 }
@@ -191,10 +422,24 @@ func (a *Analyzer) ResumeSession() {
 
 	if a.active && a.paused {
 		a.paused = false
+		if a.journal != nil {
+			a.journal.SessionPaused(false)
+		}
 		a.broadcastLocked()
 	}
 }
 
+// Punches returns every punch detected in the current session, or the first
+// maxSessionPunches of a session that has more.
+func (a *Analyzer) Punches() []PunchEvent {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	punches := make([]PunchEvent, len(a.punches))
+	copy(punches, a.punches)
+	return punches
+}
+
 // IsActive returns whether a session is currently active.
 func (a *Analyzer) IsActive() bool {
 	a.mu.RLock()
@@ -202,6 +447,41 @@ func (a *Analyzer) IsActive() bool {
 	return a.active
 }
 
+// StartedAt returns when the current session started.
+func (a *Analyzer) StartedAt() time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.startedAt
+}
+
+// Athlete returns the ID of the athlete the current session belongs to.
+func (a *Analyzer) Athlete() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.athlete
+}
+
+// SessionInfo is what a session is about beyond the athlete and its type,
+// as given when it starts.
+type SessionInfo struct {
+	PlannedRounds int    `json:"planned_rounds,omitempty"` // 0 = not planned
+	Location      string `json:"location,omitempty"`       // e.g. "ring 2"
+}
+
+// SetSessionInfo sets the metadata of new sessions.
+func (a *Analyzer) SetSessionInfo(info SessionInfo) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nextInfo = info
+}
+
+// SessionInfo returns the metadata of the current session.
+func (a *Analyzer) SessionInfo() SessionInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.info
+}
+
 // SetConnected updates the connection state for a hand.
 func (a *Analyzer) SetConnected(hand ble.Hand, connected bool) {
 	a.mu.Lock()
@@ -214,6 +494,10 @@ func (a *Analyzer) SetConnected(hand ble.Hand, connected bool) {
 
 	wasConnected := state.Connected
 	state.Connected = connected
+	if !connected {
+		// The next glove may report its battery in packets only
+		state.batteryGauge = false
+	}
 
 	// Pause only on a connected→disconnected transition during an active session,
 	// not simply because a glove was never connected (single-glove mode).
@@ -228,8 +512,110 @@ func (a *Analyzer) SetConnected(hand ble.Hand, connected bool) {
 	a.broadcastLocked()
 }
 
+// SetBattery records a battery level read outside the sensor packets (from
+// the glove's battery characteristic). From then on it is the hand's only
+// battery source, until the glove disconnects.
+func (a *Analyzer) SetBattery(hand ble.Hand, level uint8) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	state := a.left
+	if hand == ble.RightHand {
+		state = a.right
+	}
+	state.Battery = level
+	state.batteryGauge = true
+	a.checkBatteryLocked(state, hand.String())
+}
+
+// SetCorruptPackets records how many packets the BLE layer has dropped for
+// a hand because they failed validation.
+func (a *Analyzer) SetCorruptPackets(hand ble.Hand, n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	state := a.left
+	if hand == ble.RightHand {
+		state = a.right
+	}
+	state.CorruptPackets = n
+}
+
+// SetPacketLoss records the share of samples a glove lost recently, 0-100.
+func (a *Analyzer) SetPacketLoss(hand ble.Hand, percent float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	state := a.left
+	if hand == ble.RightHand {
+		state = a.right
+	}
+	state.PacketLoss = percent
+}
+
+// SwapHands exchanges the left and right hand state after the gloves were
+// reassigned (see ble.Central.SwapHands), so the punches already counted
+// move to the hand that actually threw them. Each hand keeps its punch
+// threshold, which belongs to the athlete rather than the glove.
+func (a *Analyzer) SwapHands() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.left, a.right = a.right, a.left
+	a.left.Threshold, a.right.Threshold = a.right.Threshold, a.left.Threshold
+	for _, punches := range [][]PunchEvent{a.left.RecentPunches, a.right.RecentPunches, a.punches} {
+		for i := range punches {
+			punches[i].Hand = otherHand(punches[i].Hand)
+		}
+	}
+	if a.batteryWarned != nil {
+		left, hasLeft := a.batteryWarned["left"]
+		right, hasRight := a.batteryWarned["right"]
+		delete(a.batteryWarned, "left")
+		delete(a.batteryWarned, "right")
+		if hasLeft {
+			a.batteryWarned["right"] = left
+		}
+		if hasRight {
+			a.batteryWarned["left"] = right
+		}
+	}
+	a.lagging = otherHand(a.lagging)
+	a.inferredStance = ""
+	a.left.Lead, a.right.Lead = false, false
+	a.inferStanceLocked()
+
+	a.broadcastLocked()
+}
+
+// otherHand returns the opposite hand name, leaving other values unchanged.
+func otherHand(hand string) string {
+	switch hand {
+	case "left":
+		return "right"
+	case "right":
+		return "left"
+	}
+	return hand
+}
+
+// ProcessSample handles a sample from any ingest source. Samples of a batch
+// keep the time their source dated them with.
+func (a *Analyzer) ProcessSample(sample ingest.Sample) {
+	at := sample.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	a.processPacket(sample.Hand, sample.Packet, at)
+}
+
 // ProcessPacket handles an incoming sensor packet.
 func (a *Analyzer) ProcessPacket(hand ble.Hand, packet *ble.SensorPacket) {
+	a.processPacket(hand, packet, time.Now())
+}
+
+// processPacket handles a sensor packet taken at the given time.
+func (a *Analyzer) processPacket(hand ble.Hand, packet *ble.SensorPacket, at time.Time) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -241,8 +627,12 @@ func (a *Analyzer) ProcessPacket(hand ble.Hand, packet *ble.SensorPacket) {
 		handName = "right"
 	}
 
-	// Update battery status
-	state.Battery = packet.Battery
+	// Update battery status, unless the battery characteristic reports it
+	if !state.batteryGauge {
+		state.Battery = packet.Battery
+	}
+	state.Charging = packet.IsCharging()
+	a.checkBatteryLocked(state, handName)
 
 	// Get acceleration and gyroscope values
 	ax, ay, az := packet.AccelMS2()
@@ -274,12 +664,10 @@ func (a *Analyzer) ProcessPacket(hand ble.Hand, packet *ble.SensorPacket) {
 			if state.stillnessCounter >= calibrationSamples {
 				state.GravityRef = captureGravityReference(state.calibrationBuffer)
 				state.UpAxis, state.GloveOrientation = detectOrientation(state.GravityRef)
+				state.fusion.reset(state.GravityRef)
+				state.Orientation = state.fusion.q
 				state.serverCalibrated = true
 				state.Calibrated = true
-
-				// Log calibration completion
-				_ = handName // Used in format string below
-				// log.Printf not available here, will broadcast state change
 			}
 		} else {
 			// Movement detected, reset stillness counter
@@ -297,68 +685,222 @@ func (a *Analyzer) ProcessPacket(hand ble.Hand, packet *ble.SensorPacket) {
 	// Update calibration status from firmware (for display)
 	state.Calibrated = state.serverCalibrated
 
+	// Track orientation continuously so it is current when a session starts.
+	// Gloves running on-board fusion (v2 packets) supply it directly.
+	if q, ok := packet.Quaternion(); ok {
+		state.fusion.set(int64(packet.Timestamp), q)
+	} else {
+		state.fusion.update(int64(packet.Timestamp), ax, ay, az, gx, gy, gz)
+	}
+	state.Orientation = state.fusion.q
+
+	// World-frame acceleration with gravity removed using the fused
+	// orientation, so gravity stays out even when the glove rotates
+	linear := state.fusion.linear(ax, ay, az)
+	if lx, ly, lz, ok := packet.LinearAccelMS2(); ok {
+		linear = state.fusion.q.Rotate([3]float64{lx, ly, lz})
+	}
+	punchAx, punchAy, punchAz := linear[0], linear[1], linear[2]
+	mag := math.Sqrt(punchAx*punchAx + punchAy*punchAy + punchAz*punchAz)
+	threshold := state.Threshold
+	if state.AutoThreshold {
+		threshold = autoThresholdFloor
+	}
+	if a.onRaw != nil {
+		a.onRaw(RawSample{
+			Hand:      handName,
+			Timestamp: packet.Timestamp,
+			Mag:       mag,
+			Gyro:      state.CurrentGyro,
+			Threshold: threshold,
+			Active:    a.active && !a.paused,
+		})
+	}
+
 	// ─── Punch Detection Phase ───────────────────────────────────────────────
 	// Skip punch analysis if session not active or paused
 	if !a.active || a.paused {
+		state.pending = nil
 		return
 	}
+	a.updateGuardLocked(hand, state, int64(packet.Timestamp))
 
-	// Calculate gravity-compensated acceleration magnitude
-	punchAx := ax - state.GravityRef[0]
-	punchAy := ay - state.GravityRef[1]
-	punchAz := az - state.GravityRef[2]
-	mag := math.Sqrt(punchAx*punchAx + punchAy*punchAy + punchAz*punchAz)
+	sample := motionSample{
+		ts: int64(packet.Timestamp), at: at, mag: mag,
+		ax: punchAx, ay: punchAy, az: punchAz,
+		gx: gx, gy: gy, gz: gz,
+	}
+	defer state.pushMotion(sample)
+	state.timeline.add(sample.at.Sub(a.startedAt), mag)
+	if a.spectrum {
+		state.pushSpectrum(mag)
+	}
 
-	// Punch detection: threshold + debounce
-	timeSinceLast := int64(packet.Timestamp) - state.lastPunchTS
-	if mag > punchThreshold && timeSinceLast > debounceMS {
-		// Classify punch type based on gyroscope data and calibrated up axis
-		punchType := classifyPunch(gx, gy, gz, state.UpAxis)
-
-		// Update stats
-		state.PunchCount++
-		state.lastPunchTS = int64(packet.Timestamp)
-		state.lastPunchTime = time.Now()
-
-		if mag > state.MaxForce {
-			state.MaxForce = mag
+	if a.detection.Defense {
+		a.trackDefenseLocked(hand, state, sample)
+	}
+
+	// Finish capturing a punch already in progress
+	if state.pending != nil {
+		if state.updatePunch(sample) {
+			a.registerPunchLocked(hand, state)
 		}
+		return
+	}
 
-		state.forceSum += mag
-		state.AvgForce = state.forceSum / float64(state.PunchCount)
+	// Punch detection: threshold + debounce
+	timeSinceLast := sample.ts - state.lastPunchTS
+	if mag > threshold && timeSinceLast > a.detection.DebounceMS {
+		state.lastPunchTS = sample.ts
+		state.startPunch(sample)
+	}
+}
 
-		// Calculate punches per minute
-		elapsed := time.Since(a.startedAt).Minutes()
-		if elapsed > 0 {
-			state.PunchesPerMin = float64(state.PunchCount) / elapsed
-		}
+// registerPunchLocked turns a fully captured punch into a PunchEvent and
+// updates all statistics that depend on it.
+// Must be called with a.mu held.
+func (a *Analyzer) registerPunchLocked(hand ble.Hand, state *HandState) {
+	p := state.pending
+	state.pending = nil
+	mag := p.peak.mag
+	rfd := p.rfd()
+
+	// Drop shock transmitted from a hard hit on the other hand
+	if a.isCrosstalkLocked(state, p) {
+		state.Suppressed++
+		a.broadcastLocked()
+		return
+	}
+	state.lastPeakAt = p.peakAt
+	state.lastPeakForce = mag
 
-		// Update punch breakdown
-		state.PunchBreakdown[string(punchType)]++
+	// Count a blow stopped by the guard as a block, not a punch
+	if a.isBlockLocked(state, p) {
+		state.Blocks++
+		a.recordDefenseLocked(DefenseBlock, hand.String(), p.peakAt)
+		return
+	}
 
-		// Create punch event
-		event := PunchEvent{
-			Hand:      handName,
-			Type:      punchType,
-			Force:     math.Round(mag*100) / 100,
-			RotationZ: math.Abs(gz),
-			Timestamp: int64(packet.Timestamp),
-			Count:     state.PunchCount,
-		}
+	// Classify punch type with the configured classifier
+	classifier := a.classifier
+	if classifier == nil {
+		classifier = a.detection.heuristic()
+	}
+	punchType := classifier.Classify(punchFeatures(p, state.UpAxis))
+	if punchType == PunchStraight {
+		a.recordStraightLocked(state, mag)
+		punchType = a.splitStraightLocked(hand)
+	}
 
-		// Add to recent punches (limited buffer)
-		state.RecentPunches = append(state.RecentPunches, event)
-		if len(state.RecentPunches) > maxRecentPunches {
-			state.RecentPunches = state.RecentPunches[1:]
-		}
+	// Feed the adaptive threshold while it is still being learned
+	if state.AutoThreshold {
+		a.observeThresholdLocked(hand, state, mag)
+	}
 
-		// Broadcast state update
-		a.broadcastLocked()
+	// The spectrum window now holds the impact's ringing too
+	ringing := 0.0
+	if a.spectrum {
+		state.updateSpectrum()
+		ringing = state.Spectrum.RingingShare
+	}
+
+	// Create punch event
+	event := PunchEvent{
+		Hand:       hand.String(),
+		Type:       punchType,
+		Force:      math.Round(mag*100) / 100,
+		RotationZ:  math.Abs(p.detect.gz),
+		RFD:        math.Round(rfd*10) / 10,
+		DurationMS: p.durationMS(),
+		Retraction: math.Round(p.retraction*100) / 100,
+		Trajectory: p.trajectory(),
+		Contact:    p.contact(ringing),
+		Timestamp:  p.detect.ts,
+	}
+	now := time.Now()
+	event = a.countPunchLocked(state, event, mag, rfd, now)
+	if a.journal != nil {
+		a.journal.SessionPunch(RecordedPunch{PunchEvent: event, At: now})
 	}
+	a.emitLocked("punch", event.Hand, event)
+	a.reactLocked(hand, p.onset.at)
+
+	// Broadcast state update
+	a.broadcastLocked()
 }
 
-// classifyPunch determines the punch type based on motion data and calibration.
-func classifyPunch(gx, gy, gz float64, upAxis int) PunchType {
+// countPunchLocked adds a punch registered at the given time to the session
+// statistics and returns it with its session ID and numbered within its
+// hand. mag and rfd are the unrounded force and rate of force development.
+// Must be called with a.mu held.
+func (a *Analyzer) countPunchLocked(state *HandState, event PunchEvent, mag, rfd float64, at time.Time) PunchEvent {
+	punchType := string(event.Type)
+
+	// Update stats
+	state.PunchCount++
+	state.lastPunchTime = at
+	state.rhythm.add(at)
+	a.rhythm.add(at)
+
+	if mag > state.MaxForce {
+		state.MaxForce = mag
+	}
+
+	state.forceSum += mag
+	a.countForceLocked(state, mag)
+	state.heatmap.add(at.Sub(a.startedAt), mag)
+	state.AvgForce = state.forceSum / float64(state.PunchCount)
+
+	// Calculate punches per minute
+	elapsed := at.Sub(a.startedAt).Minutes()
+	if elapsed > 0 {
+		state.PunchesPerMin = float64(state.PunchCount) / elapsed
+	}
+
+	// Update punch breakdown
+	state.PunchBreakdown[punchType]++
+
+	// Update rate of force development averages
+	state.rfdSum += rfd
+	state.AvgRFD = math.Round(state.rfdSum/float64(state.PunchCount)*10) / 10
+	state.rfdByTypeSum[punchType] += rfd
+	state.RFDByType[punchType] = math.Round(
+		state.rfdByTypeSum[punchType]/float64(state.PunchBreakdown[punchType])*10) / 10
+
+	event.ID = a.left.PunchCount + a.right.PunchCount
+	event.Count = state.PunchCount
+	event.Power = a.powerLocked(mag)
+	if event.Contact {
+		state.ContactCount++
+	} else {
+		state.AirCount++
+	}
+
+	// Add to recent punches (limited buffer)
+	state.RecentPunches = append(state.RecentPunches, event)
+	if len(state.RecentPunches) > maxRecentPunches {
+		state.RecentPunches = state.RecentPunches[1:]
+	}
+
+	if len(a.punches) < maxSessionPunches {
+		a.punches = append(a.punches, event)
+	}
+
+	// Track output fall-off for the fatigue index
+	state.fatigue.record(at, mag)
+	state.punchTimes.add(at)
+
+	// Correlate with the other hand for combination detection
+	a.recordComboPunchLocked(event, at)
+	a.recordFlurryPunchLocked(at)
+	a.checkBalanceLocked()
+	a.hr.addPunch(at.Sub(a.startedAt), event.Force, a.roundLength)
+	return event
+}
+
+// classifyPunch determines the punch type based on motion data and calibration,
+// with the gyro thresholds of c.
+func classifyPunch(gx, gy, gz float64, upAxis int, c HeuristicClassifier) PunchType {
 	absGX := math.Abs(gx)
 	absGY := math.Abs(gy)
 	absGZ := math.Abs(gz)
@@ -377,7 +919,7 @@ func classifyPunch(gx, gy, gz float64, upAxis int) PunchType {
 	}
 
 	// Hook: High rotation around vertical (up) axis - horizontal spinning motion
-	if upRotation > hookGyroThresh {
+	if upRotation > c.HookGyro {
 		return PunchHook
 	}
 
@@ -395,19 +937,49 @@ func classifyPunch(gx, gy, gz float64, upAxis int) PunchType {
 		horizontalRotation = math.Max(absGX, absGY)
 	}
 
-	if horizontalRotation > uppercutGyroThresh {
+	if horizontalRotation > c.UppercutGyro {
 		return PunchUppercut
 	}
 
 	// Straight: Low rotation overall
 	maxRotation := math.Max(absGX, math.Max(absGY, absGZ))
-	if maxRotation < straightGyroMax {
+	if maxRotation < c.StraightGyroMax {
 		return PunchStraight
 	}
 
 	return PunchUnknown
 }
 
+// SetStance sets the athlete's stance used to tell jabs from crosses.
+// An empty stance falls back to the stance inferred from which hand leads;
+// until one is inferred, straights are not split.
+func (a *Analyzer) SetStance(stance string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stance = stance
+}
+
+// splitStraightLocked refines a straight punch into a jab or cross: the lead
+// hand throws jabs and the rear hand crosses. While the stance is neither
+// known nor inferred the punch stays a straight, since force alone does not
+// tell a hard jab from a soft cross.
+// Must be called with a.mu held.
+func (a *Analyzer) splitStraightLocked(hand ble.Hand) PunchType {
+	switch a.effectiveStanceLocked() {
+	case StanceOrthodox:
+		if hand == ble.LeftHand {
+			return PunchJab
+		}
+		return PunchCross
+	case StanceSouthpaw:
+		if hand == ble.RightHand {
+			return PunchJab
+		}
+		return PunchCross
+	}
+	return PunchStraight
+}
+
 // ─── Calibration Functions ───────────────────────────────────────────────────
 
 // isStill checks if the recent samples indicate the glove is stationary
@@ -513,14 +1085,24 @@ func detectOrientation(gravityRef [3]float64) (upAxis int, orientation string) {
 	return upAxis, orientation
 }
 
-// BroadcastTick sends periodic state updates (elapsed time).
+// BroadcastTick sends periodic state updates (elapsed time) and evaluates
+// alert rules.
 func (a *Analyzer) BroadcastTick() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	now := time.Now()
 	if a.active {
+		a.flushCombosLocked(now)
+		a.flushFlurriesLocked(now)
+		a.advanceProgramLocked(now)
+		if a.spectrum {
+			a.left.updateSpectrum()
+			a.right.updateSpectrum()
+		}
 		a.broadcastLocked()
 	}
+	a.evaluateAlertsLocked(now)
 }
 
 // GetState returns the current session state.
@@ -540,14 +1122,39 @@ func (a *Analyzer) buildStateLocked() *SessionState {
 	}
 
 	// Build combined stats
+	comboBreakdown := make(map[string]int, len(a.combos.breakdown))
+	for k, v := range a.combos.breakdown {
+		comboBreakdown[k] = v
+	}
 	combined := CombinedStats{
-		TotalPunches: a.left.PunchCount + a.right.PunchCount,
+		TotalPunches:   a.left.PunchCount + a.right.PunchCount,
+		ContactPunches: a.left.ContactCount + a.right.ContactCount,
+		AirPunches:     a.left.AirCount + a.right.AirCount,
+		Combos:         a.combos.count,
+		LongestCombo:   a.combos.longest,
+		ComboBreakdown: comboBreakdown,
+		Flurries:       a.flurries.count,
+		LongestFlurry:  a.flurries.longest,
+		MaxBurstRate:   a.flurries.maxRate,
+		Balance:        a.balanceStatsLocked(),
+		Rhythm:         a.rhythm.stats(),
+		ForceHistogram: a.forceHistogramLocked(a.left.forceCounts, a.right.forceCounts),
+	}
+
+	// Rolling rate over both hands
+	now := time.Now()
+	left, right := a.left.punchTimes.counts(now), a.right.punchTimes.counts(now)
+	for i := range left {
+		left[i] += right[i]
 	}
+	combined.RollingPPM = a.rollingRateLocked(left)
 
 	if combined.TotalPunches > 0 {
 		totalForce := a.left.forceSum + a.right.forceSum
 		combined.AvgForce = totalForce / float64(combined.TotalPunches)
 		combined.MaxForce = math.Max(a.left.MaxForce, a.right.MaxForce)
+		combined.AvgPower = a.powerLocked(combined.AvgForce)
+		combined.MaxPower = a.powerLocked(combined.MaxForce)
 
 		if elapsed > 0 {
 			elapsedMin := elapsed / 60
@@ -563,12 +1170,21 @@ func (a *Analyzer) buildStateLocked() *SessionState {
 	}
 
 	return &SessionState{
-		Active:     a.active,
-		ElapsedSec: elapsed,
-		Left:       a.copyHandState(a.left),
-		Right:      a.copyHandState(a.right),
-		Combined:   combined,
-		Paused:     a.paused,
+		Active:      a.active,
+		ElapsedSec:  elapsed,
+		Left:        a.copyHandState(a.left),
+		Right:       a.copyHandState(a.right),
+		Combined:    combined,
+		Paused:      a.paused,
+		Stance:      a.effectiveStanceLocked(),
+		SessionType: a.detectionType,
+		Defense:     a.defense.stats(),
+		Reaction:    a.reactionStatsLocked(),
+		Program:     a.programStatsLocked(),
+		HeartRate:   a.heartRate,
+		HRStats:     a.hr.stats(a.heartRate, a.maxHeartRateLocked()),
+
+		EffectiveMass: a.effectiveMass,
 	}
 }
 
@@ -582,16 +1198,31 @@ func (a *Analyzer) copyHandState(h *HandState) *HandState {
 	punches := make([]PunchEvent, len(h.RecentPunches))
 	copy(punches, h.RecentPunches)
 
+	rfdByType := make(map[string]float64, len(h.RFDByType))
+	for k, v := range h.RFDByType {
+		rfdByType[k] = v
+	}
+
 	return &HandState{
 		Connected:           h.Connected,
 		Calibrated:          h.Calibrated,
 		Battery:             h.Battery,
+		Charging:            h.Charging,
 		PacketLoss:          h.PacketLoss,
+		CorruptPackets:      h.CorruptPackets,
 		PunchCount:          h.PunchCount,
+		Suppressed:          h.Suppressed,
+		Blocks:              h.Blocks,
+		ContactCount:        h.ContactCount,
+		AirCount:            h.AirCount,
 		PunchBreakdown:      breakdown,
 		MaxForce:            h.MaxForce,
 		AvgForce:            h.AvgForce,
+		MaxPower:            a.powerLocked(h.MaxForce),
+		AvgPower:            a.powerLocked(h.AvgForce),
+		ForceHistogram:      a.forceHistogramLocked(h.forceCounts),
 		PunchesPerMin:       h.PunchesPerMin,
+		RollingPPM:          a.rollingRateLocked(h.punchTimes.counts(time.Now())),
 		RecentPunches:       punches,
 		CurrentAccel:        h.CurrentAccel,
 		CurrentGyro:         h.CurrentGyro,
@@ -599,7 +1230,28 @@ func (a *Analyzer) copyHandState(h *HandState) *HandState {
 		GravityRef:          h.GravityRef,
 		GloveOrientation:    h.GloveOrientation,
 		UpAxis:              h.UpAxis,
+		Orientation:         h.Orientation,
+		Lead:                h.Lead,
+		GuardDropped:        h.GuardDropped,
+		GuardDrops:          h.GuardDrops,
+		GuardDownSec:        math.Round(h.GuardDownSec*10) / 10,
+		Threshold:           h.Threshold,
+		AutoThreshold:       h.AutoThreshold,
+		Fatigue:             h.fatigue.stats(h.PunchCount, time.Now()),
+		Rhythm:              h.rhythm.stats(),
+		Spectrum:            h.Spectrum,
+		AvgRFD:              h.AvgRFD,
+		RFDByType:           rfdByType,
+	}
+}
+
+// rollingRateLocked converts window counts to rates for the current session.
+// Must be called with a.mu held.
+func (a *Analyzer) rollingRateLocked(counts [len(rateWindows)]int) RollingRate {
+	if !a.active {
+		return RollingRate{}
 	}
+	return rollingRate(counts, time.Since(a.startedAt))
 }
 
 // broadcastLocked sends state to the handler.
@@ -633,6 +1285,8 @@ func (a *Analyzer) ResetCalibration(hand ble.Hand) {
 	state.GravityRef = [3]float64{0, 0, 0}
 	state.GloveOrientation = ""
 	state.UpAxis = 0
+	state.fusion = orientationFilter{}
+	state.Orientation = identityQuaternion
 
 	a.broadcastLocked()
 }
@@ -654,3 +1308,175 @@ func (a *Analyzer) AnyCalibrated() bool {
 	defer a.mu.RUnlock()
 	return a.left.serverCalibrated || a.right.serverCalibrated
 }
+
+// ─── Adaptive Thresholds ─────────────────────────────────────────────────────
+
+// thresholdKey identifies the thresholds learned for an athlete in one
+// session type, as shadowboxing punches peak lower than punches on a bag.
+type thresholdKey struct {
+	athlete     string
+	sessionType string
+}
+
+// SetAthlete selects the athlete whose learned thresholds apply to new sessions.
+func (a *Analyzer) SetAthlete(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.athlete = id
+}
+
+// ForgetAthlete discards the learned thresholds of an athlete.
+func (a *Analyzer) ForgetAthlete(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for key := range a.thresholds {
+		if key.athlete == id {
+			delete(a.thresholds, key)
+			a.thresholdsVer++
+		}
+	}
+}
+
+// LearnedThreshold is the detection threshold of each hand learned or set
+// for an athlete in one session type.
+type LearnedThreshold struct {
+	Athlete     string  `json:"athlete,omitempty"` // "" = sessions without an athlete
+	SessionType string  `json:"session_type"`
+	Left        float64 `json:"left,omitempty"` // 0 = none
+	Right       float64 `json:"right,omitempty"`
+}
+
+// LearnedThresholds returns the thresholds learned or set so far, by athlete
+// and session type, and a version that changes whenever they do, so that
+// callers can save them only after a change.
+func (a *Analyzer) LearnedThresholds() ([]LearnedThreshold, int) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	out := make([]LearnedThreshold, 0, len(a.thresholds))
+	for key, learned := range a.thresholds {
+		out = append(out, LearnedThreshold{Athlete: key.athlete, SessionType: key.sessionType, Left: learned[0], Right: learned[1]})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Athlete != out[j].Athlete {
+			return out[i].Athlete < out[j].Athlete
+		}
+		return out[i].SessionType < out[j].SessionType
+	})
+	return out, a.thresholdsVer
+}
+
+// LoadLearnedThresholds replaces the learned thresholds, e.g. with those
+// saved before a restart. They apply from the next session.
+func (a *Analyzer) LoadLearnedThresholds(thresholds []LearnedThreshold) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.thresholds = make(map[thresholdKey][2]float64, len(thresholds))
+	for _, t := range thresholds {
+		a.thresholds[thresholdKey{t.Athlete, t.SessionType}] = [2]float64{t.Left, t.Right}
+	}
+	a.thresholdsVer++
+}
+
+// SetAutoThreshold enables learning thresholds at session start for athletes
+// that have no learned thresholds yet.
+func (a *Analyzer) SetAutoThreshold(enabled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.autoThreshold = enabled
+}
+
+// StartAutoThreshold begins a threshold calibration drill for a hand: the next
+// autoThresholdPunches punches are observed and used to set its threshold.
+func (a *Analyzer) StartAutoThreshold(hand ble.Hand) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	state := a.left
+	if hand == ble.RightHand {
+		state = a.right
+	}
+	state.AutoThreshold = true
+	state.autoPeaks = nil
+
+	a.broadcastLocked()
+}
+
+// applyThresholdsLocked sets each hand's threshold from the current athlete's
+// learned values for the session type, or else its profile scaled to their
+// skill level, and starts learning them if auto-threshold mode is enabled.
+// Must be called with a.mu held.
+func (a *Analyzer) applyThresholdsLocked() {
+	learned, ok := a.thresholds[a.thresholdKeyLocked()]
+	base := math.Round(a.detection.Threshold*skillProfileOf(a.skill).thresholdScale*100) / 100
+	for i, state := range []*HandState{a.left, a.right} {
+		state.Threshold = base
+		if ok && learned[i] > 0 {
+			state.Threshold = learned[i]
+			state.AutoThreshold = false
+			continue
+		}
+		state.AutoThreshold = a.autoThreshold
+	}
+}
+
+// observeThresholdLocked records a punch force and, once enough punches have
+// been seen, fixes the hand's threshold to a fraction of the median force.
+// Must be called with a.mu held.
+func (a *Analyzer) observeThresholdLocked(hand ble.Hand, state *HandState, force float64) {
+	state.autoPeaks = append(state.autoPeaks, force)
+	if len(state.autoPeaks) < autoThresholdPunches {
+		return
+	}
+
+	peaks := make([]float64, len(state.autoPeaks))
+	copy(peaks, state.autoPeaks)
+	sort.Float64s(peaks)
+	median := peaks[len(peaks)/2]
+
+	threshold := median * autoThresholdRatio
+	threshold = math.Max(threshold, autoThresholdFloor)
+	threshold = math.Min(threshold, autoThresholdCeil)
+
+	state.Threshold = math.Round(threshold*100) / 100
+	state.AutoThreshold = false
+	state.autoPeaks = nil
+
+	key := a.thresholdKeyLocked()
+	learned := a.thresholds[key]
+	learned[hand] = state.Threshold
+	a.thresholds[key] = learned
+	a.thresholdsVer++
+}
+
+// thresholdKeyLocked returns the key of the current athlete's thresholds in
+// the current session type.
+// Must be called with a.mu held.
+func (a *Analyzer) thresholdKeyLocked() thresholdKey {
+	return thresholdKey{a.athlete, a.detectionType}
+}
+
+// SetThreshold fixes a hand's detection threshold for the current athlete
+// and session type, taking precedence over automatic learning.
+func (a *Analyzer) SetThreshold(hand ble.Hand, threshold float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := a.thresholdKeyLocked()
+	learned := a.thresholds[key]
+	learned[hand] = threshold
+	a.thresholds[key] = learned
+	a.thresholdsVer++
+	a.applyThresholdsLocked()
+	a.broadcastLocked()
+}
+
+// GetThreshold returns the active detection threshold for a hand.
+func (a *Analyzer) GetThreshold(hand ble.Hand) float64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if hand == ble.LeftHand {
+		return a.left.Threshold
+	}
+	return a.right.Threshold
+}
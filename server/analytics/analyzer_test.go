@@ -0,0 +1,188 @@
+package analytics
+
+import (
+	"math/rand"
+	"testing"
+
+	"boxing-analytics/ble"
+)
+
+// punchShape is a punch's forward acceleration, sample by sample, as a
+// fraction of its peak, as the simulator throws it.
+var punchShape = []float64{0.4, 0.75, 1, 0.8, 0.45}
+
+// punchRotation is the peak rotation (°/s) about the glove's X and Z axes
+// for each punch type; Z points up while the glove rests.
+var punchRotation = map[PunchType][2]float64{
+	PunchStraight: {20, 20},
+	PunchHook:     {30, 280},
+	PunchUppercut: {220, 40},
+}
+
+// synthGlove feeds one glove's synthetic 100 Hz sample stream to an analyzer.
+type synthGlove struct {
+	a    *Analyzer
+	hand ble.Hand
+	rng  *rand.Rand
+	ts   uint32 // device timestamp of the next sample
+	seq  uint16
+}
+
+func newSynthGlove(a *Analyzer, hand ble.Hand) *synthGlove {
+	return &synthGlove{a: a, hand: hand, rng: rand.New(rand.NewSource(int64(hand) + 1)), ts: 1000}
+}
+
+// feed sends one sample: resting flat plus noise, with the given forward
+// acceleration (m/s²) and rotation (°/s) added.
+func (g *synthGlove) feed(accel float64, rotation [2]float64) {
+	noise := func() int16 { return int16(g.rng.Intn(17) - 8) }
+	g.a.ProcessPacket(g.hand, &ble.SensorPacket{
+		AccX: noise() + int16(accel*100), AccY: noise(), AccZ: 980 + noise(),
+		GyroX: noise() + int16(rotation[0]*10), GyroY: noise(), GyroZ: noise() + int16(rotation[1]*10),
+		Timestamp: g.ts,
+		Sequence:  g.seq,
+		Battery:   90,
+	})
+	g.ts += 10
+	g.seq++
+}
+
+// still holds the glove at rest for ms.
+func (g *synthGlove) still(ms int) {
+	for i := 0; i < ms/10; i++ {
+		g.feed(0, [2]float64{})
+	}
+}
+
+// throw swings one punch peaking at force m/s², then rests for a second so
+// it is captured and the next one is past the debounce.
+func (g *synthGlove) throw(punch PunchType, force float64) {
+	for _, f := range punchShape {
+		g.feed(f*force, [2]float64{f * punchRotation[punch][0], f * punchRotation[punch][1]})
+	}
+	g.still(1000)
+}
+
+func TestPunchDetection(t *testing.T) {
+	type throw struct {
+		hand  ble.Hand
+		punch PunchType
+		force float64
+	}
+	tests := []struct {
+		name   string
+		stance string
+		throws []throw
+		left   map[string]int // left glove PunchBreakdown
+		right  map[string]int
+	}{
+		{
+			name:   "straight without stance",
+			throws: []throw{{ble.LeftHand, PunchStraight, 40}},
+			left:   map[string]int{"straight": 1},
+		},
+		{
+			name:   "hook",
+			throws: []throw{{ble.RightHand, PunchHook, 45}},
+			right:  map[string]int{"hook": 1},
+		},
+		{
+			name:   "uppercut",
+			throws: []throw{{ble.LeftHand, PunchUppercut, 45}},
+			left:   map[string]int{"uppercut": 1},
+		},
+		{
+			name:   "below threshold",
+			throws: []throw{{ble.LeftHand, PunchStraight, 15}, {ble.RightHand, PunchHook, 18}},
+		},
+		{
+			name:   "orthodox jab and cross",
+			stance: StanceOrthodox,
+			throws: []throw{{ble.LeftHand, PunchStraight, 35}, {ble.RightHand, PunchStraight, 50}, {ble.LeftHand, PunchStraight, 35}},
+			left:   map[string]int{"jab": 2},
+			right:  map[string]int{"cross": 1},
+		},
+		{
+			name:   "southpaw jab and cross",
+			stance: StanceSouthpaw,
+			throws: []throw{{ble.RightHand, PunchStraight, 35}, {ble.LeftHand, PunchStraight, 50}},
+			left:   map[string]int{"cross": 1},
+			right:  map[string]int{"jab": 1},
+		},
+		{
+			name: "mixed",
+			throws: []throw{
+				{ble.LeftHand, PunchStraight, 40}, {ble.RightHand, PunchHook, 50},
+				{ble.LeftHand, PunchUppercut, 45}, {ble.RightHand, PunchHook, 30},
+			},
+			left:  map[string]int{"straight": 1, "uppercut": 1},
+			right: map[string]int{"hook": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnalyzer()
+			a.SetStance(tt.stance)
+			a.StartSession()
+			gloves := map[ble.Hand]*synthGlove{
+				ble.LeftHand:  newSynthGlove(a, ble.LeftHand),
+				ble.RightHand: newSynthGlove(a, ble.RightHand),
+			}
+			for _, g := range gloves {
+				g.still(4000) // calibrate
+			}
+			for _, th := range tt.throws {
+				gloves[th.hand].throw(th.punch, th.force)
+			}
+
+			state := a.GetState()
+			checkBreakdown(t, "left", state.Left, tt.left)
+			checkBreakdown(t, "right", state.Right, tt.right)
+		})
+	}
+}
+
+func TestPunchDebounce(t *testing.T) {
+	tests := []struct {
+		name  string
+		gapMS int // rest between the two swings
+		want  int
+	}{
+		{name: "within debounce", gapMS: 100, want: 1},
+		{name: "after debounce", gapMS: 600, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnalyzer()
+			a.StartSession()
+			g := newSynthGlove(a, ble.RightHand)
+			g.still(4000)
+			for i := 0; i < 2; i++ {
+				for _, f := range punchShape {
+					g.feed(f*40, [2]float64{f * 20, f * 20})
+				}
+				g.still(tt.gapMS)
+			}
+			g.still(1000)
+
+			if got := a.GetState().Right.PunchCount; got != tt.want {
+				t.Errorf("PunchCount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// checkBreakdown compares a glove's punch counts by type with want.
+func checkBreakdown(t *testing.T, hand string, state *HandState, want map[string]int) {
+	t.Helper()
+	total := 0
+	for punch, n := range want {
+		total += n
+		if got := state.PunchBreakdown[punch]; got != n {
+			t.Errorf("%s %s = %d, want %d (breakdown %v)", hand, punch, got, n, state.PunchBreakdown)
+		}
+	}
+	if state.PunchCount != total {
+		t.Errorf("%s PunchCount = %d, want %d (breakdown %v)", hand, state.PunchCount, total, state.PunchBreakdown)
+	}
+}
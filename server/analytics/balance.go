@@ -0,0 +1,100 @@
+package analytics
+
+import "math"
+
+// Balance tracking constants
+const (
+	DefaultBalanceMinShare = 0.35 // share of punches below which a hand is lagging
+	balanceMinPunches      = 20   // punches before balance warnings are raised
+)
+
+// BalanceStats compares output between the two hands. Ratios are left over
+// right and stay zero while the right hand has nothing to compare against.
+type BalanceStats struct {
+	PunchRatio float64            `json:"punch_ratio"` // left punches / right punches
+	ForceRatio float64            `json:"force_ratio"` // left avg force / right avg force
+	LeftShare  float64            `json:"left_share"`  // left share of all punches (0-1)
+	ByType     map[string]float64 `json:"by_type"`     // left share of each punch type (0-1)
+	Lagging    string             `json:"lagging"`     // hand below the minimum share, "" if balanced
+}
+
+// ImbalanceEvent is the payload of an "imbalance" event.
+type ImbalanceEvent struct {
+	Share    float64 `json:"share"`     // lagging hand's share of punches
+	MinShare float64 `json:"min_share"` // configured minimum share
+	Punches  int     `json:"punches"`   // punches by the lagging hand
+	Total    int     `json:"total"`     // punches by both hands
+}
+
+// SetBalanceThreshold sets the minimum share of punches each hand should
+// throw before an "imbalance" event is raised. Zero disables warnings.
+func (a *Analyzer) SetBalanceThreshold(share float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.balanceMinShare = share
+}
+
+// checkBalanceLocked raises an "imbalance" event when a hand drops below the
+// minimum share. It fires once each time the hand starts lagging.
+// Must be called with a.mu held.
+func (a *Analyzer) checkBalanceLocked() {
+	total := a.left.PunchCount + a.right.PunchCount
+	if a.balanceMinShare <= 0 || total < balanceMinPunches {
+		return
+	}
+
+	hand, count := "left", a.left.PunchCount
+	if a.right.PunchCount < count {
+		hand, count = "right", a.right.PunchCount
+	}
+	share := float64(count) / float64(total)
+
+	if share >= a.balanceMinShare {
+		a.lagging = ""
+		return
+	}
+	if a.lagging == hand {
+		return
+	}
+	a.lagging = hand
+	a.emitLocked("imbalance", hand, ImbalanceEvent{
+		Share:    math.Round(share*1000) / 1000,
+		MinShare: a.balanceMinShare,
+		Punches:  count,
+		Total:    total,
+	})
+}
+
+// balanceStatsLocked computes the current symmetry between hands.
+// Must be called with a.mu held.
+func (a *Analyzer) balanceStatsLocked() BalanceStats {
+	l, r := a.left, a.right
+	b := BalanceStats{ByType: make(map[string]float64), Lagging: a.lagging}
+
+	if r.PunchCount > 0 {
+		b.PunchRatio = round3(float64(l.PunchCount) / float64(r.PunchCount))
+		if r.AvgForce > 0 {
+			b.ForceRatio = round3(l.AvgForce / r.AvgForce)
+		}
+	}
+	if total := l.PunchCount + r.PunchCount; total > 0 {
+		b.LeftShare = round3(float64(l.PunchCount) / float64(total))
+	}
+
+	for t, n := range l.PunchBreakdown {
+		if sum := n + r.PunchBreakdown[t]; sum > 0 {
+			b.ByType[t] = round3(float64(n) / float64(sum))
+		}
+	}
+	for t, n := range r.PunchBreakdown {
+		if _, ok := b.ByType[t]; !ok && n > 0 {
+			b.ByType[t] = 0
+		}
+	}
+	return b
+}
+
+// round3 rounds to three decimal places.
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
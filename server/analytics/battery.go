@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultBatteryThresholds are the battery percentages that raise a
+// "battery_low" event as a glove drains past them.
+var DefaultBatteryThresholds = []int{20, 10}
+
+// batteryRearmMargin is how far above the highest threshold a glove must
+// recover (e.g. a fresh or recharged glove) before its warnings fire again.
+const batteryRearmMargin = 5
+
+// BatteryLowEvent is the payload of a "battery_low" event.
+type BatteryLowEvent struct {
+	Level     uint8 `json:"level"`     // battery percentage
+	Threshold int   `json:"threshold"` // threshold that was crossed
+	Charging  bool  `json:"charging"`
+}
+
+// SetBatteryThresholds sets the battery percentages that raise a
+// "battery_low" event. Each fires once per glove as the battery drops past
+// it; an empty list disables the warnings.
+func (a *Analyzer) SetBatteryThresholds(thresholds []int) error {
+	for _, t := range thresholds {
+		if t <= 0 || t >= 100 {
+			return fmt.Errorf("battery threshold %d must be between 1 and 99", t)
+		}
+	}
+	sorted := append([]int(nil), thresholds...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.batteryThresholds = sorted
+	a.batteryWarned = nil
+	return nil
+}
+
+// checkBatteryLocked raises a "battery_low" event when a hand's battery
+// drops to the next threshold. Warnings re-arm once the glove charges or
+// its battery recovers above the highest threshold.
+// Must be called with a.mu held.
+func (a *Analyzer) checkBatteryLocked(state *HandState, hand string) {
+	if len(a.batteryThresholds) == 0 || !state.Connected {
+		return
+	}
+	if a.batteryWarned == nil {
+		a.batteryWarned = make(map[string]int)
+	}
+
+	level := int(state.Battery)
+	if state.Charging || level >= a.batteryThresholds[0]+batteryRearmMargin {
+		delete(a.batteryWarned, hand)
+		return
+	}
+
+	// Lowest threshold reached that hasn't been warned about yet, so a
+	// reading that skips past several thresholds warns only once
+	crossed := 0
+	warned, ok := a.batteryWarned[hand]
+	for _, t := range a.batteryThresholds {
+		if level <= t && (!ok || t < warned) {
+			crossed = t
+		}
+	}
+	if crossed == 0 {
+		return
+	}
+
+	a.batteryWarned[hand] = crossed
+	a.emitLocked("battery_low", hand, BatteryLowEvent{
+		Level:     state.Battery,
+		Threshold: crossed,
+		Charging:  state.Charging,
+	})
+}
@@ -0,0 +1,199 @@
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// Punch capture constants
+const (
+	motionHistorySize = 50   // samples kept before a detection (500ms at 100Hz)
+	punchCaptureMS    = 60   // ms after detection spent searching for the peak
+	onsetLevel        = 5.0  // m/s² - acceleration where a punch is considered to start
+	samplePeriodMS    = 10.0 // ms between samples at 100Hz
+	retractionMS      = 200  // ms after the peak spent measuring the return
+
+	// Bag contact: an impact stops the glove far harder than the arm can,
+	// then the glove and bag ring for a few cycles
+	contactSpikeMS       = 40   // ms after the peak searched for the impact spike
+	contactDecel         = 30.0 // m/s² - opposing acceleration of a sharp impact
+	contactRingCrossings = 3    // direction reversals that indicate ringing
+	contactRingingShare  = 0.15 // spectrum ringing share that indicates the bag rings
+)
+
+// motionSample is one gravity-compensated reading kept for punch analysis.
+type motionSample struct {
+	ts         int64     // device timestamp (ms)
+	at         time.Time // local receive time
+	mag        float64   // acceleration magnitude above gravity (m/s²)
+	ax, ay, az float64   // gravity-compensated acceleration (m/s²)
+	gx, gy, gz float64   // °/s
+}
+
+// pendingPunch is a detected punch still being captured: first its peak is
+// located, then the return of the glove is followed for retractionMS.
+type pendingPunch struct {
+	detect  motionSample // sample that crossed the threshold
+	onset   motionSample // last sample below onsetLevel before the detection
+	peak    motionSample // highest sample seen so far
+	peakAt  time.Time    // local receive time of the peak
+	swing   [3]float64   // world-frame velocity gained from onset to peak (m/s)
+	maxGyro [3]float64   // peak |rotation| per sensor axis from onset to peak (°/s)
+	lastTS  int64        // timestamp of the last sample added to swing
+
+	peaked     bool       // peak found, now measuring the retraction
+	dir        [3]float64 // unit direction of the punch at its peak
+	last       int64      // timestamp of the last integrated sample
+	velocity   float64    // return velocity integrated since the peak (m/s)
+	retraction float64    // fastest return velocity seen (m/s)
+
+	impact    float64 // strongest opposing acceleration just after the peak (m/s²)
+	crossings int     // sign changes of acceleration along the punch direction
+	lastAlong float64 // previous acceleration along the punch direction
+}
+
+// pushMotion appends a sample to the hand's history.
+func (h *HandState) pushMotion(s motionSample) {
+	h.motion = append(h.motion, s)
+	if len(h.motion) > motionHistorySize {
+		h.motion = h.motion[1:]
+	}
+}
+
+// startPunch begins capturing a punch at the detection sample, locating its
+// onset by walking back through the history.
+func (h *HandState) startPunch(detect motionSample) {
+	p := &pendingPunch{detect: detect, onset: detect, peak: detect, peakAt: detect.at}
+	start := len(h.motion)
+	for i := len(h.motion) - 1; i >= 0; i-- {
+		p.onset = h.motion[i]
+		start = i
+		if h.motion[i].mag < onsetLevel {
+			break
+		}
+	}
+
+	// Integrate the build-up already in the history
+	p.lastTS = p.onset.ts
+	for _, s := range h.motion[min(start+1, len(h.motion)):] {
+		p.addSwing(s)
+	}
+	p.addSwing(detect)
+	h.pending = p
+}
+
+// updatePunch feeds a sample to the pending punch and reports whether the
+// capture is complete. The peak phase ends once the peak has passed or the
+// capture window has elapsed; the retraction phase then runs for retractionMS.
+func (h *HandState) updatePunch(s motionSample) bool {
+	p := h.pending
+	if !p.peaked {
+		if s.mag > p.peak.mag {
+			p.peak = s
+			p.peakAt = s.at
+			p.addSwing(s)
+			if s.ts-p.detect.ts < punchCaptureMS {
+				return false
+			}
+		}
+		p.startRetraction()
+		if s.ts == p.peak.ts {
+			return false
+		}
+	}
+	p.integrateRetraction(s)
+	return s.ts-p.peak.ts >= retractionMS
+}
+
+// addSwing integrates a sample into the swing velocity.
+func (p *pendingPunch) addSwing(s motionSample) {
+	dt := float64(s.ts-p.lastTS) / 1000
+	if dt <= 0 {
+		dt = samplePeriodMS / 1000
+	}
+	p.lastTS = s.ts
+	p.swing[0] += s.ax * dt
+	p.swing[1] += s.ay * dt
+	p.swing[2] += s.az * dt
+
+	p.maxGyro[0] = math.Max(p.maxGyro[0], math.Abs(s.gx))
+	p.maxGyro[1] = math.Max(p.maxGyro[1], math.Abs(s.gy))
+	p.maxGyro[2] = math.Max(p.maxGyro[2], math.Abs(s.gz))
+}
+
+// trajectory describes the swing's direction of travel in the world frame.
+func (p *pendingPunch) trajectory() Trajectory {
+	h := math.Hypot(p.swing[0], p.swing[1])
+	v := p.swing[2]
+	return Trajectory{
+		Horizontal: math.Round(h*100) / 100,
+		Vertical:   math.Round(v*100) / 100,
+		Elevation:  math.Round(math.Atan2(v, h)/degToRad*10) / 10,
+	}
+}
+
+// startRetraction fixes the punch direction from its peak sample.
+func (p *pendingPunch) startRetraction() {
+	p.peaked = true
+	p.last = p.peak.ts
+	if p.peak.mag > 0 {
+		p.dir = [3]float64{p.peak.ax / p.peak.mag, p.peak.ay / p.peak.mag, p.peak.az / p.peak.mag}
+	}
+}
+
+// integrateRetraction accumulates acceleration against the punch direction.
+// After impact the glove decelerates and is pulled back, so the integral of
+// the opposing component approximates the return speed.
+func (p *pendingPunch) integrateRetraction(s motionSample) {
+	dt := float64(s.ts-p.last) / 1000
+	if dt <= 0 {
+		dt = samplePeriodMS / 1000
+	}
+	p.last = s.ts
+
+	along := s.ax*p.dir[0] + s.ay*p.dir[1] + s.az*p.dir[2]
+	p.velocity -= along * dt
+	p.retraction = math.Max(p.retraction, p.velocity)
+
+	if s.ts-p.peak.ts <= contactSpikeMS {
+		p.impact = math.Max(p.impact, -along)
+	}
+	if math.Abs(along) > onsetLevel {
+		if p.lastAlong != 0 && (along > 0) != (p.lastAlong > 0) {
+			p.crossings++
+		}
+		p.lastAlong = along
+	}
+}
+
+// contact reports whether the punch hit something rather than the air:
+// either a sharp impact spike, or a softer one followed by ringing. The
+// ringing shows in the direction reversals after the peak or, with spectrum
+// analysis on, in the hand's ringing share (0 = not analysed).
+func (p *pendingPunch) contact(ringingShare float64) bool {
+	if p.impact >= contactDecel {
+		return true
+	}
+	if p.impact < contactDecel/2 {
+		return false
+	}
+	return p.crossings >= contactRingCrossings || ringingShare >= contactRingingShare
+}
+
+// durationMS returns the length of the acceleration phase, onset to peak.
+func (p *pendingPunch) durationMS() int64 {
+	if d := p.peak.ts - p.onset.ts; d > 0 {
+		return d
+	}
+	return int64(samplePeriodMS)
+}
+
+// rfd returns the rate of force development of a captured punch: the slope
+// of the acceleration rise from onset to peak, in m/s³.
+func (p *pendingPunch) rfd() float64 {
+	dt := float64(p.peak.ts - p.onset.ts)
+	if dt <= 0 {
+		dt = samplePeriodMS
+	}
+	return math.Max(0, (p.peak.mag-p.onset.mag)/(dt/1000))
+}
@@ -0,0 +1,101 @@
+package analytics
+
+import "math"
+
+// Features summarise a captured punch for classification. Gyro values are
+// taken at the detection sample; maxima cover the swing from onset to peak.
+type Features struct {
+	GX, GY, GZ float64 // °/s at detection
+	UpAxis     int     // axis pointing up at calibration (0=X, 1=Y, 2=Z)
+
+	GyroUp         float64 // |rotation| about the up axis at detection (°/s)
+	GyroHorizontal float64 // max |rotation| about the horizontal axes at detection (°/s)
+	MaxGyroUp      float64 // peak |rotation| about the up axis during the swing
+	MaxGyroHoriz   float64 // peak |rotation| about the horizontal axes during the swing
+
+	Force      float64 // m/s²
+	RFD        float64 // m/s³
+	DurationMS float64 // onset to peak
+	Lift       float64 // vertical share of the peak acceleration (-1 to 1)
+	Trajectory Trajectory
+}
+
+// Classifier assigns a punch type to a captured punch. Straights are
+// refined into jabs and crosses afterwards, so classifiers may return
+// PunchStraight, PunchJab or PunchCross.
+type Classifier interface {
+	Classify(f Features) PunchType
+}
+
+// HeuristicClassifier is the built-in gyro-threshold classifier. Zero
+// thresholds take the heavy bag's.
+type HeuristicClassifier struct {
+	HookGyro        float64 // °/s - rotation around "up" axis for a hook
+	UppercutGyro    float64 // °/s - rotation around the horizontal axes for an uppercut
+	StraightGyroMax float64 // °/s - max rotation for a straight
+}
+
+// Classify applies the gyro thresholds, then reclassifies straights that
+// travel mostly upward as uppercuts.
+func (c HeuristicClassifier) Classify(f Features) PunchType {
+	if c.HookGyro == 0 {
+		c.HookGyro = hookGyroThresh
+	}
+	if c.UppercutGyro == 0 {
+		c.UppercutGyro = uppercutGyroThresh
+	}
+	if c.StraightGyroMax == 0 {
+		c.StraightGyroMax = straightGyroMax
+	}
+	t := classifyPunch(f.GX, f.GY, f.GZ, f.UpAxis, c)
+	if t == PunchStraight && f.Lift > uppercutLiftShare {
+		return PunchUppercut
+	}
+	return t
+}
+
+// SetClassifier replaces the punch classifier. nil restores the heuristic,
+// with the gyro thresholds of the session type.
+func (a *Analyzer) SetClassifier(c Classifier) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.classifier = c
+}
+
+// Classifier returns the punch classifier set with SetClassifier, nil for
+// the heuristic.
+func (a *Analyzer) Classifier() Classifier {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.classifier
+}
+
+// punchFeatures extracts classification features from a captured punch.
+func punchFeatures(p *pendingPunch, upAxis int) Features {
+	f := Features{
+		GX: p.detect.gx, GY: p.detect.gy, GZ: p.detect.gz,
+		UpAxis:     upAxis,
+		Force:      p.peak.mag,
+		RFD:        p.rfd(),
+		DurationMS: float64(p.durationMS()),
+		Trajectory: p.trajectory(),
+	}
+	f.GyroUp, f.GyroHorizontal = splitRotation(math.Abs(p.detect.gx), math.Abs(p.detect.gy), math.Abs(p.detect.gz), upAxis)
+	f.MaxGyroUp, f.MaxGyroHoriz = splitRotation(p.maxGyro[0], p.maxGyro[1], p.maxGyro[2], upAxis)
+	if p.peak.mag > 0 {
+		f.Lift = p.peak.az / p.peak.mag
+	}
+	return f
+}
+
+// splitRotation separates absolute rotation rates into the component about
+// the up axis and the largest component about the horizontal axes.
+func splitRotation(x, y, z float64, upAxis int) (up, horizontal float64) {
+	switch upAxis {
+	case 0:
+		return x, math.Max(y, z)
+	case 1:
+		return y, math.Max(x, z)
+	}
+	return z, math.Max(x, y)
+}
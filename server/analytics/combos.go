@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"strings"
+	"time"
+)
+
+// Combination detection constants
+const (
+	comboWindow     = 800 * time.Millisecond // max gap between punches in a combo
+	comboMinPunches = 2                      // punches needed to count as a combo
+)
+
+// ComboEvent is the payload of a "combo" event.
+type ComboEvent struct {
+	Sequence   string       `json:"sequence"` // punch types joined by "-", e.g. "jab-cross-hook"
+	Punches    []PunchEvent `json:"punches"`
+	DurationMS int64        `json:"duration_ms"` // first to last punch
+	Count      int          `json:"count"`       // combo number in session
+}
+
+// comboTracker correlates punches from both hands into combinations.
+// Gloves have independent clocks, so punches are correlated by server time.
+type comboTracker struct {
+	current   []PunchEvent
+	startedAt time.Time
+	lastAt    time.Time
+
+	count     int
+	longest   int
+	breakdown map[string]int
+}
+
+// newComboTracker creates an empty tracker.
+func newComboTracker() *comboTracker {
+	return &comboTracker{breakdown: make(map[string]int)}
+}
+
+// recordComboPunchLocked adds a punch to the combo in progress, closing the
+// previous combo first if the gap since its last punch is too long.
+// Must be called with a.mu held.
+func (a *Analyzer) recordComboPunchLocked(event PunchEvent, now time.Time) {
+	c := a.combos
+	if len(c.current) > 0 && now.Sub(c.lastAt) > comboWindow {
+		a.finishComboLocked()
+	}
+	if len(c.current) == 0 {
+		c.startedAt = now
+	}
+	c.current = append(c.current, event)
+	c.lastAt = now
+}
+
+// flushCombosLocked closes the combo in progress once no punch has followed
+// within the combo window.
+// Must be called with a.mu held.
+func (a *Analyzer) flushCombosLocked(now time.Time) {
+	c := a.combos
+	if len(c.current) > 0 && now.Sub(c.lastAt) > comboWindow {
+		a.finishComboLocked()
+	}
+}
+
+// finishComboLocked records the combo in progress if it is long enough and
+// emits a "combo" event.
+// Must be called with a.mu held.
+func (a *Analyzer) finishComboLocked() {
+	c := a.combos
+	punches := c.current
+	c.current = nil
+	if len(punches) < comboMinPunches {
+		return
+	}
+
+	types := make([]string, len(punches))
+	for i, p := range punches {
+		types[i] = string(p.Type)
+	}
+	sequence := strings.Join(types, "-")
+
+	c.count++
+	c.breakdown[sequence]++
+	if len(punches) > c.longest {
+		c.longest = len(punches)
+	}
+
+	a.emitLocked("combo", "", ComboEvent{
+		Sequence:   sequence,
+		Punches:    punches,
+		DurationMS: c.lastAt.Sub(c.startedAt).Milliseconds(),
+		Count:      c.count,
+	})
+	a.broadcastLocked()
+}
@@ -0,0 +1,98 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"boxing-analytics/ble"
+)
+
+// timedPunch is a punch registered offset after the session started.
+type timedPunch struct {
+	offset time.Duration
+	hand   ble.Hand
+	punch  PunchType
+}
+
+// countPunches registers punches as the detector would, then closes any
+// combo and flurry still in progress.
+func countPunches(a *Analyzer, punches []timedPunch) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var last time.Time
+	for _, p := range punches {
+		state := a.left
+		if p.hand == ble.RightHand {
+			state = a.right
+		}
+		last = a.startedAt.Add(p.offset)
+		a.countPunchLocked(state, PunchEvent{Hand: p.hand.String(), Type: p.punch}, 40, 2000, last)
+	}
+	a.flushCombosLocked(last.Add(time.Second))
+	a.flushFlurriesLocked(last.Add(time.Second))
+}
+
+func TestComboDetection(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name      string
+		punches   []timedPunch
+		count     int
+		longest   int
+		breakdown map[string]int
+	}{
+		{
+			name:    "single punch",
+			punches: []timedPunch{{0, ble.LeftHand, PunchJab}},
+		},
+		{
+			name: "one-two",
+			punches: []timedPunch{
+				{0, ble.LeftHand, PunchJab}, {400 * ms, ble.RightHand, PunchCross},
+			},
+			count: 1, longest: 2, breakdown: map[string]int{"jab-cross": 1},
+		},
+		{
+			name: "three punch combo",
+			punches: []timedPunch{
+				{0, ble.LeftHand, PunchJab}, {300 * ms, ble.RightHand, PunchCross}, {1000 * ms, ble.LeftHand, PunchHook},
+			},
+			count: 1, longest: 3, breakdown: map[string]int{"jab-cross-hook": 1},
+		},
+		{
+			name: "gap splits combos",
+			punches: []timedPunch{
+				{0, ble.LeftHand, PunchJab}, {500 * ms, ble.RightHand, PunchCross},
+				{1400 * ms, ble.LeftHand, PunchJab}, {1900 * ms, ble.RightHand, PunchCross},
+				{3000 * ms, ble.LeftHand, PunchUppercut},
+			},
+			count: 2, longest: 2, breakdown: map[string]int{"jab-cross": 2},
+		},
+		{
+			name: "punches too far apart",
+			punches: []timedPunch{
+				{0, ble.LeftHand, PunchJab}, {900 * ms, ble.LeftHand, PunchJab}, {1800 * ms, ble.LeftHand, PunchJab},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnalyzer()
+			a.StartSession()
+			countPunches(a, tt.punches)
+
+			combined := a.GetState().Combined
+			if combined.Combos != tt.count || combined.LongestCombo != tt.longest {
+				t.Errorf("combos = %d, longest %d, want %d, longest %d", combined.Combos, combined.LongestCombo, tt.count, tt.longest)
+			}
+			if len(combined.ComboBreakdown) != len(tt.breakdown) {
+				t.Errorf("breakdown = %v, want %v", combined.ComboBreakdown, tt.breakdown)
+			}
+			for sequence, n := range tt.breakdown {
+				if combined.ComboBreakdown[sequence] != n {
+					t.Errorf("breakdown = %v, want %v", combined.ComboBreakdown, tt.breakdown)
+				}
+			}
+		})
+	}
+}
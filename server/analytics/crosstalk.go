@@ -0,0 +1,45 @@
+package analytics
+
+import "time"
+
+// Cross-hand suppression constants
+const (
+	crosstalkWindow     = 50 * time.Millisecond // max peak separation between hands
+	crosstalkForceRatio = 2.0                   // the real hit must be this much stronger
+	swingMinMS          = 30                    // onset to peak of a genuine punch
+)
+
+// isCrosstalkLocked reports whether a captured punch is really the shock of
+// a much stronger hit on the other hand. A hard bag impact shakes both
+// gloves, but only the punching hand swings up to its peak; the idle hand
+// sees an abrupt spike with no preceding build-up.
+// Must be called with a.mu held.
+func (a *Analyzer) isCrosstalkLocked(state *HandState, p *pendingPunch) bool {
+	if p.durationMS() >= swingMinMS {
+		return false
+	}
+
+	other := a.right
+	if state == a.right {
+		other = a.left
+	}
+
+	// A hit already registered on the other hand
+	if within(p.peakAt, other.lastPeakAt) && other.lastPeakForce >= crosstalkForceRatio*p.peak.mag {
+		return true
+	}
+	// A hit on the other hand still being captured
+	if q := other.pending; q != nil && within(p.peakAt, q.peakAt) && q.peak.mag >= crosstalkForceRatio*p.peak.mag {
+		return true
+	}
+	return false
+}
+
+// within reports whether two local times fall inside the crosstalk window.
+func within(a, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return false
+	}
+	d := a.Sub(b)
+	return d > -crosstalkWindow && d < crosstalkWindow
+}
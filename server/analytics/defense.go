@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"math"
+	"time"
+
+	"boxing-analytics/ble"
+)
+
+// Defensive moves
+const (
+	DefenseSlip  = "slip"  // head moved sideways off the line of a punch
+	DefenseRoll  = "roll"  // ducked under a punch and came back up
+	DefenseBlock = "block" // punch stopped by the guard
+)
+
+// Defensive movement constants. Both gloves stay in guard and move with
+// the head, so a slip or roll shows up on both at once.
+const (
+	slipAccel       = 8.0                    // m/s² - horizontal push of the guard in a slip
+	rollAccel       = 6.0                    // m/s² - vertical acceleration into and out of a roll
+	rollMaxDuration = time.Second            // longest dip from going down to coming back up
+	defensePairGap  = 150 * time.Millisecond // max offset between the two gloves' movement
+	defenseCooldown = 600 * time.Millisecond // min time between slips and rolls
+)
+
+// DefenseStats counts the defensive moves of a defense drill.
+type DefenseStats struct {
+	Total     int            `json:"total"`
+	Breakdown map[string]int `json:"breakdown"` // move → count
+}
+
+// DefenseEvent is the payload of a "defense" event.
+type DefenseEvent struct {
+	Move  string `json:"move"`  // DefenseSlip, DefenseRoll or DefenseBlock
+	Count int    `json:"count"` // moves of this kind in the session
+}
+
+// defenseHand is one glove's part in the current slip or roll.
+type defenseHand struct {
+	lateralAt time.Time // last sideways push of the guard
+	dipAt     time.Time // glove started going down (zero = not dipping)
+	riseAt    time.Time // glove came back up out of a dip
+}
+
+// defenseTracker pairs up the movement of both gloves into slips and rolls
+// and counts defensive moves.
+type defenseTracker struct {
+	hands  [2]defenseHand
+	lastAt time.Time // last slip or roll
+	counts map[string]int
+	total  int
+}
+
+// trackDefenseLocked follows a glove's guard movement and records a slip or
+// roll once the other glove has made the same movement. Movement while
+// either glove punches or has dropped its guard is ignored.
+// Must be called with a.mu held.
+func (a *Analyzer) trackDefenseLocked(hand ble.Hand, state *HandState, s motionSample) {
+	d := &a.defense
+	h := &d.hands[hand]
+	if a.left.pending != nil || a.right.pending != nil || guardTilt(state.fusion.q, state.GravityRef) >= guardDropAngle {
+		*h = defenseHand{}
+		return
+	}
+
+	horizontal := math.Hypot(s.ax, s.ay)
+	if horizontal > slipAccel && math.Abs(s.az) < horizontal/2 {
+		h.lateralAt = s.at
+	}
+	switch {
+	case s.az < -rollAccel && h.dipAt.IsZero():
+		h.dipAt = s.at
+	case s.az > rollAccel && !h.dipAt.IsZero():
+		if s.at.Sub(h.dipAt) <= rollMaxDuration {
+			h.riseAt = s.at
+		}
+		h.dipAt = time.Time{}
+	case !h.dipAt.IsZero() && s.at.Sub(h.dipAt) > rollMaxDuration:
+		h.dipAt = time.Time{}
+	}
+
+	if s.at.Sub(d.lastAt) < defenseCooldown {
+		return
+	}
+	other := &d.hands[1-hand]
+	switch {
+	case h.riseAt.Equal(s.at) && paired(h.riseAt, other.riseAt):
+		a.recordDefenseLocked(DefenseRoll, "", s.at)
+	case h.lateralAt.Equal(s.at) && paired(h.lateralAt, other.lateralAt):
+		a.recordDefenseLocked(DefenseSlip, "", s.at)
+	}
+}
+
+// paired reports whether both gloves moved within defensePairGap.
+func paired(a, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return false
+	}
+	d := a.Sub(b)
+	return d > -defensePairGap && d < defensePairGap
+}
+
+// isBlockLocked reports whether a captured punch is really a blow stopped
+// by the guard: in a defense drill, a spike with no swing behind it on a
+// glove held in guard.
+// Must be called with a.mu held.
+func (a *Analyzer) isBlockLocked(state *HandState, p *pendingPunch) bool {
+	return a.detection.Defense && p.durationMS() < swingMinMS &&
+		guardTilt(state.fusion.q, state.GravityRef) < guardDropAngle
+}
+
+// recordDefenseLocked counts a defensive move and emits a "defense" event.
+// Slips and rolls involve both gloves and have no hand.
+// Must be called with a.mu held.
+func (a *Analyzer) recordDefenseLocked(move, hand string, at time.Time) {
+	d := &a.defense
+	if d.counts == nil {
+		d.counts = make(map[string]int)
+	}
+	d.counts[move]++
+	d.total++
+	if move != DefenseBlock {
+		d.lastAt = at
+		d.hands = [2]defenseHand{}
+	}
+	a.emitLocked("defense", hand, DefenseEvent{Move: move, Count: d.counts[move]})
+	a.broadcastLocked()
+}
+
+// stats returns the counts for SessionState.
+func (d *defenseTracker) stats() DefenseStats {
+	breakdown := make(map[string]int, len(d.counts))
+	for move, n := range d.counts {
+		breakdown[move] = n
+	}
+	return DefenseStats{Total: d.total, Breakdown: breakdown}
+}
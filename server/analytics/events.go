@@ -0,0 +1,40 @@
+package analytics
+
+import "time"
+
+// Event is a discrete notification broadcast alongside state snapshots.
+// Type identifies the payload carried in Data (e.g. "alert").
+type Event struct {
+	Seq       uint64      `json:"seq,omitempty"` // numbered by the hub as it is broadcast
+	Type      string      `json:"type"`
+	Hand      string      `json:"hand,omitempty"`
+	Side      string      `json:"side,omitempty"` // athlete "a" or "b" in sparring mode
+	Timestamp int64       `json:"ts"`             // server time, unix ms
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// EventHandler is called for every emitted event.
+type EventHandler func(event *Event)
+
+// SetEventHandler sets the callback for discrete analytics events.
+func (a *Analyzer) SetEventHandler(handler EventHandler) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onEvent = handler
+}
+
+// emitLocked sends an event to the handler.
+// Must be called with a.mu held.
+func (a *Analyzer) emitLocked(eventType, hand string, data interface{}) {
+	if a.onEvent == nil {
+		return
+	}
+	event := &Event{
+		Type:      eventType,
+		Hand:      hand,
+		Timestamp: time.Now().UnixMilli(),
+		Data:      data,
+	}
+	// Call handler outside of lock to prevent deadlocks
+	go a.onEvent(event)
+}
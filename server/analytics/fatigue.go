@@ -0,0 +1,111 @@
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// Fatigue tracking constants
+const (
+	fatigueWindow    = 20               // punches in the baseline and recent windows
+	fatigueRateSpan  = 60 * time.Second // span used for the current punch rate
+	fatigueMaxChange = 100.0            // % cap for each component of the index
+)
+
+// FatigueStats describes how a hand's output has fallen off compared with the
+// start of the session. Declines are positive when output drops.
+type FatigueStats struct {
+	Index            float64 `json:"index"`             // 0 (fresh) to 100 (spent)
+	ForceDecline     float64 `json:"force_decline"`     // % drop in average force
+	PPMDecline       float64 `json:"ppm_decline"`       // % drop in punch rate
+	IntervalIncrease float64 `json:"interval_increase"` // % growth in time between punches
+}
+
+// punchSample is one punch as seen by the fatigue tracker.
+type punchSample struct {
+	at    time.Time
+	force float64
+}
+
+// fatigueTracker compares a hand's first punches with its most recent ones.
+type fatigueTracker struct {
+	baseline []punchSample // first fatigueWindow punches of the session
+	recent   []punchSample // last punches, covering fatigueWindow and fatigueRateSpan
+}
+
+// record adds a punch to the tracker.
+func (f *fatigueTracker) record(at time.Time, force float64) {
+	sample := punchSample{at: at, force: force}
+	if len(f.baseline) < fatigueWindow {
+		f.baseline = append(f.baseline, sample)
+	}
+	f.recent = append(f.recent, sample)
+
+	// Drop samples no longer needed for either recent window
+	for len(f.recent) > fatigueWindow && at.Sub(f.recent[0].at) > fatigueRateSpan {
+		f.recent = f.recent[1:]
+	}
+}
+
+// stats computes fatigue at time now. All values stay zero until enough
+// punches exist for disjoint baseline and recent windows.
+func (f *fatigueTracker) stats(punchCount int, now time.Time) FatigueStats {
+	var s FatigueStats
+	if punchCount < 2*fatigueWindow || len(f.recent) < fatigueWindow {
+		return s
+	}
+
+	baseForce, baseGap := windowAverages(f.baseline)
+	recentForce, recentGap := windowAverages(f.recent[len(f.recent)-fatigueWindow:])
+
+	if baseForce > 0 {
+		s.ForceDecline = (baseForce - recentForce) / baseForce * 100
+	}
+	if baseGap > 0 {
+		s.IntervalIncrease = (recentGap - baseGap) / baseGap * 100
+
+		// Current rate counts idle time too, so stopping shows up immediately
+		basePPM := 60 / baseGap
+		recentCount := 0
+		for _, p := range f.recent {
+			if now.Sub(p.at) <= fatigueRateSpan {
+				recentCount++
+			}
+		}
+		span := math.Min(now.Sub(f.baseline[0].at).Seconds(), fatigueRateSpan.Seconds())
+		if span > 0 {
+			currentPPM := float64(recentCount) / span * 60
+			s.PPMDecline = (basePPM - currentPPM) / basePPM * 100
+		}
+	}
+
+	index := 0.5*clampPercent(s.ForceDecline) +
+		0.25*clampPercent(s.PPMDecline) +
+		0.25*clampPercent(s.IntervalIncrease)
+
+	s.Index = math.Round(index*10) / 10
+	s.ForceDecline = math.Round(s.ForceDecline*10) / 10
+	s.PPMDecline = math.Round(s.PPMDecline*10) / 10
+	s.IntervalIncrease = math.Round(s.IntervalIncrease*10) / 10
+	return s
+}
+
+// windowAverages returns the mean force and mean gap (seconds) of samples.
+func windowAverages(samples []punchSample) (force, gap float64) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	for _, p := range samples {
+		force += p.force
+	}
+	force /= float64(len(samples))
+	if len(samples) > 1 {
+		gap = samples[len(samples)-1].at.Sub(samples[0].at).Seconds() / float64(len(samples)-1)
+	}
+	return force, gap
+}
+
+// clampPercent limits a fatigue component to [0, fatigueMaxChange].
+func clampPercent(v float64) float64 {
+	return math.Max(0, math.Min(v, fatigueMaxChange))
+}
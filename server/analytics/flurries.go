@@ -0,0 +1,95 @@
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// Flurry detection constants
+const (
+	flurryMaxGap     = 400 * time.Millisecond // max gap between punches in a flurry
+	flurryMinPunches = 5                      // punches needed to count as a flurry
+)
+
+// FlurryEvent is the payload of a "flurry" event.
+type FlurryEvent struct {
+	Punches    int     `json:"punches"`
+	DurationMS int64   `json:"duration_ms"`
+	Rate       float64 `json:"rate"`       // punches per second
+	StartedAt  int64   `json:"started_at"` // server time, unix ms
+	EndedAt    int64   `json:"ended_at"`   // server time, unix ms
+	Count      int     `json:"count"`      // flurry number in session
+}
+
+// flurryTracker clusters punches from both hands into high-output bursts.
+type flurryTracker struct {
+	punches   int // punches in the run in progress
+	startedAt time.Time
+	lastAt    time.Time
+
+	count   int
+	longest int
+	maxRate float64
+}
+
+// recordFlurryPunchLocked extends the run in progress, closing the previous
+// run first if the gap since its last punch is too long.
+// Must be called with a.mu held.
+func (a *Analyzer) recordFlurryPunchLocked(now time.Time) {
+	f := &a.flurries
+	if f.punches > 0 && now.Sub(f.lastAt) > flurryMaxGap {
+		a.finishFlurryLocked()
+	}
+	if f.punches == 0 {
+		f.startedAt = now
+	}
+	f.punches++
+	f.lastAt = now
+}
+
+// flushFlurriesLocked closes the run in progress once no punch has followed
+// within the flurry gap.
+// Must be called with a.mu held.
+func (a *Analyzer) flushFlurriesLocked(now time.Time) {
+	f := &a.flurries
+	if f.punches > 0 && now.Sub(f.lastAt) > flurryMaxGap {
+		a.finishFlurryLocked()
+	}
+}
+
+// finishFlurryLocked records the run in progress if it is a flurry and emits
+// a "flurry" event.
+// Must be called with a.mu held.
+func (a *Analyzer) finishFlurryLocked() {
+	f := &a.flurries
+	punches := f.punches
+	f.punches = 0
+	if punches < flurryMinPunches {
+		return
+	}
+
+	duration := f.lastAt.Sub(f.startedAt)
+	rate := 0.0
+	if duration > 0 {
+		// punches-1 intervals span the duration
+		rate = math.Round(float64(punches-1)/duration.Seconds()*100) / 100
+	}
+
+	f.count++
+	if punches > f.longest {
+		f.longest = punches
+	}
+	if rate > f.maxRate {
+		f.maxRate = rate
+	}
+
+	a.emitLocked("flurry", "", FlurryEvent{
+		Punches:    punches,
+		DurationMS: duration.Milliseconds(),
+		Rate:       rate,
+		StartedAt:  f.startedAt.UnixMilli(),
+		EndedAt:    f.lastAt.UnixMilli(),
+		Count:      f.count,
+	})
+	a.broadcastLocked()
+}
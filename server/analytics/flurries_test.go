@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"boxing-analytics/ble"
+)
+
+// burst returns n punches alternating hands every gap, from start.
+func burst(start time.Duration, n int, gap time.Duration) []timedPunch {
+	punches := make([]timedPunch, n)
+	for i := range punches {
+		hand := ble.LeftHand
+		if i%2 == 1 {
+			hand = ble.RightHand
+		}
+		punches[i] = timedPunch{start + time.Duration(i)*gap, hand, PunchStraight}
+	}
+	return punches
+}
+
+func TestFlurryDetection(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name    string
+		punches []timedPunch
+		count   int
+		longest int
+		rate    float64
+	}{
+		{name: "too few punches", punches: burst(0, 4, 200*ms)},
+		{name: "five punches", punches: burst(0, 5, 250*ms), count: 1, longest: 5, rate: 4},
+		{name: "gaps too long", punches: burst(0, 8, 450*ms)},
+		{
+			name:    "two flurries",
+			punches: append(burst(0, 6, 200*ms), burst(3*time.Second, 5, 300*ms)...),
+			count:   2, longest: 6, rate: 5,
+		},
+		{
+			name:    "gap breaks the run",
+			punches: append(burst(0, 3, 200*ms), burst(900*ms, 5, 400*ms)...),
+			count:   1, longest: 5, rate: 2.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnalyzer()
+			a.StartSession()
+			countPunches(a, tt.punches)
+
+			combined := a.GetState().Combined
+			if combined.Flurries != tt.count || combined.LongestFlurry != tt.longest || combined.MaxBurstRate != tt.rate {
+				t.Errorf("flurries = %d, longest %d, rate %v, want %d, longest %d, rate %v",
+					combined.Flurries, combined.LongestFlurry, combined.MaxBurstRate, tt.count, tt.longest, tt.rate)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultForceBuckets are the edges (m/s²) between the bars of the force
+// histogram: under 20, 20-30, ..., 80-100 and 100 or more.
+var DefaultForceBuckets = []float64{20, 30, 40, 50, 60, 80, 100}
+
+// maxForceBuckets bounds the number of edges.
+const maxForceBuckets = 50
+
+// ForceBucket is one bar of a force histogram: punches of at least From and
+// under To m/s².
+type ForceBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to,omitempty"` // 0 = no upper bound
+	Count int     `json:"count"`
+}
+
+// SetForceBuckets sets the edges of the force histogram, in m/s². They are
+// sorted, must be positive and distinct, and the session's punches so far
+// are counted again.
+func (a *Analyzer) SetForceBuckets(edges []float64) error {
+	if len(edges) == 0 || len(edges) > maxForceBuckets {
+		return fmt.Errorf("force histogram needs 1 to %d bucket edges", maxForceBuckets)
+	}
+	sorted := append([]float64(nil), edges...)
+	sort.Float64s(sorted)
+	for i, e := range sorted {
+		if e <= 0 || (i > 0 && e == sorted[i-1]) {
+			return fmt.Errorf("invalid force bucket edge %v: must be positive and distinct", e)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.forceEdges = sorted
+	for _, state := range []*HandState{a.left, a.right} {
+		state.forceCounts = make([]int, len(sorted)+1)
+	}
+	for _, p := range a.punches {
+		state := a.left
+		if p.Hand == "right" {
+			state = a.right
+		}
+		state.forceCounts[a.forceBucketLocked(p.Force)]++
+	}
+	a.broadcastLocked()
+	return nil
+}
+
+// countForceLocked adds a punch force to the hand's histogram.
+// Must be called with a.mu held.
+func (a *Analyzer) countForceLocked(state *HandState, force float64) {
+	if len(state.forceCounts) != len(a.forceEdges)+1 {
+		state.forceCounts = make([]int, len(a.forceEdges)+1)
+	}
+	state.forceCounts[a.forceBucketLocked(force)]++
+}
+
+// forceBucketLocked returns the index of the bucket a force falls into.
+// Must be called with a.mu held.
+func (a *Analyzer) forceBucketLocked(force float64) int {
+	return sort.Search(len(a.forceEdges), func(i int) bool { return a.forceEdges[i] > force })
+}
+
+// forceHistogramLocked returns the histogram of counts, one per bucket.
+// Must be called with a.mu held.
+func (a *Analyzer) forceHistogramLocked(counts ...[]int) []ForceBucket {
+	buckets := make([]ForceBucket, len(a.forceEdges)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].From = a.forceEdges[i-1]
+		}
+		if i < len(a.forceEdges) {
+			buckets[i].To = a.forceEdges[i]
+		}
+		for _, c := range counts {
+			if i < len(c) {
+				buckets[i].Count += c[i]
+			}
+		}
+	}
+	return buckets
+}
@@ -0,0 +1,215 @@
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// Heart-rate analysis constants
+const (
+	DefaultMaxHeartRate = 190             // BPM, used when the profile sets none
+	DefaultRoundLength  = 3 * time.Minute // standard boxing round
+	hrZoneCount         = 5
+	hrZoneFloor         = 0.5             // zone 1 starts at 50% of max HR, each zone spans 10%
+	hrMaxGap            = 5 * time.Second // longer gaps between readings are not counted as time in zone
+	hrMinRounds         = 3               // rounds needed before HR and output are correlated
+)
+
+// HeartRateSample is one strap reading.
+type HeartRateSample struct {
+	T   int64 `json:"t"` // ms since session start
+	BPM int   `json:"bpm"`
+}
+
+// RoundStats pairs a round's heart rate with its punch output.
+type RoundStats struct {
+	Round    int     `json:"round"` // 1-based
+	AvgBPM   float64 `json:"avg_bpm"`
+	MaxBPM   int     `json:"max_bpm"`
+	Punches  int     `json:"punches"`
+	AvgForce float64 `json:"avg_force"`
+}
+
+// HeartRateStats summarises the session's heart rate.
+type HeartRateStats struct {
+	MaxHR       int                  `json:"max_hr"` // max heart rate the zones derive from
+	Zone        int                  `json:"zone"`   // current zone 1-5 (0 = below zone 1 or no reading)
+	AvgBPM      float64              `json:"avg_bpm"`
+	PeakBPM     int                  `json:"peak_bpm"`
+	TimeInZone  [hrZoneCount]float64 `json:"time_in_zone_sec"` // index 0 = zone 1
+	Rounds      []RoundStats         `json:"rounds"`
+	Correlation float64              `json:"output_correlation"` // Pearson r of round avg BPM vs punches (0 until hrMinRounds)
+}
+
+// roundAccum accumulates one round's readings and punches.
+type roundAccum struct {
+	bpmSum   float64
+	bpmN     int
+	maxBPM   int
+	punches  int
+	forceSum float64
+}
+
+// heartRateTracker holds the session's heart-rate trace and zone totals.
+type heartRateTracker struct {
+	trace      []HeartRateSample
+	timeInZone [hrZoneCount]float64
+	sum        float64
+	peak       int
+	rounds     []roundAccum
+}
+
+// SetHeartRate records the latest reading from the heart-rate strap; 0
+// means no strap is connected. It is broadcast with the next tick.
+func (a *Analyzer) SetHeartRate(bpm int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.heartRate = bpm
+	if a.active && !a.paused && bpm > 0 {
+		a.hr.add(time.Since(a.startedAt), bpm, a.maxHeartRateLocked(), a.roundLength)
+	}
+}
+
+// SetMaxHeartRate sets the current athlete's max heart rate for zone
+// calculation (0 = DefaultMaxHeartRate).
+func (a *Analyzer) SetMaxHeartRate(bpm int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.maxHeartRate = bpm
+}
+
+// SetRoundLength sets the round length HR and output are compared over.
+func (a *Analyzer) SetRoundLength(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.roundLength = d
+}
+
+// HeartRateTrace returns every heart-rate reading of the current session.
+func (a *Analyzer) HeartRateTrace() []HeartRateSample {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	trace := make([]HeartRateSample, len(a.hr.trace))
+	copy(trace, a.hr.trace)
+	return trace
+}
+
+// maxHeartRateLocked returns the max HR zones are based on.
+// Must be called with a.mu held.
+func (a *Analyzer) maxHeartRateLocked() int {
+	if a.maxHeartRate > 0 {
+		return a.maxHeartRate
+	}
+	return DefaultMaxHeartRate
+}
+
+// heartRateZone returns the 1-5 zone of bpm, or 0 below zone 1.
+func heartRateZone(bpm, maxHR int) int {
+	share := float64(bpm) / float64(maxHR)
+	if share < hrZoneFloor {
+		return 0
+	}
+	return min(hrZoneCount, int((share-hrZoneFloor)*10)+1)
+}
+
+// round returns the accumulator for the round containing elapsed.
+func (t *heartRateTracker) round(elapsed, length time.Duration) *roundAccum {
+	i := int(elapsed / length)
+	for len(t.rounds) <= i {
+		t.rounds = append(t.rounds, roundAccum{})
+	}
+	return &t.rounds[i]
+}
+
+// add records a reading taken elapsed after the session started. The time
+// since the previous reading counts towards that reading's zone.
+func (t *heartRateTracker) add(elapsed time.Duration, bpm, maxHR int, length time.Duration) {
+	ms := elapsed.Milliseconds()
+	if n := len(t.trace); n > 0 {
+		last := t.trace[n-1]
+		if gap := time.Duration(ms-last.T) * time.Millisecond; gap > 0 && gap <= hrMaxGap {
+			if zone := heartRateZone(last.BPM, maxHR); zone > 0 {
+				t.timeInZone[zone-1] += gap.Seconds()
+			}
+		}
+	}
+	t.trace = append(t.trace, HeartRateSample{T: ms, BPM: bpm})
+	t.sum += float64(bpm)
+	if bpm > t.peak {
+		t.peak = bpm
+	}
+
+	r := t.round(elapsed, length)
+	r.bpmSum += float64(bpm)
+	r.bpmN++
+	if bpm > r.maxBPM {
+		r.maxBPM = bpm
+	}
+}
+
+// addPunch counts a punch thrown elapsed after the session started.
+func (t *heartRateTracker) addPunch(elapsed time.Duration, force float64, length time.Duration) {
+	r := t.round(elapsed, length)
+	r.punches++
+	r.forceSum += force
+}
+
+// stats summarises the tracker for the current reading bpm.
+func (t *heartRateTracker) stats(bpm, maxHR int) HeartRateStats {
+	s := HeartRateStats{
+		MaxHR:   maxHR,
+		PeakBPM: t.peak,
+		Rounds:  []RoundStats{},
+	}
+	if bpm > 0 {
+		s.Zone = heartRateZone(bpm, maxHR)
+	}
+	if len(t.trace) > 0 {
+		s.AvgBPM = round2(t.sum / float64(len(t.trace)))
+	}
+	for i, v := range t.timeInZone {
+		s.TimeInZone[i] = math.Round(v*10) / 10
+	}
+
+	var bpms, punches []float64
+	for i, r := range t.rounds {
+		rs := RoundStats{Round: i + 1, MaxBPM: r.maxBPM, Punches: r.punches}
+		if r.bpmN > 0 {
+			rs.AvgBPM = round2(r.bpmSum / float64(r.bpmN))
+			bpms = append(bpms, rs.AvgBPM)
+			punches = append(punches, float64(r.punches))
+		}
+		if r.punches > 0 {
+			rs.AvgForce = round2(r.forceSum / float64(r.punches))
+		}
+		s.Rounds = append(s.Rounds, rs)
+	}
+	if len(bpms) >= hrMinRounds {
+		s.Correlation = round3(pearson(bpms, punches))
+	}
+	return s
+}
+
+// pearson returns the correlation coefficient of x and y (0 if either is
+// constant).
+func pearson(x, y []float64) float64 {
+	n := float64(len(x))
+	var sx, sy float64
+	for i := range x {
+		sx += x[i]
+		sy += y[i]
+	}
+	mx, my := sx/n, sy/n
+	var cov, vx, vy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
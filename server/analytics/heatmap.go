@@ -0,0 +1,140 @@
+package analytics
+
+import "time"
+
+// HeatmapBaseResolution is the finest bin width kept for the session.
+const HeatmapBaseResolution = time.Second
+
+// DefaultHeatmapBin is the bin width of the heatmap when none is asked for.
+const DefaultHeatmapBin = 15 * time.Second
+
+// HeatmapBin counts the punches thrown in one interval of the session.
+type HeatmapBin struct {
+	T        int64   `json:"t"` // bin start, ms since session start
+	Punches  int     `json:"punches"`
+	AvgForce float64 `json:"avg_force"` // m/s², 0 without punches
+}
+
+// Heatmap is the session's work split into fixed bins, showing when the
+// athlete worked and when they coasted. Unlike the timeline, every bin from
+// the session start is present, empty or not.
+type Heatmap struct {
+	BinMS    int64        `json:"bin_ms"`
+	Left     []HeatmapBin `json:"left"`
+	Right    []HeatmapBin `json:"right"`
+	Combined []HeatmapBin `json:"combined"`
+}
+
+// punchBin accumulates the punches of one base-resolution interval.
+type punchBin struct {
+	punches  int
+	forceSum float64
+}
+
+// heatmapTracker holds a hand's punch bins for the whole session.
+type heatmapTracker struct {
+	bins []punchBin
+}
+
+// add records a punch of the given force thrown elapsed after the session
+// started.
+func (h *heatmapTracker) add(elapsed time.Duration, force float64) {
+	i := int(elapsed / HeatmapBaseResolution)
+	if i < 0 {
+		return
+	}
+	for len(h.bins) <= i {
+		h.bins = append(h.bins, punchBin{})
+	}
+	h.bins[i].punches++
+	h.bins[i].forceSum += force
+}
+
+// resample merges base bins into n bins of factor× the base width.
+func (h *heatmapTracker) resample(factor, n int) []punchBin {
+	out := make([]punchBin, n)
+	for i, b := range h.bins {
+		if j := i / factor; j < n {
+			out[j].punches += b.punches
+			out[j].forceSum += b.forceSum
+		}
+	}
+	return out
+}
+
+// Heatmap returns the current session's punches in bins of the given width,
+// rounded down to a multiple of HeatmapBaseResolution.
+func (a *Analyzer) Heatmap(bin time.Duration) *Heatmap {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	factor := int(bin / HeatmapBaseResolution)
+	if factor < 1 {
+		factor = 1
+	}
+	width := time.Duration(factor) * HeatmapBaseResolution
+	n := 0
+	if a.active {
+		n = int(time.Since(a.startedAt)/width) + 1
+	}
+	for _, state := range []*HandState{a.left, a.right} {
+		n = max(n, (len(state.heatmap.bins)+factor-1)/factor)
+	}
+
+	left, right := a.left.heatmap.resample(factor, n), a.right.heatmap.resample(factor, n)
+	both := make([]punchBin, n)
+	for i := range both {
+		both[i] = punchBin{punches: left[i].punches + right[i].punches, forceSum: left[i].forceSum + right[i].forceSum}
+	}
+	w := width.Milliseconds()
+	return &Heatmap{
+		BinMS:    w,
+		Left:     heatmapBins(left, w),
+		Right:    heatmapBins(right, w),
+		Combined: heatmapBins(both, w),
+	}
+}
+
+// heatmapBins turns accumulated bins of width ms into HeatmapBins.
+func heatmapBins(in []punchBin, width int64) []HeatmapBin {
+	out := make([]HeatmapBin, len(in))
+	for i, b := range in {
+		out[i] = HeatmapBin{T: int64(i) * width, Punches: b.punches}
+		if b.punches > 0 {
+			out[i].AvgForce = round2(b.forceSum / float64(b.punches))
+		}
+	}
+	return out
+}
+
+// Resample returns the heatmap in coarser bins, a multiple of its own width.
+// Finer bins than stored are not possible and return the heatmap unchanged.
+func (h *Heatmap) Resample(bin time.Duration) *Heatmap {
+	if h.BinMS <= 0 {
+		return h
+	}
+	width := bin.Milliseconds() / h.BinMS * h.BinMS
+	if width <= h.BinMS {
+		return h
+	}
+	return &Heatmap{
+		BinMS:    width,
+		Left:     resampleHeatmapBins(h.Left, width),
+		Right:    resampleHeatmapBins(h.Right, width),
+		Combined: resampleHeatmapBins(h.Combined, width),
+	}
+}
+
+// resampleHeatmapBins merges time-ordered bins into bins of width ms, their
+// average forces weighted by punch counts.
+func resampleHeatmapBins(in []HeatmapBin, width int64) []HeatmapBin {
+	var merged []punchBin
+	for _, b := range in {
+		i := int(b.T / width)
+		for len(merged) <= i {
+			merged = append(merged, punchBin{})
+		}
+		merged[i].punches += b.Punches
+		merged[i].forceSum += b.AvgForce * float64(b.Punches)
+	}
+	return heatmapBins(merged, width)
+}
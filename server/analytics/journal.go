@@ -0,0 +1,69 @@
+package analytics
+
+import (
+	"time"
+
+	"boxing-analytics/ble"
+)
+
+// RecordedPunch is a punch together with the server time it was registered
+// at, as written to a Journal.
+type RecordedPunch struct {
+	PunchEvent
+	At time.Time `json:"at"`
+}
+
+// Journal records a session as it happens so that it can be restored with
+// RestoreSession after a crash. Its methods are called with the analyzer
+// locked, in order, and must neither block nor call back into the analyzer.
+type Journal interface {
+	SessionStarted(startedAt time.Time, athlete, sessionType string, info SessionInfo)
+	SessionPunch(punch RecordedPunch)
+	SessionPaused(paused bool)
+	SessionEnded() // stopped or reset
+}
+
+// SetJournal sets the journal the session is recorded to (nil = none).
+func (a *Analyzer) SetJournal(journal Journal) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.journal = journal
+}
+
+// RestoreSession resumes a session started at startedAt from its journaled
+// punches, replacing the current one. The session and its punches move
+// forward by downtime, the time the server was down, so that it counts
+// toward neither the duration nor the rates. Punch counts, forces,
+// breakdowns, combos, flurries, fatigue and rates are rebuilt; the magnitude
+// timeline, heart-rate trace, defensive moves and punches still being
+// captured are lost. Events such as combos are not re-emitted, and nothing
+// is written to the journal.
+func (a *Analyzer) RestoreSession(startedAt time.Time, downtime time.Duration, paused bool, punches []RecordedPunch) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.resetStatsLocked()
+	a.active = true
+	a.paused = paused
+	a.startedAt = startedAt.Add(downtime)
+
+	onEvent := a.onEvent
+	a.onEvent = nil
+	for _, p := range punches {
+		state := a.left
+		if p.Hand == ble.RightHand.String() {
+			state = a.right
+		}
+		switch p.Type {
+		case PunchStraight, PunchJab, PunchCross:
+			a.recordStraightLocked(state, p.Force)
+		}
+		a.countPunchLocked(state, p.PunchEvent, p.Force, p.RFD, p.At.Add(downtime))
+	}
+	now := time.Now()
+	a.flushCombosLocked(now)
+	a.flushFlurriesLocked(now)
+	a.onEvent = onEvent
+
+	a.broadcastLocked()
+}
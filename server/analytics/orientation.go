@@ -0,0 +1,132 @@
+package analytics
+
+import "math"
+
+// Sensor fusion constants
+const (
+	madgwickBeta      = 0.1 // filter gain: trust placed in the accelerometer
+	fusionAccelGate   = 0.2 // skip accel correction when |a| strays this far from g
+	uppercutLiftShare = 0.7 // vertical share of a straight's peak that makes it an uppercut
+	maxFusionDT       = 0.1 // s - longer gaps (dropped packets) use the nominal period
+	degToRad          = math.Pi / 180
+)
+
+// Quaternion is a unit rotation quaternion [w, x, y, z] taking vectors from
+// the glove's sensor frame to the world frame (Z up).
+type Quaternion [4]float64
+
+// identityQuaternion is the rotation that leaves vectors unchanged.
+var identityQuaternion = Quaternion{1, 0, 0, 0}
+
+// Rotate applies the rotation to v.
+func (q Quaternion) Rotate(v [3]float64) [3]float64 {
+	w, x, y, z := q[0], q[1], q[2], q[3]
+	// v' = v + 2w(u × v) + 2u × (u × v), u = (x, y, z)
+	cx := y*v[2] - z*v[1]
+	cy := z*v[0] - x*v[2]
+	cz := x*v[1] - y*v[0]
+	return [3]float64{
+		v[0] + 2*(w*cx+y*cz-z*cy),
+		v[1] + 2*(w*cy+z*cx-x*cz),
+		v[2] + 2*(w*cz+x*cy-y*cx),
+	}
+}
+
+// normalized returns q scaled to unit length.
+func (q Quaternion) normalized() Quaternion {
+	n := math.Sqrt(q[0]*q[0] + q[1]*q[1] + q[2]*q[2] + q[3]*q[3])
+	if n == 0 {
+		return identityQuaternion
+	}
+	return Quaternion{q[0] / n, q[1] / n, q[2] / n, q[3] / n}
+}
+
+// quaternionFromGravity returns the rotation that maps a gravity reading in
+// the sensor frame onto world up. Heading is arbitrary without a compass.
+func quaternionFromGravity(g [3]float64) Quaternion {
+	n := math.Sqrt(g[0]*g[0] + g[1]*g[1] + g[2]*g[2])
+	if n == 0 {
+		return identityQuaternion
+	}
+	u := [3]float64{g[0] / n, g[1] / n, g[2] / n}
+
+	// Shortest rotation from u to (0, 0, 1)
+	dot := u[2]
+	if dot < -0.999999 {
+		return Quaternion{0, 1, 0, 0} // upside down: half turn about X
+	}
+	return Quaternion{1 + dot, u[1], -u[0], 0}.normalized()
+}
+
+// orientationFilter is a Madgwick IMU filter fusing gyro and accelerometer
+// into an orientation estimate.
+type orientationFilter struct {
+	q      Quaternion
+	g      float64 // gravity magnitude measured at calibration (m/s²)
+	lastTS int64   // device timestamp of the last update
+	ready  bool
+}
+
+// reset initialises the filter from a calibration gravity reference.
+func (f *orientationFilter) reset(gravity [3]float64) {
+	f.q = quaternionFromGravity(gravity)
+	f.g = math.Sqrt(gravity[0]*gravity[0] + gravity[1]*gravity[1] + gravity[2]*gravity[2])
+	f.lastTS = 0
+	f.ready = f.g > 0
+}
+
+// set adopts an orientation computed elsewhere (on-board fusion).
+func (f *orientationFilter) set(ts int64, q Quaternion) {
+	f.q = q.normalized()
+	f.lastTS = ts
+}
+
+// update advances the estimate with one sample. Accel is in m/s², gyro in °/s.
+func (f *orientationFilter) update(ts int64, ax, ay, az, gx, gy, gz float64) {
+	dt := float64(ts-f.lastTS) / 1000
+	if f.lastTS == 0 || dt <= 0 || dt > maxFusionDT {
+		dt = samplePeriodMS / 1000
+	}
+	f.lastTS = ts
+
+	q0, q1, q2, q3 := f.q[0], f.q[1], f.q[2], f.q[3]
+	gx, gy, gz = gx*degToRad, gy*degToRad, gz*degToRad
+
+	// Rate of change from the gyroscope
+	qd0 := 0.5 * (-q1*gx - q2*gy - q3*gz)
+	qd1 := 0.5 * (q0*gx + q2*gz - q3*gy)
+	qd2 := 0.5 * (q0*gy - q1*gz + q3*gx)
+	qd3 := 0.5 * (q0*gz + q1*gy - q2*gx)
+
+	// Gradient-descent correction towards the measured gravity direction,
+	// skipped while the glove is accelerating hard (punches)
+	an := math.Sqrt(ax*ax + ay*ay + az*az)
+	if an > 0 && math.Abs(an-f.g) < fusionAccelGate*f.g {
+		ax, ay, az = ax/an, ay/an, az/an
+
+		f0 := 2*(q1*q3-q0*q2) - ax
+		f1 := 2*(q0*q1+q2*q3) - ay
+		f2 := 2*(0.5-q1*q1-q2*q2) - az
+		s0 := -2*q2*f0 + 2*q1*f1
+		s1 := 2*q3*f0 + 2*q0*f1 - 4*q1*f2
+		s2 := -2*q0*f0 + 2*q3*f1 - 4*q2*f2
+		s3 := 2*q1*f0 + 2*q2*f1
+
+		sn := math.Sqrt(s0*s0 + s1*s1 + s2*s2 + s3*s3)
+		if sn > 0 {
+			qd0 -= madgwickBeta * s0 / sn
+			qd1 -= madgwickBeta * s1 / sn
+			qd2 -= madgwickBeta * s2 / sn
+			qd3 -= madgwickBeta * s3 / sn
+		}
+	}
+
+	f.q = Quaternion{q0 + qd0*dt, q1 + qd1*dt, q2 + qd2*dt, q3 + qd3*dt}.normalized()
+}
+
+// linear returns the world-frame acceleration with gravity removed.
+func (f *orientationFilter) linear(ax, ay, az float64) [3]float64 {
+	w := f.q.Rotate([3]float64{ax, ay, az})
+	w[2] -= f.g
+	return w
+}
@@ -0,0 +1,338 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"boxing-analytics/validation"
+)
+
+// DefaultCallEverySec is how often a round's calls are made when it does
+// not say.
+const DefaultCallEverySec = 5
+
+// Program phases
+const (
+	PhaseRound = "round"
+	PhaseRest  = "rest"
+	PhaseDone  = "done"
+)
+
+// RoundTargets are what a round of a program asks for. Zero fields are not
+// targeted.
+type RoundTargets struct {
+	Punches  int     `json:"punches,omitempty"`
+	AvgForce float64 `json:"avg_force,omitempty"` // m/s²
+	PPM      float64 `json:"ppm,omitempty"`       // punches per minute
+}
+
+// ProgramRound is one round of a workout program and the rest after it.
+type ProgramRound struct {
+	Drill        string       `json:"drill"` // what to work on, shown to the athlete
+	DurationSec  int          `json:"duration_sec"`
+	RestSec      int          `json:"rest_sec,omitempty"`       // rest after the round, none after the last
+	Calls        []string     `json:"calls,omitempty"`          // combinations called out at random during the round
+	CallEverySec int          `json:"call_every_sec,omitempty"` // 0 = DefaultCallEverySec
+	Targets      RoundTargets `json:"targets"`
+}
+
+// Program is a guided workout: rounds of drills, each with its own targets.
+type Program struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	SessionType string         `json:"session_type,omitempty"` // session type it is meant for, "" = any
+	Rounds      []ProgramRound `json:"rounds"`
+}
+
+// Validate checks the program's rounds, returning validation.Errors listing
+// every invalid field.
+func (p *Program) Validate() error {
+	var errs validation.Errors
+	if p.ID == "" {
+		errs.Add("id", fmt.Errorf("id is required"))
+	}
+	if len(p.Rounds) == 0 {
+		errs.Add("rounds", fmt.Errorf("program needs at least one round"))
+	}
+	for i, r := range p.Rounds {
+		field := fmt.Sprintf("rounds[%d]", i)
+		if r.DurationSec <= 0 || r.RestSec < 0 || r.CallEverySec < 0 {
+			errs.Add(field, fmt.Errorf("%s: durations must be positive", field))
+		}
+		if r.Targets.Punches < 0 || r.Targets.AvgForce < 0 || r.Targets.PPM < 0 {
+			errs.Add(field+".targets", fmt.Errorf("%s: targets must be positive", field))
+		}
+	}
+	return errs.Err()
+}
+
+// ProgramEvent is the payload of a "program" event, sent as each round or
+// rest starts and when the program is done.
+type ProgramEvent struct {
+	Program     string       `json:"program"`
+	Round       int          `json:"round"` // 1-based
+	Rounds      int          `json:"rounds"`
+	Phase       string       `json:"phase"` // PhaseRound, PhaseRest or PhaseDone
+	Drill       string       `json:"drill,omitempty"`
+	DurationSec int          `json:"duration_sec,omitempty"`
+	Targets     RoundTargets `json:"targets"`
+}
+
+// ProgramCall is the payload of a "program_call" event: throw Call now.
+type ProgramCall struct {
+	Round int    `json:"round"`
+	Call  string `json:"call"`
+}
+
+// RoundScore is how a round of a program went against its targets.
+type RoundScore struct {
+	Round     int          `json:"round"`
+	Drill     string       `json:"drill"`
+	Punches   int          `json:"punches"`
+	AvgForce  float64      `json:"avg_force"`
+	PPM       float64      `json:"ppm"`
+	Targets   RoundTargets `json:"targets"`
+	Adherence float64      `json:"adherence"` // % of the targets met, 100 without targets
+	Complete  bool         `json:"complete"`  // false if the program stopped during the round
+}
+
+// ProgramStats is the progress and score of the session's program.
+type ProgramStats struct {
+	Program      string       `json:"program"`
+	Name         string       `json:"name"`
+	Running      bool         `json:"running"`
+	Round        int          `json:"round"` // current round, 1-based
+	Rounds       int          `json:"rounds"`
+	Phase        string       `json:"phase"`
+	PhaseLeftSec float64      `json:"phase_left_sec"`
+	Scores       []RoundScore `json:"scores"`    // finished rounds
+	Adherence    float64      `json:"adherence"` // mean over the complete rounds
+}
+
+// programRun is the state of a session's program.
+type programRun struct {
+	program  Program
+	running  bool
+	round    int // index into program.Rounds
+	phase    string
+	left     time.Duration // left in the phase
+	nextCall time.Duration // left in the round at the next call
+	lastTick time.Time
+
+	// Totals as the round started
+	startPunches  int
+	startForceSum float64
+
+	scores []RoundScore
+}
+
+// StartProgram runs a workout program in the running session, replacing
+// any program already running in it. The program advances with the
+// session clock, pausing while the session is paused.
+func (a *Analyzer) StartProgram(p Program) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.active {
+		return ErrNoSession
+	}
+	a.stopProgramLocked()
+	a.program = &programRun{program: p, running: true, scores: []RoundScore{}}
+	a.startRoundLocked(time.Now())
+	a.broadcastLocked()
+	return nil
+}
+
+// StopProgram stops the running program. The rounds finished so far stay
+// scored in the session.
+func (a *Analyzer) StopProgram() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopProgramLocked()
+	a.broadcastLocked()
+}
+
+// ProgramStats returns the progress and score of the session's program, nil
+// if none ran.
+func (a *Analyzer) ProgramStats() *ProgramStats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.programStatsLocked()
+}
+
+// stopProgramLocked stops the program, scoring the round in progress as
+// incomplete.
+// Must be called with a.mu held.
+func (a *Analyzer) stopProgramLocked() {
+	p := a.program
+	if p == nil || !p.running {
+		return
+	}
+	if p.phase == PhaseRound {
+		round := p.program.Rounds[p.round]
+		a.scoreRoundLocked(p, time.Duration(round.DurationSec)*time.Second-p.left, false)
+	}
+	p.running = false
+}
+
+// startRoundLocked starts the current round of the program.
+// Must be called with a.mu held.
+func (a *Analyzer) startRoundLocked(now time.Time) {
+	p := a.program
+	round := p.program.Rounds[p.round]
+	p.phase = PhaseRound
+	p.left = time.Duration(round.DurationSec) * time.Second
+	p.nextCall = p.left - callEvery(round)
+	p.lastTick = now
+	p.startPunches = a.left.PunchCount + a.right.PunchCount
+	p.startForceSum = a.left.forceSum + a.right.forceSum
+	a.emitProgramLocked(p)
+}
+
+// advanceProgramLocked moves the program on by the time since the last
+// tick, making calls and ending rounds and rests as they run out.
+// Must be called with a.mu held.
+func (a *Analyzer) advanceProgramLocked(now time.Time) {
+	p := a.program
+	if p == nil || !p.running {
+		return
+	}
+	dt := now.Sub(p.lastTick)
+	p.lastTick = now
+	if a.paused || dt <= 0 {
+		return
+	}
+	p.left -= dt
+
+	round := p.program.Rounds[p.round]
+	switch p.phase {
+	case PhaseRound:
+		if len(round.Calls) > 0 && p.left > 0 && p.left <= p.nextCall {
+			call := round.Calls[rand.Intn(len(round.Calls))]
+			a.emitLocked("program_call", "", ProgramCall{Round: p.round + 1, Call: call})
+			p.nextCall -= callEvery(round)
+		}
+		if p.left > 0 {
+			return
+		}
+		a.scoreRoundLocked(p, time.Duration(round.DurationSec)*time.Second, true)
+		if p.round == len(p.program.Rounds)-1 {
+			p.phase, p.running = PhaseDone, false
+			a.emitProgramLocked(p)
+			return
+		}
+		if round.RestSec > 0 {
+			p.phase = PhaseRest
+			p.left = time.Duration(round.RestSec) * time.Second
+			a.emitProgramLocked(p)
+			return
+		}
+		p.round++
+		a.startRoundLocked(now)
+	case PhaseRest:
+		if p.left <= 0 {
+			p.round++
+			a.startRoundLocked(now)
+		}
+	}
+}
+
+// callEvery returns the time between a round's calls.
+func callEvery(r ProgramRound) time.Duration {
+	if r.CallEverySec > 0 {
+		return time.Duration(r.CallEverySec) * time.Second
+	}
+	return DefaultCallEverySec * time.Second
+}
+
+// emitProgramLocked emits a "program" event for the program's phase.
+// Must be called with a.mu held.
+func (a *Analyzer) emitProgramLocked(p *programRun) {
+	ev := ProgramEvent{Program: p.program.ID, Round: p.round + 1, Rounds: len(p.program.Rounds), Phase: p.phase}
+	if p.phase == PhaseRound {
+		round := p.program.Rounds[p.round]
+		ev.Drill, ev.DurationSec, ev.Targets = round.Drill, round.DurationSec, round.Targets
+	} else if p.phase == PhaseRest {
+		ev.DurationSec = p.program.Rounds[p.round].RestSec
+	}
+	a.emitLocked("program", "", ev)
+}
+
+// scoreRoundLocked scores the current round, worked for the given time,
+// against its targets.
+// Must be called with a.mu held.
+func (a *Analyzer) scoreRoundLocked(p *programRun, worked time.Duration, complete bool) {
+	round := p.program.Rounds[p.round]
+	s := RoundScore{
+		Round:    p.round + 1,
+		Drill:    round.Drill,
+		Punches:  a.left.PunchCount + a.right.PunchCount - p.startPunches,
+		Targets:  round.Targets,
+		Complete: complete,
+	}
+	if s.Punches > 0 {
+		s.AvgForce = round2((a.left.forceSum + a.right.forceSum - p.startForceSum) / float64(s.Punches))
+	}
+	if worked > 0 {
+		s.PPM = math.Round(float64(s.Punches)/worked.Minutes()*10) / 10
+	}
+
+	// Each target counts its share met, up to all of it
+	var met []float64
+	if t := round.Targets.Punches; t > 0 {
+		met = append(met, math.Min(float64(s.Punches)/float64(t), 1))
+	}
+	if t := round.Targets.AvgForce; t > 0 {
+		met = append(met, math.Min(s.AvgForce/t, 1))
+	}
+	if t := round.Targets.PPM; t > 0 {
+		met = append(met, math.Min(s.PPM/t, 1))
+	}
+	s.Adherence = 100
+	if len(met) > 0 {
+		var sum float64
+		for _, m := range met {
+			sum += m
+		}
+		s.Adherence = math.Round(sum/float64(len(met))*1000) / 10
+	}
+	p.scores = append(p.scores, s)
+}
+
+// programStatsLocked summarises the program for SessionState.
+// Must be called with a.mu held.
+func (a *Analyzer) programStatsLocked() *ProgramStats {
+	p := a.program
+	if p == nil {
+		return nil
+	}
+	stats := &ProgramStats{
+		Program: p.program.ID,
+		Name:    p.program.Name,
+		Running: p.running,
+		Round:   p.round + 1,
+		Rounds:  len(p.program.Rounds),
+		Phase:   p.phase,
+		Scores:  append([]RoundScore{}, p.scores...),
+	}
+	if p.running {
+		stats.PhaseLeftSec = math.Max(0, math.Round(p.left.Seconds()*10)/10)
+	}
+	var sum float64
+	var n int
+	for _, s := range p.scores {
+		if s.Complete {
+			sum += s.Adherence
+			n++
+		}
+	}
+	if n > 0 {
+		stats.Adherence = math.Round(sum/float64(n)*10) / 10
+	}
+	return stats
+}
@@ -0,0 +1,60 @@
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// Rolling punch-rate windows
+var rateWindows = [...]time.Duration{10 * time.Second, 30 * time.Second, 60 * time.Second}
+
+// RollingRate is the punch rate over recent sliding windows, in punches per
+// minute. Unlike the session average it drops as soon as output does.
+type RollingRate struct {
+	PPM10s float64 `json:"ppm_10s"`
+	PPM30s float64 `json:"ppm_30s"`
+	PPM60s float64 `json:"ppm_60s"`
+}
+
+// rateWindow keeps the times of punches inside the longest rolling window.
+type rateWindow []time.Time
+
+// add records a punch and drops punches older than the longest window.
+func (w *rateWindow) add(at time.Time) {
+	*w = append(*w, at)
+	longest := rateWindows[len(rateWindows)-1]
+	i := 0
+	for i < len(*w) && at.Sub((*w)[i]) > longest {
+		i++
+	}
+	*w = (*w)[i:]
+}
+
+// counts returns how many punches fall in each window ending at now.
+func (w rateWindow) counts(now time.Time) (n [len(rateWindows)]int) {
+	for _, t := range w {
+		for i, span := range rateWindows {
+			if now.Sub(t) <= span {
+				n[i]++
+			}
+		}
+	}
+	return n
+}
+
+// rollingRate converts window counts to punches per minute. Windows longer
+// than the session so far are measured over the elapsed time instead, so the
+// rate is not understated early on.
+func rollingRate(n [len(rateWindows)]int, elapsed time.Duration) RollingRate {
+	var ppm [len(rateWindows)]float64
+	for i, span := range rateWindows {
+		if elapsed < span {
+			span = elapsed
+		}
+		if span < time.Second {
+			continue
+		}
+		ppm[i] = math.Round(float64(n[i])/span.Minutes()*10) / 10
+	}
+	return RollingRate{PPM10s: ppm[0], PPM30s: ppm[1], PPM60s: ppm[2]}
+}
@@ -0,0 +1,24 @@
+package analytics
+
+// RawSample is the signal punch detection saw for one sensor sample, for
+// tuning thresholds against the live waveform.
+type RawSample struct {
+	Hand      string     `json:"-"`
+	Timestamp uint32     `json:"t"`         // glove timestamp, ms
+	Mag       float64    `json:"mag"`       // gravity-free acceleration magnitude, m/s²
+	Gyro      [3]float64 `json:"gyro"`      // °/s
+	Threshold float64    `json:"threshold"` // magnitude a punch starts above
+	Active    bool       `json:"active"`    // a session is running, so punches are detected
+}
+
+// RawHandler is called for every sample once the hand is calibrated. It is
+// called with the analyzer locked, at the sensor rate, so it must return
+// quickly and not call back into the analyzer.
+type RawHandler func(sample RawSample)
+
+// SetRawHandler sets the callback for raw samples, nil for none.
+func (a *Analyzer) SetRawHandler(handler RawHandler) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onRaw = handler
+}
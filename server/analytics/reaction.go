@@ -0,0 +1,298 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"boxing-analytics/ble"
+	"boxing-analytics/validation"
+)
+
+// Reaction drill defaults
+const (
+	DefaultReactionMinDelayMS = 1500 // shortest wait before a prompt
+	DefaultReactionMaxDelayMS = 4000 // longest wait before a prompt
+	DefaultReactionTimeoutMS  = 1500 // time allowed to react
+)
+
+// reactionCaptureSlack is how long after its onset a punch is registered at
+// the latest, so a prompt waits this much past its timeout for a punch
+// started in time.
+const reactionCaptureSlack = (punchCaptureMS + retractionMS + 100) * time.Millisecond
+
+// Reaction results
+const (
+	ReactionHit  = "hit"
+	ReactionMiss = "miss" // no punch on the prompted hand in time
+)
+
+// ErrNoSession is returned when a drill needs a running session.
+var ErrNoSession = errors.New("no session running")
+
+// ReactionDrill configures a reaction-time drill: after a random delay the
+// server prompts a hand and times the punch that answers it.
+type ReactionDrill struct {
+	MinDelayMS int64  `json:"min_delay_ms"`
+	MaxDelayMS int64  `json:"max_delay_ms"`
+	TimeoutMS  int64  `json:"timeout_ms"`
+	Hands      string `json:"hands"` // "left", "right" or "both"
+}
+
+// DefaultReactionDrill returns the drill run when nothing is configured.
+func DefaultReactionDrill() ReactionDrill {
+	return ReactionDrill{
+		MinDelayMS: DefaultReactionMinDelayMS,
+		MaxDelayMS: DefaultReactionMaxDelayMS,
+		TimeoutMS:  DefaultReactionTimeoutMS,
+		Hands:      "both",
+	}
+}
+
+// Validate checks the delays and hands of a drill, returning
+// validation.Errors listing every invalid field.
+func (d ReactionDrill) Validate() error {
+	var errs validation.Errors
+	if d.MinDelayMS <= 0 {
+		errs.Add("min_delay_ms", fmt.Errorf("invalid min_delay_ms %d: must be positive", d.MinDelayMS))
+	} else if d.MaxDelayMS < d.MinDelayMS {
+		errs.Add("max_delay_ms", fmt.Errorf("invalid delays %d-%dms", d.MinDelayMS, d.MaxDelayMS))
+	}
+	if d.TimeoutMS <= 0 {
+		errs.Add("timeout_ms", fmt.Errorf("invalid timeout %dms", d.TimeoutMS))
+	}
+	switch d.Hands {
+	case "left", "right", "both":
+	default:
+		errs.Add("hands", fmt.Errorf("invalid hands %q: must be 'left', 'right' or 'both'", d.Hands))
+	}
+	return errs.Err()
+}
+
+// ReactionPrompt is the payload of a "reaction_prompt" event: punch with
+// Hand now. Clients show or play it as soon as it arrives.
+type ReactionPrompt struct {
+	ID        int    `json:"id"`
+	Hand      string `json:"hand"`
+	TimeoutMS int64  `json:"timeout_ms"`
+}
+
+// ReactionResult is the payload of a "reaction" event, answering a prompt.
+type ReactionResult struct {
+	ID         int    `json:"id"`
+	Hand       string `json:"hand"`
+	Result     string `json:"result"`      // ReactionHit or ReactionMiss
+	ReactionMS int64  `json:"reaction_ms"` // prompt to punch onset, 0 on a miss
+}
+
+// ReactionStats summarises the reaction drill of a session.
+type ReactionStats struct {
+	Running   bool               `json:"running"`
+	Drill     ReactionDrill      `json:"drill"`
+	Prompts   int                `json:"prompts"`    // answered or missed
+	Hits      int                `json:"hits"`       // punched with the prompted hand in time
+	Misses    int                `json:"misses"`     // no punch in time
+	WrongHand int                `json:"wrong_hand"` // punches with the other hand while prompted
+	AvgMS     float64            `json:"avg_ms"`
+	MedianMS  float64            `json:"median_ms"`
+	BestMS    int64              `json:"best_ms"`
+	WorstMS   int64              `json:"worst_ms"`
+	ByHand    map[string]float64 `json:"by_hand"` // average per prompted hand
+}
+
+// reactionDrill is the state of a session's reaction drill.
+type reactionDrill struct {
+	drill   ReactionDrill
+	running bool
+	timer   *time.Timer // next prompt, or the timeout of the current one
+
+	nextID   int
+	prompt   *ReactionPrompt // waiting for a punch, nil = between prompts
+	promptAt time.Time
+
+	stats  ReactionStats
+	times  []int64            // reaction times of the hits (ms)
+	byHand map[string][]int64 // and per hand
+}
+
+// StartReactionDrill starts a reaction drill in the running session,
+// replacing any drill already running in it.
+func (a *Analyzer) StartReactionDrill(drill ReactionDrill) error {
+	if err := drill.Validate(); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.active {
+		return ErrNoSession
+	}
+	a.stopReactionLocked()
+	r := &reactionDrill{drill: drill, running: true, byHand: make(map[string][]int64)}
+	r.stats.Drill = drill
+	a.reaction = r
+	a.scheduleReactionLocked(r)
+	a.broadcastLocked()
+	return nil
+}
+
+// StopReactionDrill stops the running reaction drill. Its stats stay in the
+// session.
+func (a *Analyzer) StopReactionDrill() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopReactionLocked()
+	a.broadcastLocked()
+}
+
+// ReactionStats returns the stats of the session's reaction drill, nil if
+// none ran.
+func (a *Analyzer) ReactionStats() *ReactionStats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.reactionStatsLocked()
+}
+
+// stopReactionLocked stops the drill, dropping an unanswered prompt.
+// Must be called with a.mu held.
+func (a *Analyzer) stopReactionLocked() {
+	r := a.reaction
+	if r == nil || !r.running {
+		return
+	}
+	r.running = false
+	r.prompt = nil
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+}
+
+// scheduleReactionLocked waits a random delay before the next prompt.
+// Must be called with a.mu held.
+func (a *Analyzer) scheduleReactionLocked(r *reactionDrill) {
+	delay := r.drill.MinDelayMS
+	if span := r.drill.MaxDelayMS - r.drill.MinDelayMS; span > 0 {
+		delay += rand.Int63n(span + 1)
+	}
+	r.timer = time.AfterFunc(time.Duration(delay)*time.Millisecond, func() { a.promptReaction(r) })
+}
+
+// promptReaction emits the next prompt of a drill, unless it was stopped.
+// A paused session waits for the next delay instead.
+func (a *Analyzer) promptReaction(r *reactionDrill) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.reaction != r || !r.running {
+		return
+	}
+	if a.paused {
+		a.scheduleReactionLocked(r)
+		return
+	}
+
+	hand := r.drill.Hands
+	if hand == "both" {
+		hand = [2]string{"left", "right"}[rand.Intn(2)]
+	}
+	r.nextID++
+	r.prompt = &ReactionPrompt{ID: r.nextID, Hand: hand, TimeoutMS: r.drill.TimeoutMS}
+	r.promptAt = time.Now()
+	a.emitLocked("reaction_prompt", hand, *r.prompt)
+
+	id := r.nextID
+	timeout := time.Duration(r.drill.TimeoutMS)*time.Millisecond + reactionCaptureSlack
+	r.timer = time.AfterFunc(timeout, func() { a.expireReaction(r, id) })
+}
+
+// expireReaction records a miss if prompt id is still unanswered.
+func (a *Analyzer) expireReaction(r *reactionDrill, id int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.reaction != r || !r.running || r.prompt == nil || r.prompt.ID != id {
+		return
+	}
+	a.answerReactionLocked(r, ReactionMiss, 0)
+}
+
+// reactLocked answers the current prompt with a punch by hand that began
+// moving at onset. Punches begun before the prompt are not reactions to it.
+// Must be called with a.mu held.
+func (a *Analyzer) reactLocked(hand ble.Hand, onset time.Time) {
+	r := a.reaction
+	if r == nil || r.prompt == nil || onset.Before(r.promptAt) {
+		return
+	}
+	ms := onset.Sub(r.promptAt).Milliseconds()
+	if ms > r.drill.TimeoutMS {
+		return // the timeout records the miss
+	}
+	if hand.String() != r.prompt.Hand {
+		r.stats.WrongHand++
+		return
+	}
+	a.answerReactionLocked(r, ReactionHit, ms)
+}
+
+// answerReactionLocked records the result of the current prompt, emits a
+// "reaction" event and schedules the next prompt.
+// Must be called with a.mu held.
+func (a *Analyzer) answerReactionLocked(r *reactionDrill, result string, ms int64) {
+	p := r.prompt
+	r.prompt = nil
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+
+	r.stats.Prompts++
+	if result == ReactionHit {
+		r.stats.Hits++
+		r.times = append(r.times, ms)
+		r.byHand[p.Hand] = append(r.byHand[p.Hand], ms)
+	} else {
+		r.stats.Misses++
+	}
+	a.emitLocked("reaction", p.Hand, ReactionResult{ID: p.ID, Hand: p.Hand, Result: result, ReactionMS: ms})
+	a.scheduleReactionLocked(r)
+	a.broadcastLocked()
+}
+
+// reactionStatsLocked summarises the drill for SessionState.
+// Must be called with a.mu held.
+func (a *Analyzer) reactionStatsLocked() *ReactionStats {
+	r := a.reaction
+	if r == nil {
+		return nil
+	}
+	stats := r.stats
+	stats.Running = r.running
+	stats.ByHand = make(map[string]float64, len(r.byHand))
+	for hand, times := range r.byHand {
+		stats.ByHand[hand] = averageMS(times)
+	}
+	if n := len(r.times); n > 0 {
+		sorted := make([]int64, n)
+		copy(sorted, r.times)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats.AvgMS = averageMS(sorted)
+		stats.MedianMS = float64(sorted[n/2])
+		if n%2 == 0 {
+			stats.MedianMS = float64(sorted[n/2-1]+sorted[n/2]) / 2
+		}
+		stats.BestMS, stats.WorstMS = sorted[0], sorted[n-1]
+	}
+	return &stats
+}
+
+// averageMS returns the mean of reaction times, rounded to 0.1ms.
+func averageMS(times []int64) float64 {
+	if len(times) == 0 {
+		return 0
+	}
+	var sum int64
+	for _, t := range times {
+		sum += t
+	}
+	return math.Round(float64(sum)/float64(len(times))*10) / 10
+}
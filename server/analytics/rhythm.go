@@ -0,0 +1,139 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"boxing-analytics/validation"
+)
+
+// rhythmMaxGap is the longest time between two punches that still counts as
+// an interval; a longer gap is a rest, between rounds or drills.
+const rhythmMaxGap = 5 * time.Second
+
+// Histogram defaults for IntervalHistogram
+const (
+	DefaultRhythmBucketMS = 100
+	DefaultRhythmMaxMS    = 2000
+	maxRhythmBuckets      = 500
+)
+
+// RhythmStats describes the time between consecutive punches. The lower
+// the coefficient of variation, the steadier the tempo.
+type RhythmStats struct {
+	Intervals int     `json:"intervals"`
+	MeanMS    float64 `json:"mean_ms"`
+	StdDevMS  float64 `json:"stddev_ms"`
+	CV        float64 `json:"cv"` // stddev / mean
+}
+
+// rhythmTracker records the intervals between punches.
+type rhythmTracker struct {
+	last       time.Time
+	intervals  []int64 // ms
+	sum, sumSq float64
+}
+
+// add records a punch registered at the given time.
+func (r *rhythmTracker) add(at time.Time) {
+	if !r.last.IsZero() {
+		if d := at.Sub(r.last); d >= 0 && d <= rhythmMaxGap {
+			ms := d.Milliseconds()
+			r.intervals = append(r.intervals, ms)
+			r.sum += float64(ms)
+			r.sumSq += float64(ms) * float64(ms)
+		}
+	}
+	r.last = at
+}
+
+// stats summarises the intervals.
+func (r *rhythmTracker) stats() RhythmStats {
+	n := float64(len(r.intervals))
+	if n == 0 {
+		return RhythmStats{}
+	}
+	mean := r.sum / n
+	stddev := math.Sqrt(math.Max(0, r.sumSq/n-mean*mean))
+	s := RhythmStats{
+		Intervals: len(r.intervals),
+		MeanMS:    math.Round(mean*10) / 10,
+		StdDevMS:  math.Round(stddev*10) / 10,
+	}
+	if mean > 0 {
+		s.CV = math.Round(stddev/mean*1000) / 1000
+	}
+	return s
+}
+
+// RhythmBucket is one bar of an interval histogram.
+type RhythmBucket struct {
+	FromMS int64 `json:"from_ms"`
+	ToMS   int64 `json:"to_ms"` // exclusive
+	Count  int   `json:"count"`
+}
+
+// RhythmHistogram is the distribution of intervals between punches.
+type RhythmHistogram struct {
+	Hand     string         `json:"hand"` // "left", "right" or "both"
+	BucketMS int64          `json:"bucket_ms"`
+	Buckets  []RhythmBucket `json:"buckets"`
+	Overflow int            `json:"overflow"` // intervals of the last bucket's end or longer
+	Stats    RhythmStats    `json:"stats"`
+}
+
+// IntervalHistogram sorts the session's intervals between punches of hand
+// ("left", "right" or "both") into buckets of bucketMS up to maxMS. Invalid
+// arguments fail with validation.Errors naming them "hand", "bucket_ms" and
+// "max_ms".
+func (a *Analyzer) IntervalHistogram(hand string, bucketMS, maxMS int64) (*RhythmHistogram, error) {
+	var errs validation.Errors
+	var n int64
+	switch {
+	case bucketMS <= 0:
+		errs.Add("bucket_ms", fmt.Errorf("invalid bucket_ms %d: must be positive", bucketMS))
+	case maxMS < bucketMS:
+		errs.Add("max_ms", fmt.Errorf("invalid buckets of %dms up to %dms", bucketMS, maxMS))
+	default:
+		if n = (maxMS + bucketMS - 1) / bucketMS; n > maxRhythmBuckets {
+			errs.Add("max_ms", fmt.Errorf("too many buckets: at most %d", maxRhythmBuckets))
+		}
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	r, err := a.rhythmLocked(hand)
+	if err != nil {
+		errs.Add("hand", err)
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	h := &RhythmHistogram{Hand: hand, BucketMS: bucketMS, Buckets: make([]RhythmBucket, n), Stats: r.stats()}
+	for i := range h.Buckets {
+		h.Buckets[i] = RhythmBucket{FromMS: int64(i) * bucketMS, ToMS: int64(i+1) * bucketMS}
+	}
+	for _, ms := range r.intervals {
+		if i := ms / bucketMS; i < n {
+			h.Buckets[i].Count++
+		} else {
+			h.Overflow++
+		}
+	}
+	return h, nil
+}
+
+// rhythmLocked returns the rhythm tracker of hand.
+// Must be called with a.mu held.
+func (a *Analyzer) rhythmLocked(hand string) (*rhythmTracker, error) {
+	switch hand {
+	case "left":
+		return &a.left.rhythm, nil
+	case "right":
+		return &a.right.rhythm, nil
+	case "both":
+		return &a.rhythm, nil
+	}
+	return nil, fmt.Errorf("invalid hand %q: must be 'left', 'right', or 'both'", hand)
+}
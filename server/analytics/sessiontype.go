@@ -0,0 +1,171 @@
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Session types
+const (
+	SessionHeavyBag     = "heavy_bag"
+	SessionShadowboxing = "shadowboxing"
+	SessionPads         = "pads"
+	SessionSpeedBag     = "speed_bag"
+	SessionDefense      = "defense" // slips, rolls and blocks against a partner
+)
+
+// DefaultSessionType is the type of sessions started without one.
+const DefaultSessionType = SessionHeavyBag
+
+// ErrUnknownSessionType is returned when a session type has no detection
+// profile.
+var ErrUnknownSessionType = errors.New("unknown session type")
+
+// DetectionProfile is the detection and classification parameter set a
+// session type runs with.
+type DetectionProfile struct {
+	Threshold  float64 `json:"threshold"`   // m/s² - acceleration above gravity for punch detection
+	DebounceMS int64   `json:"debounce_ms"` // milliseconds between valid punches
+
+	// Gyro classification thresholds (°/s)
+	HookGyro        float64 `json:"hook_gyro"`         // rotation around "up" axis for a hook
+	UppercutGyro    float64 `json:"uppercut_gyro"`     // rotation around the horizontal axes for an uppercut
+	StraightGyroMax float64 `json:"straight_gyro_max"` // max rotation for a straight
+
+	// Defense counts slips, rolls and blocks, and tells blocks from punches
+	Defense bool `json:"defense,omitempty"`
+}
+
+// DefaultDetectionProfiles returns the built-in profile of every session
+// type. Without a bag to hit, shadowboxing punches peak lower; pad work is
+// faster than bag work, and speed bag strikes are light and rapid. Defense
+// drills detect punches like the heavy bag, for the counters.
+func DefaultDetectionProfiles() map[string]DetectionProfile {
+	heavyBag := DetectionProfile{
+		Threshold:       punchThreshold,
+		DebounceMS:      debounceMS,
+		HookGyro:        hookGyroThresh,
+		UppercutGyro:    uppercutGyroThresh,
+		StraightGyroMax: straightGyroMax,
+	}
+	shadow, pads, speedBag, defense := heavyBag, heavyBag, heavyBag, heavyBag
+	shadow.Threshold, shadow.DebounceMS = 15, 250
+	pads.Threshold, pads.DebounceMS = 20, 250
+	speedBag.Threshold, speedBag.DebounceMS = 12, 120
+	speedBag.HookGyro, speedBag.UppercutGyro, speedBag.StraightGyroMax = 400, 300, 300 // the fist circles the bag on every strike
+	defense.Defense = true
+	return map[string]DetectionProfile{
+		SessionHeavyBag:     heavyBag,
+		SessionShadowboxing: shadow,
+		SessionPads:         pads,
+		SessionSpeedBag:     speedBag,
+		SessionDefense:      defense,
+	}
+}
+
+// LoadDetectionProfiles reads session types from a JSON file mapping each
+// type to its profile, over the defaults. Fields a type leaves out keep the
+// type's default, or the heavy bag's for a new type.
+func LoadDetectionProfiles(path string) (map[string]DetectionProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read session types: %w", err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decode session types: %w", err)
+	}
+	profiles := DefaultDetectionProfiles()
+	for name, msg := range raw {
+		p, ok := profiles[name]
+		if !ok {
+			p = profiles[DefaultSessionType]
+		}
+		if err := json.Unmarshal(msg, &p); err != nil {
+			return nil, fmt.Errorf("decode session type %q: %w", name, err)
+		}
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("session type %q: %w", name, err)
+		}
+		profiles[name] = p
+	}
+	return profiles, nil
+}
+
+// Validate checks that every parameter is positive.
+func (p DetectionProfile) Validate() error {
+	if p.Threshold <= 0 {
+		return fmt.Errorf("invalid threshold %v", p.Threshold)
+	}
+	if p.DebounceMS <= 0 {
+		return fmt.Errorf("invalid debounce %dms", p.DebounceMS)
+	}
+	if p.HookGyro <= 0 || p.UppercutGyro <= 0 || p.StraightGyroMax <= 0 {
+		return fmt.Errorf("invalid gyro thresholds: must be positive")
+	}
+	return nil
+}
+
+// heuristic returns the gyro-threshold classifier of the profile.
+func (p DetectionProfile) heuristic() HeuristicClassifier {
+	return HeuristicClassifier{HookGyro: p.HookGyro, UppercutGyro: p.UppercutGyro, StraightGyroMax: p.StraightGyroMax}
+}
+
+// SetDetectionProfiles replaces the session types sessions can be started
+// with. It must include DefaultSessionType; a session type that is no
+// longer offered falls back to it.
+func (a *Analyzer) SetDetectionProfiles(profiles map[string]DetectionProfile) error {
+	if _, ok := profiles[DefaultSessionType]; !ok {
+		return fmt.Errorf("no %s session type", DefaultSessionType)
+	}
+	for name, p := range profiles {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("session type %q: %w", name, err)
+		}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.profiles = make(map[string]DetectionProfile, len(profiles))
+	for name, p := range profiles {
+		a.profiles[name] = p
+	}
+	if _, ok := a.profiles[a.sessionType]; !ok {
+		a.sessionType = DefaultSessionType
+	}
+	return nil
+}
+
+// DetectionProfiles returns the profile of every session type.
+func (a *Analyzer) DetectionProfiles() map[string]DetectionProfile {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	profiles := make(map[string]DetectionProfile, len(a.profiles))
+	for name, p := range a.profiles {
+		profiles[name] = p
+	}
+	return profiles
+}
+
+// SetSessionType selects the session type, and so the detection profile,
+// of new sessions. "" selects DefaultSessionType.
+func (a *Analyzer) SetSessionType(sessionType string) error {
+	if sessionType == "" {
+		sessionType = DefaultSessionType
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.profiles[sessionType]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownSessionType, sessionType)
+	}
+	a.sessionType = sessionType
+	return nil
+}
+
+// SessionType returns the type of the current session.
+func (a *Analyzer) SessionType() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.detectionType
+}
@@ -0,0 +1,76 @@
+package analytics
+
+// SparringState is broadcast instead of SessionState in sparring mode, where
+// two athletes ("a" and "b") each wear a pair of gloves and each has an
+// Analyzer of their own.
+type SparringState struct {
+	Type       string             `json:"type"` // always "sparring"
+	A          *SessionState      `json:"a"`
+	B          *SessionState      `json:"b"`
+	Comparison SparringComparison `json:"comparison"`
+}
+
+// SparringComparison puts the two athletes' output side by side. Ratios are
+// A over B and stay zero while B has nothing to compare against.
+type SparringComparison struct {
+	PunchShareA    float64 `json:"punch_share_a"`   // A's share of all punches (0-1)
+	PunchDiff      int     `json:"punch_diff"`      // A punches - B punches
+	ForceRatio     float64 `json:"force_ratio"`     // A avg force / B avg force
+	PPMDiff        float64 `json:"ppm_diff"`        // A ppm - B ppm
+	IntensityDiff  int     `json:"intensity_diff"`  // A intensity score - B intensity score
+	Leader         string  `json:"leader"`          // more punches: "a", "b", or "" when level
+	HardestPuncher string  `json:"hardest_puncher"` // higher max force: "a", "b", or ""
+}
+
+// CopySettings applies the detection and warning settings of src to a, so
+// the second athlete's analyzer in sparring mode behaves like the first.
+// Athlete-specific settings (athlete, stance, max heart rate) are not copied.
+func (a *Analyzer) CopySettings(src *Analyzer) {
+	src.mu.RLock()
+	autoThreshold, spectrum, classifier := src.autoThreshold, src.spectrum, src.classifier
+	balanceMinShare, batteryThresholds, roundLength := src.balanceMinShare, src.batteryThresholds, src.roundLength
+	profiles, sessionType, forceEdges := src.profiles, src.sessionType, src.forceEdges
+	src.mu.RUnlock()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.autoThreshold = autoThreshold
+	a.spectrum = spectrum
+	a.classifier = classifier
+	a.balanceMinShare = balanceMinShare
+	a.batteryThresholds = batteryThresholds
+	a.roundLength = roundLength
+	a.profiles = profiles
+	a.sessionType = sessionType
+	a.forceEdges = forceEdges
+}
+
+// NewSparringState combines the session states of two athletes.
+func NewSparringState(a, b *SessionState) *SparringState {
+	ca, cb := a.Combined, b.Combined
+	cmp := SparringComparison{
+		PunchDiff:      ca.TotalPunches - cb.TotalPunches,
+		PPMDiff:        ca.PunchesPerMin - cb.PunchesPerMin,
+		IntensityDiff:  ca.IntensityScore - cb.IntensityScore,
+		Leader:         ahead(float64(ca.TotalPunches), float64(cb.TotalPunches)),
+		HardestPuncher: ahead(ca.MaxForce, cb.MaxForce),
+	}
+	if total := ca.TotalPunches + cb.TotalPunches; total > 0 {
+		cmp.PunchShareA = float64(ca.TotalPunches) / float64(total)
+	}
+	if cb.AvgForce > 0 {
+		cmp.ForceRatio = ca.AvgForce / cb.AvgForce
+	}
+	return &SparringState{Type: "sparring", A: a, B: b, Comparison: cmp}
+}
+
+// ahead names the athlete with the higher value, or "" when level.
+func ahead(a, b float64) string {
+	switch {
+	case a > b:
+		return "a"
+	case b > a:
+		return "b"
+	}
+	return ""
+}
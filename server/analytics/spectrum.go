@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Spectrum analysis constants
+const (
+	spectrumSize     = 256   // samples per FFT (2.56s at 100Hz)
+	sampleRateHz     = 100.0 // glove sample rate
+	rhythmMinHz      = 0.5   // lowest rhythm frequency considered
+	rhythmMaxHz      = 8.0   // highest rhythm frequency (fast speed-bag work)
+	ringingMinHz     = 12.0  // bag and glove ringing sits above punch rhythms
+	spectrumMinPower = 1.0   // band power below which the hand is considered idle
+)
+
+// SpectrumStats describes the frequency content of a hand's recent motion.
+type SpectrumStats struct {
+	DominantHz   float64 `json:"dominant_hz"`   // strongest rhythm frequency (0 = idle)
+	Consistency  float64 `json:"consistency"`   // 0-1, share of rhythm power at the dominant frequency
+	RingingShare float64 `json:"ringing_share"` // 0-1, share of power above ringingMinHz
+}
+
+// SetSpectrumAnalysis enables or disables the FFT stage. It is off by
+// default since most sessions do not need it.
+func (a *Analyzer) SetSpectrumAnalysis(enabled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.spectrum = enabled
+	if !enabled {
+		for _, h := range []*HandState{a.left, a.right} {
+			h.spectrumBuf = nil
+			h.Spectrum = SpectrumStats{}
+		}
+	}
+}
+
+// pushSpectrum appends a magnitude sample to the hand's FFT window.
+func (h *HandState) pushSpectrum(mag float64) {
+	h.spectrumBuf = append(h.spectrumBuf, mag)
+	if len(h.spectrumBuf) > spectrumSize {
+		h.spectrumBuf = h.spectrumBuf[1:]
+	}
+}
+
+// updateSpectrum recomputes the hand's spectrum stats once its window is full.
+func (h *HandState) updateSpectrum() {
+	if len(h.spectrumBuf) < spectrumSize {
+		return
+	}
+	h.Spectrum = analyzeSpectrum(h.spectrumBuf)
+}
+
+// analyzeSpectrum runs a Hann-windowed FFT over samples (len a power of
+// two) and summarises the rhythm band and the ringing band.
+func analyzeSpectrum(samples []float64) SpectrumStats {
+	n := len(samples)
+	mean := 0.0
+	for _, v := range samples {
+		mean += v
+	}
+	mean /= float64(n)
+
+	x := make([]complex128, n)
+	for i, v := range samples {
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+		x[i] = complex((v-mean)*w, 0)
+	}
+	fft(x)
+
+	binHz := sampleRateHz / float64(n)
+	var total, rhythm, ringing, peak float64
+	peakBin := 0
+	for k := 1; k <= n/2; k++ {
+		p := real(x[k])*real(x[k]) + imag(x[k])*imag(x[k])
+		f := float64(k) * binHz
+		total += p
+		switch {
+		case f >= rhythmMinHz && f <= rhythmMaxHz:
+			rhythm += p
+			if p > peak {
+				peak, peakBin = p, k
+			}
+		case f >= ringingMinHz:
+			ringing += p
+		}
+	}
+
+	var s SpectrumStats
+	if total < spectrumMinPower {
+		return s
+	}
+	s.RingingShare = math.Round(ringing/total*1000) / 1000
+	if rhythm >= spectrumMinPower && peakBin > 0 {
+		// Count the neighbouring bins too: Hann windowing spreads a pure tone
+		energy := peak
+		if peakBin > 1 {
+			energy += cmplx.Abs(x[peakBin-1]) * cmplx.Abs(x[peakBin-1])
+		}
+		energy += cmplx.Abs(x[peakBin+1]) * cmplx.Abs(x[peakBin+1])
+		s.DominantHz = math.Round(float64(peakBin)*binHz*100) / 100
+		s.Consistency = math.Round(math.Min(1, energy/rhythm)*1000) / 1000
+	}
+	return s
+}
+
+// fft computes an in-place radix-2 Cooley-Tukey FFT. len(x) must be a
+// power of two.
+func fft(x []complex128) {
+	n := len(x)
+
+	// Bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u := x[start+k]
+				v := w * x[start+k+size/2]
+				x[start+k] = u + v
+				x[start+k+size/2] = u - v
+				w *= step
+			}
+		}
+	}
+}
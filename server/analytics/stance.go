@@ -0,0 +1,107 @@
+package analytics
+
+import (
+	"math"
+
+	"boxing-analytics/ble"
+)
+
+// Stance and guard inference constants
+const (
+	stanceMinStraights = 12   // straights (both hands) before a stance is inferred
+	stanceCountRatio   = 1.3  // lead hand throws this many times more straights
+	stanceForceRatio   = 1.1  // or the rear hand's straights are this much harder
+	guardDropAngle     = 50.0 // degrees of tilt away from the calibrated guard
+	guardDropHoldMS    = 1500 // ms the tilt must last to count as a dropped guard
+)
+
+// GuardEvent is the payload of a "guard_dropped" event.
+type GuardEvent struct {
+	Tilt  float64 `json:"tilt"`  // degrees away from the guard position
+	Drops int     `json:"drops"` // guard drops by this hand in the session
+}
+
+// recordStraightLocked adds a straight punch to the stance evidence and
+// re-infers the stance.
+// Must be called with a.mu held.
+func (a *Analyzer) recordStraightLocked(state *HandState, force float64) {
+	state.straightCount++
+	state.straightForceSum += force
+	a.inferStanceLocked()
+}
+
+// inferStanceLocked decides which hand leads from the straights thrown so
+// far: the lead hand jabs more often and less hard than the rear hand.
+// Must be called with a.mu held.
+func (a *Analyzer) inferStanceLocked() {
+	l, r := a.left, a.right
+	if l.straightCount+r.straightCount < stanceMinStraights || l.straightCount == 0 || r.straightCount == 0 {
+		return
+	}
+	lc, rc := float64(l.straightCount), float64(r.straightCount)
+	lf, rf := l.straightForceSum/lc, r.straightForceSum/rc
+
+	switch {
+	case lc >= rc*stanceCountRatio, rf >= lf*stanceForceRatio && lc >= rc:
+		a.inferredStance = StanceOrthodox
+	case rc >= lc*stanceCountRatio, lf >= rf*stanceForceRatio && rc >= lc:
+		a.inferredStance = StanceSouthpaw
+	}
+	l.Lead = a.inferredStance == StanceOrthodox
+	r.Lead = a.inferredStance == StanceSouthpaw
+}
+
+// effectiveStanceLocked returns the configured stance, or the inferred one
+// when the athlete's stance is unknown.
+// Must be called with a.mu held.
+func (a *Analyzer) effectiveStanceLocked() string {
+	if a.stance != "" {
+		return a.stance
+	}
+	return a.inferredStance
+}
+
+// updateGuardLocked tracks whether a glove has left the guard position. The
+// calibration pose is taken as the guard; a glove tilted well away from it
+// for a sustained period, without punching, has dropped its guard.
+// Must be called with a.mu held.
+func (a *Analyzer) updateGuardLocked(hand ble.Hand, state *HandState, ts int64) {
+	tilt := guardTilt(state.fusion.q, state.GravityRef)
+	if tilt < guardDropAngle || state.pending != nil {
+		if state.GuardDropped {
+			state.GuardDownSec += float64(ts-state.guardDownTS) / 1000
+		}
+		state.guardTiltTS = 0
+		state.GuardDropped = false
+		return
+	}
+
+	if state.guardTiltTS == 0 {
+		state.guardTiltTS = ts
+	}
+	if !state.GuardDropped && ts-state.guardTiltTS >= guardDropHoldMS {
+		state.GuardDropped = true
+		state.GuardDrops++
+		state.guardDownTS = ts
+		a.emitLocked("guard_dropped", hand.String(), GuardEvent{
+			Tilt:  math.Round(tilt),
+			Drops: state.GuardDrops,
+		})
+		a.broadcastLocked()
+	}
+}
+
+// guardTilt returns the angle in degrees between the calibrated gravity
+// direction and the one implied by the current orientation, both in the
+// sensor frame.
+func guardTilt(q Quaternion, ref [3]float64) float64 {
+	n := math.Sqrt(ref[0]*ref[0] + ref[1]*ref[1] + ref[2]*ref[2])
+	if n == 0 {
+		return 0
+	}
+	// Rotate world up back into the sensor frame
+	w, x, y, z := q[0], q[1], q[2], q[3]
+	up := [3]float64{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)}
+	dot := (up[0]*ref[0] + up[1]*ref[1] + up[2]*ref[2]) / n
+	return math.Acos(math.Max(-1, math.Min(1, dot))) / degToRad
+}
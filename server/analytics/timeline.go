@@ -0,0 +1,160 @@
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+// TimelineBaseResolution is the finest bucket width kept for the session.
+const TimelineBaseResolution = 100 * time.Millisecond
+
+// TimelineBucket summarises acceleration magnitude over one interval.
+type TimelineBucket struct {
+	T   int64   `json:"t"` // bucket start, ms since session start
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+	N   int     `json:"n"` // samples in the bucket
+}
+
+// Timeline is a downsampled magnitude waveform for both hands. Intervals
+// without samples (paused, disconnected) have no bucket.
+type Timeline struct {
+	ResolutionMS int64            `json:"resolution_ms"`
+	Left         []TimelineBucket `json:"left"`
+	Right        []TimelineBucket `json:"right"`
+}
+
+// magBucket accumulates samples for one base-resolution interval.
+type magBucket struct {
+	min, max, sum float64
+	n             int
+}
+
+// timelineTracker holds a hand's magnitude buckets for the whole session.
+type timelineTracker struct {
+	buckets []magBucket
+}
+
+// add records a sample taken elapsed after the session started.
+func (t *timelineTracker) add(elapsed time.Duration, mag float64) {
+	i := int(elapsed / TimelineBaseResolution)
+	if i < 0 {
+		return
+	}
+	for len(t.buckets) <= i {
+		t.buckets = append(t.buckets, magBucket{})
+	}
+	b := &t.buckets[i]
+	if b.n == 0 || mag < b.min {
+		b.min = mag
+	}
+	if b.n == 0 || mag > b.max {
+		b.max = mag
+	}
+	b.sum += mag
+	b.n++
+}
+
+// resample merges base buckets into buckets of factor× the base width.
+func (t *timelineTracker) resample(factor int) []TimelineBucket {
+	out := []TimelineBucket{}
+	width := int64(factor) * TimelineBaseResolution.Milliseconds()
+	for start := 0; start < len(t.buckets); start += factor {
+		var m magBucket
+		for _, b := range t.buckets[start:min(start+factor, len(t.buckets))] {
+			if b.n == 0 {
+				continue
+			}
+			if m.n == 0 || b.min < m.min {
+				m.min = b.min
+			}
+			if m.n == 0 || b.max > m.max {
+				m.max = b.max
+			}
+			m.sum += b.sum
+			m.n += b.n
+		}
+		if m.n == 0 {
+			continue
+		}
+		out = append(out, TimelineBucket{
+			T:   int64(start/factor) * width,
+			Min: round2(m.min),
+			Avg: round2(m.sum / float64(m.n)),
+			Max: round2(m.max),
+			N:   m.n,
+		})
+	}
+	return out
+}
+
+// Timeline returns the current session's magnitude waveform at the given
+// resolution, rounded down to a multiple of TimelineBaseResolution.
+func (a *Analyzer) Timeline(resolution time.Duration) *Timeline {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.timelineLocked(resolution)
+}
+
+// timelineLocked builds a Timeline.
+// Must be called with a.mu held.
+func (a *Analyzer) timelineLocked(resolution time.Duration) *Timeline {
+	factor := int(resolution / TimelineBaseResolution)
+	if factor < 1 {
+		factor = 1
+	}
+	return &Timeline{
+		ResolutionMS: int64(factor) * TimelineBaseResolution.Milliseconds(),
+		Left:         a.left.timeline.resample(factor),
+		Right:        a.right.timeline.resample(factor),
+	}
+}
+
+// Resample returns the timeline at a coarser resolution. Buckets are merged
+// weighted by their sample counts; finer resolutions than stored are not
+// possible and return the timeline unchanged.
+func (t *Timeline) Resample(resolution time.Duration) *Timeline {
+	if t.ResolutionMS <= 0 {
+		return t
+	}
+	width := resolution.Milliseconds() / t.ResolutionMS * t.ResolutionMS
+	if width <= t.ResolutionMS {
+		return t
+	}
+	return &Timeline{
+		ResolutionMS: width,
+		Left:         resampleBuckets(t.Left, width),
+		Right:        resampleBuckets(t.Right, width),
+	}
+}
+
+// resampleBuckets merges time-ordered buckets into buckets of width ms.
+func resampleBuckets(in []TimelineBucket, width int64) []TimelineBucket {
+	out := []TimelineBucket{}
+	var sum float64
+	for _, b := range in {
+		t := b.T / width * width
+		if len(out) == 0 || out[len(out)-1].T != t {
+			if len(out) > 0 {
+				out[len(out)-1].Avg = round2(sum / float64(out[len(out)-1].N))
+			}
+			out = append(out, TimelineBucket{T: t, Min: b.Min, Max: b.Max})
+			sum = 0
+		}
+		last := &out[len(out)-1]
+		last.Min = math.Min(last.Min, b.Min)
+		last.Max = math.Max(last.Max, b.Max)
+		last.N += b.N
+		sum += b.Avg * float64(b.N)
+	}
+	if len(out) > 0 {
+		out[len(out)-1].Avg = round2(sum / float64(out[len(out)-1].N))
+	}
+	return out
+}
+
+// round2 rounds to two decimal places.
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
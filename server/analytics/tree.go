@@ -0,0 +1,112 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// treeFeatures maps feature names usable in a decision tree to their values.
+var treeFeatures = map[string]func(f *Features) float64{
+	"gx":              func(f *Features) float64 { return f.GX },
+	"gy":              func(f *Features) float64 { return f.GY },
+	"gz":              func(f *Features) float64 { return f.GZ },
+	"gyro_up":         func(f *Features) float64 { return f.GyroUp },
+	"gyro_horizontal": func(f *Features) float64 { return f.GyroHorizontal },
+	"max_gyro_up":     func(f *Features) float64 { return f.MaxGyroUp },
+	"max_gyro_horiz":  func(f *Features) float64 { return f.MaxGyroHoriz },
+	"force":           func(f *Features) float64 { return f.Force },
+	"rfd":             func(f *Features) float64 { return f.RFD },
+	"duration_ms":     func(f *Features) float64 { return f.DurationMS },
+	"lift":            func(f *Features) float64 { return f.Lift },
+	"horizontal":      func(f *Features) float64 { return f.Trajectory.Horizontal },
+	"vertical":        func(f *Features) float64 { return f.Trajectory.Vertical },
+	"elevation":       func(f *Features) float64 { return f.Trajectory.Elevation },
+}
+
+// treeLabels are the punch types a leaf may give. Straights are refined into
+// jabs and crosses afterwards, as for any Classifier.
+var treeLabels = map[PunchType]bool{
+	PunchStraight: true,
+	PunchJab:      true,
+	PunchCross:    true,
+	PunchHook:     true,
+	PunchUppercut: true,
+	PunchUnknown:  true,
+}
+
+// TreeNode is one node of a decision tree. Leaves set Label; split nodes go
+// to Left when the feature is <= Threshold and to Right otherwise.
+type TreeNode struct {
+	Feature   string    `json:"feature,omitempty"`
+	Threshold float64   `json:"threshold,omitempty"`
+	Left      int       `json:"left,omitempty"`
+	Right     int       `json:"right,omitempty"`
+	Label     PunchType `json:"label,omitempty"`
+}
+
+// DecisionTree is a trained classifier stored as a flat node list, root
+// first, as exported by common tree learners.
+type DecisionTree struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description,omitempty"` // where the tree came from
+	Nodes       []TreeNode `json:"nodes"`
+}
+
+// LoadDecisionTree reads and validates a decision tree model file.
+func LoadDecisionTree(path string) (*DecisionTree, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read model: %w", err)
+	}
+	var t DecisionTree
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("decode model: %w", err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("model %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+// Validate checks that every split uses a known feature, every child index
+// is in range and points forward (so evaluation always terminates), and
+// every leaf is labelled with a punch type.
+func (t *DecisionTree) Validate() error {
+	if len(t.Nodes) == 0 {
+		return fmt.Errorf("tree has no nodes")
+	}
+	for i, n := range t.Nodes {
+		if n.Label != "" {
+			if !treeLabels[n.Label] {
+				return fmt.Errorf("node %d: unknown punch type %q", i, n.Label)
+			}
+			continue
+		}
+		if _, ok := treeFeatures[n.Feature]; !ok {
+			return fmt.Errorf("node %d: unknown feature %q", i, n.Feature)
+		}
+		for _, c := range []int{n.Left, n.Right} {
+			if c <= i || c >= len(t.Nodes) {
+				return fmt.Errorf("node %d: invalid child %d", i, c)
+			}
+		}
+	}
+	return nil
+}
+
+// Classify walks the tree from the root to a leaf.
+func (t *DecisionTree) Classify(f Features) PunchType {
+	i := 0
+	for {
+		n := &t.Nodes[i]
+		if n.Label != "" {
+			return n.Label
+		}
+		if treeFeatures[n.Feature](&f) <= n.Threshold {
+			i = n.Left
+		} else {
+			i = n.Right
+		}
+	}
+}
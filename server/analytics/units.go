@@ -0,0 +1,246 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Units and unit systems
+const (
+	UnitMS2        = "m/s2"
+	UnitG          = "g"
+	UnitNewton     = "N"
+	UnitPoundForce = "lbf"
+	UnitKg         = "kg"
+	UnitLb         = "lb"
+
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// Conversion factors
+const (
+	standardGravity = 9.80665 // m/s² per g
+	newtonsPerLbf   = 4.4482216152605
+	kgPerLb         = 0.45359237
+)
+
+// Units are what values are reported in, converted from the m/s², N and kg
+// the analyzer works in. Empty fields keep those.
+type Units struct {
+	Accel string `json:"accel,omitempty"` // "m/s2" or "g": forces, thresholds and rate of force development
+	Force string `json:"force,omitempty"` // "N" or "lbf": power estimates
+	Mass  string `json:"mass,omitempty"`  // "kg" or "lb"
+}
+
+// MetricUnits are the analyzer's own units.
+func MetricUnits() Units {
+	return Units{Accel: UnitMS2, Force: UnitNewton, Mass: UnitKg}
+}
+
+// ImperialUnits report accelerations in g, forces in lbf and masses in lb.
+func ImperialUnits() Units {
+	return Units{Accel: UnitG, Force: UnitPoundForce, Mass: UnitLb}
+}
+
+// ParseUnits parses a unit preference: "metric", "imperial", or a list of
+// quantities and units such as "accel=g,mass=lb" where quantities left out
+// stay metric.
+func ParseUnits(s string) (Units, error) {
+	switch s = strings.TrimSpace(s); s {
+	case "", UnitsMetric:
+		return MetricUnits(), nil
+	case UnitsImperial:
+		return ImperialUnits(), nil
+	}
+	u := MetricUnits()
+	for _, part := range strings.Split(s, ",") {
+		quantity, unit, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return Units{}, fmt.Errorf("invalid units %q: want metric, imperial or quantity=unit pairs", s)
+		}
+		switch quantity {
+		case "accel":
+			u.Accel = unit
+		case "force":
+			u.Force = unit
+		case "mass":
+			u.Mass = unit
+		default:
+			return Units{}, fmt.Errorf("unknown quantity %q: must be accel, force or mass", quantity)
+		}
+	}
+	return u, u.Validate()
+}
+
+// Validate checks that each unit is known.
+func (u Units) Validate() error {
+	switch u.Accel {
+	case "", UnitMS2, UnitG:
+	default:
+		return fmt.Errorf("invalid accel unit %q: must be '%s' or '%s'", u.Accel, UnitMS2, UnitG)
+	}
+	switch u.Force {
+	case "", UnitNewton, UnitPoundForce:
+	default:
+		return fmt.Errorf("invalid force unit %q: must be '%s' or '%s'", u.Force, UnitNewton, UnitPoundForce)
+	}
+	switch u.Mass {
+	case "", UnitKg, UnitLb:
+	default:
+		return fmt.Errorf("invalid mass unit %q: must be '%s' or '%s'", u.Mass, UnitKg, UnitLb)
+	}
+	return nil
+}
+
+// IsMetric reports whether u needs no conversion.
+func (u Units) IsMetric() bool {
+	return u.Accel != UnitG && u.Force != UnitPoundForce && u.Mass != UnitLb
+}
+
+// Normalized returns u with empty fields set to the metric units.
+func (u Units) Normalized() Units {
+	m := MetricUnits()
+	if u.Accel == "" {
+		u.Accel = m.Accel
+	}
+	if u.Force == "" {
+		u.Force = m.Force
+	}
+	if u.Mass == "" {
+		u.Mass = m.Mass
+	}
+	return u
+}
+
+// ConvertAccel converts m/s² (or m/s³, for rates of force development) to
+// u.
+func (u Units) ConvertAccel(v float64) float64 {
+	if u.Accel != UnitG {
+		return v
+	}
+	return math.Round(v/standardGravity*1000) / 1000
+}
+
+// ConvertForce converts N to u.
+func (u Units) ConvertForce(v float64) float64 {
+	if u.Force != UnitPoundForce {
+		return v
+	}
+	return math.Round(v/newtonsPerLbf*10) / 10
+}
+
+// ConvertMass converts kg to u.
+func (u Units) ConvertMass(v float64) float64 {
+	if u.Mass != UnitLb {
+		return v
+	}
+	return math.Round(v/kgPerLb*100) / 100
+}
+
+// InUnits returns a copy of the state with its accelerations, forces and
+// masses in u, which it is tagged with. Metric units return the state as
+// it is.
+func (s *SessionState) InUnits(u Units) *SessionState {
+	if s == nil || u.IsMetric() {
+		return s
+	}
+	out := *s
+	out.Left = s.Left.inUnits(u)
+	out.Right = s.Right.inUnits(u)
+	out.Combined = s.Combined.inUnits(u)
+	out.EffectiveMass = u.ConvertMass(s.EffectiveMass)
+	units := u.Normalized()
+	out.Units = &units
+	return &out
+}
+
+// inUnits returns a copy of h converted to u.
+func (h *HandState) inUnits(u Units) *HandState {
+	if h == nil {
+		return nil
+	}
+	out := *h
+	out.MaxForce = u.ConvertAccel(h.MaxForce)
+	out.AvgForce = u.ConvertAccel(h.AvgForce)
+	out.MaxPower = u.ConvertForce(h.MaxPower)
+	out.AvgPower = u.ConvertForce(h.AvgPower)
+	out.ForceHistogram = bucketsInUnits(h.ForceHistogram, u)
+	out.RecentPunches = make([]PunchEvent, len(h.RecentPunches))
+	for i, p := range h.RecentPunches {
+		out.RecentPunches[i] = p.InUnits(u)
+	}
+	for i := range h.CurrentAccel {
+		out.CurrentAccel[i] = u.ConvertAccel(h.CurrentAccel[i])
+		out.GravityRef[i] = u.ConvertAccel(h.GravityRef[i])
+	}
+	out.Threshold = u.ConvertAccel(h.Threshold)
+	out.AvgRFD = u.ConvertAccel(h.AvgRFD)
+	out.RFDByType = make(map[string]float64, len(h.RFDByType))
+	for k, v := range h.RFDByType {
+		out.RFDByType[k] = u.ConvertAccel(v)
+	}
+	return &out
+}
+
+// inUnits returns a copy of c converted to u.
+func (c CombinedStats) inUnits(u Units) CombinedStats {
+	c.AvgForce = u.ConvertAccel(c.AvgForce)
+	c.MaxForce = u.ConvertAccel(c.MaxForce)
+	c.AvgPower = u.ConvertForce(c.AvgPower)
+	c.MaxPower = u.ConvertForce(c.MaxPower)
+	c.ForceHistogram = bucketsInUnits(c.ForceHistogram, u)
+	return c
+}
+
+// InUnits returns the punch with its force, rate of force development and
+// power estimate in u.
+func (p PunchEvent) InUnits(u Units) PunchEvent {
+	p.Force = u.ConvertAccel(p.Force)
+	p.RFD = u.ConvertAccel(p.RFD)
+	p.Power = u.ConvertForce(p.Power)
+	return p
+}
+
+// bucketsInUnits returns a copy of the force histogram with its edges in u.
+func bucketsInUnits(buckets []ForceBucket, u Units) []ForceBucket {
+	if buckets == nil {
+		return nil
+	}
+	out := make([]ForceBucket, len(buckets))
+	for i, b := range buckets {
+		out[i] = ForceBucket{From: u.ConvertAccel(b.From), To: u.ConvertAccel(b.To), Count: b.Count}
+	}
+	return out
+}
+
+// InUnits returns a copy of the timeline with its magnitudes in u.
+func (t *Timeline) InUnits(u Units) *Timeline {
+	if t == nil || u.IsMetric() {
+		return t
+	}
+	convert := func(buckets []TimelineBucket) []TimelineBucket {
+		out := make([]TimelineBucket, len(buckets))
+		for i, b := range buckets {
+			out[i] = TimelineBucket{T: b.T, Min: u.ConvertAccel(b.Min), Avg: u.ConvertAccel(b.Avg), Max: u.ConvertAccel(b.Max), N: b.N}
+		}
+		return out
+	}
+	return &Timeline{ResolutionMS: t.ResolutionMS, Left: convert(t.Left), Right: convert(t.Right)}
+}
+
+// InUnits returns a copy of the heatmap with its average forces in u.
+func (h *Heatmap) InUnits(u Units) *Heatmap {
+	if h == nil || u.IsMetric() {
+		return h
+	}
+	convert := func(bins []HeatmapBin) []HeatmapBin {
+		out := make([]HeatmapBin, len(bins))
+		for i, b := range bins {
+			out[i] = HeatmapBin{T: b.T, Punches: b.Punches, AvgForce: u.ConvertAccel(b.AvgForce)}
+		}
+		return out
+	}
+	return &Heatmap{BinMS: h.BinMS, Left: convert(h.Left), Right: convert(h.Right), Combined: convert(h.Combined)}
+}
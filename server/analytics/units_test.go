@@ -0,0 +1,96 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Units
+		err  string // substring of the error, "" = valid
+	}{
+		{in: "", want: MetricUnits()},
+		{in: "metric", want: MetricUnits()},
+		{in: " imperial ", want: ImperialUnits()},
+		{in: "accel=g", want: Units{Accel: UnitG, Force: UnitNewton, Mass: UnitKg}},
+		{in: "accel=g, mass=lb", want: Units{Accel: UnitG, Force: UnitNewton, Mass: UnitLb}},
+		{in: "force=lbf,accel=m/s2", want: Units{Accel: UnitMS2, Force: UnitPoundForce, Mass: UnitKg}},
+		{in: "g", err: "quantity=unit"},
+		{in: "speed=mph", err: "unknown quantity"},
+		{in: "accel=ft/s2", err: "accel unit"},
+		{in: "mass=kg,force=kgf", err: "force unit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseUnits(tt.in)
+			switch {
+			case tt.err != "":
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("ParseUnits(%q) error = %v, want one about %s", tt.in, err, tt.err)
+				}
+			case err != nil:
+				t.Errorf("ParseUnits(%q) error = %v", tt.in, err)
+			case got != tt.want:
+				t.Errorf("ParseUnits(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnitConversion(t *testing.T) {
+	imperial := ImperialUnits()
+	tests := []struct {
+		name    string
+		convert func(float64) float64
+		in      float64
+		want    float64
+	}{
+		{"1 g", imperial.ConvertAccel, 9.80665, 1},
+		{"accel rounds to 3 decimals", imperial.ConvertAccel, 45, 4.589},
+		{"1 lbf", imperial.ConvertForce, 4.4482216152605, 1},
+		{"force rounds to 1 decimal", imperial.ConvertForce, 1000, 224.8},
+		{"1 lb", imperial.ConvertMass, 0.45359237, 1},
+		{"mass rounds to 2 decimals", imperial.ConvertMass, 70, 154.32},
+		{"metric accel", MetricUnits().ConvertAccel, 45.678, 45.678},
+		{"metric force", MetricUnits().ConvertForce, 1234.5678, 1234.5678},
+		{"metric mass", Units{}.ConvertMass, 70.123, 70.123},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.convert(tt.in); got != tt.want {
+				t.Errorf("convert(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionStateInUnits(t *testing.T) {
+	state := &SessionState{
+		Left:          &HandState{MaxForce: 98.0665, MaxPower: 444.82216152605, RecentPunches: []PunchEvent{{Force: 19.6133}}},
+		Right:         &HandState{},
+		Combined:      CombinedStats{MaxForce: 98.0665},
+		EffectiveMass: 4.5359237,
+	}
+	if got := state.InUnits(MetricUnits()); got != state {
+		t.Error("metric InUnits copied the state")
+	}
+
+	got := state.InUnits(Units{Accel: UnitG, Mass: UnitLb})
+	if got.Units == nil || *got.Units != (Units{Accel: UnitG, Force: UnitNewton, Mass: UnitLb}) {
+		t.Errorf("Units = %+v, want g, N and lb", got.Units)
+	}
+	if got.Left.MaxForce != 10 || got.Combined.MaxForce != 10 || got.Left.RecentPunches[0].Force != 2 {
+		t.Errorf("forces = %v, %v, %v, want 10, 10, 2", got.Left.MaxForce, got.Combined.MaxForce, got.Left.RecentPunches[0].Force)
+	}
+	if got.Left.MaxPower != state.Left.MaxPower {
+		t.Errorf("MaxPower = %v, want it left in N", got.Left.MaxPower)
+	}
+	if got.EffectiveMass != 10 {
+		t.Errorf("EffectiveMass = %v, want 10", got.EffectiveMass)
+	}
+	if state.Left.MaxForce != 98.0665 || state.Units != nil {
+		t.Error("InUnits changed the original state")
+	}
+}
@@ -0,0 +1,241 @@
+// Package assets serves the dashboard's static files with caching headers
+// and compressed variants, so tablets reloading the dashboard revalidate it
+// instead of downloading the whole bundle from the Pi again.
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	// hashedDir holds Vite's build output, whose file names carry a hash of
+	// their content, so it can be cached for good.
+	hashedDir      = "assets/"
+	immutableCache = "public, max-age=31536000, immutable"
+	// Everything else (index.html) is revalidated against its ETag.
+	revalidateCache = "no-cache"
+
+	minCompressSize = 1024 // bytes; smaller files are sent as they are
+	brotliQuality   = 9    // compressed once per file, so favor size
+)
+
+// Content codings in order of preference, with the file extension of
+// variants compressed at build time.
+var codings = []struct {
+	name, ext string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Handler serves the files of an fs.FS. Each file is read, hashed and
+// compressed once, on its first request; files that change on disk (a
+// -static-dir) are reloaded. Variants compressed ahead of time
+// ("index.js.br", "index.js.gz") are used instead of compressing.
+type Handler struct {
+	fsys fs.FS
+
+	mu    sync.Mutex
+	files map[string]*file
+}
+
+// file is a loaded file with its encodings.
+type file struct {
+	size        int64
+	modTime     time.Time
+	contentType string
+	hash        string
+	bodies      map[string][]byte // by content coding, "" = identity
+}
+
+// NewHandler creates a handler serving fsys.
+func NewHandler(fsys fs.FS) *Handler {
+	return &Handler{fsys: fsys, files: make(map[string]*file)}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "GET only", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	f, name, err := h.load(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "read failed", http.StatusInternalServerError)
+		return
+	}
+
+	hdr := w.Header()
+	hdr.Set("Content-Type", f.contentType)
+	if strings.HasPrefix(name, hashedDir) {
+		hdr.Set("Cache-Control", immutableCache)
+	} else {
+		hdr.Set("Cache-Control", revalidateCache)
+	}
+	if len(f.bodies) > 1 {
+		hdr.Add("Vary", "Accept-Encoding")
+	}
+	coding := negotiate(r.Header.Get("Accept-Encoding"), f)
+	if coding == "" {
+		hdr.Set("ETag", `"`+f.hash+`"`)
+	} else {
+		hdr.Set("ETag", `"`+f.hash+"-"+coding+`"`)
+		hdr.Set("Content-Encoding", coding)
+	}
+	http.ServeContent(w, r, name, f.modTime, bytes.NewReader(f.bodies[coding]))
+}
+
+// Preload loads and compresses every file up front, so the first tablet
+// to open the dashboard does not wait for the bundle to be compressed.
+func (h *Handler) Preload() error {
+	return fs.WalkDir(h.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		for _, c := range codings {
+			if strings.HasSuffix(name, c.ext) {
+				return nil // a variant, loaded with its file
+			}
+		}
+		_, _, err = h.load(name)
+		return err
+	})
+}
+
+// load returns a file ("" or a directory meaning its index.html) and its
+// resolved name, reading it unless it is cached and unchanged.
+func (h *Handler) load(name string) (*file, string, error) {
+	if name == "" {
+		name = "index.html"
+	}
+	info, err := fs.Stat(h.fsys, name)
+	if err == nil && info.IsDir() {
+		name = path.Join(name, "index.html")
+		info, err = fs.Stat(h.fsys, name)
+	}
+	if err != nil {
+		return nil, name, err
+	}
+
+	h.mu.Lock()
+	f := h.files[name]
+	h.mu.Unlock()
+	if f != nil && f.size == info.Size() && f.modTime.Equal(info.ModTime()) {
+		return f, name, nil
+	}
+
+	data, err := fs.ReadFile(h.fsys, name)
+	if err != nil {
+		return nil, name, err
+	}
+	sum := sha256.Sum256(data)
+	f = &file{
+		size:        info.Size(),
+		modTime:     info.ModTime(),
+		contentType: mime.TypeByExtension(path.Ext(name)),
+		hash:        hex.EncodeToString(sum[:8]),
+		bodies:      map[string][]byte{"": data},
+	}
+	if f.contentType == "" {
+		f.contentType = http.DetectContentType(data)
+	}
+	for _, c := range codings {
+		if body, err := fs.ReadFile(h.fsys, name+c.ext); err == nil {
+			f.bodies[c.name] = body
+		} else if compressible(f.contentType) && len(data) >= minCompressSize {
+			if body, err := compress(c.name, data); err == nil && len(body) < len(data) {
+				f.bodies[c.name] = body
+			}
+		}
+	}
+
+	h.mu.Lock()
+	h.files[name] = f
+	h.mu.Unlock()
+	return f, name, nil
+}
+
+// compressible reports whether a content type is worth compressing; images
+// other than SVG and fonts are compressed already.
+func compressible(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.HasPrefix(contentType, "application/javascript") ||
+		strings.HasPrefix(contentType, "application/json") ||
+		strings.HasPrefix(contentType, "application/wasm") ||
+		strings.HasPrefix(contentType, "image/svg+xml")
+}
+
+// compress encodes data with a content coding.
+func compress(coding string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch coding {
+	case "br":
+		bw := brotli.NewWriterLevel(&buf, brotliQuality)
+		if _, err = bw.Write(data); err == nil {
+			err = bw.Close()
+		}
+	case "gzip":
+		gw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if _, err = gw.Write(data); err == nil {
+			err = gw.Close()
+		}
+	}
+	return buf.Bytes(), err
+}
+
+// negotiate picks the preferred coding that the Accept-Encoding header
+// allows and f has, "" for identity.
+func negotiate(accept string, f *file) string {
+	allowed := make(map[string]bool)
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		allowed[strings.ToLower(strings.TrimSpace(coding))] = qValue(params) > 0
+	}
+	for _, c := range codings {
+		if _, ok := f.bodies[c.name]; !ok {
+			continue
+		}
+		if ok, listed := allowed[c.name]; ok || (!listed && allowed["*"]) {
+			return c.name
+		}
+	}
+	return ""
+}
+
+// qValue returns the weight in Accept-Encoding parameters ("q=0.5"),
+// 1 if absent.
+func qValue(params string) float64 {
+	for _, p := range strings.Split(params, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if ok && strings.TrimSpace(k) == "q" {
+			q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return 0
+			}
+			return q
+		}
+	}
+	return 1
+}
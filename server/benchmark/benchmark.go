@@ -0,0 +1,339 @@
+// Package benchmark scores punch detection and classification against a
+// versioned corpus of labeled recordings.
+package benchmark
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/replay"
+)
+
+// ManifestFile is the corpus index inside a corpus directory.
+const ManifestFile = "manifest.json"
+
+// MatchToleranceMS is how far a detected punch may be from its label and
+// still count as the same punch.
+const MatchToleranceMS = 150
+
+const downloadTimeout = 2 * time.Minute
+
+// Entry is one recording listed in the manifest. Recordings too large to
+// bundle give a URL and checksum and are fetched on demand.
+type Entry struct {
+	Name   string `json:"name"`
+	File   string `json:"file"` // relative to the corpus directory
+	URL    string `json:"url,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Corpus is a versioned set of labeled recordings.
+type Corpus struct {
+	Version    string  `json:"version"`
+	Recordings []Entry `json:"recordings"`
+
+	dir string
+}
+
+// LoadCorpus reads the manifest of a corpus directory.
+func LoadCorpus(dir string) (*Corpus, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("read corpus manifest: %w", err)
+	}
+	var c Corpus
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("decode corpus manifest: %w", err)
+	}
+	c.dir = dir
+	return &c, nil
+}
+
+// Open loads a recording, downloading it first if it is missing locally and
+// the manifest gives a URL. Checksums are verified whenever they are listed.
+func (c *Corpus) Open(e Entry) (*replay.Recording, error) {
+	path := filepath.Join(c.dir, e.File)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && e.URL != "" {
+		if err := download(e.URL, path); err != nil {
+			return nil, fmt.Errorf("download %s: %w", e.Name, err)
+		}
+	}
+	if e.SHA256 != "" {
+		if err := verify(path, e.SHA256); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name, err)
+		}
+	}
+	return replay.Load(path)
+}
+
+// download fetches url into path.
+func download(url, path string) error {
+	client := &http.Client{Timeout: downloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// verify checks a file against its expected SHA-256.
+func verify(path, want string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", got, want)
+	}
+	return nil
+}
+
+// Score holds precision and recall for one category.
+type Score struct {
+	TruePositives  int     `json:"tp"`
+	FalsePositives int     `json:"fp"`
+	FalseNegatives int     `json:"fn"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+}
+
+// add accumulates the counts of another score.
+func (s *Score) add(o Score) {
+	s.TruePositives += o.TruePositives
+	s.FalsePositives += o.FalsePositives
+	s.FalseNegatives += o.FalseNegatives
+}
+
+// finish derives precision, recall and F1 from the counts.
+func (s *Score) finish() {
+	if d := s.TruePositives + s.FalsePositives; d > 0 {
+		s.Precision = float64(s.TruePositives) / float64(d)
+	}
+	if d := s.TruePositives + s.FalseNegatives; d > 0 {
+		s.Recall = float64(s.TruePositives) / float64(d)
+	}
+	if s.Precision+s.Recall > 0 {
+		s.F1 = 2 * s.Precision * s.Recall / (s.Precision + s.Recall)
+	}
+}
+
+// Result is the evaluation of one recording, or of the whole corpus.
+type Result struct {
+	Name      string `json:"name"`
+	Labels    int    `json:"labels"`
+	Detected  int    `json:"detected"`
+	Detection Score  `json:"detection"`
+
+	// Classification is scored over matched punches only, per labeled type
+	Classification map[string]*Score `json:"classification"`
+	Accuracy       float64           `json:"accuracy"` // correct types among matched punches
+	correct        int
+}
+
+// Report is the outcome of evaluating a corpus.
+type Report struct {
+	Version    string    `json:"version"`
+	Recordings []*Result `json:"recordings"`
+	Total      *Result   `json:"total"`
+}
+
+// Evaluate replays every recording of the corpus through a fresh analyzer
+// and scores the detected punches against the labels. A nil classifier uses
+// the analyzer's default.
+func Evaluate(c *Corpus, classifier analytics.Classifier) (*Report, error) {
+	report := &Report{
+		Version: c.Version,
+		Total:   &Result{Name: "total", Classification: make(map[string]*Score)},
+	}
+	for _, e := range c.Recordings {
+		rec, err := c.Open(e)
+		if err != nil {
+			return nil, err
+		}
+		res, err := EvaluateRecording(rec, classifier)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name, err)
+		}
+		report.Recordings = append(report.Recordings, res)
+		report.Total.merge(res)
+	}
+	report.Total.finish()
+	return report, nil
+}
+
+// EvaluateRecording replays one recording and scores it.
+func EvaluateRecording(rec *replay.Recording, classifier analytics.Classifier) (*Result, error) {
+	a := analytics.NewAnalyzer()
+	rec.ApplySettings(a)
+	a.SetClassifier(classifier) // the one under evaluation, not the recorded one
+	a.StartSession()
+	if err := replay.Run(a, rec); err != nil {
+		return nil, err
+	}
+
+	res := &Result{Name: rec.Name, Classification: make(map[string]*Score)}
+	res.score(rec.Labels, a.Punches())
+	res.finish()
+	return res, nil
+}
+
+// score matches detections to labels hand by hand and counts the outcomes.
+func (r *Result) score(labels []replay.Label, punches []analytics.PunchEvent) {
+	r.Labels = len(labels)
+	r.Detected = len(punches)
+
+	for _, hand := range []string{"left", "right"} {
+		var hl []replay.Label
+		for _, l := range labels {
+			if l.Hand == hand {
+				hl = append(hl, l)
+			}
+		}
+		var hp []analytics.PunchEvent
+		for _, p := range punches {
+			if p.Hand == hand {
+				hp = append(hp, p)
+			}
+		}
+		sort.Slice(hl, func(i, j int) bool { return hl[i].Timestamp < hl[j].Timestamp })
+		sort.Slice(hp, func(i, j int) bool { return hp[i].Timestamp < hp[j].Timestamp })
+
+		// Both lists are time ordered, so a single sweep pairs each label
+		// with the earliest unclaimed detection inside the tolerance
+		matched := make([]bool, len(hp))
+		next := 0
+		for _, l := range hl {
+			for next < len(hp) && hp[next].Timestamp < l.Timestamp-MatchToleranceMS {
+				next++
+			}
+			if next < len(hp) && hp[next].Timestamp <= l.Timestamp+MatchToleranceMS {
+				matched[next] = true
+				r.Detection.TruePositives++
+				r.classify(l.Type, string(hp[next].Type))
+				next++
+				continue
+			}
+			r.Detection.FalseNegatives++
+			r.typeScore(l.Type).FalseNegatives++
+		}
+		for _, m := range matched {
+			if !m {
+				r.Detection.FalsePositives++
+			}
+		}
+	}
+}
+
+// classify records the predicted type of a matched punch. A "straight"
+// label accepts either a jab or a cross.
+func (r *Result) classify(label, predicted string) {
+	if label == string(analytics.PunchStraight) &&
+		(predicted == string(analytics.PunchJab) || predicted == string(analytics.PunchCross)) {
+		predicted = label
+	}
+	if predicted == label {
+		r.correct++
+		r.typeScore(label).TruePositives++
+		return
+	}
+	r.typeScore(label).FalseNegatives++
+	r.typeScore(predicted).FalsePositives++
+}
+
+// typeScore returns the classification score for a punch type.
+func (r *Result) typeScore(t string) *Score {
+	s, ok := r.Classification[t]
+	if !ok {
+		s = &Score{}
+		r.Classification[t] = s
+	}
+	return s
+}
+
+// merge adds another result's counts to r.
+func (r *Result) merge(o *Result) {
+	r.Labels += o.Labels
+	r.Detected += o.Detected
+	r.Detection.add(o.Detection)
+	r.correct += o.correct
+	for t, s := range o.Classification {
+		r.typeScore(t).add(*s)
+	}
+}
+
+// finish derives all ratios from the counts.
+func (r *Result) finish() {
+	r.Detection.finish()
+	for _, s := range r.Classification {
+		s.finish()
+	}
+	if r.Detection.TruePositives > 0 {
+		r.Accuracy = float64(r.correct) / float64(r.Detection.TruePositives)
+	}
+}
+
+// WriteText prints the report as a table.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Corpus version %s\n\n", r.Version)
+	fmt.Fprintln(tw, "RECORDING\tLABELS\tDETECTED\tPRECISION\tRECALL\tF1\tTYPE ACC")
+	for _, res := range append(r.Recordings, r.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.3f\t%.3f\t%.3f\t%.3f\n",
+			res.Name, res.Labels, res.Detected,
+			res.Detection.Precision, res.Detection.Recall, res.Detection.F1, res.Accuracy)
+	}
+
+	types := make([]string, 0, len(r.Total.Classification))
+	for t := range r.Total.Classification {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	fmt.Fprintln(tw, "\nTYPE\tTP\tFP\tFN\tPRECISION\tRECALL\tF1")
+	for _, t := range types {
+		s := r.Total.Classification[t]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.3f\t%.3f\t%.3f\n",
+			t, s.TruePositives, s.FalsePositives, s.FalseNegatives, s.Precision, s.Recall, s.F1)
+	}
+	return tw.Flush()
+}
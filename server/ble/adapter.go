@@ -0,0 +1,43 @@
+package ble
+
+import "time"
+
+// DefaultAdapterID is the BlueZ adapter used for scanning and, unless a
+// glove is assigned elsewhere, for connections.
+const DefaultAdapterID = "hci0"
+
+// adapterDiscoveryTimeout is how long a secondary adapter may take to see a
+// glove that the scanning adapter found.
+const adapterDiscoveryTimeout = 10 * time.Second
+
+// Link is an established BLE connection.
+type Link interface {
+	Disconnect() error
+}
+
+// SetAdapter assigns a glove to a Bluetooth adapter (e.g. "hci1"). With each
+// glove on its own adapter the two 100Hz streams no longer share radio
+// airtime. Scanning always uses DefaultAdapterID.
+func (c *Central) SetAdapter(hand Hand, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.adapters == nil {
+		c.adapters = make(map[Hand]string)
+	}
+	c.adapters[hand] = id
+}
+
+// adapterFor returns the adapter ID a glove connects through.
+func (c *Central) adapterFor(hand Hand) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.adapterForLocked(hand)
+}
+
+// adapterForLocked is adapterFor with c.mu held.
+func (c *Central) adapterForLocked(hand Hand) string {
+	if id := c.adapters[hand]; id != "" {
+		return id
+	}
+	return DefaultAdapterID
+}
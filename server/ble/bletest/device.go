@@ -0,0 +1,300 @@
+package bletest
+
+import (
+	"context"
+	"time"
+
+	"boxing-analytics/ble"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Device is a fake glove or heart-rate strap. Exported fields may be set
+// before the device is first connected.
+type Device struct {
+	Name       string
+	Address    bluetooth.Address
+	RSSI       int16
+	MTU        uint16
+	Passkey    uint32 // Passkey Bond expects
+	ConnectErr error  // Returned by Connect while set
+
+	service     string
+	chars       map[string]*charState // By characteristic UUID
+	sensorUUID  string                // Sensor or heart-rate measurement characteristic
+	batteryUUID string
+	commandUUID string
+	heartRate   bool
+
+	link     *link // nil while disconnected
+	hidden   bool  // Not advertising
+	bonded   bool
+	connects int
+	writes   [][]byte
+
+	t *Transport
+}
+
+// charState is a characteristic's value, permissions and subscriber.
+type charState struct {
+	value               []byte
+	read, write, notify bool
+
+	handler func([]byte)
+	done    chan struct{} // Closed when the subscription ends, nil if none
+}
+
+// endLocked ends the characteristic's subscription.
+// Must be called with t.mu held.
+func (cs *charState) endLocked() {
+	if cs.done != nil {
+		close(cs.done)
+		cs.done = nil
+		cs.handler = nil
+	}
+}
+
+// Connected reports whether the device has an open link.
+func (d *Device) Connected() bool {
+	d.t.mu.Lock()
+	defer d.t.mu.Unlock()
+	return d.link != nil
+}
+
+// Connects returns how many links have been opened to the device.
+func (d *Device) Connects() int {
+	d.t.mu.Lock()
+	defer d.t.mu.Unlock()
+	return d.connects
+}
+
+// Bonded reports whether the device has been bonded.
+func (d *Device) Bonded() bool {
+	d.t.mu.Lock()
+	defer d.t.mu.Unlock()
+	return d.bonded
+}
+
+// Writes returns the values written to the command characteristic.
+func (d *Device) Writes() [][]byte {
+	d.t.mu.Lock()
+	defer d.t.mu.Unlock()
+	out := make([][]byte, len(d.writes))
+	for i, w := range d.writes {
+		out[i] = append([]byte(nil), w...)
+	}
+	return out
+}
+
+// SetAdvertising makes the device visible to scans or not, e.g. to take a
+// glove out of range.
+func (d *Device) SetAdvertising(on bool) {
+	d.t.mu.Lock()
+	defer d.t.mu.Unlock()
+	d.hidden = !on
+}
+
+// Notify sends a raw value as a sensor (or heart-rate measurement)
+// notification. It returns false if nothing is subscribed.
+func (d *Device) Notify(data []byte) bool {
+	return d.notify(d.sensorUUID, data)
+}
+
+// NotifyHeartRate sends a heart-rate measurement.
+func (d *Device) NotifyHeartRate(bpm uint8) bool {
+	return d.notify(d.sensorUUID, []byte{0, bpm})
+}
+
+// SetBattery updates the battery level and notifies it.
+func (d *Device) SetBattery(level uint8) {
+	d.t.mu.Lock()
+	if cs, ok := d.chars[d.batteryUUID]; ok {
+		cs.value = []byte{level}
+	}
+	d.t.mu.Unlock()
+	d.notify(d.batteryUUID, []byte{level})
+}
+
+func (d *Device) notify(uuid string, data []byte) bool {
+	d.t.mu.Lock()
+	cs, ok := d.chars[uuid]
+	var handler func([]byte)
+	if ok && d.link != nil {
+		handler = cs.handler
+	}
+	d.t.mu.Unlock()
+	if handler == nil {
+		return false
+	}
+	handler(append([]byte(nil), data...))
+	return true
+}
+
+// Replay streams packets as sensor notifications, one per interval. It
+// waits while the glove is disconnected or unsubscribed and resumes where
+// it left off. The returned channel is closed once the last packet is sent
+// or ctx is cancelled.
+func (d *Device) Replay(ctx context.Context, packets []*ble.SensorPacket, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for i := 0; i < len(packets); {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if d.Notify(packets[i].Marshal()) {
+				i++
+			}
+		}
+	}()
+	return done
+}
+
+// Drop simulates a remote disconnect, e.g. the glove going out of range:
+// notifications end and watchers report the link lost.
+func (d *Device) Drop() {
+	d.t.mu.Lock()
+	l := d.link
+	d.t.mu.Unlock()
+	if l != nil {
+		_ = l.Disconnect()
+	}
+}
+
+// disconnectLocked closes a link if it is still the device's current one.
+// Must be called with t.mu held.
+func (d *Device) disconnectLocked(l *link) {
+	if d.link != l {
+		return
+	}
+	d.link = nil
+	close(l.lost)
+	for _, cs := range d.chars {
+		cs.endLocked()
+	}
+}
+
+func (d *Device) scanResult() bluetooth.ScanResult {
+	d.t.mu.Lock()
+	defer d.t.mu.Unlock()
+	rssi := d.RSSI
+	if rssi == 0 {
+		rssi = -60
+	}
+	return bluetooth.ScanResult{
+		Address:              d.Address,
+		RSSI:                 rssi,
+		AdvertisementPayload: payload{name: d.Name, heartRate: d.heartRate},
+	}
+}
+
+// link is an open connection to a fake device.
+type link struct {
+	d    *Device
+	lost chan struct{} // Closed on disconnect
+}
+
+func (l *link) Disconnect() error {
+	l.d.t.mu.Lock()
+	defer l.d.t.mu.Unlock()
+	l.d.disconnectLocked(l)
+	return nil
+}
+
+// characteristic is a characteristic of a fake device, valid for one link.
+type characteristic struct {
+	link *link
+	uuid string
+}
+
+// stateLocked returns the characteristic's state if its link is open.
+// Must be called with t.mu held.
+func (c *characteristic) stateLocked() (*charState, error) {
+	if c.link.d.link != c.link {
+		return nil, ErrNotConnected
+	}
+	return c.link.d.chars[c.uuid], nil
+}
+
+func (c *characteristic) ReadValue() ([]byte, error) {
+	c.link.d.t.mu.Lock()
+	defer c.link.d.t.mu.Unlock()
+	cs, err := c.stateLocked()
+	if err != nil {
+		return nil, err
+	}
+	if !cs.read {
+		return nil, ErrNotPermitted
+	}
+	return append([]byte(nil), cs.value...), nil
+}
+
+func (c *characteristic) WriteValue(data []byte) error {
+	c.link.d.t.mu.Lock()
+	defer c.link.d.t.mu.Unlock()
+	cs, err := c.stateLocked()
+	if err != nil {
+		return err
+	}
+	if !cs.write {
+		return ErrNotPermitted
+	}
+	c.link.d.writes = append(c.link.d.writes, append([]byte(nil), data...))
+	return nil
+}
+
+func (c *characteristic) Subscribe(handler func([]byte)) (<-chan struct{}, error) {
+	c.link.d.t.mu.Lock()
+	defer c.link.d.t.mu.Unlock()
+	cs, err := c.stateLocked()
+	if err != nil {
+		return nil, err
+	}
+	if !cs.notify {
+		return nil, ErrNotPermitted
+	}
+	cs.endLocked()
+	cs.handler = handler
+	cs.done = make(chan struct{})
+	return cs.done, nil
+}
+
+func (c *characteristic) Unsubscribe() error {
+	c.link.d.t.mu.Lock()
+	defer c.link.d.t.mu.Unlock()
+	cs, err := c.stateLocked()
+	if err != nil {
+		return nil // Subscription ended with the link
+	}
+	cs.endLocked()
+	return nil
+}
+
+func (c *characteristic) MTU() (uint16, error) {
+	c.link.d.t.mu.Lock()
+	defer c.link.d.t.mu.Unlock()
+	return c.link.d.MTU, nil
+}
+
+// payload is the advertisement of a fake device.
+type payload struct {
+	name      string
+	heartRate bool
+}
+
+func (p payload) LocalName() string { return p.name }
+
+func (p payload) HasServiceUUID(uuid bluetooth.UUID) bool {
+	if p.heartRate {
+		return uuid == bluetooth.ServiceUUIDHeartRate
+	}
+	return uuid == ble.ServiceUUID
+}
+
+func (p payload) Bytes() []byte { return nil }
+
+func (p payload) ManufacturerData() map[uint16][]byte { return nil }
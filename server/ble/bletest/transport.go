@@ -0,0 +1,317 @@
+// Package bletest provides an in-memory ble.Transport so the Scanner,
+// Central bookkeeping and the analytics pipeline can run without gloves.
+//
+//	t := bletest.NewTransport()
+//	left := t.AddGlove(ble.LeftDeviceName)
+//	done := left.Replay(ctx, packets, 10*time.Millisecond)
+//
+//	central := ble.NewCentral()
+//	central.SetTransport(t)
+//	central.Enable(ctx)
+//
+// Fake gloves advertise to every scan until connected, then stream their
+// replayed packets as sensor notifications.
+package bletest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"boxing-analytics/ble"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// AdvertiseInterval is how often a scan reports each advertising device.
+const AdvertiseInterval = 100 * time.Millisecond
+
+// DefaultMTU is the ATT MTU fake devices report.
+const DefaultMTU = 247
+
+// Short-form UUIDs of the standard Heart Rate Service and Heart Rate
+// Measurement characteristic, as ble.DeviceConfig normalizes them.
+const (
+	heartRateServiceUUID = "0000180d-0000-1000-8000-00805f9b34fb"
+	heartRateCharUUID    = "00002a37-0000-1000-8000-00805f9b34fb"
+)
+
+// Errors returned by the fake stack.
+var (
+	ErrUnknownDevice = errors.New("bletest: unknown device")
+	ErrNotConnected  = errors.New("bletest: device not connected")
+	ErrNoSuchChar    = errors.New("bletest: characteristic not found")
+	ErrNotPermitted  = errors.New("bletest: operation not permitted")
+	ErrScanning      = errors.New("bletest: already scanning")
+)
+
+// Transport is an in-memory Bluetooth stack. It is safe for concurrent use.
+type Transport struct {
+	mu       sync.Mutex
+	devices  []*Device
+	stopScan chan struct{} // nil while not scanning
+	passkey  uint32
+	agent    bool
+}
+
+// NewTransport creates a stack with no devices.
+func NewTransport() *Transport {
+	return &Transport{}
+}
+
+// AddGlove adds a glove advertising under name with the default GATT
+// profile (see ble.DefaultDeviceConfig), a full battery and device info for
+// the hand the name implies.
+func (t *Transport) AddGlove(name string) *Device {
+	cfg := ble.DefaultDeviceConfig()
+	hand, _ := cfg.HandFor(name)
+	d := t.addDevice(name, cfg.ServiceUUID)
+	d.chars[cfg.SensorCharUUID] = &charState{notify: true}
+	d.chars[cfg.BatteryCharUUID] = &charState{value: []byte{100}, read: true, notify: true}
+	d.chars[cfg.DeviceCharUUID] = &charState{value: []byte{byte(hand), 1, 0, 0, 1}, read: true}
+	d.chars[cfg.CommandCharUUID] = &charState{write: true}
+	d.sensorUUID = cfg.SensorCharUUID
+	d.batteryUUID = cfg.BatteryCharUUID
+	d.commandUUID = cfg.CommandCharUUID
+	return d
+}
+
+// AddHeartRateStrap adds a strap advertising the standard Heart Rate
+// Service.
+func (t *Transport) AddHeartRateStrap(name string) *Device {
+	d := t.addDevice(name, heartRateServiceUUID)
+	d.heartRate = true
+	d.chars[heartRateCharUUID] = &charState{notify: true}
+	d.sensorUUID = heartRateCharUUID
+	return d
+}
+
+func (t *Transport) addDevice(name, service string) *Device {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d := &Device{
+		Name:    name,
+		Address: fakeAddress(len(t.devices) + 1),
+		MTU:     DefaultMTU,
+		service: service,
+		chars:   make(map[string]*charState),
+		t:       t,
+	}
+	t.devices = append(t.devices, d)
+	return d
+}
+
+// fakeAddress returns a distinct address for the nth device: a locally
+// administered MAC on Linux and Windows, a UUID on macOS.
+func fakeAddress(n int) bluetooth.Address {
+	var addr bluetooth.Address
+	addr.Set(fmt.Sprintf("02:00:00:00:%02X:%02X", n>>8&0xff, n&0xff))
+	if addr.String() == (bluetooth.Address{}).String() {
+		addr.Set(fmt.Sprintf("00000000-0000-4000-8000-%012x", n))
+	}
+	return addr
+}
+
+// device returns the device with an address.
+func (t *Transport) device(addr bluetooth.Address) (*Device, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, d := range t.devices {
+		if d.Address.String() == addr.String() {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownDevice, addr.String())
+}
+
+// Enable implements ble.Transport.
+func (t *Transport) Enable() error {
+	return nil
+}
+
+// Scan implements ble.Transport, reporting every device that is not
+// connected each AdvertiseInterval until StopScan.
+func (t *Transport) Scan(callback func(bluetooth.ScanResult)) error {
+	t.mu.Lock()
+	if t.stopScan != nil {
+		t.mu.Unlock()
+		return ErrScanning
+	}
+	stop := make(chan struct{})
+	t.stopScan = stop
+	t.mu.Unlock()
+
+	ticker := time.NewTicker(AdvertiseInterval)
+	defer ticker.Stop()
+	for {
+		for _, d := range t.advertising() {
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+			callback(d.scanResult())
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// advertising returns the devices a scan can see.
+func (t *Transport) advertising() []*Device {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []*Device
+	for _, d := range t.devices {
+		if d.link == nil && !d.hidden {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// StopScan implements ble.Transport.
+func (t *Transport) StopScan() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopScan != nil {
+		close(t.stopScan)
+		t.stopScan = nil
+	}
+	return nil
+}
+
+// Connect implements ble.Transport. It fails with the device's ConnectErr
+// if one is set.
+func (t *Transport) Connect(ctx context.Context, _ string, addr bluetooth.Address, _ ble.ConnParams) (ble.Link, error) {
+	d, err := t.device(addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if d.ConnectErr != nil {
+		return nil, d.ConnectErr
+	}
+	if d.link != nil {
+		return nil, fmt.Errorf("bletest: %s already connected", d.Name)
+	}
+	d.link = &link{d: d, lost: make(chan struct{})}
+	d.connects++
+	return d.link, nil
+}
+
+// Characteristic implements ble.Transport.
+func (t *Transport) Characteristic(l ble.Link, _ string, _ bluetooth.Address, serviceUUID, charUUID string) (ble.Characteristic, error) {
+	fl, ok := l.(*link)
+	if !ok {
+		return nil, fmt.Errorf("bletest: foreign link %T", l)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if fl.d.link != fl {
+		return nil, ErrNotConnected
+	}
+	if serviceUUID != fl.d.service {
+		return nil, fmt.Errorf("%w: service %s", ErrNoSuchChar, serviceUUID)
+	}
+	if _, ok := fl.d.chars[charUUID]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchChar, charUUID)
+	}
+	return &characteristic{link: fl, uuid: charUUID}, nil
+}
+
+// Watch implements ble.Transport, calling onLost when the device is
+// dropped (see Device.Drop).
+func (t *Transport) Watch(_ string, addr bluetooth.Address, _ string, stillConnected func() bool, onLost func()) {
+	d, err := t.device(addr)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	l := d.link
+	t.mu.Unlock()
+	if l == nil {
+		return
+	}
+
+	ticker := time.NewTicker(ble.ConnectionCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !stillConnected() {
+				return
+			}
+		case <-l.lost:
+			if stillConnected() {
+				onLost()
+			}
+			return
+		}
+	}
+}
+
+// Forget implements ble.Transport by closing a link still open to the
+// device, as BlueZ does when it removes a device.
+func (t *Transport) Forget(_ string, addr bluetooth.Address, force bool) {
+	d, err := t.device(addr)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	l := d.link
+	if force {
+		d.bonded = false
+	}
+	t.mu.Unlock()
+	if l != nil {
+		_ = l.Disconnect()
+	}
+}
+
+// RegisterAgent implements ble.Transport.
+func (t *Transport) RegisterAgent(passkey uint32) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.agent = true
+	t.passkey = passkey
+	return nil
+}
+
+// Bond implements ble.Transport. It fails if no agent is registered or
+// the passkey does not match the device's Passkey.
+func (t *Transport) Bond(_ string, addr bluetooth.Address) error {
+	d, err := t.device(addr)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.agent || t.passkey != d.Passkey {
+		return fmt.Errorf("%w: authentication failed", ErrNotPermitted)
+	}
+	d.bonded = true
+	return nil
+}
+
+// ParseAddress implements ble.Transport.
+func (t *Transport) ParseAddress(s string) (bluetooth.Address, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, d := range t.devices {
+		if d.Address.String() == s {
+			return d.Address, nil
+		}
+	}
+	return bluetooth.Address{}, fmt.Errorf("%w: %s", ErrUnknownDevice, s)
+}
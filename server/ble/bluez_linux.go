@@ -0,0 +1,608 @@
+package ble
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/muka/go-bluetooth/bluez"
+	"github.com/muka/go-bluetooth/bluez/profile/adapter"
+	"github.com/muka/go-bluetooth/bluez/profile/agent"
+	"github.com/muka/go-bluetooth/bluez/profile/device"
+	"github.com/muka/go-bluetooth/bluez/profile/gatt"
+	"tinygo.org/x/bluetooth"
+)
+
+// bluezBackend drives BlueZ over D-Bus. tinygo bluetooth only covers
+// scanning and default-adapter connects here; GATT goes through
+// go-bluetooth and raw D-Bus calls, which see the object tree more reliably.
+type bluezBackend struct {
+	adapterScanner
+}
+
+// newPlatformTransport returns the Linux transport.
+func newPlatformTransport() Transport {
+	return bluezBackend{adapterScanner{bluetooth.DefaultAdapter}}
+}
+
+// Connect opens the link and waits for BlueZ to resolve the GATT profile.
+func (b bluezBackend) Connect(ctx context.Context, adapterID string, addr bluetooth.Address, params ConnParams) (Link, error) {
+	link, err := connectLink(ctx, b.adapter, adapterID, addr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait for BlueZ to complete GATT service discovery (ServicesResolved = true).
+	if err := waitForServicesResolved(ctx, adapterID, addr, 15*time.Second); err != nil {
+		link.Disconnect()
+		return nil, fmt.Errorf("GATT not resolved: %w", err)
+	}
+	return link, nil
+}
+
+func (bluezBackend) Characteristic(_ Link, adapterID string, addr bluetooth.Address, serviceUUID, charUUID string) (Characteristic, error) {
+	char, err := discoverGATT(adapterID, addr, serviceUUID, charUUID)
+	if err != nil {
+		return nil, err
+	}
+	return &bluezCharacteristic{char: char}, nil
+}
+
+func (bluezBackend) Watch(adapterID string, addr bluetooth.Address, name string, stillConnected func() bool, onLost func()) {
+	watchDeviceConnection(adapterID, addr, name, stillConnected, onLost)
+}
+
+func (bluezBackend) Forget(adapterID string, addr bluetooth.Address, force bool) {
+	removeDevice(adapterID, addr, force)
+}
+
+// RegisterAgent exposes a BlueZ pairing agent that answers passkey requests.
+func (bluezBackend) RegisterAgent(passkey uint32) error {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return fmt.Errorf("system bus: %w", err)
+	}
+	ag := agent.NewSimpleAgent()
+	ag.SetPassKey(passkey)
+	if err := agent.ExposeAgent(conn, ag, agent.CapKeyboardOnly, true); err != nil {
+		return fmt.Errorf("register pairing agent: %w", err)
+	}
+	return nil
+}
+
+// Bond pairs with a connected device unless it is already bonded, and marks
+// it trusted so BlueZ keeps the keys.
+func (bluezBackend) Bond(adapterID string, addr bluetooth.Address) error {
+	dev, err := device.NewDevice1(resolveDevicePath(adapterID, addr))
+	if err != nil {
+		return err
+	}
+	if paired, err := dev.GetPaired(); err != nil || !paired {
+		log.Printf("BLE: Pairing with %s...", addr.String())
+		if err := dev.Pair(); err != nil {
+			return fmt.Errorf("pair: %w", err)
+		}
+	}
+	if err := dev.SetTrusted(true); err != nil {
+		return fmt.Errorf("set trusted: %w", err)
+	}
+	return nil
+}
+
+func (bluezBackend) ParseAddress(s string) (bluetooth.Address, error) {
+	mac, err := bluetooth.ParseMAC(s)
+	if err != nil {
+		return bluetooth.Address{}, err
+	}
+	return bluetooth.Address{MACAddress: bluetooth.MACAddress{MAC: mac}}, nil
+}
+
+// bluezCharacteristic is a GattCharacteristic1 D-Bus object. Notifications
+// arrive as PropertiesChanged signals on its Value property.
+type bluezCharacteristic struct {
+	char   *gatt.GattCharacteristic1
+	propCh chan *bluez.PropertyChanged
+}
+
+func (b *bluezCharacteristic) ReadValue() ([]byte, error) {
+	return b.char.ReadValue(map[string]interface{}{})
+}
+
+func (b *bluezCharacteristic) WriteValue(data []byte) error {
+	return b.char.WriteValue(data, map[string]interface{}{})
+}
+
+// Subscribe watches the characteristic's properties, which replicates
+// bluetooth.DeviceCharacteristic.EnableNotifications, and asks BlueZ to
+// start notifications from the peripheral. BlueZ closes the property
+// channel when the device goes away.
+func (b *bluezCharacteristic) Subscribe(handler func([]byte)) (<-chan struct{}, error) {
+	propCh, err := b.char.WatchProperties()
+	if err != nil {
+		return nil, fmt.Errorf("WatchProperties failed: %w", err)
+	}
+	if err := b.char.StartNotify(); err != nil {
+		_ = b.char.UnwatchProperties(propCh)
+		return nil, fmt.Errorf("StartNotify failed: %w", err)
+	}
+	b.propCh = propCh
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for update := range propCh {
+			if update == nil || update.Interface != "org.bluez.GattCharacteristic1" || update.Name != "Value" {
+				continue
+			}
+			if data, ok := update.Value.([]byte); ok {
+				handler(data)
+			}
+		}
+	}()
+	return done, nil
+}
+
+// Unsubscribe stops notifications and cleans up the D-Bus signal
+// subscription.
+func (b *bluezCharacteristic) Unsubscribe() error {
+	err := b.char.StopNotify()
+	if b.propCh != nil {
+		_ = b.char.UnwatchProperties(b.propCh)
+	}
+	return err
+}
+
+// MTU returns the ATT MTU, which BlueZ exchanges on connect, offering its
+// maximum.
+func (b *bluezCharacteristic) MTU() (uint16, error) {
+	return b.char.GetMTU()
+}
+
+// devicePath returns the D-Bus object path BlueZ gives a device first seen
+// under the given address.
+// e.g. ("hci0", "D4:E9:F4:E2:B5:8A") → "/org/bluez/hci0/dev_D4_E9_F4_E2_B5_8A"
+// A glove using a resolvable private address keeps the path of the address
+// it was first seen with while BlueZ reports its identity address, so use
+// resolveDevicePath to find an existing device.
+func devicePath(adapterID string, addr bluetooth.Address) dbus.ObjectPath {
+	mac := strings.ToUpper(addr.String())
+	return dbus.ObjectPath("/org/bluez/" + adapterID + "/dev_" + strings.ReplaceAll(mac, ":", "_"))
+}
+
+// managedObjects is the result of BlueZ's GetManagedObjects.
+type managedObjects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+
+// getManagedObjects fetches all BlueZ objects over a fresh D-Bus connection
+// (the go-bluetooth singleton can return stale data).
+func getManagedObjects() (managedObjects, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("dbus connect: %w", err)
+	}
+	defer conn.Close()
+
+	var managed managedObjects
+	if err := conn.Object("org.bluez", "/").Call("org.freedesktop.DBus.ObjectManager.GetManagedObjects", 0).Store(&managed); err != nil {
+		return nil, fmt.Errorf("GetManagedObjects: %w", err)
+	}
+	return managed, nil
+}
+
+// findDevicePath looks up the object of a device under an adapter by its
+// Address property, falling back to a path match for devices still known
+// under the address they advertised.
+func (m managedObjects) findDevicePath(adapterID string, addr bluetooth.Address) (dbus.ObjectPath, bool) {
+	guess := devicePath(adapterID, addr)
+	if dev, ok := m[guess]["org.bluez.Device1"]; ok {
+		if a, ok := dev["Address"].Value().(string); !ok || strings.EqualFold(a, addr.String()) {
+			return guess, true
+		}
+	}
+	prefix := "/org/bluez/" + adapterID + "/dev_"
+	for path, ifaces := range m {
+		dev, ok := ifaces["org.bluez.Device1"]
+		if !ok || !strings.HasPrefix(string(path), prefix) {
+			continue
+		}
+		if a, ok := dev["Address"].Value().(string); ok && strings.EqualFold(a, addr.String()) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// lookupDevicePath returns the object path of a device BlueZ knows under an
+// adapter.
+func lookupDevicePath(adapterID string, addr bluetooth.Address) (dbus.ObjectPath, bool) {
+	managed, err := getManagedObjects()
+	if err != nil {
+		return "", false
+	}
+	return managed.findDevicePath(adapterID, addr)
+}
+
+// resolveDevicePath returns the object path of a device, or the path it
+// will get if BlueZ does not know it yet.
+func resolveDevicePath(adapterID string, addr bluetooth.Address) dbus.ObjectPath {
+	if path, ok := lookupDevicePath(adapterID, addr); ok {
+		return path
+	}
+	return devicePath(adapterID, addr)
+}
+
+// connectLink connects to a device through the given adapter, requesting
+// the given connection parameters. The default adapter usually knows
+// the device from the scan; otherwise (a secondary adapter, or a direct
+// connect to a paired glove) the adapter runs its own discovery until the
+// device shows up under it.
+func connectLink(ctx context.Context, a *bluetooth.Adapter, adapterID string, addr bluetooth.Address, params ConnParams) (Link, error) {
+	if err := applyConnParams(adapterID, params); err != nil {
+		log.Printf("BLE: Connection parameters not applied on %s (BlueZ defaults in use): %v", adapterID, err)
+	}
+
+	// tinygo builds the object path from the address, which only holds on
+	// the default adapter for devices not using a private address
+	path, known := lookupDevicePath(adapterID, addr)
+	if adapterID == DefaultAdapterID && known && path == devicePath(adapterID, addr) {
+		return a.Connect(addr, params.bluetoothParams())
+	}
+
+	var dev *device.Device1
+	var err error
+	if known {
+		dev, err = device.NewDevice1(path)
+	} else {
+		dev, err = awaitDevice(ctx, adapterID, addr, adapterDiscoveryTimeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("BLE: Connecting %s through adapter %s", addr.String(), adapterID)
+	if err := dev.Connect(); err != nil {
+		return nil, err
+	}
+	return dev, nil
+}
+
+// awaitDevice runs LE discovery on an adapter until BlueZ has an object for
+// the given address, the timeout expires or ctx is cancelled.
+func awaitDevice(ctx context.Context, adapterID string, addr bluetooth.Address, timeout time.Duration) (*device.Device1, error) {
+	a, err := adapter.GetAdapter(adapterID)
+	if err != nil {
+		return nil, fmt.Errorf("adapter %s: %w", adapterID, err)
+	}
+	if err := a.SetDiscoveryFilter(map[string]interface{}{"Transport": "le"}); err != nil {
+		return nil, fmt.Errorf("adapter %s discovery filter: %w", adapterID, err)
+	}
+	if err := a.StartDiscovery(); err != nil {
+		return nil, fmt.Errorf("adapter %s discovery: %w", adapterID, err)
+	}
+	defer func() { _ = a.StopDiscovery() }()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if path, ok := lookupDevicePath(adapterID, addr); ok {
+			return device.NewDevice1(path)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	return nil, fmt.Errorf("%s not seen by adapter %s", addr.String(), adapterID)
+}
+
+// watchDeviceConnection monitors the BlueZ Device1.Connected property via D-Bus
+// so a remote disconnect is noticed at once rather than after the packet
+// timeout. When the device disconnects, it calls onLost. The watch ends when
+// stillConnected reports false, e.g. after a local disconnect or reconnect.
+func watchDeviceConnection(adapterID string, addr bluetooth.Address, deviceName string, stillConnected func() bool, onLost func()) {
+	devPath := resolveDevicePath(adapterID, addr)
+
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		log.Printf("BLE: Failed to connect to D-Bus for disconnect watch: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// Subscribe to PropertiesChanged signals on this device object.
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchObjectPath(devPath),
+	); err != nil {
+		log.Printf("BLE: Failed to add D-Bus match for disconnect watch: %v", err)
+		return
+	}
+
+	ch := make(chan *dbus.Signal, 16)
+	conn.Signal(ch)
+
+	// Re-check periodically so the watch ends promptly once the connection
+	// is gone, even if BlueZ sends no further signals for this device.
+	ticker := time.NewTicker(ConnectionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !stillConnected() {
+				return
+			}
+		case sig, ok := <-ch:
+			if !ok || !stillConnected() {
+				// Already marked as disconnected, stop watching
+				return
+			}
+			if deviceDisconnected(sig) {
+				log.Printf("BLE: D-Bus reports %s disconnected", deviceName)
+				onLost()
+				return
+			}
+		}
+	}
+}
+
+// deviceDisconnected reports whether a PropertiesChanged signal sets
+// Device1.Connected to false.
+func deviceDisconnected(sig *dbus.Signal) bool {
+	if len(sig.Body) < 2 {
+		return false
+	}
+	iface, ok := sig.Body[0].(string)
+	if !ok || iface != "org.bluez.Device1" {
+		return false
+	}
+	changed, ok := sig.Body[1].(map[string]dbus.Variant)
+	if !ok {
+		return false
+	}
+	v, ok := changed["Connected"]
+	if !ok {
+		return false
+	}
+	connected, ok := v.Value().(bool)
+	return ok && !connected
+}
+
+// removeDevice removes a device from the BlueZ cache via D-Bus.
+// This helps with reconnection after ESP32 deep sleep wake-up, as BlueZ
+// may have stale cached state that prevents proper re-discovery.
+// It first attempts to disconnect, then removes the device from cache.
+// Bonded devices are only disconnected, as removing them drops their keys,
+// unless force is set.
+func removeDevice(adapterID string, addr bluetooth.Address, force bool) {
+	devPath, ok := lookupDevicePath(adapterID, addr)
+	if !ok {
+		return // BlueZ does not know the device
+	}
+
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		log.Printf("BLE: Failed to connect to D-Bus for device removal: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// First, try to disconnect if still connected (ignore errors)
+	devObj := conn.Object("org.bluez", devPath)
+	_ = devObj.Call("org.bluez.Device1.Disconnect", 0)
+	time.Sleep(100 * time.Millisecond)
+
+	if !force {
+		if paired, err := devObj.GetProperty("org.bluez.Device1.Paired"); err == nil && paired.Value() == true {
+			return
+		}
+	}
+
+	// Call RemoveDevice on the adapter
+	adapterObj := conn.Object("org.bluez", dbus.ObjectPath("/org/bluez/"+adapterID))
+	call := adapterObj.Call("org.bluez.Adapter1.RemoveDevice", 0, devPath)
+	if call.Err != nil {
+		// Only log if it's not a "does not exist" error
+		errStr := call.Err.Error()
+		if !strings.Contains(errStr, "Does Not Exist") && !strings.Contains(errStr, "DoesNotExist") {
+			log.Printf("BLE: RemoveDevice %s: %v", devPath, call.Err)
+		}
+	} else {
+		log.Printf("BLE: Removed cached device %s from BlueZ", devPath)
+	}
+}
+
+// waitForServicesResolved blocks until BlueZ reports ServicesResolved = true
+// for the given device address, or until the timeout expires.
+//
+// BlueZ performs GATT service discovery asynchronously after the ACL connection
+// is established. The ServicesResolved property on the Device1 D-Bus object
+// transitions false → true when the GATT profile is fully resolved. Polling
+// DiscoverServices before this event yields an empty list even on success.
+func waitForServicesResolved(ctx context.Context, adapterID string, addr bluetooth.Address, timeout time.Duration) error {
+	devPath := resolveDevicePath(adapterID, addr)
+
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return fmt.Errorf("dbus: %w", err)
+	}
+	defer conn.Close()
+
+	obj := conn.Object("org.bluez", devPath)
+
+	// Fast path: already resolved (e.g. reconnect after prior session).
+	v, err := obj.GetProperty("org.bluez.Device1.ServicesResolved")
+	if err == nil {
+		if resolved, ok := v.Value().(bool); ok && resolved {
+			return nil
+		}
+	}
+
+	// Subscribe to PropertiesChanged signals on this device object.
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchObjectPath(devPath),
+	); err != nil {
+		return fmt.Errorf("dbus match: %w", err)
+	}
+
+	ch := make(chan *dbus.Signal, 16)
+	conn.Signal(ch)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case sig, ok := <-ch:
+			if !ok {
+				return fmt.Errorf("dbus signal channel closed")
+			}
+			if len(sig.Body) < 2 {
+				continue
+			}
+			iface, ok := sig.Body[0].(string)
+			if !ok || iface != "org.bluez.Device1" {
+				continue
+			}
+			changed, ok := sig.Body[1].(map[string]dbus.Variant)
+			if !ok {
+				continue
+			}
+			if v, ok := changed["ServicesResolved"]; ok {
+				if resolved, ok := v.Value().(bool); ok && resolved {
+					return nil
+				}
+			}
+		case <-timer.C:
+			return fmt.Errorf("timeout waiting for ServicesResolved")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// discoverGATT opens a fresh D-Bus connection and calls GetManagedObjects
+// directly on org.bluez, bypassing the go-bluetooth singleton ObjectManager
+// which can return a stale/incomplete view of the GATT object tree.
+//
+// It returns the GattCharacteristic1 for the given (serviceUUID, charUUID) pair
+// under the device identified by addr.
+func discoverGATT(adapterID string, addr bluetooth.Address, serviceUUIDStr, charUUIDStr string) (*gatt.GattCharacteristic1, error) {
+	serviceUUIDStr = strings.ToLower(serviceUUIDStr)
+	charUUIDStr = strings.ToLower(charUUIDStr)
+
+	// Call GetManagedObjects on the root BlueZ ObjectManager, over a fresh
+	// D-Bus connection — NOT the go-bluetooth singleton, whose cached
+	// connection may return stale data.
+	managed, err := getManagedObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("BLE: GetManagedObjects returned %d total objects", len(managed))
+
+	// Find the device object by address rather than rebuilding its path, as
+	// gloves using a private address live under a different path.
+	path, ok := managed.findDevicePath(adapterID, addr)
+	if !ok {
+		return nil, fmt.Errorf("device %s not known to adapter %s", addr.String(), adapterID)
+	}
+	devPath := string(path)
+
+	// Find the service path under our device.
+	var servicePath string
+	for path, ifaces := range managed {
+		pathStr := string(path)
+
+		// Must be exactly one level under devPath: devPath/serviceXXXX
+		if !strings.HasPrefix(pathStr, devPath+"/service") {
+			continue
+		}
+		suffix := pathStr[len(devPath)+1:] // e.g. "service0028"
+		if strings.Contains(suffix, "/") {
+			continue // deeper nesting — skip
+		}
+
+		svcIface, ok := ifaces["org.bluez.GattService1"]
+		if !ok {
+			continue
+		}
+		uuidVar, ok := svcIface["UUID"]
+		if !ok {
+			continue
+		}
+		uuid, ok := uuidVar.Value().(string)
+		if !ok {
+			continue
+		}
+		log.Printf("BLE: Service candidate %s UUID=%s", pathStr, uuid)
+		if strings.ToLower(uuid) == serviceUUIDStr {
+			servicePath = pathStr
+			log.Printf("BLE: Matched service at %s", servicePath)
+			break
+		}
+	}
+
+	if servicePath == "" {
+		// Emit everything we found under this device for diagnostics.
+		for path := range managed {
+			if strings.HasPrefix(string(path), devPath) {
+				log.Printf("BLE: Object under device: %s", path)
+			}
+		}
+		return nil, fmt.Errorf("service %s not found on %s", serviceUUIDStr, devPath)
+	}
+
+	// Find the sensor characteristic under the matched service.
+	var charPath string
+	for path, ifaces := range managed {
+		pathStr := string(path)
+
+		// Must be exactly one level under servicePath: servicePath/charXXXX
+		if !strings.HasPrefix(pathStr, servicePath+"/char") {
+			continue
+		}
+		suffix := pathStr[len(servicePath)+1:] // e.g. "char0029"
+		if strings.Contains(suffix, "/") {
+			continue
+		}
+
+		charIface, ok := ifaces["org.bluez.GattCharacteristic1"]
+		if !ok {
+			continue
+		}
+		uuidVar, ok := charIface["UUID"]
+		if !ok {
+			continue
+		}
+		uuid, ok := uuidVar.Value().(string)
+		if !ok {
+			continue
+		}
+		log.Printf("BLE: Char candidate %s UUID=%s", pathStr, uuid)
+		if strings.ToLower(uuid) == charUUIDStr {
+			charPath = pathStr
+			log.Printf("BLE: Matched characteristic at %s", charPath)
+			break
+		}
+	}
+
+	if charPath == "" {
+		return nil, fmt.Errorf("characteristic %s not found under %s", charUUIDStr, servicePath)
+	}
+
+	// Construct the GattCharacteristic1 wrapper.
+	// NewGattCharacteristic1 uses the go-bluetooth Client which lazily connects
+	// via the singleton D-Bus connection — this is fine for method calls like
+	// StartNotify and WatchProperties; only GetManagedObjects was unreliable.
+	char, err := gatt.NewGattCharacteristic1(dbus.ObjectPath(charPath))
+	if err != nil {
+		return nil, fmt.Errorf("NewGattCharacteristic1(%s): %w", charPath, err)
+	}
+
+	return char, nil
+}
@@ -0,0 +1,57 @@
+package ble
+
+import (
+	"fmt"
+	"log"
+)
+
+// CommandClearBonds makes a glove forget its bonded server (see firmware
+// config.h). Only the bonded server can send it, as the command
+// characteristic requires an encrypted link.
+const CommandClearBonds byte = 0x03
+
+// maxPasskey is the largest 6-digit BLE passkey.
+const maxPasskey = 999999
+
+// EnableBonding registers a pairing agent that answers the gloves'
+// passkey requests and makes every new glove connection pair and bond
+// before streaming. Gloves built with BLE_BONDING then only talk to this
+// server over an encrypted link.
+func (c *Central) EnableBonding(passkey uint32) error {
+	if passkey > maxPasskey {
+		return fmt.Errorf("passkey must have at most 6 digits")
+	}
+	if err := c.transport.RegisterAgent(passkey); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bonding = true
+	return nil
+}
+
+// BondingEnabled returns true if gloves must be bonded before use.
+func (c *Central) BondingEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.bonding
+}
+
+// Unbond makes a connected glove forget this server and removes its keys
+// from BlueZ, so the glove can be bonded to another server.
+func (c *Central) Unbond(hand Hand) error {
+	glove := c.ConnectedGlove(hand)
+	if glove == nil {
+		return fmt.Errorf("%s glove not connected", hand)
+	}
+	if err := c.SendCommand(hand, []byte{CommandClearBonds}); err != nil {
+		return err
+	}
+	if err := c.Disconnect(hand); err != nil {
+		log.Printf("BLE: Failed to disconnect %s glove: %v", hand, err)
+	}
+	c.transport.Forget(glove.Adapter, glove.Address, true)
+	log.Printf("BLE: Unbonded %s glove %s", hand, glove.Name)
+	return nil
+}
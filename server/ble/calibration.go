@@ -0,0 +1,238 @@
+package ble
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Calibration constants
+const (
+	DefaultCalibrationDuration = 3 * time.Second
+	calibrationMinSamples      = 100  // 1 second at 100Hz
+	calibrationStillAccel      = 0.5  // m/s² - max accel std deviation while still
+	calibrationStillGyro       = 5.0  // °/s - max gyro std deviation while still
+	standardGravity            = 9.81 // m/s²
+)
+
+// ErrGloveNotConnected is returned when an operation needs a connected glove.
+var ErrGloveNotConnected = errors.New("glove not connected")
+
+// ErrGloveMoved is returned when the glove was not held still during calibration.
+var ErrGloveMoved = errors.New("glove moved during calibration")
+
+// ErrCalibrating is returned when a glove is already being calibrated.
+var ErrCalibrating = errors.New("glove is already calibrating")
+
+// Offsets holds the per-glove sensor bias measured during calibration.
+// Offsets are subtracted from every packet received from that glove.
+type Offsets struct {
+	Accel      [3]float64 `json:"accel"` // m/s²
+	Gyro       [3]float64 `json:"gyro"`  // °/s
+	MeasuredAt time.Time  `json:"measured_at"`
+}
+
+// ApplyOffsets subtracts calibration offsets from the raw sensor values.
+func (p *SensorPacket) ApplyOffsets(o Offsets) {
+	p.AccX = clampInt16(float64(p.AccX) - o.Accel[0]*100)
+	p.AccY = clampInt16(float64(p.AccY) - o.Accel[1]*100)
+	p.AccZ = clampInt16(float64(p.AccZ) - o.Accel[2]*100)
+	p.GyroX = clampInt16(float64(p.GyroX) - o.Gyro[0]*10)
+	p.GyroY = clampInt16(float64(p.GyroY) - o.Gyro[1]*10)
+	p.GyroZ = clampInt16(float64(p.GyroZ) - o.Gyro[2]*10)
+}
+
+// clampInt16 rounds v and clamps it to the int16 range.
+func clampInt16(v float64) int16 {
+	v = math.Round(v)
+	if v > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if v < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(v)
+}
+
+// ─── Calibration Store ───────────────────────────────────────────────────────
+
+// CalibrationStore persists glove offsets keyed by MAC address.
+type CalibrationStore struct {
+	mu      sync.RWMutex
+	path    string
+	offsets map[string]Offsets
+}
+
+// NewCalibrationStore loads offsets from path, starting empty if the file
+// does not exist yet.
+func NewCalibrationStore(path string) (*CalibrationStore, error) {
+	s := &CalibrationStore{path: path, offsets: make(map[string]Offsets)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read calibration: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.offsets); err != nil {
+		return nil, fmt.Errorf("decode calibration: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns the offsets for a glove MAC address.
+func (s *CalibrationStore) Get(mac string) (Offsets, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	o, ok := s.offsets[strings.ToUpper(mac)]
+	return o, ok
+}
+
+// Set stores the offsets for a glove MAC address and writes them to disk.
+func (s *CalibrationStore) Set(mac string, o Offsets) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offsets[strings.ToUpper(mac)] = o
+	data, err := json.MarshalIndent(s.offsets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal calibration: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("write calibration: %w", err)
+	}
+	return nil
+}
+
+// ─── Calibration Routine ─────────────────────────────────────────────────────
+
+// calibrationRun collects raw packets for one glove while it is held still.
+type calibrationRun struct {
+	samples []*SensorPacket
+}
+
+// SetCalibrationStore sets the store used to look up and save glove offsets.
+func (c *Central) SetCalibrationStore(store *CalibrationStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calibration = store
+}
+
+// Calibrating reports whether a glove is being calibrated.
+func (c *Central) Calibrating(hand Hand) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.calibrating[hand]
+	return ok
+}
+
+// Calibrate records raw samples from a glove for the given duration, measures
+// its accelerometer and gyroscope bias, and stores the offsets for its MAC.
+// The glove must be held still for the whole period; a second calibration of
+// the same glove meanwhile fails with ErrCalibrating.
+func (c *Central) Calibrate(ctx context.Context, hand Hand, duration time.Duration) (Offsets, error) {
+	glove := c.ConnectedGlove(hand)
+	if glove == nil {
+		return Offsets{}, ErrGloveNotConnected
+	}
+
+	run := &calibrationRun{}
+	c.mu.Lock()
+	if _, busy := c.calibrating[hand]; busy {
+		c.mu.Unlock()
+		return Offsets{}, ErrCalibrating
+	}
+	if c.calibrating == nil {
+		c.calibrating = make(map[Hand]*calibrationRun)
+	}
+	c.calibrating[hand] = run
+	store := c.calibration
+	c.mu.Unlock()
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	c.mu.Lock()
+	delete(c.calibrating, hand)
+	samples := run.samples
+	c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return Offsets{}, err
+	}
+
+	offsets, err := measureOffsets(samples)
+	if err != nil {
+		return Offsets{}, err
+	}
+
+	if store != nil {
+		if err := store.Set(glove.Address.String(), offsets); err != nil {
+			return offsets, err
+		}
+	}
+	ev := gloveEvent(EventCalibrationChanged, glove)
+	ev.Offsets = &offsets
+	c.emit(ev)
+	return offsets, nil
+}
+
+// measureOffsets derives sensor bias from samples taken while stationary.
+// Gyro bias is the mean rate; accel bias is the difference between the mean
+// reading and an ideal 1g vector along the same direction.
+func measureOffsets(samples []*SensorPacket) (Offsets, error) {
+	if len(samples) < calibrationMinSamples {
+		return Offsets{}, fmt.Errorf("not enough samples for calibration: %d", len(samples))
+	}
+
+	var mean [6]float64
+	for _, p := range samples {
+		ax, ay, az := p.AccelMS2()
+		gx, gy, gz := p.GyroDPS()
+		for i, v := range [6]float64{ax, ay, az, gx, gy, gz} {
+			mean[i] += v
+		}
+	}
+	n := float64(len(samples))
+	for i := range mean {
+		mean[i] /= n
+	}
+
+	var variance [6]float64
+	for _, p := range samples {
+		ax, ay, az := p.AccelMS2()
+		gx, gy, gz := p.GyroDPS()
+		for i, v := range [6]float64{ax, ay, az, gx, gy, gz} {
+			variance[i] += (v - mean[i]) * (v - mean[i])
+		}
+	}
+	accelStd := math.Sqrt((variance[0] + variance[1] + variance[2]) / n)
+	gyroStd := math.Sqrt((variance[3] + variance[4] + variance[5]) / n)
+	if accelStd > calibrationStillAccel || gyroStd > calibrationStillGyro {
+		return Offsets{}, ErrGloveMoved
+	}
+
+	mag := math.Sqrt(mean[0]*mean[0] + mean[1]*mean[1] + mean[2]*mean[2])
+	if mag == 0 {
+		return Offsets{}, fmt.Errorf("no gravity reading during calibration")
+	}
+	scale := standardGravity / mag
+
+	o := Offsets{MeasuredAt: time.Now()}
+	for i := 0; i < 3; i++ {
+		o.Accel[i] = mean[i] - mean[i]*scale
+		o.Gyro[i] = mean[i+3]
+	}
+	return o, nil
+}
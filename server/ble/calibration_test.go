@@ -0,0 +1,43 @@
+package ble_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"boxing-analytics/ble"
+)
+
+func TestCalibrateRejectsOverlap(t *testing.T) {
+	c, transport := newCentral(t)
+	transport.AddGlove(ble.LeftDeviceName)
+	if err := c.StartScanning(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "left glove connected", func() bool { return c.ConnectedGlove(ble.LeftHand) != nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Calibrate(ctx, ble.LeftHand, time.Minute)
+		done <- err
+	}()
+	waitFor(t, "calibration running", func() bool { return c.Calibrating(ble.LeftHand) })
+
+	if _, err := c.Calibrate(context.Background(), ble.LeftHand, time.Second); !errors.Is(err, ble.ErrCalibrating) {
+		t.Fatalf("overlapping calibration returned %v, want ErrCalibrating", err)
+	}
+	if !c.Calibrating(ble.LeftHand) {
+		t.Error("rejected calibration ended the running one")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled calibration returned %v", err)
+	}
+	if c.Calibrating(ble.LeftHand) {
+		t.Error("calibration still running after it returned")
+	}
+}
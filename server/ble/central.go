@@ -2,15 +2,13 @@
 package ble
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"strings"
 	"sync"
 	"time"
 
-	"github.com/godbus/dbus/v5"
-	"github.com/muka/go-bluetooth/bluez"
-	"github.com/muka/go-bluetooth/bluez/profile/gatt"
 	"tinygo.org/x/bluetooth"
 )
 
@@ -35,33 +33,56 @@ var (
 	SensorCharUUID  = bluetooth.NewUUID([16]byte{0xfb, 0x34, 0x9b, 0x5f, 0x80, 0x00, 0x00, 0x80, 0x00, 0x10, 0x00, 0x00, 0x35, 0x12, 0x00, 0x00})
 	BatteryCharUUID = bluetooth.NewUUID([16]byte{0xfb, 0x34, 0x9b, 0x5f, 0x80, 0x00, 0x00, 0x80, 0x00, 0x10, 0x00, 0x00, 0x36, 0x12, 0x00, 0x00})
 	DeviceCharUUID  = bluetooth.NewUUID([16]byte{0xfb, 0x34, 0x9b, 0x5f, 0x80, 0x00, 0x00, 0x80, 0x00, 0x10, 0x00, 0x00, 0x37, 0x12, 0x00, 0x00})
+	CommandCharUUID = bluetooth.NewUUID([16]byte{0xfb, 0x34, 0x9b, 0x5f, 0x80, 0x00, 0x00, 0x80, 0x00, 0x10, 0x00, 0x00, 0x38, 0x12, 0x00, 0x00})
 )
 
-// Device names for scanning
+// Default device names for scanning (see DeviceConfig)
 const (
 	LeftDeviceName  = "FighterLink_L"
 	RightDeviceName = "FighterLink_R"
 )
 
-// Standard big-endian UUID strings as BlueZ returns them in GetManagedObjects.
+// Default big-endian UUID strings as BlueZ returns them in GetManagedObjects.
+// DeviceConfig can replace them for other firmware.
 // bluetooth.UUID.String() outputs little-endian bytes and does NOT match these.
 const (
-	serviceUUIDStr    = "00001234-0000-1000-8000-00805f9b34fb"
-	sensorCharUUIDStr = "00001235-0000-1000-8000-00805f9b34fb"
+	serviceUUIDStr     = "00001234-0000-1000-8000-00805f9b34fb"
+	sensorCharUUIDStr  = "00001235-0000-1000-8000-00805f9b34fb"
+	batteryCharUUIDStr = "00001236-0000-1000-8000-00805f9b34fb"
+	deviceCharUUIDStr  = "00001237-0000-1000-8000-00805f9b34fb"
+	commandCharUUIDStr = "00001238-0000-1000-8000-00805f9b34fb"
 )
 
 // GloveConnection represents a connected glove.
 type GloveConnection struct {
 	Hand           Hand
 	Name           string // Device name (e.g., "FighterLink_L")
-	Device         *bluetooth.Device
+	Adapter        string // BlueZ adapter the glove is connected through (e.g., "hci0")
+	Device         Link
 	Address        bluetooth.Address
-	SensorChar     *gatt.GattCharacteristic1
-	PropCh         chan *bluez.PropertyChanged
-	Connected      bool
+	SensorChar     Characteristic
 	LastSeq        uint16
-	PacketLoss     float64
+	PacketLoss     float64   // Percent of samples lost over the last lossWindow sequence numbers
 	LastPacketTime time.Time // For packet timeout detection
+	MTU            uint16    // Negotiated ATT MTU (0 if BlueZ does not report it)
+	CorruptPackets int       // Notifications dropped for a bad or missing checksum or size
+
+	// From the battery, device info and command characteristics
+	CommandChar     Characteristic // nil if the firmware takes no commands
+	BatteryChar     Characteristic
+	Battery         uint8
+	HasBattery      bool
+	FirmwareVersion string
+	HardwareRev     string
+
+	// GATT discovery, for Diagnostics
+	ConnectedAt time.Time
+	DiscoveryMS int64           // connect to last characteristic looked up
+	Discovery   []CharDiscovery // characteristics looked up, in order
+
+	loss       lossTracker   // Rolling packet-loss window
+	checksums  ChecksumGuard // Rejects unchecked packets once checksummed ones arrived
+	lastSample *SensorPacket // Last sample passed on, for gap interpolation
 }
 
 // PacketHandler is called when a sensor packet is received.
@@ -76,27 +97,55 @@ const (
 	ConnectionCheckInterval = 500 * time.Millisecond
 )
 
+// pairWindow is how long a scan keeps looking for the second glove after the
+// first one is found, so both can be connected together.
+const pairWindow = 2 * time.Second
+
 // Central manages BLE connections to FighterLink gloves.
 type Central struct {
-	adapter *bluetooth.Adapter
-	mu      sync.RWMutex
+	transport Transport
+	mu        sync.RWMutex
 
 	leftGlove  *GloveConnection
 	rightGlove *GloveConnection
 
 	onPacket     PacketHandler
 	onDisconnect DisconnectHandler
-	scanning     bool
+	onHeartRate  HeartRateHandler
+	scan         sync.Mutex // Held while the adapter scans (StartScanning, ScanDevices)
 	stopScan     chan struct{}
 	stopMonitor  chan struct{} // For stopping the connection monitor
+
+	calibration *CalibrationStore        // Per-glove offsets keyed by MAC
+	calibrating map[Hand]*calibrationRun // Active calibration sample collectors
+
+	hrStrap *HeartRateConnection // Optional heart-rate strap
+
+	adapters   map[Hand]string // Adapter assignment per glove (default DefaultAdapterID)
+	connParams ConnParams      // Requested LE connection parameters for gloves
+	devices    DeviceConfig    // Accepted names and GATT UUIDs
+	pairing    *PairingStore   // Remembered glove per hand (nil = accept any)
+	bonding    bool            // Pair and bond gloves before streaming
+	maxFillGap int             // Longest sequence gap filled by interpolation (0 = off)
+
+	// Lifecycle state per glove, indexed by Hand (see state.go). A glove
+	// is connected exactly while its hand is StateConnected.
+	links [2]LinkState
+
+	leader *Central   // Central whose scans cover this one (nil = scans itself)
+	peers  []*Central // Centrals whose gloves this one's scans look for
+
+	events  eventBus // Lifecycle event subscribers (see Events)
+	connLog connLog  // Recent lifecycle steps (see ConnLog)
 }
 
 // NewCentral creates a new BLE Central manager.
 func NewCentral() *Central {
 	return &Central{
-		adapter:     bluetooth.DefaultAdapter,
+		transport:   newPlatformTransport(),
 		stopScan:    make(chan struct{}),
 		stopMonitor: make(chan struct{}),
+		devices:     DefaultDeviceConfig(),
 	}
 }
 
@@ -114,32 +163,35 @@ func (c *Central) SetDisconnectHandler(handler DisconnectHandler) {
 	c.onDisconnect = handler
 }
 
-// Enable initializes the BLE adapter.
-func (c *Central) Enable() error {
+// Enable initializes the BLE adapter. Cancelling ctx stops the connection
+// monitor; call DisconnectAll to release the gloves.
+func (c *Central) Enable(ctx context.Context) error {
 	log.Println("BLE: Enabling adapter...")
-	if err := c.adapter.Enable(); err != nil {
-		return fmt.Errorf("failed to enable BLE adapter: %w", err)
+	if err := c.transport.Enable(); err != nil {
+		return err
 	}
 	log.Println("BLE: Adapter enabled")
 
 	// Stop any stale scans from previous runs/crashes
 	// This ensures BlueZ is in a clean state
-	c.adapter.StopScan()
+	c.transport.StopScan()
 	time.Sleep(100 * time.Millisecond)
 
 	// Start the connection monitor goroutine
-	go c.connectionMonitor()
+	go c.connectionMonitor(ctx)
 
 	return nil
 }
 
 // connectionMonitor periodically checks for packet timeouts and handles disconnections.
-func (c *Central) connectionMonitor() {
+func (c *Central) connectionMonitor(ctx context.Context) {
 	ticker := time.NewTicker(ConnectionCheckInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
+		case <-ctx.Done():
+			return
 		case <-c.stopMonitor:
 			return
 		case <-ticker.C:
@@ -150,124 +202,72 @@ func (c *Central) connectionMonitor() {
 
 // checkConnectionHealth checks if gloves have timed out (no packets received).
 func (c *Central) checkConnectionHealth() {
-	c.mu.RLock()
-	leftGlove := c.leftGlove
-	rightGlove := c.rightGlove
-	c.mu.RUnlock()
-
 	now := time.Now()
+	var timedOut []Hand
 
-	// Check left glove
-	if leftGlove != nil && leftGlove.Connected {
-		if !leftGlove.LastPacketTime.IsZero() && now.Sub(leftGlove.LastPacketTime) > PacketTimeoutDuration {
-			log.Printf("BLE: Packet timeout detected for %s (no data for %.1fs)", leftGlove.Name, now.Sub(leftGlove.LastPacketTime).Seconds())
-			c.markDisconnected(LeftHand)
-		}
-	}
-
-	// Check right glove
-	if rightGlove != nil && rightGlove.Connected {
-		if !rightGlove.LastPacketTime.IsZero() && now.Sub(rightGlove.LastPacketTime) > PacketTimeoutDuration {
-			log.Printf("BLE: Packet timeout detected for %s (no data for %.1fs)", rightGlove.Name, now.Sub(rightGlove.LastPacketTime).Seconds())
-			c.markDisconnected(RightHand)
+	c.mu.RLock()
+	for _, hand := range []Hand{LeftHand, RightHand} {
+		if glove := c.connectedGloveLocked(hand); glove != nil {
+			if !glove.LastPacketTime.IsZero() && now.Sub(glove.LastPacketTime) > PacketTimeoutDuration {
+				log.Printf("BLE: Packet timeout detected for %s (no data for %.1fs)", glove.Name, now.Sub(glove.LastPacketTime).Seconds())
+				timedOut = append(timedOut, glove.Hand)
+			}
 		}
 	}
-}
-
-// watchDeviceConnection monitors the BlueZ Device1.Connected property via D-Bus.
-// When the device disconnects, it calls markDisconnected.
-func (c *Central) watchDeviceConnection(addr bluetooth.Address, hand Hand, deviceName string) {
-	// Build device D-Bus path from MAC address.
-	mac := strings.ToUpper(addr.String())
-	devID := strings.ReplaceAll(mac, ":", "_")
-	devPath := dbus.ObjectPath("/org/bluez/hci0/dev_" + devID)
+	strap := c.hrStrap
+	strapTimedOut := strap != nil && strap.Connected && now.Sub(strap.LastPacketTime) > HeartRateTimeout
+	c.mu.RUnlock()
 
-	conn, err := dbus.ConnectSystemBus()
-	if err != nil {
-		log.Printf("BLE: Failed to connect to D-Bus for disconnect watch: %v", err)
-		return
+	for _, hand := range timedOut {
+		c.markDisconnected(hand)
 	}
-	defer conn.Close()
 
-	// Subscribe to PropertiesChanged signals on this device object.
-	if err := conn.AddMatchSignal(
-		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
-		dbus.WithMatchMember("PropertiesChanged"),
-		dbus.WithMatchObjectPath(devPath),
-	); err != nil {
-		log.Printf("BLE: Failed to add D-Bus match for disconnect watch: %v", err)
-		return
+	// Check heart-rate strap
+	if strapTimedOut {
+		log.Printf("BLE: Heart rate timeout detected for %s", strap.Name)
+		c.markHeartRateDisconnected()
 	}
+}
 
-	ch := make(chan *dbus.Signal, 16)
-	conn.Signal(ch)
-
-	for sig := range ch {
-		// Check if we're still supposed to be connected
-		c.mu.RLock()
-		var glove *GloveConnection
-		if hand == LeftHand {
-			glove = c.leftGlove
-		} else {
-			glove = c.rightGlove
-		}
-		stillConnected := glove != nil && glove.Connected
-		c.mu.RUnlock()
-
-		if !stillConnected {
-			// Already marked as disconnected, stop watching
-			return
-		}
-
-		if len(sig.Body) < 2 {
-			continue
-		}
-		iface, ok := sig.Body[0].(string)
-		if !ok || iface != "org.bluez.Device1" {
-			continue
-		}
-		changed, ok := sig.Body[1].(map[string]dbus.Variant)
-		if !ok {
-			continue
-		}
+// isCurrent reports whether glove is still the connected glove for its hand,
+// so watchers from an earlier connection cannot tear down a newer one.
+func (c *Central) isCurrent(glove *GloveConnection) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.connectedGloveLocked(glove.Hand) == glove
+}
 
-		// Check if Connected property changed to false
-		if v, ok := changed["Connected"]; ok {
-			if connected, ok := v.Value().(bool); ok && !connected {
-				log.Printf("BLE: D-Bus reports %s disconnected", deviceName)
-				c.markDisconnected(hand)
-				return
-			}
-		}
+// markLost marks a glove disconnected if it is still the current connection.
+func (c *Central) markLost(glove *GloveConnection) {
+	if c.isCurrent(glove) {
+		c.markDisconnected(glove.Hand)
 	}
 }
 
 // markDisconnected marks a glove as disconnected and triggers the callback.
 func (c *Central) markDisconnected(hand Hand) {
 	c.mu.Lock()
-	var glove *GloveConnection
-	if hand == LeftHand {
-		glove = c.leftGlove
-	} else {
-		glove = c.rightGlove
-	}
-
-	if glove == nil || !glove.Connected {
+	glove := c.connectedGloveLocked(hand)
+	if glove == nil {
 		c.mu.Unlock()
 		return
 	}
 
 	deviceName := glove.Name
 	deviceAddr := glove.Address
-	glove.Connected = false
+	adapterID := glove.Adapter
+	_ = c.transitionLocked(hand, StateIdle)
 	handler := c.onDisconnect
 	c.mu.Unlock()
 
+	c.emit(gloveEvent(EventDisconnected, glove))
+	c.record(LogEntry{Kind: LogDisconnected, Hand: hand.String(), Name: deviceName, Address: deviceAddr.String(), Adapter: adapterID, Detail: "lost"})
+
 	log.Printf("BLE: Connection lost with %s (%s hand) - will attempt reconnect", deviceName, hand)
 
 	// Remove the device from BlueZ cache synchronously to allow fresh reconnection
 	// This is important when ESP32 wakes from deep sleep with a new BLE session
-	removeDeviceFromBlueZ(deviceAddr)
+	c.transport.Forget(adapterID, deviceAddr, false)
 	time.Sleep(500 * time.Millisecond) // Wait for BlueZ to process removal
 
 	// Trigger disconnect callback (which should start re-scanning)
@@ -276,55 +276,41 @@ func (c *Central) markDisconnected(hand Hand) {
 	}
 }
 
-// removeDeviceFromBlueZ removes a device from the BlueZ cache via D-Bus.
-// This helps with reconnection after ESP32 deep sleep wake-up, as BlueZ
-// may have stale cached state that prevents proper re-discovery.
-// It first attempts to disconnect, then removes the device from cache.
-func removeDeviceFromBlueZ(addr bluetooth.Address) {
-	mac := strings.ToUpper(addr.String())
-	devID := strings.ReplaceAll(mac, ":", "_")
-	devPath := dbus.ObjectPath("/org/bluez/hci0/dev_" + devID)
-
-	conn, err := dbus.ConnectSystemBus()
-	if err != nil {
-		log.Printf("BLE: Failed to connect to D-Bus for device removal: %v", err)
-		return
-	}
-	defer conn.Close()
-
-	// First, try to disconnect if still connected (ignore errors)
-	devObj := conn.Object("org.bluez", devPath)
-	_ = devObj.Call("org.bluez.Device1.Disconnect", 0)
-	time.Sleep(100 * time.Millisecond)
-
-	// Call RemoveDevice on the adapter
-	adapterObj := conn.Object("org.bluez", "/org/bluez/hci0")
-	call := adapterObj.Call("org.bluez.Adapter1.RemoveDevice", 0, devPath)
-	if call.Err != nil {
-		// Only log if it's not a "does not exist" error
-		errStr := call.Err.Error()
-		if !strings.Contains(errStr, "Does Not Exist") && !strings.Contains(errStr, "DoesNotExist") {
-			log.Printf("BLE: RemoveDevice %s: %v", devPath, call.Err)
-		}
-	} else {
-		log.Printf("BLE: Removed cached device %s from BlueZ", devPath)
+// gloveLocked returns the connection for a hand.
+// Must be called with c.mu held.
+func (c *Central) gloveLocked(hand Hand) *GloveConnection {
+	if hand == LeftHand {
+		return c.leftGlove
 	}
+	return c.rightGlove
 }
 
 // GetGlove returns the connection for a specific hand.
 func (c *Central) GetGlove(hand Hand) *GloveConnection {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	if hand == LeftHand {
-		return c.leftGlove
+	return c.gloveLocked(hand)
+}
+
+// ConnectedGlove returns the connection for a hand if its glove is
+// connected, nil otherwise.
+func (c *Central) ConnectedGlove(hand Hand) *GloveConnection {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.connectedGloveLocked(hand)
+}
+
+// connectedGloveLocked is ConnectedGlove with c.mu held.
+func (c *Central) connectedGloveLocked(hand Hand) *GloveConnection {
+	if c.links[hand] != StateConnected {
+		return nil
 	}
-	return c.rightGlove
+	return c.gloveLocked(hand)
 }
 
 // IsConnected returns true if the specified hand is connected.
 func (c *Central) IsConnected(hand Hand) bool {
-	glove := c.GetGlove(hand)
-	return glove != nil && glove.Connected
+	return c.LinkState(hand) == StateConnected
 }
 
 // BothConnected returns true if both gloves are connected.
@@ -332,309 +318,205 @@ func (c *Central) BothConnected() bool {
 	return c.IsConnected(LeftHand) && c.IsConnected(RightHand)
 }
 
-// handleNotification processes incoming BLE notifications.
-func (c *Central) handleNotification(hand Hand) func([]byte) {
+// handleNotification processes incoming BLE notifications, which carry one
+// sample or, with batched firmware, several. The hand is looked up on every
+// notification, as SwapHands may reassign the glove.
+func (c *Central) handleNotification(glove *GloveConnection) func([]byte) {
 	return func(data []byte) {
-		packet, err := ParsePacket(data)
+		c.mu.RLock()
+		hand := glove.Hand
+		c.mu.RUnlock()
+
+		packets, err := ParsePackets(data)
+		if err == nil {
+			c.mu.Lock()
+			err = glove.checksums.Check(packets)
+			c.mu.Unlock()
+		}
 		if err != nil {
 			log.Printf("BLE: Failed to parse packet from %s: %v", hand, err)
+			if errors.Is(err, ErrChecksum) || errors.Is(err, ErrInvalidPacketSize) || errors.Is(err, ErrMissingChecksum) {
+				c.mu.Lock()
+				glove.CorruptPackets++
+				c.mu.Unlock()
+			}
 			return
 		}
-
-		// Track packet loss via sequence numbers and update last packet time
-		c.mu.Lock()
-		glove := c.leftGlove
-		if hand == RightHand {
-			glove = c.rightGlove
-		}
-		if glove != nil {
-			// Update last packet time for timeout detection
-			glove.LastPacketTime = time.Now()
-
-			if glove.LastSeq > 0 {
-				expected := glove.LastSeq + 1
-				if packet.Sequence != expected && packet.Sequence != 0 {
-					// Calculate packet loss (simple approximation)
-					missed := int(packet.Sequence) - int(expected)
-					if missed > 0 && missed < 100 {
-						glove.PacketLoss = float64(missed) / float64(packet.Sequence) * 100
-					}
-				}
-			}
-			glove.LastSeq = packet.Sequence
-		}
-		handler := c.onPacket
-		c.mu.Unlock()
-
-		// Call the packet handler
-		if handler != nil {
-			handler(hand, packet)
+		for _, packet := range packets {
+			c.handleSample(hand, packet)
 		}
 	}
 }
 
-// waitForServicesResolved blocks until BlueZ reports ServicesResolved = true
-// for the given device address, or until the timeout expires.
-//
-// BlueZ performs GATT service discovery asynchronously after the ACL connection
-// is established. The ServicesResolved property on the Device1 D-Bus object
-// transitions false → true when the GATT profile is fully resolved. Polling
-// DiscoverServices before this event yields an empty list even on success.
-func waitForServicesResolved(addr bluetooth.Address, timeout time.Duration) error {
-	// Derive the BlueZ D-Bus object path from the MAC address.
-	// e.g. "D4:E9:F4:E2:B5:8A" → "/org/bluez/hci0/dev_D4_E9_F4_E2_B5_8A"
-	mac := strings.ToUpper(addr.String())
-	devID := strings.ReplaceAll(mac, ":", "_")
-	devPath := dbus.ObjectPath("/org/bluez/hci0/dev_" + devID)
-
-	conn, err := dbus.ConnectSystemBus()
-	if err != nil {
-		return fmt.Errorf("dbus: %w", err)
-	}
-	defer conn.Close()
-
-	obj := conn.Object("org.bluez", devPath)
-
-	// Fast path: already resolved (e.g. reconnect after prior session).
-	v, err := obj.GetProperty("org.bluez.Device1.ServicesResolved")
-	if err == nil {
-		if resolved, ok := v.Value().(bool); ok && resolved {
-			return nil
-		}
-	}
-
-	// Subscribe to PropertiesChanged signals on this device object.
-	if err := conn.AddMatchSignal(
-		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
-		dbus.WithMatchMember("PropertiesChanged"),
-		dbus.WithMatchObjectPath(devPath),
-	); err != nil {
-		return fmt.Errorf("dbus match: %w", err)
-	}
-
-	ch := make(chan *dbus.Signal, 16)
-	conn.Signal(ch)
-
-	timer := time.NewTimer(timeout)
-	defer timer.Stop()
-
-	for {
-		select {
-		case sig, ok := <-ch:
-			if !ok {
-				return fmt.Errorf("dbus signal channel closed")
-			}
-			if len(sig.Body) < 2 {
-				continue
-			}
-			iface, ok := sig.Body[0].(string)
-			if !ok || iface != "org.bluez.Device1" {
-				continue
-			}
-			changed, ok := sig.Body[1].(map[string]dbus.Variant)
-			if !ok {
-				continue
-			}
-			if v, ok := changed["ServicesResolved"]; ok {
-				if resolved, ok := v.Value().(bool); ok && resolved {
-					return nil
-				}
-			}
-		case <-timer.C:
-			return fmt.Errorf("timeout waiting for ServicesResolved")
-		}
-	}
-}
+// handleSample tracks loss and calibration for one sample and passes it to
+// the packet handler.
+func (c *Central) handleSample(hand Hand, packet *SensorPacket) {
 
-// discoverGATT opens a fresh D-Bus connection and calls GetManagedObjects
-// directly on org.bluez, bypassing the go-bluetooth singleton ObjectManager
-// which can return a stale/incomplete view of the GATT object tree.
-//
-// It returns the GattCharacteristic1 for the given (serviceUUID, charUUID) pair
-// under the device identified by addr.
-func discoverGATT(addr bluetooth.Address, serviceUUIDStr, charUUIDStr string) (*gatt.GattCharacteristic1, error) {
-	// Build device D-Bus path from MAC address.
-	// e.g. "D4:E9:F4:E2:B5:8A" → "/org/bluez/hci0/dev_D4_E9_F4_E2_B5_8A"
-	mac := strings.ToUpper(addr.String())
-	devID := strings.ReplaceAll(mac, ":", "_")
-	devPath := "/org/bluez/hci0/dev_" + devID
-
-	serviceUUIDStr = strings.ToLower(serviceUUIDStr)
-	charUUIDStr = strings.ToLower(charUUIDStr)
-
-	// Open a fresh D-Bus connection — NOT the go-bluetooth singleton.
-	// The singleton's cached connection may return stale GetManagedObjects data.
-	conn, err := dbus.ConnectSystemBus()
-	if err != nil {
-		return nil, fmt.Errorf("dbus connect: %w", err)
-	}
-	defer conn.Close()
-
-	// Call GetManagedObjects on the root BlueZ ObjectManager.
-	obj := conn.Object("org.bluez", "/")
-	var managed map[dbus.ObjectPath]map[string]map[string]dbus.Variant
-	if err := obj.Call("org.freedesktop.DBus.ObjectManager.GetManagedObjects", 0).Store(&managed); err != nil {
-		return nil, fmt.Errorf("GetManagedObjects: %w", err)
+	// Track packet loss via sequence numbers and update last packet time
+	var fills []*SensorPacket
+	c.mu.Lock()
+	glove := c.leftGlove
+	if hand == RightHand {
+		glove = c.rightGlove
 	}
+	if glove != nil {
+		// Update last packet time for timeout detection
+		glove.LastPacketTime = time.Now()
 
-	log.Printf("BLE: GetManagedObjects returned %d total objects", len(managed))
-
-	// Find the service path under our device.
-	var servicePath string
-	for path, ifaces := range managed {
-		pathStr := string(path)
-
-		// Must be exactly one level under devPath: devPath/serviceXXXX
-		if !strings.HasPrefix(pathStr, devPath+"/service") {
-			continue
-		}
-		suffix := pathStr[len(devPath)+1:] // e.g. "service0028"
-		if strings.Contains(suffix, "/") {
-			continue // deeper nesting — skip
+		gap, fresh := glove.loss.observe(packet.Sequence)
+		glove.PacketLoss = glove.loss.percent()
+		if fresh {
+			glove.LastSeq = packet.Sequence
 		}
 
-		svcIface, ok := ifaces["org.bluez.GattService1"]
-		if !ok {
-			continue
-		}
-		uuidVar, ok := svcIface["UUID"]
-		if !ok {
-			continue
+		// Collect raw samples for an in-progress calibration, then
+		// correct the packet with any stored offsets for this glove
+		if run, ok := c.calibrating[hand]; ok {
+			raw := *packet
+			run.samples = append(run.samples, &raw)
 		}
-		uuid, ok := uuidVar.Value().(string)
-		if !ok {
-			continue
-		}
-		log.Printf("BLE: Service candidate %s UUID=%s", pathStr, uuid)
-		if strings.ToLower(uuid) == serviceUUIDStr {
-			servicePath = pathStr
-			log.Printf("BLE: Matched service at %s", servicePath)
-			break
+		if c.calibration != nil {
+			if offsets, ok := c.calibration.Get(glove.Address.String()); ok {
+				packet.ApplyOffsets(offsets)
+			}
 		}
-	}
 
-	if servicePath == "" {
-		// Emit everything we found under this device for diagnostics.
-		for path := range managed {
-			if strings.HasPrefix(string(path), devPath) {
-				log.Printf("BLE: Object under device: %s", path)
+		// Fill short gaps so dropped samples don't shorten detection windows
+		if fresh {
+			if gap > 0 && gap <= c.maxFillGap && glove.lastSample != nil {
+				fills = interpolateSamples(glove.lastSample, packet, gap)
 			}
+			last := *packet
+			glove.lastSample = &last
 		}
-		return nil, fmt.Errorf("service %s not found on %s", serviceUUIDStr, devPath)
 	}
+	handler := c.onPacket
+	c.mu.Unlock()
 
-	// Find the sensor characteristic under the matched service.
-	var charPath string
-	for path, ifaces := range managed {
-		pathStr := string(path)
-
-		// Must be exactly one level under servicePath: servicePath/charXXXX
-		if !strings.HasPrefix(pathStr, servicePath+"/char") {
-			continue
-		}
-		suffix := pathStr[len(servicePath)+1:] // e.g. "char0029"
-		if strings.Contains(suffix, "/") {
-			continue
-		}
-
-		charIface, ok := ifaces["org.bluez.GattCharacteristic1"]
-		if !ok {
-			continue
-		}
-		uuidVar, ok := charIface["UUID"]
-		if !ok {
-			continue
-		}
-		uuid, ok := uuidVar.Value().(string)
-		if !ok {
-			continue
-		}
-		log.Printf("BLE: Char candidate %s UUID=%s", pathStr, uuid)
-		if strings.ToLower(uuid) == charUUIDStr {
-			charPath = pathStr
-			log.Printf("BLE: Matched characteristic at %s", charPath)
-			break
+	// Call the packet handler
+	if handler != nil {
+		for _, fill := range fills {
+			handler(hand, fill)
 		}
+		handler(hand, packet)
 	}
+}
 
-	if charPath == "" {
-		return nil, fmt.Errorf("characteristic %s not found under %s", charUUIDStr, servicePath)
-	}
+// SetGapInterpolation makes the central synthesize up to maxGap missing
+// samples between two received ones by linear interpolation. Synthesized
+// samples have Interpolated set. 0 turns interpolation off.
+func (c *Central) SetGapInterpolation(maxGap int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxFillGap = maxGap
+}
 
-	// Construct the GattCharacteristic1 wrapper.
-	// NewGattCharacteristic1 uses the go-bluetooth Client which lazily connects
-	// via the singleton D-Bus connection — this is fine for method calls like
-	// StartNotify and WatchProperties; only GetManagedObjects was unreliable.
-	char, err := gatt.NewGattCharacteristic1(dbus.ObjectPath(charPath))
-	if err != nil {
-		return nil, fmt.Errorf("NewGattCharacteristic1(%s): %w", charPath, err)
+// connectToDevice establishes a connection to a glove the caller has moved
+// to StateConnecting, leaving it connected on success and idle on failure.
+func (c *Central) connectToDevice(ctx context.Context, name string, addr bluetooth.Address, hand Hand) error {
+	start := time.Now()
+	if err := c.connect(ctx, name, addr, hand); err != nil {
+		_ = c.transition(hand, StateIdle)
+		c.record(LogEntry{Kind: LogConnectFailed, Hand: hand.String(), Name: name, Address: addr.String(),
+			MS: time.Since(start).Milliseconds(), Error: err.Error()})
+		return err
 	}
-
-	return char, nil
+	if glove := c.ConnectedGlove(hand); glove != nil {
+		c.emit(gloveEvent(EventConnected, glove))
+		if level, ok := c.GetBatteryLevel(hand); ok {
+			c.emit(batteryEvent(glove, level))
+		}
+	}
+	return nil
 }
 
-// connectToDevice establishes a connection to a discovered glove.
-func (c *Central) connectToDevice(result bluetooth.ScanResult, hand Hand) error {
-	log.Printf("BLE: Connecting to %s (%s)...", result.LocalName(), result.Address.String())
+// connect sets up the link, GATT subscriptions and connection record.
+func (c *Central) connect(ctx context.Context, name string, addr bluetooth.Address, hand Hand) error {
+	log.Printf("BLE: Connecting to %s (%s)...", name, addr.String())
+
+	adapterID := c.adapterFor(hand)
+	entry := LogEntry{Hand: hand.String(), Name: name, Address: addr.String(), Adapter: adapterID}
+	entry.Kind = LogConnecting
+	c.record(entry)
 
 	// Remove any stale cached device before connecting to ensure clean state
-	removeDeviceFromBlueZ(result.Address)
+	c.transport.Forget(adapterID, addr, false)
 	time.Sleep(300 * time.Millisecond)
+	start := time.Now()
 
-	device, err := c.adapter.Connect(result.Address, bluetooth.ConnectionParams{})
+	c.mu.RLock()
+	params := c.connParams
+	c.mu.RUnlock()
+	device, err := c.transport.Connect(ctx, adapterID, addr, params)
 	if err != nil {
-		return fmt.Errorf("failed to connect: %w", err)
+		return fmt.Errorf("failed to connect to %s: %w", name, err)
 	}
+	entry.Kind = LogServicesResolved
+	entry.MS = time.Since(start).Milliseconds()
+	c.record(entry)
 
-	log.Printf("BLE: Connected to %s, waiting for GATT profile...", result.LocalName())
-
-	// Wait for BlueZ to complete GATT service discovery (ServicesResolved = true).
-	if err := waitForServicesResolved(result.Address, 15*time.Second); err != nil {
-		device.Disconnect()
-		return fmt.Errorf("GATT not resolved on %s: %w", result.LocalName(), err)
+	if c.BondingEnabled() {
+		if err := c.transport.Bond(adapterID, addr); err != nil {
+			device.Disconnect()
+			return fmt.Errorf("bonding with %s failed: %w", name, err)
+		}
 	}
 
-	log.Printf("BLE: GATT resolved, discovering services via direct D-Bus...")
+	log.Printf("BLE: Connected to %s, discovering services...", name)
+	connectedAt := time.Now()
 
-	// Discover the sensor characteristic using our own D-Bus call, bypassing
-	// the go-bluetooth singleton ObjectManager.
-	sensorChar, err := discoverGATT(result.Address, serviceUUIDStr, sensorCharUUIDStr)
+	devices := c.deviceConfig()
+	sensorChar, err := c.transport.Characteristic(device, adapterID, addr, devices.ServiceUUID, devices.SensorCharUUID)
 	if err != nil {
 		device.Disconnect()
-		return fmt.Errorf("GATT discovery failed on %s: %w", result.LocalName(), err)
-	}
-
-	// Subscribe to PropertiesChanged signals for this characteristic.
-	// This replicates bluetooth.DeviceCharacteristic.EnableNotifications internally.
-	propCh, err := sensorChar.WatchProperties()
-	if err != nil {
-		device.Disconnect()
-		return fmt.Errorf("WatchProperties failed: %w", err)
-	}
-
-	// Ask BlueZ to start sending GATT notifications from the peripheral.
-	if err := sensorChar.StartNotify(); err != nil {
-		_ = sensorChar.UnwatchProperties(propCh)
-		device.Disconnect()
-		return fmt.Errorf("StartNotify failed: %w", err)
+		return fmt.Errorf("GATT discovery failed on %s: %w", name, err)
 	}
 
-	deviceName := result.LocalName()
-
 	// Create glove connection record.
 	glove := &GloveConnection{
 		Hand:           hand,
-		Name:           deviceName,
+		Name:           name,
+		Adapter:        adapterID,
 		Device:         device,
-		Address:        result.Address,
+		Address:        addr,
 		SensorChar:     sensorChar,
-		PropCh:         propCh,
-		Connected:      true,
 		LastPacketTime: time.Now(), // Initialize to avoid immediate timeout
+		ConnectedAt:    connectedAt,
+	}
+	glove.discovered("sensor", devices.SensorCharUUID, nil)
+
+	// Dispatch incoming GATT notifications to the packet handler.
+	notifyDone, err := sensorChar.Subscribe(c.handleNotification(glove))
+	if err != nil {
+		c.record(LogEntry{Kind: LogNotifyFailed, Hand: hand.String(), Name: name, Address: addr.String(),
+			Adapter: adapterID, Detail: "sensor", Error: err.Error()})
+		device.Disconnect()
+		return fmt.Errorf("subscribe to %s: %w", name, err)
+	}
+
+	// The ATT MTU is exchanged on connect. Batched packets need more than
+	// the 23-byte default.
+	if mtu, err := sensorChar.MTU(); err != nil {
+		log.Printf("BLE: MTU of %s unknown: %v", name, err)
+	} else {
+		glove.MTU = mtu
+		log.Printf("BLE: %s ATT MTU %d (up to %d samples per notification)", name, mtu, MaxBatchSamples(mtu, 0))
 	}
+	c.readGloveInfo(glove)
+	glove.DiscoveryMS = time.Since(connectedAt).Milliseconds()
+	entry.Kind = LogConnected
+	entry.MS = glove.DiscoveryMS
+	c.record(entry)
 
-	// Store before launching the goroutine so handleNotification can find it.
+	// Store and mark connected together, before watching so the watchers
+	// see the current connection.
 	c.mu.Lock()
+	if err := c.transitionLocked(hand, StateConnected); err != nil {
+		// Disconnected while connecting
+		c.mu.Unlock()
+		device.Disconnect()
+		return err
+	}
 	if hand == LeftHand {
 		c.leftGlove = glove
 	} else {
@@ -642,116 +524,198 @@ func (c *Central) connectToDevice(result bluetooth.ScanResult, hand Hand) error
 	}
 	c.mu.Unlock()
 
-	// Dispatch incoming GATT notifications to the packet handler.
-	notifHandler := c.handleNotification(hand)
 	go func() {
-		for update := range propCh {
-			if update == nil {
-				continue
-			}
-			if update.Interface == "org.bluez.GattCharacteristic1" && update.Name == "Value" {
-				notifHandler(update.Value.([]byte))
-			}
-		}
-		// Channel closed - this typically means disconnection
-		log.Printf("BLE: Property channel closed for %s - device may have disconnected", deviceName)
-		c.markDisconnected(hand)
+		<-notifyDone
+		// Notifications ended - this typically means disconnection
+		log.Printf("BLE: Notification stream closed for %s - device may have disconnected", name)
+		c.markLost(glove)
 	}()
 
-	// Start watching for device disconnection via D-Bus
-	go c.watchDeviceConnection(result.Address, hand, deviceName)
+	// Start watching for device disconnection
+	go c.transport.Watch(adapterID, addr, name,
+		func() bool { return c.isCurrent(glove) },
+		func() { c.markLost(glove) })
+
+	c.rememberGlove(hand, name, addr)
 
-	log.Printf("BLE: Connection established with %s (%s hand)", deviceName, hand)
+	log.Printf("BLE: Connection established with %s (%s hand)", name, hand)
 	return nil
 }
 
-// StartScanning begins scanning for FighterLink devices.
-func (c *Central) StartScanning() error {
-	c.mu.Lock()
-	if c.scanning {
-		// Already scanning according to our flag - just return
-		c.mu.Unlock()
+// StartScanning begins scanning for FighterLink devices. Cancelling ctx
+// stops the scan and any connection attempts it started.
+//
+// Gloves found during the scan are collected rather than connected one at a
+// time: once every glove still needed has been seen (or pairWindow after the
+// first one), the scan stops and all found devices are connected in
+// parallel. The scan lock is held until the connections finish so the
+// scanner does not start another scan meanwhile.
+func (c *Central) StartScanning(ctx context.Context) error {
+	if leader := c.scanLeader(); leader != nil {
+		return leader.StartScanning(ctx)
+	}
+
+	if !c.scan.TryLock() {
+		// Already scanning - just return
 		return nil
 	}
-	c.mu.Unlock()
 
 	// Always try to stop any existing scan at the adapter level first
 	// This handles stale BlueZ state from previous runs/crashes
-	c.adapter.StopScan()
+	c.transport.StopScan()
 	time.Sleep(100 * time.Millisecond)
 
 	c.mu.Lock()
-	c.scanning = true
 	c.stopScan = make(chan struct{})
 	c.mu.Unlock()
+	group := c.scanGroup()
+	setScanning(group, true)
 
 	log.Println("BLE: Starting scan for FighterLink devices...")
+	c.record(LogEntry{Kind: LogScanStarted})
 
 	go func() {
-		err := c.adapter.Scan(func(adapter *bluetooth.Adapter, result bluetooth.ScanResult) {
-			name := result.LocalName()
-
-			// Check if this is a FighterLink device we need
-			var hand Hand
-			var needsConnection bool
-
-			switch name {
-			case LeftDeviceName:
-				hand = LeftHand
-				needsConnection = !c.IsConnected(LeftHand)
-			case RightDeviceName:
-				hand = RightHand
-				needsConnection = !c.IsConnected(RightHand)
-			default:
-				return // Not a FighterLink device
+		// Release the scan lock once everything found is connected - the
+		// scanner's periodic checkAndScan() will restart the scan if more
+		// devices are needed
+		defer c.scan.Unlock()
+
+		var (
+			foundMu sync.Mutex
+			gloves  []foundGlove
+			strap   *bluetooth.ScanResult
+			window  *time.Timer
+			seen    = make(map[string]bool) // addresses logged this scan
+		)
+
+		scanDone := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				c.transport.StopScan()
+			case <-scanDone:
 			}
+		}()
 
-			if !needsConnection {
-				return // Already connected
-			}
+		err := c.transport.Scan(func(result bluetooth.ScanResult) {
+			name := result.LocalName()
 
-			log.Printf("BLE: Found %s at %s", name, result.Address.String())
+			foundMu.Lock()
+			defer foundMu.Unlock()
 
-			// Stop scanning to connect
-			adapter.StopScan()
+			entry := LogEntry{Kind: LogScanResult, Name: name, Address: result.Address.String(), RSSI: result.RSSI}
+			logFirst := func(detail string) {
+				if !seen[entry.Address] {
+					seen[entry.Address] = true
+					entry.Detail = detail
+					c.record(entry)
+				}
+			}
 
-			// Reset scanning flag - let scanner restart if needed
-			c.mu.Lock()
-			c.scanning = false
-			c.mu.Unlock()
+			// Check if this is a glove we need
+			if !isGloveName(group, name) {
+				if result.HasServiceUUID(bluetooth.ServiceUUIDHeartRate) {
+					logFirst("heart-rate strap")
+				}
+				if strap == nil && result.HasServiceUUID(bluetooth.ServiceUUIDHeartRate) && c.NeedsHeartRate() {
+					log.Printf("BLE: Found heart-rate strap %s at %s", name, result.Address.String())
+					strap = &result
+					if len(gloves) == 0 {
+						c.transport.StopScan()
+					}
+				}
+				return // Not a glove
+			}
 
-			if err := c.connectToDevice(result, hand); err != nil {
-				log.Printf("BLE: Failed to connect to %s: %v", name, err)
-				// Connection failed - scanner's periodic checkAndScan() will restart
+			found, ok := claimGlove(group, result)
+			if !ok {
+				logFirst("not claimed")
 				return
 			}
+			entry.Hand = found.hand.String()
+			logFirst("")
 
-			if c.BothConnected() {
-				log.Println("BLE: Both gloves connected")
+			log.Printf("BLE: Found %s at %s", name, result.Address.String())
+			gloves = append(gloves, found)
+			found.central.emit(Event{Type: EventDeviceDiscovered, Hand: found.hand, Name: name, Address: result.Address})
+
+			if !groupScanning(group) {
+				// Everything needed is found - stop scanning to connect
+				c.transport.StopScan()
+			} else if window == nil {
+				// Give the other gloves a moment to show up so they
+				// connect together
+				window = time.AfterFunc(pairWindow, func() { c.transport.StopScan() })
 			}
-			// Scanner's periodic checkAndScan() will restart scan if more devices needed
 		})
 
+		close(scanDone)
+		foundMu.Lock()
+		if window != nil {
+			window.Stop()
+		}
+		foundMu.Unlock()
+
 		if err != nil {
 			log.Printf("BLE: Scan error: %v", err)
-			// Reset scanning flag on error so scanner can retry
-			c.mu.Lock()
-			c.scanning = false
-			c.mu.Unlock()
+		}
+		foundMu.Lock()
+		c.record(LogEntry{Kind: LogScanStopped, Detail: fmt.Sprintf("%d claimed", len(gloves)), Error: errString(err)})
+		foundMu.Unlock()
+
+		// Gloves not found go back to idle for the next scan
+		setScanning(group, false)
+
+		// Connect everything found concurrently
+		var wg sync.WaitGroup
+		for _, found := range gloves {
+			wg.Add(1)
+			go func(found foundGlove) {
+				defer wg.Done()
+				name := found.result.LocalName()
+				if err := found.central.connectToDevice(ctx, name, found.result.Address, found.hand); err != nil {
+					log.Printf("BLE: Failed to connect to %s: %v", name, err)
+					// Connection failed - scanner's periodic checkAndScan() will restart
+				}
+			}(found)
+		}
+		if strap != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := c.connectHeartRate(ctx, *strap); err != nil {
+					log.Printf("BLE: Failed to connect to heart-rate strap %s: %v", strap.LocalName(), err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(gloves) > 0 && c.BothConnected() {
+			log.Println("BLE: Both gloves connected")
 		}
 	}()
 
 	return nil
 }
 
+// IsScanning returns true while a glove of this Central, or of a Central it
+// shares scans with (see ShareScan), is being scanned for or connected.
+func (c *Central) IsScanning() bool {
+	if leader := c.scanLeader(); leader != nil {
+		return leader.IsScanning()
+	}
+	for _, member := range c.scanGroup() {
+		if member.linkBusy() {
+			return true
+		}
+	}
+	return false
+}
+
 // StopScanning stops the BLE scan.
 func (c *Central) StopScanning() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if c.scanning {
-		c.scanning = false
-		c.adapter.StopScan()
+	if c.IsScanning() {
+		c.transport.StopScan()
 		log.Println("BLE: Scan stopped")
 	}
 }
@@ -773,44 +737,71 @@ func (c *Central) Disconnect(hand Hand) error {
 		c.mu.Unlock()
 		return nil
 	}
+	wasConnected := c.links[hand] == StateConnected
+	_ = c.transitionLocked(hand, StateIdle)
 
 	deviceAddr := glove.Address
-	wasConnected := glove.Connected
+	adapterID := glove.Adapter
 	c.mu.Unlock()
 
 	if wasConnected {
-		glove.Connected = false
-		// Stop notifications and clean up the D-Bus signal subscription.
+		// Stop notifications before dropping the link.
 		if glove.SensorChar != nil {
-			_ = glove.SensorChar.StopNotify()
-			if glove.PropCh != nil {
-				_ = glove.SensorChar.UnwatchProperties(glove.PropCh)
-			}
+			_ = glove.SensorChar.Unsubscribe()
+		}
+		if glove.BatteryChar != nil {
+			_ = glove.BatteryChar.Unsubscribe()
 		}
 		if err := glove.Device.Disconnect(); err != nil {
 			return fmt.Errorf("failed to disconnect %s glove: %w", hand, err)
 		}
 		log.Printf("BLE: %s glove disconnected", hand)
+		c.emit(gloveEvent(EventDisconnected, glove))
+		c.record(LogEntry{Kind: LogDisconnected, Hand: hand.String(), Name: glove.Name, Address: deviceAddr.String(), Adapter: adapterID, Detail: "closed"})
 
 		// Remove from BlueZ cache to allow clean reconnection
-		go removeDeviceFromBlueZ(deviceAddr)
+		go c.transport.Forget(adapterID, deviceAddr, false)
 	}
 
 	return nil
 }
 
-// DisconnectAll disconnects from all gloves.
+// DisconnectAll disconnects from all gloves and the heart-rate strap.
 func (c *Central) DisconnectAll() {
 	c.Disconnect(LeftHand)
 	c.Disconnect(RightHand)
+	c.disconnectHeartRate()
 }
 
-// GetBatteryLevel returns the battery level for a glove (if available).
+// GetBatteryLevel returns the battery level for a glove as last reported by
+// its battery characteristic (if available).
 func (c *Central) GetBatteryLevel(hand Hand) (uint8, bool) {
-	glove := c.GetGlove(hand)
-	if glove == nil || !glove.Connected {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	glove := c.connectedGloveLocked(hand)
+	if glove == nil || !glove.HasBattery {
 		return 0, false
 	}
-	// Battery is included in each packet, so we track it in the analyzer
-	return 0, false
+	return glove.Battery, true
+}
+
+// GetPacketLoss returns the percentage of samples a glove lost recently.
+func (c *Central) GetPacketLoss(hand Hand) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if glove := c.gloveLocked(hand); glove != nil {
+		return glove.PacketLoss
+	}
+	return 0
+}
+
+// GetCorruptPackets returns how many notifications from a glove failed their
+// checksum or size check on the current connection.
+func (c *Central) GetCorruptPackets(hand Hand) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if glove := c.gloveLocked(hand); glove != nil {
+		return glove.CorruptPackets
+	}
+	return 0
 }
@@ -0,0 +1,138 @@
+package ble_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"boxing-analytics/ble"
+)
+
+// nextEvent returns the next lifecycle event of a type, skipping others.
+func nextEvent(t *testing.T, events <-chan ble.Event, typ ble.EventType) ble.Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == typ {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for a %s event", typ)
+		}
+	}
+}
+
+func TestConnectNotifyDisconnect(t *testing.T) {
+	c, transport := newCentral(t)
+	events, stop := c.Events()
+	defer stop()
+	left := transport.AddGlove(ble.LeftDeviceName)
+	right := transport.AddGlove(ble.RightDeviceName)
+
+	var (
+		mu      sync.Mutex
+		packets = make(map[ble.Hand][]*ble.SensorPacket)
+	)
+	c.SetPacketHandler(func(hand ble.Hand, p *ble.SensorPacket) {
+		mu.Lock()
+		defer mu.Unlock()
+		packets[hand] = append(packets[hand], p)
+	})
+	received := func(hand ble.Hand) []*ble.SensorPacket {
+		mu.Lock()
+		defer mu.Unlock()
+		return packets[hand]
+	}
+
+	if err := c.StartScanning(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for range [2]struct{}{} {
+		ev := nextEvent(t, events, ble.EventConnected)
+		if ev.Glove == nil || ev.Name == "" {
+			t.Errorf("connected event without its glove: %+v", ev)
+		}
+	}
+	if !c.BothConnected() {
+		t.Fatal("both gloves connected but not reported so")
+	}
+	if !left.Connected() || !right.Connected() {
+		t.Fatal("fake gloves have no open link")
+	}
+
+	// Notifications reach the packet handler under the glove's hand
+	if !left.Notify((&ble.SensorPacket{AccX: 1234, GyroZ: -50, Sequence: 1, Battery: 90}).Marshal()) {
+		t.Fatal("left glove sensor notifications not subscribed")
+	}
+	waitFor(t, "left packet", func() bool { return len(received(ble.LeftHand)) == 1 })
+	if p := received(ble.LeftHand)[0]; p.AccX != 1234 || p.GyroZ != -50 || p.Sequence != 1 {
+		t.Errorf("left packet %+v, want AccX 1234, GyroZ -50, Sequence 1", p)
+	}
+	if n := len(received(ble.RightHand)); n != 0 {
+		t.Errorf("right hand got %d packets from the left glove", n)
+	}
+
+	// A corrupt notification is counted, not passed on
+	left.Notify([]byte{ble.PacketVersion2, 0, 1, 2, 3})
+	waitFor(t, "corrupt packet counted", func() bool { return c.GetCorruptPackets(ble.LeftHand) == 1 })
+	if n := len(received(ble.LeftHand)); n != 1 {
+		t.Errorf("left hand got %d packets, want 1", n)
+	}
+
+	// Closing the link from the server side
+	if err := c.Disconnect(ble.LeftHand); err != nil {
+		t.Fatal(err)
+	}
+	if ev := nextEvent(t, events, ble.EventDisconnected); ev.Hand != ble.LeftHand {
+		t.Errorf("disconnected event for the %s hand, want left", ev.Hand)
+	}
+	if left.Connected() {
+		t.Error("left glove link still open after Disconnect")
+	}
+	if c.IsConnected(ble.LeftHand) || c.GetGlove(ble.LeftHand) != nil {
+		t.Error("left glove still recorded after Disconnect")
+	}
+
+	// Losing the link from the glove side
+	right.Drop()
+	if ev := nextEvent(t, events, ble.EventDisconnected); ev.Hand != ble.RightHand {
+		t.Errorf("disconnected event for the %s hand, want right", ev.Hand)
+	}
+	waitFor(t, "right glove lost", func() bool { return !c.IsConnected(ble.RightHand) })
+	if right.Notify((&ble.SensorPacket{Sequence: 2}).Marshal()) {
+		t.Error("dropped glove still has a subscriber")
+	}
+}
+
+func TestBatteryEvents(t *testing.T) {
+	c, transport := newCentral(t)
+	events, stop := c.Events()
+	left := transport.AddGlove(ble.LeftDeviceName)
+
+	if err := c.StartScanning(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	nextEvent(t, events, ble.EventConnected)
+
+	// The level read on connect, then each one the glove notifies
+	if ev := nextEvent(t, events, ble.EventBattery); ev.Hand != ble.LeftHand || ev.Battery != 100 {
+		t.Errorf("battery event for the %s hand at %d%%, want left at 100%%", ev.Hand, ev.Battery)
+	}
+	left.SetBattery(15)
+	if ev := nextEvent(t, events, ble.EventBattery); ev.Battery != 15 {
+		t.Errorf("battery event at %d%%, want 15%%", ev.Battery)
+	}
+
+	// Cancelling unsubscribes and closes the channel
+	stop()
+	stop()
+	left.SetBattery(10)
+	for ev := range events {
+		if ev.Type == ble.EventBattery && ev.Battery == 10 {
+			t.Error("battery event delivered after cancel")
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package ble
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Command characteristic opcodes (see firmware config.h)
+const (
+	CommandBuzz byte = 0x01 // [0x01, duration_ms (uint16 LE), intensity]
+	CommandLED  byte = 0x02 // [0x02, r, g, b, duration_ms (uint16 LE)]
+)
+
+// MaxFeedbackDuration is the longest buzz or LED override a command can hold.
+const MaxFeedbackDuration = 10 * time.Second
+
+// ErrNoCommandChar is returned when a glove's firmware has no command
+// characteristic.
+var ErrNoCommandChar = errors.New("glove does not support feedback commands")
+
+// BuzzCommand encodes a haptic buzz of the given duration and intensity
+// (0-255 PWM duty).
+func BuzzCommand(duration time.Duration, intensity uint8) []byte {
+	cmd := []byte{CommandBuzz, 0, 0, intensity}
+	binary.LittleEndian.PutUint16(cmd[1:3], feedbackMS(duration))
+	return cmd
+}
+
+// LEDCommand encodes an LED colour held for the given duration.
+func LEDCommand(r, g, b uint8, duration time.Duration) []byte {
+	cmd := []byte{CommandLED, r, g, b, 0, 0}
+	binary.LittleEndian.PutUint16(cmd[4:6], feedbackMS(duration))
+	return cmd
+}
+
+// feedbackMS clamps a feedback duration to the command's range.
+func feedbackMS(d time.Duration) uint16 {
+	if d > MaxFeedbackDuration {
+		d = MaxFeedbackDuration
+	}
+	if d < 0 {
+		d = 0
+	}
+	return uint16(d.Milliseconds())
+}
+
+// SendCommand writes a raw command to a glove's command characteristic.
+func (c *Central) SendCommand(hand Hand, cmd []byte) error {
+	glove := c.ConnectedGlove(hand)
+	if glove == nil {
+		return fmt.Errorf("%s glove not connected", hand)
+	}
+	if glove.CommandChar == nil {
+		return ErrNoCommandChar
+	}
+	if err := glove.CommandChar.WriteValue(cmd); err != nil {
+		return fmt.Errorf("write command to %s glove: %w", hand, err)
+	}
+	return nil
+}
+
+// Buzz vibrates a glove's haptic motor.
+func (c *Central) Buzz(hand Hand, duration time.Duration, intensity uint8) error {
+	return c.SendCommand(hand, BuzzCommand(duration, intensity))
+}
+
+// SetLED shows a colour on a glove's LED for the given duration.
+func (c *Central) SetLED(hand Hand, r, g, b uint8, duration time.Duration) error {
+	return c.SendCommand(hand, LEDCommand(r, g, b, duration))
+}
@@ -0,0 +1,80 @@
+package ble
+
+import (
+	"sync"
+	"time"
+)
+
+// connLogSize is how many entries the connection log keeps.
+const connLogSize = 500
+
+// Connection log entry kinds
+const (
+	LogScanStarted      = "scan_started"
+	LogScanResult       = "scan_result"       // first sighting of a glove or strap in a scan
+	LogScanStopped      = "scan_stopped"      // Detail: how many were claimed
+	LogConnecting       = "connecting"        // a connect attempt started
+	LogServicesResolved = "services_resolved" // link up with GATT services discovered; MS the connect took
+	LogNotifyFailed     = "notify_failed"     // StartNotify failed; Detail: the characteristic
+	LogConnected        = "connected"         // streaming; MS spent on GATT discovery
+	LogConnectFailed    = "connect_failed"    // MS since connecting
+	LogDisconnected     = "disconnected"      // Detail: "lost" or "closed"
+)
+
+// LogEntry is one step in the life of a BLE connection.
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Hand    string    `json:"hand,omitempty"` // "" for the heart-rate strap and unclaimed results
+	Name    string    `json:"name,omitempty"`
+	Address string    `json:"address,omitempty"`
+	Adapter string    `json:"adapter,omitempty"`
+	RSSI    int16     `json:"rssi,omitempty"`
+	MS      int64     `json:"ms,omitempty"`
+	Detail  string    `json:"detail,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// connLog is a ring of the last connLogSize entries, so the lifecycle of
+// recent connections can be read back over the API instead of from the
+// system journal.
+type connLog struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int // where the next entry goes once the ring is full
+}
+
+// add appends an entry, overwriting the oldest once the log is full.
+func (l *connLog) add(e LogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) < connLogSize {
+		l.entries = append(l.entries, e)
+		return
+	}
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % connLogSize
+}
+
+// ConnLog returns the connection log, oldest entry first.
+func (c *Central) ConnLog() []LogEntry {
+	c.connLog.mu.Lock()
+	defer c.connLog.mu.Unlock()
+	out := make([]LogEntry, 0, len(c.connLog.entries))
+	out = append(out, c.connLog.entries[c.connLog.next:]...)
+	return append(out, c.connLog.entries[:c.connLog.next]...)
+}
+
+// record adds an entry to the connection log.
+func (c *Central) record(e LogEntry) {
+	e.Time = time.Now()
+	c.connLog.add(e)
+}
+
+// errString returns err's message, or "" for nil.
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,117 @@
+package ble
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// LE connection parameter units and limits (Bluetooth Core spec, Vol 6 Part B 4.5)
+const (
+	connIntervalUnit   = 1250 * time.Microsecond
+	connIntervalMin    = 6 * connIntervalUnit    // 7.5ms
+	connIntervalMax    = 3200 * connIntervalUnit // 4s
+	supervisionUnit    = 10 * time.Millisecond
+	supervisionMin     = 100 * time.Millisecond
+	supervisionMax     = 32 * time.Second
+	connLatencyMax     = 499
+	bluetoothDebugRoot = "/sys/kernel/debug/bluetooth"
+)
+
+// ConnParams are the LE connection parameters requested for glove links. A
+// zero value leaves the BlueZ defaults, whose 30-50ms intervals queue
+// several 100Hz packets per connection event.
+type ConnParams struct {
+	MinInterval        time.Duration // shortest connection interval (>= 7.5ms)
+	MaxInterval        time.Duration // longest connection interval
+	Latency            uint16        // connection events the glove may skip
+	SupervisionTimeout time.Duration // silence before the link is dropped
+}
+
+// DefaultConnParams returns parameters suited to two 100Hz streams: one or
+// two packets per connection event and a quick link-loss detection.
+func DefaultConnParams() ConnParams {
+	return ConnParams{
+		MinInterval:        7500 * time.Microsecond,  // 6 units
+		MaxInterval:        11250 * time.Microsecond, // 9 units
+		Latency:            0,
+		SupervisionTimeout: 2 * time.Second,
+	}
+}
+
+// IsZero reports whether no parameters are set.
+func (p ConnParams) IsZero() bool {
+	return p == ConnParams{}
+}
+
+// Validate checks the parameters against the limits of the specification.
+func (p ConnParams) Validate() error {
+	if p.IsZero() {
+		return nil
+	}
+	if p.MinInterval < connIntervalMin || p.MaxInterval > connIntervalMax || p.MinInterval > p.MaxInterval {
+		return fmt.Errorf("connection interval %v-%v must be within %v-%v", p.MinInterval, p.MaxInterval, connIntervalMin, connIntervalMax)
+	}
+	if p.Latency > connLatencyMax {
+		return fmt.Errorf("connection latency %d exceeds %d", p.Latency, connLatencyMax)
+	}
+	if p.SupervisionTimeout < supervisionMin || p.SupervisionTimeout > supervisionMax {
+		return fmt.Errorf("supervision timeout %v must be within %v-%v", p.SupervisionTimeout, supervisionMin, supervisionMax)
+	}
+	// The link must survive the longest run of skipped connection events
+	if p.SupervisionTimeout <= 2*time.Duration(1+int(p.Latency))*p.MaxInterval {
+		return fmt.Errorf("supervision timeout %v too short for latency %d at %v", p.SupervisionTimeout, p.Latency, p.MaxInterval)
+	}
+	return nil
+}
+
+// SetConnParams sets the connection parameters requested for new glove links.
+func (c *Central) SetConnParams(p ConnParams) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connParams = p
+}
+
+// bluetoothParams converts the parameters for tinygo's Connect.
+func (p ConnParams) bluetoothParams() bluetooth.ConnectionParams {
+	return bluetooth.ConnectionParams{
+		MinInterval: bluetooth.NewDuration(p.MinInterval),
+		MaxInterval: bluetooth.NewDuration(p.MaxInterval),
+	}
+}
+
+// applyConnParams sets the kernel's defaults for new LE connections on an
+// adapter. BlueZ has no D-Bus API for connection parameters, so they are
+// written to the adapter's debugfs entries, which needs root and a mounted
+// debugfs.
+func applyConnParams(adapterID string, p ConnParams) error {
+	if p.IsZero() {
+		return nil
+	}
+	dir := filepath.Join(bluetoothDebugRoot, adapterID)
+	write := func(name string, v int) error {
+		return os.WriteFile(filepath.Join(dir, name), []byte(strconv.Itoa(v)), 0o644)
+	}
+
+	// The kernel rejects min > max, so widen max before narrowing min
+	steps := []struct {
+		name string
+		v    int
+	}{
+		{"conn_max_interval", int(connIntervalMax / connIntervalUnit)},
+		{"conn_min_interval", int(p.MinInterval / connIntervalUnit)},
+		{"conn_max_interval", int(p.MaxInterval / connIntervalUnit)},
+		{"conn_latency", int(p.Latency)},
+		{"supervision_timeout", int(p.SupervisionTimeout / supervisionUnit)},
+	}
+	for _, s := range steps {
+		if err := write(s.name, s.v); err != nil {
+			return fmt.Errorf("set %s: %w", s.name, err)
+		}
+	}
+	return nil
+}
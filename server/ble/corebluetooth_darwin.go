@@ -0,0 +1,210 @@
+package ble
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// coreBluetoothBackend drives CoreBluetooth through tinygo bluetooth, so
+// the full stack runs on a Mac for development. macOS has a single adapter,
+// handles pairing itself and addresses devices by a UUID it assigns rather
+// than by MAC.
+type coreBluetoothBackend struct {
+	adapterScanner
+	mu     sync.Mutex
+	links  tinygoLinks
+	hooked bool                       // Connect handler installed on the adapter
+	lost   map[string][]chan struct{} // Closed when a device disconnects, keyed by address
+}
+
+// newPlatformTransport returns the macOS transport.
+func newPlatformTransport() Transport {
+	return &coreBluetoothBackend{adapterScanner: adapterScanner{bluetooth.DefaultAdapter}}
+}
+
+func (b *coreBluetoothBackend) Connect(_ context.Context, adapterID string, addr bluetooth.Address, params ConnParams) (Link, error) {
+	if adapterID != DefaultAdapterID {
+		log.Printf("BLE: macOS has a single adapter, ignoring %s", adapterID)
+	}
+	b.hook()
+
+	// CoreBluetooth discovers services on demand, so there is nothing to
+	// wait for once the link is up.
+	device, err := b.adapter.Connect(addr, params.bluetoothParams())
+	if err != nil {
+		return nil, err
+	}
+	return b.links.add(addr, device), nil
+}
+
+// hook routes the adapter's disconnect callbacks to the watchers. tinygo
+// has one connect handler per adapter, so it is installed once.
+func (b *coreBluetoothBackend) hook() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.hooked {
+		return
+	}
+	b.hooked = true
+	b.adapter.SetConnectHandler(func(addr bluetooth.Address, connected bool) {
+		if !connected {
+			b.release(addr)
+		}
+	})
+}
+
+// lostSignal returns a channel that is closed when the device disconnects.
+func (b *coreBluetoothBackend) lostSignal(addr bluetooth.Address) <-chan struct{} {
+	ch := make(chan struct{})
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.lost == nil {
+		b.lost = make(map[string][]chan struct{})
+	}
+	b.lost[addr.String()] = append(b.lost[addr.String()], ch)
+	return ch
+}
+
+// release closes the lost signals of a device.
+func (b *coreBluetoothBackend) release(addr bluetooth.Address) {
+	b.mu.Lock()
+	chans := b.lost[addr.String()]
+	delete(b.lost, addr.String())
+	b.mu.Unlock()
+	for _, ch := range chans {
+		close(ch)
+	}
+}
+
+func (b *coreBluetoothBackend) Characteristic(link Link, _ string, addr bluetooth.Address, serviceUUID, charUUID string) (Characteristic, error) {
+	char, err := discoverCharacteristic(link, addr, serviceUUID, charUUID)
+	if err != nil {
+		return nil, err
+	}
+	return &coreBluetoothCharacteristic{char: char, backend: b, addr: addr}, nil
+}
+
+func (b *coreBluetoothBackend) Watch(_ string, addr bluetooth.Address, name string, stillConnected func() bool, onLost func()) {
+	lost := b.lostSignal(addr)
+
+	// Re-check periodically so the watch ends once the connection is gone
+	ticker := time.NewTicker(ConnectionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !stillConnected() {
+				return
+			}
+		case <-lost:
+			if stillConnected() {
+				log.Printf("BLE: CoreBluetooth reports %s disconnected", name)
+				onLost()
+			}
+			return
+		}
+	}
+}
+
+// Forget closes a link still open to the device and ends its watchers.
+// CoreBluetooth keeps no device cache the server can clear, and bonds are
+// removed in System Settings.
+func (b *coreBluetoothBackend) Forget(_ string, addr bluetooth.Address, _ bool) {
+	b.links.close(addr)
+	b.release(addr)
+}
+
+// RegisterAgent has nothing to register: macOS asks for the passkey in a
+// system dialog when a bonded glove first connects.
+func (b *coreBluetoothBackend) RegisterAgent(uint32) error {
+	log.Println("BLE: macOS prompts for the glove passkey itself, BLE_PASSKEY is not used")
+	return nil
+}
+
+// Bond leaves pairing to CoreBluetooth, which pairs as soon as an encrypted
+// characteristic is first accessed.
+func (b *coreBluetoothBackend) Bond(string, bluetooth.Address) error {
+	return nil
+}
+
+func (b *coreBluetoothBackend) ParseAddress(s string) (bluetooth.Address, error) {
+	uuid, err := bluetooth.ParseUUID(s)
+	if err != nil {
+		return bluetooth.Address{}, err
+	}
+	return bluetooth.Address{UUID: uuid}, nil
+}
+
+// coreBluetoothCharacteristic is a characteristic discovered through
+// tinygo bluetooth.
+type coreBluetoothCharacteristic struct {
+	char    bluetooth.DeviceCharacteristic
+	backend *coreBluetoothBackend
+	addr    bluetooth.Address
+
+	stop     chan struct{} // Closed by Unsubscribe
+	stopOnce sync.Once
+}
+
+func (c *coreBluetoothCharacteristic) ReadValue() ([]byte, error) {
+	return readCharacteristic(&c.char)
+}
+
+// WriteValue writes without response, the only write tinygo supports on
+// macOS. The firmware's command characteristic accepts both kinds.
+func (c *coreBluetoothCharacteristic) WriteValue(data []byte) error {
+	_, err := c.char.WriteWithoutResponse(data)
+	return err
+}
+
+// Subscribe enables notifications. The returned channel closes when the
+// device disconnects or Unsubscribe is called.
+func (c *coreBluetoothCharacteristic) Subscribe(handler func([]byte)) (<-chan struct{}, error) {
+	c.stop = make(chan struct{})
+	stop := c.stop
+	err := c.char.EnableNotifications(func(buf []byte) {
+		select {
+		case <-stop:
+		default:
+			handler(buf)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lost := c.backend.lostSignal(c.addr)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-lost:
+		case <-stop:
+		}
+	}()
+	return done, nil
+}
+
+// Unsubscribe stops passing notifications on. tinygo cannot disable them
+// on macOS, so they keep arriving until the link is closed.
+func (c *coreBluetoothCharacteristic) Unsubscribe() error {
+	if c.stop != nil {
+		c.stopOnce.Do(func() { close(c.stop) })
+	}
+	return nil
+}
+
+// MTU adds the 3-byte ATT header back to the longest write without
+// response, which is what tinygo reports on macOS.
+func (c *coreBluetoothCharacteristic) MTU() (uint16, error) {
+	n, err := c.char.GetMTU()
+	if err != nil {
+		return 0, err
+	}
+	return n + 3, nil
+}
@@ -0,0 +1,101 @@
+package ble
+
+import (
+	"fmt"
+	"log"
+)
+
+// DeviceInfo is the content of the glove's device info characteristic.
+type DeviceInfo struct {
+	Hand     Hand
+	Firmware string // e.g. "1.0.0", "" for firmware that only reports the hand
+	Hardware string // e.g. "rev1"
+}
+
+// deviceInfoSize is the length of the full device info value:
+// [hand_id, fw_major, fw_minor, fw_patch, hw_rev].
+const deviceInfoSize = 5
+
+// ParseDeviceInfo decodes a device info value. Older firmware sends only the
+// hand ID byte.
+func ParseDeviceInfo(data []byte) (DeviceInfo, error) {
+	if len(data) < 1 {
+		return DeviceInfo{}, fmt.Errorf("device info empty")
+	}
+	info := DeviceInfo{Hand: Hand(data[0])}
+	if len(data) >= deviceInfoSize {
+		info.Firmware = fmt.Sprintf("%d.%d.%d", data[1], data[2], data[3])
+		info.Hardware = fmt.Sprintf("rev%d", data[4])
+	}
+	return info, nil
+}
+
+// readGloveInfo reads the device info characteristic, looks up the command
+// characteristic and subscribes to the battery characteristic of a newly
+// connected glove. All are optional: failures are logged and the glove keeps
+// streaming sensor data.
+func (c *Central) readGloveInfo(glove *GloveConnection) {
+	devices := c.deviceConfig()
+	find := func(name, charUUID string) (Characteristic, error) {
+		if charUUID == "" {
+			err := fmt.Errorf("no UUID configured")
+			glove.discovered(name, charUUID, err)
+			return nil, err
+		}
+		char, err := c.transport.Characteristic(glove.Device, glove.Adapter, glove.Address, devices.ServiceUUID, charUUID)
+		glove.discovered(name, charUUID, err)
+		return char, err
+	}
+
+	if char, err := find("command", devices.CommandCharUUID); err == nil {
+		glove.CommandChar = char
+	} else {
+		log.Printf("BLE: No command characteristic on %s (feedback disabled)", glove.Name)
+	}
+
+	if char, err := find("device_info", devices.DeviceCharUUID); err != nil {
+		log.Printf("BLE: No device info on %s: %v", glove.Name, err)
+	} else if data, err := char.ReadValue(); err != nil {
+		glove.discoveryFailed(fmt.Errorf("read: %w", err))
+		log.Printf("BLE: Failed to read device info from %s: %v", glove.Name, err)
+	} else if info, err := ParseDeviceInfo(data); err != nil {
+		glove.discoveryFailed(err)
+		log.Printf("BLE: Invalid device info from %s: %v", glove.Name, err)
+	} else {
+		if info.Hand != glove.Hand {
+			log.Printf("BLE: %s reports hand %s, expected %s", glove.Name, info.Hand, glove.Hand)
+		}
+		glove.FirmwareVersion = info.Firmware
+		glove.HardwareRev = info.Hardware
+		log.Printf("BLE: %s firmware %q hardware %q", glove.Name, info.Firmware, info.Hardware)
+	}
+
+	char, err := find("battery", devices.BatteryCharUUID)
+	if err != nil {
+		log.Printf("BLE: No battery characteristic on %s: %v", glove.Name, err)
+		return
+	}
+	if data, err := char.ReadValue(); err == nil && len(data) > 0 {
+		glove.Battery = data[0]
+		// Not yet shared, so no lock; the level is reported on connect
+		glove.HasBattery = true
+	}
+	_, err = char.Subscribe(func(data []byte) {
+		if len(data) == 0 {
+			return
+		}
+		c.mu.Lock()
+		glove.Battery = data[0]
+		glove.HasBattery = true
+		c.mu.Unlock()
+		c.emit(batteryEvent(glove, data[0]))
+	})
+	if err != nil {
+		glove.discoveryFailed(fmt.Errorf("subscribe: %w", err))
+		c.record(LogEntry{Kind: LogNotifyFailed, Hand: glove.Hand.String(), Name: glove.Name, Address: glove.Address.String(),
+			Adapter: glove.Adapter, Detail: "battery", Error: err.Error()})
+		log.Printf("BLE: Battery notifications failed on %s: %v", glove.Name, err)
+		return
+	}
+	glove.BatteryChar = char
+}
@@ -0,0 +1,141 @@
+package ble
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DeviceConfig selects which advertised names are taken as gloves and which
+// GATT UUIDs their data is read from. The defaults match the FighterLink
+// firmware; renamed or third-party IMU firmware can be connected by changing
+// them.
+type DeviceConfig struct {
+	// Name patterns per hand, in path.Match syntax (e.g. "FighterLink_L",
+	// "IMU-L*"). Left patterns are tried first.
+	LeftNames  []string
+	RightNames []string
+
+	// UUIDs in the big-endian form BlueZ reports. 16-bit short forms such
+	// as "1234" are expanded to the Bluetooth base UUID.
+	ServiceUUID     string
+	SensorCharUUID  string
+	BatteryCharUUID string
+	DeviceCharUUID  string
+	CommandCharUUID string
+}
+
+// bluetoothBaseUUID is the suffix of every 16-bit Bluetooth SIG UUID.
+const bluetoothBaseUUID = "-0000-1000-8000-00805f9b34fb"
+
+// DefaultDeviceConfig returns the names and UUIDs of the FighterLink firmware.
+func DefaultDeviceConfig() DeviceConfig {
+	return DeviceConfig{
+		LeftNames:       []string{LeftDeviceName},
+		RightNames:      []string{RightDeviceName},
+		ServiceUUID:     serviceUUIDStr,
+		SensorCharUUID:  sensorCharUUIDStr,
+		BatteryCharUUID: batteryCharUUIDStr,
+		DeviceCharUUID:  deviceCharUUIDStr,
+		CommandCharUUID: commandCharUUIDStr,
+	}
+}
+
+// Normalize expands short UUIDs and lower-cases all UUIDs.
+func (d DeviceConfig) Normalize() (DeviceConfig, error) {
+	var err error
+	for _, u := range []*string{&d.ServiceUUID, &d.SensorCharUUID, &d.BatteryCharUUID, &d.DeviceCharUUID, &d.CommandCharUUID} {
+		if *u, err = NormalizeUUID(*u); err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+// Validate checks that both hands have usable name patterns and that the
+// service and sensor characteristic UUIDs are set.
+func (d DeviceConfig) Validate() error {
+	if len(d.LeftNames) == 0 || len(d.RightNames) == 0 {
+		return fmt.Errorf("both hands need at least one device name")
+	}
+	for _, pattern := range append(append([]string{}, d.LeftNames...), d.RightNames...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid device name pattern %q", pattern)
+		}
+	}
+	if d.ServiceUUID == "" || d.SensorCharUUID == "" {
+		return fmt.Errorf("service and sensor characteristic UUIDs are required")
+	}
+	_, err := d.Normalize()
+	return err
+}
+
+// HandFor returns the hand whose name patterns match an advertised name.
+func (d DeviceConfig) HandFor(name string) (Hand, bool) {
+	if name == "" {
+		return LeftHand, false
+	}
+	if matchAny(d.LeftNames, name) {
+		return LeftHand, true
+	}
+	if matchAny(d.RightNames, name) {
+		return RightHand, true
+	}
+	return LeftHand, false
+}
+
+// matchAny reports whether name matches any of the patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeUUID returns a UUID in lower-case 128-bit form. An empty UUID
+// stays empty (the characteristic is treated as absent).
+// e.g. "1234" → "00001234-0000-1000-8000-00805f9b34fb"
+func NormalizeUUID(u string) (string, error) {
+	u = strings.ToLower(strings.TrimSpace(u))
+	switch len(u) {
+	case 0:
+		return "", nil
+	case 4:
+		u = "0000" + u + bluetoothBaseUUID
+	case 8:
+		u = u + bluetoothBaseUUID
+	}
+	if len(u) != 36 || u[8] != '-' || u[13] != '-' || u[18] != '-' || u[23] != '-' {
+		return "", fmt.Errorf("invalid UUID %q", u)
+	}
+	for i, r := range u {
+		if i == 8 || i == 13 || i == 18 || i == 23 {
+			continue
+		}
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return "", fmt.Errorf("invalid UUID %q", u)
+		}
+	}
+	return u, nil
+}
+
+// SetDeviceConfig sets the device names and UUIDs used for new connections.
+func (c *Central) SetDeviceConfig(d DeviceConfig) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	d, _ = d.Normalize()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.devices = d
+	return nil
+}
+
+// deviceConfig returns the current device names and UUIDs.
+func (c *Central) deviceConfig() DeviceConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.devices
+}
@@ -0,0 +1,60 @@
+package ble
+
+import (
+	"context"
+	"errors"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// ErrScanInProgress is returned by ScanDevices while the Central is
+// scanning for gloves itself.
+var ErrScanInProgress = errors.New("scan already in progress")
+
+// Advertisement is a device seen by ScanDevices.
+type Advertisement struct {
+	Name    string
+	Address bluetooth.Address
+	RSSI    int16
+	Glove   bool // Name matches the device config's glove patterns
+	Hand    Hand // Hand the name matches (valid if Glove)
+
+	HeartRate bool // Advertises the heart-rate service
+}
+
+// ScanDevices reports every advertisement the adapter sees until ctx is
+// cancelled, without connecting anything. It is meant for listing nearby
+// gloves, so it fails with ErrScanInProgress while StartScanning runs.
+// Call Enable first.
+func (c *Central) ScanDevices(ctx context.Context, found func(Advertisement)) error {
+	if !c.scan.TryLock() {
+		return ErrScanInProgress
+	}
+	defer c.scan.Unlock()
+	devices := c.deviceConfig()
+
+	scanDone := make(chan struct{})
+	defer close(scanDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.transport.StopScan()
+		case <-scanDone:
+		}
+	}()
+
+	return c.transport.Scan(func(result bluetooth.ScanResult) {
+		if ctx.Err() != nil {
+			c.transport.StopScan() // Cancelled before the scan started
+			return
+		}
+		ad := Advertisement{
+			Name:      result.LocalName(),
+			Address:   result.Address,
+			RSSI:      result.RSSI,
+			HeartRate: result.HasServiceUUID(bluetooth.ServiceUUIDHeartRate),
+		}
+		ad.Hand, ad.Glove = devices.HandFor(ad.Name)
+		found(ad)
+	})
+}
@@ -0,0 +1,175 @@
+package ble
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// CharDiscovery is the outcome of looking up one GATT characteristic of a
+// glove as it connected.
+type CharDiscovery struct {
+	Name  string `json:"name"` // sensor, command, device_info or battery
+	UUID  string `json:"uuid"`
+	Found bool   `json:"found"`
+	Error string `json:"error,omitempty"` // why it was not found, or failed once found
+}
+
+// discovered records a characteristic lookup. Only called before the glove
+// connection is shared.
+func (g *GloveConnection) discovered(name, uuid string, err error) {
+	d := CharDiscovery{Name: name, UUID: uuid, Found: err == nil}
+	if err != nil {
+		d.Error = err.Error()
+	}
+	g.Discovery = append(g.Discovery, d)
+}
+
+// discoveryFailed records that the last characteristic found could not be
+// used after all, e.g. its value did not parse.
+func (g *GloveConnection) discoveryFailed(err error) {
+	if n := len(g.Discovery); n > 0 {
+		g.Discovery[n-1].Error = err.Error()
+	}
+}
+
+// GloveDiagnostics describes the link to one hand's glove.
+type GloveDiagnostics struct {
+	Hand    string       `json:"hand"`
+	State   string       `json:"state"`
+	Adapter string       `json:"adapter"`
+	Paired  *PairedGlove `json:"paired,omitempty"` // remembered glove, nil = accepts any
+
+	// Connected gloves only
+	Name             string          `json:"name,omitempty"`
+	Address          string          `json:"address,omitempty"`
+	ConnectedAt      *time.Time      `json:"connected_at,omitempty"`
+	DiscoveryMS      int64           `json:"discovery_ms,omitempty"`
+	Discovery        []CharDiscovery `json:"discovery,omitempty"`
+	MTU              uint16          `json:"mtu,omitempty"`
+	FirmwareVersion  string          `json:"firmware_version,omitempty"`
+	HardwareRev      string          `json:"hardware_rev,omitempty"`
+	PacketLoss       float64         `json:"packet_loss"`
+	CorruptPackets   int             `json:"corrupt_packets"`
+	LastPacketMsAgo  int64           `json:"last_packet_ms_ago,omitempty"`
+	LastSeq          uint16          `json:"last_seq,omitempty"`
+	BatteryNotifying bool            `json:"battery_notifying,omitempty"`
+}
+
+// Diagnostics is a snapshot of a central for troubleshooting: what it looks
+// for, what GATT discovery found on each glove and how the links are doing.
+type Diagnostics struct {
+	Scanning   bool               `json:"scanning"`
+	Bonding    bool               `json:"bonding"`
+	ConnParams map[string]string  `json:"conn_params"`
+	Devices    map[string]string  `json:"devices"` // service and characteristic UUIDs
+	LeftNames  []string           `json:"left_names"`
+	RightNames []string           `json:"right_names"`
+	Gloves     []GloveDiagnostics `json:"gloves"`
+	HeartRate  *GloveDiagnostics  `json:"heart_rate,omitempty"` // connected strap
+}
+
+// Diagnostics returns the central's current diagnostics.
+func (c *Central) Diagnostics() Diagnostics {
+	devices := c.deviceConfig()
+	pairing := c.Pairing()
+	scanning := c.IsScanning()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	d := Diagnostics{
+		Scanning: scanning,
+		Bonding:  c.bonding,
+		ConnParams: map[string]string{
+			"min_interval":        c.connParams.MinInterval.String(),
+			"max_interval":        c.connParams.MaxInterval.String(),
+			"latency":             fmt.Sprint(c.connParams.Latency),
+			"supervision_timeout": c.connParams.SupervisionTimeout.String(),
+		},
+		Devices: map[string]string{
+			"service":     devices.ServiceUUID,
+			"sensor":      devices.SensorCharUUID,
+			"battery":     devices.BatteryCharUUID,
+			"device_info": devices.DeviceCharUUID,
+			"command":     devices.CommandCharUUID,
+		},
+		LeftNames:  devices.LeftNames,
+		RightNames: devices.RightNames,
+	}
+	now := time.Now()
+	for _, hand := range []Hand{LeftHand, RightHand} {
+		g := GloveDiagnostics{
+			Hand:    hand.String(),
+			State:   c.links[hand].String(),
+			Adapter: c.adapterForLocked(hand),
+		}
+		if pairing != nil {
+			if paired, ok := pairing.Get(hand); ok {
+				g.Paired = &paired
+			}
+		}
+		if glove := c.connectedGloveLocked(hand); glove != nil {
+			connectedAt := glove.ConnectedAt
+			g.Name = glove.Name
+			g.Address = glove.Address.String()
+			g.Adapter = glove.Adapter
+			g.ConnectedAt = &connectedAt
+			g.DiscoveryMS = glove.DiscoveryMS
+			g.Discovery = glove.Discovery
+			g.MTU = glove.MTU
+			g.FirmwareVersion = glove.FirmwareVersion
+			g.HardwareRev = glove.HardwareRev
+			g.PacketLoss = glove.PacketLoss
+			g.CorruptPackets = glove.CorruptPackets
+			g.LastPacketMsAgo = now.Sub(glove.LastPacketTime).Milliseconds()
+			g.LastSeq = glove.LastSeq
+			g.BatteryNotifying = glove.BatteryChar != nil
+		}
+		d.Gloves = append(d.Gloves, g)
+	}
+	if strap := c.hrStrap; strap != nil && strap.Connected {
+		d.HeartRate = &GloveDiagnostics{
+			Hand:            "heart_rate",
+			State:           StateConnected.String(),
+			Name:            strap.Name,
+			Address:         strap.Address.String(),
+			LastPacketMsAgo: now.Sub(strap.LastPacketTime).Milliseconds(),
+		}
+	}
+	return d
+}
+
+// ForgetGlove disconnects a hand's glove, forgets its pairing and drops all
+// the Bluetooth stack cached for it, bonding keys included, so the next
+// matching glove found is set up from scratch. Unlike Unbond it works on a
+// glove that is not connected, which then keeps its own bond.
+func (c *Central) ForgetGlove(hand Hand) error {
+	var adapterID string
+	var addr string
+	if glove := c.GetGlove(hand); glove != nil {
+		adapterID, addr = glove.Adapter, glove.Address.String()
+	} else {
+		adapterID = c.adapterFor(hand)
+	}
+	if err := c.Disconnect(hand); err != nil {
+		log.Printf("BLE: Failed to disconnect %s glove: %v", hand, err)
+	}
+
+	if store := c.Pairing(); store != nil {
+		if paired, ok := store.Get(hand); ok && addr == "" {
+			addr = paired.Address
+		}
+		if err := store.Forget(hand); err != nil {
+			return err
+		}
+	}
+	if addr != "" {
+		parsed, err := c.transport.ParseAddress(addr)
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+		c.transport.Forget(adapterID, parsed, true)
+	}
+	log.Printf("BLE: Forgot %s glove %s", hand, addr)
+	return nil
+}
@@ -0,0 +1,112 @@
+package ble
+
+import (
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// EventType identifies a connection lifecycle event.
+type EventType int
+
+const (
+	EventDeviceDiscovered   EventType = iota // a glove was found by a scan
+	EventConnected                           // a glove is connected and streaming
+	EventDisconnected                        // a glove's link was lost or closed
+	EventBattery                             // the battery characteristic reported a level
+	EventCalibrationChanged                  // new sensor offsets were stored
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventDeviceDiscovered:
+		return "discovered"
+	case EventConnected:
+		return "connected"
+	case EventDisconnected:
+		return "disconnected"
+	case EventBattery:
+		return "battery"
+	case EventCalibrationChanged:
+		return "calibration_changed"
+	}
+	return "unknown"
+}
+
+// eventBuffer is how many events a slow subscriber may fall behind before
+// further events are dropped for it.
+const eventBuffer = 32
+
+// Event is a connection lifecycle event for one glove.
+type Event struct {
+	Type    EventType
+	Hand    Hand
+	Name    string
+	Address bluetooth.Address
+	Time    time.Time
+
+	Battery uint8    // EventBattery
+	Offsets *Offsets // EventCalibrationChanged
+	Glove   *GloveConnection
+}
+
+// eventBus fans events out to subscribers without blocking the BLE code.
+type eventBus struct {
+	mu   sync.Mutex
+	subs []chan Event
+}
+
+// Events subscribes to lifecycle events. Each call returns a new channel,
+// and a cancel func that unsubscribes and closes it; a subscriber that falls
+// more than eventBuffer events behind misses events rather than stalling the
+// BLE stack.
+func (c *Central) Events() (<-chan Event, func()) {
+	ch := make(chan Event, eventBuffer)
+	c.events.mu.Lock()
+	c.events.subs = append(c.events.subs, ch)
+	c.events.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			c.events.mu.Lock()
+			defer c.events.mu.Unlock()
+			for i, sub := range c.events.subs {
+				if sub == ch {
+					c.events.subs = append(c.events.subs[:i], c.events.subs[i+1:]...)
+					break
+				}
+			}
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// emit sends an event to every subscriber.
+func (c *Central) emit(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	c.events.mu.Lock()
+	defer c.events.mu.Unlock()
+	for _, ch := range c.events.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// batteryEvent builds an EventBattery for a glove.
+func batteryEvent(glove *GloveConnection, level uint8) Event {
+	ev := gloveEvent(EventBattery, glove)
+	ev.Battery = level
+	return ev
+}
+
+// gloveEvent builds an event for a glove connection.
+func gloveEvent(t EventType, glove *GloveConnection) Event {
+	return Event{Type: t, Hand: glove.Hand, Name: glove.Name, Address: glove.Address, Glove: glove}
+}
@@ -0,0 +1,9 @@
+package ble
+
+// CanTransition exposes the link state machine to the external tests, which
+// use bletest and so cannot live in package ble.
+var CanTransition = canTransition
+
+// TimeOutHeartRate does what the connection monitor does once the strap
+// has been quiet for HeartRateTimeout.
+var TimeOutHeartRate = (*Central).markHeartRateDisconnected
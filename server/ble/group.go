@@ -0,0 +1,106 @@
+package ble
+
+import "tinygo.org/x/bluetooth"
+
+// ShareScan makes c's scans also look for peer's gloves. An adapter runs one
+// scan at a time, so when several Centrals share it (one per athlete in
+// sparring mode) the first runs the scans for all of them: peer.StartScanning
+// starts c's scan, and gloves found for peer are connected through peer.
+// Call it before either Central starts scanning.
+func (c *Central) ShareScan(peer *Central) {
+	c.mu.Lock()
+	c.peers = append(c.peers, peer)
+	c.mu.Unlock()
+
+	peer.mu.Lock()
+	peer.leader = c
+	peer.mu.Unlock()
+}
+
+// scanLeader returns the Central whose scans cover c, or nil if c scans for
+// itself.
+func (c *Central) scanLeader() *Central {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.leader
+}
+
+// scanGroup returns c followed by the Centrals sharing its scans.
+func (c *Central) scanGroup() []*Central {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]*Central{c}, c.peers...)
+}
+
+// foundGlove is a glove seen by a scan, claimed for a Central and hand.
+type foundGlove struct {
+	central *Central
+	hand    Hand
+	result  bluetooth.ScanResult
+}
+
+// claimGlove finds the Central in the group a scanned device is a glove for
+// and moves that hand to connecting. It returns false if the device is not a
+// glove, or the hand is not waiting for one.
+func claimGlove(group []*Central, result bluetooth.ScanResult) (foundGlove, bool) {
+	name := result.LocalName()
+	for _, c := range group {
+		hand, isGlove := c.deviceConfig().HandFor(name)
+		if !isGlove {
+			continue
+		}
+		if paired, ok := c.pairedHand(result.Address); ok {
+			hand = paired
+		}
+		if !c.acceptsAddress(hand, result.Address) {
+			continue // Same name, but not the glove paired to this hand
+		}
+		if c.transition(hand, StateConnecting) != nil {
+			continue // Already found, connecting or connected
+		}
+		return foundGlove{central: c, hand: hand, result: result}, true
+	}
+	return foundGlove{}, false
+}
+
+// isGloveName reports whether a device name matches a glove of any Central
+// in the group.
+func isGloveName(group []*Central, name string) bool {
+	for _, c := range group {
+		if _, ok := c.deviceConfig().HandFor(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// groupScanning reports whether any hand in the group is still waiting for
+// the scan to find its glove.
+func groupScanning(group []*Central) bool {
+	for _, c := range group {
+		for _, hand := range []Hand{LeftHand, RightHand} {
+			if c.LinkState(hand) == StateScanning {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setScanning moves every idle hand of the group to scanning, or every
+// scanning hand back to idle.
+func setScanning(group []*Central, scanning bool) {
+	from, to := StateIdle, StateScanning
+	if !scanning {
+		from, to = StateScanning, StateIdle
+	}
+	for _, c := range group {
+		c.mu.Lock()
+		for _, hand := range []Hand{LeftHand, RightHand} {
+			if c.links[hand] == from {
+				_ = c.transitionLocked(hand, to)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,243 @@
+package ble
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Standard Heart Rate Service (0x180D) and Heart Rate Measurement
+// characteristic (0x2A37), in the big-endian form BlueZ reports.
+const (
+	heartRateServiceUUIDStr = "0000180d-0000-1000-8000-00805f9b34fb"
+	heartRateCharUUIDStr    = "00002a37-0000-1000-8000-00805f9b34fb"
+)
+
+// HeartRateTimeout is how long a strap may go without a measurement before
+// it is considered disconnected. Straps notify about once per second.
+const HeartRateTimeout = 5 * time.Second
+
+// Heart Rate Measurement flags
+const (
+	hrFlagUint16 = 0x01 // BPM is a uint16 rather than a uint8
+)
+
+// HeartRateConnection represents a connected heart-rate strap.
+type HeartRateConnection struct {
+	Name           string
+	Device         Link
+	Address        bluetooth.Address
+	MeasurementChr Characteristic
+	Connected      bool
+	BPM            int
+	LastPacketTime time.Time
+}
+
+// HeartRateHandler is called with each BPM reading, and with 0 when the
+// strap disconnects.
+type HeartRateHandler func(bpm int)
+
+// SetHeartRateHandler sets the callback for heart-rate readings. Setting a
+// handler also makes the scanner look for a heart-rate strap.
+func (c *Central) SetHeartRateHandler(handler HeartRateHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onHeartRate = handler
+}
+
+// GetHeartRateStrap returns the heart-rate strap connection, if any.
+func (c *Central) GetHeartRateStrap() *HeartRateConnection {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hrStrap
+}
+
+// NeedsHeartRate returns true if heart rate is wanted but no strap is connected.
+func (c *Central) NeedsHeartRate() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.onHeartRate != nil && (c.hrStrap == nil || !c.hrStrap.Connected)
+}
+
+// ParseHeartRate extracts the BPM from a Heart Rate Measurement value.
+func ParseHeartRate(data []byte) (int, error) {
+	if len(data) < 2 {
+		return 0, fmt.Errorf("heart rate measurement too short: %d bytes", len(data))
+	}
+	if data[0]&hrFlagUint16 != 0 {
+		if len(data) < 3 {
+			return 0, fmt.Errorf("heart rate measurement too short: %d bytes", len(data))
+		}
+		return int(binary.LittleEndian.Uint16(data[1:3])), nil
+	}
+	return int(data[1]), nil
+}
+
+// handleHeartRate processes incoming Heart Rate Measurement notifications
+// from strap. Late ones, after the strap was marked disconnected, are
+// dropped so they cannot bring a stale reading back.
+func (c *Central) handleHeartRate(strap *HeartRateConnection, data []byte) {
+	bpm, err := ParseHeartRate(data)
+	if err != nil {
+		log.Printf("BLE: Failed to parse heart rate: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	if c.hrStrap != strap || !strap.Connected {
+		c.mu.Unlock()
+		return
+	}
+	strap.BPM = bpm
+	strap.LastPacketTime = time.Now()
+	handler := c.onHeartRate
+	c.mu.Unlock()
+
+	if handler != nil {
+		handler(bpm)
+	}
+}
+
+// connectHeartRate establishes a connection to a discovered heart-rate strap.
+func (c *Central) connectHeartRate(ctx context.Context, result bluetooth.ScanResult) error {
+	name := result.LocalName()
+	log.Printf("BLE: Connecting to heart-rate strap %s (%s)...", name, result.Address.String())
+
+	c.transport.Forget(DefaultAdapterID, result.Address, false)
+	time.Sleep(300 * time.Millisecond)
+
+	entry := LogEntry{Name: name, Address: result.Address.String(), Adapter: DefaultAdapterID}
+	entry.Kind = LogConnecting
+	c.record(entry)
+	start := time.Now()
+	fail := func(err error) error {
+		entry.Kind = LogConnectFailed
+		entry.MS = time.Since(start).Milliseconds()
+		entry.Detail = ""
+		entry.Error = err.Error()
+		c.record(entry)
+		return err
+	}
+
+	device, err := c.transport.Connect(ctx, DefaultAdapterID, result.Address, ConnParams{})
+	if err != nil {
+		return fail(fmt.Errorf("failed to connect to %s: %w", name, err))
+	}
+	entry.Kind = LogServicesResolved
+	entry.MS = time.Since(start).Milliseconds()
+	c.record(entry)
+	char, err := c.transport.Characteristic(device, DefaultAdapterID, result.Address, heartRateServiceUUIDStr, heartRateCharUUIDStr)
+	if err != nil {
+		device.Disconnect()
+		return fail(fmt.Errorf("GATT discovery failed on %s: %w", name, err))
+	}
+
+	strap := &HeartRateConnection{
+		Name:           name,
+		Device:         device,
+		Address:        result.Address,
+		MeasurementChr: char,
+		Connected:      true,
+		LastPacketTime: time.Now(),
+	}
+	notifyDone, err := char.Subscribe(func(data []byte) { c.handleHeartRate(strap, data) })
+	if err != nil {
+		entry.Kind = LogNotifyFailed
+		entry.Detail = "heart_rate"
+		entry.Error = err.Error()
+		c.record(entry)
+		device.Disconnect()
+		return fail(fmt.Errorf("subscribe to %s: %w", name, err))
+	}
+	c.mu.Lock()
+	c.hrStrap = strap
+	c.mu.Unlock()
+
+	go func() {
+		<-notifyDone
+		log.Printf("BLE: Notification stream closed for %s - strap may have disconnected", name)
+		c.markStrapLost(strap)
+	}()
+
+	// Start watching for strap disconnection
+	go c.transport.Watch(DefaultAdapterID, result.Address, name,
+		func() bool { return c.isCurrentStrap(strap) },
+		func() { c.markStrapLost(strap) })
+
+	entry.Kind = LogConnected
+	entry.MS = 0
+	c.record(entry)
+	log.Printf("BLE: Connection established with heart-rate strap %s", name)
+	return nil
+}
+
+// markHeartRateDisconnected marks the strap as disconnected, closes its link
+// (a strap that merely went quiet may still hold it) and reports a BPM of 0
+// so stale readings are not shown. The strap stays recorded, so the scanner
+// keeps looking for it.
+func (c *Central) markHeartRateDisconnected() {
+	c.mu.Lock()
+	strap := c.hrStrap
+	if strap == nil || !strap.Connected {
+		c.mu.Unlock()
+		return
+	}
+	strap.Connected = false
+	strap.BPM = 0
+	handler := c.onHeartRate
+	c.mu.Unlock()
+
+	log.Printf("BLE: Connection lost with heart-rate strap %s", strap.Name)
+	c.record(LogEntry{Kind: LogDisconnected, Name: strap.Name, Address: strap.Address.String(), Adapter: DefaultAdapterID, Detail: "lost"})
+	// The watch ends on its own now that the strap is no longer current
+	closeStrap(strap)
+	c.transport.Forget(DefaultAdapterID, strap.Address, false)
+
+	if handler != nil {
+		handler(0)
+	}
+}
+
+// isCurrentStrap reports whether strap is still the connected strap.
+func (c *Central) isCurrentStrap(strap *HeartRateConnection) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hrStrap == strap && strap.Connected
+}
+
+// markStrapLost marks the strap disconnected if it is still the current one.
+func (c *Central) markStrapLost(strap *HeartRateConnection) {
+	if c.isCurrentStrap(strap) {
+		c.markHeartRateDisconnected()
+	}
+}
+
+// disconnectHeartRate disconnects from the heart-rate strap.
+func (c *Central) disconnectHeartRate() {
+	c.mu.Lock()
+	strap := c.hrStrap
+	c.hrStrap = nil
+	if strap == nil || !strap.Connected {
+		c.mu.Unlock()
+		return
+	}
+	strap.Connected = false
+	c.mu.Unlock()
+
+	closeStrap(strap)
+	go c.transport.Forget(DefaultAdapterID, strap.Address, false)
+}
+
+// closeStrap ends a strap's notifications and closes its link.
+func closeStrap(strap *HeartRateConnection) {
+	if strap.MeasurementChr != nil {
+		_ = strap.MeasurementChr.Unsubscribe()
+	}
+	if err := strap.Device.Disconnect(); err != nil {
+		log.Printf("BLE: Failed to disconnect heart-rate strap: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package ble_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"boxing-analytics/ble"
+)
+
+func TestHeartRateTimeoutClosesStrap(t *testing.T) {
+	c, transport := newCentral(t)
+	strap := transport.AddHeartRateStrap("Polar H10")
+
+	var (
+		mu       sync.Mutex
+		readings []int
+	)
+	c.SetHeartRateHandler(func(bpm int) {
+		mu.Lock()
+		defer mu.Unlock()
+		readings = append(readings, bpm)
+	})
+	last := func() (int, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(readings) == 0 {
+			return 0, 0
+		}
+		return readings[len(readings)-1], len(readings)
+	}
+
+	if err := c.StartScanning(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "strap connected", func() bool { return !c.NeedsHeartRate() })
+	if !strap.NotifyHeartRate(142) {
+		t.Fatal("strap measurements not subscribed")
+	}
+	waitFor(t, "first reading", func() bool { bpm, _ := last(); return bpm == 142 })
+
+	// Gone quiet: the link is closed, not just marked, and 0 is reported
+	strap.SetAdvertising(false)
+	ble.TimeOutHeartRate(c)
+	if strap.Connected() {
+		t.Error("strap link still open after the timeout")
+	}
+	if bpm, _ := last(); bpm != 0 {
+		t.Errorf("last reading %d after the timeout, want 0", bpm)
+	}
+	if !c.NeedsHeartRate() {
+		t.Error("timed out strap still counted as connected")
+	}
+
+	// Nothing left to deliver late readings
+	_, n := last()
+	strap.NotifyHeartRate(150)
+	if _, after := last(); after != n {
+		t.Errorf("%d readings delivered after the timeout", after-n)
+	}
+}
@@ -0,0 +1,130 @@
+package ble
+
+import "math"
+
+// lossWindow is how many recent sequence numbers packet loss is measured
+// over: 5 seconds at 100Hz.
+const lossWindow = 500
+
+// lossResetGap is the largest sequence jump counted as loss. A bigger jump,
+// forward or back, means the glove restarted its counter (e.g. after deep
+// sleep) and tracking starts over.
+const lossResetGap = 1000
+
+// lossTracker measures packet loss from sequence numbers over a rolling
+// window, handling uint16 wraparound.
+type lossTracker struct {
+	missed  [lossWindow]bool // One slot per expected sequence number
+	next    int              // Slot the next sequence number goes in
+	filled  int              // Slots in use, up to lossWindow
+	lost    int              // Missed slots in the window
+	lastSeq uint16
+	started bool
+}
+
+// observe records a received sequence number. It returns how many samples
+// were skipped just before it, and false if the sample is a duplicate or
+// arrived late and should not count.
+func (t *lossTracker) observe(seq uint16) (gap int, ok bool) {
+	if !t.started {
+		t.started = true
+		t.lastSeq = seq
+		t.push(false)
+		return 0, true
+	}
+
+	delta := int(seq - t.lastSeq) // Wraps, so 65535 → 0 is a delta of 1
+	switch {
+	case delta == 0:
+		return 0, false
+	case delta > math.MaxUint16-lossResetGap:
+		return 0, false // Behind the last sequence number
+	case delta > lossResetGap:
+		t.reset()
+		return t.observe(seq)
+	}
+
+	gap = delta - 1
+	for i := 0; i < min(gap, lossWindow); i++ {
+		t.push(true)
+	}
+	t.push(false)
+	t.lastSeq = seq
+	return gap, true
+}
+
+// push adds one slot to the window, evicting the oldest when full.
+func (t *lossTracker) push(missed bool) {
+	if t.filled == lossWindow {
+		if t.missed[t.next] {
+			t.lost--
+		}
+	} else {
+		t.filled++
+	}
+	t.missed[t.next] = missed
+	if missed {
+		t.lost++
+	}
+	t.next = (t.next + 1) % lossWindow
+}
+
+// reset clears the window.
+func (t *lossTracker) reset() {
+	*t = lossTracker{}
+}
+
+// percent returns the share of samples lost over the window, 0-100.
+func (t *lossTracker) percent() float64 {
+	if t.filled == 0 {
+		return 0
+	}
+	return float64(t.lost) / float64(t.filled) * 100
+}
+
+// interpolateSamples synthesizes the gap samples missing between prev and
+// next by linear interpolation, so a few dropped notifications don't cut a
+// punch's detection window short. Quaternions are blended component-wise,
+// which is close enough over the few samples a short gap spans.
+func interpolateSamples(prev, next *SensorPacket, gap int) []*SensorPacket {
+	// Blend towards whichever of q and -q is nearer so the rotation takes
+	// the short way round.
+	nextQuat := next.Quat
+	var dot int
+	for i := range prev.Quat {
+		dot += int(prev.Quat[i]) * int(nextQuat[i])
+	}
+	if dot < 0 {
+		for i := range nextQuat {
+			nextQuat[i] = -nextQuat[i]
+		}
+	}
+
+	fills := make([]*SensorPacket, gap)
+	for i := range fills {
+		f := float64(i+1) / float64(gap+1)
+		p := *next
+		p.AccX = lerp16(prev.AccX, next.AccX, f)
+		p.AccY = lerp16(prev.AccY, next.AccY, f)
+		p.AccZ = lerp16(prev.AccZ, next.AccZ, f)
+		p.GyroX = lerp16(prev.GyroX, next.GyroX, f)
+		p.GyroY = lerp16(prev.GyroY, next.GyroY, f)
+		p.GyroZ = lerp16(prev.GyroZ, next.GyroZ, f)
+		for k := range p.Quat {
+			p.Quat[k] = lerp16(prev.Quat[k], nextQuat[k], f)
+		}
+		for k := range p.LinAccel {
+			p.LinAccel[k] = lerp16(prev.LinAccel[k], next.LinAccel[k], f)
+		}
+		p.Timestamp = prev.Timestamp + uint32(math.Round(float64(next.Timestamp-prev.Timestamp)*f))
+		p.Sequence = prev.Sequence + uint16(i+1)
+		p.Interpolated = true
+		fills[i] = &p
+	}
+	return fills
+}
+
+// lerp16 blends two raw sensor values.
+func lerp16(a, b int16, f float64) int16 {
+	return int16(math.Round(float64(a) + (float64(b)-float64(a))*f))
+}
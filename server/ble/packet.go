@@ -1,4 +1,3 @@
-// Package ble provides BLE Central functionality for FighterLink.
 package ble
 
 import (
@@ -7,9 +6,39 @@ import (
 	"fmt"
 )
 
-// PacketSize is the expected size of a sensor packet in bytes.
+// PacketSize is the size of a v1 sensor packet in bytes.
 const PacketSize = 20
 
+// Packet format versions. v1 packets carry no version byte and are
+// recognised by their size; v2 and v3 packets start with their version.
+const (
+	PacketVersion1 uint8 = 1
+	PacketVersion2 uint8 = 2
+	PacketVersion3 uint8 = 3 // batched: several v2-layout samples per notification
+)
+
+// v2 layout: version, content flags, the v1 body, then optional fields in
+// content-flag order. Packets above 20 bytes need a negotiated ATT MTU.
+const (
+	v2HeaderSize     = 2
+	v2QuaternionSize = 8 // 4 × int16, ÷10000
+	v2LinearSize     = 6 // 3 × int16, ÷100 m/s²
+)
+
+// v3 layout: version, content flags, sample count, then that many samples,
+// each a v1 body followed by the optional fields the content flags select.
+const v3HeaderSize = 3
+
+// v2 content flags
+const (
+	ContentQuaternion  uint8 = 1 << 0 // Bit 0: on-board fusion quaternion present
+	ContentLinearAccel uint8 = 1 << 1 // Bit 1: gravity-free acceleration present
+	ContentCRC         uint8 = 1 << 2 // Bit 2: packet ends with a CRC16 over all preceding bytes, version and content byte included
+)
+
+// crcSize is the size of the CRC16 trailer.
+const crcSize = 2
+
 // SensorPacket represents the 20-byte binary packet from a FighterLink glove.
 // All multi-byte fields are little-endian.
 type SensorPacket struct {
@@ -23,6 +52,14 @@ type SensorPacket struct {
 	Sequence  uint16 // Packet sequence number
 	Battery   uint8  // Battery percentage (0-100)
 	Flags     uint8  // Status flags
+
+	// v2 fields
+	Version  uint8    // Packet format version
+	Content  uint8    // v2 content flags (which optional fields are set)
+	Quat     [4]int16 // Orientation quaternion w, x, y, z (raw, divide by 10000)
+	LinAccel [3]int16 // Linear acceleration X, Y, Z in the sensor frame (raw, divide by 100)
+
+	Interpolated bool // Synthesized by the server to fill a short sequence gap (see SetGapInterpolation)
 }
 
 // Flag bit positions
@@ -31,16 +68,237 @@ const (
 	FlagCalibrated uint8 = 1 << 1 // Bit 1: Calibration complete
 )
 
-// ErrInvalidPacketSize is returned when the packet data is not 20 bytes.
-var ErrInvalidPacketSize = errors.New("invalid packet size: expected 20 bytes")
+// ErrInvalidPacketSize is returned when the packet data does not match the
+// size its format requires.
+var ErrInvalidPacketSize = errors.New("invalid packet size")
+
+// ErrUnsupportedVersion is returned for packets of an unknown format version.
+var ErrUnsupportedVersion = errors.New("unsupported packet version")
+
+// ErrChecksum is returned when a packet's CRC does not match its content.
+var ErrChecksum = errors.New("packet checksum mismatch")
+
+// ErrMissingChecksum is returned by ChecksumGuard for a packet without a
+// CRC from a glove that has sent checksummed ones.
+var ErrMissingChecksum = errors.New("packet without checksum from a glove that sends them")
 
-// ParsePacket decodes a 20-byte binary packet into a SensorPacket struct.
+// ErrBatchedPacket is returned by ParsePacket for v3 notifications that
+// carry several samples; use ParsePackets for those.
+var ErrBatchedPacket = errors.New("batched packet holds multiple samples")
+
+// ParsePacket decodes a binary packet, dispatching on its format version.
 func ParsePacket(data []byte) (*SensorPacket, error) {
-	if len(data) != PacketSize {
-		return nil, fmt.Errorf("%w, got %d", ErrInvalidPacketSize, len(data))
+	if len(data) == PacketSize {
+		return parseV1(data), nil
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty packet", ErrInvalidPacketSize)
+	}
+	switch data[0] {
+	case PacketVersion2:
+		return parseV2(data)
+	case PacketVersion3:
+		packets, err := parseV3(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(packets) != 1 {
+			return nil, fmt.Errorf("%w (%d samples)", ErrBatchedPacket, len(packets))
+		}
+		return packets[0], nil
+	}
+	return nil, fmt.Errorf("%w %d (%d bytes)", ErrUnsupportedVersion, data[0], len(data))
+}
+
+// attHeaderSize is the ATT notification overhead within the MTU.
+const attHeaderSize = 3
+
+// MaxBatchSamples returns how many samples with the given content fit in one
+// v3 notification at the given ATT MTU.
+func MaxBatchSamples(mtu uint16, content uint8) int {
+	n := (int(mtu) - attHeaderSize - v3HeaderSize - trailerSize(content)) / sampleSize(content)
+	if n < 0 {
+		return 0
+	}
+	return min(n, 255)
+}
+
+// ParsePackets decodes a notification that may carry several samples,
+// returning them in the order they were taken.
+func ParsePackets(data []byte) ([]*SensorPacket, error) {
+	if len(data) > 0 && len(data) != PacketSize && data[0] == PacketVersion3 {
+		return parseV3(data)
+	}
+	p, err := ParsePacket(data)
+	if err != nil {
+		return nil, err
+	}
+	return []*SensorPacket{p}, nil
+}
+
+// MarshalBatch encodes up to 255 samples as one v3 packet with the content
+// of the first, the inverse of ParsePackets.
+func MarshalBatch(packets []*SensorPacket) []byte {
+	content := packets[0].Content
+	data := []byte{PacketVersion3, content, uint8(len(packets))}
+	for _, p := range packets {
+		sample := *p
+		sample.Version, sample.Content = PacketVersion2, content&^ContentCRC
+		data = append(data, sample.Marshal()[v2HeaderSize:]...)
+	}
+	if content&ContentCRC != 0 {
+		data = binary.LittleEndian.AppendUint16(data, CRC16(data))
+	}
+	return data
+}
+
+// sampleSize returns the size of one sample body with the given content.
+func sampleSize(content uint8) int {
+	n := PacketSize
+	if content&ContentQuaternion != 0 {
+		n += v2QuaternionSize
+	}
+	if content&ContentLinearAccel != 0 {
+		n += v2LinearSize
+	}
+	return n
+}
+
+// parseV3 decodes a batched packet. It reuses the v2 decoder for each
+// sample by prefixing the v2 header.
+func parseV3(data []byte) ([]*SensorPacket, error) {
+	if len(data) < v3HeaderSize {
+		return nil, fmt.Errorf("%w: v3 needs at least %d bytes, got %d", ErrInvalidPacketSize, v3HeaderSize, len(data))
+	}
+	content, count := data[1], int(data[2])
+	size := sampleSize(content)
+	if count == 0 || len(data) != v3HeaderSize+count*size+trailerSize(content) {
+		return nil, fmt.Errorf("%w: v3 with %d samples of %d bytes, got %d bytes",
+			ErrInvalidPacketSize, count, size, len(data))
+	}
+	if err := verifyCRC(data, content); err != nil {
+		return nil, err
+	}
+
+	packets := make([]*SensorPacket, 0, count)
+	sample := make([]byte, v2HeaderSize+size)
+	sample[0], sample[1] = PacketVersion2, content&^ContentCRC
+	for i := 0; i < count; i++ {
+		off := v3HeaderSize + i*size
+		copy(sample[v2HeaderSize:], data[off:off+size])
+		p, err := parseV2(sample)
+		if err != nil {
+			return nil, err
+		}
+		p.Version = PacketVersion3
+		p.Content = content
+		packets = append(packets, p)
 	}
+	return packets, nil
+}
+
+// parseV2 decodes a v2 packet: header, v1 body and optional fields.
+func parseV2(data []byte) (*SensorPacket, error) {
+	if len(data) < v2HeaderSize+PacketSize {
+		return nil, fmt.Errorf("%w: v2 needs at least %d bytes, got %d",
+			ErrInvalidPacketSize, v2HeaderSize+PacketSize, len(data))
+	}
+	content := data[1]
+	want := v2HeaderSize + sampleSize(content) + trailerSize(content)
+	if len(data) != want {
+		return nil, fmt.Errorf("%w: v2 content 0x%02x needs %d bytes, got %d",
+			ErrInvalidPacketSize, content, want, len(data))
+	}
+	if err := verifyCRC(data, content); err != nil {
+		return nil, err
+	}
+
+	p := parseV1(data[v2HeaderSize : v2HeaderSize+PacketSize])
+	p.Version = PacketVersion2
+	p.Content = content
+
+	off := v2HeaderSize + PacketSize
+	if content&ContentQuaternion != 0 {
+		for i := range p.Quat {
+			p.Quat[i] = int16(binary.LittleEndian.Uint16(data[off+2*i:]))
+		}
+		off += v2QuaternionSize
+	}
+	if content&ContentLinearAccel != 0 {
+		for i := range p.LinAccel {
+			p.LinAccel[i] = int16(binary.LittleEndian.Uint16(data[off+2*i:]))
+		}
+	}
+	return p, nil
+}
+
+// trailerSize returns the size of the CRC trailer selected by content.
+func trailerSize(content uint8) int {
+	if content&ContentCRC != 0 {
+		return crcSize
+	}
+	return 0
+}
+
+// verifyCRC checks the trailing CRC16 of a packet whose content selects one.
+func verifyCRC(data []byte, content uint8) error {
+	if content&ContentCRC == 0 {
+		return nil
+	}
+	n := len(data) - crcSize
+	want := binary.LittleEndian.Uint16(data[n:])
+	if got := CRC16(data[:n]); got != want {
+		return fmt.Errorf("%w: got 0x%04x, packet says 0x%04x", ErrChecksum, got, want)
+	}
+	return nil
+}
+
+// ChecksumGuard remembers whether a glove sends checksummed packets. Once it
+// has, packets without a CRC are rejected rather than passed on unchecked:
+// a flipped CRC bit in the content byte must not switch the check off. The
+// zero value accepts packets without a CRC until the first one with one;
+// use one guard per connection, as reflashed firmware reconnects.
+type ChecksumGuard struct {
+	required bool
+}
+
+// Check returns ErrMissingChecksum for packets of one notification that
+// carry no CRC after an earlier one did.
+func (g *ChecksumGuard) Check(packets []*SensorPacket) error {
+	if len(packets) == 0 {
+		return nil
+	}
+	if packets[0].Content&ContentCRC != 0 {
+		g.required = true
+		return nil
+	}
+	if g.required {
+		return fmt.Errorf("%w (v%d)", ErrMissingChecksum, packets[0].Version)
+	}
+	return nil
+}
 
+// CRC16 computes CRC-16/CCITT-FALSE (poly 0x1021, init 0xFFFF), matching
+// crc16() in the firmware's sensor_packet.h.
+func CRC16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// parseV1 decodes the 20-byte v1 body.
+func parseV1(data []byte) *SensorPacket {
 	p := &SensorPacket{
+		Version:   PacketVersion1,
 		AccX:      int16(binary.LittleEndian.Uint16(data[0:2])),
 		AccY:      int16(binary.LittleEndian.Uint16(data[2:4])),
 		AccZ:      int16(binary.LittleEndian.Uint16(data[4:6])),
@@ -53,7 +311,7 @@ func ParsePacket(data []byte) (*SensorPacket, error) {
 		Flags:     data[19],
 	}
 
-	return p, nil
+	return p
 }
 
 // AccelMS2 returns accelerometer values in m/s².
@@ -70,6 +328,30 @@ func (p *SensorPacket) GyroDPS() (x, y, z float64) {
 		float64(p.GyroZ) / 10.0
 }
 
+// Quaternion returns the on-board fusion orientation (w, x, y, z) and
+// whether the packet carries one.
+func (p *SensorPacket) Quaternion() (q [4]float64, ok bool) {
+	if p.Content&ContentQuaternion == 0 {
+		return q, false
+	}
+	for i, v := range p.Quat {
+		q[i] = float64(v) / 10000.0
+	}
+	return q, true
+}
+
+// LinearAccelMS2 returns the on-board gravity-free acceleration in m/s² and
+// whether the packet carries it.
+func (p *SensorPacket) LinearAccelMS2() (x, y, z float64, ok bool) {
+	if p.Content&ContentLinearAccel == 0 {
+		return 0, 0, 0, false
+	}
+	return float64(p.LinAccel[0]) / 100.0,
+		float64(p.LinAccel[1]) / 100.0,
+		float64(p.LinAccel[2]) / 100.0,
+		true
+}
+
 // IsCharging returns true if the glove is currently charging.
 func (p *SensorPacket) IsCharging() bool {
 	return p.Flags&FlagCharging != 0
@@ -89,3 +371,42 @@ func (p *SensorPacket) String() string {
 		ax, ay, az, gx, gy, gz, p.Timestamp, p.Sequence, p.Battery, p.Flags,
 	)
 }
+
+// Marshal encodes the packet in its own format version, the inverse of
+// ParsePacket. Samples from a batch encode as a single-sample v3 packet.
+func (p *SensorPacket) Marshal() []byte {
+	body := make([]byte, PacketSize)
+	binary.LittleEndian.PutUint16(body[0:2], uint16(p.AccX))
+	binary.LittleEndian.PutUint16(body[2:4], uint16(p.AccY))
+	binary.LittleEndian.PutUint16(body[4:6], uint16(p.AccZ))
+	binary.LittleEndian.PutUint16(body[6:8], uint16(p.GyroX))
+	binary.LittleEndian.PutUint16(body[8:10], uint16(p.GyroY))
+	binary.LittleEndian.PutUint16(body[10:12], uint16(p.GyroZ))
+	binary.LittleEndian.PutUint32(body[12:16], p.Timestamp)
+	binary.LittleEndian.PutUint16(body[16:18], p.Sequence)
+	body[18] = p.Battery
+	body[19] = p.Flags
+	var data []byte
+	switch p.Version {
+	case PacketVersion2:
+		data = append([]byte{PacketVersion2, p.Content}, body...)
+	case PacketVersion3:
+		data = append([]byte{PacketVersion3, p.Content, 1}, body...)
+	default:
+		return body
+	}
+	if p.Content&ContentQuaternion != 0 {
+		for _, v := range p.Quat {
+			data = binary.LittleEndian.AppendUint16(data, uint16(v))
+		}
+	}
+	if p.Content&ContentLinearAccel != 0 {
+		for _, v := range p.LinAccel {
+			data = binary.LittleEndian.AppendUint16(data, uint16(v))
+		}
+	}
+	if p.Content&ContentCRC != 0 {
+		data = binary.LittleEndian.AppendUint16(data, CRC16(data))
+	}
+	return data
+}
@@ -0,0 +1,59 @@
+package ble_test
+
+import (
+	"errors"
+	"testing"
+
+	"boxing-analytics/ble"
+)
+
+func TestChecksumCoversHeaderAndFlags(t *testing.T) {
+	for _, version := range []uint8{ble.PacketVersion2, ble.PacketVersion3} {
+		p := &ble.SensorPacket{Version: version, Content: ble.ContentCRC, AccX: 981, Sequence: 7, Flags: ble.FlagCalibrated}
+		data := p.Marshal()
+		if _, err := ble.ParsePackets(data); err != nil {
+			t.Fatalf("v%d: %v", version, err)
+		}
+
+		// Status flags are the last body byte, before the CRC
+		flags := len(data) - 3
+		for name, i := range map[string]int{"version": 0, "content": 1, "flags": flags} {
+			corrupt := append([]byte(nil), data...)
+			corrupt[i] ^= 0x10
+			_, err := ble.ParsePackets(corrupt)
+			if !errors.Is(err, ble.ErrChecksum) && !errors.Is(err, ble.ErrInvalidPacketSize) && !errors.Is(err, ble.ErrUnsupportedVersion) {
+				t.Errorf("v%d with a flipped %s byte: err %v, want it rejected", version, name, err)
+			}
+		}
+	}
+}
+
+func TestChecksumGuard(t *testing.T) {
+	parse := func(p *ble.SensorPacket) []*ble.SensorPacket {
+		t.Helper()
+		packets, err := ble.ParsePackets(p.Marshal())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return packets
+	}
+	plain := &ble.SensorPacket{Version: ble.PacketVersion2}
+	checked := &ble.SensorPacket{Version: ble.PacketVersion2, Content: ble.ContentCRC}
+	batch := &ble.SensorPacket{Version: ble.PacketVersion3}
+
+	var g ble.ChecksumGuard
+	if err := g.Check(parse(plain)); err != nil {
+		t.Errorf("packet without a CRC rejected before any had one: %v", err)
+	}
+	if err := g.Check(parse(checked)); err != nil {
+		t.Errorf("checksummed packet rejected: %v", err)
+	}
+	for _, p := range []*ble.SensorPacket{plain, batch, {}} {
+		if err := g.Check(parse(p)); !errors.Is(err, ble.ErrMissingChecksum) {
+			t.Errorf("v%d packet without a CRC after a checksummed one: err %v, want ErrMissingChecksum", p.Version, err)
+		}
+	}
+	if err := g.Check(parse(checked)); err != nil {
+		t.Errorf("checksummed packet rejected after unchecked ones: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package ble
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// ErrNotPaired is returned when a direct connect is requested for a hand
+// without a remembered glove.
+var ErrNotPaired = errors.New("no glove paired for this hand")
+
+// PairedGlove is a glove remembered for one hand.
+type PairedGlove struct {
+	Address  string    `json:"address"` // MAC address, upper case
+	Name     string    `json:"name"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// PairingStore persists the glove paired to each hand. Once a hand is
+// paired the central connects straight to that MAC on startup and ignores
+// other gloves advertising the same name (e.g. a neighbor's).
+type PairingStore struct {
+	mu     sync.RWMutex
+	path   string
+	gloves map[string]PairedGlove // keyed by Hand.String()
+}
+
+// NewPairingStore loads pairings from path, starting empty if the file does
+// not exist yet.
+func NewPairingStore(path string) (*PairingStore, error) {
+	s := &PairingStore{path: path, gloves: make(map[string]PairedGlove)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read pairings: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.gloves); err != nil {
+		return nil, fmt.Errorf("decode pairings: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns the glove paired to a hand.
+func (s *PairingStore) Get(hand Hand) (PairedGlove, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	g, ok := s.gloves[hand.String()]
+	return g, ok
+}
+
+// All returns the pairings keyed by hand name.
+func (s *PairingStore) All() map[string]PairedGlove {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]PairedGlove, len(s.gloves))
+	for k, v := range s.gloves {
+		out[k] = v
+	}
+	return out
+}
+
+// Set pairs a glove to a hand and writes the pairings to disk.
+func (s *PairingStore) Set(hand Hand, g PairedGlove) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g.Address = strings.ToUpper(g.Address)
+	s.gloves[hand.String()] = g
+	return s.saveLocked()
+}
+
+// Forget removes the pairing of a hand so the next matching glove found by
+// scanning is paired instead.
+func (s *PairingStore) Forget(hand Hand) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.gloves, hand.String())
+	return s.saveLocked()
+}
+
+// Swap exchanges the left and right pairings and writes them to disk.
+func (s *PairingStore) Swap() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	left, hasLeft := s.gloves[LeftHand.String()]
+	right, hasRight := s.gloves[RightHand.String()]
+	delete(s.gloves, LeftHand.String())
+	delete(s.gloves, RightHand.String())
+	if hasLeft {
+		s.gloves[RightHand.String()] = left
+	}
+	if hasRight {
+		s.gloves[LeftHand.String()] = right
+	}
+	return s.saveLocked()
+}
+
+// saveLocked writes the pairings to disk.
+// Must be called with s.mu held.
+func (s *PairingStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.gloves, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal pairings: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("write pairings: %w", err)
+	}
+	return nil
+}
+
+// SetPairingStore sets the store used to remember and direct-connect gloves.
+func (c *Central) SetPairingStore(store *PairingStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pairing = store
+}
+
+// Pairing returns the pairing store, or nil if none is set.
+func (c *Central) Pairing() *PairingStore {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pairing
+}
+
+// acceptsAddress reports whether a scanned glove may be connected as a hand:
+// either the hand is unpaired or the address is the paired one.
+func (c *Central) acceptsAddress(hand Hand, addr bluetooth.Address) bool {
+	store := c.Pairing()
+	if store == nil {
+		return true
+	}
+	g, ok := store.Get(hand)
+	return !ok || strings.EqualFold(g.Address, addr.String())
+}
+
+// pairedHand returns the hand a glove address is paired to, which overrides
+// the hand its name implies (see SwapHands).
+func (c *Central) pairedHand(addr bluetooth.Address) (Hand, bool) {
+	store := c.Pairing()
+	if store == nil {
+		return 0, false
+	}
+	for _, hand := range []Hand{LeftHand, RightHand} {
+		if g, ok := store.Get(hand); ok && strings.EqualFold(g.Address, addr.String()) {
+			return hand, true
+		}
+	}
+	return 0, false
+}
+
+// rememberGlove pairs a newly connected glove to its hand.
+func (c *Central) rememberGlove(hand Hand, name string, addr bluetooth.Address) {
+	store := c.Pairing()
+	if store == nil {
+		return
+	}
+	g := PairedGlove{Address: addr.String(), Name: name, LastSeen: time.Now()}
+	if err := store.Set(hand, g); err != nil {
+		log.Printf("BLE: Failed to remember %s glove: %v", hand, err)
+	}
+}
+
+// ConnectPaired connects directly to the glove paired to a hand, without
+// waiting for a scan to find it.
+func (c *Central) ConnectPaired(ctx context.Context, hand Hand) error {
+	store := c.Pairing()
+	if store == nil {
+		return ErrNotPaired
+	}
+	g, ok := store.Get(hand)
+	if !ok {
+		return ErrNotPaired
+	}
+	addr, err := c.transport.ParseAddress(g.Address)
+	if err != nil {
+		return fmt.Errorf("paired address %q: %w", g.Address, err)
+	}
+
+	if err := c.transition(hand, StateConnecting); err != nil {
+		return err
+	}
+	log.Printf("BLE: Direct connect to paired %s glove %s (%s)", hand, g.Name, g.Address)
+	return c.connectToDevice(ctx, g.Name, addr, hand)
+}
@@ -1,8 +1,10 @@
-// Package ble provides BLE Central functionality for FighterLink.
 package ble
 
 import (
+	"context"
+	"errors"
 	"log"
+	"strings"
 	"sync"
 	"time"
 )
@@ -17,6 +19,9 @@ type ScanConfig struct {
 	ScanInterval time.Duration
 	// AutoReconnect enables automatic reconnection on disconnect
 	AutoReconnect bool
+	// ConnParams are the LE connection parameters requested for gloves
+	// (zero value = BlueZ defaults)
+	ConnParams ConnParams
 }
 
 // DefaultScanConfig returns sensible defaults for scanning.
@@ -26,6 +31,7 @@ func DefaultScanConfig() ScanConfig {
 		RetryDelay:    3 * time.Second, // Wait 3s after disconnect before scanning
 		ScanInterval:  3 * time.Second, // Check every 3 seconds
 		AutoReconnect: true,
+		ConnParams:    DefaultConnParams(),
 	}
 }
 
@@ -34,8 +40,12 @@ type Scanner struct {
 	central *Central
 	config  ScanConfig
 	running bool
-	stop    chan struct{}
-	scanMu  sync.Mutex // Prevents concurrent scan attempts
+	ctx     context.Context    // Cancelled by Stop or the caller's context
+	cancel  context.CancelFunc // Stops the scan loop
+	scanMu  sync.Mutex         // Prevents concurrent scan attempts
+
+	directMu sync.Mutex
+	direct   map[Hand]bool // Hands due a direct connect to their paired glove
 }
 
 // NewScanner creates a new Scanner with the given Central and config.
@@ -43,19 +53,26 @@ func NewScanner(central *Central, config ScanConfig) *Scanner {
 	return &Scanner{
 		central: central,
 		config:  config,
-		stop:    make(chan struct{}),
+		direct:  map[Hand]bool{LeftHand: true, RightHand: true},
 	}
 }
 
 // Start begins the scanning loop.
 // This will continuously scan for devices and attempt to connect.
 // If AutoReconnect is enabled, it will restart scanning when a device disconnects.
-func (s *Scanner) Start() {
+// The loop runs until Stop is called or ctx is cancelled.
+func (s *Scanner) Start(ctx context.Context) {
 	if s.running {
 		return
 	}
 	s.running = true
-	s.stop = make(chan struct{})
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	if err := s.config.ConnParams.Validate(); err != nil {
+		log.Printf("Scanner: Ignoring connection parameters: %v", err)
+	} else {
+		s.central.SetConnParams(s.config.ConnParams)
+	}
 
 	// Register disconnect handler for immediate reconnection
 	if s.config.AutoReconnect {
@@ -72,21 +89,43 @@ func (s *Scanner) onDisconnect(hand Hand, deviceName string) {
 		return
 	}
 	log.Printf("Scanner: %s disconnected, initiating reconnection scan...", deviceName)
+	s.directMu.Lock()
+	s.direct[hand] = true
+	s.directMu.Unlock()
 	// Wait a moment for BlueZ to process the device removal before scanning
 	// This helps when ESP32 wakes from deep sleep and re-advertises
 	go func() {
-		time.Sleep(s.config.RetryDelay)
-		s.checkAndScan()
+		select {
+		case <-time.After(s.config.RetryDelay):
+			s.checkAndScan()
+		case <-s.ctx.Done():
+		}
 	}()
 }
 
+// Rescan checks for disconnected gloves now rather than at the next
+// ScanInterval, first trying a direct connect to each paired glove as after
+// a disconnect.
+func (s *Scanner) Rescan() error {
+	if !s.running {
+		return errors.New("scanner not running")
+	}
+	s.directMu.Lock()
+	for hand := range s.direct {
+		s.direct[hand] = true
+	}
+	s.directMu.Unlock()
+	go s.checkAndScan()
+	return nil
+}
+
 // Stop halts the scanning loop.
 func (s *Scanner) Stop() {
 	if !s.running {
 		return
 	}
 	s.running = false
-	close(s.stop)
+	s.cancel()
 	s.central.StopScanning()
 }
 
@@ -103,7 +142,8 @@ func (s *Scanner) scanLoop() {
 
 	for {
 		select {
-		case <-s.stop:
+		case <-s.ctx.Done():
+			s.central.StopScanning()
 			log.Println("Scanner: Stopped")
 			return
 		case <-ticker.C:
@@ -120,54 +160,90 @@ func (s *Scanner) checkAndScan() {
 	}
 	defer s.scanMu.Unlock()
 
+	s.connectPaired()
+
 	needLeft := !s.central.IsConnected(LeftHand)
 	needRight := !s.central.IsConnected(RightHand)
+	needHR := s.central.NeedsHeartRate()
 
-	if !needLeft && !needRight {
-		// Both connected, nothing to do
+	if !needLeft && !needRight && !needHR {
+		// Everything connected, nothing to do
 		return
 	}
 
 	// Build list of needed devices for logging
+	devices := s.central.deviceConfig()
 	var needed []string
 	if needLeft {
-		needed = append(needed, LeftDeviceName)
+		needed = append(needed, strings.Join(devices.LeftNames, "|"))
 	}
 	if needRight {
-		needed = append(needed, RightDeviceName)
+		needed = append(needed, strings.Join(devices.RightNames, "|"))
+	}
+	if needHR {
+		needed = append(needed, "heart-rate strap")
 	}
-	log.Printf("Scanner: Scanning for gloves (need: %v)", needed)
+	log.Printf("Scanner: Scanning for devices (need: %v)", needed)
 
 	// Start scanning
-	if err := s.central.StartScanning(); err != nil {
+	if err := s.central.StartScanning(s.ctx); err != nil {
 		log.Printf("Scanner: Failed to start scan: %v", err)
 	}
 }
 
-// WaitForBothGloves blocks until both gloves are connected or the context is cancelled.
-func (s *Scanner) WaitForBothGloves(timeout time.Duration) bool {
-	start := time.Now()
-	for {
-		if s.central.BothConnected() {
-			return true
+// connectPaired tries one direct connect to each disconnected paired glove
+// after startup or a disconnect, before falling back to scanning. Discovery
+// cannot run alongside a scan, so it waits while one is in progress.
+func (s *Scanner) connectPaired() {
+	if s.central.IsScanning() {
+		return
+	}
+	for _, hand := range []Hand{LeftHand, RightHand} {
+		s.directMu.Lock()
+		due := s.direct[hand]
+		s.direct[hand] = false
+		s.directMu.Unlock()
+		if !due || s.central.IsConnected(hand) {
+			continue
 		}
-		if timeout > 0 && time.Since(start) > timeout {
-			return false
+		if err := s.central.ConnectPaired(s.ctx, hand); err != nil && !errors.Is(err, ErrNotPaired) {
+			log.Printf("Scanner: Direct connect to %s glove failed, scanning instead: %v", hand, err)
 		}
-		time.Sleep(100 * time.Millisecond)
 	}
 }
 
-// WaitForAnyGlove blocks until at least one glove is connected.
-func (s *Scanner) WaitForAnyGlove(timeout time.Duration) bool {
-	start := time.Now()
+// WaitForBothGloves blocks until both gloves are connected or the context is
+// cancelled (use context.WithTimeout for a deadline).
+func (s *Scanner) WaitForBothGloves(ctx context.Context) bool {
+	return s.waitFor(ctx, s.central.BothConnected)
+}
+
+// WaitForAnyGlove blocks until at least one glove is connected or the
+// context is cancelled.
+func (s *Scanner) WaitForAnyGlove(ctx context.Context) bool {
+	return s.waitFor(ctx, func() bool {
+		return s.central.IsConnected(LeftHand) || s.central.IsConnected(RightHand)
+	})
+}
+
+// WaitForGlove blocks until the glove for hand is connected or the context
+// is cancelled.
+func (s *Scanner) WaitForGlove(ctx context.Context, hand Hand) bool {
+	return s.waitFor(ctx, func() bool { return s.central.IsConnected(hand) })
+}
+
+// waitFor polls cond until it holds or ctx is cancelled.
+func (s *Scanner) waitFor(ctx context.Context, cond func() bool) bool {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
 	for {
-		if s.central.IsConnected(LeftHand) || s.central.IsConnected(RightHand) {
+		if cond() {
 			return true
 		}
-		if timeout > 0 && time.Since(start) > timeout {
+		select {
+		case <-ctx.Done():
 			return false
+		case <-ticker.C:
 		}
-		time.Sleep(100 * time.Millisecond)
 	}
 }
@@ -0,0 +1,96 @@
+package ble
+
+import (
+	"fmt"
+	"log"
+)
+
+// LinkState is where a glove is in the scan/connect lifecycle.
+//
+//	idle ──scan──▶ scanning ──found──▶ connecting ──ok──▶ connected
+//	  ▲               │                    │                  │
+//	  └──scan ended───┘◀──────failed───────┘◀──disconnected───┘
+//
+// A direct connect to a paired glove goes from idle to connecting without
+// scanning.
+type LinkState int
+
+const (
+	StateIdle LinkState = iota
+	StateScanning
+	StateConnecting
+	StateConnected
+)
+
+func (s LinkState) String() string {
+	switch s {
+	case StateIdle:
+		return "idle"
+	case StateScanning:
+		return "scanning"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	}
+	return fmt.Sprintf("LinkState(%d)", int(s))
+}
+
+// linkTransitions lists the states each state may move to.
+var linkTransitions = map[LinkState][]LinkState{
+	StateIdle:       {StateScanning, StateConnecting},
+	StateScanning:   {StateIdle, StateConnecting},
+	StateConnecting: {StateIdle, StateConnected},
+	StateConnected:  {StateIdle},
+}
+
+// canTransition reports whether a glove may move from one state to another.
+func canTransition(from, to LinkState) bool {
+	for _, s := range linkTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// linkBusy reports whether a glove is being scanned for or connected.
+func (c *Central) linkBusy() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, s := range c.links {
+		if s == StateScanning || s == StateConnecting {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkState returns the lifecycle state of a glove.
+func (c *Central) LinkState(hand Hand) LinkState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.links[hand]
+}
+
+// transition moves a glove to a new state, refusing transitions the state
+// machine does not allow.
+func (c *Central) transition(hand Hand, to LinkState) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.transitionLocked(hand, to)
+}
+
+// transitionLocked is transition with c.mu held.
+func (c *Central) transitionLocked(hand Hand, to LinkState) error {
+	from := c.links[hand]
+	if from == to && to == StateIdle {
+		return nil
+	}
+	if !canTransition(from, to) {
+		return fmt.Errorf("%s glove cannot go from %s to %s", hand, from, to)
+	}
+	c.links[hand] = to
+	log.Printf("BLE: %s glove %s → %s", hand, from, to)
+	return nil
+}
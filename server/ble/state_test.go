@@ -0,0 +1,145 @@
+package ble_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"boxing-analytics/ble"
+	"boxing-analytics/ble/bletest"
+)
+
+func TestLinkTransitions(t *testing.T) {
+	states := []ble.LinkState{ble.StateIdle, ble.StateScanning, ble.StateConnecting, ble.StateConnected}
+	allowed := map[[2]ble.LinkState]bool{
+		{ble.StateIdle, ble.StateScanning}:        true,
+		{ble.StateIdle, ble.StateConnecting}:      true,
+		{ble.StateScanning, ble.StateIdle}:        true,
+		{ble.StateScanning, ble.StateConnecting}:  true,
+		{ble.StateConnecting, ble.StateIdle}:      true,
+		{ble.StateConnecting, ble.StateConnected}: true,
+		{ble.StateConnected, ble.StateIdle}:       true,
+	}
+	for _, from := range states {
+		for _, to := range states {
+			want := allowed[[2]ble.LinkState{from, to}]
+			if got := ble.CanTransition(from, to); got != want {
+				t.Errorf("%s → %s allowed = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+// newCentral returns an enabled Central on a fake stack.
+func newCentral(t *testing.T) (*ble.Central, *bletest.Transport) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	transport := bletest.NewTransport()
+	c := ble.NewCentral()
+	c.SetTransport(transport)
+	if err := c.Enable(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.DisconnectAll)
+	return c, transport
+}
+
+// waitFor fails the test unless cond holds within a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestScanConnectAndLose(t *testing.T) {
+	c, transport := newCentral(t)
+	left := transport.AddGlove(ble.LeftDeviceName)
+	right := transport.AddGlove(ble.RightDeviceName)
+	right.SetAdvertising(false)
+
+	if err := c.StartScanning(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsScanning() {
+		t.Error("not scanning after StartScanning")
+	}
+	waitFor(t, "left glove connected", func() bool { return c.LinkState(ble.LeftHand) == ble.StateConnected })
+	waitFor(t, "scan to end", func() bool { return !c.IsScanning() })
+
+	if got := c.LinkState(ble.RightHand); got != ble.StateIdle {
+		t.Errorf("right glove not found, state %s, want idle", got)
+	}
+	if !c.IsConnected(ble.LeftHand) || c.ConnectedGlove(ble.LeftHand) == nil {
+		t.Error("left glove connected but not reported connected")
+	}
+	if c.IsConnected(ble.RightHand) || c.ConnectedGlove(ble.RightHand) != nil {
+		t.Error("right glove reported connected")
+	}
+
+	left.Drop()
+	waitFor(t, "left glove lost", func() bool { return c.LinkState(ble.LeftHand) == ble.StateIdle })
+	if c.IsConnected(ble.LeftHand) || c.ConnectedGlove(ble.LeftHand) != nil {
+		t.Error("lost glove still reported connected")
+	}
+}
+
+func TestConnectFailureReturnsToIdle(t *testing.T) {
+	c, transport := newCentral(t)
+	events, stop := c.Events()
+	defer stop()
+	left := transport.AddGlove(ble.LeftDeviceName)
+	left.ConnectErr = errors.New("refused")
+	transport.AddGlove(ble.RightDeviceName).SetAdvertising(false)
+
+	if err := c.StartScanning(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "left glove discovered", func() bool {
+		select {
+		case ev := <-events:
+			return ev.Type == ble.EventDeviceDiscovered && ev.Hand == ble.LeftHand
+		default:
+			return false
+		}
+	})
+	waitFor(t, "scan and connect to end", func() bool { return !c.IsScanning() })
+	if got := c.LinkState(ble.LeftHand); got != ble.StateIdle {
+		t.Errorf("left glove state %s after a failed connect, want idle", got)
+	}
+	if c.IsConnected(ble.LeftHand) {
+		t.Error("failed glove reported connected")
+	}
+}
+
+func TestSwapHandsMovesLinkStates(t *testing.T) {
+	c, transport := newCentral(t)
+	transport.AddGlove(ble.LeftDeviceName)
+	transport.AddGlove(ble.RightDeviceName).SetAdvertising(false)
+
+	if err := c.StartScanning(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "left glove connected", func() bool { return c.IsConnected(ble.LeftHand) })
+	waitFor(t, "scan to end", func() bool { return !c.IsScanning() })
+
+	if err := c.SwapHands(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.LinkState(ble.RightHand); got != ble.StateConnected {
+		t.Errorf("right hand state %s after swap, want connected", got)
+	}
+	if got := c.LinkState(ble.LeftHand); got != ble.StateIdle {
+		t.Errorf("left hand state %s after swap, want idle", got)
+	}
+	glove := c.ConnectedGlove(ble.RightHand)
+	if glove == nil || glove.Name != ble.LeftDeviceName {
+		t.Errorf("right hand glove %v after swap, want %s", glove, ble.LeftDeviceName)
+	}
+}
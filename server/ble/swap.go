@@ -0,0 +1,49 @@
+package ble
+
+import (
+	"errors"
+	"log"
+)
+
+// ErrSwapBusy is returned when the hands cannot be swapped because a glove
+// is connecting or calibrating.
+var ErrSwapBusy = errors.New("a glove is connecting or calibrating")
+
+// SwapHands reassigns each glove to the other hand, e.g. when the left-named
+// glove is worn on the right hand or the gloves were mixed up between
+// athletes. Connected gloves keep streaming under their new hand, and the
+// pairings are swapped so the assignment survives reconnects.
+func (c *Central) SwapHands() error {
+	c.mu.Lock()
+	if len(c.calibrating) > 0 || c.links[LeftHand] == StateConnecting || c.links[RightHand] == StateConnecting {
+		c.mu.Unlock()
+		return ErrSwapBusy
+	}
+	c.leftGlove, c.rightGlove = c.rightGlove, c.leftGlove
+	if c.leftGlove != nil {
+		c.leftGlove.Hand = LeftHand
+	}
+	if c.rightGlove != nil {
+		c.rightGlove.Hand = RightHand
+	}
+	c.links[LeftHand], c.links[RightHand] = c.links[RightHand], c.links[LeftHand]
+	left, right := c.leftGlove, c.rightGlove
+	store := c.pairing
+	c.mu.Unlock()
+
+	if store != nil {
+		if err := store.Swap(); err != nil {
+			log.Printf("BLE: Failed to save swapped pairings: %v", err)
+		}
+	}
+	log.Printf("BLE: Swapped hands (left: %s, right: %s)", gloveName(left), gloveName(right))
+	return nil
+}
+
+// gloveName returns a glove's name for logging, or "none".
+func gloveName(glove *GloveConnection) string {
+	if glove == nil {
+		return "none"
+	}
+	return glove.Name
+}
@@ -0,0 +1,108 @@
+//go:build darwin || windows
+
+package ble
+
+import (
+	"fmt"
+	"sync"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// maxAttributeValue is the longest value an ATT attribute can hold.
+const maxAttributeValue = 512
+
+// tinygoLinks tracks the open links by address, so forget can close a
+// link the central has given up on (e.g. after a packet timeout) before it
+// reconnects.
+type tinygoLinks struct {
+	mu    sync.Mutex
+	links map[string]*tinygoLink
+}
+
+// tinygoLink is a tinygo bluetooth device connection. Disconnect may be
+// called more than once.
+type tinygoLink struct {
+	device *bluetooth.Device
+	owner  *tinygoLinks
+	addr   string
+	once   sync.Once
+}
+
+// add records a new link to a device.
+func (t *tinygoLinks) add(addr bluetooth.Address, device *bluetooth.Device) *tinygoLink {
+	l := &tinygoLink{device: device, owner: t, addr: addr.String()}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.links == nil {
+		t.links = make(map[string]*tinygoLink)
+	}
+	t.links[l.addr] = l
+	return l
+}
+
+// close disconnects the open link to a device, if any.
+func (t *tinygoLinks) close(addr bluetooth.Address) {
+	t.mu.Lock()
+	l := t.links[addr.String()]
+	t.mu.Unlock()
+	if l != nil {
+		_ = l.Disconnect()
+	}
+}
+
+func (l *tinygoLink) Disconnect() error {
+	var err error
+	l.once.Do(func() {
+		l.owner.mu.Lock()
+		if l.owner.links[l.addr] == l {
+			delete(l.owner.links, l.addr)
+		}
+		l.owner.mu.Unlock()
+		err = l.device.Disconnect()
+	})
+	return err
+}
+
+// discoverCharacteristic looks up a characteristic through tinygo
+// bluetooth, which serves GATT on macOS and Windows.
+func discoverCharacteristic(link Link, addr bluetooth.Address, serviceUUID, charUUID string) (bluetooth.DeviceCharacteristic, error) {
+	l, ok := link.(*tinygoLink)
+	if !ok {
+		return bluetooth.DeviceCharacteristic{}, fmt.Errorf("link to %s is not a tinygo bluetooth device", addr.String())
+	}
+	svcUUID, err := bluetooth.ParseUUID(serviceUUID)
+	if err != nil {
+		return bluetooth.DeviceCharacteristic{}, fmt.Errorf("service UUID %q: %w", serviceUUID, err)
+	}
+	chrUUID, err := bluetooth.ParseUUID(charUUID)
+	if err != nil {
+		return bluetooth.DeviceCharacteristic{}, fmt.Errorf("characteristic UUID %q: %w", charUUID, err)
+	}
+
+	services, err := l.device.DiscoverServices([]bluetooth.UUID{svcUUID})
+	if err != nil {
+		return bluetooth.DeviceCharacteristic{}, fmt.Errorf("discover services: %w", err)
+	}
+	if len(services) == 0 {
+		return bluetooth.DeviceCharacteristic{}, fmt.Errorf("service %s not found on %s", serviceUUID, addr.String())
+	}
+	chars, err := services[0].DiscoverCharacteristics([]bluetooth.UUID{chrUUID})
+	if err != nil {
+		return bluetooth.DeviceCharacteristic{}, fmt.Errorf("characteristic %s not found under %s: %w", charUUID, serviceUUID, err)
+	}
+	if len(chars) == 0 {
+		return bluetooth.DeviceCharacteristic{}, fmt.Errorf("characteristic %s not found under %s", charUUID, serviceUUID)
+	}
+	return chars[0], nil
+}
+
+// readCharacteristic reads a characteristic's current value.
+func readCharacteristic(char *bluetooth.DeviceCharacteristic) ([]byte, error) {
+	buf := make([]byte, maxAttributeValue)
+	n, err := char.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:min(n, len(buf))], nil
+}
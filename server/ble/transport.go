@@ -0,0 +1,95 @@
+package ble
+
+import (
+	"context"
+	"fmt"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Characteristic is a GATT characteristic of a connected device. The
+// platform's Bluetooth stack serves it: BlueZ over D-Bus on Linux
+// (bluez_linux.go), CoreBluetooth on macOS (corebluetooth_darwin.go) or
+// WinRT on Windows (winrt_windows.go).
+type Characteristic interface {
+	ReadValue() ([]byte, error)
+	WriteValue(data []byte) error
+
+	// Subscribe enables notifications and calls handler with each value.
+	// The returned channel is closed once notifications end, whether
+	// through Unsubscribe or because the link dropped.
+	Subscribe(handler func([]byte)) (<-chan struct{}, error)
+	Unsubscribe() error
+
+	// MTU returns the negotiated ATT MTU.
+	MTU() (uint16, error)
+}
+
+// Transport is the Bluetooth stack a Central finds and talks to devices
+// through. NewCentral uses the platform's; package bletest has an
+// in-memory one for running without hardware.
+type Transport interface {
+	// Enable initializes the stack.
+	Enable() error
+
+	// Scan calls callback with each advertisement seen until StopScan.
+	Scan(callback func(bluetooth.ScanResult)) error
+	StopScan() error
+
+	// Connect opens a link to a device through an adapter and returns once
+	// its GATT profile can be queried.
+	Connect(ctx context.Context, adapterID string, addr bluetooth.Address, params ConnParams) (Link, error)
+
+	// Characteristic looks up a characteristic of a connected device by
+	// the big-endian UUID strings of DeviceConfig.
+	Characteristic(link Link, adapterID string, addr bluetooth.Address, serviceUUID, charUUID string) (Characteristic, error)
+
+	// Watch calls onLost as soon as the stack reports the device
+	// disconnected. It returns once stillConnected reports false.
+	Watch(adapterID string, addr bluetooth.Address, name string, stillConnected func() bool, onLost func())
+
+	// Forget disconnects a device and drops the stack's cached state for
+	// it so the next connection starts clean. Bonded devices keep their
+	// keys unless force is set.
+	Forget(adapterID string, addr bluetooth.Address, force bool)
+
+	// RegisterAgent answers the devices' pairing requests with passkey.
+	RegisterAgent(passkey uint32) error
+
+	// Bond pairs with a connected device unless it is already bonded.
+	Bond(adapterID string, addr bluetooth.Address) error
+
+	// ParseAddress parses a device address as Address.String() formats it.
+	ParseAddress(s string) (bluetooth.Address, error)
+}
+
+// SetTransport replaces the platform's Bluetooth stack. Call it before
+// Enable.
+func (c *Central) SetTransport(t Transport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transport = t
+}
+
+// adapterScanner scans through a tinygo bluetooth adapter, which every
+// platform transport does.
+type adapterScanner struct {
+	adapter *bluetooth.Adapter
+}
+
+func (s adapterScanner) Enable() error {
+	if err := s.adapter.Enable(); err != nil {
+		return fmt.Errorf("failed to enable BLE adapter: %w", err)
+	}
+	return nil
+}
+
+func (s adapterScanner) Scan(callback func(bluetooth.ScanResult)) error {
+	return s.adapter.Scan(func(_ *bluetooth.Adapter, result bluetooth.ScanResult) {
+		callback(result)
+	})
+}
+
+func (s adapterScanner) StopScan() error {
+	return s.adapter.StopScan()
+}
@@ -0,0 +1,123 @@
+package ble
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// winRTBackend drives the WinRT Bluetooth APIs through tinygo bluetooth,
+// so a Windows mini-PC can be the hub. Windows has a single adapter and
+// keeps pairing to its own settings. tinygo reports no disconnects there,
+// so a dropped glove is noticed by the packet timeout.
+type winRTBackend struct {
+	adapterScanner
+	links tinygoLinks
+}
+
+// newPlatformTransport returns the Windows transport.
+func newPlatformTransport() Transport {
+	return &winRTBackend{adapterScanner: adapterScanner{bluetooth.DefaultAdapter}}
+}
+
+// Connect opens a GATT session that Windows keeps connected until it is
+// closed. Services are discovered on demand, so there is nothing to wait for.
+func (b *winRTBackend) Connect(_ context.Context, adapterID string, addr bluetooth.Address, params ConnParams) (Link, error) {
+	if adapterID != DefaultAdapterID {
+		log.Printf("BLE: Windows has a single adapter, ignoring %s", adapterID)
+	}
+	device, err := b.adapter.Connect(addr, params.bluetoothParams())
+	if err != nil {
+		return nil, err
+	}
+	return b.links.add(addr, device), nil
+}
+
+func (b *winRTBackend) Characteristic(link Link, _ string, addr bluetooth.Address, serviceUUID, charUUID string) (Characteristic, error) {
+	char, err := discoverCharacteristic(link, addr, serviceUUID, charUUID)
+	if err != nil {
+		return nil, err
+	}
+	return &winRTCharacteristic{char: char}, nil
+}
+
+// Watch has no disconnect signal to wait for (see winRTBackend).
+func (b *winRTBackend) Watch(string, bluetooth.Address, string, func() bool, func()) {}
+
+// Forget closes a GATT session still open to the device. Windows keeps no
+// other state the server can clear, and bonds are removed in Settings.
+func (b *winRTBackend) Forget(_ string, addr bluetooth.Address, _ bool) {
+	b.links.close(addr)
+}
+
+// RegisterAgent has nothing to register: bonded gloves are paired once
+// through Windows Settings, which asks for the passkey.
+func (b *winRTBackend) RegisterAgent(uint32) error {
+	log.Println("BLE: Pair bonded gloves in Windows Settings, BLE_PASSKEY is not used")
+	return nil
+}
+
+// Bond leaves pairing to Windows (see RegisterAgent).
+func (b *winRTBackend) Bond(string, bluetooth.Address) error {
+	return nil
+}
+
+func (b *winRTBackend) ParseAddress(s string) (bluetooth.Address, error) {
+	mac, err := bluetooth.ParseMAC(s)
+	if err != nil {
+		return bluetooth.Address{}, err
+	}
+	return bluetooth.Address{MACAddress: bluetooth.MACAddress{MAC: mac}}, nil
+}
+
+// winRTCharacteristic is a characteristic discovered through tinygo
+// bluetooth.
+type winRTCharacteristic struct {
+	char bluetooth.DeviceCharacteristic
+
+	stop     chan struct{} // Closed by Unsubscribe
+	stopOnce sync.Once
+}
+
+func (c *winRTCharacteristic) ReadValue() ([]byte, error) {
+	return readCharacteristic(&c.char)
+}
+
+func (c *winRTCharacteristic) WriteValue(data []byte) error {
+	_, err := c.char.Write(data)
+	return err
+}
+
+// Subscribe enables notifications. The returned channel closes on
+// Unsubscribe only, as link loss is not reported.
+func (c *winRTCharacteristic) Subscribe(handler func([]byte)) (<-chan struct{}, error) {
+	c.stop = make(chan struct{})
+	stop := c.stop
+	err := c.char.EnableNotifications(func(buf []byte) {
+		select {
+		case <-stop:
+		default:
+			handler(buf)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stop, nil
+}
+
+// Unsubscribe stops passing notifications on. tinygo cannot disable them
+// on Windows, so they keep arriving until the GATT session is closed.
+func (c *winRTCharacteristic) Unsubscribe() error {
+	if c.stop != nil {
+		c.stopOnce.Do(func() { close(c.stop) })
+	}
+	return nil
+}
+
+// MTU returns the session's maximum PDU size, which is the ATT MTU.
+func (c *winRTCharacteristic) MTU() (uint16, error) {
+	return c.char.GetMTU()
+}
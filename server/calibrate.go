@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"boxing-analytics/ble"
+	"boxing-analytics/server"
+)
+
+// runCalibrate implements the "calibrate" subcommand: it connects the
+// gloves, measures their sensor bias while they are held still and stores
+// the offsets in DATA_DIR for the server, like POST /api/calibrate. Stop
+// the server first so the gloves are free to connect.
+func runCalibrate(args []string) int {
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	handName := fs.String("hand", "both", "glove to calibrate: left, right or both")
+	duration := fs.Duration("duration", ble.DefaultCalibrationDuration, "how long the gloves are held still")
+	timeout := fs.Duration("timeout", 30*time.Second, "how long to wait for the gloves to connect")
+	fs.Parse(args)
+
+	var hands []ble.Hand
+	switch *handName {
+	case "left":
+		hands = []ble.Hand{ble.LeftHand}
+	case "right":
+		hands = []ble.Hand{ble.RightHand}
+	case "both":
+		hands = []ble.Hand{ble.LeftHand, ble.RightHand}
+	}
+	if fs.NArg() != 0 || hands == nil || *duration < time.Second || *duration > 30*time.Second {
+		fmt.Fprintln(fs.Output(), "calibrate: -hand must be left, right or both and -duration 1s-30s")
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := server.ConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "calibrate: %v\n", err)
+		return 1
+	}
+	if err := os.MkdirAll(cfg.DataDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "calibrate: %v\n", err)
+		return 1
+	}
+	central, err := server.NewCentral(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "calibrate: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	if err := central.Enable(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "calibrate: %v\n", err)
+		return 1
+	}
+	scanner := ble.NewScanner(central, ble.DefaultScanConfig())
+	scanner.Start(ctx)
+	defer central.DisconnectAll()
+	defer scanner.Stop()
+
+	fmt.Println("Waiting for the gloves to connect...")
+	waitCtx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	for _, hand := range hands {
+		if !scanner.WaitForGlove(waitCtx, hand) {
+			fmt.Fprintf(os.Stderr, "calibrate: %s glove did not connect within %s\n", hand, *timeout)
+			return 1
+		}
+	}
+
+	// Calibrate requested gloves in parallel during the same stillness period
+	fmt.Printf("Hold the gloves still for %.0fs...\n", duration.Seconds())
+	failed := false
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, hand := range hands {
+		wg.Add(1)
+		go func(hand ble.Hand) {
+			defer wg.Done()
+			offsets, err := central.Calibrate(ctx, hand, *duration)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "calibrate: %s glove: %v\n", hand, err)
+				failed = true
+				return
+			}
+			fmt.Printf("%s glove: accel bias %.2f %.2f %.2f m/s², gyro bias %.2f %.2f %.2f °/s\n", hand,
+				offsets.Accel[0], offsets.Accel[1], offsets.Accel[2],
+				offsets.Gyro[0], offsets.Gyro[1], offsets.Gyro[2])
+		}(hand)
+	}
+	wg.Wait()
+	if failed {
+		return 1
+	}
+	fmt.Println("Calibration stored")
+	return 0
+}
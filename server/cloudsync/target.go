@@ -0,0 +1,217 @@
+// Package cloudsync uploads finished sessions, and optionally their raw
+// recordings, to S3-compatible object storage or an HTTPS endpoint so the
+// history can be reached from other devices.
+package cloudsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// uploadTimeout bounds a single upload.
+const uploadTimeout = 30 * time.Second
+
+// defaultRegion is the S3 region signed for when none is configured. Most
+// S3-compatible stores (MinIO, Garage) accept it whatever their own.
+const defaultRegion = "us-east-1"
+
+// Config selects where sessions are uploaded. URL is either
+//
+//	s3://bucket/prefix        S3 or an S3-compatible store at Endpoint
+//	https://host/path         PUT to https://host/path/<key>
+//
+// and an empty URL disables syncing.
+type Config struct {
+	URL        string
+	Token      string // Bearer token for HTTPS endpoints
+	Endpoint   string // S3 endpoint, e.g. "https://minio.local:9000" ("" = AWS)
+	Region     string // S3 region ("" = us-east-1)
+	AccessKey  string // S3 credentials
+	SecretKey  string
+	Recordings bool // Also upload raw recordings
+}
+
+// Target stores uploaded objects under a key such as
+// "sessions/<id>.json".
+type Target interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+}
+
+// NewTarget creates the target cfg.URL points at.
+func NewTarget(cfg Config) (Target, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync URL %q: %w", cfg.URL, err)
+	}
+	client := &http.Client{Timeout: uploadTimeout}
+	switch u.Scheme {
+	case "https", "http":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid sync URL %q: missing host", cfg.URL)
+		}
+		return &httpTarget{base: strings.TrimSuffix(cfg.URL, "/"), token: cfg.Token, client: client}, nil
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid sync URL %q: missing bucket", cfg.URL)
+		}
+		if cfg.AccessKey == "" || cfg.SecretKey == "" {
+			return nil, fmt.Errorf("S3 sync needs an access key and secret key")
+		}
+		t := &s3Target{
+			bucket:    u.Host,
+			prefix:    strings.Trim(u.Path, "/"),
+			region:    cfg.Region,
+			accessKey: cfg.AccessKey,
+			secretKey: cfg.SecretKey,
+			client:    client,
+		}
+		if t.region == "" {
+			t.region = defaultRegion
+		}
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = "https://s3." + t.region + ".amazonaws.com"
+		}
+		t.endpoint, err = url.Parse(endpoint)
+		if err != nil || (t.endpoint.Scheme != "http" && t.endpoint.Scheme != "https") || t.endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+		}
+		return t, nil
+	default:
+		return nil, fmt.Errorf("invalid sync URL %q: must be s3:// or https://", cfg.URL)
+	}
+}
+
+// httpTarget PUTs objects below a base URL.
+type httpTarget struct {
+	base   string
+	token  string
+	client *http.Client
+}
+
+// Put implements Target.
+func (t *httpTarget) Put(ctx context.Context, key, contentType string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, t.base+"/"+key, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if t.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return do(t.client, req)
+}
+
+// s3Target PUTs objects into a bucket using path-style URLs, which every
+// S3-compatible store supports, signed with AWS Signature Version 4.
+type s3Target struct {
+	endpoint  *url.URL
+	bucket    string
+	prefix    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// Put implements Target.
+func (t *s3Target) Put(ctx context.Context, key, contentType string, data []byte) error {
+	if t.prefix != "" {
+		key = t.prefix + "/" + key
+	}
+	path := "/" + uriEncode(t.bucket) + "/" + uriEncode(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, t.endpoint.Scheme+"://"+t.endpoint.Host+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	t.sign(req, path, data, time.Now().UTC())
+	return do(t.client, req)
+}
+
+// sign adds a Signature Version 4 Authorization header to req.
+func (t *s3Target) sign(req *http.Request, path string, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		"", // no query
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + t.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+t.secretKey), date)
+	key = hmacSHA256(key, t.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		t.accessKey, scope, signedHeaders, signature))
+}
+
+// do sends an upload request, turning non-2xx responses into errors.
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("PUT %s: HTTP %d %s", req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// uriEncode escapes an S3 object key the way Signature Version 4 expects:
+// everything but unreserved characters and "/".
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
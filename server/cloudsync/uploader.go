@@ -0,0 +1,179 @@
+package cloudsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"boxing-analytics/storage"
+)
+
+// Upload pacing
+const (
+	syncInterval = 15 * time.Minute // pass without being notified, catching missed sessions
+	minBackoff   = 5 * time.Second  // first retry after a failure
+	maxBackoff   = 10 * time.Minute // retries back off exponentially up to this
+)
+
+// syncState is the uploader's record of what reached the target, saved so
+// nothing is uploaded twice across restarts.
+type syncState struct {
+	Sessions   map[string]time.Time `json:"sessions"`
+	Recordings map[string]time.Time `json:"recordings"`
+}
+
+// Uploader pushes every stored session the target does not have yet, and
+// optionally its raw recording. A failed pass is retried with exponential
+// backoff, so sessions recorded offline go up once the network is back.
+type Uploader struct {
+	target        Target
+	store         *storage.Store
+	recordingsDir string // "" = sessions only
+	statePath     string
+	state         syncState
+	notify        chan struct{}
+}
+
+// NewUploader creates an uploader for the sessions in store, recording
+// progress in statePath. With a recordingsDir, the raw recordings kept there
+// are uploaded too.
+func NewUploader(target Target, store *storage.Store, recordingsDir, statePath string) (*Uploader, error) {
+	u := &Uploader{
+		target:        target,
+		store:         store,
+		recordingsDir: recordingsDir,
+		statePath:     statePath,
+		notify:        make(chan struct{}, 1),
+	}
+	data, err := os.ReadFile(statePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read sync state: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &u.state); err != nil {
+			return nil, fmt.Errorf("decode sync state: %w", err)
+		}
+	}
+	if u.state.Sessions == nil {
+		u.state.Sessions = make(map[string]time.Time)
+	}
+	if u.state.Recordings == nil {
+		u.state.Recordings = make(map[string]time.Time)
+	}
+	return u, nil
+}
+
+// Notify starts a pass soon, e.g. after a session was saved.
+func (u *Uploader) Notify() {
+	select {
+	case u.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run uploads until ctx is cancelled: a pass at start, after every Notify
+// and every syncInterval, with failed passes retried after a backoff.
+func (u *Uploader) Run(ctx context.Context) {
+	backoff := time.Duration(0)
+	for {
+		wait := syncInterval
+		if err := u.pass(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			backoff = min(max(backoff*2, minBackoff), maxBackoff)
+			wait = backoff
+			log.Printf("Cloud sync: %v (retrying in %s)", err, wait)
+		} else {
+			backoff = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-u.notify:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// pass uploads everything not uploaded yet, stopping at the first failure.
+func (u *Uploader) pass(ctx context.Context) error {
+	ids, err := u.store.IDs()
+	if err != nil {
+		return err
+	}
+	uploaded := 0
+	defer func() {
+		if uploaded > 0 {
+			log.Printf("Cloud sync: uploaded %d files", uploaded)
+		}
+	}()
+	for _, id := range ids {
+		if _, ok := u.state.Sessions[id]; !ok {
+			sess, err := u.store.Get(id)
+			if err != nil {
+				continue // unreadable, skipped like Store.List does
+			}
+			data, err := json.Marshal(sess)
+			if err != nil {
+				return fmt.Errorf("marshal session %s: %w", id, err)
+			}
+			if err := u.target.Put(ctx, "sessions/"+id+".json", "application/json", data); err != nil {
+				return fmt.Errorf("upload session %s: %w", id, err)
+			}
+			u.state.Sessions[id] = time.Now()
+			uploaded++
+			if err := u.saveState(); err != nil {
+				return err
+			}
+		}
+
+		if u.recordingsDir == "" {
+			continue
+		}
+		if _, ok := u.state.Recordings[id]; ok {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(u.recordingsDir, id+".json"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue // not recorded, or pruned already
+		}
+		if err != nil {
+			return fmt.Errorf("read recording %s: %w", id, err)
+		}
+		if err := u.target.Put(ctx, "recordings/"+id+".json", "application/json", data); err != nil {
+			return fmt.Errorf("upload recording %s: %w", id, err)
+		}
+		u.state.Recordings[id] = time.Now()
+		uploaded++
+		if err := u.saveState(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveState writes the sync state atomically.
+func (u *Uploader) saveState() error {
+	data, err := json.MarshalIndent(u.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal sync state: %w", err)
+	}
+	tmp := u.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write sync state: %w", err)
+	}
+	if err := os.Rename(tmp, u.statePath); err != nil {
+		return fmt.Errorf("commit sync state: %w", err)
+	}
+	return nil
+}
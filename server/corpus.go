@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/benchmark"
+	"boxing-analytics/ble"
+	"boxing-analytics/replay"
+)
+
+// corpusVersion is written to the manifest of a generated corpus. Bump it
+// whenever the specs or the glove model change, since scores are only
+// comparable within a version.
+const corpusVersion = "2"
+
+// corpusStillMS is how long the gloves are held still before the first
+// punch, so the analyzer calibrates.
+const corpusStillMS = 5000
+
+// corpusSameHandMS is the shortest time between two movements of one glove;
+// quicker follow-ups go to the other hand.
+const corpusSameHandMS = 350
+
+// corpusSpec describes one synthetic benchmark recording.
+type corpusSpec struct {
+	name        string
+	description string
+	seed        int64
+	stance      string  // labels straights as jabs and crosses, "" = "straight"
+	punches     int     // labeled punches
+	feints      int     // unlabeled movements below the detection threshold
+	minGapMS    int     // shortest gap between two movements, either hand
+	maxGapMS    int     // longest gap
+	minForce    float64 // m/s² at the peak
+	maxForce    float64
+	noise       int     // raw sensor noise amplitude
+	jitter      float64 // random share the rotation of each punch is off its type's
+}
+
+// corpusSpecs are the recordings of the bundled corpus, from clean to
+// deliberately hard: the synthetic glove model is simple, so the harder ones
+// are what tell classifier changes apart.
+var corpusSpecs = []corpusSpec{
+	{
+		name:        "synthetic-basic",
+		description: "Clean punches of every type at a steady pace, orthodox stance, two feints",
+		seed:        1, stance: analytics.StanceOrthodox, punches: 20, feints: 2,
+		minGapMS: 900, maxGapMS: 1500, minForce: 30, maxForce: 55, noise: 8,
+	},
+	{
+		name:        "synthetic-southpaw",
+		description: "Clean punches of every type, southpaw stance, so jabs are thrown with the right hand",
+		seed:        2, stance: analytics.StanceSouthpaw, punches: 20, feints: 2,
+		minGapMS: 900, maxGapMS: 1500, minForce: 30, maxForce: 55, noise: 8,
+	},
+	{
+		name:        "synthetic-sloppy",
+		description: "Noisy sensors and punch rotations up to 60% off their type's, no stance given",
+		seed:        3, punches: 20, feints: 4,
+		minGapMS: 900, maxGapMS: 1500, minForce: 30, maxForce: 55, noise: 40, jitter: 0.6,
+	},
+	{
+		name:        "synthetic-soft",
+		description: "Light punches around the detection threshold, orthodox stance",
+		seed:        4, stance: analytics.StanceOrthodox, punches: 20,
+		minGapMS: 900, maxGapMS: 1500, minForce: 20, maxForce: 34, noise: 8, jitter: 0.2,
+	},
+	{
+		name:        "synthetic-fast",
+		description: "Quick combinations with gaps down to 150ms between hands, orthodox stance",
+		seed:        5, stance: analytics.StanceOrthodox, punches: 20,
+		minGapMS: 150, maxGapMS: 600, minForce: 30, maxForce: 55, noise: 8, jitter: 0.2,
+	},
+}
+
+// runCorpus implements the "corpus" subcommand: it (re)builds the synthetic
+// recordings of the benchmark corpus and their manifest. Generation is
+// deterministic, so unchanged specs give byte-identical files.
+func runCorpus(args []string) int {
+	fs := flag.NewFlagSet("corpus", flag.ExitOnError)
+	out := fs.String("out", defaultCorpusDir, "corpus directory to write")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := writeCorpus(*out, corpusSpecs); err != nil {
+		fmt.Fprintf(os.Stderr, "corpus: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeCorpus generates the recordings of specs into dir, then the manifest.
+func writeCorpus(dir string, specs []corpusSpec) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	manifest := benchmark.Corpus{Version: corpusVersion}
+	for _, spec := range specs {
+		rec := spec.generate()
+		file := spec.name + ".json"
+		path := filepath.Join(dir, file)
+		if err := rec.Save(path); err != nil {
+			return err
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		manifest.Recordings = append(manifest.Recordings, benchmark.Entry{Name: spec.name, File: file, SHA256: sum})
+		fmt.Printf("%s: %d packets, %d labels\n", path, len(rec.Packets), len(rec.Labels))
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, benchmark.ManifestFile), append(data, '\n'), 0o644)
+}
+
+// generate synthesises the spec's recording: both gloves sampled at
+// simulateRate, still at first, then the punches and feints in random
+// order, hands and types.
+func (s corpusSpec) generate() *replay.Recording {
+	rng := rand.New(rand.NewSource(s.seed))
+	rec := &replay.Recording{
+		Version:     replay.FormatVersion,
+		Name:        s.name,
+		Description: s.description,
+		Stance:      s.stance,
+	}
+	gloves := []*simGlove{
+		{hand: ble.LeftHand, noise: s.noise, step: len(simulateShape)},
+		{hand: ble.RightHand, noise: s.noise, step: len(simulateShape)},
+	}
+	types := []analytics.PunchType{analytics.PunchStraight, analytics.PunchHook, analytics.PunchUppercut}
+	feint := make([]bool, s.punches+s.feints)
+	for _, i := range rng.Perm(len(feint))[:s.feints] {
+		feint[i] = true
+	}
+
+	const stepMS = 1000 / simulateRate
+	next, moves := corpusStillMS, 0
+	last := []int{-corpusSameHandMS, -corpusSameHandMS} // ms of each glove's last movement
+	for ms := 0; moves < len(feint) || ms < next+corpusStillMS/5; ms += stepMS {
+		ts := uint32(simulateBootMS + ms)
+		if moves < len(feint) && ms >= next {
+			i := rng.Intn(len(gloves))
+			if ms-last[i] < corpusSameHandMS {
+				i = 1 - i
+			}
+			g := gloves[i]
+			last[i] = ms
+			peakTS := int64(ts) + 2*stepMS // simulateShape peaks on the third sample
+			if feint[moves] {
+				g.swing(10+rng.Float64()*8, simulateRotation[analytics.PunchStraight])
+			} else {
+				punch := types[rng.Intn(len(types))]
+				base := simulateRotation[punch]
+				var rotation [2]float64
+				for i := range rotation {
+					rotation[i] = base[i] * (1 + s.jitter*(2*rng.Float64()-1))
+				}
+				g.swing(s.minForce+rng.Float64()*(s.maxForce-s.minForce), rotation)
+				rec.Labels = append(rec.Labels, replay.Label{Hand: g.hand.String(), Timestamp: peakTS, Type: s.label(g.hand, punch)})
+			}
+			moves++
+			next = ms + s.minGapMS + rng.Intn(s.maxGapMS-s.minGapMS+1)
+		}
+		for _, g := range gloves {
+			rec.Packets = append(rec.Packets, replay.Packet{Hand: g.hand.String(), Data: g.sample(rng, ts).Marshal()})
+		}
+	}
+	return rec
+}
+
+// label names a punch as the corpus expects it classified: straights by
+// the hand they are thrown with once the stance is known.
+func (s corpusSpec) label(hand ble.Hand, punch analytics.PunchType) string {
+	if punch != analytics.PunchStraight || s.stance == "" {
+		return string(punch)
+	}
+	lead := ble.LeftHand
+	if s.stance == analytics.StanceSouthpaw {
+		lead = ble.RightHand
+	}
+	if hand == lead {
+		return string(analytics.PunchJab)
+	}
+	return string(analytics.PunchCross)
+}
+
+// fileSHA256 returns the hex SHA-256 of a file, as the manifest lists it.
+func fileSHA256(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
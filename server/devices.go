@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"boxing-analytics/ble"
+	"boxing-analytics/server"
+)
+
+// runDevices implements the "devices" subcommand group; "devices scan" is
+// its only command so far.
+func runDevices(args []string) int {
+	if len(args) == 0 || args[0] != "scan" {
+		fmt.Fprintln(os.Stderr, "usage: devices scan [flags]")
+		return 2
+	}
+	return runDevicesScan(args[1:])
+}
+
+// runDevicesScan implements "devices scan": it lists the gloves (and with
+// -all every other device) advertising nearby, using the server's BLE
+// configuration from the environment. Stop the server first; an adapter
+// runs one scan at a time.
+func runDevicesScan(args []string) int {
+	fs := flag.NewFlagSet("devices scan", flag.ExitOnError)
+	duration := fs.Duration("duration", 10*time.Second, "how long to scan")
+	all := fs.Bool("all", false, "list every device, not only gloves and heart-rate straps")
+	fs.Parse(args)
+	if fs.NArg() != 0 || *duration <= 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := server.ConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "devices scan: %v\n", err)
+		return 1
+	}
+	central, err := server.NewCentral(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "devices scan: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	if err := central.Enable(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "devices scan: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Scanning for %s...\n", *duration)
+	var mu sync.Mutex
+	seen := make(map[string]ble.Advertisement)
+	scanCtx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+	err = central.ScanDevices(scanCtx, func(ad ble.Advertisement) {
+		if !*all && !ad.Glove && !ad.HeartRate {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := seen[ad.Address.String()]; !ok {
+			fmt.Printf("Found %s at %s\n", deviceName(ad), ad.Address.String())
+		}
+		seen[ad.Address.String()] = ad // latest RSSI
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "devices scan: %v\n", err)
+		return 1
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) == 0 {
+		fmt.Println("No devices found")
+		return 0
+	}
+	ads := make([]ble.Advertisement, 0, len(seen))
+	for _, ad := range seen {
+		ads = append(ads, ad)
+	}
+	sort.Slice(ads, func(i, j int) bool { return ads[i].RSSI > ads[j].RSSI })
+
+	pairing := central.Pairing()
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tADDRESS\tRSSI\tKIND")
+	for _, ad := range ads {
+		kind := ""
+		switch {
+		case ad.Glove:
+			kind = ad.Hand.String() + " glove"
+			for _, hand := range []ble.Hand{ble.LeftHand, ble.RightHand} {
+				if g, ok := pairing.Get(hand); ok && strings.EqualFold(g.Address, ad.Address.String()) {
+					kind += " (paired " + hand.String() + ")"
+				}
+			}
+		case ad.HeartRate:
+			kind = "heart-rate strap"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", deviceName(ad), ad.Address.String(), ad.RSSI, kind)
+	}
+	tw.Flush()
+	return 0
+}
+
+// deviceName returns an advertised name, or a placeholder for devices that
+// advertise none.
+func deviceName(ad ble.Advertisement) string {
+	if ad.Name == "" {
+		return "(unnamed)"
+	}
+	return ad.Name
+}
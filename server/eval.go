@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/benchmark"
+)
+
+// defaultCorpusDir is where the bundled benchmark corpus lives.
+const defaultCorpusDir = "corpus"
+
+// runEval implements the "eval" subcommand: it replays the benchmark corpus
+// through the analyzer and prints detection and classification scores.
+func runEval(args []string) int {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	corpusDir := fs.String("corpus", defaultCorpusDir, "benchmark corpus directory")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	model := fs.String("model", "", "decision tree model to classify with (default: heuristic)")
+	fs.Parse(args)
+
+	var classifier analytics.Classifier
+	if *model != "" {
+		tree, err := analytics.LoadDecisionTree(*model)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+			return 1
+		}
+		classifier = tree
+	}
+
+	corpus, err := benchmark.LoadCorpus(*corpusDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+		return 1
+	}
+	report, err := benchmark.Evaluate(corpus, classifier)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+		return 1
+	}
+	return 0
+}
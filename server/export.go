@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/server"
+	"boxing-analytics/storage"
+)
+
+// runExport implements the "export" subcommand: it writes a stored session
+// as JSON (everything the server saved) or CSV (one row per punch).
+func runExport(args []string) int {
+	cfg, err := server.ConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dataDir := fs.String("data", cfg.DataDir, "data directory holding the sessions (DATA_DIR)")
+	format := fs.String("format", "json", "output format: json or csv")
+	out := fs.String("o", "", "output file (default: stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: export [flags] session-id")
+		fmt.Fprintln(fs.Output(), "CSV holds the punches the session kept, the last 50 per hand.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || (*format != "json" && *format != "csv") {
+		fs.Usage()
+		return 2
+	}
+
+	// NewStore would create a mistyped directory
+	if _, err := os.Stat(*dataDir); err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+	store, err := storage.NewStore(*dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+	sess, err := store.Get(fs.Arg(0))
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "export: session %s not found in %s\n", fs.Arg(0), *dataDir)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "csv" {
+		err = writePunchCSV(w, sess)
+	} else {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(sess)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writePunchCSV writes a session's punches, left hand first.
+func writePunchCSV(w io.Writer, sess *storage.Session) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "hand", "count", "ts", "type", "force", "rotation_z", "rfd", "duration_ms", "retraction", "contact"})
+	if sess.State != nil {
+		for _, hand := range []*analytics.HandState{sess.State.Left, sess.State.Right} {
+			if hand == nil {
+				continue
+			}
+			for _, p := range hand.RecentPunches {
+				cw.Write([]string{
+					strconv.Itoa(p.ID),
+					p.Hand,
+					strconv.Itoa(p.Count),
+					strconv.FormatInt(p.Timestamp, 10),
+					string(p.Type),
+					strconv.FormatFloat(p.Force, 'f', -1, 64),
+					strconv.FormatFloat(p.RotationZ, 'f', -1, 64),
+					strconv.FormatFloat(p.RFD, 'f', -1, 64),
+					strconv.FormatInt(p.DurationMS, 10),
+					strconv.FormatFloat(p.Retraction, 'f', -1, 64),
+					strconv.FormatBool(p.Contact),
+				})
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,336 @@
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"boxing-analytics/validation"
+)
+
+// ErrCampaignNotFound is returned when a campaign ID does not exist.
+var ErrCampaignNotFound = errors.New("campaign not found")
+
+// Campaign statuses
+const (
+	CampaignActive    = "active"
+	CampaignPaused    = "paused" // a device failed to update, see ResumeCampaign
+	CampaignCompleted = "completed"
+	CampaignCancelled = "cancelled"
+)
+
+// Per-device target statuses
+const (
+	TargetPending = "pending" // waiting for an earlier stage to finish
+	TargetQueued  = "queued"  // update offered to the device
+	TargetDone    = "done"    // device reports the target version
+	TargetFailed  = "failed"  // update reported as failed
+)
+
+// Campaign rolls a firmware version out across devices in stages, so a bad
+// build only reaches StageSize gloves before it is noticed: the first failed
+// update pauses the campaign.
+type Campaign struct {
+	ID          int               `json:"id"`
+	Version     string            `json:"firmware_version"`
+	URL         string            `json:"firmware_url,omitempty"`
+	StageSize   int               `json:"stage_size"`
+	Status      string            `json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	Targets     map[string]string `json:"targets"` // device address → target status
+	Order       []string          `json:"order"`   // rollout order of device addresses
+	Errors      map[string]string `json:"errors,omitempty"`
+}
+
+// CampaignRequest describes a new campaign.
+type CampaignRequest struct {
+	Version   string   `json:"firmware_version"`
+	URL       string   `json:"firmware_url"`
+	StageSize int      `json:"stage_size"` // devices per stage (0 = all at once)
+	Targets   []string `json:"targets"`    // device addresses (empty = whole fleet)
+}
+
+// Validate checks the version and stage size of a request, returning
+// validation.Errors listing every invalid field.
+func (req CampaignRequest) Validate() error {
+	var errs validation.Errors
+	if req.Version == "" {
+		errs.Add("firmware_version", fmt.Errorf("firmware_version is required"))
+	}
+	if req.StageSize < 0 {
+		errs.Add("stage_size", fmt.Errorf("stage_size must not be negative"))
+	}
+	return errs.Err()
+}
+
+// CreateCampaign queues a staged firmware update. Devices already running the
+// target version are marked done immediately. An invalid request or unknown
+// target devices fail with validation.Errors.
+func (r *Registry) CreateCampaign(req CampaignRequest) (Campaign, error) {
+	if err := req.Validate(); err != nil {
+		return Campaign{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs validation.Errors
+	for i, addr := range req.Targets {
+		if _, ok := r.devices[strings.ToUpper(addr)]; !ok {
+			errs.Add(fmt.Sprintf("targets[%d]", i), fmt.Errorf("%w: %s", ErrDeviceNotFound, addr))
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return Campaign{}, err
+	}
+
+	targets := req.Targets
+	if len(targets) == 0 {
+		for addr := range r.devices {
+			targets = append(targets, addr)
+		}
+		sort.Strings(targets)
+	}
+	if len(targets) == 0 {
+		errs.Add("targets", fmt.Errorf("no devices to update"))
+		return Campaign{}, errs
+	}
+
+	c := &Campaign{
+		Version:   req.Version,
+		URL:       req.URL,
+		StageSize: req.StageSize,
+		Status:    CampaignActive,
+		CreatedAt: time.Now(),
+		Targets:   make(map[string]string, len(targets)),
+		Errors:    make(map[string]string),
+	}
+	for _, addr := range targets {
+		addr = strings.ToUpper(addr)
+		d := r.devices[addr]
+		if _, dup := c.Targets[addr]; dup {
+			continue
+		}
+		c.Order = append(c.Order, addr)
+		if d.FirmwareVersion == req.Version {
+			c.Targets[addr] = TargetDone
+		} else {
+			c.Targets[addr] = TargetPending
+		}
+	}
+	r.nextID++
+	c.ID = r.nextID
+	c.advanceLocked()
+
+	r.campaigns = append(r.campaigns, c)
+	r.dirty = true
+	return c.clone(), nil
+}
+
+// Campaigns returns all campaigns, newest last.
+func (r *Registry) Campaigns() []Campaign {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	campaigns := make([]Campaign, 0, len(r.campaigns))
+	for _, c := range r.campaigns {
+		campaigns = append(campaigns, c.clone())
+	}
+	return campaigns
+}
+
+// Campaign returns a single campaign by ID.
+func (r *Registry) Campaign(id int) (Campaign, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c := r.campaignLocked(id)
+	if c == nil {
+		return Campaign{}, ErrCampaignNotFound
+	}
+	return c.clone(), nil
+}
+
+// CancelCampaign stops a campaign from queuing further devices.
+func (r *Registry) CancelCampaign(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.campaignLocked(id)
+	if c == nil {
+		return ErrCampaignNotFound
+	}
+	if c.Status == CampaignActive || c.Status == CampaignPaused {
+		c.Status = CampaignCancelled
+		r.dirty = true
+	}
+	return nil
+}
+
+// ResumeCampaign continues a paused campaign with its next stage once the
+// devices still queued have reported. Failed devices are not retried.
+func (r *Registry) ResumeCampaign(id int) (Campaign, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.campaignLocked(id)
+	if c == nil {
+		return Campaign{}, ErrCampaignNotFound
+	}
+	if c.Status != CampaignPaused {
+		return Campaign{}, fmt.Errorf("campaign %d is %s, not paused", id, c.Status)
+	}
+	c.Status = CampaignActive
+	c.advanceLocked()
+	r.dirty = true
+	return c.clone(), nil
+}
+
+// ReportUpdate records the outcome of an update on one device and queues the
+// next stage once the current one has finished. An invalid status or a device
+// outside the campaign fail with validation.Errors naming the field.
+func (r *Registry) ReportUpdate(id int, address, status, message string) error {
+	var errs validation.Errors
+	if status != TargetDone && status != TargetFailed {
+		errs.Add("status", fmt.Errorf("invalid status %q: must be 'done' or 'failed'", status))
+		return errs
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.campaignLocked(id)
+	if c == nil {
+		return ErrCampaignNotFound
+	}
+	address = strings.ToUpper(address)
+	if _, ok := c.Targets[address]; !ok {
+		errs.Add("address", fmt.Errorf("%w: %s is not part of campaign %d", ErrDeviceNotFound, address, id))
+		return errs
+	}
+	c.reportLocked(address, status, message)
+	if status == TargetDone {
+		if d, ok := r.devices[address]; ok {
+			d.FirmwareVersion = c.Version
+		}
+	}
+	r.dirty = true
+	return nil
+}
+
+// PendingUpdate returns the active campaign that has queued an update for a
+// device, if any.
+func (r *Registry) PendingUpdate(address string) (Campaign, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	address = strings.ToUpper(address)
+	for _, c := range r.campaigns {
+		if c.Status == CampaignActive && c.Targets[address] == TargetQueued {
+			return c.clone(), true
+		}
+	}
+	return Campaign{}, false
+}
+
+// campaignLocked finds a campaign by ID.
+// Must be called with r.mu held.
+func (r *Registry) campaignLocked(id int) *Campaign {
+	for _, c := range r.campaigns {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// clone returns a copy of c that shares no maps or slices with it, for
+// callers outside the registry lock.
+func (c *Campaign) clone() Campaign {
+	out := *c
+	out.Targets = make(map[string]string, len(c.Targets))
+	for addr, status := range c.Targets {
+		out.Targets[addr] = status
+	}
+	out.Errors = make(map[string]string, len(c.Errors))
+	for addr, msg := range c.Errors {
+		out.Errors[addr] = msg
+	}
+	out.Order = append([]string(nil), c.Order...)
+	if c.CompletedAt != nil {
+		at := *c.CompletedAt
+		out.CompletedAt = &at
+	}
+	return out
+}
+
+// reportLocked updates one target and advances the rollout. A failure
+// pauses the campaign; devices already queued may still report while it is
+// paused.
+// Must be called with the registry lock held.
+func (c *Campaign) reportLocked(address, status, message string) {
+	if c.Status != CampaignActive && c.Status != CampaignPaused {
+		return
+	}
+	current, ok := c.Targets[address]
+	if !ok || current == TargetDone {
+		return
+	}
+	c.Targets[address] = status
+	if message != "" {
+		if c.Errors == nil {
+			c.Errors = make(map[string]string) // none when loaded, see omitempty
+		}
+		c.Errors[address] = message
+	}
+	if status == TargetFailed {
+		c.Status = CampaignPaused
+		return
+	}
+	c.advanceLocked()
+}
+
+// advanceLocked queues the next stage when no device is still queued, and
+// marks the campaign completed when no device is left to update.
+// Must be called with the registry lock held.
+func (c *Campaign) advanceLocked() {
+	if c.Status != CampaignActive {
+		return
+	}
+
+	queued, pending := 0, 0
+	for _, status := range c.Targets {
+		switch status {
+		case TargetQueued:
+			queued++
+		case TargetPending:
+			pending++
+		}
+	}
+	if queued > 0 {
+		return // current stage still in progress
+	}
+	if pending == 0 {
+		now := time.Now()
+		c.Status = CampaignCompleted
+		c.CompletedAt = &now
+		return
+	}
+
+	stage := c.StageSize
+	if stage <= 0 {
+		stage = pending
+	}
+	for _, addr := range c.Order {
+		if stage == 0 {
+			break
+		}
+		if c.Targets[addr] == TargetPending {
+			c.Targets[addr] = TargetQueued
+			stage--
+		}
+	}
+}
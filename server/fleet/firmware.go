@@ -0,0 +1,163 @@
+package fleet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FirmwareExt is the extension of the firmware binaries a directory offers.
+const FirmwareExt = ".bin"
+
+// ErrFirmwareNotFound is returned when no firmware image has the requested
+// version.
+var ErrFirmwareNotFound = errors.New("firmware not found")
+
+// FirmwareImage is one firmware binary gloves can update to.
+type FirmwareImage struct {
+	Version  string    `json:"version"`
+	File     string    `json:"file"`
+	URL      string    `json:"url"` // path the image is served at
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	Modified time.Time `json:"modified"`
+}
+
+// FirmwareManifest lists the firmware images on offer, oldest version first.
+type FirmwareManifest struct {
+	Latest string          `json:"latest,omitempty"`
+	Images []FirmwareImage `json:"images"`
+}
+
+// ManifestCache builds the manifest of the firmware binaries in a
+// directory, hashing each binary only when it is new or its size or
+// modification time changed.
+type ManifestCache struct {
+	dir, urlPrefix string
+
+	mu     sync.Mutex
+	images map[string]FirmwareImage // by file name
+}
+
+// NewManifestCache returns a cache of the manifest of dir, whose binaries
+// are served under urlPrefix.
+func NewManifestCache(dir, urlPrefix string) *ManifestCache {
+	return &ManifestCache{dir: dir, urlPrefix: urlPrefix, images: make(map[string]FirmwareImage)}
+}
+
+// Manifest returns the manifest of the binaries in the directory. A binary
+// is named for its version, e.g. fighterlink-1.5.0.bin is version 1.5.0; a
+// missing directory offers none.
+func (c *ManifestCache) Manifest() (*FirmwareManifest, error) {
+	m := &FirmwareManifest{Images: []FirmwareImage{}}
+	entries, err := os.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read firmware dir: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != FirmwareExt {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, fmt.Errorf("read firmware: %w", err)
+		}
+		img, ok := c.images[e.Name()]
+		if !ok || img.Size != info.Size() || !img.Modified.Equal(info.ModTime()) {
+			if img, err = readImage(filepath.Join(c.dir, e.Name())); err != nil {
+				return nil, err
+			}
+			img.URL = path.Join(c.urlPrefix, e.Name())
+			c.images[e.Name()] = img
+		}
+		seen[e.Name()] = true
+		m.Images = append(m.Images, img)
+	}
+	for name := range c.images {
+		if !seen[name] {
+			delete(c.images, name)
+		}
+	}
+	sort.Slice(m.Images, func(i, j int) bool {
+		return compareVersions(m.Images[i].Version, m.Images[j].Version) < 0
+	})
+	if n := len(m.Images); n > 0 {
+		m.Latest = m.Images[n-1].Version
+	}
+	return m, nil
+}
+
+// Image returns the image of a version, "" = the latest.
+func (m *FirmwareManifest) Image(version string) (FirmwareImage, error) {
+	if version == "" {
+		version = m.Latest
+	}
+	for _, img := range m.Images {
+		if img.Version == version {
+			return img, nil
+		}
+	}
+	return FirmwareImage{}, fmt.Errorf("%w: version %q", ErrFirmwareNotFound, version)
+}
+
+// readImage hashes one firmware binary.
+func readImage(name string) (FirmwareImage, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return FirmwareImage{}, fmt.Errorf("read firmware: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return FirmwareImage{}, fmt.Errorf("read firmware: %w", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return FirmwareImage{}, fmt.Errorf("read firmware: %w", err)
+	}
+	base := strings.TrimSuffix(filepath.Base(name), FirmwareExt)
+	return FirmwareImage{
+		Version:  base[strings.LastIndex(base, "-")+1:],
+		File:     filepath.Base(name),
+		Size:     info.Size(),
+		SHA256:   hex.EncodeToString(h.Sum(nil)),
+		Modified: info.ModTime(),
+	}, nil
+}
+
+// compareVersions orders dotted versions part by part, numerically where
+// both parts are numbers, so 1.10.0 comes after 1.9.2.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, errX := strconv.Atoi(as[i])
+		y, errY := strconv.Atoi(bs[i])
+		switch {
+		case errX == nil && errY == nil && x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		case (errX != nil || errY != nil) && as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
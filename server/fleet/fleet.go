@@ -0,0 +1,244 @@
+// Package fleet tracks every glove a server has seen and manages staged
+// firmware update campaigns across them.
+package fleet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDeviceNotFound is returned when a device address is not in the registry.
+var ErrDeviceNotFound = errors.New("device not found")
+
+// Device is one glove known to the fleet registry.
+type Device struct {
+	Address         string    `json:"address"`
+	Name            string    `json:"name"`
+	Hand            string    `json:"hand"`
+	FirmwareVersion string    `json:"firmware_version,omitempty"`
+	HardwareRev     string    `json:"hardware_rev,omitempty"`
+	FirstSeen       time.Time `json:"first_seen"`
+	LastSeen        time.Time `json:"last_seen"`
+	Connections     int       `json:"connections"` // number of times the glove connected
+	Connected       bool      `json:"connected"`
+
+	// Battery wear indicators
+	Battery       uint8   `json:"battery"`
+	Charging      bool    `json:"charging"`
+	ChargeCycles  int     `json:"charge_cycles"`  // charger plug-in events observed
+	DischargeRate float64 `json:"discharge_rate"` // %/hour over the last discharge period
+
+	// Discharge tracking (persisted so rates survive restarts)
+	DischargeStart        time.Time `json:"discharge_start,omitempty"`
+	DischargeStartBattery uint8     `json:"discharge_start_battery,omitempty"`
+}
+
+// Registry is the persistent set of known devices and update campaigns.
+type Registry struct {
+	mu        sync.RWMutex
+	path      string
+	devices   map[string]*Device
+	campaigns []*Campaign
+	nextID    int
+	dirty     bool
+}
+
+// registryFile is the on-disk layout of a Registry.
+type registryFile struct {
+	Devices   []*Device   `json:"devices"`
+	Campaigns []*Campaign `json:"campaigns"`
+}
+
+// minDischargeWindow is the shortest discharge period used for a rate estimate.
+const minDischargeWindow = 10 * time.Minute
+
+// NewRegistry loads a registry from path, starting empty if it does not exist.
+func NewRegistry(path string) (*Registry, error) {
+	r := &Registry{path: path, devices: make(map[string]*Device)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read fleet: %w", err)
+	}
+
+	var file registryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode fleet: %w", err)
+	}
+	for _, d := range file.Devices {
+		d.Connected = false // nothing is connected until it is seen again
+		r.devices[d.Address] = d
+	}
+	r.campaigns = file.Campaigns
+	for _, c := range r.campaigns {
+		if c.ID > r.nextID {
+			r.nextID = c.ID
+		}
+	}
+	return r, nil
+}
+
+// Connected records that a glove connected.
+func (r *Registry) Connected(address, name, hand string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := r.deviceLocked(address)
+	d.Name = name
+	d.Hand = hand
+	d.Connected = true
+	d.Connections++
+	d.LastSeen = time.Now()
+	r.dirty = true
+}
+
+// Seen records a glove streaming without a connection, over Wi-Fi, by the
+// identity it discovered the server with.
+func (r *Registry) Seen(address, hand string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := r.deviceLocked(address)
+	if hand != "" {
+		d.Hand = hand
+	}
+	d.LastSeen = time.Now()
+	r.dirty = true
+}
+
+// Disconnected records that a glove disconnected.
+func (r *Registry) Disconnected(address string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d, ok := r.devices[strings.ToUpper(address)]; ok {
+		d.Connected = false
+		r.dirty = true
+	}
+}
+
+// UpdateBattery records a battery reading and updates wear indicators.
+func (r *Registry) UpdateBattery(address string, battery uint8, charging bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := r.deviceLocked(address)
+	now := time.Now()
+	d.LastSeen = now
+
+	if charging && !d.Charging {
+		d.ChargeCycles++
+		d.DischargeStart = time.Time{}
+	}
+	if !charging {
+		if d.DischargeStart.IsZero() || battery > d.DischargeStartBattery {
+			d.DischargeStart = now
+			d.DischargeStartBattery = battery
+		} else if elapsed := now.Sub(d.DischargeStart); elapsed >= minDischargeWindow {
+			drop := float64(d.DischargeStartBattery - battery)
+			d.DischargeRate = drop / elapsed.Hours()
+		}
+	}
+
+	d.Battery = battery
+	d.Charging = charging
+	r.dirty = true
+}
+
+// SetHand records the hand a glove is assigned to, e.g. after the hands
+// were swapped.
+func (r *Registry) SetHand(address, hand string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deviceLocked(address).Hand = hand
+	r.dirty = true
+}
+
+// SetFirmware records the firmware version and hardware revision of a glove,
+// completing any campaign step waiting for that version.
+func (r *Registry) SetFirmware(address, firmware, hardware string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := r.deviceLocked(address)
+	d.FirmwareVersion = firmware
+	d.HardwareRev = hardware
+	for _, c := range r.campaigns {
+		if c.Version == firmware {
+			c.reportLocked(d.Address, TargetDone, "")
+		}
+	}
+	r.dirty = true
+}
+
+// Devices returns all known devices ordered by address.
+func (r *Registry) Devices() []Device {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	devices := make([]Device, 0, len(r.devices))
+	for _, d := range r.devices {
+		devices = append(devices, *d)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Address < devices[j].Address })
+	return devices
+}
+
+// Device returns a single device by address.
+func (r *Registry) Device(address string) (Device, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.devices[strings.ToUpper(address)]
+	if !ok {
+		return Device{}, ErrDeviceNotFound
+	}
+	return *d, nil
+}
+
+// Save writes the registry to disk if it changed since the last save.
+func (r *Registry) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.dirty {
+		return nil
+	}
+
+	file := registryFile{Campaigns: r.campaigns}
+	for _, d := range r.devices {
+		file.Devices = append(file.Devices, d)
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal fleet: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0o644); err != nil {
+		return fmt.Errorf("write fleet: %w", err)
+	}
+	r.dirty = false
+	return nil
+}
+
+// deviceLocked returns the device for address, creating it if needed.
+// Must be called with r.mu held.
+func (r *Registry) deviceLocked(address string) *Device {
+	address = strings.ToUpper(address)
+	d, ok := r.devices[address]
+	if !ok {
+		now := time.Now()
+		d = &Device{Address: address, FirstSeen: now, LastSeen: now}
+		r.devices[address] = d
+	}
+	return d
+}
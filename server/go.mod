@@ -3,16 +3,19 @@ module boxing-analytics
 go 1.21
 
 require (
+	github.com/andybalholm/brotli v1.1.0
 	github.com/godbus/dbus/v5 v5.1.0
 	github.com/muka/go-bluetooth v0.0.0-20221213043340-85dc80edc4e1
+	github.com/sirupsen/logrus v1.9.3
+	go.bug.st/serial v1.6.2
 	tinygo.org/x/bluetooth v0.8.0
 )
 
 require (
+	github.com/creack/goselect v0.1.2 // indirect
 	github.com/fatih/structs v1.1.0 // indirect
 	github.com/go-ole/go-ole v1.2.6 // indirect
-	github.com/saltosystems/winrt-go v0.0.0-20240509164145-4f7860a3bd2b // indirect
-	github.com/sirupsen/logrus v1.9.3 // indirect
+	github.com/saltosystems/winrt-go v0.0.0-20230921082907-2ab5b7d431e1 // indirect
 	github.com/tinygo-org/cbgo v0.0.4 // indirect
 	golang.org/x/sys v0.19.0 // indirect
 )
@@ -0,0 +1,263 @@
+// Package goals tracks the weekly training targets athletes set themselves
+// and keeps a history of the weeks they met them.
+package goals
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"boxing-analytics/storage"
+	"boxing-analytics/validation"
+)
+
+// ErrNotFound is returned when an athlete has no weekly targets.
+var ErrNotFound = errors.New("no weekly goal")
+
+// weekLayout formats the Monday a week starts on.
+const weekLayout = "2006-01-02"
+
+// Targets are an athlete's weekly targets. Zero fields are not targeted.
+type Targets struct {
+	Punches  int     `json:"punches,omitempty"`
+	Minutes  float64 `json:"minutes,omitempty"` // time trained
+	Sessions int     `json:"sessions,omitempty"`
+
+	Since time.Time `json:"since"` // when the athlete first set a goal
+}
+
+// Validate checks that at least one target is set and none is negative,
+// returning validation.Errors listing every invalid field.
+func (t Targets) Validate() error {
+	var errs validation.Errors
+	if t.Punches < 0 {
+		errs.Add("punches", fmt.Errorf("invalid punches %d: must be positive", t.Punches))
+	}
+	if t.Minutes < 0 {
+		errs.Add("minutes", fmt.Errorf("invalid minutes %v: must be positive", t.Minutes))
+	}
+	if t.Sessions < 0 {
+		errs.Add("sessions", fmt.Errorf("invalid sessions %d: must be positive", t.Sessions))
+	}
+	if t.Punches == 0 && t.Minutes == 0 && t.Sessions == 0 {
+		errs.Add("punches", fmt.Errorf("set at least one of punches, minutes or sessions"))
+	}
+	return errs.Err()
+}
+
+// Progress is an athlete's week against their targets.
+type Progress struct {
+	Athlete    string  `json:"athlete"`
+	Week       string  `json:"week"` // Monday the week starts on, YYYY-MM-DD
+	Targets    Targets `json:"targets"`
+	Punches    int     `json:"punches"`
+	Minutes    float64 `json:"minutes"`
+	Sessions   int     `json:"sessions"`
+	Completion float64 `json:"completion"` // % of the targets met, each counting up to 100
+	Met        bool    `json:"met"`
+}
+
+// WeekStart returns the start of the week t falls in: Monday 00:00 in t's
+// location.
+func WeekStart(t time.Time) time.Time {
+	y, m, d := t.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// ComputeProgress adds up the athlete's sessions started in the week
+// beginning at week (see WeekStart) against their targets.
+func ComputeProgress(sessions []*storage.Session, athlete string, week time.Time, t Targets) Progress {
+	p := Progress{Athlete: athlete, Week: week.Format(weekLayout), Targets: t}
+	end := week.AddDate(0, 0, 7)
+	for _, sess := range sessions {
+		if sess.Athlete != athlete || sess.StartedAt.Before(week) || !sess.StartedAt.Before(end) {
+			continue
+		}
+		p.Sessions++
+		p.Minutes += sess.DurationSec / 60
+		if sess.State != nil {
+			p.Punches += sess.State.Combined.TotalPunches
+		}
+	}
+	p.Minutes = math.Round(p.Minutes*10) / 10
+
+	var met []float64
+	if t.Punches > 0 {
+		met = append(met, math.Min(float64(p.Punches)/float64(t.Punches), 1))
+	}
+	if t.Minutes > 0 {
+		met = append(met, math.Min(p.Minutes/t.Minutes, 1))
+	}
+	if t.Sessions > 0 {
+		met = append(met, math.Min(float64(p.Sessions)/float64(t.Sessions), 1))
+	}
+	var sum float64
+	for _, m := range met {
+		sum += m
+	}
+	if len(met) > 0 {
+		p.Completion = math.Round(sum/float64(len(met))*1000) / 10
+	}
+	p.Met = len(met) > 0 && p.Completion == 100
+	return p
+}
+
+// Store keeps the athletes' targets and the history of finished weeks in a
+// single JSON file.
+type Store struct {
+	mu   sync.RWMutex
+	path string
+	data storeData
+}
+
+// storeData is the file format of a Store.
+type storeData struct {
+	Targets map[string]Targets `json:"targets"` // athlete → targets
+	History []Progress         `json:"history"` // finished weeks, oldest first
+}
+
+// NewStore loads goals from path, starting empty if it does not exist.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, data: storeData{Targets: make(map[string]Targets)}}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read goals: %w", err)
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("decode goals: %w", err)
+	}
+	if s.data.Targets == nil {
+		s.data.Targets = make(map[string]Targets)
+	}
+	return s, nil
+}
+
+// Targets returns an athlete's weekly targets.
+func (s *Store) Targets(athlete string) (Targets, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.data.Targets[athlete]
+	if !ok {
+		return Targets{}, ErrNotFound
+	}
+	return t, nil
+}
+
+// Athletes returns the athletes with weekly targets, sorted.
+func (s *Store) Athletes() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	athletes := make([]string, 0, len(s.data.Targets))
+	for athlete := range s.data.Targets {
+		athletes = append(athletes, athlete)
+	}
+	sort.Strings(athletes)
+	return athletes
+}
+
+// SetTargets sets an athlete's weekly targets and writes the store to disk.
+func (s *Store) SetTargets(athlete string, t Targets) (Targets, error) {
+	if athlete == "" {
+		return Targets{}, fmt.Errorf("athlete is required")
+	}
+	if err := t.Validate(); err != nil {
+		return Targets{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t.Since = time.Now()
+	if existing, ok := s.data.Targets[athlete]; ok {
+		t.Since = existing.Since
+	}
+	s.data.Targets[athlete] = t
+	if err := s.saveLocked(); err != nil {
+		return Targets{}, err
+	}
+	return t, nil
+}
+
+// DeleteTargets removes an athlete's weekly targets. Their history stays.
+func (s *Store) DeleteTargets(athlete string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.Targets[athlete]; !ok {
+		return ErrNotFound
+	}
+	delete(s.data.Targets, athlete)
+	return s.saveLocked()
+}
+
+// History returns the finished weeks of an athlete ("" = all), oldest
+// first.
+func (s *Store) History(athlete string) []Progress {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := []Progress{}
+	for _, p := range s.data.History {
+		if athlete == "" || p.Athlete == athlete {
+			history = append(history, p)
+		}
+	}
+	return history
+}
+
+// Record adds a finished week to the history, replacing the athlete's
+// record of the same week, and writes the store to disk.
+func (s *Store) Record(p Progress) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, old := range s.data.History {
+		if old.Athlete == p.Athlete && old.Week == p.Week {
+			s.data.History[i] = p
+			return s.saveLocked()
+		}
+	}
+	s.data.History = append(s.data.History, p)
+	sort.SliceStable(s.data.History, func(i, j int) bool { return s.data.History[i].Week < s.data.History[j].Week })
+	return s.saveLocked()
+}
+
+// Recorded reports whether the athlete's week beginning at week is in the
+// history.
+func (s *Store) Recorded(athlete string, week time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w := week.Format(weekLayout)
+	for _, p := range s.data.History {
+		if p.Athlete == athlete && p.Week == w {
+			return true
+		}
+	}
+	return false
+}
+
+// saveLocked writes the targets and history to disk.
+// Must be called with s.mu held.
+func (s *Store) saveLocked() error {
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal goals: %w", err)
+	}
+	if err := os.WriteFile(s.path, raw, 0o644); err != nil {
+		return fmt.Errorf("write goals: %w", err)
+	}
+	return nil
+}
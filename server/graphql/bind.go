@@ -0,0 +1,144 @@
+package graphql
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Binder derives object types from Go structs, with the fields encoding/json
+// encodes under their JSON names, so that a query selects what the REST
+// API would return. Structs become objects named after their Go type,
+// prefixed with the package name if two share a name; maps become JSON.
+type Binder struct {
+	objects map[reflect.Type]*Object
+	names   map[string]bool
+}
+
+// NewBinder returns a Binder that has bound no types.
+func NewBinder() *Binder {
+	return &Binder{objects: make(map[reflect.Type]*Object), names: make(map[string]bool)}
+}
+
+// Object returns the object type bound to the struct v, or a pointer to
+// one, binding it on first use. Fields added to it extend the bound ones.
+func (b *Binder) Object(v interface{}) *Object {
+	return b.object(structType(reflect.TypeOf(v)), "")
+}
+
+// Named is Object with name for the object type instead of the Go type's.
+// It must be called before the type is bound.
+func (b *Binder) Named(v interface{}, name string) *Object {
+	return b.object(structType(reflect.TypeOf(v)), name)
+}
+
+// TypeOf returns the GraphQL type of values of t: non-null unless t is a
+// pointer, slice, map or interface.
+func (b *Binder) TypeOf(t reflect.Type) Type {
+	switch t {
+	case timeType:
+		return NonNullOf(String)
+	case rawMessageType:
+		return JSON
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return NonNullOf(Boolean)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return NonNullOf(Int)
+	case reflect.Float32, reflect.Float64:
+		return NonNullOf(Float)
+	case reflect.String:
+		return NonNullOf(String)
+	case reflect.Ptr:
+		return unwrapNonNull(b.TypeOf(t.Elem()))
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return String
+		}
+		return ListOf(b.TypeOf(t.Elem()))
+	case reflect.Array:
+		return NonNullOf(ListOf(b.TypeOf(t.Elem())))
+	case reflect.Struct:
+		return NonNullOf(b.object(t, ""))
+	}
+	return JSON // maps and interface{}
+}
+
+// object returns the object type of the struct t, binding it under name
+// ("" = from the Go type) on first use.
+func (b *Binder) object(t reflect.Type, name string) *Object {
+	if o, ok := b.objects[t]; ok {
+		return o
+	}
+	if name == "" {
+		name = "Object"
+		if t.Name() != "" {
+			name = exportedName(t.Name())
+		}
+		if b.names[name] && t.PkgPath() != "" {
+			name = exportedName(path.Base(t.PkgPath())) + name
+		}
+		for base, n := name, 2; b.names[name]; n++ {
+			name = base + strconv.Itoa(n)
+		}
+	}
+	b.names[name] = true
+	o := NewObject(name, "")
+	b.objects[t] = o // before the fields, for recursive types
+	b.addFields(o, t, nil)
+	return o
+}
+
+// addFields adds the JSON fields of the struct t, at index below the bound
+// struct, to o, inlining the fields of embedded structs.
+func (b *Binder) addFields(o *Object, t reflect.Type, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fieldIndex := append(index[:len(index):len(index)], i)
+		if f.Anonymous && name == "" && structType(f.Type).Kind() == reflect.Struct {
+			if f.IsExported() {
+				b.addFields(o, structType(f.Type), fieldIndex)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		o.AddField(name, &Field{Type: b.TypeOf(f.Type), index: fieldIndex})
+	}
+}
+
+// structType returns the struct type of t or of what it points to.
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// exportedName upper-cases the first letter of a type name.
+func exportedName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
@@ -0,0 +1,791 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// MaxDepth is how deeply the selections of a query may nest, which bounds
+// the work of queries following the cycles of a schema.
+const MaxDepth = 12
+
+// Request is a query and its variables, as sent to a GraphQL endpoint.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is the result of a query. Data is nil when the query could not
+// be executed, e.g. when it does not parse or asks for unknown fields.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is an error of a query, located in it or at Path in the result.
+type Error struct {
+	Message   string        `json:"message"`
+	Locations []Location    `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"` // field names and list indices
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Execute runs the query of req. Fields are resolved one at a time, in
+// the order they are selected.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{err}}
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{err}}
+	}
+	v := &validator{schema: s, doc: doc, vars: make(map[string]*varDef)}
+	v.validate(op)
+	if len(v.errs) > 0 {
+		return &Response{Errors: v.errs}
+	}
+	vars, errs := coerceVariables(op, req.Variables)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+
+	e := &executor{ctx: ctx, doc: doc, vars: vars}
+	data, ok := e.selectionSet(s.Query, nil, op.selections, nil)
+	resp := &Response{Data: data, Errors: e.errs}
+	if !ok {
+		resp.Data = json.RawMessage("null")
+	}
+	return resp
+}
+
+// operation returns the operation called name, which may be left out when
+// the document has only one.
+func (d *document) operation(name string) (*operation, *Error) {
+	if name == "" {
+		if len(d.operations) != 1 {
+			return nil, &Error{Message: "operationName is required for a document with several operations"}
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q", name)}
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+// validator checks an operation against the schema before it runs, so that
+// a query is rejected as a whole rather than failing halfway.
+type validator struct {
+	schema   *Schema
+	doc      *document
+	vars     map[string]*varDef
+	visiting map[string]bool // fragments being validated, to catch cycles
+	deep     bool            // MaxDepth exceeded, reported once
+	errs     []*Error
+}
+
+func (v *validator) errorf(loc Location, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}})
+}
+
+func (v *validator) validate(op *operation) {
+	for _, d := range op.vars {
+		if _, dup := v.vars[d.name]; dup {
+			v.errorf(d.loc, "There can be only one variable named \"$%s\"", d.name)
+			continue
+		}
+		v.vars[d.name] = d
+		t, ok := inputType(d.typ)
+		if !ok {
+			v.errorf(d.loc, "Variable \"$%s\" cannot be of type %q", d.name, d.typ)
+			continue
+		}
+		if d.def != nil {
+			if _, err := coerceLiteral(t, d.def, nil); err != nil {
+				v.errorf(d.loc, "Variable \"$%s\" has an invalid default value: %s", d.name, err)
+			}
+		}
+	}
+	v.directives(op.directives)
+	v.visiting = make(map[string]bool)
+	v.selections(v.schema.Query, op.selections, 1)
+}
+
+// selections checks the selections of an object type at depth.
+func (v *validator) selections(o *Object, sels []selection, depth int) {
+	if depth > MaxDepth {
+		if !v.deep {
+			v.deep = true
+			v.errorf(locationOf(sels[0]), "Query is nested more than %d levels deep", MaxDepth)
+		}
+		return
+	}
+	keys := make(map[string]string) // response key → field name
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *field:
+			v.directives(sel.directives)
+			if name, ok := keys[sel.alias]; ok && name != sel.name {
+				v.errorf(sel.loc, "Fields %q conflict because %s and %s are different fields", sel.alias, name, sel.name)
+			}
+			keys[sel.alias] = sel.name
+			v.field(o, sel, depth)
+		case *fragmentSpread:
+			v.directives(sel.directives)
+			f, ok := v.doc.fragments[sel.name]
+			if !ok {
+				v.errorf(sel.loc, "Unknown fragment %q", sel.name)
+				continue
+			}
+			if v.visiting[f.name] {
+				v.errorf(sel.loc, "Cannot spread fragment %q within itself", f.name)
+				continue
+			}
+			if f.on != o.Name {
+				v.errorf(sel.loc, "Fragment %q cannot be spread here as objects of type %q can never be of type %q", f.name, o.Name, f.on)
+				continue
+			}
+			v.visiting[f.name] = true
+			v.selections(o, f.selections, depth)
+			delete(v.visiting, f.name)
+		case *inlineFragment:
+			v.directives(sel.directives)
+			if sel.on != "" && sel.on != o.Name {
+				v.errorf(sel.loc, "Fragment cannot be spread here as objects of type %q can never be of type %q", o.Name, sel.on)
+				continue
+			}
+			v.selections(o, sel.selections, depth)
+		}
+	}
+}
+
+// field checks a selected field of o, its arguments and subselections.
+func (v *validator) field(o *Object, f *field, depth int) {
+	if f.name == "__typename" {
+		if len(f.args) > 0 || len(f.selections) > 0 {
+			v.errorf(f.loc, "Field \"__typename\" takes no arguments or subselections")
+		}
+		return
+	}
+	def := o.Field(f.name)
+	if def == nil && o == v.schema.Query && (f.name == "__schema" || f.name == "__type") {
+		v.errorf(f.loc, "Introspection field %q is not supported, the schema is published as SDL", f.name)
+		return
+	}
+	if def == nil {
+		v.errorf(f.loc, "Cannot query field %q on type %q", f.name, o.Name)
+		return
+	}
+	v.arguments(fmt.Sprintf("field %q", f.name), def.Args, f.args, f.loc)
+
+	switch t := namedType(def.Type).(type) {
+	case *Object:
+		if len(f.selections) == 0 {
+			v.errorf(f.loc, "Field %q of type %q must have a selection of subfields", f.name, def.Type)
+			return
+		}
+		v.selections(t, f.selections, depth+1)
+	default:
+		if len(f.selections) > 0 {
+			v.errorf(f.loc, "Field %q must not have a selection since type %q has no subfields", f.name, def.Type)
+		}
+	}
+}
+
+// arguments checks the arguments given to a field or directive.
+func (v *validator) arguments(of string, defs []Arg, args []*argument, loc Location) {
+	given := make(map[string]bool)
+	for _, a := range args {
+		def := findArg(defs, a.name)
+		if def == nil {
+			v.errorf(a.loc, "Unknown argument %q on %s", a.name, of)
+			continue
+		}
+		if given[a.name] {
+			v.errorf(a.loc, "There can be only one argument named %q", a.name)
+			continue
+		}
+		given[a.name] = true
+		v.value(def.Type, a.value, a.loc, fmt.Sprintf("Argument %q", a.name))
+	}
+	for _, def := range defs {
+		if _, required := def.Type.(*NonNull); required && def.Default == nil && !given[def.Name] {
+			v.errorf(loc, "Argument %q of type %q is required on %s", def.Name, def.Type, of)
+		}
+	}
+}
+
+// value checks a literal given where a t is expected; variables must be
+// declared with a type that fits.
+func (v *validator) value(t Type, val value, loc Location, what string) {
+	switch val := val.(type) {
+	case variable:
+		d, ok := v.vars[string(val)]
+		if !ok {
+			v.errorf(loc, "Variable \"$%s\" is not defined", val)
+			return
+		}
+		if !fits(d.typ, t, d.def != nil) {
+			v.errorf(loc, "Variable \"$%s\" of type %q used in position expecting type %q", val, d.typ, t)
+		}
+		return
+	case []value:
+		if list, ok := unwrapNonNull(t).(*List); ok {
+			for _, item := range val {
+				v.value(list.Of, item, loc, what)
+			}
+			return
+		}
+	}
+	if _, err := coerceLiteral(t, val, nil); err != nil {
+		v.errorf(loc, "%s has an invalid value: %s", what, err)
+	}
+}
+
+// directives checks @include and @skip; other directives are unknown.
+func (v *validator) directives(dirs []*directive) {
+	for _, d := range dirs {
+		if d.name != "include" && d.name != "skip" {
+			v.errorf(d.loc, "Unknown directive \"@%s\"", d.name)
+			continue
+		}
+		v.arguments("directive \"@"+d.name+"\"", conditionArgs, d.args, d.loc)
+	}
+}
+
+// conditionArgs are the arguments of @include and @skip.
+var conditionArgs = []Arg{{Name: "if", Type: NonNullOf(Boolean)}}
+
+// fits reports whether a variable declared as ref may be used where t is
+// expected; a nullable variable with a default fits a non-null position.
+func fits(ref *typeRef, t Type, hasDefault bool) bool {
+	if nn, ok := t.(*NonNull); ok {
+		if !ref.nonNull && !hasDefault {
+			return false
+		}
+		t = nn.Of
+	}
+	r := *ref
+	r.nonNull = false
+	switch t := t.(type) {
+	case *List:
+		return r.elem != nil && fits(r.elem, t.Of, false)
+	case *Scalar:
+		return r.name == t.Name
+	}
+	return false
+}
+
+// inputType resolves the type of a variable: a scalar or list of them.
+func inputType(ref *typeRef) (Type, bool) {
+	var t Type
+	if ref.elem != nil {
+		elem, ok := inputType(ref.elem)
+		if !ok {
+			return nil, false
+		}
+		t = ListOf(elem)
+	} else {
+		s, ok := inputScalars[ref.name]
+		if !ok {
+			return nil, false
+		}
+		t = s
+	}
+	if ref.nonNull {
+		t = NonNullOf(t)
+	}
+	return t, true
+}
+
+func findArg(defs []Arg, name string) *Arg {
+	for i := range defs {
+		if defs[i].Name == name {
+			return &defs[i]
+		}
+	}
+	return nil
+}
+
+func unwrapNonNull(t Type) Type {
+	if nn, ok := t.(*NonNull); ok {
+		return nn.Of
+	}
+	return t
+}
+
+func locationOf(sel selection) Location {
+	switch sel := sel.(type) {
+	case *field:
+		return sel.loc
+	case *fragmentSpread:
+		return sel.loc
+	case *inlineFragment:
+		return sel.loc
+	}
+	return Location{}
+}
+
+// ─── Input coercion ──────────────────────────────────────────────────────────
+
+// coerceVariables coerces the JSON values of an operation's variables to
+// their declared types, using defaults for those not given.
+func coerceVariables(op *operation, given map[string]interface{}) (map[string]interface{}, []*Error) {
+	vars := make(map[string]interface{})
+	var errs []*Error
+	for _, d := range op.vars {
+		t, _ := inputType(d.typ)
+		raw, ok := given[d.name]
+		if !ok {
+			if d.def != nil {
+				vars[d.name], _ = coerceLiteral(t, d.def, nil)
+			} else if _, required := t.(*NonNull); required {
+				errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" of required type %q was not provided", d.name, d.typ), Locations: []Location{d.loc}})
+			}
+			continue
+		}
+		v, err := coerceInput(t, raw)
+		if err != nil {
+			errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" got invalid value: %s", d.name, err), Locations: []Location{d.loc}})
+			continue
+		}
+		vars[d.name] = v
+	}
+	return vars, errs
+}
+
+// coerceInput coerces a JSON value to t.
+func coerceInput(t Type, v interface{}) (interface{}, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected non-null %s", t)
+		}
+		return coerceInput(nn.Of, v)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case *List:
+		items, ok := v.([]interface{})
+		if !ok {
+			items = []interface{}{v}
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			c, err := coerceInput(t.Of, item)
+			if err != nil {
+				return nil, fmt.Errorf("at index %d: %w", i, err)
+			}
+			out[i] = c
+		}
+		return out, nil
+	case *Scalar:
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				v = i
+			} else if f, err := n.Float64(); err == nil {
+				v = f
+			}
+		}
+		if f, ok := v.(float64); ok && f == math.Trunc(f) && t != Float && t != JSON {
+			v = int64(f)
+		}
+		return coerceScalar(t, v)
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+// coerceLiteral coerces a literal of a query to t, with variables taken
+// from vars.
+func coerceLiteral(t Type, val value, vars map[string]interface{}) (interface{}, error) {
+	if name, ok := val.(variable); ok {
+		return vars[string(name)], nil
+	}
+	if nn, ok := t.(*NonNull); ok {
+		if val == nil {
+			return nil, fmt.Errorf("expected non-null %s", t)
+		}
+		return coerceLiteral(nn.Of, val, vars)
+	}
+	if val == nil {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case *List:
+		items, ok := val.([]value)
+		if !ok {
+			items = []value{val}
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			c, err := coerceLiteral(t.Of, item, vars)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = c
+		}
+		return out, nil
+	case *Scalar:
+		if t == JSON {
+			return literalJSON(val, vars), nil
+		}
+		if e, ok := val.(enumValue); ok {
+			return nil, fmt.Errorf("%s cannot represent %s", t, e)
+		}
+		return coerceScalar(t, val)
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+// coerceScalar coerces an int64, float64, string or bool to s.
+func coerceScalar(s *Scalar, v interface{}) (interface{}, error) {
+	switch s {
+	case Int:
+		if n, ok := v.(int64); ok && n >= math.MinInt32 && n <= math.MaxInt32 {
+			return int(n), nil
+		}
+		return nil, fmt.Errorf("Int cannot represent %s", describe(v))
+	case Float:
+		switch n := v.(type) {
+		case int64:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+		return nil, fmt.Errorf("Float cannot represent %s", describe(v))
+	case String:
+		if str, ok := v.(string); ok {
+			return str, nil
+		}
+		return nil, fmt.Errorf("String cannot represent %s", describe(v))
+	case ID:
+		switch id := v.(type) {
+		case string:
+			return id, nil
+		case int64:
+			return fmt.Sprint(id), nil
+		}
+		return nil, fmt.Errorf("ID cannot represent %s", describe(v))
+	case Boolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("Boolean cannot represent %s", describe(v))
+	}
+	return v, nil
+}
+
+// literalJSON converts a literal to the JSON value it spells.
+func literalJSON(val value, vars map[string]interface{}) interface{} {
+	switch val := val.(type) {
+	case variable:
+		return vars[string(val)]
+	case enumValue:
+		return string(val)
+	case []value:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = literalJSON(item, vars)
+		}
+		return out
+	case []*argument:
+		out := make(map[string]interface{}, len(val))
+		for _, a := range val {
+			out[a.name] = literalJSON(a.value, vars)
+		}
+		return out
+	}
+	return val
+}
+
+// describe formats a value in an error message.
+func describe(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []*argument:
+		return "an object"
+	case []interface{}, []value:
+		return "a list"
+	}
+	return fmt.Sprint(v)
+}
+
+// ─── Execution ───────────────────────────────────────────────────────────────
+
+// executor resolves the fields of a validated operation.
+type executor struct {
+	ctx  context.Context
+	doc  *document
+	vars map[string]interface{}
+	errs []*Error
+}
+
+func (e *executor) errorf(path []interface{}, loc Location, format string, args ...interface{}) {
+	e.errs = append(e.errs, &Error{
+		Message:   fmt.Sprintf(format, args...),
+		Locations: []Location{loc},
+		Path:      append([]interface{}(nil), path...),
+	})
+}
+
+// selectionSet resolves the selections of object type o on source. ok is
+// false when a non-null field of it is null, which makes it null too.
+func (e *executor) selectionSet(o *Object, source interface{}, sels []selection, path []interface{}) (*result, bool) {
+	out := &result{}
+	var keys []string
+	fields := make(map[string][]*field)
+	e.collect(o, sels, &keys, fields, make(map[string]bool))
+	for _, key := range keys {
+		fs := fields[key]
+		f := fs[0]
+		fieldPath := append(path[:len(path):len(path)], key)
+		if f.name == "__typename" {
+			out.add(key, o.Name)
+			continue
+		}
+		def := o.Field(f.name)
+		v, ok := e.resolve(def, source, f, fieldPath)
+		if ok {
+			var sub []selection
+			for _, f := range fs {
+				sub = append(sub, f.selections...)
+			}
+			v, ok = e.complete(def.Type, v, sub, fieldPath, f.loc)
+		}
+		if !ok {
+			if _, nonNull := def.Type.(*NonNull); nonNull {
+				return nil, false
+			}
+			v = nil
+		}
+		out.add(key, v)
+	}
+	return out, true
+}
+
+// collect gathers the fields of sels by response key, in the order they are
+// first selected, following fragments and leaving out those skipped.
+func (e *executor) collect(o *Object, sels []selection, keys *[]string, fields map[string][]*field, spread map[string]bool) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *field:
+			if !e.included(sel.directives) {
+				continue
+			}
+			if _, ok := fields[sel.alias]; !ok {
+				*keys = append(*keys, sel.alias)
+			}
+			fields[sel.alias] = append(fields[sel.alias], sel)
+		case *fragmentSpread:
+			if !e.included(sel.directives) || spread[sel.name] {
+				continue
+			}
+			spread[sel.name] = true
+			e.collect(o, e.doc.fragments[sel.name].selections, keys, fields, spread)
+		case *inlineFragment:
+			if e.included(sel.directives) {
+				e.collect(o, sel.selections, keys, fields, spread)
+			}
+		}
+	}
+}
+
+// included evaluates @include and @skip.
+func (e *executor) included(dirs []*directive) bool {
+	for _, d := range dirs {
+		cond, _ := coerceLiteral(Boolean, d.args[0].value, e.vars)
+		if b, _ := cond.(bool); b != (d.name == "include") {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve returns the value of a field on source; ok is false when its
+// resolver failed.
+func (e *executor) resolve(def *Field, source interface{}, f *field, path []interface{}) (interface{}, bool) {
+	args := make(map[string]interface{})
+	for _, a := range def.Args {
+		if a.Default != nil {
+			args[a.Name] = a.Default
+		}
+	}
+	for _, a := range f.args {
+		if name, ok := a.value.(variable); ok {
+			if _, given := e.vars[string(name)]; !given {
+				continue // keep the default
+			}
+		}
+		args[a.name], _ = coerceLiteral(findArg(def.Args, a.name).Type, a.value, e.vars)
+	}
+	for _, a := range def.Args {
+		if _, required := a.Type.(*NonNull); required && args[a.Name] == nil {
+			e.errorf(path, f.loc, "Argument %q of non-null type %q must not be null", a.Name, a.Type)
+			return nil, false
+		}
+	}
+
+	if def.Resolve == nil {
+		return structField(source, def.index), true
+	}
+	v, err := def.Resolve(Params{Context: e.ctx, Source: source, Args: args})
+	if err != nil {
+		e.errorf(path, f.loc, "%s", err)
+		return nil, false
+	}
+	return v, true
+}
+
+// complete converts the resolved value of a field of type t to its result.
+// ok is false when it is null because of an error, already reported; a
+// nullable parent then takes the null, a non-null one passes it on.
+func (e *executor) complete(t Type, v interface{}, sels []selection, path []interface{}, loc Location) (interface{}, bool) {
+	if nn, ok := t.(*NonNull); ok {
+		r, ok := e.complete(nn.Of, v, sels, path, loc)
+		if !ok {
+			return nil, false
+		}
+		if r == nil {
+			e.errorf(path, loc, "Cannot return null for non-nullable field")
+			return nil, false
+		}
+		return r, true
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, true
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map) && rv.IsNil() {
+		return nil, true
+	}
+
+	switch t := t.(type) {
+	case *List:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.errorf(path, loc, "Expected a list, got %s", rv.Type())
+			return nil, false
+		}
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			item, ok := e.complete(t.Of, rv.Index(i).Interface(), sels, append(path[:len(path):len(path)], i), loc)
+			if !ok {
+				if _, nonNull := t.Of.(*NonNull); nonNull {
+					return nil, false
+				}
+			}
+			items[i] = item
+		}
+		return items, true
+	case *Object:
+		r, ok := e.selectionSet(t, v, sels, path)
+		if !ok {
+			return nil, false
+		}
+		return r, true
+	case *Scalar:
+		out, err := serialize(t, rv)
+		if err != nil {
+			e.errorf(path, loc, "%s", err)
+			return nil, false
+		}
+		return out, true
+	}
+	return nil, true
+}
+
+// structField returns the field at index of the struct source, or of the
+// struct it points to; nil for a nil pointer along the way.
+func structField(source interface{}, index []int) interface{} {
+	rv := reflect.ValueOf(source)
+	for _, i := range index {
+		if !rv.IsValid() {
+			return nil
+		}
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return nil
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(i)
+	}
+	return rv.Interface()
+}
+
+// serialize converts a scalar value for the JSON result.
+func serialize(s *Scalar, rv reflect.Value) (interface{}, error) {
+	if s == JSON {
+		return rv.Interface(), nil
+	}
+	if t, ok := rv.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("%s cannot represent %v", s, f)
+		}
+		return f, nil
+	case reflect.String:
+		return rv.String(), nil
+	}
+	return nil, fmt.Errorf("%s cannot represent a %s", s, rv.Type())
+}
+
+// result is an object of the response, which keeps its fields in the order
+// they were selected.
+type result struct {
+	keys   []string
+	values []interface{}
+}
+
+func (r *result) add(key string, v interface{}) {
+	r.keys = append(r.keys, key)
+	r.values = append(r.values, v)
+}
+
+// MarshalJSON encodes the fields in order.
+func (r *result) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		b.Write(k)
+		b.WriteByte(':')
+		v, err := json.Marshal(r.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,209 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testAuthor struct {
+	Name   string `json:"name"`
+	hidden int
+}
+
+type testBook struct {
+	ID      string         `json:"id"`
+	Title   string         `json:"title"`
+	Pages   int            `json:"pages"`
+	Rating  float64        `json:"rating,omitempty"`
+	Out     time.Time      `json:"out"`
+	Author  *testAuthor    `json:"author"`
+	Tags    []string       `json:"tags,omitempty"`
+	Extra   map[string]int `json:"extra,omitempty"`
+	Skipped string         `json:"-"`
+}
+
+// testSchema has books, a book by ID and fields that fail.
+func testSchema() *Schema {
+	books := []*testBook{
+		{ID: "1", Title: "Jab", Pages: 100, Rating: 4.5, Out: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Author: &testAuthor{Name: "Ana"}, Tags: []string{"a"}, Extra: map[string]int{"x": 1}},
+		{ID: "2", Title: "Hook", Pages: 200},
+	}
+	b := NewBinder()
+	book := b.Named(testBook{}, "Book")
+	book.AddField("error", &Field{
+		Type:    String,
+		Resolve: func(p Params) (interface{}, error) { return nil, errors.New("broken") },
+	})
+	book.AddField("similar", &Field{
+		Type:    NonNullOf(ListOf(NonNullOf(book))),
+		Resolve: func(p Params) (interface{}, error) { return books, nil },
+	})
+	book.AddField("required", &Field{
+		Type:    NonNullOf(String),
+		Resolve: func(p Params) (interface{}, error) { return nil, nil },
+	})
+
+	query := NewObject("Query", "")
+	query.AddField("books", &Field{
+		Type: NonNullOf(ListOf(NonNullOf(book))),
+		Args: []Arg{{Name: "limit", Type: Int, Default: 10}, {Name: "tags", Type: ListOf(String)}},
+		Resolve: func(p Params) (interface{}, error) {
+			limit := p.Int("limit")
+			if limit > len(books) {
+				limit = len(books)
+			}
+			return books[:limit], nil
+		},
+	})
+	query.AddField("book", &Field{
+		Type: book,
+		Args: []Arg{{Name: "id", Type: NonNullOf(ID)}},
+		Resolve: func(p Params) (interface{}, error) {
+			for _, bk := range books {
+				if bk.ID == p.String("id") {
+					return bk, nil
+				}
+			}
+			return nil, nil
+		},
+	})
+	query.AddField("echo", &Field{
+		Type: JSON,
+		Args: []Arg{{Name: "v", Type: JSON}, {Name: "f", Type: Float}, {Name: "b", Type: Boolean, Default: false}},
+		Resolve: func(p Params) (interface{}, error) {
+			return map[string]interface{}{"v": p.Args["v"], "f": p.Float("f"), "b": p.Bool("b")}, nil
+		},
+	})
+	return NewSchema(query)
+}
+
+// run executes a query and returns the response as JSON.
+func run(t *testing.T, query string, vars map[string]interface{}) (string, *Response) {
+	t.Helper()
+	resp := testSchema().Execute(context.Background(), Request{Query: query, Variables: vars})
+	out, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), resp
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		want  string
+	}{
+		{
+			name:  "bound fields in selection order",
+			query: `{ books { title id pages rating out } }`,
+			want:  `{"data":{"books":[{"title":"Jab","id":"1","pages":100,"rating":4.5,"out":"2020-01-02T03:04:05Z"},{"title":"Hook","id":"2","pages":200,"rating":0,"out":"0001-01-01T00:00:00Z"}]}}`,
+		},
+		{
+			name:  "nested objects, lists, maps and nulls",
+			query: `{ books { author { name } tags extra } }`,
+			want:  `{"data":{"books":[{"author":{"name":"Ana"},"tags":["a"],"extra":{"x":1}},{"author":null,"tags":null,"extra":null}]}}`,
+		},
+		{
+			name:  "aliases and typename",
+			query: `{ first: book(id: "1") { __typename t: title } none: book(id: 9) { title } }`,
+			want:  `{"data":{"first":{"__typename":"Book","t":"Jab"},"none":null}}`,
+		},
+		{
+			name:  "fragments merge",
+			query: `query { books(limit: 1) { ...A ... on Book { pages } id } } fragment A on Book { id title }`,
+			want:  `{"data":{"books":[{"id":"1","title":"Jab","pages":100}]}}`,
+		},
+		{
+			name:  "variables and defaults",
+			query: `query ($n: Int = 2, $id: ID!) { books(limit: $n) { id } book(id: $id) { title } }`,
+			vars:  map[string]interface{}{"id": "2"},
+			want:  `{"data":{"books":[{"id":"1"},{"id":"2"}],"book":{"title":"Hook"}}}`,
+		},
+		{
+			name:  "variables from JSON numbers",
+			query: `query ($n: Int) { books(limit: $n) { id } }`,
+			vars:  map[string]interface{}{"n": 1.0},
+			want:  `{"data":{"books":[{"id":"1"}]}}`,
+		},
+		{
+			name:  "include and skip",
+			query: `query ($yes: Boolean!) { books(limit: 1) { id @skip(if: $yes) title @include(if: $yes) pages @include(if: false) } }`,
+			vars:  map[string]interface{}{"yes": true},
+			want:  `{"data":{"books":[{"title":"Jab"}]}}`,
+		},
+		{
+			name:  "literal arguments",
+			query: `{ echo(v: {a: [1, "x", null]}, f: 2) }`,
+			want:  `{"data":{"echo":{"b":false,"f":2,"v":{"a":[1,"x",null]}}}}`,
+		},
+		{
+			name:  "field error nulls the field",
+			query: `{ book(id: "1") { title error } }`,
+			want:  `{"data":{"book":{"title":"Jab","error":null}},"errors":[{"message":"broken","locations":[{"line":1,"column":25}],"path":["book","error"]}]}`,
+		},
+		{
+			name:  "null in a non-null field nulls the parent",
+			query: `{ book(id: "1") { title required } }`,
+			want:  `{"data":{"book":null},"errors":[{"message":"Cannot return null for non-nullable field","locations":[{"line":1,"column":25}],"path":["book","required"]}]}`,
+		},
+		{
+			name:  "null propagates to the data through non-null lists",
+			query: `{ books { required } }`,
+			want:  `{"data":null,"errors":[{"message":"Cannot return null for non-nullable field","locations":[{"line":1,"column":11}],"path":["books",0,"required"]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := run(t, tt.query, tt.vars); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"syntax", `{ books {`, "Syntax Error"},
+		{"unknown field", `{ books { isbn } }`, `Cannot query field "isbn" on type "Book"`},
+		{"field left out of JSON", `{ books { Skipped } }`, `Cannot query field "Skipped" on type "Book"`},
+		{"unexported field", `{ books { author { hidden } } }`, `Cannot query field "hidden" on type "TestAuthor"`},
+		{"introspection", `{ __schema { types { name } } }`, `Introspection field "__schema" is not supported`},
+		{"type introspection", `{ __type(name: "Book") { name } }`, `Introspection field "__type" is not supported`},
+		{"missing subselection", `{ books }`, `Field "books" of type "[Book!]!" must have a selection of subfields`},
+		{"subselection of a scalar", `{ books { id { x } } }`, `Field "id" must not have a selection`},
+		{"unknown argument", `{ books(first: 1) { id } }`, `Unknown argument "first" on field "books"`},
+		{"missing required argument", `{ book { id } }`, `Argument "id" of type "ID!" is required on field "book"`},
+		{"argument of the wrong type", `{ books(limit: "x") { id } }`, `has an invalid value`},
+		{"undefined variable", `{ books(limit: $n) { id } }`, `Variable "$n" is not defined`},
+		{"variable of the wrong type", `query ($n: String) { books(limit: $n) { id } }`, `Variable "$n" of type "String" used in position expecting type "Int"`},
+		{"missing non-null variable", `query ($id: ID!) { book(id: $id) { id } }`, `Variable "$id"`},
+		{"unknown fragment", `{ books { ...F } }`, `Unknown fragment "F"`},
+		{"fragment cycle", `{ books { ...F } } fragment F on Book { ...G } fragment G on Book { ...F }`, `Cannot spread fragment`},
+		{"fragment on another type", `{ books { ...F } } fragment F on Query { books { id } }`, `can never be of type "Query"`},
+		{"conflicting aliases", `{ books { x: id x: title } }`, `Fields "x" conflict`},
+		{"unknown directive", `{ books @defer { id } }`, `Unknown directive "@defer"`},
+		{"several operations without a name", `query A { books { id } } query B { books { id } }`, "operationName"},
+		{"too deep", "{ books " + strings.Repeat("{ similar ", 2*MaxDepth) + "{ id" + strings.Repeat(" }", 2*MaxDepth+2), "nested more than"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, resp := run(t, tt.query, nil)
+			if resp.Data != nil {
+				t.Errorf("invalid query executed: %s", out)
+			}
+			if len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, tt.want) {
+				t.Errorf("errors %s, want one containing %q", out, tt.want)
+			}
+		})
+	}
+}
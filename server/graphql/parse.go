@@ -0,0 +1,729 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Location is a line and column (from 1) in a query.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// document is a parsed query.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	name       string
+	vars       []*varDef
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+type varDef struct {
+	name string
+	typ  *typeRef
+	def  value // nil = none
+	loc  Location
+}
+
+// typeRef is a type as written in a variable definition.
+type typeRef struct {
+	name    string   // of a named type, "" for a list
+	elem    *typeRef // of a list
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+type fragment struct {
+	name       string
+	on         string
+	selections []selection
+	loc        Location
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment.
+type selection interface{}
+
+type field struct {
+	alias      string // response key, the name when not aliased
+	name       string
+	args       []*argument
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	loc        Location
+}
+
+type inlineFragment struct {
+	on         string // "" = the enclosing type
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+type argument struct {
+	name  string
+	value value
+	loc   Location
+}
+
+type directive struct {
+	name string
+	args []*argument
+	loc  Location
+}
+
+// value is a literal: int64, float64, string, bool, nil (null), enumValue,
+// variable, []value or []*argument (an object).
+type value interface{}
+
+type (
+	enumValue string
+	variable  string
+)
+
+const byteOrderMark = "\uFEFF"
+
+// Token kinds
+const (
+	tokEOF = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  int
+	text  string // punctuator or name; value of a string
+	loc   Location
+	start int   // offset in the query
+	value value // of an int, float or string
+}
+
+// parser parses a query one token ahead.
+type parser struct {
+	src       string
+	pos       int
+	line      int
+	lineStart int
+	tok       token
+}
+
+// parse parses a query document.
+func parse(src string) (*document, *Error) {
+	p := &parser{src: src, line: 1}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &document{fragments: make(map[string]*fragment)}
+	if p.tok.kind == tokEOF {
+		return nil, p.errorf("Syntax Error: Unexpected end of query")
+	}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peek("{"):
+			op := &operation{loc: p.tok.loc}
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			op.selections = sels
+			doc.operations = append(doc.operations, op)
+		case p.tok.kind == tokName && p.tok.text == "query":
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.tok.kind == tokName && (p.tok.text == "mutation" || p.tok.text == "subscription"):
+			return nil, p.errorf("Only queries are supported, not %ss", p.tok.text)
+		case p.tok.kind == tokName && p.tok.text == "fragment":
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.fragments[f.name]; dup {
+				return nil, &Error{Message: fmt.Sprintf("There can be only one fragment named %q", f.name), Locations: []Location{f.loc}}
+			}
+			doc.fragments[f.name] = f
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	return doc, nil
+}
+
+func (p *parser) operation() (*operation, *Error) {
+	op := &operation{loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokName {
+		op.name = p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.peek(")") {
+			v, err := p.varDef()
+			if err != nil {
+				return nil, err
+			}
+			op.vars = append(op.vars, v)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	var err *Error
+	if op.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if op.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *parser) varDef() (*varDef, *Error) {
+	v := &varDef{loc: p.tok.loc}
+	if err := p.expect("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	v.name = name
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if v.typ, err = p.typeRef(); err != nil {
+		return nil, err
+	}
+	if p.peek("=") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if v.def, err = p.value(true); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (p *parser) typeRef() (*typeRef, *Error) {
+	t := &typeRef{}
+	if p.peek("[") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		elem, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		t.elem = elem
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t.name = name
+	}
+	if p.peek("!") {
+		t.nonNull = true
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (p *parser) fragment() (*fragment, *Error) {
+	f := &fragment{loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, &Error{Message: `A fragment cannot be named "on"`, Locations: []Location{f.loc}}
+	}
+	f.name = name
+	if err := p.keyword("on"); err != nil {
+		return nil, err
+	}
+	if f.on, err = p.name(); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	if f.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (p *parser) selectionSet() ([]selection, *Error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []selection
+	for !p.peek("}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, p.errorf("Syntax Error: Empty selection set")
+	}
+	return sels, p.advance()
+}
+
+func (p *parser) selection() (selection, *Error) {
+	loc := p.tok.loc
+	if !p.peek("...") {
+		return p.field()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokName && p.tok.text != "on" {
+		spread := &fragmentSpread{name: p.tok.text, loc: loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err *Error
+		spread.directives, err = p.directives()
+		return spread, err
+	}
+	inline := &inlineFragment{loc: loc}
+	if p.tok.kind == tokName {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		inline.on = name
+	}
+	var err *Error
+	if inline.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if inline.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return inline, nil
+}
+
+func (p *parser) field() (*field, *Error) {
+	f := &field{loc: p.tok.loc}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f.alias, f.name = name, name
+	if p.peek(":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if f.args, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// arguments parses the arguments in parentheses, if any.
+func (p *parser) arguments(constant bool) ([]*argument, *Error) {
+	if !p.peek("(") {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var args []*argument
+	for !p.peek(")") {
+		a := &argument{loc: p.tok.loc}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		a.name = name
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if a.value, err = p.value(constant); err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+	}
+	if len(args) == 0 {
+		return nil, p.errorf("Syntax Error: Empty argument list")
+	}
+	return args, p.advance()
+}
+
+func (p *parser) directives() ([]*directive, *Error) {
+	var dirs []*directive
+	for p.peek("@") {
+		d := &directive{loc: p.tok.loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		d.name = name
+		if d.args, err = p.arguments(false); err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+// value parses a literal; constant ones (defaults) may not hold variables.
+func (p *parser) value(constant bool) (value, *Error) {
+	tok := p.tok
+	switch {
+	case tok.kind == tokInt, tok.kind == tokFloat, tok.kind == tokString:
+		return tok.value, p.advance()
+	case tok.kind == tokName:
+		var v value
+		switch tok.text {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = enumValue(tok.text)
+		}
+		return v, p.advance()
+	case p.peek("$") && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return variable(name), err
+	case p.peek("["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []value{}
+		for !p.peek("]") {
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, p.advance()
+	case p.peek("{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		obj := []*argument{}
+		for !p.peek("}") {
+			a := &argument{loc: p.tok.loc}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			a.name = name
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if a.value, err = p.value(constant); err != nil {
+				return nil, err
+			}
+			obj = append(obj, a)
+		}
+		return obj, p.advance()
+	}
+	return nil, p.unexpected()
+}
+
+// peek reports whether the current token is the punctuator text.
+func (p *parser) peek(text string) bool {
+	return p.tok.kind == tokPunct && p.tok.text == text
+}
+
+// expect consumes the punctuator text.
+func (p *parser) expect(text string) *Error {
+	if !p.peek(text) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+// keyword consumes the name text.
+func (p *parser) keyword(text string) *Error {
+	if p.tok.kind != tokName || p.tok.text != text {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+// name consumes a name and returns it.
+func (p *parser) name() (string, *Error) {
+	if p.tok.kind != tokName {
+		return "", p.unexpected()
+	}
+	name := p.tok.text
+	return name, p.advance()
+}
+
+func (p *parser) unexpected() *Error {
+	if p.tok.kind == tokEOF {
+		return p.errorf("Syntax Error: Unexpected end of query")
+	}
+	return p.errorf("Syntax Error: Unexpected %q", p.src[p.tok.start:p.pos])
+}
+
+func (p *parser) errorf(format string, args ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{p.tok.loc}}
+}
+
+// advance reads the next token.
+func (p *parser) advance() *Error {
+	p.skipIgnored()
+	start := p.pos
+	p.tok = token{loc: Location{Line: p.line, Column: start - p.lineStart + 1}, start: start}
+	if p.pos == len(p.src) {
+		p.tok.kind = tokEOF
+		return nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok.kind, p.tok.text = tokPunct, "..."
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		p.pos++
+		p.tok.kind, p.tok.text = tokPunct, string(c)
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok.kind, p.tok.text = tokName, p.src[start:p.pos]
+	case c == '-' || isDigit(c):
+		return p.number()
+	case c == '"':
+		return p.string()
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		return p.errorf("Syntax Error: Unexpected character %q", r)
+	}
+	return nil
+}
+
+// skipIgnored skips whitespace, commas, comments and byte order marks.
+func (p *parser) skipIgnored() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == '\n':
+			p.pos++
+			p.line, p.lineStart = p.line+1, p.pos
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case strings.HasPrefix(p.src[p.pos:], byteOrderMark):
+			p.pos += len(byteOrderMark)
+		default:
+			return
+		}
+	}
+}
+
+// number reads an Int or Float token.
+func (p *parser) number() *Error {
+	start := p.pos
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() int {
+		n := 0
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+			n++
+		}
+		return n
+	}
+	float := false
+	if digits() == 0 {
+		return p.errorf("Syntax Error: Invalid number")
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.pos++
+		float = true
+		if digits() == 0 {
+			return p.errorf("Syntax Error: Invalid number")
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		float = true
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			return p.errorf("Syntax Error: Invalid number")
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == '_' || p.src[p.pos] == '.' || isLetter(p.src[p.pos])) {
+		return p.errorf("Syntax Error: Invalid number")
+	}
+
+	text := p.src[start:p.pos]
+	if float {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return p.errorf("Syntax Error: Invalid number %s", text)
+		}
+		p.tok.kind, p.tok.value = tokFloat, f
+		return nil
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return p.errorf("Syntax Error: Invalid number %s", text)
+	}
+	p.tok.kind, p.tok.value = tokInt, n
+	return nil
+}
+
+// string reads a String token, quoted or a """block""".
+func (p *parser) string() *Error {
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+		if end < 0 {
+			return p.errorf("Syntax Error: Unterminated string")
+		}
+		raw := p.src[p.pos+3 : p.pos+3+end]
+		for _, c := range raw {
+			if c == '\n' {
+				p.line++
+			}
+		}
+		if i := strings.LastIndexByte(raw, '\n'); i >= 0 {
+			p.lineStart = p.pos + 3 + i + 1
+		}
+		p.pos += 3 + end + 3
+		p.tok.kind, p.tok.value = tokString, strings.TrimSpace(raw)
+		return nil
+	}
+
+	var b strings.Builder
+	p.pos++
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			return p.errorf("Syntax Error: Unterminated string")
+		}
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			p.tok.kind, p.tok.value = tokString, b.String()
+			return nil
+		case '\\':
+			if p.pos+1 >= len(p.src) {
+				return p.errorf("Syntax Error: Unterminated string")
+			}
+			e := p.src[p.pos+1]
+			p.pos += 2
+			switch e {
+			case '"', '\\', '/':
+				b.WriteByte(e)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if p.pos+4 > len(p.src) {
+					return p.errorf("Syntax Error: Invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					return p.errorf("Syntax Error: Invalid unicode escape")
+				}
+				b.WriteRune(rune(r))
+				p.pos += 4
+			default:
+				return p.errorf("Syntax Error: Invalid escape \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
@@ -0,0 +1,128 @@
+package graphql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := parse(`
+		# comment
+		query Recent($n: Int = 5, $tags: [String!]!) @skip(if: false) {
+			latest: sessions(limit: $n, tags: $tags, min: -1.5e2, name: "a\"b\u00e9", on: true, none: null, kind: HEAVY, obj: {x: [1, 2]}) {
+				id
+				...Stats
+				... on Session @include(if: true) { notes }
+			}
+		}
+		fragment Stats on Session { state { total } }
+		{ other }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.operations) != 2 || len(doc.fragments) != 1 {
+		t.Fatalf("%d operations and %d fragments, want 2 and 1", len(doc.operations), len(doc.fragments))
+	}
+
+	op := doc.operations[0]
+	if op.name != "Recent" || len(op.directives) != 1 || op.directives[0].name != "skip" {
+		t.Errorf("operation %q with directives %v", op.name, op.directives)
+	}
+	if op.loc != (Location{Line: 3, Column: 3}) {
+		t.Errorf("operation at %+v, want 3:3", op.loc)
+	}
+	if len(op.vars) != 2 || op.vars[0].typ.String() != "Int" || op.vars[0].def != int64(5) || op.vars[1].typ.String() != "[String!]!" {
+		t.Errorf("variables %+v %+v", op.vars[0], op.vars[1])
+	}
+
+	f := op.selections[0].(*field)
+	if f.alias != "latest" || f.name != "sessions" {
+		t.Errorf("field %q aliased %q, want sessions as latest", f.name, f.alias)
+	}
+	want := map[string]value{
+		"limit": variable("n"),
+		"tags":  variable("tags"),
+		"min":   -150.0,
+		"name":  "a\"bé",
+		"on":    true,
+		"none":  nil,
+		"kind":  enumValue("HEAVY"),
+	}
+	for _, a := range f.args {
+		if a.name == "obj" {
+			obj := a.value.([]*argument)
+			if len(obj) != 1 || obj[0].name != "x" || !reflect.DeepEqual(obj[0].value, []value{int64(1), int64(2)}) {
+				t.Errorf("object argument %+v", obj)
+			}
+			continue
+		}
+		if w, ok := want[a.name]; !ok || !reflect.DeepEqual(a.value, w) {
+			t.Errorf("argument %s = %#v, want %#v", a.name, a.value, w)
+		}
+	}
+	if len(f.selections) != 3 {
+		t.Fatalf("%d selections, want 3", len(f.selections))
+	}
+	if s, ok := f.selections[1].(*fragmentSpread); !ok || s.name != "Stats" {
+		t.Errorf("second selection %#v, want the Stats spread", f.selections[1])
+	}
+	if in, ok := f.selections[2].(*inlineFragment); !ok || in.on != "Session" || len(in.directives) != 1 {
+		t.Errorf("third selection %#v, want an inline fragment on Session", f.selections[2])
+	}
+
+	if frag := doc.fragments["Stats"]; frag == nil || frag.on != "Session" {
+		t.Errorf("fragment %+v", frag)
+	}
+	if other := doc.operations[1]; other.name != "" || other.selections[0].(*field).name != "other" {
+		t.Errorf("anonymous operation %+v", other)
+	}
+}
+
+func TestParseBlockString(t *testing.T) {
+	doc, err := parse("{ f(s: \"\"\"\n  block \"quoted\"\n\"\"\") g }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sels := doc.operations[0].selections
+	if s := sels[0].(*field).args[0].value; s != `block "quoted"` {
+		t.Errorf("block string %q", s)
+	}
+	if g := sels[1].(*field); g.loc != (Location{Line: 3, Column: 6}) {
+		t.Errorf("field after the block string at %+v, want 3:6", g.loc)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		msg   string
+		loc   Location
+	}{
+		{"", "Syntax Error: Unexpected end of query", Location{1, 1}},
+		{"{ a", "Syntax Error", Location{1, 4}},
+		{"{ a(x: 1.) }", "Syntax Error: Invalid number", Location{1, 8}},
+		{"{ a(x: 01x) }", "Syntax Error: Invalid number", Location{1, 8}},
+		{"{ a(x: \"open) }", "Syntax Error: Unterminated string", Location{1, 8}},
+		{"{ a(x: \"\\q\") }", "Syntax Error: Invalid escape \\q", Location{1, 8}},
+		{"{ a(x: \"\\u12\") }", "Syntax Error: Invalid unicode escape", Location{1, 8}},
+		{"mutation { a }", "Only queries are supported, not mutations", Location{1, 1}},
+		{"subscription { a }", "Only queries are supported, not subscriptions", Location{1, 1}},
+		{"fragment F on T { a } fragment F on T { b }", `There can be only one fragment named "F"`, Location{1, 23}},
+		{"{ a(x: $v) }\n}", "Syntax Error", Location{2, 1}},
+	}
+	for _, tt := range tests {
+		_, err := parse(tt.query)
+		if err == nil {
+			t.Errorf("%q parsed", tt.query)
+			continue
+		}
+		if !strings.HasPrefix(err.Message, tt.msg) {
+			t.Errorf("%q: error %q, want %q", tt.query, err.Message, tt.msg)
+		}
+		if len(err.Locations) != 1 || err.Locations[0] != tt.loc {
+			t.Errorf("%q: error at %v, want %v", tt.query, err.Locations, tt.loc)
+		}
+	}
+}
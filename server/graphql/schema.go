@@ -0,0 +1,278 @@
+// Package graphql executes GraphQL queries against a schema of Go values.
+// It implements what a read-only API needs: queries with variables,
+// aliases, fragments and the @include and @skip directives. Mutations,
+// subscriptions, interfaces and introspection beyond __typename are not
+// supported: queries of __schema or __type fail validation, and the schema
+// is published as SDL instead (see Schema.SDL).
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Type is a GraphQL type: a *Scalar, *Object, *List or *NonNull.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type.
+type Scalar struct {
+	Name        string
+	Description string
+}
+
+// Built-in scalars and JSON, which holds any JSON value (maps of the Go
+// types).
+var (
+	Int     = &Scalar{Name: "Int"}
+	Float   = &Scalar{Name: "Float"}
+	String  = &Scalar{Name: "String"}
+	Boolean = &Scalar{Name: "Boolean"}
+	ID      = &Scalar{Name: "ID"}
+	JSON    = &Scalar{Name: "JSON", Description: "Any JSON value."}
+)
+
+// inputScalars are the scalars variables can be declared as, by name.
+var inputScalars = map[string]*Scalar{
+	Int.Name: Int, Float.Name: Float, String.Name: String, Boolean.Name: Boolean, ID.Name: ID, JSON.Name: JSON,
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+// List is a list of Of.
+type List struct {
+	Of Type
+}
+
+// ListOf returns the list type of t.
+func ListOf(t Type) *List { return &List{Of: t} }
+
+func (l *List) String() string { return "[" + l.Of.String() + "]" }
+
+// NonNull is Of without null.
+type NonNull struct {
+	Of Type
+}
+
+// NonNullOf returns the non-null type of t.
+func NonNullOf(t Type) *NonNull { return &NonNull{Of: t} }
+
+func (n *NonNull) String() string { return n.Of.String() + "!" }
+
+// Object is an object type: named fields, in the order they were added.
+type Object struct {
+	Name        string
+	Description string
+
+	fields map[string]*Field
+	order  []string
+}
+
+// NewObject returns an object type without fields.
+func NewObject(name, description string) *Object {
+	return &Object{Name: name, Description: description, fields: make(map[string]*Field)}
+}
+
+func (o *Object) String() string { return o.Name }
+
+// AddField adds a field to o, replacing any of the same name.
+func (o *Object) AddField(name string, f *Field) {
+	if _, ok := o.fields[name]; !ok {
+		o.order = append(o.order, name)
+	}
+	o.fields[name] = f
+}
+
+// Field returns the field of o called name, nil if there is none.
+func (o *Object) Field(name string) *Field {
+	return o.fields[name]
+}
+
+// Field is a field of an object type.
+type Field struct {
+	Type        Type
+	Description string
+	Args        []Arg
+	Resolve     ResolveFunc // nil = the struct field the object was bound to (see Binder)
+
+	index []int // of the struct field, for fields without Resolve
+}
+
+// Arg is an argument of a field. Arguments are scalars or lists of them.
+type Arg struct {
+	Name        string
+	Type        Type
+	Default     interface{} // used when the argument is left out, nil = none
+	Description string
+}
+
+// ResolveFunc returns the value of a field: a Go value of its type, e.g. a
+// struct or pointer to one for an object type and a slice for a list.
+type ResolveFunc func(p Params) (interface{}, error)
+
+// Params are what a field is resolved from.
+type Params struct {
+	Context context.Context
+	Source  interface{}            // parent object as resolved, e.g. a *T for a pointer; nil for Query fields
+	Args    map[string]interface{} // int, float64, string, bool or []interface{}; left out when not given and without default
+}
+
+// String returns a string argument, "" when not given.
+func (p Params) String(name string) string {
+	s, _ := p.Args[name].(string)
+	return s
+}
+
+// Int returns an Int argument, 0 when not given.
+func (p Params) Int(name string) int {
+	n, _ := p.Args[name].(int)
+	return n
+}
+
+// Float returns a Float argument, 0 when not given.
+func (p Params) Float(name string) float64 {
+	f, _ := p.Args[name].(float64)
+	return f
+}
+
+// Bool returns a Boolean argument, false when not given.
+func (p Params) Bool(name string) bool {
+	b, _ := p.Args[name].(bool)
+	return b
+}
+
+// Has reports whether an argument was given or has a default.
+func (p Params) Has(name string) bool {
+	v, ok := p.Args[name]
+	return ok && v != nil
+}
+
+// Schema is the types a query is executed against, reached from Query.
+type Schema struct {
+	Query *Object
+}
+
+// NewSchema returns the schema of the query type.
+func NewSchema(query *Object) *Schema {
+	return &Schema{Query: query}
+}
+
+// SDL returns the schema in the GraphQL schema definition language: the
+// object types in the order they are reached from Query, then the scalars
+// that are not built in.
+func (s *Schema) SDL() string {
+	var b strings.Builder
+	seen := map[*Object]bool{s.Query: true}
+	scalars := map[string]*Scalar{}
+	queue := []*Object{s.Query}
+	for len(queue) > 0 {
+		o := queue[0]
+		queue = queue[1:]
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		writeDescription(&b, "", o.Description)
+		fmt.Fprintf(&b, "type %s {\n", o.Name)
+		for _, name := range o.order {
+			f := o.fields[name]
+			writeDescription(&b, "  ", f.Description)
+			b.WriteString("  " + name)
+			if len(f.Args) > 0 {
+				writeArgs(&b, f.Args)
+				for _, a := range f.Args {
+					if s, ok := namedType(a.Type).(*Scalar); ok {
+						scalars[s.Name] = s
+					}
+				}
+			}
+			b.WriteString(": " + f.Type.String() + "\n")
+			switch t := namedType(f.Type).(type) {
+			case *Object:
+				if !seen[t] {
+					seen[t] = true
+					queue = append(queue, t)
+				}
+			case *Scalar:
+				scalars[t.Name] = t
+			}
+		}
+		b.WriteString("}\n")
+	}
+
+	names := make([]string, 0, len(scalars))
+	for name := range scalars {
+		if _, builtIn := inputScalars[name]; !builtIn || name == JSON.Name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString("\n")
+		writeDescription(&b, "", scalars[name].Description)
+		fmt.Fprintf(&b, "scalar %s\n", name)
+	}
+	return b.String()
+}
+
+// writeArgs writes the arguments of a field, one per line if any has a
+// description.
+func writeArgs(b *strings.Builder, args []Arg) {
+	described := false
+	defs := make([]string, len(args))
+	for i, a := range args {
+		defs[i] = a.Name + ": " + a.Type.String()
+		if a.Default != nil {
+			defs[i] += " = " + literal(a.Default)
+		}
+		described = described || a.Description != ""
+	}
+	if !described {
+		b.WriteString("(" + strings.Join(defs, ", ") + ")")
+		return
+	}
+	b.WriteString("(\n")
+	for i, a := range args {
+		writeDescription(b, "    ", a.Description)
+		b.WriteString("    " + defs[i] + "\n")
+	}
+	b.WriteString("  )")
+}
+
+// writeDescription writes a description string, if any, at indent.
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description != "" {
+		fmt.Fprintf(b, "%s%q\n", indent, description)
+	}
+}
+
+// literal formats a default value as a GraphQL literal.
+func literal(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = literal(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+// namedType strips the lists and non-nulls off t.
+func namedType(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *List:
+			t = w.Of
+		case *NonNull:
+			t = w.Of
+		default:
+			return t
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package health converts stored sessions into workout payloads for phone
+// fitness stores: Apple HealthKit and Android Health Connect (which Google
+// Fit data now goes through). A companion app or shortcut passes them to the
+// platform API as they are; identifiers are the platforms' own names.
+package health
+
+import (
+	"math"
+	"time"
+
+	"boxing-analytics/storage"
+)
+
+// Formats accepted by Export
+const (
+	FormatHealthKit     = "healthkit"
+	FormatHealthConnect = "healthconnect"
+)
+
+// DefaultWeightKg is the body weight energy is estimated for when the
+// athlete's is unknown.
+const DefaultWeightKg = 70
+
+// boxingMET is the metabolic equivalent of bag work (Compendium of Physical
+// Activities, "boxing, punching bag").
+const boxingMET = 5.5
+
+// Energy estimates the kilocalories burned over a session from its
+// duration, as MET × weight × hours.
+func Energy(sess *storage.Session, weightKg float64) float64 {
+	hours := sess.DurationSec / 3600
+	return math.Round(boxingMET*weightKg*hours*10) / 10
+}
+
+// ─── HealthKit ───────────────────────────────────────────────────────────────
+
+// HealthKitWorkout is an HKWorkout with its heart-rate samples.
+type HealthKitWorkout struct {
+	Workout HKWorkout  `json:"workout"`
+	Samples []HKSample `json:"samples"`
+}
+
+// HKWorkout mirrors HKWorkout's properties.
+type HKWorkout struct {
+	ActivityType      string            `json:"activityType"`
+	StartDate         time.Time         `json:"startDate"`
+	EndDate           time.Time         `json:"endDate"`
+	Duration          float64           `json:"duration"` // seconds
+	TotalEnergyBurned HKQuantity        `json:"totalEnergyBurned"`
+	Metadata          map[string]string `json:"metadata"`
+}
+
+// HKQuantity is a value in an HKUnit, e.g. "kcal" or "count/min".
+type HKQuantity struct {
+	Unit  string  `json:"unit"`
+	Value float64 `json:"value"`
+}
+
+// HKSample is an HKQuantitySample.
+type HKSample struct {
+	Type      string    `json:"type"`
+	Unit      string    `json:"unit"`
+	Value     float64   `json:"value"`
+	StartDate time.Time `json:"startDate"`
+	EndDate   time.Time `json:"endDate"`
+}
+
+// HealthKit builds the HealthKit payload for a session.
+func HealthKit(sess *storage.Session, weightKg float64) *HealthKitWorkout {
+	w := &HealthKitWorkout{
+		Workout: HKWorkout{
+			ActivityType:      "HKWorkoutActivityTypeBoxing",
+			StartDate:         sess.StartedAt,
+			EndDate:           sess.EndedAt,
+			Duration:          sess.DurationSec,
+			TotalEnergyBurned: HKQuantity{Unit: "kcal", Value: Energy(sess, weightKg)},
+			Metadata:          map[string]string{"HKExternalUUID": sess.ID},
+		},
+		Samples: []HKSample{},
+	}
+	for _, s := range sess.HeartRate {
+		at := sess.StartedAt.Add(time.Duration(s.T) * time.Millisecond)
+		w.Samples = append(w.Samples, HKSample{
+			Type:      "HKQuantityTypeIdentifierHeartRate",
+			Unit:      "count/min",
+			Value:     float64(s.BPM),
+			StartDate: at,
+			EndDate:   at,
+		})
+	}
+	return w
+}
+
+// ─── Health Connect ──────────────────────────────────────────────────────────
+
+// HealthConnectWorkout holds the Health Connect records for a session.
+type HealthConnectWorkout struct {
+	ExerciseSession     ExerciseSessionRecord      `json:"exerciseSession"`
+	TotalCaloriesBurned TotalCaloriesBurnedRecord  `json:"totalCaloriesBurned"`
+	HeartRate           *HeartRateRecord           `json:"heartRate,omitempty"` // nil without a strap
+	Metadata            HealthConnectRecordContext `json:"metadata"`
+}
+
+// ExerciseSessionRecord mirrors Health Connect's ExerciseSessionRecord.
+type ExerciseSessionRecord struct {
+	ExerciseType string    `json:"exerciseType"`
+	StartTime    time.Time `json:"startTime"`
+	EndTime      time.Time `json:"endTime"`
+	Title        string    `json:"title"`
+}
+
+// TotalCaloriesBurnedRecord mirrors TotalCaloriesBurnedRecord.
+type TotalCaloriesBurnedRecord struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Energy    struct {
+		Kilocalories float64 `json:"inKilocalories"`
+	} `json:"energy"`
+}
+
+// HeartRateRecord mirrors HeartRateRecord.
+type HeartRateRecord struct {
+	StartTime time.Time         `json:"startTime"`
+	EndTime   time.Time         `json:"endTime"`
+	Samples   []HeartRateSample `json:"samples"`
+}
+
+// HeartRateSample is one HeartRateRecord.Sample.
+type HeartRateSample struct {
+	Time           time.Time `json:"time"`
+	BeatsPerMinute int       `json:"beatsPerMinute"`
+}
+
+// HealthConnectRecordContext is the Metadata shared by the records, so that
+// importing a session twice updates it rather than duplicating it.
+type HealthConnectRecordContext struct {
+	ClientRecordID string `json:"clientRecordId"`
+}
+
+// HealthConnect builds the Health Connect payload for a session.
+func HealthConnect(sess *storage.Session, weightKg float64) *HealthConnectWorkout {
+	w := &HealthConnectWorkout{
+		ExerciseSession: ExerciseSessionRecord{
+			ExerciseType: "EXERCISE_TYPE_BOXING",
+			StartTime:    sess.StartedAt,
+			EndTime:      sess.EndedAt,
+			Title:        "Boxing",
+		},
+		Metadata: HealthConnectRecordContext{ClientRecordID: sess.ID},
+	}
+	w.TotalCaloriesBurned.StartTime = sess.StartedAt
+	w.TotalCaloriesBurned.EndTime = sess.EndedAt
+	w.TotalCaloriesBurned.Energy.Kilocalories = Energy(sess, weightKg)
+
+	if len(sess.HeartRate) > 0 {
+		w.HeartRate = &HeartRateRecord{StartTime: sess.StartedAt, EndTime: sess.EndedAt}
+		for _, s := range sess.HeartRate {
+			w.HeartRate.Samples = append(w.HeartRate.Samples, HeartRateSample{
+				Time:           sess.StartedAt.Add(time.Duration(s.T) * time.Millisecond),
+				BeatsPerMinute: s.BPM,
+			})
+		}
+	}
+	return w
+}
+
+// Export builds the payload for a session in format, reporting false for
+// an unknown format.
+func Export(sess *storage.Session, format string, weightKg float64) (interface{}, bool) {
+	switch format {
+	case FormatHealthKit:
+		return HealthKit(sess, weightKg), true
+	case FormatHealthConnect:
+		return HealthConnect(sess, weightKg), true
+	}
+	return nil, false
+}
@@ -0,0 +1,465 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+	"boxing-analytics/fleet"
+	"boxing-analytics/hub"
+	"boxing-analytics/profiles"
+)
+
+func alertsHandler(analyzer *analytics.Analyzer, rulesPath string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// /api/alerts addresses the collection, /api/alerts/{id} a single rule
+		idStr := strings.Trim(strings.TrimPrefix(r.URL.Path, apiV1+"/alerts"), "/")
+		if idStr == "" {
+			switch r.Method {
+			case http.MethodGet:
+				writeJSON(w, http.StatusOK, analyzer.AlertRules())
+			case http.MethodPost:
+				var rule analytics.AlertRule
+				if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+					invalidBody(w, err)
+					return
+				}
+				created, err := analyzer.AddAlertRule(rule)
+				if err != nil {
+					invalidRequest(w, err)
+					return
+				}
+				if err := saveAlertRules(analyzer, rulesPath); err != nil {
+					httpError(w, "Alert rule created but not saved", http.StatusInternalServerError)
+					return
+				}
+				log.Printf("Alert rule %d created", created.ID)
+				writeJSON(w, http.StatusCreated, created)
+			default:
+				httpError(w, "GET or POST only", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			httpError(w, "Invalid alert rule ID", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			rule, err := analyzer.GetAlertRule(id)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, rule)
+		case http.MethodPut:
+			var rule analytics.AlertRule
+			if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+				invalidBody(w, err)
+				return
+			}
+			updated, err := analyzer.UpdateAlertRule(id, rule)
+			if errors.Is(err, analytics.ErrAlertNotFound) {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				invalidRequest(w, err)
+				return
+			}
+			if err := saveAlertRules(analyzer, rulesPath); err != nil {
+				httpError(w, "Alert rule updated but not saved", http.StatusInternalServerError)
+				return
+			}
+			log.Printf("Alert rule %d updated", id)
+			writeJSON(w, http.StatusOK, updated)
+		case http.MethodDelete:
+			if err := analyzer.DeleteAlertRule(id); err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if err := saveAlertRules(analyzer, rulesPath); err != nil {
+				httpError(w, "Alert rule deleted but not saved", http.StatusInternalServerError)
+				return
+			}
+			log.Printf("Alert rule %d deleted", id)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			httpError(w, "GET, PUT, or DELETE only", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// LoadAlertRules restores alert rules saved by saveAlertRules.
+func LoadAlertRules(analyzer *analytics.Analyzer, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Alert rules load: %v", err)
+		}
+		return
+	}
+	var rules []analytics.AlertRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		log.Printf("Alert rules decode: %v", err)
+		return
+	}
+	analyzer.LoadAlertRules(rules)
+	log.Printf("Loaded %d alert rules", len(rules))
+}
+
+// saveAlertRules writes the analyzer's alert rules to disk.
+func saveAlertRules(analyzer *analytics.Analyzer, path string) error {
+	data, err := json.MarshalIndent(analyzer.AlertRules(), "", "  ")
+	if err != nil {
+		log.Printf("Alert rules marshal: %v", err)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Printf("Alert rules save: %v", err)
+		return err
+	}
+	return nil
+}
+
+func fleetHandler(registry *fleet.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, registry.Devices())
+	}
+}
+
+// bleAdminHandler troubleshoots the gloves' links without a restart: GET
+// /api/admin/ble dumps the central's diagnostics, and POST
+// /api/admin/ble/{rescan,disconnect,forget}?hand=left|right|both acts on
+// them. A disconnected glove is reconnected by the scanner; a forgotten one
+// is paired afresh.
+func bleAdminHandler(central *ble.Central, scanner *ble.Scanner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		action := strings.Trim(strings.TrimPrefix(r.URL.Path, apiV1+"/admin/ble"), "/")
+		if action == "" {
+			if r.Method != http.MethodGet {
+				httpError(w, "GET only", http.StatusMethodNotAllowed)
+				return
+			}
+			writeJSON(w, http.StatusOK, central.Diagnostics())
+			return
+		}
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var hands []ble.Hand
+		switch r.URL.Query().Get("hand") {
+		case "left":
+			hands = []ble.Hand{ble.LeftHand}
+		case "right":
+			hands = []ble.Hand{ble.RightHand}
+		case "both", "":
+			hands = []ble.Hand{ble.LeftHand, ble.RightHand}
+		default:
+			httpError(w, "Invalid hand: must be 'left', 'right', or 'both'", http.StatusBadRequest)
+			return
+		}
+
+		switch action {
+		case "rescan":
+			if scanner == nil {
+				httpError(w, "Scanner not running", http.StatusConflict)
+				return
+			}
+			if err := scanner.Rescan(); err != nil {
+				httpError(w, err.Error(), http.StatusConflict)
+				return
+			}
+			log.Println("Admin: BLE rescan")
+		case "disconnect":
+			for _, hand := range hands {
+				if err := central.Disconnect(hand); err != nil {
+					httpError(w, err.Error(), http.StatusBadGateway)
+					return
+				}
+				log.Printf("Admin: disconnected %s glove", hand)
+			}
+		case "forget":
+			for _, hand := range hands {
+				if err := central.ForgetGlove(hand); err != nil {
+					httpError(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				log.Printf("Admin: forgot %s glove", hand)
+			}
+		default:
+			httpError(w, "Invalid action: must be 'rescan', 'disconnect' or 'forget'", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+// bleLogHandler serves GET /api/debug/ble: the central's recent connection
+// lifecycle, oldest first, optionally only for ?hand=left|right.
+func bleLogHandler(central *ble.Central) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		entries := central.ConnLog()
+		if hand := r.URL.Query().Get("hand"); hand != "" {
+			if hand != "left" && hand != "right" {
+				httpError(w, "Invalid hand: must be 'left' or 'right'", http.StatusBadRequest)
+				return
+			}
+			kept := entries[:0]
+			for _, e := range entries {
+				if e.Hand == hand {
+					kept = append(kept, e)
+				}
+			}
+			entries = kept
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"entries": entries})
+	}
+}
+
+func clientsHandler(h *hub.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, h.Clients())
+	}
+}
+
+// campaignReport is the body of POST /api/fleet/campaigns/{id}/report, a
+// glove's progress in a rollout.
+type campaignReport struct {
+	Address string `json:"address"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+func campaignsHandler(registry *fleet.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// /api/fleet/campaigns, /api/fleet/campaigns/{id}, /api/fleet/campaigns/{id}/report,
+		// /api/fleet/campaigns/{id}/resume
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, apiV1+"/fleet/campaigns"), "/")
+		if rest == "" {
+			switch r.Method {
+			case http.MethodGet:
+				writeJSON(w, http.StatusOK, registry.Campaigns())
+			case http.MethodPost:
+				var req fleet.CampaignRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					invalidBody(w, err)
+					return
+				}
+				campaign, err := registry.CreateCampaign(req)
+				if err != nil {
+					invalidRequest(w, err)
+					return
+				}
+				log.Printf("Fleet: campaign %d created for firmware %s (%d devices)",
+					campaign.ID, campaign.Version, len(campaign.Targets))
+				writeJSON(w, http.StatusCreated, campaign)
+			default:
+				httpError(w, "GET or POST only", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		parts := strings.Split(rest, "/")
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			httpError(w, "Invalid campaign ID", http.StatusBadRequest)
+			return
+		}
+
+		if len(parts) == 2 && parts[1] == "report" {
+			if r.Method != http.MethodPost {
+				httpError(w, "POST only", http.StatusMethodNotAllowed)
+				return
+			}
+			var report campaignReport
+			if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+				invalidBody(w, err)
+				return
+			}
+			err := registry.ReportUpdate(id, report.Address, report.Status, report.Message)
+			if errors.Is(err, fleet.ErrCampaignNotFound) {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				invalidRequest(w, err)
+				return
+			}
+			campaign, _ := registry.Campaign(id)
+			writeJSON(w, http.StatusOK, campaign)
+			return
+		}
+		if len(parts) == 2 && parts[1] == "resume" {
+			if r.Method != http.MethodPost {
+				httpError(w, "POST only", http.StatusMethodNotAllowed)
+				return
+			}
+			campaign, err := registry.ResumeCampaign(id)
+			if errors.Is(err, fleet.ErrCampaignNotFound) {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				httpError(w, err.Error(), http.StatusConflict)
+				return
+			}
+			log.Printf("Fleet: campaign %d resumed", id)
+			writeJSON(w, http.StatusOK, campaign)
+			return
+		}
+		if len(parts) != 1 {
+			notFoundHandler(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			campaign, err := registry.Campaign(id)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, campaign)
+		case http.MethodDelete:
+			if err := registry.CancelCampaign(id); err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			log.Printf("Fleet: campaign %d cancelled", id)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			httpError(w, "GET or DELETE only", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func profilesHandler(profileStore *profiles.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// /api/profiles addresses the collection, /api/profiles/{id} a single profile
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, apiV1+"/profiles"), "/")
+		if id == "" {
+			switch r.Method {
+			case http.MethodGet:
+				writeJSON(w, http.StatusOK, profileStore.List(r.URL.Query().Get("guests") == "1"))
+			case http.MethodPost:
+				var profile profiles.Profile
+				if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+					invalidBody(w, err)
+					return
+				}
+				if _, err := profileStore.Get(profile.ID); err == nil {
+					httpError(w, "Profile already exists", http.StatusConflict)
+					return
+				}
+				created, err := profileStore.Put(profile)
+				if err != nil {
+					invalidRequest(w, err)
+					return
+				}
+				log.Printf("Profile %s created", created.ID)
+				writeJSON(w, http.StatusCreated, created)
+			default:
+				httpError(w, "GET or POST only", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			profile, err := profileStore.Get(id)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, profile)
+		case http.MethodPut:
+			if _, err := profileStore.Get(id); err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			var profile profiles.Profile
+			if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+				invalidBody(w, err)
+				return
+			}
+			profile.ID = id
+			updated, err := profileStore.Put(profile)
+			if err != nil {
+				invalidRequest(w, err)
+				return
+			}
+			log.Printf("Profile %s updated", id)
+			writeJSON(w, http.StatusOK, updated)
+		case http.MethodDelete:
+			if err := profileStore.Delete(id); err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			log.Printf("Profile %s deleted", id)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			httpError(w, "GET, PUT, or DELETE only", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// guestRequest is the optional body of POST /api/guest.
+type guestRequest struct {
+	Name   string `json:"name"`
+	Stance string `json:"stance"`
+}
+
+func guestHandler(profileStore *profiles.Store, ttl time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Body is optional: a walk-in may only give a name, or nothing at all
+		var req guestRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				invalidBody(w, err)
+				return
+			}
+		}
+
+		guest, err := profileStore.NewGuest(req.Name, req.Stance, ttl)
+		if err != nil {
+			invalidRequest(w, err)
+			return
+		}
+		log.Printf("Guest profile %s created (expires %s)", guest.ID, guest.ExpiresAt.Format(time.RFC3339))
+		writeJSON(w, http.StatusCreated, guest)
+	}
+}
@@ -0,0 +1,163 @@
+// Package httpapi implements the server's REST API over the analyzers, the
+// BLE central and the stores.
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+	"boxing-analytics/fleet"
+	"boxing-analytics/goals"
+	"boxing-analytics/hub"
+	"boxing-analytics/ingest"
+	"boxing-analytics/profiles"
+	"boxing-analytics/programs"
+	"boxing-analytics/replay"
+	"boxing-analytics/schedule"
+	"boxing-analytics/storage"
+)
+
+// apiV1 is where version 1 of the API is served. Breaking changes go to a
+// new version beside it; the unversioned /api/ paths stay aliases of v1 for
+// the dashboards deployed before the API was versioned.
+const apiV1 = "/api/v1"
+
+// legacyDeprecation is when the unversioned /api/ paths were deprecated, as
+// announced in their Deprecation header.
+var legacyDeprecation = time.Date(2026, time.October, 14, 0, 0, 0, 0, time.UTC)
+
+// storedTimelineResolution is the waveform resolution kept with saved sessions.
+const storedTimelineResolution = time.Second
+
+// Deps are the components the API serves.
+type Deps struct {
+	Analyzer *analytics.Analyzer
+	Opponent *analytics.Analyzer // Second athlete in sparring mode, nil otherwise
+	Central  *ble.Central
+	Scanner  *ble.Scanner // Reconnects the gloves, nil = none
+	Store    *storage.Store
+	Profiles *profiles.Store
+	Programs *programs.Store
+	Schedule *schedule.Store
+	Goals    *goals.Store
+	Fleet    *fleet.Registry
+	UDP      *ingest.UDPSource // Gloves on Wi-Fi, nil without UDP ingest
+	Hub      *hub.Hub
+	Recorder *replay.Recorder // nil unless raw sessions are recorded
+	Recovery Recovery         // Unfinished sessions on offer, nil = none
+
+	SessionSaved func(*storage.Session) // Called for each session a stop saved, nil = none
+
+	Gym            string          // Tags sessions recorded here
+	Units          analytics.Units // Default output units of the stored sessions
+	RecordingsDir  string          // Raw session recordings
+	AlertRulesPath string          // Where alert rules are persisted
+	FirmwareDir    string          // Firmware binaries served at /firmware/
+	GuestTTL       time.Duration   // Lifetime of guest profiles
+	Version        string          // Server version, reported in the OpenAPI document
+}
+
+// Register adds the /api/v1 routes, their unversioned /api aliases,
+// /firmware/ and /metrics to mux. Wrap the mux in Middleware so that errors
+// carry request IDs.
+func Register(mux *http.ServeMux, d Deps) {
+	history := graphqlSchema(d.Store, d.Profiles, d.Units)
+	manifests := fleet.NewManifestCache(d.FirmwareDir, firmwarePrefix)
+	mux.HandleFunc(apiV1+"/session/start", sessionStartHandler(d.Analyzer, d.Opponent, d.Profiles, d.Programs, d.Schedule, d.Recorder, d.Recovery))
+	mux.HandleFunc(apiV1+"/session/types", sessionTypesHandler(d.Analyzer))
+	mux.HandleFunc(apiV1+"/session/reaction", reactionHandler(d.Analyzer))
+	mux.HandleFunc(apiV1+"/session/program", sessionProgramHandler(d.Analyzer, d.Programs))
+	mux.HandleFunc(apiV1+"/session/reset", sessionResetHandler(d.Analyzer, d.Opponent))
+	mux.HandleFunc(apiV1+"/session/pause", sessionPauseHandler(d.Analyzer, d.Opponent))
+	mux.HandleFunc(apiV1+"/session/resume", sessionResumeHandler(d.Analyzer, d.Opponent))
+	mux.HandleFunc(apiV1+"/session/recovery", recoveryHandler(d.Recovery))
+	mux.HandleFunc(apiV1+"/session/recovery/", recoveryHandler(d.Recovery))
+	mux.HandleFunc(apiV1+"/session/stop", sessionStopHandler(d.Analyzer, d.Opponent, d.Store, d.Gym, d.Recorder, d.RecordingsDir, d.SessionSaved))
+	mux.HandleFunc(apiV1+"/sessions", sessionsHandler(d.Store, d.Profiles, d.RecordingsDir, d.Units))
+	mux.HandleFunc(apiV1+"/sessions/", sessionsHandler(d.Store, d.Profiles, d.RecordingsDir, d.Units))
+	mux.HandleFunc(apiV1+"/recalibrate", recalibrateHandler(d.Analyzer))
+	mux.HandleFunc(apiV1+"/calibrate", calibrateHandler(d.Central, d.Analyzer))
+	mux.HandleFunc(apiV1+"/threshold/auto", autoThresholdHandler(d.Analyzer))
+	mux.HandleFunc(apiV1+"/status", statusHandler(d.Central, d.UDP))
+	mux.HandleFunc(apiV1+"/device/", feedbackHandler(d.Central))
+	mux.HandleFunc(apiV1+"/device/swap", swapHandler(d.Central, d.Analyzer, d.Fleet))
+	mux.HandleFunc(apiV1+"/pairing", pairingHandler(d.Central))
+	mux.HandleFunc(apiV1+"/pairing/", pairingHandler(d.Central))
+	mux.HandleFunc(apiV1+"/profiles", profilesHandler(d.Profiles))
+	mux.HandleFunc(apiV1+"/profiles/", profilesHandler(d.Profiles))
+	mux.HandleFunc(apiV1+"/programs", programsHandler(d.Programs))
+	mux.HandleFunc(apiV1+"/programs/", programsHandler(d.Programs))
+	mux.HandleFunc(apiV1+"/schedule", scheduleHandler(d.Schedule, d.Programs))
+	mux.HandleFunc(apiV1+"/schedule/", scheduleHandler(d.Schedule, d.Programs))
+	mux.HandleFunc(apiV1+"/goals", goalsHandler(d.Goals, d.Store))
+	mux.HandleFunc(apiV1+"/goals/", goalsHandler(d.Goals, d.Store))
+	mux.HandleFunc(apiV1+"/guest", guestHandler(d.Profiles, d.GuestTTL))
+	mux.HandleFunc(apiV1+"/fleet", fleetHandler(d.Fleet))
+	mux.HandleFunc(apiV1+"/fleet/campaigns", campaignsHandler(d.Fleet))
+	mux.HandleFunc(apiV1+"/fleet/campaigns/", campaignsHandler(d.Fleet))
+	mux.HandleFunc(apiV1+"/fleet/ota", otaHandler(d.UDP, d.Fleet, manifests))
+	mux.HandleFunc("/firmware/", firmwareHandler(d.FirmwareDir, manifests))
+	mux.HandleFunc(apiV1+"/admin/clients", clientsHandler(d.Hub))
+	mux.HandleFunc(apiV1+"/admin/ble", bleAdminHandler(d.Central, d.Scanner))
+	mux.HandleFunc(apiV1+"/admin/ble/", bleAdminHandler(d.Central, d.Scanner))
+	mux.HandleFunc(apiV1+"/debug/ble", bleLogHandler(d.Central))
+	mux.HandleFunc("/metrics", metricsHandler(d.Hub))
+	mux.HandleFunc(apiV1+"/events/poll", pollHandler(d.Hub))
+	mux.HandleFunc(apiV1+"/ws/schema", wsSchemaHandler)
+	mux.HandleFunc(apiV1+"/alerts", alertsHandler(d.Analyzer, d.AlertRulesPath))
+	mux.HandleFunc(apiV1+"/alerts/", alertsHandler(d.Analyzer, d.AlertRulesPath))
+	mux.HandleFunc(apiV1+"/stats/patterns", patternsHandler(d.Store))
+	mux.HandleFunc(apiV1+"/stats/trends", trendsHandler(d.Store))
+	mux.HandleFunc(apiV1+"/session/timeline", timelineHandler(d.Analyzer, d.Store))
+	mux.HandleFunc(apiV1+"/session/heatmap", heatmapHandler(d.Analyzer, d.Store))
+	mux.HandleFunc(apiV1+"/session/rhythm", rhythmHandler(d.Analyzer))
+	mux.HandleFunc(apiV1+"/graphql", graphqlHandler(history))
+	mux.HandleFunc(apiV1+"/graphql/schema", graphqlSchemaHandler(history))
+	mux.HandleFunc(apiV1+"/openapi.json", openAPIHandler(d.Version))
+	mux.HandleFunc(apiV1+"/", notFoundHandler)
+	mux.HandleFunc("/api/", legacyHandler(mux))
+}
+
+// legacyHandler serves the unversioned /api/ paths as their v1 counterparts
+// on mux. The responses are marked deprecated and link to the v1 path.
+// Paths of other versions, e.g. /api/v2/ before it exists, are not found.
+func legacyHandler(mux http.Handler) http.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(legacyDeprecation.Unix(), 10)
+	return func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, "/api")
+		if isVersioned(rest) {
+			notFoundHandler(w, r)
+			return
+		}
+		path := apiV1 + rest
+		w.Header().Set("Deprecation", deprecation)
+		w.Header().Set("Link", "<"+path+`>; rel="successor-version"`)
+
+		v1 := r.Clone(r.Context())
+		v1.URL.Path, v1.URL.RawPath = path, ""
+		mux.ServeHTTP(w, v1)
+	}
+}
+
+// isVersioned reports whether a path below /api starts with a version
+// segment such as /v1.
+func isVersioned(rest string) bool {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(segment[1:])
+	return err == nil
+}
+
+// writeJSON encodes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,291 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+	"boxing-analytics/fleet"
+	"boxing-analytics/ingest"
+)
+
+func calibrateHandler(central *ble.Central, analyzer *analytics.Analyzer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var hands []ble.Hand
+		switch r.URL.Query().Get("hand") {
+		case "left":
+			hands = []ble.Hand{ble.LeftHand}
+		case "right":
+			hands = []ble.Hand{ble.RightHand}
+		case "both", "":
+			hands = []ble.Hand{ble.LeftHand, ble.RightHand}
+		default:
+			httpError(w, "Invalid hand: must be 'left', 'right', or 'both'", http.StatusBadRequest)
+			return
+		}
+
+		duration := ble.DefaultCalibrationDuration
+		if d := r.URL.Query().Get("duration"); d != "" {
+			sec, err := strconv.ParseFloat(d, 64)
+			if err != nil || sec < 1 || sec > 30 {
+				httpError(w, "Invalid duration: must be 1-30 seconds", http.StatusBadRequest)
+				return
+			}
+			duration = time.Duration(sec * float64(time.Second))
+		}
+
+		for _, hand := range hands {
+			if central.Calibrating(hand) {
+				httpError(w, "Calibration already running for the "+hand.String()+" glove", http.StatusConflict)
+				return
+			}
+		}
+
+		log.Printf("Calibration: hold gloves still for %.0fs", duration.Seconds())
+
+		// Calibrate requested gloves in parallel during the same stillness period
+		results := make(map[string]*calibrationResult)
+		busy := false
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, hand := range hands {
+			wg.Add(1)
+			go func(hand ble.Hand) {
+				defer wg.Done()
+				res := &calibrationResult{}
+				offsets, err := central.Calibrate(r.Context(), hand, duration)
+				if err != nil {
+					res.Error = err.Error()
+					log.Printf("Calibration failed for %s glove: %v", hand, err)
+				} else {
+					res.Offsets = &offsets
+					log.Printf("Calibration stored for %s glove", hand)
+				}
+				mu.Lock()
+				results[hand.String()] = res
+				busy = busy || errors.Is(err, ble.ErrCalibrating)
+				mu.Unlock()
+			}(hand)
+		}
+		wg.Wait()
+
+		w.Header().Set("Content-Type", "application/json")
+		if busy {
+			// Another run started between the check above and this one
+			w.WriteHeader(http.StatusConflict)
+		}
+		json.NewEncoder(w).Encode(results)
+	}
+}
+
+// calibrationResult is a glove's outcome in the response of POST
+// /api/calibrate, keyed by hand.
+type calibrationResult struct {
+	Offsets *ble.Offsets `json:"offsets,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// feedbackRequest is the body of POST /api/device/{hand}/feedback.
+type feedbackRequest struct {
+	BuzzMS    int    `json:"buzz_ms"`   // haptic buzz length, 0 = no buzz
+	Intensity uint8  `json:"intensity"` // buzz strength 1-255, 0 = full
+	LED       string `json:"led"`       // LED colour as "#rrggbb", "" = unchanged
+	LEDMS     int    `json:"led_ms"`    // how long to show the colour
+}
+
+// feedbackResult is a glove's outcome in the response of POST
+// /api/device/{hand}/feedback, keyed by hand.
+type feedbackResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func feedbackHandler(central *ble.Central) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// /api/device/{hand}/feedback
+		handStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, apiV1+"/device/"), "/")
+		if action != "feedback" {
+			notFoundHandler(w, r)
+			return
+		}
+		var hands []ble.Hand
+		switch handStr {
+		case "left":
+			hands = []ble.Hand{ble.LeftHand}
+		case "right":
+			hands = []ble.Hand{ble.RightHand}
+		case "both":
+			hands = []ble.Hand{ble.LeftHand, ble.RightHand}
+		default:
+			httpError(w, "Invalid hand: must be 'left', 'right', or 'both'", http.StatusBadRequest)
+			return
+		}
+
+		var req feedbackRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		if req.BuzzMS <= 0 && req.LED == "" {
+			httpError(w, "Nothing to do: set buzz_ms or led", http.StatusBadRequest)
+			return
+		}
+		var cmds [][]byte
+		if req.BuzzMS > 0 {
+			intensity := req.Intensity
+			if intensity == 0 {
+				intensity = 255
+			}
+			cmds = append(cmds, ble.BuzzCommand(time.Duration(req.BuzzMS)*time.Millisecond, intensity))
+		}
+		if req.LED != "" {
+			var red, green, blue uint8
+			if _, err := fmt.Sscanf(req.LED, "#%02x%02x%02x", &red, &green, &blue); err != nil || len(req.LED) != 7 {
+				httpError(w, "Invalid led: must be '#rrggbb'", http.StatusBadRequest)
+				return
+			}
+			cmds = append(cmds, ble.LEDCommand(red, green, blue, time.Duration(req.LEDMS)*time.Millisecond))
+		}
+
+		results := make(map[string]*feedbackResult)
+		for _, hand := range hands {
+			res := &feedbackResult{OK: true}
+			for _, cmd := range cmds {
+				if err := central.SendCommand(hand, cmd); err != nil {
+					res.OK, res.Error = false, err.Error()
+					break
+				}
+			}
+			results[hand.String()] = res
+		}
+		writeJSON(w, http.StatusOK, results)
+	}
+}
+
+// pairingHandler lists the remembered gloves on GET /api/pairing and forgets
+// them on DELETE /api/pairing/{left|right|both}, e.g. after swapping a glove.
+// With bonding enabled a connected glove is also unbonded.
+func pairingHandler(central *ble.Central) http.HandlerFunc {
+	pairing := central.Pairing()
+	return func(w http.ResponseWriter, r *http.Request) {
+		handStr := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, apiV1+"/pairing"), "/")
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, pairing.All())
+		case http.MethodDelete:
+			var hands []ble.Hand
+			switch handStr {
+			case "left":
+				hands = []ble.Hand{ble.LeftHand}
+			case "right":
+				hands = []ble.Hand{ble.RightHand}
+			case "both":
+				hands = []ble.Hand{ble.LeftHand, ble.RightHand}
+			default:
+				httpError(w, "Invalid hand: must be 'left', 'right', or 'both'", http.StatusBadRequest)
+				return
+			}
+			for _, hand := range hands {
+				if central.BondingEnabled() && central.IsConnected(hand) {
+					if err := central.Unbond(hand); err != nil {
+						httpError(w, err.Error(), http.StatusBadGateway)
+						return
+					}
+				}
+				if err := pairing.Forget(hand); err != nil {
+					httpError(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				log.Printf("Forgot paired %s glove", hand)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			httpError(w, "GET or DELETE only", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// swapHandler reassigns the gloves to the opposite hands when the left-named
+// glove is worn on the right hand (or gloves were swapped between athletes).
+func swapHandler(central *ble.Central, analyzer *analytics.Analyzer, registry *fleet.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := central.SwapHands(); err != nil {
+			httpError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		analyzer.SwapHands()
+		for _, hand := range []ble.Hand{ble.LeftHand, ble.RightHand} {
+			if glove := central.GetGlove(hand); glove != nil {
+				registry.SetHand(glove.Address.String(), hand.String())
+			}
+		}
+		log.Println("Gloves swapped between hands")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+func statusHandler(central *ble.Central, udp *ingest.UDPSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(DeviceStatus(central, udp))
+	}
+}
+
+// DeviceStatus reports the gloves' link states and, for connected gloves,
+// their details, as served by /api/status. With UDP ingest (udp not nil)
+// it lists the gloves streaming over Wi-Fi too, with their firmware update
+// progress.
+func DeviceStatus(central *ble.Central, udp *ingest.UDPSource) map[string]interface{} {
+	status := map[string]interface{}{
+		"left_connected":  central.IsConnected(ble.LeftHand),
+		"right_connected": central.IsConnected(ble.RightHand),
+	}
+	// Device details for connected gloves
+	for _, hand := range []ble.Hand{ble.LeftHand, ble.RightHand} {
+		status[hand.String()+"_state"] = central.LinkState(hand).String()
+		glove := central.ConnectedGlove(hand)
+		if glove == nil {
+			continue
+		}
+		device := map[string]interface{}{
+			"name":             glove.Name,
+			"address":          glove.Address.String(),
+			"adapter":          glove.Adapter,
+			"firmware_version": glove.FirmwareVersion,
+			"hardware_rev":     glove.HardwareRev,
+		}
+		if level, ok := central.GetBatteryLevel(hand); ok {
+			device["battery"] = level
+		}
+		status[hand.String()] = device
+	}
+	if udp != nil {
+		status["udp"] = udp.Devices()
+	}
+	return status
+}
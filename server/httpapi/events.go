@@ -0,0 +1,83 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"boxing-analytics/hub"
+	"boxing-analytics/wire"
+)
+
+// Long-poll timeouts: long enough to save requests, short enough for the
+// idle timeouts of common proxies
+const (
+	defaultPollTimeout = 25 * time.Second
+	maxPollTimeout     = 60 * time.Second
+)
+
+// pollResponse is the body of GET /api/events/poll.
+type pollResponse struct {
+	Seq    uint64            `json:"seq"`    // pass as since in the next poll
+	Events []json.RawMessage `json:"events"` // WebSocket messages, oldest first
+}
+
+// pollHandler serves the WebSocket event stream to clients that cannot keep
+// a WebSocket open: GET /api/events/poll?since=SEQ returns the events
+// published after SEQ, waiting up to timeout (e.g. "25s") for one when there
+// are none yet. Without since it waits for the next event.
+func pollHandler(h *hub.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		query := r.URL.Query()
+
+		since := h.Seq()
+		if v := query.Get("since"); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				httpError(w, "Invalid since", http.StatusBadRequest)
+				return
+			}
+			since = n
+		}
+		timeout := defaultPollTimeout
+		if v := query.Get("timeout"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 || d > maxPollTimeout {
+				httpError(w, "Invalid timeout: must be a duration up to 60s", http.StatusBadRequest)
+				return
+			}
+			timeout = d
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		events, seq := h.Events(ctx, since)
+		if r.Context().Err() != nil {
+			return // client gone
+		}
+
+		resp := pollResponse{Seq: seq, Events: make([]json.RawMessage, len(events))}
+		for i, ev := range events {
+			resp.Events[i] = ev
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// wsSchemaHandler serves GET /api/v1/ws/schema, the protobuf schema of the
+// binary WebSocket messages.
+func wsSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, "GET only", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(wire.Proto)
+}
@@ -0,0 +1,152 @@
+package httpapi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"boxing-analytics/fleet"
+	"boxing-analytics/ingest"
+)
+
+// firmwarePrefix is where firmware binaries are served.
+const firmwarePrefix = "/firmware/"
+
+// firmwareHandler serves the binaries in dir under /firmware/ for gloves to
+// download, and /firmware/manifest.json listing them with their versions
+// and SHA-256 hashes.
+func firmwareHandler(dir string, manifests *fleet.ManifestCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, firmwarePrefix)
+		if name == "manifest.json" {
+			manifest, err := manifests.Manifest()
+			if err != nil {
+				log.Printf("Firmware manifest: %v", err)
+				httpError(w, "Failed to read firmware", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, manifest)
+			return
+		}
+		// Binaries only, no listings or subdirectories
+		if filepath.Ext(name) != fleet.FirmwareExt || strings.ContainsAny(name, `/\`) {
+			httpError(w, "Not found", http.StatusNotFound)
+			return
+		}
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			httpError(w, "Not found", http.StatusNotFound)
+			return
+		}
+		http.ServeFile(w, r, path)
+	}
+}
+
+// otaRequest is the body of POST /api/fleet/ota.
+type otaRequest struct {
+	Device  string `json:"device"`   // glove address or hand
+	BaseURL string `json:"base_url"` // where the glove downloads from, "" = this server
+}
+
+// otaHandler lists the gloves streaming over UDP with their firmware update
+// progress (GET), and offers one the update a fleet campaign has queued for
+// it (POST {"device", "base_url"}). device is a UDP address or
+// "left"/"right", matched to the fleet by the identity the glove discovered
+// the server with; base_url, which the glove downloads from unless the
+// campaign has a firmware_url, defaults to the address the request was made
+// to. The server reports the outcome to the campaign.
+func otaHandler(udp *ingest.UDPSource, registry *fleet.Registry, manifests *fleet.ManifestCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			devices := []ingest.UDPDevice{}
+			if udp != nil {
+				devices = udp.Devices()
+			}
+			writeJSON(w, http.StatusOK, devices)
+			return
+		case http.MethodPost:
+		default:
+			httpError(w, "GET or POST only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req otaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		if req.Device == "" {
+			httpError(w, "device is required", http.StatusBadRequest)
+			return
+		}
+		if udp == nil {
+			httpError(w, ingest.ErrNotRunning.Error(), http.StatusConflict)
+			return
+		}
+		base := req.BaseURL
+		if base == "" {
+			base = "http://" + r.Host
+		} else if u, err := url.Parse(base); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			httpError(w, "Invalid base_url", http.StatusBadRequest)
+			return
+		}
+
+		device, err := udp.Device(req.Device)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if device.Identity == "" {
+			httpError(w, "Glove did not discover the server with an identity, so it is in no campaign", http.StatusConflict)
+			return
+		}
+		campaign, ok := registry.PendingUpdate(device.Identity)
+		if !ok {
+			httpError(w, "No active campaign has queued an update for "+device.Identity, http.StatusConflict)
+			return
+		}
+
+		manifest, err := manifests.Manifest()
+		if err != nil {
+			log.Printf("Firmware manifest: %v", err)
+			httpError(w, "Failed to read firmware", http.StatusInternalServerError)
+			return
+		}
+		image, err := manifest.Image(campaign.Version)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		sum, _ := hex.DecodeString(image.SHA256)
+		imageURL := campaign.URL
+		if imageURL == "" {
+			imageURL = strings.TrimSuffix(base, "/") + image.URL
+		}
+
+		device, err = udp.StartUpdate(device.Address, image.Version, imageURL, sum, campaign.ID)
+		switch {
+		case errors.Is(err, ingest.ErrUnknownDevice):
+			httpError(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, ingest.ErrUpdateInProgress), errors.Is(err, ingest.ErrNotRunning):
+			httpError(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			httpError(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		log.Printf("Fleet: firmware %s offered to %s glove (%s) for campaign %d", image.Version, device.Hand, device.Address, campaign.ID)
+		writeJSON(w, http.StatusAccepted, device)
+	}
+}
@@ -0,0 +1,77 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"boxing-analytics/goals"
+	"boxing-analytics/storage"
+)
+
+// goalsHandler serves weekly goals: /api/goals lists this week's progress
+// of every athlete with a goal, /api/goals/{athlete} reads an athlete's
+// goal, progress and history, sets it (PUT) or removes it (DELETE).
+func goalsHandler(goalStore *goals.Store, store *storage.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		athlete := strings.Trim(strings.TrimPrefix(r.URL.Path, apiV1+"/goals"), "/")
+		week := goals.WeekStart(time.Now())
+
+		switch {
+		case r.Method == http.MethodGet:
+			sessions, err := store.List()
+			if err != nil {
+				log.Printf("Session list: %v", err)
+				httpError(w, "Failed to load sessions", http.StatusInternalServerError)
+				return
+			}
+			if athlete == "" {
+				list := []goals.Progress{}
+				for _, a := range goalStore.Athletes() {
+					if targets, err := goalStore.Targets(a); err == nil {
+						list = append(list, goals.ComputeProgress(sessions, a, week, targets))
+					}
+				}
+				writeJSON(w, http.StatusOK, list)
+				return
+			}
+			targets, err := goalStore.Targets(athlete)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"targets": targets,
+				"week":    goals.ComputeProgress(sessions, athlete, week, targets),
+				"history": goalStore.History(athlete),
+			})
+		case athlete == "":
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+		case r.Method == http.MethodPut:
+			var targets goals.Targets
+			if err := json.NewDecoder(r.Body).Decode(&targets); err != nil {
+				invalidBody(w, err)
+				return
+			}
+			set, err := goalStore.SetTargets(athlete, targets)
+			if err != nil {
+				invalidRequest(w, err)
+				return
+			}
+			log.Printf("Weekly goal of %s set", athlete)
+			writeJSON(w, http.StatusOK, set)
+		case r.Method == http.MethodDelete:
+			if err := goalStore.DeleteTargets(athlete); err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			log.Printf("Weekly goal of %s removed", athlete)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			httpError(w, "GET, PUT, or DELETE only", http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/graphql"
+	"boxing-analytics/profiles"
+	"boxing-analytics/storage"
+)
+
+// historyGraph resolves the GraphQL schema of the stored history.
+type historyGraph struct {
+	store        *storage.Store
+	profileStore *profiles.Store
+	units        analytics.Units // default output units
+}
+
+// loadedSessionsKey is the context key of a query's loadedSessions.
+type loadedSessionsKey struct{}
+
+// loadedSessions are the stored sessions as loaded by the first field of a
+// query that needs all of them (patterns and trends), so that nested ones
+// (users { trend }) read the store once. Session lists are filtered in the
+// store instead, see storage.Store.Sessions.
+type loadedSessions struct {
+	done     bool
+	sessions []*storage.Session
+	err      error
+}
+
+// graphqlSchema builds the GraphQL schema over the stored sessions, their
+// punch events, the users (athlete profiles) and the aggregates of
+// /api/v1/stats. Objects are bound to the types the REST API encodes, so
+// their fields have the same snake_case names and units.
+func graphqlSchema(store *storage.Store, profileStore *profiles.Store, units analytics.Units) *graphql.Schema {
+	h := &historyGraph{store: store, profileStore: profileStore, units: units}
+	b := graphql.NewBinder()
+	user := b.Named(profiles.Profile{}, "User")
+	session := b.Object(storage.Session{})
+	nonNullList := func(t graphql.Type) graphql.Type {
+		return graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(t)))
+	}
+
+	sessionArgs := []graphql.Arg{
+		{Name: "tag", Type: graphql.String, Description: "only sessions tagged so"},
+		{Name: "type", Type: graphql.String, Description: "only sessions of this type, e.g. heavy_bag"},
+		{Name: "since", Type: graphql.String, Description: "only sessions started at or after this RFC 3339 time"},
+		{Name: "until", Type: graphql.String, Description: "only sessions started before this RFC 3339 time"},
+		{Name: "limit", Type: graphql.Int, Description: "at most this many sessions, null = all"},
+		{Name: "offset", Type: graphql.Int, Default: 0, Description: "newest sessions to skip"},
+		{Name: "units", Type: graphql.String, Description: "output units, e.g. imperial; default the athlete's"},
+	}
+	trendArgs := []graphql.Arg{
+		{Name: "metric", Type: graphql.String, Default: "avg_force"},
+		{Name: "gym", Type: graphql.String},
+		{Name: "window", Type: graphql.String, Default: "90d", Description: "days (90d) or a duration (36h) before now"},
+		{Name: "rolling", Type: graphql.Int, Default: storage.DefaultTrendRolling, Description: "sessions per rolling average"},
+	}
+	athleteArg := graphql.Arg{Name: "athlete", Type: graphql.String}
+
+	query := graphql.NewObject("Query", "The stored training history.")
+	query.AddField("sessions", &graphql.Field{
+		Type:        nonNullList(session),
+		Description: "Stored sessions, newest first.",
+		Args:        append([]graphql.Arg{athleteArg}, sessionArgs...),
+		Resolve: func(p graphql.Params) (interface{}, error) {
+			return h.sessions(p, p.String("athlete"))
+		},
+	})
+	query.AddField("session", &graphql.Field{
+		Type:        session,
+		Description: "A stored session, null if there is none with the ID.",
+		Args:        []graphql.Arg{{Name: "id", Type: graphql.NonNullOf(graphql.ID)}, sessionArgs[len(sessionArgs)-1]},
+		Resolve:     h.session,
+	})
+	query.AddField("users", &graphql.Field{
+		Type:        nonNullList(user),
+		Description: "Athlete profiles, ordered by ID.",
+		Args:        []graphql.Arg{{Name: "guests", Type: graphql.Boolean, Default: false, Description: "include guest profiles"}},
+		Resolve: func(p graphql.Params) (interface{}, error) {
+			return profileStore.List(p.Bool("guests")), nil
+		},
+	})
+	query.AddField("user", &graphql.Field{
+		Type:        user,
+		Description: "An athlete profile, null if there is none with the ID.",
+		Args:        []graphql.Arg{{Name: "id", Type: graphql.NonNullOf(graphql.ID)}},
+		Resolve: func(p graphql.Params) (interface{}, error) {
+			profile, err := profileStore.Get(p.String("id"))
+			if errors.Is(err, profiles.ErrNotFound) {
+				return nil, nil
+			}
+			return profile, err
+		},
+	})
+	query.AddField("patterns", &graphql.Field{
+		Type:        graphql.NonNullOf(b.Object(storage.Patterns{})),
+		Description: "Training output by hour of day and day of week.",
+		Args:        []graphql.Arg{athleteArg, {Name: "gym", Type: graphql.String}},
+		Resolve: func(p graphql.Params) (interface{}, error) {
+			return h.patterns(p, p.String("athlete"))
+		},
+	})
+	query.AddField("trend", &graphql.Field{
+		Type:        graphql.NonNullOf(b.Object(storage.Trend{})),
+		Description: "A metric across the sessions of a window, with a line fitted through it.",
+		Args:        append([]graphql.Arg{athleteArg}, trendArgs...),
+		Resolve: func(p graphql.Params) (interface{}, error) {
+			return h.trend(p, p.String("athlete"))
+		},
+	})
+	query.AddField("trend_metrics", &graphql.Field{
+		Type:        nonNullList(graphql.String),
+		Description: "Metrics a trend can follow.",
+		Resolve: func(p graphql.Params) (interface{}, error) {
+			return storage.TrendMetrics(), nil
+		},
+	})
+
+	session.AddField("user", &graphql.Field{
+		Type:        user,
+		Description: "Profile of the athlete, null if there is none.",
+		Resolve: func(p graphql.Params) (interface{}, error) {
+			profile, err := profileStore.Get(p.Source.(*storage.Session).Athlete)
+			if err != nil {
+				return nil, nil
+			}
+			return profile, nil
+		},
+	})
+	session.AddField("punches", &graphql.Field{
+		Type:        b.Object(storage.PunchPage{}),
+		Description: "A page of the session's punch events in the order they landed, in the session's units; null if none are stored.",
+		Args: []graphql.Arg{
+			{Name: "hand", Type: graphql.String, Description: "left or right"},
+			{Name: "type", Type: graphql.String, Description: "e.g. hook"},
+			{Name: "min_force", Type: graphql.Float},
+			{Name: "limit", Type: graphql.Int, Default: storage.DefaultPunchLimit, Description: fmt.Sprintf("page size, at most %d", storage.MaxPunchLimit)},
+			{Name: "cursor", Type: graphql.Int, Default: 0, Description: "next of the previous page"},
+		},
+		Resolve: h.punches,
+	})
+
+	user.AddField("sessions", &graphql.Field{
+		Type:        nonNullList(session),
+		Description: "The athlete's stored sessions, newest first.",
+		Args:        sessionArgs,
+		Resolve: func(p graphql.Params) (interface{}, error) {
+			return h.sessions(p, p.Source.(profiles.Profile).ID)
+		},
+	})
+	user.AddField("patterns", &graphql.Field{
+		Type: graphql.NonNullOf(b.Object(storage.Patterns{})),
+		Args: []graphql.Arg{{Name: "gym", Type: graphql.String}},
+		Resolve: func(p graphql.Params) (interface{}, error) {
+			return h.patterns(p, p.Source.(profiles.Profile).ID)
+		},
+	})
+	user.AddField("trend", &graphql.Field{
+		Type: graphql.NonNullOf(b.Object(storage.Trend{})),
+		Args: trendArgs,
+		Resolve: func(p graphql.Params) (interface{}, error) {
+			return h.trend(p, p.Source.(profiles.Profile).ID)
+		},
+	})
+
+	return graphql.NewSchema(query)
+}
+
+// stored returns the stored sessions, oldest first, loaded once per query.
+func (h *historyGraph) stored(ctx context.Context) ([]*storage.Session, error) {
+	loaded, _ := ctx.Value(loadedSessionsKey{}).(*loadedSessions)
+	if loaded == nil {
+		loaded = &loadedSessions{}
+	}
+	if !loaded.done {
+		loaded.sessions, loaded.err = h.store.List()
+		loaded.done = true
+		if loaded.err != nil {
+			log.Printf("Session list: %v", loaded.err)
+		}
+	}
+	if loaded.err != nil {
+		return nil, errors.New("failed to load sessions")
+	}
+	return loaded.sessions, nil
+}
+
+// sessions resolves a list of stored sessions of athlete ("" = all),
+// newest first.
+func (h *historyGraph) sessions(p graphql.Params, athlete string) (interface{}, error) {
+	units := p.String("units")
+	if _, err := analytics.ParseUnits(units); err != nil {
+		return nil, err
+	}
+	since, err := timeArg(p, "since")
+	if err != nil {
+		return nil, err
+	}
+	until, err := timeArg(p, "until")
+	if err != nil {
+		return nil, err
+	}
+	q := storage.SessionQuery{Athlete: athlete, Tag: p.String("tag"), Type: p.String("type"), Since: since, Until: until, Offset: p.Int("offset")}
+	if p.Has("limit") {
+		if q.Limit = p.Int("limit"); q.Limit < 0 {
+			return nil, errors.New("invalid limit: must not be negative")
+		}
+		if q.Limit == 0 {
+			return []*storage.Session{}, nil
+		}
+	}
+	if q.Offset < 0 {
+		return nil, errors.New("invalid offset: must not be negative")
+	}
+
+	stored, err := h.store.Sessions(q)
+	if err != nil {
+		log.Printf("Session list: %v", err)
+		return nil, errors.New("failed to load sessions")
+	}
+	for i, sess := range stored {
+		u, _ := sessionUnits(units, h.profileStore, sess.Athlete, h.units)
+		stored[i] = sess.InUnits(u)
+	}
+	return stored, nil
+}
+
+// timeArg returns an RFC 3339 time argument, zero if not given.
+func timeArg(p graphql.Params, name string) (time.Time, error) {
+	if !p.Has(name) {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, p.String(name))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: must be an RFC 3339 time", name)
+	}
+	return t, nil
+}
+
+// session resolves a stored session by ID.
+func (h *historyGraph) session(p graphql.Params) (interface{}, error) {
+	sess, err := h.store.Get(p.String("id"))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Session load: %v", err)
+		return nil, errors.New("failed to load session")
+	}
+	units, err := sessionUnits(p.String("units"), h.profileStore, sess.Athlete, h.units)
+	if err != nil {
+		return nil, err
+	}
+	return sess.InUnits(units), nil
+}
+
+// punches resolves a page of the punch events of a session, in the units
+// the session was resolved in.
+func (h *historyGraph) punches(p graphql.Params) (interface{}, error) {
+	sess := p.Source.(*storage.Session)
+	q := storage.PunchQuery{
+		Hand:     p.String("hand"),
+		Type:     analytics.PunchType(p.String("type")),
+		MinForce: p.Float("min_force"),
+		After:    p.Int("cursor"),
+		Limit:    p.Int("limit"),
+		Units:    analytics.MetricUnits(),
+	}
+	switch q.Hand {
+	case "", "left", "right":
+	default:
+		return nil, errors.New("invalid hand: must be 'left' or 'right'")
+	}
+	if q.MinForce < 0 {
+		return nil, errors.New("invalid min_force: must not be negative")
+	}
+	if q.Limit < 1 || q.Limit > storage.MaxPunchLimit {
+		return nil, fmt.Errorf("invalid limit: must be 1-%d", storage.MaxPunchLimit)
+	}
+	if q.After < 0 {
+		return nil, errors.New("invalid cursor: must not be negative")
+	}
+	if sess.State != nil && sess.State.Units != nil {
+		q.Units = *sess.State.Units
+	}
+
+	page, err := h.store.Punches(sess.ID, q)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrNoPunches) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Session punches %s: %v", sess.ID, err)
+		return nil, errors.New("failed to load punches")
+	}
+	return page, nil
+}
+
+// patterns resolves the training patterns of athlete ("" = all).
+func (h *historyGraph) patterns(p graphql.Params, athlete string) (interface{}, error) {
+	stored, err := h.stored(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	return storage.ComputePatterns(stored, athlete, p.String("gym"), time.Local), nil
+}
+
+// trend resolves a trend of athlete ("" = all).
+func (h *historyGraph) trend(p graphql.Params, athlete string) (interface{}, error) {
+	window, err := storage.ParseWindow(p.String("window"))
+	if err != nil {
+		return nil, errors.New("invalid window: must be a number of days (e.g. 90d) or a duration")
+	}
+	rolling := p.Int("rolling")
+	if rolling < 1 {
+		return nil, errors.New("invalid rolling: must be a positive number of sessions")
+	}
+	stored, err := h.stored(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	return storage.ComputeTrend(stored, p.String("metric"), athlete, p.String("gym"), window, rolling, time.Now())
+}
+
+// graphqlHandler serves /api/v1/graphql, GraphQL queries over the stored
+// history: POST a {"query", "variables", "operationName"} body, or GET with
+// query=, variables= (a JSON object) and operationName=. Queries that do
+// not validate answer 400; errors of single fields come with the data.
+func graphqlHandler(schema *graphql.Schema) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req graphql.Request
+		switch r.Method {
+		case http.MethodGet:
+			q := r.URL.Query()
+			req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+			if v := q.Get("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+					httpError(w, "Invalid variables: must be a JSON object", http.StatusBadRequest)
+					return
+				}
+			}
+		case http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				invalidBody(w, err)
+				return
+			}
+		default:
+			httpError(w, "GET or POST only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), loadedSessionsKey{}, &loadedSessions{})
+		resp := schema.Execute(ctx, req)
+		status := http.StatusOK
+		if resp.Data == nil {
+			status = http.StatusBadRequest
+		}
+		writeJSON(w, status, resp)
+	}
+}
+
+// graphqlSchemaHandler serves GET /api/v1/graphql/schema, the GraphQL
+// schema in SDL.
+func graphqlSchemaHandler(schema *graphql.Schema) http.HandlerFunc {
+	sdl := []byte(schema.SDL())
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(sdl)
+	}
+}
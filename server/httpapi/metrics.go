@@ -0,0 +1,40 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"boxing-analytics/hub"
+)
+
+// labelEscaper escapes Prometheus label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricsHandler serves the WebSocket hub's counters in the Prometheus text
+// format, so a dashboard that keeps falling behind can be alerted on.
+func metricsHandler(h *hub.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		stats := h.Stats()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		metric := func(name, kind, help string, value int64) {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+		}
+		metric("smartpunch_ws_clients", "gauge", "Connected WebSocket clients.", int64(stats.Clients))
+		metric("smartpunch_ws_frames_sent_total", "counter", "Frames written to WebSocket clients.", stats.FramesSent)
+		metric("smartpunch_ws_frames_dropped_total", "counter", "Frames dropped for WebSocket clients that fell behind.", stats.FramesDropped)
+		metric("smartpunch_ws_slow_disconnects_total", "counter", "WebSocket clients disconnected for dropping too many frames.", stats.SlowDisconnects)
+
+		fmt.Fprint(w, "# HELP smartpunch_ws_client_frames_dropped_total Frames dropped per connected WebSocket client.\n")
+		fmt.Fprint(w, "# TYPE smartpunch_ws_client_frames_dropped_total counter\n")
+		for _, c := range h.Clients() {
+			fmt.Fprintf(w, "smartpunch_ws_client_frames_dropped_total{id=\"%s\",name=\"%s\",role=\"%s\"} %d\n",
+				c.ID, labelEscaper.Replace(c.Name), labelEscaper.Replace(c.Role), c.FramesDropped)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package httpapi
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"boxing-analytics/validation"
+)
+
+// requestIDHeader carries a request's ID, taken from the client when it
+// sends a usable one and echoed on every response.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestID bounds the length of a client-supplied request ID.
+const maxRequestID = 64
+
+// Error codes beyond those of the HTTP statuses
+const (
+	codeInvalidBody   = "invalid_body"   // not JSON, or a field of the wrong type
+	codeInvalidFields = "invalid_fields" // fields failed validation
+)
+
+// errorResponse is the body of every error the API returns.
+type errorResponse struct {
+	Code      string                  `json:"code"` // e.g. "not_found" or "invalid_fields"
+	Message   string                  `json:"message"`
+	Details   []validation.FieldError `json:"details,omitempty"` // the invalid fields of a request body
+	Error     string                  `json:"error"`             // same as message, kept for earlier clients
+	Status    int                     `json:"status"`
+	RequestID string                  `json:"request_id,omitempty"`
+}
+
+// httpError replies with a JSON error, tagged with the request's ID. It
+// takes the place of http.Error, and its arguments.
+func httpError(w http.ResponseWriter, message string, status int) {
+	writeError(w, status, statusCode(status), message, nil)
+}
+
+// invalidBody replies 400 to a request body that does not decode, naming
+// the field when one has the wrong type.
+func invalidBody(w http.ResponseWriter, err error) {
+	var details validation.Errors
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		details.Add(typeErr.Field, fmt.Errorf("%s must be %s, got %s", typeErr.Field, jsonKind(typeErr.Type), typeErr.Value))
+	}
+	writeError(w, http.StatusBadRequest, codeInvalidBody, "Invalid JSON body", details)
+}
+
+// invalidRequest replies 400 to a request that failed validation, listing
+// the invalid fields when err is validation.Errors.
+func invalidRequest(w http.ResponseWriter, err error) {
+	var fields validation.Errors
+	if errors.As(err, &fields) {
+		writeError(w, http.StatusBadRequest, codeInvalidFields, err.Error(), fields)
+		return
+	}
+	httpError(w, err.Error(), http.StatusBadRequest)
+}
+
+// invalidField replies 400 to a request whose one invalid field or query
+// parameter is field.
+func invalidField(w http.ResponseWriter, field string, err error) {
+	var errs validation.Errors
+	errs.Add(field, err)
+	invalidRequest(w, errs)
+}
+
+// writeError writes an errorResponse.
+func writeError(w http.ResponseWriter, status int, code, message string, details []validation.FieldError) {
+	w.Header().Del("Content-Length")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	writeJSON(w, status, errorResponse{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		Error:     message,
+		Status:    status,
+		RequestID: w.Header().Get(requestIDHeader),
+	})
+}
+
+// statusCode returns the error code of an HTTP status, its text in snake
+// case, e.g. "method_not_allowed".
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ToLower(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
+
+// jsonKind describes the JSON value a Go type decodes from.
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "a " + t.String()
+}
+
+// notFoundHandler answers /api paths no handler serves.
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	httpError(w, "Not found", http.StatusNotFound)
+}
+
+// Middleware wraps the server's handler: it gives every request an ID,
+// recovers panics as 500 errors, and logs each request with its status,
+// size and duration unless accessLog is false.
+func Middleware(next http.Handler, accessLog bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		rec := &recorder{ResponseWriter: w}
+		start := time.Now()
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				log.Printf("HTTP %s panic serving %s %s: %v\n%s", id, r.Method, r.URL.Path, p, debug.Stack())
+				if !rec.wroteHeader {
+					httpError(rec, "Internal server error", http.StatusInternalServerError)
+				}
+			}
+			if accessLog {
+				status := rec.status
+				if status == 0 {
+					status = http.StatusOK // nothing written
+				}
+				log.Printf("HTTP %s %s %s %d %dB %s %s", id, r.Method, r.URL.RequestURI(), status,
+					rec.bytes, time.Since(start).Round(time.Microsecond), r.RemoteAddr)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// validRequestID reports whether a client's request ID is short and
+// printable enough to be logged and echoed.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestID {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 12-character ID.
+func newRequestID() string {
+	var b [6]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// recorder notes the status and size of a response. It passes hijacking
+// (for WebSockets) and flushing through to the underlying writer.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	n, err := rec.ResponseWriter.Write(p)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher.
+func (rec *recorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, recording the switch to a WebSocket.
+func (rec *recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	conn, buf, err := hj.Hijack()
+	if err == nil {
+		rec.status = http.StatusSwitchingProtocols
+		rec.wroteHeader = true
+	}
+	return conn, buf, err
+}
+
+// Unwrap gives http.ResponseController the underlying writer.
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
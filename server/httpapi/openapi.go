@@ -0,0 +1,460 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+	"boxing-analytics/fleet"
+	"boxing-analytics/goals"
+	"boxing-analytics/graphql"
+	"boxing-analytics/hub"
+	"boxing-analytics/ingest"
+	"boxing-analytics/profiles"
+	"boxing-analytics/replay"
+	"boxing-analytics/schedule"
+	"boxing-analytics/storage"
+)
+
+// openAPIVersion is the OpenAPI version of the document served at
+// /api/openapi.json.
+const openAPIVersion = "3.0.3"
+
+// okResponse is the body of the endpoints that only acknowledge a request.
+type okResponse struct {
+	OK bool `json:"ok"`
+}
+
+// apiParam is a query parameter of an operation.
+type apiParam struct {
+	name        string
+	typ         string // "string", "integer", "number" or "boolean"
+	description string
+}
+
+// apiOperation describes an endpoint in the OpenAPI document. Its schemas
+// are generated from the Go values the handler decodes and encodes, so
+// they follow the types.
+type apiOperation struct {
+	method       string
+	path         string // {name} marks a path parameter
+	summary      string
+	query        []apiParam
+	body         interface{} // zero value of the request body, nil = none
+	optionalBody bool
+	status       int         // success status, 0 = 200
+	response     interface{} // zero value of the response body, nil = okResponse
+	contentType  string      // of the response, "" = application/json
+}
+
+// Response bodies the handlers build as maps
+type (
+	sessionTypesResponse struct {
+		Default string                                `json:"default"`
+		Types   map[string]analytics.DetectionProfile `json:"types"`
+	}
+	recoveryResponse struct {
+		Pending  bool                `json:"pending"`
+		Sessions []UnfinishedSession `json:"sessions"`
+	}
+	goalResponse struct {
+		Targets goals.Targets    `json:"targets"`
+		Week    goals.Progress   `json:"week"`
+		History []goals.Progress `json:"history"`
+	}
+	bleLogResponse struct {
+		Entries []ble.LogEntry `json:"entries"`
+	}
+	gloveStatus struct {
+		Name            string `json:"name"`
+		Address         string `json:"address"`
+		Adapter         string `json:"adapter"`
+		FirmwareVersion string `json:"firmware_version"`
+		HardwareRev     string `json:"hardware_rev"`
+		Battery         *int   `json:"battery,omitempty"`
+	}
+	statusResponse struct {
+		LeftConnected  bool               `json:"left_connected"`
+		RightConnected bool               `json:"right_connected"`
+		LeftState      string             `json:"left_state"`
+		RightState     string             `json:"right_state"`
+		Left           *gloveStatus       `json:"left,omitempty"`
+		Right          *gloveStatus       `json:"right,omitempty"`
+		UDP            []ingest.UDPDevice `json:"udp,omitempty"`
+	}
+)
+
+// streamedTypes are the WebSocket messages of /ws and /api/events/poll,
+// added to the document's schemas as no REST response names them all.
+var streamedTypes = []interface{}{analytics.SessionState{}, analytics.SparringState{}, analytics.PunchEvent{}}
+
+var (
+	athleteParam = apiParam{"athlete", "string", "athlete ID"}
+	gymParam     = apiParam{"gym", "string", "gym the sessions were recorded at"}
+	handParam    = apiParam{"hand", "string", "left, right or both"}
+	unitsParam   = apiParam{"units", "string", "metric, imperial or quantity=unit pairs, e.g. accel=g,mass=lb"}
+	storedParam  = apiParam{"session", "string", "stored session ID, default the live session"}
+)
+
+// apiOperations are the endpoints Register serves.
+var apiOperations = []apiOperation{
+	{method: http.MethodGet, path: apiV1 + "/openapi.json", summary: "This document", response: map[string]interface{}{}},
+
+	{method: http.MethodPost, path: apiV1 + "/session/start", summary: "Start a session", body: sessionStart{}, optionalBody: true,
+		query: []apiParam{{"type", "string", "session type"}, athleteParam, {"program", "string", "program ID"}, {"opponent", "string", "athlete b in sparring mode"}}},
+	{method: http.MethodPost, path: apiV1 + "/session/stop", summary: "Stop and save the session"},
+	{method: http.MethodPost, path: apiV1 + "/session/reset", summary: "Reset the session stats"},
+	{method: http.MethodPost, path: apiV1 + "/session/pause", summary: "Pause the session"},
+	{method: http.MethodPost, path: apiV1 + "/session/resume", summary: "Resume a paused session"},
+	{method: http.MethodGet, path: apiV1 + "/session/types", summary: "List the session types", response: sessionTypesResponse{}},
+	{method: http.MethodGet, path: apiV1 + "/session/reaction", summary: "Reaction drill stats", response: analytics.ReactionStats{}},
+	{method: http.MethodPost, path: apiV1 + "/session/reaction", summary: "Start a reaction drill", body: analytics.ReactionDrill{}, optionalBody: true, response: analytics.ReactionStats{}},
+	{method: http.MethodDelete, path: apiV1 + "/session/reaction", summary: "Stop the reaction drill"},
+	{method: http.MethodGet, path: apiV1 + "/session/program", summary: "Program progress and score", response: analytics.ProgramStats{}},
+	{method: http.MethodPost, path: apiV1 + "/session/program", summary: "Start a stored program", body: programStartRequest{}, response: analytics.ProgramStats{}},
+	{method: http.MethodDelete, path: apiV1 + "/session/program", summary: "Stop the program"},
+	{method: http.MethodGet, path: apiV1 + "/session/recovery", summary: "List the sessions a crash left unfinished", response: recoveryResponse{}},
+	{method: http.MethodPost, path: apiV1 + "/session/recovery/resume", summary: "Resume the unfinished sessions"},
+	{method: http.MethodPost, path: apiV1 + "/session/recovery/discard", summary: "Discard the unfinished sessions"},
+	{method: http.MethodGet, path: apiV1 + "/session/timeline", summary: "Force waveform of a session", response: analytics.Timeline{},
+		query: []apiParam{{"resolution", "string", "bucket duration, e.g. 1s"}, storedParam}},
+	{method: http.MethodGet, path: apiV1 + "/session/heatmap", summary: "Punch density over a session", response: analytics.Heatmap{},
+		query: []apiParam{{"bin", "string", "bin duration, e.g. 10s"}, storedParam}},
+	{method: http.MethodGet, path: apiV1 + "/session/rhythm", summary: "Histogram of the intervals between punches", response: analytics.RhythmHistogram{},
+		query: []apiParam{handParam, {"bucket_ms", "integer", "bucket width"}, {"max_ms", "integer", "longest interval"}}},
+
+	{method: http.MethodGet, path: apiV1 + "/sessions", summary: "List the stored sessions, newest first", response: []storage.Summary{},
+		query: []apiParam{{"tag", "string", "only sessions with this tag"}, athleteParam, unitsParam}},
+	{method: http.MethodGet, path: apiV1 + "/sessions/{id}", summary: "Read a stored session", response: storage.Session{}, query: []apiParam{unitsParam}},
+	{method: http.MethodPatch, path: apiV1 + "/sessions/{id}", summary: "Annotate a stored session", body: storage.Annotation{}, response: storage.Summary{}},
+	{method: http.MethodGet, path: apiV1 + "/sessions/{id}/punches", summary: "Page through a stored session's punch events", response: storage.PunchPage{},
+		query: []apiParam{{"hand", "string", "left or right"}, {"type", "string", "punch type, e.g. hook"}, {"min_force", "number", "least force, in the output units"},
+			{"limit", "integer", "page size, 1-1000, default 100"}, {"cursor", "integer", "next of the previous page"}, unitsParam}},
+	{method: http.MethodPost, path: apiV1 + "/sessions/{id}/reanalyze", summary: "Rerun a session's raw recording with new parameters", body: replay.Params{}, optionalBody: true, response: replay.Comparison{}},
+	{method: http.MethodGet, path: apiV1 + "/sessions/{id}/health", summary: "Export a session as a health workout", response: map[string]interface{}{},
+		query: []apiParam{{"format", "string", "healthkit or healthconnect"}, {"weight_kg", "number", "body weight for the energy estimate"}}},
+	{method: http.MethodGet, path: apiV1 + "/stats/patterns", summary: "Training patterns across stored sessions", response: storage.Patterns{}, query: []apiParam{athleteParam, gymParam}},
+	{method: http.MethodGet, path: apiV1 + "/stats/trends", summary: "A metric's trend across stored sessions", response: storage.Trend{},
+		query: []apiParam{{"metric", "string", "metric to follow"}, {"window", "string", "days (90d) or a duration"}, athleteParam, gymParam, {"rolling", "integer", "sessions per rolling average"}}},
+	{method: http.MethodPost, path: apiV1 + "/graphql", summary: "Run a GraphQL query over the stored history", body: graphql.Request{}, response: graphql.Response{}},
+	{method: http.MethodGet, path: apiV1 + "/graphql", summary: "Run a GraphQL query over the stored history", response: graphql.Response{},
+		query: []apiParam{{"query", "string", "GraphQL query"}, {"variables", "string", "JSON object of the query's variables"}, {"operationName", "string", "operation to run"}}},
+	{method: http.MethodGet, path: apiV1 + "/graphql/schema", summary: "The GraphQL schema in SDL", response: "", contentType: "text/plain"},
+
+	{method: http.MethodPost, path: apiV1 + "/recalibrate", summary: "Re-learn the gloves' gravity reference", query: []apiParam{handParam}},
+	{method: http.MethodPost, path: apiV1 + "/threshold/auto", summary: "Learn the gloves' thresholds from their next punches", query: []apiParam{handParam, athleteParam}},
+	{method: http.MethodPost, path: apiV1 + "/calibrate", summary: "Calibrate the gloves' sensor offsets", response: map[string]calibrationResult{},
+		query: []apiParam{handParam, {"duration", "number", "seconds to hold still, 1-30"}}},
+	{method: http.MethodGet, path: apiV1 + "/status", summary: "Glove links and details", response: statusResponse{}},
+	{method: http.MethodPost, path: apiV1 + "/device/{hand}/feedback", summary: "Buzz a glove or light its LED", body: feedbackRequest{}, response: map[string]feedbackResult{}},
+	{method: http.MethodPost, path: apiV1 + "/device/swap", summary: "Swap the gloves between hands"},
+	{method: http.MethodGet, path: apiV1 + "/pairing", summary: "List the remembered gloves", response: map[string]ble.PairedGlove{}},
+	{method: http.MethodDelete, path: apiV1 + "/pairing/{hand}", summary: "Forget a remembered glove"},
+
+	{method: http.MethodGet, path: apiV1 + "/profiles", summary: "List the athlete profiles", response: []profiles.Profile{}, query: []apiParam{{"guests", "string", "1 to include guests"}}},
+	{method: http.MethodPost, path: apiV1 + "/profiles", summary: "Create a profile", body: profiles.Profile{}, status: http.StatusCreated, response: profiles.Profile{}},
+	{method: http.MethodGet, path: apiV1 + "/profiles/{id}", summary: "Read a profile", response: profiles.Profile{}},
+	{method: http.MethodPut, path: apiV1 + "/profiles/{id}", summary: "Update a profile", body: profiles.Profile{}, response: profiles.Profile{}},
+	{method: http.MethodDelete, path: apiV1 + "/profiles/{id}", summary: "Delete a profile"},
+	{method: http.MethodPost, path: apiV1 + "/guest", summary: "Create a guest profile", body: guestRequest{}, optionalBody: true, status: http.StatusCreated, response: profiles.Profile{}},
+	{method: http.MethodGet, path: apiV1 + "/programs", summary: "List the programs", response: []analytics.Program{}},
+	{method: http.MethodPost, path: apiV1 + "/programs", summary: "Create a program", body: analytics.Program{}, status: http.StatusCreated, response: analytics.Program{}},
+	{method: http.MethodGet, path: apiV1 + "/programs/{id}", summary: "Read a program", response: analytics.Program{}},
+	{method: http.MethodPut, path: apiV1 + "/programs/{id}", summary: "Update a program", body: analytics.Program{}, response: analytics.Program{}},
+	{method: http.MethodDelete, path: apiV1 + "/programs/{id}", summary: "Delete a program"},
+	{method: http.MethodGet, path: apiV1 + "/schedule", summary: "List the scheduled sessions", response: []schedule.Entry{},
+		query: []apiParam{athleteParam, {"from", "string", "RFC 3339 time"}, {"to", "string", "RFC 3339 time"}}},
+	{method: http.MethodPost, path: apiV1 + "/schedule", summary: "Schedule a session", body: schedule.Entry{}, status: http.StatusCreated, response: schedule.Entry{}},
+	{method: http.MethodGet, path: apiV1 + "/schedule/{id}", summary: "Read a scheduled session", response: schedule.Entry{}},
+	{method: http.MethodPut, path: apiV1 + "/schedule/{id}", summary: "Update a scheduled session", body: schedule.Entry{}, response: schedule.Entry{}},
+	{method: http.MethodDelete, path: apiV1 + "/schedule/{id}", summary: "Cancel a scheduled session"},
+	{method: http.MethodGet, path: apiV1 + "/goals", summary: "This week's progress of every athlete with a goal", response: []goals.Progress{}},
+	{method: http.MethodGet, path: apiV1 + "/goals/{athlete}", summary: "An athlete's weekly goal, progress and history", response: goalResponse{}},
+	{method: http.MethodPut, path: apiV1 + "/goals/{athlete}", summary: "Set an athlete's weekly goal", body: goals.Targets{}, response: goals.Targets{}},
+	{method: http.MethodDelete, path: apiV1 + "/goals/{athlete}", summary: "Remove an athlete's weekly goal"},
+
+	{method: http.MethodGet, path: apiV1 + "/alerts", summary: "List the alert rules", response: []analytics.AlertRule{}},
+	{method: http.MethodPost, path: apiV1 + "/alerts", summary: "Create an alert rule", body: analytics.AlertRule{}, status: http.StatusCreated, response: analytics.AlertRule{}},
+	{method: http.MethodGet, path: apiV1 + "/alerts/{id}", summary: "Read an alert rule", response: analytics.AlertRule{}},
+	{method: http.MethodPut, path: apiV1 + "/alerts/{id}", summary: "Update an alert rule", body: analytics.AlertRule{}, response: analytics.AlertRule{}},
+	{method: http.MethodDelete, path: apiV1 + "/alerts/{id}", summary: "Delete an alert rule"},
+
+	{method: http.MethodGet, path: apiV1 + "/fleet", summary: "List the known gloves", response: []fleet.Device{}},
+	{method: http.MethodGet, path: apiV1 + "/fleet/campaigns", summary: "List the firmware campaigns", response: []fleet.Campaign{}},
+	{method: http.MethodPost, path: apiV1 + "/fleet/campaigns", summary: "Start a firmware campaign", body: fleet.CampaignRequest{}, status: http.StatusCreated, response: fleet.Campaign{}},
+	{method: http.MethodGet, path: apiV1 + "/fleet/campaigns/{id}", summary: "Read a firmware campaign", response: fleet.Campaign{}},
+	{method: http.MethodDelete, path: apiV1 + "/fleet/campaigns/{id}", summary: "Cancel a firmware campaign"},
+	{method: http.MethodPost, path: apiV1 + "/fleet/campaigns/{id}/report", summary: "Report a glove's progress in a campaign", body: campaignReport{}, response: fleet.Campaign{}},
+	{method: http.MethodPost, path: apiV1 + "/fleet/campaigns/{id}/resume", summary: "Resume a campaign paused by a failed update", response: fleet.Campaign{}},
+	{method: http.MethodGet, path: apiV1 + "/fleet/ota", summary: "List the gloves on Wi-Fi with their update progress", response: []ingest.UDPDevice{}},
+	{method: http.MethodPost, path: apiV1 + "/fleet/ota", summary: "Offer a glove on Wi-Fi the update its campaign queued", body: otaRequest{}, status: http.StatusAccepted, response: ingest.UDPDevice{}},
+	{method: http.MethodGet, path: "/firmware/manifest.json", summary: "List the firmware images", response: fleet.FirmwareManifest{}},
+	{method: http.MethodGet, path: "/firmware/{file}", summary: "Download a firmware image", contentType: "application/octet-stream"},
+
+	{method: http.MethodGet, path: apiV1 + "/admin/clients", summary: "List the WebSocket clients", response: []hub.ClientInfo{}},
+	{method: http.MethodGet, path: apiV1 + "/admin/ble", summary: "BLE diagnostics", response: ble.Diagnostics{}},
+	{method: http.MethodPost, path: apiV1 + "/admin/ble/{action}", summary: "Rescan, disconnect or forget gloves", query: []apiParam{handParam}},
+	{method: http.MethodGet, path: apiV1 + "/debug/ble", summary: "BLE connection log", response: bleLogResponse{}, query: []apiParam{{"hand", "string", "left or right"}}},
+	{method: http.MethodGet, path: apiV1 + "/events/poll", summary: "Long-poll the WebSocket messages", response: pollResponse{},
+		query: []apiParam{{"since", "integer", "sequence number of the last message seen"}, {"timeout", "string", "how long to wait, up to 60s"}}},
+	{method: http.MethodGet, path: apiV1 + "/ws/schema", summary: "The protobuf schema of binary WebSocket messages", response: "", contentType: "text/plain"},
+	{method: http.MethodGet, path: "/metrics", summary: "Prometheus metrics", response: "", contentType: "text/plain"},
+}
+
+// openAPIHandler serves the OpenAPI document of the API, built once.
+func openAPIHandler(version string) http.HandlerFunc {
+	doc := openAPIDocument(version)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, doc)
+	}
+}
+
+// openAPIDocument builds the OpenAPI document of apiOperations.
+func openAPIDocument(version string) map[string]interface{} {
+	g := newSchemaGenerator()
+	errorSchema := g.schemaOf(reflect.TypeOf(errorResponse{}))
+	for _, v := range streamedTypes {
+		g.schemaOf(reflect.TypeOf(v))
+	}
+
+	paths := make(map[string]map[string]interface{})
+	for _, op := range apiOperations {
+		params := []map[string]interface{}{}
+		for _, segment := range strings.Split(op.path, "/") {
+			if name, ok := strings.CutPrefix(segment, "{"); ok {
+				params = append(params, map[string]interface{}{
+					"name": strings.TrimSuffix(name, "}"), "in": "path", "required": true,
+					"schema": &schema{Type: "string"},
+				})
+			}
+		}
+		for _, p := range op.query {
+			params = append(params, map[string]interface{}{
+				"name": p.name, "in": "query", "description": p.description,
+				"schema": &schema{Type: p.typ},
+			})
+		}
+
+		status := op.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		contentType := op.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		var body *schema
+		switch {
+		case op.response != nil:
+			body = g.schemaOf(reflect.TypeOf(op.response))
+		case op.contentType != "":
+			body = &schema{Type: "string", Format: "binary"}
+		default:
+			body = g.schemaOf(reflect.TypeOf(okResponse{}))
+		}
+
+		operation := map[string]interface{}{
+			"summary":    op.summary,
+			"tags":       []string{operationTag(op.path)},
+			"parameters": params,
+			"responses": map[string]interface{}{
+				strconv.Itoa(status): map[string]interface{}{
+					"description": http.StatusText(status),
+					"content":     map[string]interface{}{contentType: map[string]interface{}{"schema": body}},
+				},
+				"default": map[string]interface{}{
+					"description": "Error",
+					"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorSchema}},
+				},
+			},
+		}
+		if op.body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": !op.optionalBody,
+				"content": map[string]interface{}{"application/json": map[string]interface{}{
+					"schema": g.schemaOf(reflect.TypeOf(op.body)),
+				}},
+			}
+		}
+		if paths[op.path] == nil {
+			paths[op.path] = make(map[string]interface{})
+		}
+		paths[op.path][strings.ToLower(op.method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":       "Boxing Analytics API",
+			"version":     version,
+			"description": "REST API of the boxing analytics server. The unversioned /api/ paths are deprecated aliases of /api/v1/. Live session state is streamed as SessionState (SparringState in sparring mode) and PunchEvent messages over the /ws WebSocket and /api/events/poll; WebSocket clients may take them as protobuf instead (see /api/v1/ws/schema).",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.components},
+	}
+}
+
+// operationTag groups an operation by the first segment of its path below
+// /api/v1/, e.g. "session" or "fleet".
+func operationTag(p string) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(p, apiV1), "/"), "/")
+	return segment
+}
+
+// schema is an OpenAPI schema object, the subset the Go types need.
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	AllOf                []*schema          `json:"allOf,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaGenerator derives schemas from Go types the way encoding/json
+// encodes them. Named structs become components, referenced by name.
+type schemaGenerator struct {
+	components map[string]*schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{components: make(map[string]*schema), names: make(map[reflect.Type]string)}
+}
+
+// schemaOf returns the schema of t.
+func (g *schemaGenerator) schemaOf(t reflect.Type) *schema {
+	switch t {
+	case timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &schema{Type: "integer"}
+	case reflect.Int64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32:
+		return &schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Ptr:
+		elem := g.schemaOf(t.Elem())
+		if elem.Ref != "" {
+			return &schema{AllOf: []*schema{elem}, Nullable: true}
+		}
+		nullable := *elem
+		nullable.Nullable = true
+		return &nullable
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &schema{Type: "string", Format: "byte"}
+		}
+		return &schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Array:
+		n := t.Len()
+		return &schema{Type: "array", Items: g.schemaOf(t.Elem()), MinItems: &n, MaxItems: &n}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.ref(t)
+	}
+	return &schema{} // interface{}: any value
+}
+
+// ref returns a reference to the component of the named struct t, adding
+// it on first use.
+func (g *schemaGenerator) ref(t reflect.Type) *schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = exportedName(t.Name())
+		if _, taken := g.components[name]; taken {
+			name = exportedName(path.Base(t.PkgPath())) + name
+		}
+		g.names[t] = name
+		g.components[name] = &schema{} // placeholder for recursive types
+		g.components[name] = g.structSchema(t)
+	}
+	return &schema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema returns the object schema of struct t: its JSON fields,
+// those without omitempty required, and the fields of embedded structs
+// inlined.
+func (g *schemaGenerator) structSchema(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: make(map[string]*schema)}
+	g.addFields(s, t)
+	return s
+}
+
+func (g *schemaGenerator) addFields(s *schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schemaOf(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// exportedName upper-cases the first letter of a type name.
+func exportedName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
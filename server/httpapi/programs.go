@@ -0,0 +1,135 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/programs"
+)
+
+// programsHandler manages the workout programs: /api/programs lists and
+// creates them, /api/programs/{id} reads, replaces and deletes one.
+func programsHandler(programStore *programs.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, apiV1+"/programs"), "/")
+		if id == "" {
+			switch r.Method {
+			case http.MethodGet:
+				writeJSON(w, http.StatusOK, programStore.List())
+			case http.MethodPost:
+				var program analytics.Program
+				if err := json.NewDecoder(r.Body).Decode(&program); err != nil {
+					invalidBody(w, err)
+					return
+				}
+				if _, err := programStore.Get(program.ID); err == nil {
+					httpError(w, "Program already exists", http.StatusConflict)
+					return
+				}
+				created, err := programStore.Put(program)
+				if err != nil {
+					invalidRequest(w, err)
+					return
+				}
+				log.Printf("Program %s created", created.ID)
+				writeJSON(w, http.StatusCreated, created)
+			default:
+				httpError(w, "GET or POST only", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			program, err := programStore.Get(id)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, program)
+		case http.MethodPut:
+			if _, err := programStore.Get(id); err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			var program analytics.Program
+			if err := json.NewDecoder(r.Body).Decode(&program); err != nil {
+				invalidBody(w, err)
+				return
+			}
+			program.ID = id
+			updated, err := programStore.Put(program)
+			if err != nil {
+				invalidRequest(w, err)
+				return
+			}
+			log.Printf("Program %s updated", id)
+			writeJSON(w, http.StatusOK, updated)
+		case http.MethodDelete:
+			if err := programStore.Delete(id); err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			log.Printf("Program %s deleted", id)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			httpError(w, "GET, PUT, or DELETE only", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// programStartRequest is the body of POST /api/session/program.
+type programStartRequest struct {
+	ID string `json:"id"` // stored program
+}
+
+// sessionProgramHandler returns the progress and score of the session's
+// program (GET), starts a stored program (POST {"id": ...}) or stops it
+// (DELETE).
+func sessionProgramHandler(analyzer *analytics.Analyzer, programStore *programs.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			stats := analyzer.ProgramStats()
+			if stats == nil {
+				httpError(w, "No program in this session", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, stats)
+		case http.MethodPost:
+			var req programStartRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				invalidBody(w, err)
+				return
+			}
+			program, err := programStore.Get(req.ID)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			err = analyzer.StartProgram(program)
+			if errors.Is(err, analytics.ErrNoSession) {
+				httpError(w, "Start a session first", http.StatusConflict)
+				return
+			}
+			if err != nil {
+				invalidRequest(w, err)
+				return
+			}
+			log.Printf("Program %s started", program.ID)
+			writeJSON(w, http.StatusOK, analyzer.ProgramStats())
+		case http.MethodDelete:
+			analyzer.StopProgram()
+			log.Println("Program stopped")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			httpError(w, "GET, POST, or DELETE only", http.StatusMethodNotAllowed)
+		}
+	}
+}
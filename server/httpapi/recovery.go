@@ -0,0 +1,69 @@
+package httpapi
+
+import (
+	"net/http"
+	"time"
+)
+
+// UnfinishedSession describes a session a crash left unfinished.
+type UnfinishedSession struct {
+	Side      string    `json:"side,omitempty"` // "b" for athlete b in sparring mode
+	StartedAt time.Time `json:"started_at"`
+	Athlete   string    `json:"athlete,omitempty"`
+	Punches   int       `json:"punches"`
+}
+
+// Recovery holds the sessions a crash left unfinished until they are
+// resumed or discarded.
+type Recovery interface {
+	Pending() []UnfinishedSession // nil when none are on offer
+	Resume() bool                 // false when none were on offer
+	Discard() bool
+}
+
+// recoveryHandler serves GET /api/session/recovery, listing the unfinished
+// sessions on offer, and POST /api/session/recovery/{resume,discard}.
+func recoveryHandler(recovery Recovery) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		action := r.URL.Path[len(apiV1+"/session/recovery"):]
+		if action == "" {
+			if r.Method != http.MethodGet {
+				httpError(w, "GET only", http.StatusMethodNotAllowed)
+				return
+			}
+			var pending []UnfinishedSession
+			if recovery != nil {
+				pending = recovery.Pending()
+			}
+			if pending == nil {
+				pending = []UnfinishedSession{}
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"pending":  len(pending) > 0,
+				"sessions": pending,
+			})
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		var ok bool
+		switch action {
+		case "/resume":
+			ok = recovery != nil && recovery.Resume()
+		case "/discard":
+			ok = recovery != nil && recovery.Discard()
+		default:
+			notFoundHandler(w, r)
+			return
+		}
+		if !ok {
+			httpError(w, "No unfinished session", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
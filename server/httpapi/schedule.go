@@ -0,0 +1,124 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"boxing-analytics/programs"
+	"boxing-analytics/schedule"
+)
+
+// scheduleHandler manages planned sessions: /api/schedule lists
+// (athlete=, from= and to= as YYYY-MM-DD, both inclusive) and creates
+// them, /api/schedule/{id} reads, replaces and deletes one.
+func scheduleHandler(scheduleStore *schedule.Store, programStore *programs.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, apiV1+"/schedule"), "/")
+		if id == "" {
+			switch r.Method {
+			case http.MethodGet:
+				q := r.URL.Query()
+				var from, to time.Time
+				if v := q.Get("from"); v != "" {
+					d, err := time.ParseInLocation("2006-01-02", v, time.Local)
+					if err != nil {
+						httpError(w, "Invalid from: must be YYYY-MM-DD", http.StatusBadRequest)
+						return
+					}
+					from = d
+				}
+				if v := q.Get("to"); v != "" {
+					d, err := time.ParseInLocation("2006-01-02", v, time.Local)
+					if err != nil {
+						httpError(w, "Invalid to: must be YYYY-MM-DD", http.StatusBadRequest)
+						return
+					}
+					to = d.AddDate(0, 0, 1)
+				}
+				writeJSON(w, http.StatusOK, scheduleStore.List(q.Get("athlete"), from, to))
+			case http.MethodPost:
+				var entry schedule.Entry
+				if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+					invalidBody(w, err)
+					return
+				}
+				if entry.ID != "" {
+					if _, err := scheduleStore.Get(entry.ID); err == nil {
+						httpError(w, "Planned session already exists", http.StatusConflict)
+						return
+					}
+				}
+				if !knownProgram(w, programStore, entry.Program) {
+					return
+				}
+				created, err := scheduleStore.Put(entry)
+				if err != nil {
+					invalidRequest(w, err)
+					return
+				}
+				log.Printf("Session %s planned for %s at %s", created.ID, created.Athlete, created.At.Format(time.RFC3339))
+				writeJSON(w, http.StatusCreated, created)
+			default:
+				httpError(w, "GET or POST only", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			entry, err := scheduleStore.Get(id)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, entry)
+		case http.MethodPut:
+			if _, err := scheduleStore.Get(id); err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			var entry schedule.Entry
+			if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+				invalidBody(w, err)
+				return
+			}
+			if !knownProgram(w, programStore, entry.Program) {
+				return
+			}
+			entry.ID = id
+			updated, err := scheduleStore.Put(entry)
+			if err != nil {
+				invalidRequest(w, err)
+				return
+			}
+			log.Printf("Planned session %s updated", id)
+			writeJSON(w, http.StatusOK, updated)
+		case http.MethodDelete:
+			if err := scheduleStore.Delete(id); err != nil {
+				httpError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			log.Printf("Planned session %s deleted", id)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			httpError(w, "GET, PUT, or DELETE only", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// knownProgram reports whether a planned session's program ("" = none)
+// exists, answering 400 if not.
+func knownProgram(w http.ResponseWriter, programStore *programs.Store, id string) bool {
+	if id == "" {
+		return true
+	}
+	if _, err := programStore.Get(id); err != nil {
+		invalidField(w, "program", err)
+		return false
+	}
+	return true
+}
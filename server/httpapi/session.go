@@ -0,0 +1,912 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+	"boxing-analytics/health"
+	"boxing-analytics/profiles"
+	"boxing-analytics/programs"
+	"boxing-analytics/replay"
+	"boxing-analytics/schedule"
+	"boxing-analytics/storage"
+	"boxing-analytics/validation"
+)
+
+// ApplyProfile sets the athlete of an analyzer and applies their profile:
+// stance for the jab/cross split, max heart rate, skill level for the
+// detection thresholds and the effective mass for power estimates. Settings
+// the profile leaves out, or all of them without a profile or athlete, go
+// back to their defaults, so none carry over from the previous athlete.
+func ApplyProfile(analyzer *analytics.Analyzer, profileStore *profiles.Store, athlete string) {
+	analyzer.SetAthlete(athlete)
+	var profile profiles.Profile
+	if p, err := profileStore.Get(athlete); err == nil {
+		profile = p
+	}
+	analyzer.SetStance(profile.Stance)
+	analyzer.SetMaxHeartRate(profile.MaxHR)
+	analyzer.SetSkillLevel(profile.Skill)
+	analyzer.SetEffectiveMass(analytics.EffectiveMass(profile.WeightKg, profile.ReachCm, profile.Skill))
+}
+
+// maxLocationLen bounds the location a session start gives.
+const maxLocationLen = 100
+
+// sessionStart is the optional body of POST /api/session/start. The query
+// parameters of the same names are read first, the body overrides them.
+type sessionStart struct {
+	Athlete       string `json:"athlete"`
+	SessionType   string `json:"session_type"`
+	Program       string `json:"program"`
+	Opponent      string `json:"opponent"` // athlete b in sparring mode
+	PlannedRounds int    `json:"planned_rounds"`
+	Location      string `json:"location"`
+}
+
+// validate checks the start fields and trims the location.
+func (s *sessionStart) validate() error {
+	var errs validation.Errors
+	if s.PlannedRounds < 0 {
+		errs.Add("planned_rounds", fmt.Errorf("invalid planned_rounds %d", s.PlannedRounds))
+	}
+	s.Location = strings.TrimSpace(s.Location)
+	if len(s.Location) > maxLocationLen {
+		errs.Add("location", fmt.Errorf("location is too long: at most %d characters", maxLocationLen))
+	}
+	return errs.Err()
+}
+
+// The session handlers also drive the opponent's analyzer in sparring mode
+// (nil otherwise).
+
+func sessionStartHandler(analyzer, opponent *analytics.Analyzer, profileStore *profiles.Store, programStore *programs.Store, scheduleStore *schedule.Store, recorder *replay.Recorder, recovery Recovery) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		req := sessionStart{
+			Athlete:     q.Get("athlete"),
+			SessionType: q.Get("type"),
+			Program:     q.Get("program"),
+			Opponent:    q.Get("opponent"),
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				invalidBody(w, err)
+				return
+			}
+		}
+		if err := req.validate(); err != nil {
+			invalidRequest(w, err)
+			return
+		}
+		athlete, sessionType, programID := req.Athlete, req.SessionType, req.Program
+		info := analytics.SessionInfo{PlannedRounds: req.PlannedRounds, Location: req.Location}
+
+		// An athlete's session planned for today fills in what the
+		// request leaves out
+		planned, isPlanned := schedule.Entry{}, false
+		if athlete != "" {
+			planned, isPlanned = scheduleStore.Planned(athlete, time.Now())
+		}
+		if isPlanned {
+			if programID == "" {
+				programID = planned.Program
+			}
+			if sessionType == "" {
+				sessionType = planned.SessionType
+			}
+		}
+
+		var program *analytics.Program
+		if programID != "" {
+			p, err := programStore.Get(programID)
+			if err != nil {
+				invalidField(w, "program", err)
+				return
+			}
+			program = &p
+		}
+		if program != nil {
+			if sessionType == "" {
+				sessionType = program.SessionType
+			}
+			if info.PlannedRounds == 0 {
+				info.PlannedRounds = len(program.Rounds)
+			}
+		}
+		if err := analyzer.SetSessionType(sessionType); err != nil {
+			invalidField(w, "session_type", err)
+			return
+		}
+		// A new session replaces any unfinished one on offer
+		if recovery != nil {
+			recovery.Discard()
+		}
+		// Without an athlete the previous one's settings must not carry over
+		ApplyProfile(analyzer, profileStore, athlete)
+		analyzer.SetSessionInfo(info)
+		analyzer.StartSession()
+		if opponent != nil {
+			ApplyProfile(opponent, profileStore, req.Opponent)
+			opponent.SetSessionType(sessionType)
+			opponent.SetSessionInfo(info)
+			opponent.StartSession()
+		}
+		if recorder != nil {
+			recorder.Reset()
+		}
+		log.Printf("Session started (%s)", analyzer.SessionType())
+		if isPlanned {
+			if err := scheduleStore.MarkStarted(planned.ID, analyzer.StartedAt()); err != nil {
+				log.Printf("Schedule: %v", err)
+			}
+			log.Printf("Planned session %s started", planned.ID)
+		}
+		if program != nil {
+			if err := analyzer.StartProgram(*program); err != nil {
+				log.Printf("Program %s: %v", program.ID, err)
+			} else {
+				log.Printf("Program %s started", program.ID)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+// sessionTypesHandler lists the session types with their detection profiles.
+func sessionTypesHandler(analyzer *analytics.Analyzer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"default": analytics.DefaultSessionType,
+			"types":   analyzer.DetectionProfiles(),
+		})
+	}
+}
+
+// reactionHandler returns the session's reaction drill stats (GET), starts
+// a drill (POST, with an optional ReactionDrill body over the defaults) or
+// stops it (DELETE).
+func reactionHandler(analyzer *analytics.Analyzer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			stats := analyzer.ReactionStats()
+			if stats == nil {
+				httpError(w, "No reaction drill in this session", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, stats)
+		case http.MethodPost:
+			drill := analytics.DefaultReactionDrill()
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&drill); err != nil {
+					invalidBody(w, err)
+					return
+				}
+			}
+			err := analyzer.StartReactionDrill(drill)
+			if errors.Is(err, analytics.ErrNoSession) {
+				httpError(w, "Start a session first", http.StatusConflict)
+				return
+			}
+			if err != nil {
+				invalidRequest(w, err)
+				return
+			}
+			log.Printf("Reaction drill started (%s hand, %d-%dms apart)", drill.Hands, drill.MinDelayMS, drill.MaxDelayMS)
+			writeJSON(w, http.StatusOK, analyzer.ReactionStats())
+		case http.MethodDelete:
+			analyzer.StopReactionDrill()
+			log.Println("Reaction drill stopped")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			httpError(w, "GET, POST, or DELETE only", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func sessionResetHandler(analyzer, opponent *analytics.Analyzer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		analyzer.ResetSession()
+		if opponent != nil {
+			opponent.ResetSession()
+		}
+		log.Println("Session reset")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+func sessionPauseHandler(analyzer, opponent *analytics.Analyzer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		analyzer.PauseSession()
+		if opponent != nil {
+			opponent.PauseSession()
+		}
+		log.Println("Session paused")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+func sessionResumeHandler(analyzer, opponent *analytics.Analyzer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		analyzer.ResumeSession()
+		if opponent != nil {
+			opponent.ResumeSession()
+		}
+		log.Println("Session resumed")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+// saveSession persists an analyzer's running session, returning nil if none
+// is running. The suffix keeps the IDs of sessions started together (the two
+// athletes of a sparring session) apart.
+func saveSession(analyzer *analytics.Analyzer, store *storage.Store, gym, suffix string) (*storage.Session, error) {
+	state := analyzer.GetState()
+	if !state.Active {
+		return nil, nil
+	}
+	startedAt := analyzer.StartedAt()
+	info := analyzer.SessionInfo()
+	sess := &storage.Session{
+		ID:            storage.NewSessionID(startedAt) + suffix,
+		Athlete:       analyzer.Athlete(),
+		Type:          analyzer.SessionType(),
+		Gym:           gym,
+		StartedAt:     startedAt,
+		EndedAt:       time.Now(),
+		DurationSec:   state.ElapsedSec,
+		State:         state,
+		Timeline:      analyzer.Timeline(storedTimelineResolution),
+		Heatmap:       analyzer.Heatmap(analytics.DefaultHeatmapBin),
+		HeartRate:     analyzer.HeartRateTrace(),
+		PlannedRounds: info.PlannedRounds,
+		Location:      info.Location,
+	}
+	if err := store.Save(sess); err != nil {
+		log.Printf("Session save: %v", err)
+		return nil, err
+	}
+	// Every punch event, for the punch query; the session keeps only the latest
+	if err := store.SavePunches(sess.ID, analyzer.Punches()); err != nil {
+		log.Printf("Session punches save: %v", err)
+	}
+	log.Printf("Session %s saved", sess.ID)
+	return sess, nil
+}
+
+func sessionStopHandler(analyzer, opponent *analytics.Analyzer, store *storage.Store, gym string, recorder *replay.Recorder, recordingsDir string, saved func(*storage.Session)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Persist the finished sessions before the analyzers clear them. If
+		// one fails to save both keep running, so the stop can be retried.
+		var finished []*storage.Session
+		if opponent != nil {
+			sess, err := saveSession(opponent, store, gym, "-b")
+			if err != nil {
+				httpError(w, "Failed to save session", http.StatusInternalServerError)
+				return
+			}
+			if sess != nil {
+				finished = append(finished, sess)
+			}
+		}
+		sess, err := saveSession(analyzer, store, gym, "")
+		if err != nil {
+			httpError(w, "Failed to save session", http.StatusInternalServerError)
+			return
+		}
+		if opponent != nil {
+			opponent.ResetSession()
+		}
+		if sess != nil {
+			finished = append(finished, sess)
+			// Keep the raw packets so the session can be reanalysed later
+			if recorder != nil {
+				rec := recorder.Finish(sess.ID, analyzer)
+				if err := rec.Save(recordingPath(recordingsDir, sess.ID)); err != nil {
+					log.Printf("Recording save: %v", err)
+				}
+			}
+		}
+		if saved != nil {
+			for _, sess := range finished {
+				saved(sess)
+			}
+		}
+
+		// Stop keeps the stats but marks session as inactive
+		analyzer.ResetSession() // For now, same as reset - stats are kept in frontend
+		log.Println("Session stopped")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+func recalibrateHandler(analyzer *analytics.Analyzer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Get hand from query param
+		hand := r.URL.Query().Get("hand")
+		if hand == "" {
+			httpError(w, "Missing 'hand' query parameter (left or right)", http.StatusBadRequest)
+			return
+		}
+
+		switch hand {
+		case "left":
+			analyzer.ResetCalibration(ble.LeftHand)
+			log.Println("Recalibration started for left glove")
+		case "right":
+			analyzer.ResetCalibration(ble.RightHand)
+			log.Println("Recalibration started for right glove")
+		case "both":
+			analyzer.ResetCalibration(ble.LeftHand)
+			analyzer.ResetCalibration(ble.RightHand)
+			log.Println("Recalibration started for both gloves")
+		default:
+			httpError(w, "Invalid hand: must be 'left', 'right', or 'both'", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+func autoThresholdHandler(analyzer *analytics.Analyzer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpError(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Optional athlete the learned thresholds are stored for
+		if athlete := r.URL.Query().Get("athlete"); athlete != "" {
+			analyzer.SetAthlete(athlete)
+		}
+
+		hand := r.URL.Query().Get("hand")
+		if hand == "" {
+			hand = "both"
+		}
+
+		switch hand {
+		case "left":
+			analyzer.StartAutoThreshold(ble.LeftHand)
+		case "right":
+			analyzer.StartAutoThreshold(ble.RightHand)
+		case "both":
+			analyzer.StartAutoThreshold(ble.LeftHand)
+			analyzer.StartAutoThreshold(ble.RightHand)
+		default:
+			httpError(w, "Invalid hand: must be 'left', 'right', or 'both'", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Threshold calibration started for %s", hand)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+// timelineHandler serves GET /api/session/timeline?resolution=1s[&session=id]
+// with the live session's magnitude waveform, or a stored session's.
+func timelineHandler(analyzer *analytics.Analyzer, store *storage.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		resolution := time.Second
+		if v := r.URL.Query().Get("resolution"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < analytics.TimelineBaseResolution {
+				httpError(w, "Invalid resolution: must be a duration of at least 100ms", http.StatusBadRequest)
+				return
+			}
+			resolution = d
+		}
+
+		id := r.URL.Query().Get("session")
+		if id == "" {
+			writeJSON(w, http.StatusOK, analyzer.Timeline(resolution))
+			return
+		}
+
+		sess, err := store.Get(id)
+		if errors.Is(err, storage.ErrNotFound) {
+			httpError(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Session load: %v", err)
+			httpError(w, "Failed to load session", http.StatusInternalServerError)
+			return
+		}
+		if sess.Timeline == nil {
+			httpError(w, "Session has no timeline", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, sess.Timeline.Resample(resolution))
+	}
+}
+
+// heatmapHandler serves GET /api/session/heatmap?bin=15s[&session=id] with
+// the live session's punches per interval, or a stored session's.
+func heatmapHandler(analyzer *analytics.Analyzer, store *storage.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		bin := analytics.DefaultHeatmapBin
+		if v := r.URL.Query().Get("bin"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < analytics.HeatmapBaseResolution {
+				httpError(w, "Invalid bin: must be a duration of at least 1s", http.StatusBadRequest)
+				return
+			}
+			bin = d
+		}
+
+		id := r.URL.Query().Get("session")
+		if id == "" {
+			writeJSON(w, http.StatusOK, analyzer.Heatmap(bin))
+			return
+		}
+
+		sess, err := store.Get(id)
+		if errors.Is(err, storage.ErrNotFound) {
+			httpError(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Session load: %v", err)
+			httpError(w, "Failed to load session", http.StatusInternalServerError)
+			return
+		}
+		if sess.Heatmap == nil {
+			httpError(w, "Session has no heatmap", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, sess.Heatmap.Resample(bin))
+	}
+}
+
+// rhythmHandler returns the histogram of intervals between punches in the
+// running session (hand=left|right|both, bucket_ms, max_ms).
+func rhythmHandler(analyzer *analytics.Analyzer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		hand := q.Get("hand")
+		if hand == "" {
+			hand = "both"
+		}
+		bucketMS, maxMS := int64(analytics.DefaultRhythmBucketMS), int64(analytics.DefaultRhythmMaxMS)
+		if v := q.Get("bucket_ms"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				invalidField(w, "bucket_ms", fmt.Errorf("invalid bucket_ms %q", v))
+				return
+			}
+			bucketMS = n
+		}
+		if v := q.Get("max_ms"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				invalidField(w, "max_ms", fmt.Errorf("invalid max_ms %q", v))
+				return
+			}
+			maxMS = n
+		}
+
+		histogram, err := analyzer.IntervalHistogram(hand, bucketMS, maxMS)
+		if err != nil {
+			invalidRequest(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, histogram)
+	}
+}
+
+func patternsHandler(store *storage.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessions, err := store.List()
+		if err != nil {
+			log.Printf("Session list: %v", err)
+			httpError(w, "Failed to load sessions", http.StatusInternalServerError)
+			return
+		}
+
+		q := r.URL.Query()
+		patterns := storage.ComputePatterns(sessions, q.Get("athlete"), q.Get("gym"), time.Local)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(patterns)
+	}
+}
+
+// trendsHandler serves GET /api/stats/trends?metric=avg_force&window=90d,
+// a metric across the stored sessions (athlete, gym and rolling optional).
+func trendsHandler(store *storage.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpError(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		metric := q.Get("metric")
+		if metric == "" {
+			metric = "avg_force"
+		}
+		window := 90 * 24 * time.Hour
+		if v := q.Get("window"); v != "" {
+			d, err := storage.ParseWindow(v)
+			if err != nil {
+				invalidField(w, "window", fmt.Errorf("invalid window %q: must be a number of days (e.g. 90d) or a duration", v))
+				return
+			}
+			window = d
+		}
+		rolling := storage.DefaultTrendRolling
+		if v := q.Get("rolling"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				invalidField(w, "rolling", fmt.Errorf("invalid rolling %q: must be a positive number of sessions", v))
+				return
+			}
+			rolling = n
+		}
+
+		sessions, err := store.List()
+		if err != nil {
+			log.Printf("Session list: %v", err)
+			httpError(w, "Failed to load sessions", http.StatusInternalServerError)
+			return
+		}
+		trend, err := storage.ComputeTrend(sessions, metric, q.Get("athlete"), q.Get("gym"), window, rolling, time.Now())
+		if err != nil {
+			invalidField(w, "metric", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, trend)
+	}
+}
+
+// sessionsHandler serves the stored sessions: /api/sessions lists them
+// (tag= and athlete= filter), /api/sessions/{id} reads one or annotates it
+// (PATCH), and the per-session actions below /api/sessions/{id}/. Sessions
+// are read in units (see outputUnits).
+func sessionsHandler(store *storage.Store, profileStore *profiles.Store, recordingsDir string, units analytics.Units) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, apiV1+"/sessions"), "/")
+		if rest == "" {
+			listSessions(w, r, store, profileStore, units)
+			return
+		}
+		id, action, _ := strings.Cut(rest, "/")
+		if id == "." || id == ".." || strings.Contains(id, `\`) {
+			notFoundHandler(w, r)
+			return
+		}
+		switch action {
+		case "":
+			storedSession(w, r, store, profileStore, id, units)
+		case "punches":
+			storedPunches(w, r, store, profileStore, id, units)
+		case "reanalyze":
+			reanalyze(w, r, recordingsDir, id)
+		case "health":
+			healthExport(w, r, store, id)
+		default:
+			notFoundHandler(w, r)
+		}
+	}
+}
+
+// outputUnits returns the units the sessions of athlete ("" = several) are
+// served in: units= of the request, else the athlete's profile, else def.
+func outputUnits(r *http.Request, profileStore *profiles.Store, athlete string, def analytics.Units) (analytics.Units, error) {
+	return sessionUnits(r.URL.Query().Get("units"), profileStore, athlete, def)
+}
+
+// sessionUnits returns the units the sessions of athlete are served in:
+// the unit preference v if given, else the athlete's profile, else def.
+func sessionUnits(v string, profileStore *profiles.Store, athlete string, def analytics.Units) (analytics.Units, error) {
+	if v != "" {
+		return analytics.ParseUnits(v)
+	}
+	if profile, err := profileStore.Get(athlete); err == nil && profile.Units != nil {
+		return *profile.Units, nil
+	}
+	return def, nil
+}
+
+// listSessions serves GET /api/sessions, the summaries of the stored
+// sessions, newest first.
+func listSessions(w http.ResponseWriter, r *http.Request, store *storage.Store, profileStore *profiles.Store, def analytics.Units) {
+	if r.Method != http.MethodGet {
+		httpError(w, "GET only", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	tag, athlete := q.Get("tag"), q.Get("athlete")
+	units, err := outputUnits(r, profileStore, athlete, def)
+	if err != nil {
+		invalidField(w, "units", err)
+		return
+	}
+
+	sessions, err := store.Sessions(storage.SessionQuery{Athlete: athlete, Tag: tag})
+	if err != nil {
+		log.Printf("Session list: %v", err)
+		httpError(w, "Failed to load sessions", http.StatusInternalServerError)
+		return
+	}
+	list := make([]storage.Summary, 0, len(sessions))
+	for _, sess := range sessions {
+		list = append(list, sess.Summary().InUnits(units))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// storedSession serves /api/sessions/{id}: GET returns the stored session,
+// PATCH changes its notes, tags and perceived exertion; fields left out of
+// the body stay as they are.
+func storedSession(w http.ResponseWriter, r *http.Request, store *storage.Store, profileStore *profiles.Store, id string, def analytics.Units) {
+	switch r.Method {
+	case http.MethodGet:
+		sess, err := store.Get(id)
+		if errors.Is(err, storage.ErrNotFound) {
+			httpError(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Session load: %v", err)
+			httpError(w, "Failed to load session", http.StatusInternalServerError)
+			return
+		}
+		units, err := outputUnits(r, profileStore, sess.Athlete, def)
+		if err != nil {
+			invalidField(w, "units", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, sess.InUnits(units))
+	case http.MethodPatch:
+		var a storage.Annotation
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			invalidBody(w, err)
+			return
+		}
+		if err := a.Validate(); err != nil {
+			invalidRequest(w, err)
+			return
+		}
+		sess, err := store.Annotate(id, a)
+		if errors.Is(err, storage.ErrNotFound) {
+			httpError(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Session annotate %s: %v", id, err)
+			httpError(w, "Failed to save session", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Session %s annotated", id)
+		writeJSON(w, http.StatusOK, sess.Summary())
+	default:
+		httpError(w, "GET or PATCH only", http.StatusMethodNotAllowed)
+	}
+}
+
+// storedPunches serves GET /api/sessions/{id}/punches, a page of the
+// session's punch events: hand=, type= and min_force= (in the output units)
+// filter them, limit= sizes the page and cursor= continues after the
+// previous one, from its next.
+func storedPunches(w http.ResponseWriter, r *http.Request, store *storage.Store, profileStore *profiles.Store, id string, def analytics.Units) {
+	if r.Method != http.MethodGet {
+		httpError(w, "GET only", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	query := storage.PunchQuery{Hand: q.Get("hand"), Type: analytics.PunchType(q.Get("type"))}
+	switch query.Hand {
+	case "", "left", "right":
+	default:
+		httpError(w, "Invalid hand: must be 'left' or 'right'", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("min_force"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			httpError(w, "Invalid min_force", http.StatusBadRequest)
+			return
+		}
+		query.MinForce = f
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > storage.MaxPunchLimit {
+			httpError(w, fmt.Sprintf("Invalid limit: must be 1-%d", storage.MaxPunchLimit), http.StatusBadRequest)
+			return
+		}
+		query.Limit = n
+	}
+	if v := q.Get("cursor"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			httpError(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		query.After = n
+	}
+
+	sess, err := store.Get(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		httpError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Session load: %v", err)
+		httpError(w, "Failed to load session", http.StatusInternalServerError)
+		return
+	}
+	if query.Units, err = outputUnits(r, profileStore, sess.Athlete, def); err != nil {
+		invalidField(w, "units", err)
+		return
+	}
+
+	page, err := store.Punches(id, query)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrNoPunches) {
+		httpError(w, "No punch events stored for session", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Session punches %s: %v", id, err)
+		httpError(w, "Failed to load punches", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// reanalyze serves POST /api/sessions/{id}/reanalyze, rerunning a
+// session's raw recording with new parameters.
+func reanalyze(w http.ResponseWriter, r *http.Request, recordingsDir, id string) {
+	if r.Method != http.MethodPost {
+		httpError(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var params replay.Params
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			invalidBody(w, err)
+			return
+		}
+	}
+	switch params.Stance {
+	case "", analytics.StanceOrthodox, analytics.StanceSouthpaw:
+	default:
+		httpError(w, "Invalid stance: must be 'orthodox' or 'southpaw'", http.StatusBadRequest)
+		return
+	}
+	if params.Threshold < 0 {
+		httpError(w, "Invalid threshold", http.StatusBadRequest)
+		return
+	}
+
+	rec, err := replay.Load(recordingPath(recordingsDir, id))
+	if errors.Is(err, os.ErrNotExist) {
+		httpError(w, "No raw recording for session", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Recording load: %v", err)
+		httpError(w, "Failed to load recording", http.StatusInternalServerError)
+		return
+	}
+
+	cmp, err := replay.Reanalyze(rec, params)
+	if err != nil {
+		log.Printf("Reanalyze %s: %v", id, err)
+		httpError(w, "Reanalysis failed", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, cmp)
+}
+
+// recordingPath returns where a session's raw recording is stored.
+func recordingPath(dir, id string) string {
+	return filepath.Join(dir, id+".json")
+}
+
+// healthExport serves GET /api/sessions/{id}/health?format=healthkit|healthconnect
+// [&weight_kg=70] as a downloadable workout for the phone's fitness store.
+func healthExport(w http.ResponseWriter, r *http.Request, store *storage.Store, id string) {
+	if r.Method != http.MethodGet {
+		httpError(w, "GET only", http.StatusMethodNotAllowed)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = health.FormatHealthConnect
+	}
+	weight := float64(health.DefaultWeightKg)
+	if v := r.URL.Query().Get("weight_kg"); v != "" {
+		kg, err := strconv.ParseFloat(v, 64)
+		if err != nil || kg <= 0 {
+			httpError(w, "Invalid weight_kg", http.StatusBadRequest)
+			return
+		}
+		weight = kg
+	}
+
+	sess, err := store.Get(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		httpError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Session load: %v", err)
+		httpError(w, "Failed to load session", http.StatusInternalServerError)
+		return
+	}
+	payload, ok := health.Export(sess, format, weight)
+	if !ok {
+		httpError(w, "Invalid format: must be 'healthkit' or 'healthconnect'", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.json"`, sess.ID, format))
+	writeJSON(w, http.StatusOK, payload)
+}
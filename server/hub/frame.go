@@ -0,0 +1,62 @@
+package hub
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledFrame is the largest frame buffer returned to the pool; the odd
+// huge message (a session export) should not pin its memory.
+const maxPooledFrame = 64 << 10
+
+// framePool recycles frame buffers between broadcasts.
+var framePool = sync.Pool{New: func() interface{} { return new(frame) }}
+
+// frame is an encoded WebSocket frame shared by every client it is queued
+// for. Whoever holds a reference calls release when done with it;
+// the last release returns the buffer to the pool.
+type frame struct {
+	buf  []byte
+	refs atomic.Int32
+}
+
+// newFrame encodes payload as a frame of opcode (wsText or wsBinary),
+// holding one reference.
+func newFrame(opcode byte, payload []byte) *frame {
+	f := framePool.Get().(*frame)
+	f.buf = appendFrame(f.buf[:0], opcode, payload)
+	f.refs.Store(1)
+	return f
+}
+
+// retain adds a reference.
+func (f *frame) retain() {
+	f.refs.Add(1)
+}
+
+// release drops a reference.
+func (f *frame) release() {
+	if f.refs.Add(-1) != 0 {
+		return
+	}
+	if cap(f.buf) <= maxPooledFrame {
+		framePool.Put(f)
+	}
+}
+
+// appendFrame appends an unmasked, final frame of opcode carrying payload.
+func appendFrame(dst []byte, opcode byte, payload []byte) []byte {
+	length := len(payload)
+	switch {
+	case length < 126:
+		dst = append(dst, 0x80|opcode, byte(length))
+	case length < 65536:
+		dst = append(dst, 0x80|opcode, 126, byte(length>>8), byte(length))
+	default:
+		dst = append(dst, 0x80|opcode, 127,
+			0, 0, 0, 0,
+			byte(length>>24), byte(length>>16), byte(length>>8), byte(length),
+		)
+	}
+	return append(dst, payload...)
+}
@@ -0,0 +1,546 @@
+// Package hub fans analytics broadcasts out to WebSocket clients.
+package hub
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxClientLabel bounds the name and role a client connects with.
+const maxClientLabel = 64
+
+// sendQueueSize is how many frames may wait for a client before it counts
+// as slow.
+const sendQueueSize = 64
+
+// writeTimeout bounds writing one frame; a client that stops reading
+// without closing the connection is disconnected after it.
+const writeTimeout = 10 * time.Second
+
+// Slow-client drop policies: which frame Broadcast discards when a client's
+// send queue is full
+const (
+	DropNewest = "drop-newest" // the new frame (the default)
+	DropOldest = "drop-oldest" // the oldest queued frame, making room for the new one
+)
+
+// SlowClientPolicy decides what happens to clients that cannot keep up.
+type SlowClientPolicy struct {
+	Drop     string // DropNewest or DropOldest
+	MaxDrops int    // Disconnect after this many drops in a row, 0 = never
+}
+
+// Validate checks the policy.
+func (p *SlowClientPolicy) Validate() error {
+	if p.Drop != DropNewest && p.Drop != DropOldest {
+		return fmt.Errorf("invalid drop policy %q: must be 'drop-newest' or 'drop-oldest'", p.Drop)
+	}
+	if p.MaxDrops < 0 {
+		return fmt.Errorf("invalid max drops %d", p.MaxDrops)
+	}
+	return nil
+}
+
+// Encodings clients take broadcasts in
+const (
+	EncodingJSON     = "json"     // text frames (the default)
+	EncodingProtobuf = "protobuf" // binary frames, see Message
+)
+
+// ProtobufProtocol is the WebSocket subprotocol a client offers to take
+// broadcasts as protobuf, like connecting with ?format=protobuf. It names the
+// version of the schema, so that a later incompatible one can be told apart.
+const ProtobufProtocol = "smartpunch.v1.protobuf"
+
+// Message is a broadcast in each encoding: JSON, and protobuf for the
+// messages that have a protobuf form.
+type Message struct {
+	JSON     []byte
+	Protobuf []byte // nil = protobuf clients get JSON as well
+}
+
+// ─── WebSocket Hub ────────────────────────────────────────────────────────────
+
+type wsClient struct {
+	conn net.Conn
+	send chan *frame // written to conn by the client's writer goroutine
+
+	id          string
+	name, role  string // as given at connect, "" = none
+	userAgent   string
+	topics      []string // opted into at connect, see BroadcastTopic
+	protobuf    bool     // takes EncodingProtobuf
+	connectedAt time.Time
+	sent        atomic.Int64
+	dropped     atomic.Int64
+	streak      int  // frames dropped in a row, guarded by Hub.mu
+	kicked      bool // disconnected for being slow, guarded by Hub.mu
+}
+
+// ClientInfo describes a connected WebSocket client.
+type ClientInfo struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name,omitempty"`
+	Role          string    `json:"role,omitempty"`
+	Addr          string    `json:"addr"`
+	UserAgent     string    `json:"user_agent,omitempty"`
+	Topics        []string  `json:"topics,omitempty"`
+	Encoding      string    `json:"encoding"` // EncodingJSON or EncodingProtobuf
+	ConnectedAt   time.Time `json:"connected_at"`
+	FramesSent    int64     `json:"frames_sent"`
+	FramesDropped int64     `json:"frames_dropped"` // dropped because the client fell behind
+	Queued        int       `json:"queued"`         // frames waiting to be written
+}
+
+// Stats are the hub's totals since it was created, including clients that
+// have since disconnected.
+type Stats struct {
+	Clients         int   `json:"clients"`
+	FramesSent      int64 `json:"frames_sent"`
+	FramesDropped   int64 `json:"frames_dropped"`
+	SlowDisconnects int64 `json:"slow_disconnects"` // clients dropped under SlowClientPolicy.MaxDrops
+}
+
+// Hub tracks connected WebSocket clients.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+	lastID  int
+	policy  SlowClientPolicy
+	seq     uint64      // last published event
+	events  []sequenced // replay buffer, oldest first
+
+	published chan struct{} // closed by the next Publish, nil = nobody waiting
+
+	sent            atomic.Int64
+	dropped         atomic.Int64
+	slowDisconnects atomic.Int64
+}
+
+// New creates a hub with no clients that drops the newest frame for slow
+// clients and never disconnects them.
+func New() *Hub {
+	return &Hub{
+		clients: make(map[*wsClient]struct{}),
+		policy:  SlowClientPolicy{Drop: DropNewest},
+	}
+}
+
+// SetSlowClientPolicy changes how slow clients are handled.
+func (h *Hub) SetSlowClientPolicy(policy SlowClientPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.policy = policy
+	h.mu.Unlock()
+	return nil
+}
+
+// registerLocked adds a client to the hub.
+// Must be called with h.mu held.
+func (h *Hub) registerLocked(c *wsClient) {
+	h.lastID++
+	c.id = fmt.Sprintf("c%d", h.lastID)
+	h.clients[c] = struct{}{}
+}
+
+func (h *Hub) unregister(c *wsClient) {
+	h.mu.Lock()
+	_, exists := h.clients[c]
+	if exists {
+		delete(h.clients, c)
+		close(c.send) // the writer releases what is still queued
+	}
+	h.mu.Unlock()
+}
+
+// Broadcast sends payload to every client as a text frame. The frame is
+// encoded once, into a pooled buffer, and shared by all clients; payload is
+// not retained.
+func (h *Hub) Broadcast(payload []byte) {
+	h.BroadcastMessage(Message{JSON: payload})
+}
+
+// BroadcastMessage sends m to every client in the encoding it took, like
+// Broadcast.
+func (h *Hub) BroadcastMessage(m Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.broadcastLocked("", m)
+}
+
+// BroadcastTopic sends payload to the clients that opted into topic with
+// ?topics= when they connected, like Broadcast does to all of them.
+func (h *Hub) BroadcastTopic(topic string, payload []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.broadcastLocked(topic, Message{JSON: payload})
+}
+
+// Subscribers returns how many clients opted into topic, so that callers
+// can skip encoding what nobody would receive.
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for c := range h.clients {
+		if c.subscribed(topic) {
+			n++
+		}
+	}
+	return n
+}
+
+// broadcastLocked sends m to every client subscribed to topic, or to all of
+// them for "", applying the slow-client policy. Each encoding is framed once,
+// when the first client taking it is reached.
+// Must be called with h.mu held.
+func (h *Hub) broadcastLocked(topic string, m Message) {
+	var text, binary *frame
+	defer func() {
+		if text != nil {
+			text.release()
+		}
+		if binary != nil {
+			binary.release()
+		}
+	}()
+	for c := range h.clients {
+		if c.kicked || (topic != "" && !c.subscribed(topic)) {
+			continue
+		}
+		if c.protobuf && m.Protobuf != nil {
+			if binary == nil {
+				binary = newFrame(wsBinary, m.Protobuf)
+			}
+			h.queueLocked(c, binary)
+		} else {
+			if text == nil {
+				text = newFrame(wsText, m.JSON)
+			}
+			h.queueLocked(c, text)
+		}
+	}
+}
+
+// queueLocked queues f for c, taking a reference, or drops a frame when c
+// is slow.
+// Must be called with h.mu held.
+func (h *Hub) queueLocked(c *wsClient, f *frame) {
+	f.retain()
+	select {
+	case c.send <- f:
+		c.streak = 0
+		return
+	default:
+	}
+
+	// Slow client — drop a frame
+	if h.policy.Drop == DropOldest {
+		select {
+		case old := <-c.send:
+			old.release()
+		default:
+		}
+	}
+	select {
+	case c.send <- f:
+	default:
+		f.release()
+	}
+	c.dropped.Add(1)
+	h.dropped.Add(1)
+	c.streak++
+	if h.policy.MaxDrops > 0 && c.streak >= h.policy.MaxDrops {
+		c.kicked = true
+		h.slowDisconnects.Add(1)
+		log.Printf("WS client %s too slow (%d frames dropped in a row), disconnecting", c.id, c.streak)
+		c.conn.Close()
+	}
+}
+
+// Stats returns the hub's totals.
+func (h *Hub) Stats() Stats {
+	h.mu.Lock()
+	clients := len(h.clients)
+	h.mu.Unlock()
+	return Stats{
+		Clients:         clients,
+		FramesSent:      h.sent.Load(),
+		FramesDropped:   h.dropped.Load(),
+		SlowDisconnects: h.slowDisconnects.Load(),
+	}
+}
+
+// Clients lists the connected clients in the order they connected.
+func (h *Hub) Clients() []ClientInfo {
+	h.mu.Lock()
+	out := make([]ClientInfo, 0, len(h.clients))
+	for c := range h.clients {
+		out = append(out, ClientInfo{
+			ID:            c.id,
+			Name:          c.name,
+			Role:          c.role,
+			Addr:          c.conn.RemoteAddr().String(),
+			UserAgent:     c.userAgent,
+			Topics:        c.topics,
+			Encoding:      c.encoding(),
+			ConnectedAt:   c.connectedAt,
+			FramesSent:    c.sent.Load(),
+			FramesDropped: c.dropped.Load(),
+			Queued:        len(c.send),
+		})
+	}
+	h.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].ConnectedAt.Before(out[j].ConnectedAt) })
+	return out
+}
+
+// ─── WebSocket Handshake ──────────────────────────────────────────────────────
+
+func wsAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(strings.TrimSpace(key) + wsGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// upgradeToWS completes the handshake, accepting protocol ("" = none) as the
+// subprotocol.
+func upgradeToWS(w http.ResponseWriter, r *http.Request, protocol string) (net.Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, fmt.Errorf("missing Sec-WebSocket-Key")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("hijacking not supported")
+	}
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + wsAcceptKey(key) + "\r\n"
+	if protocol != "" {
+		resp += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+	resp += "\r\n"
+	if _, err := buf.WriteString(resp); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := buf.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// ─── HTTP Handler ─────────────────────────────────────────────────────────────
+
+// Handler upgrades requests to WebSocket connections and registers them
+// with the hub. Clients may identify themselves with ?name= and ?role=
+// (e.g. "coach", "display") and opt into topics with ?topics=a,b. Each hello encodes a message every client gets
+// on connect, before any broadcast, e.g. the current state; one that fails
+// is skipped. A client reconnecting with ?since=SEQ then gets the published
+// events it missed that the replay buffer still holds. Clients take JSON
+// text frames unless they offer ProtobufProtocol or connect with
+// ?format=protobuf.
+func (h *Hub) Handler(hello ...func() (Message, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		protobuf := false
+		switch r.URL.Query().Get("format") {
+		case "", EncodingJSON:
+		case EncodingProtobuf:
+			protobuf = true
+		default:
+			http.Error(w, "Invalid format: must be json or protobuf", http.StatusBadRequest)
+			return
+		}
+		protocol := ""
+		if offered(r, ProtobufProtocol) {
+			protocol, protobuf = ProtobufProtocol, true
+		}
+		conn, err := upgradeToWS(w, r, protocol)
+		if err != nil {
+			log.Printf("WS upgrade: %v", err)
+			http.Error(w, "WS upgrade failed", http.StatusBadRequest)
+			return
+		}
+
+		client := &wsClient{
+			conn:        conn,
+			name:        clientLabel(r.URL.Query().Get("name")),
+			role:        clientLabel(r.URL.Query().Get("role")),
+			userAgent:   r.UserAgent(),
+			topics:      parseTopics(r.URL.Query().Get("topics")),
+			protobuf:    protobuf,
+			connectedAt: time.Now(),
+		}
+		var snapshot []Message
+		for _, encode := range hello {
+			if m, err := encode(); err == nil {
+				snapshot = append(snapshot, m)
+			} else {
+				log.Printf("WS hello: %v", err)
+			}
+		}
+
+		// Queue the snapshot and missed events before any broadcast, with
+		// room for all of them on top of the usual queue
+		h.mu.Lock()
+		var missed []sequenced
+		since, resume := parseSince(r.URL.Query().Get("since"))
+		if resume {
+			missed = h.sinceLocked(since)
+		}
+		client.send = make(chan *frame, sendQueueSize+len(snapshot)+len(missed))
+		for _, m := range snapshot {
+			client.send <- client.frame(m)
+		}
+		for _, ev := range missed {
+			client.send <- client.frame(ev.msg)
+		}
+		h.registerLocked(client)
+		h.mu.Unlock()
+
+		if resume {
+			log.Printf("WS client %s resumed after event %d: %s%s (%d missed events)",
+				client.id, since, conn.RemoteAddr(), client.describe(), len(missed))
+		} else {
+			log.Printf("WS client %s connected: %s%s", client.id, conn.RemoteAddr(), client.describe())
+		}
+
+		go h.writePump(client)
+
+		// Read pump — consume frames to detect disconnect
+		rbuf := make([]byte, 512)
+		for {
+			if _, err := conn.Read(rbuf); err != nil {
+				break
+			}
+		}
+		h.unregister(client)
+	}
+}
+
+// writePump writes the client's queued frames until the hub unregisters it.
+// A failed or timed-out write closes the connection, which ends the read
+// pump and so unregisters the client; frames still queued are released.
+func (h *Hub) writePump(c *wsClient) {
+	var err error
+	for f := range c.send {
+		if err == nil {
+			c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if _, err = c.conn.Write(f.buf); err != nil {
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					log.Printf("WS client %s write timed out", c.id)
+				}
+				c.conn.Close()
+			} else {
+				c.sent.Add(1)
+				h.sent.Add(1)
+			}
+		}
+		f.release()
+	}
+	c.conn.Close()
+	log.Printf("WS client %s disconnected: %s%s", c.id, c.conn.RemoteAddr(), c.describe())
+}
+
+// clientLabel trims a client-supplied name or role to maxClientLabel bytes.
+func clientLabel(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > maxClientLabel {
+		s = s[:maxClientLabel]
+	}
+	return s
+}
+
+// parseTopics splits a comma-separated ?topics= list, dropping empty and
+// repeated names.
+func parseTopics(s string) []string {
+	var topics []string
+	for _, t := range strings.Split(s, ",") {
+		t = clientLabel(t)
+		if t == "" || contains(topics, t) {
+			continue
+		}
+		topics = append(topics, t)
+	}
+	return topics
+}
+
+// offered reports whether the client offered protocol as a WebSocket
+// subprotocol.
+func offered(r *http.Request, protocol string) bool {
+	for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(v, ",") {
+			if strings.TrimSpace(p) == protocol {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// encoding returns the encoding the client takes.
+func (c *wsClient) encoding() string {
+	if c.protobuf {
+		return EncodingProtobuf
+	}
+	return EncodingJSON
+}
+
+// frame encodes m as a frame of the client's encoding, holding one
+// reference.
+func (c *wsClient) frame(m Message) *frame {
+	if c.protobuf && m.Protobuf != nil {
+		return newFrame(wsBinary, m.Protobuf)
+	}
+	return newFrame(wsText, m.JSON)
+}
+
+// subscribed reports whether the client opted into topic.
+func (c *wsClient) subscribed(topic string) bool {
+	return contains(c.topics, topic)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// describe formats the client's name and role for log lines.
+func (c *wsClient) describe() string {
+	switch {
+	case c.name != "" && c.role != "":
+		return fmt.Sprintf(" (%s, %s)", c.name, c.role)
+	case c.name != "":
+		return " (" + c.name + ")"
+	case c.role != "":
+		return " (" + c.role + ")"
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package hub
+
+import (
+	"context"
+	"strconv"
+)
+
+// replaySize is how many published events the hub keeps for clients that
+// resume with ?since=.
+const replaySize = 1024
+
+// sequenced is a published event with its sequence number.
+type sequenced struct {
+	seq uint64
+	msg Message
+}
+
+// Publish broadcasts an event numbered with the hub's next sequence number
+// and keeps it, among the last replaySize, for clients that reconnect with
+// ?since=SEQ. encode marshals the event carrying seq; the hub keeps the
+// message it returns. Sequence numbers start at 1 and restart with the
+// server.
+func (h *Hub) Publish(encode func(seq uint64) (Message, error)) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seq := h.seq + 1
+	m, err := encode(seq)
+	if err != nil {
+		return err
+	}
+	h.seq = seq
+	h.events = append(h.events, sequenced{seq: seq, msg: m})
+	if len(h.events) > replaySize {
+		h.events[0] = sequenced{}
+		h.events = h.events[1:]
+	}
+	h.broadcastLocked("", m)
+	if h.published != nil {
+		close(h.published)
+		h.published = nil
+	}
+	return nil
+}
+
+// Events returns the kept events published after since, as JSON and oldest
+// first, and the sequence number of the last event published. As with
+// ?since=, the first event is numbered above since+1 when the rest fell out
+// of the buffer. If none was published after since, Events waits for one
+// until ctx is done.
+func (h *Hub) Events(ctx context.Context, since uint64) ([][]byte, uint64) {
+	for {
+		h.mu.Lock()
+		kept := h.sinceLocked(since)
+		seq := h.seq
+		if len(kept) > 0 {
+			events := make([][]byte, len(kept))
+			for i, ev := range kept {
+				events[i] = ev.msg.JSON
+			}
+			h.mu.Unlock()
+			return events, seq
+		}
+		if since > seq {
+			since = seq // numbered before a restart: wait for the next event
+		}
+		if h.published == nil {
+			h.published = make(chan struct{})
+		}
+		published := h.published
+		h.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, seq
+		case <-published:
+		}
+	}
+}
+
+// Seq returns the sequence number of the last published event, 0 before
+// the first.
+func (h *Hub) Seq() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.seq
+}
+
+// sinceLocked returns the kept events published after since, oldest first.
+// Events that fell out of the buffer are missing: the first returned is then
+// numbered above since+1.
+// Must be called with h.mu held.
+func (h *Hub) sinceLocked(since uint64) []sequenced {
+	if len(h.events) == 0 || since >= h.seq {
+		return nil
+	}
+	first := h.events[0].seq
+	if since < first {
+		return h.events
+	}
+	return h.events[since-first+1:]
+}
+
+// parseSince reads a ?since= value; ok is false when it is absent or
+// invalid.
+func parseSince(v string) (since uint64, ok bool) {
+	if v == "" {
+		return 0, false
+	}
+	since, err := strconv.ParseUint(v, 10, 64)
+	return since, err == nil
+}
@@ -0,0 +1,36 @@
+package ingest
+
+import (
+	"context"
+
+	"boxing-analytics/ble"
+)
+
+// BLESource delivers the packets of the gloves a Central is connected to.
+// Connecting them (Enable, Scanner) stays with the caller.
+type BLESource struct {
+	central *ble.Central
+}
+
+// NewBLESource creates a source for a Central's gloves.
+func NewBLESource(central *ble.Central) *BLESource {
+	return &BLESource{central: central}
+}
+
+// Name implements Source.
+func (s *BLESource) Name() string { return "ble" }
+
+// Run implements Source. It takes over the Central's packet handler until
+// ctx is cancelled.
+func (s *BLESource) Run(ctx context.Context, handler Handler) error {
+	s.central.SetPacketHandler(func(hand ble.Hand, packet *ble.SensorPacket) {
+		sample := Sample{Source: s.Name(), Hand: hand, Packet: packet}
+		if glove := s.central.GetGlove(hand); glove != nil {
+			sample.Device = glove.Address.String()
+		}
+		handler(sample)
+	})
+	<-ctx.Done()
+	s.central.SetPacketHandler(nil)
+	return nil
+}
@@ -0,0 +1,105 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+)
+
+// discoverRequest starts the datagram a glove broadcasts to find the
+// server: "discover", optionally followed by a space and an identity that
+// survives reboots and DHCP, e.g. its MAC address.
+const discoverRequest = "discover"
+
+// ProtocolVersion is the version of the UDP protocol gloves speak to the
+// server. 1: sample datagrams; 2: adds discovery and firmware updates.
+const ProtocolVersion = 2
+
+// maxPacketVersion is the newest sensor packet format sample datagrams may
+// carry.
+const maxPacketVersion = 3
+
+// DiscoveryInfo describes the server to gloves discovering it.
+type DiscoveryInfo struct {
+	Version  string // Server version
+	HTTPPort int    // Port of the REST API, WebSocket and /firmware/
+}
+
+// discoveryReply answers a discover datagram, as JSON.
+type discoveryReply struct {
+	Server           string `json:"server"` // always "smart-punch"
+	Version          string `json:"version"`
+	Protocol         int    `json:"protocol"`
+	MaxPacketVersion int    `json:"max_packet_version"`
+	HTTPPort         int    `json:"http_port,omitempty"`
+	UDPPort          int    `json:"udp_port"`
+	DeviceID         int    `json:"device_id"` // assigned to the glove, the same on every discovery
+}
+
+// SetDiscoveryInfo sets what the source tells gloves discovering the
+// server. Set it before Run.
+func (s *UDPSource) SetDiscoveryInfo(info DiscoveryInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info = info
+}
+
+// isDiscoverRequest reports whether a datagram is a discover request.
+func isDiscoverRequest(data []byte) bool {
+	rest, ok := bytes.CutPrefix(data, []byte(discoverRequest))
+	return ok && (len(rest) == 0 || rest[0] == ' ')
+}
+
+// handleDiscover answers a discover request from peer. Requests arrive
+// unicast or as broadcasts, including directed broadcasts a router forwards
+// from another subnet; the reply always goes straight back to the peer.
+func (s *UDPSource) handleDiscover(conn net.PacketConn, peer net.Addr, data []byte) error {
+	ip := peer.String()
+	if udp, ok := peer.(*net.UDPAddr); ok {
+		ip = udp.IP.String()
+	}
+	identity := strings.TrimSpace(string(data[len(discoverRequest):]))
+	who := fmt.Sprintf("glove %s at %s", identity, ip)
+	if identity == "" {
+		identity = ip
+		who = "glove at " + ip
+	}
+
+	s.mu.Lock()
+	id, known := s.deviceIDs[identity]
+	if !known {
+		id = len(s.deviceIDs) + 1
+		s.deviceIDs[identity] = id
+	}
+	s.ipDeviceIDs[ip] = id
+	if identity != ip {
+		s.identities[ip] = identity
+	}
+	reply := discoveryReply{
+		Server:           "smart-punch",
+		Version:          s.info.Version,
+		Protocol:         ProtocolVersion,
+		MaxPacketVersion: maxPacketVersion,
+		HTTPPort:         s.info.HTTPPort,
+		DeviceID:         id,
+	}
+	s.mu.Unlock()
+	if local, ok := conn.LocalAddr().(*net.UDPAddr); ok {
+		reply.UDPPort = local.Port
+	}
+
+	if !known {
+		log.Printf("UDP: %s discovered the server (device %d)", who, id)
+	}
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.WriteTo(data, peer); err != nil {
+		return fmt.Errorf("reply to %s: %w", peer, err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package ingest
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"boxing-analytics/ble"
+)
+
+// reorderDepth is how many samples a stream holds back while waiting for a
+// missing one: 30ms at 100Hz, longer than UDP or BLE take to reorder
+// samples and too short to delay detection noticeably.
+const reorderDepth = 3
+
+// reorderResetGap is the largest forward sequence jump still treated as
+// the same run; a bigger one means the glove restarted its counter.
+const reorderResetGap = 1000
+
+// maxLateMS is how far a sample's device timestamp may trail the last
+// delivered one and still count as late. A sample further back comes from a
+// glove that rebooted.
+const maxLateMS = 1000
+
+// orderReportInterval is how often a stream logs what it dropped.
+const orderReportInterval = time.Minute
+
+// streamKey identifies one glove's sample stream.
+type streamKey struct {
+	source string
+	device string
+	hand   ble.Hand
+}
+
+// orderer delivers each stream's samples in sequence order and drops
+// duplicates and samples that arrive after later ones were delivered, so a
+// UDP retransmission or a coalesced BLE notification cannot count a spike
+// twice or run detection backwards in time.
+type orderer struct {
+	handler Handler
+
+	mu      sync.Mutex
+	streams map[streamKey]*stream
+}
+
+// stream is the ordering state of one glove.
+type stream struct {
+	mu      sync.Mutex
+	started bool
+	lastSeq uint16   // last sequence number delivered
+	lastTS  uint32   // its device timestamp
+	held    []Sample // waiting for a gap before them, in sequence order
+
+	duplicates, late, reordered int // since reportedAt
+	reportedAt                  time.Time
+}
+
+// newOrderer creates an orderer in front of handler.
+func newOrderer(handler Handler) *orderer {
+	return &orderer{handler: handler, streams: make(map[streamKey]*stream)}
+}
+
+// handle is the orderer's Handler.
+func (o *orderer) handle(sample Sample) {
+	if sample.At.IsZero() {
+		sample.At = time.Now() // held samples keep their arrival time
+	}
+	key := streamKey{sample.Source, sample.Device, sample.Hand}
+	o.mu.Lock()
+	st := o.streams[key]
+	if st == nil {
+		st = &stream{reportedAt: sample.At}
+		o.streams[key] = st
+	}
+	o.mu.Unlock()
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.addLocked(sample, o.handler)
+	st.reportLocked(key, sample.At)
+}
+
+// addLocked delivers sample, and any held samples it releases, or holds or
+// drops it.
+// Must be called with st.mu held.
+func (st *stream) addLocked(sample Sample, handler Handler) {
+	seq := sample.Packet.Sequence
+	if !st.started {
+		st.deliverLocked(sample, handler)
+		return
+	}
+
+	delta := int(seq - st.lastSeq) // Wraps, so 65535 → 0 is a delta of 1
+	switch {
+	case delta == 0 || delta > math.MaxUint16-reorderResetGap:
+		if st.lastTS-sample.Packet.Timestamp > maxLateMS {
+			st.restartLocked(sample, handler) // further back than late: a reboot
+		} else if delta == 0 {
+			st.duplicates++
+		} else {
+			st.late++
+		}
+		return
+	case delta > reorderResetGap:
+		st.restartLocked(sample, handler)
+		return
+	}
+
+	// Hold it in sequence order unless it is already held
+	i := 0
+	for ; i < len(st.held); i++ {
+		d := int(st.held[i].Packet.Sequence - st.lastSeq)
+		if d == delta {
+			st.duplicates++
+			return
+		}
+		if d > delta {
+			break
+		}
+	}
+	if delta == 1 && len(st.held) > 0 {
+		st.reordered++ // fills the gap the held samples wait on
+	}
+	st.held = append(st.held, Sample{})
+	copy(st.held[i+1:], st.held[i:])
+	st.held[i] = sample
+
+	// Give up on a gap once too many samples wait behind it
+	if len(st.held) > reorderDepth || delta == 1 {
+		st.deliverLocked(st.held[0], handler)
+		st.held = st.held[1:]
+	}
+	for len(st.held) > 0 && st.held[0].Packet.Sequence == st.lastSeq+1 {
+		st.deliverLocked(st.held[0], handler)
+		st.held = st.held[1:]
+	}
+}
+
+// restartLocked starts the stream over from sample after the glove reset
+// its counter, delivering what was held first.
+// Must be called with st.mu held.
+func (st *stream) restartLocked(sample Sample, handler Handler) {
+	for _, held := range st.held {
+		handler(held)
+	}
+	st.held = nil
+	st.deliverLocked(sample, handler)
+}
+
+// deliverLocked passes a sample on and makes it the last delivered.
+// Must be called with st.mu held.
+func (st *stream) deliverLocked(sample Sample, handler Handler) {
+	st.started = true
+	st.lastSeq = sample.Packet.Sequence
+	st.lastTS = sample.Packet.Timestamp
+	handler(sample)
+}
+
+// reportLocked logs what the stream dropped or reordered, at most every
+// orderReportInterval.
+// Must be called with st.mu held.
+func (st *stream) reportLocked(key streamKey, now time.Time) {
+	if now.Sub(st.reportedAt) < orderReportInterval {
+		return
+	}
+	if st.duplicates+st.late+st.reordered > 0 {
+		log.Printf("Ingest: %s %s (%s hand): dropped %d duplicate and %d late samples, reordered %d in the last %s",
+			key.source, key.device, key.hand, st.duplicates, st.late, st.reordered, now.Sub(st.reportedAt).Round(time.Second))
+	}
+	st.duplicates, st.late, st.reordered = 0, 0, 0
+	st.reportedAt = now
+}
@@ -0,0 +1,262 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"boxing-analytics/ble"
+)
+
+// Control datagrams. Sample datagrams start with a hand byte, 0 or 1, so
+// these first bytes are free.
+const (
+	// DatagramUpdate tells a glove to fetch and apply a firmware update:
+	// [0xF0, SHA-256 of the image (32 bytes), image URL]
+	DatagramUpdate byte = 0xF0
+
+	// DatagramUpdateStatus reports an update's progress back:
+	// [0xF1, state (UpdateState*), percent, message]
+	DatagramUpdateStatus byte = 0xF1
+)
+
+// Update state bytes of a DatagramUpdateStatus
+const (
+	UpdateStateDownloading byte = 1
+	UpdateStateApplying    byte = 2 // image verified, being written to flash
+	UpdateStateDone        byte = 3 // rebooting into the new firmware
+	UpdateStateFailed      byte = 4 // message says why
+)
+
+// Update states as reported in UpdateStatus
+const (
+	UpdateRequested   = "requested" // sent, not yet acknowledged
+	UpdateDownloading = "downloading"
+	UpdateApplying    = "applying"
+	UpdateDone        = "done"
+	UpdateFailed      = "failed"
+)
+
+// updateStates maps state bytes to UpdateStatus states.
+var updateStates = map[byte]string{
+	UpdateStateDownloading: UpdateDownloading,
+	UpdateStateApplying:    UpdateApplying,
+	UpdateStateDone:        UpdateDone,
+	UpdateStateFailed:      UpdateFailed,
+}
+
+// updateAckTimeout is how long a requested update waits for the glove's
+// first progress report before it is marked failed.
+const updateAckTimeout = 15 * time.Second
+
+// Errors returned by UDPSource.StartUpdate
+var (
+	ErrNotRunning       = errors.New("UDP ingest not running")
+	ErrUnknownDevice    = errors.New("no glove is streaming from that device")
+	ErrUpdateInProgress = errors.New("update already in progress")
+)
+
+// UDPDevice is a glove streaming to a UDPSource.
+type UDPDevice struct {
+	Address  string        `json:"address"`             // UDP peer the glove sends from
+	DeviceID int           `json:"device_id,omitempty"` // assigned on discovery, 0 = not discovered
+	Identity string        `json:"identity,omitempty"`  // sent on discovery, e.g. the glove's MAC address
+	Hand     string        `json:"hand"`
+	LastSeen time.Time     `json:"last_seen"`
+	Update   *UpdateStatus `json:"update,omitempty"` // latest firmware update, nil = none
+}
+
+// UpdateStatus is the progress of a firmware update.
+type UpdateStatus struct {
+	Version   string    `json:"version,omitempty"`
+	Campaign  int       `json:"campaign,omitempty"` // fleet campaign that offered the update, 0 = none
+	State     string    `json:"state"`              // UpdateRequested, UpdateDownloading, ...
+	Percent   int       `json:"percent"`            // of the download or flash write
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// active reports whether the update has yet to finish.
+func (u *UpdateStatus) active() bool {
+	return u != nil && u.State != UpdateDone && u.State != UpdateFailed
+}
+
+// SetUpdateHandler sets a function called whenever a glove's update
+// progresses. Set it before Run.
+func (s *UDPSource) SetUpdateHandler(handler func(UDPDevice)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onUpdate = handler
+}
+
+// Devices returns the gloves streaming to the source, by address. Updates
+// that time out on the way are passed to the update handler as failed.
+func (s *UDPSource) Devices() []UDPDevice {
+	s.mu.Lock()
+	devices := make([]UDPDevice, 0, len(s.devices))
+	var expired []UDPDevice
+	for _, d := range s.devices {
+		if s.expireLocked(d) {
+			expired = append(expired, d.snapshot())
+		}
+		devices = append(devices, d.snapshot())
+	}
+	handler := s.onUpdate
+	s.mu.Unlock()
+
+	if handler != nil {
+		for _, d := range expired {
+			handler(d)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Address < devices[j].Address })
+	return devices
+}
+
+// Device returns a glove by UDP address, or "left" or "right" for whichever
+// glove last streamed that hand.
+func (s *UDPSource) Device(device string) (UDPDevice, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.resolveLocked(device)
+	if d == nil {
+		return UDPDevice{}, fmt.Errorf("%w %q", ErrUnknownDevice, device)
+	}
+	s.expireLocked(d)
+	return d.snapshot(), nil
+}
+
+// StartUpdate tells a glove to update its firmware from url, checking the
+// image against sha256. device is as for Device; campaign is the fleet
+// campaign offering the update, reported back in UpdateStatus.
+func (s *UDPSource) StartUpdate(device, version, url string, sha256 []byte, campaign int) (UDPDevice, error) {
+	if len(sha256) != 32 {
+		return UDPDevice{}, fmt.Errorf("invalid SHA-256 of %d bytes", len(sha256))
+	}
+	msg := append([]byte{DatagramUpdate}, sha256...)
+	msg = append(msg, url...)
+	if len(msg) > maxDatagramSize {
+		return UDPDevice{}, fmt.Errorf("firmware URL too long")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return UDPDevice{}, ErrNotRunning
+	}
+	d := s.resolveLocked(device)
+	if d == nil {
+		return UDPDevice{}, fmt.Errorf("%w %q", ErrUnknownDevice, device)
+	}
+	s.expireLocked(d)
+	if d.Update.active() {
+		return UDPDevice{}, ErrUpdateInProgress
+	}
+	if _, err := s.conn.WriteTo(msg, d.peer); err != nil {
+		return UDPDevice{}, fmt.Errorf("send update to %s: %w", d.Address, err)
+	}
+	d.Update = &UpdateStatus{Version: version, Campaign: campaign, State: UpdateRequested, UpdatedAt: time.Now()}
+	return d.snapshot(), nil
+}
+
+// udpDevice is the source's record of a glove.
+type udpDevice struct {
+	UDPDevice
+	peer      net.Addr
+	checksums ble.ChecksumGuard
+}
+
+// snapshot copies the device for callers.
+func (d *udpDevice) snapshot() UDPDevice {
+	out := d.UDPDevice
+	if d.Update != nil {
+		u := *d.Update
+		out.Update = &u
+	}
+	return out
+}
+
+// seenLocked records a sample datagram from peer and returns its glove.
+// Must be called with s.mu held.
+func (s *UDPSource) seenLocked(peer net.Addr, hand string, now time.Time) *udpDevice {
+	d := s.devices[peer.String()]
+	if d == nil {
+		d = &udpDevice{UDPDevice: UDPDevice{Address: peer.String()}, peer: peer}
+		s.devices[d.Address] = d
+	}
+	d.Hand = hand
+	d.LastSeen = now
+	if udp, ok := peer.(*net.UDPAddr); ok {
+		d.DeviceID = s.ipDeviceIDs[udp.IP.String()]
+		d.Identity = s.identities[udp.IP.String()]
+	}
+	return d
+}
+
+// resolveLocked finds a glove by address or hand.
+// Must be called with s.mu held.
+func (s *UDPSource) resolveLocked(device string) *udpDevice {
+	if d, ok := s.devices[device]; ok {
+		return d
+	}
+	var found *udpDevice
+	for _, d := range s.devices {
+		if d.Hand == device && (found == nil || d.LastSeen.After(found.LastSeen)) {
+			found = d
+		}
+	}
+	return found
+}
+
+// expireLocked fails an update the glove never acknowledged, as firmware
+// without OTA support ignores the request, reporting whether it did.
+// Must be called with s.mu held.
+func (s *UDPSource) expireLocked(d *udpDevice) bool {
+	if u := d.Update; u != nil && u.State == UpdateRequested && time.Since(u.UpdatedAt) > updateAckTimeout {
+		u.State = UpdateFailed
+		u.Message = "no response from glove"
+		u.UpdatedAt = time.Now()
+		return true
+	}
+	return false
+}
+
+// handleUpdateStatus records a DatagramUpdateStatus from peer.
+func (s *UDPSource) handleUpdateStatus(peer net.Addr, data []byte) error {
+	if len(data) < 3 {
+		return fmt.Errorf("update status of %d bytes", len(data))
+	}
+	state, ok := updateStates[data[1]]
+	if !ok {
+		return fmt.Errorf("invalid update state %d", data[1])
+	}
+
+	s.mu.Lock()
+	d := s.devices[peer.String()]
+	if d == nil {
+		d = &udpDevice{UDPDevice: UDPDevice{Address: peer.String(), LastSeen: time.Now()}, peer: peer}
+		s.devices[d.Address] = d
+	}
+	if d.Update == nil {
+		d.Update = &UpdateStatus{} // not requested by this server
+	}
+	d.Update.State = state
+	d.Update.Percent = min(int(data[2]), 100)
+	d.Update.Message = strings.TrimSpace(string(data[3:]))
+	d.Update.UpdatedAt = time.Now()
+	device := d.snapshot()
+	handler := s.onUpdate
+	s.mu.Unlock()
+
+	if state == UpdateDone || state == UpdateFailed {
+		log.Printf("UDP: firmware update of %s glove (%s) %s %s", device.Hand, device.Address, state, device.Update.Message)
+	}
+	if handler != nil {
+		handler(device)
+	}
+	return nil
+}
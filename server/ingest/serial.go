@@ -0,0 +1,172 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"boxing-analytics/ble"
+
+	"go.bug.st/serial"
+)
+
+// DefaultBaudRate is the glove firmware's serial speed. USB CDC ports (the
+// XIAO's built-in USB) ignore it.
+const DefaultBaudRate = 115200
+
+// serialRetryInterval is how long a SerialSource waits before reopening a
+// port that failed to open or went away (e.g. a glove unplugged).
+const serialRetryInterval = 2 * time.Second
+
+// maxFrameSize bounds serial frames; longer runs without a frame end are
+// discarded.
+const maxFrameSize = 1500
+
+// SLIP (RFC 1055) framing bytes
+const (
+	slipEnd    = 0xC0
+	slipEsc    = 0xDB
+	slipEscEnd = 0xDC
+	slipEscEsc = 0xDD
+)
+
+// errFrameTooLong is returned for frames over maxFrameSize.
+var errFrameTooLong = errors.New("frame too long")
+
+// SerialSource reads samples from gloves wired over USB or a serial port.
+// The stream is SLIP framed: each frame holds a UDP datagram (hand byte and
+// sensor packet, see UDPSource) and is sent between two END bytes, so the
+// firmware's text log lines end up in frames of their own. Those are logged
+// as the glove's console rather than analyzed.
+type SerialSource struct {
+	port string
+	baud int
+}
+
+// NewSerialSource creates a source reading port (e.g. "/dev/ttyACM0" or
+// "COM3") at baud.
+func NewSerialSource(port string, baud int) *SerialSource {
+	return &SerialSource{port: port, baud: baud}
+}
+
+// Name implements Source.
+func (s *SerialSource) Name() string { return "serial" }
+
+// Run implements Source. The port is reopened whenever it fails, until ctx
+// is cancelled.
+func (s *SerialSource) Run(ctx context.Context, handler Handler) error {
+	for {
+		if err := s.read(ctx, handler); err != nil {
+			log.Printf("Serial: %s: %v (retrying in %s)", s.port, err, serialRetryInterval)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(serialRetryInterval):
+		}
+	}
+}
+
+// read opens the port and delivers its samples until it fails or ctx is
+// cancelled.
+func (s *SerialSource) read(ctx context.Context, handler Handler) error {
+	port, err := serial.Open(s.port, &serial.Mode{BaudRate: s.baud})
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	log.Printf("Serial: reading glove samples from %s", s.port)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		port.Close()
+	}()
+
+	r := bufio.NewReader(port)
+	var checksums [2]ble.ChecksumGuard // per hand
+	for {
+		frame, err := readFrame(r)
+		if errors.Is(err, errFrameTooLong) {
+			log.Printf("Serial: %s: discarded frame over %d bytes", s.port, maxFrameSize)
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if len(frame) == 0 {
+			continue
+		}
+		if isConsoleText(frame) {
+			if line := strings.TrimSpace(string(frame)); line != "" {
+				log.Printf("Serial %s: %s", s.port, line)
+			}
+			continue
+		}
+		hand, packets, err := ParseDatagram(frame)
+		if err == nil {
+			err = checksums[hand].Check(packets)
+		}
+		if err != nil {
+			log.Printf("Serial: Failed to parse frame from %s: %v", s.port, err)
+			continue
+		}
+		deliverBatch(handler, Sample{Source: s.Name(), Device: s.port, Hand: hand}, packets, time.Now())
+	}
+}
+
+// readFrame reads one SLIP frame, returning it unescaped. Empty frames
+// (back-to-back END bytes) are returned as such.
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	var frame []byte
+	tooLong := false
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		switch b {
+		case slipEnd:
+			if tooLong {
+				return nil, errFrameTooLong
+			}
+			return frame, nil
+		case slipEsc:
+			b, err = r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			switch b {
+			case slipEscEnd:
+				b = slipEnd
+			case slipEscEsc:
+				b = slipEsc
+			}
+		}
+		if len(frame) >= maxFrameSize {
+			tooLong = true
+			continue
+		}
+		frame = append(frame, b)
+	}
+}
+
+// isConsoleText reports whether a frame is a line of the firmware's text
+// log. Sample frames start with a hand byte (0 or 1), never printable.
+func isConsoleText(frame []byte) bool {
+	return frame[0] >= 0x20 || frame[0] == '\n' || frame[0] == '\r'
+}
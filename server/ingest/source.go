@@ -0,0 +1,77 @@
+// Package ingest collects glove samples from every transport the server
+// listens on (BLE, UDP, ...) and delivers them, normalized, to one handler,
+// so all of them feed the same analytics engine.
+package ingest
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"boxing-analytics/ble"
+)
+
+// Sample is one normalized sensor sample: the packet carries acceleration,
+// gyro, device timestamp and sequence number in the glove's fixed-point
+// units, whichever transport delivered it.
+type Sample struct {
+	Source string // Name of the source that delivered it, e.g. "ble" or "udp"
+	Device string // Sending device: BLE address or UDP peer address
+	Hand   ble.Hand
+	Packet *ble.SensorPacket
+	At     time.Time // When it was taken by the server clock, zero = as it arrived
+}
+
+// maxBatchSpan bounds how far apart the samples of one batch are dated; a
+// wider spread of device timestamps means the glove restarted mid-batch.
+const maxBatchSpan = time.Second
+
+// deliverBatch hands the samples of one batch to handler in order. The last
+// was taken as the batch arrived at now, each earlier one as many
+// milliseconds before as its device timestamp is older, so a batch does not
+// collapse onto a single instant.
+func deliverBatch(handler Handler, sample Sample, packets []*ble.SensorPacket, now time.Time) {
+	last := packets[len(packets)-1].Timestamp
+	for _, packet := range packets {
+		age := time.Duration(last-packet.Timestamp) * time.Millisecond
+		if age > maxBatchSpan {
+			age = 0
+		}
+		sample.Packet = packet
+		sample.At = now.Add(-age)
+		handler(sample)
+	}
+}
+
+// Handler receives samples. Sources call it from their own goroutines, so
+// it may be called concurrently.
+type Handler func(Sample)
+
+// Source produces samples from one transport.
+type Source interface {
+	// Name identifies the source in samples and logs.
+	Name() string
+
+	// Run delivers samples to handler until ctx is cancelled or the
+	// source fails.
+	Run(ctx context.Context, handler Handler) error
+}
+
+// Run runs every source until ctx is cancelled, delivering their samples to
+// handler in sequence order per glove, without duplicates. A source that
+// fails is logged and does not stop the others.
+func Run(ctx context.Context, handler Handler, sources ...Source) {
+	handler = newOrderer(handler).handle
+	var wg sync.WaitGroup
+	for _, src := range sources {
+		wg.Add(1)
+		go func(src Source) {
+			defer wg.Done()
+			if err := src.Run(ctx, handler); err != nil {
+				log.Printf("Ingest: %s source stopped: %v", src.Name(), err)
+			}
+		}(src)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,139 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"boxing-analytics/ble"
+)
+
+// maxDatagramSize bounds the datagrams a UDPSource reads; larger ones are
+// truncated and fail to parse.
+const maxDatagramSize = 1500
+
+// ErrInvalidHand is returned for datagrams whose hand byte is neither
+// ble.LeftHand nor ble.RightHand.
+var ErrInvalidHand = errors.New("invalid hand")
+
+// UDPSource receives samples from gloves streaming over Wi-Fi. Each datagram
+// is one hand byte (0 = left, 1 = right) followed by a sensor packet in any
+// format ble.ParsePackets accepts, as the glove would notify it over BLE. A
+// v3 packet batches samples: sending 5 per datagram at 20 datagrams/s cuts
+// Wi-Fi traffic to a fifth of plain 100Hz packets, and each sample is still
+// dated by its own device timestamp.
+//
+// The gloves can be told to update their firmware (see StartUpdate), and
+// report the update's progress back on the same socket. Gloves that do
+// not know the server's address find it by broadcasting a discover request.
+type UDPSource struct {
+	addr string
+
+	mu          sync.Mutex
+	conn        net.PacketConn // nil unless running
+	devices     map[string]*udpDevice
+	onUpdate    func(UDPDevice)
+	info        DiscoveryInfo
+	deviceIDs   map[string]int    // by glove identity
+	ipDeviceIDs map[string]int    // by the IP the glove last discovered from
+	identities  map[string]string // identity a glove discovered with, by IP
+}
+
+// NewUDPSource creates a source listening on addr, e.g. ":5005".
+func NewUDPSource(addr string) *UDPSource {
+	return &UDPSource{
+		addr:        addr,
+		devices:     make(map[string]*udpDevice),
+		deviceIDs:   make(map[string]int),
+		ipDeviceIDs: make(map[string]int),
+		identities:  make(map[string]string),
+	}
+}
+
+// Name implements Source.
+func (s *UDPSource) Name() string { return "udp" }
+
+// Run implements Source. Malformed datagrams are logged and skipped.
+func (s *UDPSource) Run(ctx context.Context, handler Handler) error {
+	conn, err := net.ListenPacket("udp", s.addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.addr, err)
+	}
+	log.Printf("UDP: listening for glove samples on %s", conn.LocalAddr())
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+	}()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("read: %w", err)
+		}
+		if isDiscoverRequest(buf[:n]) {
+			if err := s.handleDiscover(conn, peer, buf[:n]); err != nil {
+				log.Printf("UDP: Discovery: %v", err)
+			}
+			continue
+		}
+		if n > 0 && buf[0] == DatagramUpdateStatus {
+			if err := s.handleUpdateStatus(peer, buf[:n]); err != nil {
+				log.Printf("UDP: Invalid update status from %s: %v", peer, err)
+			}
+			continue
+		}
+		hand, packets, err := ParseDatagram(buf[:n])
+		if err != nil {
+			log.Printf("UDP: Failed to parse datagram from %s: %v", peer, err)
+			continue
+		}
+		now := time.Now()
+		s.mu.Lock()
+		err = s.seenLocked(peer, hand.String(), now).checksums.Check(packets)
+		s.mu.Unlock()
+		if err != nil {
+			log.Printf("UDP: Dropped datagram from %s: %v", peer, err)
+			continue
+		}
+		deliverBatch(handler, Sample{Source: s.Name(), Device: peer.String(), Hand: hand}, packets, now)
+	}
+}
+
+// ParseDatagram decodes a UDP sample datagram (see UDPSource).
+func ParseDatagram(data []byte) (ble.Hand, []*ble.SensorPacket, error) {
+	if len(data) == 0 {
+		return 0, nil, fmt.Errorf("%w: empty datagram", ble.ErrInvalidPacketSize)
+	}
+	hand := ble.Hand(data[0])
+	if hand != ble.LeftHand && hand != ble.RightHand {
+		return 0, nil, fmt.Errorf("%w %d", ErrInvalidHand, data[0])
+	}
+	packets, err := ble.ParsePackets(data[1:])
+	if err != nil {
+		return 0, nil, err
+	}
+	return hand, packets, nil
+}
+
+// MarshalDatagram encodes sensor packet data (anything ble.ParsePackets
+// accepts) from one hand as a UDP sample datagram.
+func MarshalDatagram(hand ble.Hand, data []byte) []byte {
+	return append([]byte{byte(hand)}, data...)
+}
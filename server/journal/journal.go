@@ -0,0 +1,263 @@
+// Package journal keeps an append-only log of the session in progress so
+// that it survives a crash or power cut of the server.
+package journal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"boxing-analytics/analytics"
+)
+
+// DefaultSyncInterval is how often Run flushes new entries to disk. Entries
+// reach the OS as they are written, so only a power cut can lose the last
+// interval.
+const DefaultSyncInterval = time.Second
+
+// AliveInterval is how often Run marks an open session as still running
+// when nothing else was journaled. At most this much of the time the server
+// was down is counted toward a restored session.
+const AliveInterval = 5 * time.Second
+
+// Entry types
+const (
+	EntryStart   = "start"
+	EntryPunch   = "punch"
+	EntryPause   = "pause"
+	EntryResume  = "resume"
+	EntryAlive   = "alive"   // the session was still running
+	EntryRestore = "restore" // the session was restored after a crash
+)
+
+// Entry is one line of the journal.
+type Entry struct {
+	Type        string                   `json:"type"`
+	Time        time.Time                `json:"time"`
+	Athlete     string                   `json:"athlete,omitempty"`      // start only
+	SessionType string                   `json:"session_type,omitempty"` // start only
+	Info        *analytics.SessionInfo   `json:"info,omitempty"`         // start only
+	Punch       *analytics.RecordedPunch `json:"punch,omitempty"`        // punch only
+}
+
+// Session is an unfinished session read back from a journal. Times are
+// those of the session's own clock, which stood still while the server was
+// down: punches after an earlier restore are moved back by Downtime.
+type Session struct {
+	StartedAt    time.Time
+	Athlete      string
+	SessionType  string
+	Info         analytics.SessionInfo
+	Paused       bool
+	Punches      []analytics.RecordedPunch
+	Downtime     time.Duration // server downtime of earlier restores
+	LastActivity time.Time     // last entry before the server went down
+}
+
+// Journal appends a session's entries to a file, one JSON object per line.
+// The file only ever holds the current session: it is truncated when a
+// session starts or ends. It implements analytics.Journal; write errors are
+// logged, as the analyzer has no one to report them to.
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	dirty   bool      // entries written since the last sync
+	open    bool      // a session is running, see keepAlive
+	written time.Time // of the last entry
+}
+
+// Open opens the journal at path for appending, creating it if needed.
+// Load any unfinished session first: the next start truncates the file.
+func Open(path string) (*Journal, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	return &Journal{path: path, file: f}, nil
+}
+
+// Load reads the session left unfinished in the journal at path, returning
+// nil if there is none (or no journal). Unreadable lines, such as one cut
+// short by a crash mid-write, are skipped.
+func Load(path string) (*Session, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	defer f.Close()
+
+	var sess *Session
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.Type == EntryStart {
+			sess = &Session{StartedAt: entry.Time, Athlete: entry.Athlete, SessionType: entry.SessionType}
+			if entry.Info != nil {
+				sess.Info = *entry.Info
+			}
+		}
+		if sess == nil {
+			continue
+		}
+		if entry.Type == EntryRestore {
+			sess.Downtime += entry.Time.Sub(sess.LastActivity)
+		}
+		sess.LastActivity = entry.Time
+		switch entry.Type {
+		case EntryPunch:
+			if entry.Punch != nil {
+				punch := *entry.Punch
+				punch.At = punch.At.Add(-sess.Downtime)
+				sess.Punches = append(sess.Punches, punch)
+			}
+		case EntryPause:
+			sess.Paused = true
+		case EntryResume:
+			sess.Paused = false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+	return sess, nil
+}
+
+// SessionStarted implements analytics.Journal.
+func (j *Journal) SessionStarted(startedAt time.Time, athlete, sessionType string, info analytics.SessionInfo) {
+	entry := Entry{Type: EntryStart, Time: startedAt, Athlete: athlete, SessionType: sessionType}
+	if info != (analytics.SessionInfo{}) {
+		entry.Info = &info
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.truncateLocked()
+	j.writeLocked(entry)
+	j.open = true
+}
+
+// SessionPunch implements analytics.Journal.
+func (j *Journal) SessionPunch(punch analytics.RecordedPunch) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.writeLocked(Entry{Type: EntryPunch, Time: punch.At, Punch: &punch})
+}
+
+// SessionPaused implements analytics.Journal.
+func (j *Journal) SessionPaused(paused bool) {
+	entryType := EntryResume
+	if paused {
+		entryType = EntryPause
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.writeLocked(Entry{Type: entryType, Time: time.Now()})
+}
+
+// SessionEnded implements analytics.Journal. The finished session has been
+// saved by then, so the journal is simply emptied.
+func (j *Journal) SessionEnded() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.truncateLocked()
+	j.open = false
+}
+
+// SessionRestored records that the session loaded from the journal runs
+// again, so that the time the server was down is known should it go down
+// once more.
+func (j *Journal) SessionRestored() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.writeLocked(Entry{Type: EntryRestore, Time: time.Now()})
+	j.open = true
+}
+
+// Run syncs new entries to disk every interval until ctx is cancelled, then
+// syncs one last time. It also keeps a running session marked alive.
+func (j *Journal) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			j.Sync()
+			return
+		case <-ticker.C:
+			j.keepAlive()
+			j.Sync()
+		}
+	}
+}
+
+// keepAlive journals that the open session is still running if nothing was
+// written for AliveInterval, so that a restore can tell when it stopped.
+func (j *Journal) keepAlive() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.open && time.Since(j.written) >= AliveInterval {
+		j.writeLocked(Entry{Type: EntryAlive, Time: time.Now()})
+	}
+}
+
+// Sync flushes the entries written since the last sync to disk.
+func (j *Journal) Sync() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.dirty {
+		j.syncLocked()
+	}
+}
+
+// Close syncs and closes the journal file, keeping its content.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.syncLocked()
+	return j.file.Close()
+}
+
+// writeLocked appends an entry. Must be called with j.mu held.
+func (j *Journal) writeLocked(entry Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Journal: marshal %s entry: %v", entry.Type, err)
+		return
+	}
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		log.Printf("Journal: write %s: %v", j.path, err)
+		return
+	}
+	j.dirty = true
+	j.written = time.Now()
+}
+
+// truncateLocked empties the journal. Must be called with j.mu held.
+func (j *Journal) truncateLocked() {
+	if err := j.file.Truncate(0); err != nil {
+		log.Printf("Journal: truncate %s: %v", j.path, err)
+	}
+	j.dirty = true
+}
+
+// syncLocked flushes the journal to disk. Must be called with j.mu held.
+func (j *Journal) syncLocked() {
+	if err := j.file.Sync(); err != nil {
+		log.Printf("Journal: sync %s: %v", j.path, err)
+		return
+	}
+	j.dirty = false
+}
@@ -0,0 +1,240 @@
+// Package logfile writes the server's log to a file that is rotated by size
+// or by the hour or day, keeping a bounded number of old files, for
+// installations without journald.
+package logfile
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotation schedules, besides rotating by size
+const (
+	RotateHourly = "hourly"
+	RotateDaily  = "daily"
+)
+
+// Defaults for file logging
+const (
+	DefaultMaxSizeMB = 10 // rotate at 10 MB
+	DefaultKeep      = 5  // rotated files kept
+)
+
+// stampLayout timestamps rotated files, e.g. server-20060102-150405.log.
+const stampLayout = "20060102-150405"
+
+// Config selects where the log is written and how it is rotated.
+type Config struct {
+	Path       string // Log file, "" = console only
+	MaxSizeMB  int    // Rotate once the file reaches this size, 0 = no limit
+	Rotate     string // Also rotate at every RotateHourly or RotateDaily boundary, "" = size only
+	Keep       int    // Rotated files kept, 0 = all
+	MaxAgeDays int    // Rotated files deleted after this many days, 0 = never
+	Quiet      bool   // Log to the file only, not the console
+}
+
+// Validate checks the config.
+func (c *Config) Validate() error {
+	if c.MaxSizeMB < 0 {
+		return fmt.Errorf("invalid log max size %d MB", c.MaxSizeMB)
+	}
+	switch c.Rotate {
+	case "", RotateHourly, RotateDaily:
+	default:
+		return fmt.Errorf("invalid log rotation %q: must be 'hourly' or 'daily'", c.Rotate)
+	}
+	if c.Keep < 0 || c.MaxAgeDays < 0 {
+		return fmt.Errorf("invalid log retention: must not be negative")
+	}
+	return nil
+}
+
+// period names the rotation period t falls in; a change means the file is
+// due for rotation.
+func (c *Config) period(t time.Time) string {
+	switch c.Rotate {
+	case RotateHourly:
+		return t.Format("2006010215")
+	case RotateDaily:
+		return t.Format("20060102")
+	}
+	return ""
+}
+
+// Writer is a log file that rotates itself as it is written. It is safe for
+// concurrent use.
+type Writer struct {
+	cfg Config
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	period string // rotation period the file was started in
+}
+
+// Open opens cfg.Path for appending, creating its directory if needed. A
+// file left from an earlier run continues until it is due for rotation.
+func Open(cfg Config) (*Writer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("create log dir: %w", err)
+	}
+	w := &Writer{cfg: cfg}
+	if err := w.openLocked(); err != nil {
+		return nil, err
+	}
+	w.prune(time.Now())
+	return w, nil
+}
+
+// openLocked opens the log file, continuing it if it exists.
+// Must be called with w.mu held (or before w is shared).
+func (w *Writer) openLocked() error {
+	f, err := os.OpenFile(w.cfg.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("open log file: %w", err)
+	}
+	w.f = f
+	w.size = info.Size()
+	started := time.Now()
+	if w.size > 0 {
+		started = info.ModTime()
+	}
+	w.period = w.cfg.period(started)
+	return nil
+}
+
+// Write implements io.Writer, rotating first when p would take the file
+// past MaxSizeMB or a new period has begun. A failed rotation is reported
+// on stderr and the current file kept, so no log line is lost.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	if w.dueLocked(len(p), now) {
+		if err := w.rotateLocked(now); err != nil {
+			fmt.Fprintf(os.Stderr, "Log rotation: %v\n", err)
+		}
+	}
+	if w.f == nil {
+		return 0, os.ErrClosed
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// dueLocked reports whether the file must be rotated before writing n more
+// bytes at now.
+// Must be called with w.mu held.
+func (w *Writer) dueLocked(n int, now time.Time) bool {
+	if w.f == nil || w.size == 0 {
+		return false
+	}
+	if limit := int64(w.cfg.MaxSizeMB) << 20; limit > 0 && w.size+int64(n) > limit {
+		return true
+	}
+	return w.cfg.period(now) != w.period
+}
+
+// rotateLocked renames the file aside with a timestamp, starts a new one
+// and prunes old files.
+// Must be called with w.mu held.
+func (w *Writer) rotateLocked(now time.Time) error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	w.f = nil
+	renameErr := os.Rename(w.cfg.Path, w.rotatedName(now))
+	if err := w.openLocked(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		// Retry once another MaxSizeMB has been written, not on every line
+		w.size = 0
+		return renameErr
+	}
+	go w.prune(now)
+	return nil
+}
+
+// rotatedName returns an unused name for the file rotated at now, e.g.
+// logs/server-20060102-150405.log.
+func (w *Writer) rotatedName(now time.Time) string {
+	prefix, ext := w.splitPath()
+	name := prefix + now.Format(stampLayout)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name + ext); os.IsNotExist(err) {
+			return name + ext
+		}
+		name = fmt.Sprintf("%s%s-%d", prefix, now.Format(stampLayout), i)
+	}
+}
+
+// splitPath returns what rotated files start and end with.
+func (w *Writer) splitPath() (prefix, ext string) {
+	ext = filepath.Ext(w.cfg.Path)
+	return strings.TrimSuffix(w.cfg.Path, ext) + "-", ext
+}
+
+// prune deletes rotated files beyond Keep and older than MaxAgeDays.
+func (w *Writer) prune(now time.Time) {
+	if w.cfg.Keep == 0 && w.cfg.MaxAgeDays == 0 {
+		return
+	}
+	prefix, ext := w.splitPath()
+	matches, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return
+	}
+	// Timestamps sort oldest first, a file without a -N suffix before the
+	// ones rotated in the same second
+	key := func(name string) string {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if len(stamp) == len(stampLayout) {
+			stamp += "-0"
+		}
+		return stamp
+	}
+	sort.Slice(matches, func(i, j int) bool { return key(matches[i]) < key(matches[j]) })
+	cutoff := now.AddDate(0, 0, -w.cfg.MaxAgeDays)
+	for i, name := range matches {
+		expired := false
+		if w.cfg.Keep > 0 && i < len(matches)-w.cfg.Keep {
+			expired = true
+		} else if w.cfg.MaxAgeDays > 0 {
+			info, err := os.Stat(name)
+			expired = err == nil && info.ModTime().Before(cutoff)
+		}
+		if expired {
+			if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+				log.Printf("Log retention: %v", err)
+			}
+		}
+	}
+}
+
+// Close closes the log file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/goals"
+	"boxing-analytics/schedule"
+	"boxing-analytics/storage"
+)
+
+// SessionSummary is the KindSession message for a finished session.
+func SessionSummary(sess *storage.Session) Message {
+	msg := Message{Kind: KindSession, Athlete: sess.Athlete, Title: "Session finished", Data: sess}
+	duration := (time.Duration(sess.DurationSec) * time.Second).Round(time.Second)
+	if sess.State == nil {
+		msg.Text = duration.String()
+		return msg
+	}
+	c := sess.State.Combined
+	msg.Text = fmt.Sprintf("%s, %d punches (%d left, %d right)\nAvg force %.1f, max %.1f m/s²\n%.0f punches/min, intensity %d",
+		duration, c.TotalPunches, sess.State.Left.PunchCount, sess.State.Right.PunchCount,
+		c.AvgForce, c.MaxForce, c.PunchesPerMin, c.IntensityScore)
+	return msg
+}
+
+// BatteryLow is the KindBattery message for a "battery_low" event.
+func BatteryLow(athlete, hand string, low analytics.BatteryLowEvent) Message {
+	return Message{
+		Kind:    KindBattery,
+		Athlete: athlete,
+		Title:   "Glove battery low",
+		Text:    fmt.Sprintf("The %s glove is at %d%%. Charge it before the next session.", hand, low.Level),
+		Data:    low,
+	}
+}
+
+// ScheduledSession is the KindSchedule reminder of a planned session.
+// program is the name of the program it runs, "" for none.
+func ScheduledSession(e schedule.Entry, program string) Message {
+	text := "Session planned for " + e.At.Local().Format("Mon Jan 2, 15:04")
+	if program != "" {
+		text += ": " + program
+	}
+	if e.Notes != "" {
+		text += "\n" + e.Notes
+	}
+	return Message{Kind: KindSchedule, Athlete: e.Athlete, Title: "Upcoming session", Text: text, Data: e}
+}
+
+// GoalReminder is the KindGoal message for a weekly goal not met: a
+// reminder while the week still runs, or a report once it is over.
+func GoalReminder(p goals.Progress, weekOver bool) Message {
+	msg := Message{Kind: KindGoal, Athlete: p.Athlete, Title: "Weekly goal", Data: p}
+	done := fmt.Sprintf("%d punches, %.0f minutes, %d sessions", p.Punches, p.Minutes, p.Sessions)
+	if weekOver {
+		msg.Title = "Weekly goal missed"
+		msg.Text = fmt.Sprintf("Last week you reached %.0f%% of your goal (%s).", p.Completion, done)
+		return msg
+	}
+	msg.Text = fmt.Sprintf("You are at %.0f%% of this week's goal (%s). There is still today to reach it.", p.Completion, done)
+	return msg
+}
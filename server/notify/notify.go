@@ -0,0 +1,175 @@
+// Package notify delivers notifications, such as session summaries and
+// battery warnings, to the channels each athlete configured: webhooks,
+// Pushover or a Telegram bot.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Message kinds, which channels subscribe to
+const (
+	KindSession  = "session"  // summary of a finished session
+	KindBattery  = "battery"  // a glove's battery ran low
+	KindSchedule = "schedule" // reminder of a planned session
+	KindGoal     = "goal"     // weekly goal not met yet, or missed
+)
+
+// Provider names
+const (
+	ProviderWebhook  = "webhook"
+	ProviderPushover = "pushover"
+	ProviderTelegram = "telegram"
+)
+
+// sendTimeout bounds the delivery of one message to one channel.
+const sendTimeout = 10 * time.Second
+
+// Provider API endpoints
+var (
+	pushoverURL = "https://api.pushover.net/1/messages.json"
+	telegramURL = "https://api.telegram.org"
+)
+
+// client is used for all deliveries.
+var client = &http.Client{Timeout: sendTimeout}
+
+// Message is one notification.
+type Message struct {
+	Kind    string      `json:"kind"`
+	Athlete string      `json:"athlete,omitempty"`
+	Title   string      `json:"title"`
+	Text    string      `json:"text"`
+	Data    interface{} `json:"data,omitempty"` // webhooks only: the session or event
+}
+
+// Channel is one place an athlete's notifications are delivered to.
+type Channel struct {
+	Provider string   `json:"provider"`          // ProviderWebhook, ProviderPushover or ProviderTelegram
+	URL      string   `json:"url,omitempty"`     // webhook URL
+	Token    string   `json:"token,omitempty"`   // Pushover application token or Telegram bot token
+	User     string   `json:"user,omitempty"`    // Pushover user key
+	ChatID   string   `json:"chat_id,omitempty"` // Telegram chat
+	Events   []string `json:"events,omitempty"`  // kinds delivered, empty = all
+}
+
+// Validate checks that the channel has what its provider needs.
+func (c *Channel) Validate() error {
+	switch c.Provider {
+	case ProviderWebhook:
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook url %q", c.URL)
+		}
+	case ProviderPushover:
+		if c.Token == "" || c.User == "" {
+			return fmt.Errorf("pushover needs a token and a user key")
+		}
+	case ProviderTelegram:
+		if c.Token == "" || c.ChatID == "" {
+			return fmt.Errorf("telegram needs a bot token and a chat_id")
+		}
+	default:
+		return fmt.Errorf("invalid provider %q: must be 'webhook', 'pushover' or 'telegram'", c.Provider)
+	}
+	for _, kind := range c.Events {
+		switch kind {
+		case KindSession, KindBattery, KindSchedule, KindGoal:
+		default:
+			return fmt.Errorf("invalid event %q: must be 'session', 'battery', 'schedule' or 'goal'", kind)
+		}
+	}
+	return nil
+}
+
+// wants reports whether the channel subscribes to a kind of message.
+func (c *Channel) wants(kind string) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, k := range c.Events {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Send delivers msg to every channel subscribed to its kind. Failures are
+// logged; notifications are best effort.
+func Send(channels []Channel, msg Message) {
+	for _, c := range channels {
+		if !c.wants(msg.Kind) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		if err := c.send(ctx, msg); err != nil {
+			log.Printf("Notify %s (%s): %v", msg.Athlete, c.Provider, err)
+		}
+		cancel()
+	}
+}
+
+// send delivers msg through the channel's provider.
+func (c *Channel) send(ctx context.Context, msg Message) error {
+	switch c.Provider {
+	case ProviderWebhook:
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return post(ctx, c.URL, "application/json", data)
+	case ProviderPushover:
+		form := url.Values{
+			"token":   {c.Token},
+			"user":    {c.User},
+			"title":   {msg.Title},
+			"message": {msg.Text},
+		}
+		return post(ctx, pushoverURL, "application/x-www-form-urlencoded", []byte(form.Encode()))
+	case ProviderTelegram:
+		data, err := json.Marshal(map[string]string{
+			"chat_id": c.ChatID,
+			"text":    msg.Title + "\n" + msg.Text,
+		})
+		if err != nil {
+			return err
+		}
+		return post(ctx, telegramURL+"/bot"+c.Token+"/sendMessage", "application/json", data)
+	}
+	return fmt.Errorf("unknown provider %q", c.Provider)
+}
+
+// post sends a request body, turning non-2xx responses into errors. The URL
+// is left out of errors: Telegram's carries the bot token.
+func post(ctx context.Context, rawURL, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid request")
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("HTTP %d %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
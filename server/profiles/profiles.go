@@ -0,0 +1,240 @@
+// Package profiles stores athlete profiles used to personalize analytics.
+package profiles
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/notify"
+	"boxing-analytics/validation"
+)
+
+// ErrNotFound is returned when a profile does not exist.
+var ErrNotFound = errors.New("profile not found")
+
+// Profile describes one athlete.
+type Profile struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Stance   string  `json:"stance,omitempty"`    // "orthodox", "southpaw", or "" if unknown
+	MaxHR    int     `json:"max_hr,omitempty"`    // max heart rate for HR zones, 0 if unknown
+	WeightKg float64 `json:"weight_kg,omitempty"` // body weight for power estimates, 0 if unknown
+	ReachCm  float64 `json:"reach_cm,omitempty"`  // fingertip to fingertip, 0 if unknown
+	Skill    string  `json:"skill,omitempty"`     // "beginner", "intermediate", "advanced", or "" if unknown
+
+	Units     *analytics.Units `json:"units,omitempty"` // output units while training, nil = the server's
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+
+	// Guest (drop-in) profiles are ephemeral and removed with their data on expiry
+	Guest     bool       `json:"guest,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Where session summaries and battery warnings are sent
+	Notifications []notify.Channel `json:"notifications,omitempty"`
+}
+
+// Validate checks the profile fields, returning validation.Errors listing
+// every invalid one.
+func (p *Profile) Validate() error {
+	var errs validation.Errors
+	if p.ID == "" {
+		errs.Add("id", fmt.Errorf("id is required"))
+	}
+	switch p.Stance {
+	case "", analytics.StanceOrthodox, analytics.StanceSouthpaw:
+	default:
+		errs.Add("stance", fmt.Errorf("invalid stance %q: must be 'orthodox' or 'southpaw'", p.Stance))
+	}
+	if p.MaxHR != 0 && (p.MaxHR < 100 || p.MaxHR > 250) {
+		errs.Add("max_hr", fmt.Errorf("invalid max_hr %d: must be between 100 and 250", p.MaxHR))
+	}
+	if p.WeightKg != 0 && (p.WeightKg < 30 || p.WeightKg > 200) {
+		errs.Add("weight_kg", fmt.Errorf("invalid weight_kg %v: must be between 30 and 200", p.WeightKg))
+	}
+	if p.ReachCm != 0 && (p.ReachCm < 120 || p.ReachCm > 240) {
+		errs.Add("reach_cm", fmt.Errorf("invalid reach_cm %v: must be between 120 and 240", p.ReachCm))
+	}
+	if err := analytics.ValidateSkill(p.Skill); err != nil {
+		errs.Add("skill", err)
+	}
+	if p.Units != nil {
+		if err := p.Units.Validate(); err != nil {
+			errs.Add("units", fmt.Errorf("units: %w", err))
+		}
+	}
+	for i := range p.Notifications {
+		if err := p.Notifications[i].Validate(); err != nil {
+			field := fmt.Sprintf("notifications[%d]", i)
+			errs.Add(field, fmt.Errorf("%s: %w", field, err))
+		}
+	}
+	return errs.Err()
+}
+
+// Store keeps all profiles in a single JSON file.
+type Store struct {
+	mu       sync.RWMutex
+	path     string
+	profiles map[string]*Profile
+}
+
+// NewStore loads profiles from path, starting empty if it does not exist.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, profiles: make(map[string]*Profile)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read profiles: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.profiles); err != nil {
+		return nil, fmt.Errorf("decode profiles: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns a profile by ID.
+func (s *Store) Get(id string) (Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.profiles[id]
+	if !ok {
+		return Profile{}, ErrNotFound
+	}
+	return *p, nil
+}
+
+// List returns profiles ordered by ID. Guest profiles are only included
+// when includeGuests is set.
+func (s *Store) List(includeGuests bool) []Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Profile, 0, len(s.profiles))
+	for _, p := range s.profiles {
+		if p.Guest && !includeGuests {
+			continue
+		}
+		list = append(list, *p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Put creates or replaces a profile and writes the store to disk.
+func (s *Store) Put(p Profile) (Profile, error) {
+	if err := p.Validate(); err != nil {
+		return Profile{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := s.profiles[p.ID]; ok {
+		// Guest status is fixed at creation
+		p.CreatedAt = existing.CreatedAt
+		p.Guest = existing.Guest
+		p.ExpiresAt = existing.ExpiresAt
+	} else {
+		p.CreatedAt = now
+	}
+	p.UpdatedAt = now
+	s.profiles[p.ID] = &p
+
+	if err := s.saveLocked(); err != nil {
+		return Profile{}, err
+	}
+	return p, nil
+}
+
+// NewGuest creates an ephemeral guest profile that expires after ttl.
+func (s *Store) NewGuest(name, stance string, ttl time.Duration) (Profile, error) {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return Profile{}, fmt.Errorf("generate guest id: %w", err)
+	}
+	if name == "" {
+		name = "Guest"
+	}
+
+	now := time.Now()
+	expires := now.Add(ttl)
+	p := Profile{
+		ID:        "guest-" + hex.EncodeToString(b[:]),
+		Name:      name,
+		Stance:    stance,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Guest:     true,
+		ExpiresAt: &expires,
+	}
+	if err := p.Validate(); err != nil {
+		return Profile{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.profiles[p.ID] = &p
+	if err := s.saveLocked(); err != nil {
+		return Profile{}, err
+	}
+	return p, nil
+}
+
+// RemoveExpired deletes guest profiles whose expiry has passed and returns
+// them so their data can be removed too.
+func (s *Store) RemoveExpired(now time.Time) ([]Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []Profile
+	for id, p := range s.profiles {
+		if p.Guest && p.ExpiresAt != nil && now.After(*p.ExpiresAt) {
+			expired = append(expired, *p)
+			delete(s.profiles, id)
+		}
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+	return expired, s.saveLocked()
+}
+
+// Delete removes a profile.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.profiles[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.profiles, id)
+	return s.saveLocked()
+}
+
+// saveLocked writes all profiles to disk.
+// Must be called with s.mu held.
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.profiles, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal profiles: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("write profiles: %w", err)
+	}
+	return nil
+}
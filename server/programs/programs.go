@@ -0,0 +1,106 @@
+// Package programs stores the guided workout programs sessions can run.
+package programs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"boxing-analytics/analytics"
+)
+
+// ErrNotFound is returned when a program does not exist.
+var ErrNotFound = errors.New("program not found")
+
+// Store keeps all programs in a single JSON file.
+type Store struct {
+	mu       sync.RWMutex
+	path     string
+	programs map[string]*analytics.Program
+}
+
+// NewStore loads programs from path, starting empty if it does not exist.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, programs: make(map[string]*analytics.Program)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read programs: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.programs); err != nil {
+		return nil, fmt.Errorf("decode programs: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns a program by ID.
+func (s *Store) Get(id string) (analytics.Program, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.programs[id]
+	if !ok {
+		return analytics.Program{}, ErrNotFound
+	}
+	return *p, nil
+}
+
+// List returns the programs ordered by ID.
+func (s *Store) List() []analytics.Program {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]analytics.Program, 0, len(s.programs))
+	for _, p := range s.programs {
+		list = append(list, *p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Put creates or replaces a program and writes the store to disk.
+func (s *Store) Put(p analytics.Program) (analytics.Program, error) {
+	if err := p.Validate(); err != nil {
+		return analytics.Program{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.programs[p.ID] = &p
+	if err := s.saveLocked(); err != nil {
+		return analytics.Program{}, err
+	}
+	return p, nil
+}
+
+// Delete removes a program.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.programs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.programs, id)
+	return s.saveLocked()
+}
+
+// saveLocked writes all programs to disk.
+// Must be called with s.mu held.
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.programs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal programs: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("write programs: %w", err)
+	}
+	return nil
+}
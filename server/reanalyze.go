@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/replay"
+)
+
+// runReanalyze implements the "reanalyze" subcommand: it reruns a raw
+// recording with different parameters and reports what changed.
+func runReanalyze(args []string) int {
+	fs := flag.NewFlagSet("reanalyze", flag.ExitOnError)
+	threshold := fs.Float64("threshold", 0, "detection threshold in m/s² for both hands (default: the recorded ones, else built-in)")
+	stance := fs.String("stance", "", "athlete stance: orthodox or southpaw")
+	model := fs.String("model", "", "decision tree model to classify with (default: the recorded one, else the heuristic)")
+	asJSON := fs.Bool("json", false, "print the comparison as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: reanalyze [flags] recording.json")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	params := replay.Params{Threshold: *threshold, Stance: *stance}
+	if *model != "" {
+		tree, err := analytics.LoadDecisionTree(*model)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "reanalyze: %v\n", err)
+			return 1
+		}
+		params.Classifier = tree
+	}
+
+	rec, err := replay.Load(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reanalyze: %v\n", err)
+		return 1
+	}
+	cmp, err := replay.Reanalyze(rec, params)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reanalyze: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(cmp)
+	} else {
+		err = printComparison(cmp)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reanalyze: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printComparison writes a reanalysis comparison as a table.
+func printComparison(c *replay.Comparison) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tORIGINAL\tREANALYZED")
+	fmt.Fprintf(tw, "total\t%d\t%d\n", c.Original.Total, c.Reanalyzed.Total)
+	fmt.Fprintf(tw, "left\t%d\t%d\n", c.Original.Left, c.Reanalyzed.Left)
+	fmt.Fprintf(tw, "right\t%d\t%d\n", c.Original.Right, c.Reanalyzed.Right)
+	fmt.Fprintf(tw, "avg force\t%.2f\t%.2f\n", c.Original.AvgForce, c.Reanalyzed.AvgForce)
+
+	types := make(map[string]bool)
+	for t := range c.Original.Breakdown {
+		types[t] = true
+	}
+	for t := range c.Reanalyzed.Breakdown {
+		types[t] = true
+	}
+	names := make([]string, 0, len(types))
+	for t := range types {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	for _, t := range names {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", t, c.Original.Breakdown[t], c.Reanalyzed.Breakdown[t])
+	}
+
+	fmt.Fprintf(tw, "\nmatched %d, added %d, removed %d, reclassified %d\n",
+		c.Matched, len(c.Added), len(c.Removed), len(c.TypeChanges))
+	return tw.Flush()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"boxing-analytics/ble"
+	"boxing-analytics/ingest"
+	"boxing-analytics/replay"
+)
+
+// defaultUDPTarget is where replay and simulate send samples: a server on
+// this machine with the default UDP_PORT.
+const defaultUDPTarget = "localhost:5005"
+
+// runReplay implements the "replay" subcommand: it streams a raw recording
+// to a running server over UDP at the pace it was recorded, so the
+// dashboard shows it as if the gloves were live.
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	to := fs.String("to", defaultUDPTarget, "UDP address of the server")
+	speed := fs.Float64("speed", 1, "playback speed (2 = twice as fast)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: replay [flags] recording.json")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || *speed <= 0 {
+		fs.Usage()
+		return 2
+	}
+
+	rec, err := replay.Load(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 1
+	}
+	conn, err := net.Dial("udp", *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	fmt.Printf("Replaying %s (%d packets) to %s\n", rec.Name, len(rec.Packets), *to)
+
+	// Each glove's packets are sent when its own clock says they are due
+	start := time.Now()
+	first := make(map[ble.Hand]uint32)
+	sent := 0
+	for i, p := range rec.Packets {
+		hand, err := parseHand(p.Hand)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "replay: packet %d: %v\n", i, err)
+			return 1
+		}
+		packets, err := ble.ParsePackets(p.Data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "replay: packet %d: %v\n", i, err)
+			return 1
+		}
+		ts := packets[0].Timestamp
+		if _, ok := first[hand]; !ok {
+			first[hand] = ts
+		}
+		due := start.Add(time.Duration(float64(ts-first[hand]) * float64(time.Millisecond) / *speed))
+		select {
+		case <-ctx.Done():
+			fmt.Printf("Stopped after %d packets\n", sent)
+			return 0
+		case <-time.After(time.Until(due)):
+		}
+		if err := sendDatagram(conn, ingest.MarshalDatagram(hand, p.Data)); err != nil {
+			fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+			return 1
+		}
+		sent++
+	}
+	fmt.Printf("Sent %d packets in %.1fs\n", sent, time.Since(start).Seconds())
+	return 0
+}
+
+// sendDatagram sends one sample datagram. A server that is not listening
+// (yet) is not an error: the samples are simply lost, as with real gloves.
+func sendDatagram(conn net.Conn, data []byte) error {
+	if _, err := conn.Write(data); err != nil && !errors.Is(err, syscall.ECONNREFUSED) {
+		return err
+	}
+	return nil
+}
+
+// parseHand maps a hand name ("left" or "right") to a ble.Hand.
+func parseHand(name string) (ble.Hand, error) {
+	switch name {
+	case "left":
+		return ble.LeftHand, nil
+	case "right":
+		return ble.RightHand, nil
+	}
+	return 0, fmt.Errorf("invalid hand %q: must be left or right", name)
+}
@@ -0,0 +1,169 @@
+package replay
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+)
+
+// MatchToleranceMS is how far apart two detections of the same punch may be.
+const MatchToleranceMS = 150
+
+// Params are the analytics settings to rerun a recording with. Zero values
+// keep those the recording was made with, else the defaults.
+type Params struct {
+	Threshold  float64              `json:"threshold,omitempty"` // m/s², both hands
+	Stance     string               `json:"stance,omitempty"`
+	Classifier analytics.Classifier `json:"-"`
+}
+
+// Summary condenses a list of punches.
+type Summary struct {
+	Total     int            `json:"total"`
+	Left      int            `json:"left"`
+	Right     int            `json:"right"`
+	Breakdown map[string]int `json:"breakdown"`
+	AvgForce  float64        `json:"avg_force"`
+}
+
+// TypeChange is a punch detected both times but classified differently.
+type TypeChange struct {
+	Hand      string              `json:"hand"`
+	Timestamp int64               `json:"ts"`
+	From      analytics.PunchType `json:"from"`
+	To        analytics.PunchType `json:"to"`
+}
+
+// Comparison reports how a reanalysis differs from the original detections.
+type Comparison struct {
+	Params      Params                 `json:"params"`
+	Original    Summary                `json:"original"`
+	Reanalyzed  Summary                `json:"reanalyzed"`
+	Matched     int                    `json:"matched"`
+	Added       []analytics.PunchEvent `json:"added"`   // only found by the reanalysis
+	Removed     []analytics.PunchEvent `json:"removed"` // only found originally
+	TypeChanges []TypeChange           `json:"type_changes"`
+}
+
+// Reanalyze reruns a recording with new parameters and compares the result
+// with the punches detected when it was recorded. Recordings without live
+// detections are compared against a rerun with default parameters.
+func Reanalyze(r *Recording, params Params) (*Comparison, error) {
+	original := r.Punches
+	if original == nil {
+		var err error
+		if original, err = analyze(r, Params{}); err != nil {
+			return nil, err
+		}
+	}
+	punches, err := analyze(r, params)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Comparison{
+		Params:      params,
+		Original:    summarize(original),
+		Reanalyzed:  summarize(punches),
+		Added:       []analytics.PunchEvent{},
+		Removed:     []analytics.PunchEvent{},
+		TypeChanges: []TypeChange{},
+	}
+	for _, hand := range []string{"left", "right"} {
+		c.compare(byHand(original, hand), byHand(punches, hand))
+	}
+	return c, nil
+}
+
+// analyze runs a recording through a fresh analyzer configured as it was
+// recorded, then by params.
+func analyze(r *Recording, params Params) ([]analytics.PunchEvent, error) {
+	a := analytics.NewAnalyzer()
+	r.ApplySettings(a)
+	if params.Threshold > 0 {
+		a.SetThreshold(ble.LeftHand, params.Threshold)
+		a.SetThreshold(ble.RightHand, params.Threshold)
+	}
+	if params.Classifier != nil {
+		a.SetClassifier(params.Classifier)
+	}
+	a.StartSession()
+
+	rec := *r
+	if params.Stance != "" {
+		rec.Stance = params.Stance
+	}
+	if err := Run(a, &rec); err != nil {
+		return nil, fmt.Errorf("replay %s: %w", r.Name, err)
+	}
+	return a.Punches(), nil
+}
+
+// compare pairs the time-ordered punches of one hand and records the
+// differences.
+func (c *Comparison) compare(before, after []analytics.PunchEvent) {
+	matched := make([]bool, len(after))
+	next := 0
+	for _, b := range before {
+		for next < len(after) && after[next].Timestamp < b.Timestamp-MatchToleranceMS {
+			if !matched[next] {
+				c.Added = append(c.Added, after[next])
+			}
+			next++
+		}
+		if next < len(after) && after[next].Timestamp <= b.Timestamp+MatchToleranceMS {
+			matched[next] = true
+			c.Matched++
+			if b.Type != after[next].Type {
+				c.TypeChanges = append(c.TypeChanges, TypeChange{
+					Hand:      b.Hand,
+					Timestamp: b.Timestamp,
+					From:      b.Type,
+					To:        after[next].Type,
+				})
+			}
+			next++
+			continue
+		}
+		c.Removed = append(c.Removed, b)
+	}
+	for ; next < len(after); next++ {
+		if !matched[next] {
+			c.Added = append(c.Added, after[next])
+		}
+	}
+}
+
+// byHand returns one hand's punches ordered by device time.
+func byHand(punches []analytics.PunchEvent, hand string) []analytics.PunchEvent {
+	var out []analytics.PunchEvent
+	for _, p := range punches {
+		if p.Hand == hand {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp < out[j].Timestamp })
+	return out
+}
+
+// summarize counts punches per hand and type.
+func summarize(punches []analytics.PunchEvent) Summary {
+	s := Summary{Total: len(punches), Breakdown: make(map[string]int)}
+	force := 0.0
+	for _, p := range punches {
+		if p.Hand == "left" {
+			s.Left++
+		} else {
+			s.Right++
+		}
+		s.Breakdown[string(p.Type)]++
+		force += p.Force
+	}
+	if s.Total > 0 {
+		s.AvgForce = math.Round(force/float64(s.Total)*100) / 100
+	}
+	return s
+}
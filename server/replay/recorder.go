@@ -0,0 +1,67 @@
+package replay
+
+import (
+	"sync"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+)
+
+// Recorder captures raw packets from live gloves into a Recording.
+type Recorder struct {
+	mu      sync.Mutex
+	packets []Packet
+}
+
+// NewRecorder creates an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Add appends a packet in arrival order.
+func (r *Recorder) Add(hand ble.Hand, packet *ble.SensorPacket) {
+	p := Packet{Hand: hand.String(), Data: packet.Marshal()}
+	r.mu.Lock()
+	r.packets = append(r.packets, p)
+	r.mu.Unlock()
+}
+
+// Reset discards everything recorded so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.packets = nil
+	r.mu.Unlock()
+}
+
+// Len returns the number of packets recorded.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.packets)
+}
+
+// Finish returns the packets recorded so far as a named Recording and
+// starts a new, empty one. The recording keeps what the analyzer detected
+// from them and the stance, thresholds and classifier it used, so that a
+// reanalysis starts from the live session.
+func (r *Recorder) Finish(name string, a *analytics.Analyzer) *Recording {
+	r.mu.Lock()
+	packets := r.packets
+	r.packets = nil
+	r.mu.Unlock()
+
+	rec := &Recording{
+		Version: FormatVersion,
+		Name:    name,
+		Stance:  a.GetState().Stance,
+		Packets: packets,
+		Punches: a.Punches(),
+		Settings: &Settings{
+			Thresholds: [2]float64{a.GetThreshold(ble.LeftHand), a.GetThreshold(ble.RightHand)},
+		},
+	}
+	if tree, ok := a.Classifier().(*analytics.DecisionTree); ok {
+		rec.Settings.Classifier = tree
+	}
+	return rec
+}
@@ -0,0 +1,129 @@
+// Package replay stores raw glove packet streams and feeds them back through
+// the analytics pipeline, so recorded sessions can be re-analysed offline.
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+)
+
+// FormatVersion is the current recording file format.
+const FormatVersion = 1
+
+// Packet is one raw 20-byte sensor packet as received from a glove.
+type Packet struct {
+	Hand string `json:"hand"` // "left" or "right"
+	Data []byte `json:"data"` // base64 in JSON
+}
+
+// Label marks a punch known to be in the recording.
+type Label struct {
+	Hand      string `json:"hand"`
+	Timestamp int64  `json:"ts"`   // device timestamp of the punch (ms)
+	Type      string `json:"type"` // jab, cross, straight, hook or uppercut
+}
+
+// Settings are the analytics settings a live session was recorded with.
+type Settings struct {
+	Thresholds [2]float64              `json:"thresholds"`           // m/s², left and right, as the session ended
+	Classifier *analytics.DecisionTree `json:"classifier,omitempty"` // nil = the heuristic
+}
+
+// Recording is a captured packet stream, in arrival order, with optional
+// ground-truth labels.
+type Recording struct {
+	Version     int      `json:"version"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Stance      string   `json:"stance,omitempty"` // orthodox or southpaw: the live one, or the labels'
+	Packets     []Packet `json:"packets"`
+	Labels      []Label  `json:"labels,omitempty"`
+
+	// Punches detected live while recording, the baseline for reanalysis,
+	// and the settings they were detected with (nil = not recorded live)
+	Punches  []analytics.PunchEvent `json:"punches,omitempty"`
+	Settings *Settings              `json:"settings,omitempty"`
+}
+
+// Load reads a recording file.
+func Load(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read recording: %w", err)
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("decode recording %s: %w", path, err)
+	}
+	if rec.Version > FormatVersion {
+		return nil, fmt.Errorf("recording %s has unsupported version %d", path, rec.Version)
+	}
+	return &rec, nil
+}
+
+// Save writes a recording file.
+func (r *Recording) Save(path string) error {
+	r.Version = FormatVersion
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshal recording: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write recording: %w", err)
+	}
+	return nil
+}
+
+// ApplySettings configures an analyzer as the live session was, before
+// Run. Recordings without settings leave it unchanged.
+func (r *Recording) ApplySettings(a *analytics.Analyzer) {
+	if r.Settings == nil {
+		return
+	}
+	for i, hand := range []ble.Hand{ble.LeftHand, ble.RightHand} {
+		if t := r.Settings.Thresholds[i]; t > 0 {
+			a.SetThreshold(hand, t)
+		}
+	}
+	if r.Settings.Classifier != nil {
+		a.SetClassifier(r.Settings.Classifier)
+	}
+}
+
+// Run streams every packet of the recording into the analyzer. The caller
+// decides whether a session is active while replaying.
+func Run(a *analytics.Analyzer, r *Recording) error {
+	if r.Stance != "" {
+		a.SetStance(r.Stance)
+	}
+	for i, p := range r.Packets {
+		hand, err := parseHand(p.Hand)
+		if err != nil {
+			return fmt.Errorf("packet %d: %w", i, err)
+		}
+		packets, err := ble.ParsePackets(p.Data)
+		if err != nil {
+			return fmt.Errorf("packet %d: %w", i, err)
+		}
+		for _, packet := range packets {
+			a.ProcessPacket(hand, packet)
+		}
+	}
+	return nil
+}
+
+// parseHand maps a recorded hand name to a ble.Hand.
+func parseHand(name string) (ble.Hand, error) {
+	switch name {
+	case "left":
+		return ble.LeftHand, nil
+	case "right":
+		return ble.RightHand, nil
+	}
+	return 0, errors.New("invalid hand " + name)
+}
@@ -0,0 +1,249 @@
+// Package schedule stores the sessions coaches plan for their athletes.
+package schedule
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"boxing-analytics/validation"
+)
+
+// ErrNotFound is returned when a planned session does not exist.
+var ErrNotFound = errors.New("planned session not found")
+
+// DefaultRemindMin is how long before a planned session its reminder goes
+// out when it does not say.
+const DefaultRemindMin = 60
+
+// Entry is a planned session.
+type Entry struct {
+	ID          string    `json:"id"`
+	Athlete     string    `json:"athlete"`
+	At          time.Time `json:"at"`                     // planned start
+	Program     string    `json:"program,omitempty"`      // workout program to run
+	SessionType string    `json:"session_type,omitempty"` // "" = the program's, or the default
+	Notes       string    `json:"notes,omitempty"`        // from the coach
+	RemindMin   int       `json:"remind_min,omitempty"`   // minutes ahead to remind, 0 = DefaultRemindMin, -1 = never
+
+	Reminded  bool       `json:"reminded,omitempty"`   // reminder sent
+	StartedAt *time.Time `json:"started_at,omitempty"` // when the athlete started it, nil = not yet
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Validate checks the entry fields, returning validation.Errors listing
+// every invalid one.
+func (e *Entry) Validate() error {
+	var errs validation.Errors
+	if e.Athlete == "" {
+		errs.Add("athlete", fmt.Errorf("athlete is required"))
+	}
+	if e.At.IsZero() {
+		errs.Add("at", fmt.Errorf("at is required"))
+	}
+	if e.RemindMin < -1 {
+		errs.Add("remind_min", fmt.Errorf("invalid remind_min %d", e.RemindMin))
+	}
+	return errs.Err()
+}
+
+// RemindAt returns when the entry's reminder is due, zero if it has none.
+func (e *Entry) RemindAt() time.Time {
+	switch e.RemindMin {
+	case -1:
+		return time.Time{}
+	case 0:
+		return e.At.Add(-DefaultRemindMin * time.Minute)
+	}
+	return e.At.Add(-time.Duration(e.RemindMin) * time.Minute)
+}
+
+// Store keeps all planned sessions in a single JSON file.
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	entries map[string]*Entry
+}
+
+// NewStore loads planned sessions from path, starting empty if it does not
+// exist.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, entries: make(map[string]*Entry)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read schedule: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return nil, fmt.Errorf("decode schedule: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns a planned session by ID.
+func (s *Store) Get(id string) (Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	return *e, nil
+}
+
+// List returns the sessions planned from from up to to (zero = unbounded)
+// for athlete ("" = all), in planned order.
+func (s *Store) List(athlete string, from, to time.Time) []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if athlete != "" && e.Athlete != athlete {
+			continue
+		}
+		if (!from.IsZero() && e.At.Before(from)) || (!to.IsZero() && !e.At.Before(to)) {
+			continue
+		}
+		list = append(list, *e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].At.Equal(list[j].At) {
+			return list[i].At.Before(list[j].At)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Put creates an entry, given a new random ID when it has none, or
+// replaces one and writes the store to disk. Moving a session resends its
+// reminder.
+func (s *Store) Put(e Entry) (Entry, error) {
+	if err := e.Validate(); err != nil {
+		return Entry{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if e.ID == "" {
+		var b [6]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return Entry{}, fmt.Errorf("generate id: %w", err)
+		}
+		e.ID = hex.EncodeToString(b[:])
+	}
+	if existing, ok := s.entries[e.ID]; ok {
+		e.CreatedAt = existing.CreatedAt
+		e.StartedAt = existing.StartedAt
+		e.Reminded = existing.Reminded && existing.At.Equal(e.At) && existing.RemindMin == e.RemindMin
+	} else {
+		e.CreatedAt = now
+		e.Reminded, e.StartedAt = false, nil
+	}
+	e.UpdatedAt = now
+	s.entries[e.ID] = &e
+
+	if err := s.saveLocked(); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+// Delete removes a planned session.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.entries, id)
+	return s.saveLocked()
+}
+
+// DueReminders marks the sessions whose reminder is due at now, and that
+// have not started, as reminded and returns them.
+func (s *Store) DueReminders(now time.Time) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []Entry
+	for _, e := range s.entries {
+		at := e.RemindAt()
+		if e.Reminded || e.StartedAt != nil || at.IsZero() || now.Before(at) || !now.Before(e.At) {
+			continue
+		}
+		e.Reminded = true
+		due = append(due, *e)
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].At.Before(due[j].At) })
+	return due, s.saveLocked()
+}
+
+// Planned returns the athlete's next session planned for the day of now
+// that has not started yet. ok is false when there is none.
+func (s *Store) Planned(athlete string, now time.Time) (entry Entry, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	y, m, d := now.Date()
+	dayStart := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	var next *Entry
+	for _, e := range s.entries {
+		if e.Athlete != athlete || e.StartedAt != nil || e.At.Before(dayStart) || !e.At.Before(dayEnd) {
+			continue
+		}
+		if next == nil || e.At.Before(next.At) {
+			next = e
+		}
+	}
+	if next == nil {
+		return Entry{}, false
+	}
+	return *next, true
+}
+
+// MarkStarted records that a planned session was started at the given time.
+func (s *Store) MarkStarted(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	e.StartedAt = &at
+	return s.saveLocked()
+}
+
+// saveLocked writes all planned sessions to disk.
+// Must be called with s.mu held.
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal schedule: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("write schedule: %w", err)
+	}
+	return nil
+}
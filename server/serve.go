@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"boxing-analytics/logfile"
+	"boxing-analytics/server"
+)
+
+// defaultViteURL is where "npm run dev" serves the dashboard.
+const defaultViteURL = "http://localhost:5173"
+
+// runServe implements the "serve" subcommand: it runs the analytics server,
+// configured from the environment (see README), until interrupted.
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	staticDir := flags.String("static-dir", "", "serve the dashboard from this directory instead of the embedded build")
+	dev := flags.Bool("dev", false, "proxy the dashboard to a running Vite dev server (see -vite-url)")
+	viteURL := flags.String("vite-url", defaultViteURL, "Vite dev server used by -dev")
+	httpAddr := flags.String("http-addr", "", "HTTP/WebSocket bind address and port, e.g. 127.0.0.1:8080 (overrides HTTP_PORT)")
+	udpAddr := flags.String("udp-addr", "", "UDP ingest bind address and port, e.g. 192.168.1.10:5005, or off (overrides UDP_PORT)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: serve [flags]")
+		fmt.Fprintln(flags.Output(), "The server is otherwise configured by environment variables, see README.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	cfg, err := server.ConfigFromEnv()
+	if err != nil {
+		log.Printf("Invalid configuration: %v", err)
+		return 1
+	}
+	if *httpAddr != "" {
+		cfg.HTTPAddr = server.ListenAddr(*httpAddr)
+	}
+	switch *udpAddr {
+	case "":
+	case "off":
+		cfg.UDPAddr = ""
+	default:
+		cfg.UDPAddr = server.ListenAddr(*udpAddr)
+	}
+
+	// Log to a file too, or instead, when one is configured
+	if cfg.Log.Path != "" {
+		file, err := logfile.Open(cfg.Log)
+		if err != nil {
+			log.Printf("Log file: %v", err)
+			return 1
+		}
+		defer file.Close()
+		if cfg.Log.Quiet {
+			log.SetOutput(file)
+		} else {
+			log.SetOutput(io.MultiWriter(os.Stderr, file))
+		}
+		defer log.SetOutput(os.Stderr)
+	}
+
+	log.Println("========================================")
+	log.Println("FighterLink Boxing Analytics Server", server.Version)
+	log.Println("========================================")
+
+	// Cancelled on Ctrl-C / SIGTERM to shut BLE and HTTP down cleanly
+	ctx, stop := signalContext()
+	defer stop()
+
+	// Embedded React build, unless front-end development overrides it
+	switch {
+	case *dev:
+		cfg.DevProxy = *viteURL
+	case *staticDir != "":
+		if _, err := os.Stat(filepath.Join(*staticDir, "index.html")); err != nil {
+			log.Printf("Invalid static dir: %v", err)
+			return 1
+		}
+		cfg.Static = os.DirFS(*staticDir)
+		log.Printf("Dashboard served from %s", *staticDir)
+	default:
+		cfg.Static, err = fs.Sub(staticFiles, "static")
+		if err != nil {
+			log.Printf("embed sub: %v", err)
+			return 1
+		}
+	}
+
+	srv, err := server.New(cfg)
+	if err != nil {
+		log.Printf("Failed to start server: %v", err)
+		return 1
+	}
+	if err := srv.Run(ctx); err != nil {
+		log.Printf("Server: %v", err)
+		return 1
+	}
+	return 0
+}
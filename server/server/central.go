@@ -0,0 +1,62 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"boxing-analytics/ble"
+)
+
+// NewCentral returns a BLE central for the (first) athlete's gloves set up
+// as the server sets up its own: cfg's transport, device names, adapters
+// and bonding, with the calibration and glove pairings kept in cfg.DataDir.
+// Tools that talk to the gloves without serving (calibration, scans) start
+// from it; call Enable before use.
+func NewCentral(cfg Config) (*ble.Central, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	central, _, err := newCentral(cfg)
+	return central, err
+}
+
+// newCentral implements NewCentral, also returning the calibration store so
+// the opponent's central can share it.
+func newCentral(cfg Config) (*ble.Central, *ble.CalibrationStore, error) {
+	central := ble.NewCentral()
+	if cfg.Transport != nil {
+		central.SetTransport(cfg.Transport)
+	}
+
+	// Per-glove sensor offsets measured via /api/calibrate
+	calibration, err := ble.NewCalibrationStore(filepath.Join(cfg.DataDir, "calibration.json"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("load glove calibration: %w", err)
+	}
+	central.SetCalibrationStore(calibration)
+
+	// Gloves paired to each hand, connected directly by MAC on startup
+	pairing, err := ble.NewPairingStore(filepath.Join(cfg.DataDir, "pairing.json"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("load glove pairings: %w", err)
+	}
+	central.SetPairingStore(pairing)
+	central.SetGapInterpolation(cfg.FillGap)
+
+	if cfg.Bonding {
+		if err := central.EnableBonding(cfg.Passkey); err != nil {
+			return nil, nil, fmt.Errorf("enable BLE bonding: %w", err)
+		}
+		log.Println("BLE bonding enabled")
+	}
+
+	if err := central.SetDeviceConfig(cfg.Devices); err != nil {
+		return nil, nil, fmt.Errorf("invalid BLE device config: %w", err)
+	}
+	for hand, id := range cfg.Adapters {
+		central.SetAdapter(hand, id)
+		log.Printf("%s glove assigned to adapter %s", hand, id)
+	}
+	return central, calibration, nil
+}
@@ -0,0 +1,316 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+)
+
+// ConfigFromEnv returns the default configuration overridden by the
+// server's environment variables (see README).
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+
+	// Check for debug mode
+	cfg.DebugBLE = os.Getenv("DEBUG_BLE") == "1"
+
+	// HTTP_PORT is the HTTP/WebSocket listen address: a port, or a bind
+	// address and port such as "127.0.0.1:8080"
+	if v := os.Getenv("HTTP_PORT"); v != "" {
+		cfg.HTTPAddr = ListenAddr(v)
+	}
+	// HTTP_ACCESS_LOG=0 stops logging every request
+	cfg.AccessLog = os.Getenv("HTTP_ACCESS_LOG") != "0"
+
+	// UDP_PORT (default :5005, "off" to disable) receives samples from
+	// gloves streaming over Wi-Fi, with an optional bind address like
+	// HTTP_PORT
+	if v := os.Getenv("UDP_PORT"); v == "off" {
+		cfg.UDPAddr = ""
+	} else if v != "" {
+		cfg.UDPAddr = ListenAddr(v)
+	}
+
+	// SERIAL_PORTS (comma-separated, e.g. "/dev/ttyACM0,/dev/ttyACM1") reads
+	// gloves wired over USB at SERIAL_BAUD (default 115200)
+	cfg.SerialPorts = splitList(os.Getenv("SERIAL_PORTS"))
+	if v := os.Getenv("SERIAL_BAUD"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid SERIAL_BAUD %q", v)
+		}
+		cfg.SerialBaud = n
+	}
+
+	// Session history lives in DATA_DIR; GYM_ID tags sessions recorded here
+	if v := os.Getenv("DATA_DIR"); v != "" {
+		cfg.DataDir = v
+	}
+	cfg.Gym = os.Getenv("GYM_ID")
+
+	// RECORD_RAW=1 keeps every session's raw packets for offline reanalysis
+	cfg.RecordRaw = os.Getenv("RECORD_RAW") == "1"
+
+	// SESSION_RESTORE (auto, offer or off) decides whether a session the
+	// server crashed in the middle of is resumed at startup, offered over
+	// the API, or discarded
+	if v := os.Getenv("SESSION_RESTORE"); v != "" {
+		cfg.SessionRestore = v
+	}
+
+	// RETAIN_RECORDINGS_DAYS deletes raw recordings after that many days and
+	// RETAIN_PUNCHES_MONTHS strips per-punch detail from sessions after that
+	// many months (default 0 = keep forever); summaries are never deleted
+	for env, n := range map[string]*int{
+		"RETAIN_RECORDINGS_DAYS": &cfg.RecordingRetentionDays,
+		"RETAIN_PUNCHES_MONTHS":  &cfg.PunchRetentionMonths,
+	} {
+		if v := os.Getenv(env); v != "" {
+			value, err := strconv.Atoi(v)
+			if err != nil || value < 0 {
+				return cfg, fmt.Errorf("invalid %s %q", env, v)
+			}
+			*n = value
+		}
+	}
+
+	// SYNC_URL (s3://bucket/prefix or https://host/path) uploads finished
+	// sessions, and with SYNC_RECORDINGS=1 their raw recordings. S3 uses
+	// AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY / AWS_REGION and, for
+	// S3-compatible stores, SYNC_S3_ENDPOINT; HTTPS sends SYNC_TOKEN
+	cfg.Sync.URL = os.Getenv("SYNC_URL")
+	cfg.Sync.Token = os.Getenv("SYNC_TOKEN")
+	cfg.Sync.Endpoint = os.Getenv("SYNC_S3_ENDPOINT")
+	cfg.Sync.Region = os.Getenv("AWS_REGION")
+	cfg.Sync.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	cfg.Sync.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	cfg.Sync.Recordings = os.Getenv("SYNC_RECORDINGS") == "1"
+
+	// WS_SLOW_CLIENTS (drop-newest or drop-oldest) picks which frame a
+	// dashboard that falls behind loses; WS_MAX_DROPS=N disconnects it after
+	// N frames dropped in a row (default 0 = never)
+	if v := os.Getenv("WS_SLOW_CLIENTS"); v != "" {
+		cfg.SlowClients.Drop = v
+	}
+	if v := os.Getenv("WS_MAX_DROPS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid WS_MAX_DROPS %q", v)
+		}
+		cfg.SlowClients.MaxDrops = n
+	}
+
+	// LOG_FILE also writes the log to that file, rotated at LOG_MAX_SIZE_MB
+	// (default 10, 0 = no limit) and, with LOG_ROTATE=hourly or daily, at
+	// every hour or day. LOG_KEEP (default 5, 0 = all) rotated files are
+	// kept, none older than LOG_MAX_AGE_DAYS (default 0 = no limit).
+	// LOG_CONSOLE=0 stops logging to the console as well
+	cfg.Log.Path = os.Getenv("LOG_FILE")
+	cfg.Log.Rotate = os.Getenv("LOG_ROTATE")
+	cfg.Log.Quiet = os.Getenv("LOG_CONSOLE") == "0"
+	if v := os.Getenv("LOG_MAX_SIZE_MB"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid LOG_MAX_SIZE_MB %q", v)
+		}
+		cfg.Log.MaxSizeMB = n
+	}
+	if v := os.Getenv("LOG_KEEP"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid LOG_KEEP %q", v)
+		}
+		cfg.Log.Keep = n
+	}
+	if v := os.Getenv("LOG_MAX_AGE_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid LOG_MAX_AGE_DAYS %q", v)
+		}
+		cfg.Log.MaxAgeDays = n
+	}
+
+	// Guest (drop-in) profiles expire after GUEST_TTL, e.g. "12h"
+	if v := os.Getenv("GUEST_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			return cfg, fmt.Errorf("invalid GUEST_TTL %q", v)
+		}
+		cfg.GuestTTL = ttl
+	}
+
+	// Optional lobby-screen pushes configured by SIGNAGE_CONFIG
+	cfg.SignageConfig = os.Getenv("SIGNAGE_CONFIG")
+
+	// Learn per-athlete detection thresholds from the first punches of a session
+	cfg.AutoThreshold = os.Getenv("AUTO_THRESHOLD") == "1"
+
+	// SPECTRUM=1 enables FFT rhythm and ringing analysis
+	cfg.Spectrum = os.Getenv("SPECTRUM") == "1"
+
+	// CLASSIFIER_MODEL selects a trained decision tree over the gyro heuristic
+	cfg.ClassifierModel = os.Getenv("CLASSIFIER_MODEL")
+
+	// SESSION_TYPES names a JSON file of detection profiles per session type,
+	// e.g. {"shadowboxing":{"threshold":18}}, overriding or adding to the
+	// built-in heavy_bag, shadowboxing, pads and speed_bag
+	if v := os.Getenv("SESSION_TYPES"); v != "" {
+		profiles, err := analytics.LoadDetectionProfiles(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid SESSION_TYPES %q: %w", v, err)
+		}
+		cfg.SessionTypes = profiles
+	}
+
+	// FORCE_BUCKETS (comma-separated m/s², default "20,30,40,50,60,80,100")
+	// are the edges between the bars of the force histogram
+	if v := os.Getenv("FORCE_BUCKETS"); v != "" {
+		var edges []float64
+		for _, f := range splitList(v) {
+			e, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid FORCE_BUCKETS %q", v)
+			}
+			edges = append(edges, e)
+		}
+		cfg.ForceBuckets = edges
+	}
+
+	// Warn when one hand throws less than BALANCE_MIN_SHARE of all punches (0 = off)
+	if v := os.Getenv("BALANCE_MIN_SHARE"); v != "" {
+		share, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid BALANCE_MIN_SHARE %q", v)
+		}
+		cfg.BalanceMinShare = share
+	}
+
+	// BATTERY_THRESHOLDS (comma-separated percentages, default "20,10") raise a
+	// one-shot "battery_low" event per glove as its battery drains past each;
+	// "off" disables them. BATTERY_WEBHOOK_URL also POSTs those events.
+	if v := os.Getenv("BATTERY_THRESHOLDS"); v != "" {
+		var thresholds []int
+		if v != "off" {
+			for _, f := range splitList(v) {
+				t, err := strconv.Atoi(f)
+				if err != nil {
+					return cfg, fmt.Errorf("invalid BATTERY_THRESHOLDS %q", v)
+				}
+				thresholds = append(thresholds, t)
+			}
+		}
+		cfg.BatteryThresholds = thresholds
+	}
+	cfg.BatteryWebhookURL = os.Getenv("BATTERY_WEBHOOK_URL")
+
+	// BLE_FILL_GAP=N fills gaps of up to N dropped samples by interpolation
+	// so punch detection sees a continuous stream (default 0 = off)
+	if v := os.Getenv("BLE_FILL_GAP"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid BLE_FILL_GAP %q", v)
+		}
+		cfg.FillGap = n
+	}
+
+	// BLE_PASSKEY bonds gloves built with BLE_BONDING using the same passkey,
+	// encrypting the link and locking the gloves to this server
+	if v := os.Getenv("BLE_PASSKEY"); v != "" {
+		passkey, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid BLE_PASSKEY %q", v)
+		}
+		cfg.Bonding = true
+		cfg.Passkey = uint32(passkey)
+	}
+
+	// BLE_LEFT_NAMES / BLE_RIGHT_NAMES (comma-separated patterns such as
+	// "FighterLink_L,IMU-L*") and BLE_*_UUID accept renamed or third-party
+	// glove firmware. An empty optional characteristic UUID disables it.
+	if v := os.Getenv("BLE_LEFT_NAMES"); v != "" {
+		cfg.Devices.LeftNames = splitList(v)
+	}
+	if v := os.Getenv("BLE_RIGHT_NAMES"); v != "" {
+		cfg.Devices.RightNames = splitList(v)
+	}
+	for env, uuid := range map[string]*string{
+		"BLE_SERVICE_UUID": &cfg.Devices.ServiceUUID,
+		"BLE_SENSOR_UUID":  &cfg.Devices.SensorCharUUID,
+		"BLE_BATTERY_UUID": &cfg.Devices.BatteryCharUUID,
+		"BLE_DEVICE_UUID":  &cfg.Devices.DeviceCharUUID,
+		"BLE_COMMAND_UUID": &cfg.Devices.CommandCharUUID,
+	} {
+		if v, ok := os.LookupEnv(env); ok {
+			*uuid = v
+		}
+	}
+
+	// BLE_ADAPTER_LEFT / BLE_ADAPTER_RIGHT put a glove on its own adapter (e.g. "hci1")
+	for _, hand := range []ble.Hand{ble.LeftHand, ble.RightHand} {
+		if id := os.Getenv("BLE_ADAPTER_" + strings.ToUpper(hand.String())); id != "" {
+			cfg.Adapters[hand] = id
+		}
+	}
+
+	// HEART_RATE=1 also connects a standard Bluetooth heart-rate strap
+	cfg.HeartRate = os.Getenv("HEART_RATE") == "1"
+
+	// ROUND_LENGTH sets the round length heart rate and output are compared over
+	if v := os.Getenv("ROUND_LENGTH"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid ROUND_LENGTH %q", v)
+		}
+		cfg.RoundLength = d
+	}
+
+	// UNITS reports the output in "metric" (the default) or "imperial" units,
+	// or a mix such as "accel=g,force=N,mass=lb"
+	if v := os.Getenv("UNITS"); v != "" {
+		u, err := analytics.ParseUnits(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid UNITS: %w", err)
+		}
+		cfg.Units = u
+	}
+
+	// ROUND_BUZZ=1 buzzes both gloves at the end of every round
+	cfg.RoundBuzz = os.Getenv("ROUND_BUZZ") == "1"
+
+	// SPARRING=1 adds a second athlete ("b") wearing gloves named
+	// FighterLink_L2 / FighterLink_R2 (BLE_B_LEFT_NAMES / BLE_B_RIGHT_NAMES)
+	cfg.Sparring = os.Getenv("SPARRING") == "1"
+	if v := os.Getenv("BLE_B_LEFT_NAMES"); v != "" {
+		cfg.OpponentLeftNames = splitList(v)
+	}
+	if v := os.Getenv("BLE_B_RIGHT_NAMES"); v != "" {
+		cfg.OpponentRightNames = splitList(v)
+	}
+
+	return cfg, nil
+}
+
+// ListenAddr turns a bare port such as "8080" into a listen address on all
+// interfaces; host:port addresses are returned as they are.
+func ListenAddr(v string) string {
+	if _, err := strconv.Atoi(v); err == nil {
+		return ":" + v
+	}
+	return v
+}
+
+// splitList splits a comma-separated setting, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+	"boxing-analytics/fleet"
+	"boxing-analytics/httpapi"
+	"boxing-analytics/hub"
+	"boxing-analytics/ingest"
+	"boxing-analytics/notify"
+	"boxing-analytics/profiles"
+	"boxing-analytics/storage"
+	"boxing-analytics/wire"
+)
+
+// sessionMessage encodes the state broadcast to WebSocket clients: the
+// session state, or in sparring mode both athletes side by side, each in
+// their units.
+func (s *Server) sessionMessage() (hub.Message, error) {
+	state := s.analyzer.GetState()
+	if s.opponent == nil {
+		state = state.InUnits(s.unitsOf(s.analyzer))
+		data, err := json.Marshal(state)
+		return hub.Message{JSON: data, Protobuf: wire.State(state)}, err
+	}
+	// Compared before conversion, as the athletes' units may differ
+	sparring := analytics.NewSparringState(state, s.opponent.GetState())
+	sparring.A = sparring.A.InUnits(s.unitsOf(s.analyzer))
+	sparring.B = sparring.B.InUnits(s.unitsOf(s.opponent))
+	data, err := json.Marshal(sparring)
+	return hub.Message{JSON: data, Protobuf: wire.Sparring(sparring)}, err
+}
+
+// unitsOf returns the output units of an analyzer's session: its athlete's
+// choice, or else the server's.
+func (s *Server) unitsOf(analyzer *analytics.Analyzer) analytics.Units {
+	if profile, err := s.profiles.Get(analyzer.Athlete()); err == nil && profile.Units != nil {
+		return *profile.Units
+	}
+	return s.cfg.Units
+}
+
+// eventUnits returns the units of an analyzer's events, see unitsOf.
+func (s *Server) eventUnits(analyzer *analytics.Analyzer) func() analytics.Units {
+	return func() analytics.Units { return s.unitsOf(analyzer) }
+}
+
+// deviceStatusMessage encodes a glove set's status, as served by
+// /api/status, as a "device_status" message. side tags the athlete's gloves
+// in sparring mode; udp, the UDP ingest, belongs to the first athlete and is
+// nil for the second. It has no protobuf form.
+func deviceStatusMessage(central *ble.Central, udp *ingest.UDPSource, side string) (hub.Message, error) {
+	data, err := json.Marshal(&analytics.Event{
+		Type:      "device_status",
+		Side:      side,
+		Timestamp: time.Now().UnixMilli(),
+		Data:      httpapi.DeviceStatus(central, udp),
+	})
+	return hub.Message{JSON: data}, err
+}
+
+// snapshot returns the messages a WebSocket client gets as it connects, so
+// a dashboard shows the session and the gloves at once rather than at the
+// next punch or tick: the session state, then each glove set's status.
+func (s *Server) snapshot() []func() (hub.Message, error) {
+	hello := []func() (hub.Message, error){
+		s.sessionMessage,
+	}
+	if s.opponent == nil {
+		return append(hello, func() (hub.Message, error) { return deviceStatusMessage(s.central, s.udp, "") })
+	}
+	return append(hello,
+		func() (hub.Message, error) { return deviceStatusMessage(s.central, s.udp, "a") },
+		func() (hub.Message, error) { return deviceStatusMessage(s.opponentCentral, nil, "b") },
+	)
+}
+
+// firmwareUpdated broadcasts the gloves' status as a glove's firmware update
+// over UDP progresses, so the dashboard shows it, and reports a finished
+// update to the campaign that offered it.
+func (s *Server) firmwareUpdated(device ingest.UDPDevice) {
+	if u := device.Update; u != nil && u.Campaign != 0 && (u.State == ingest.UpdateDone || u.State == ingest.UpdateFailed) {
+		status := fleet.TargetDone
+		if u.State == ingest.UpdateFailed {
+			status = fleet.TargetFailed
+		}
+		if err := s.registry.ReportUpdate(u.Campaign, device.Identity, status, u.Message); err != nil {
+			log.Printf("Fleet: report update of %s: %v", device.Identity, err)
+		}
+	}
+
+	side := ""
+	if s.opponent != nil {
+		side = "a"
+	}
+	m, err := deviceStatusMessage(s.central, s.udp, side)
+	if err != nil {
+		log.Printf("JSON marshal error: %v", err)
+		return
+	}
+	s.hub.BroadcastMessage(m)
+}
+
+// handleBLEEvents keeps the analyzer and fleet registry in step with glove
+// connections, battery readings and calibration changes.
+func handleBLEEvents(events <-chan ble.Event, analyzer *analytics.Analyzer, registry *fleet.Registry) {
+	for ev := range events {
+		addr := ev.Address.String()
+		switch ev.Type {
+		case ble.EventConnected:
+			analyzer.SetConnected(ev.Hand, true)
+			registry.Connected(addr, ev.Name, ev.Hand.String())
+			if ev.Glove != nil && ev.Glove.FirmwareVersion != "" {
+				registry.SetFirmware(addr, ev.Glove.FirmwareVersion, ev.Glove.HardwareRev)
+			}
+		case ble.EventDisconnected:
+			analyzer.SetConnected(ev.Hand, false)
+			registry.Disconnected(addr)
+		case ble.EventBattery:
+			// The analyzer raises the warnings, from these readings or,
+			// for gloves without the characteristic, from the packets
+			analyzer.SetBattery(ev.Hand, ev.Battery)
+		case ble.EventCalibrationChanged:
+			// Offsets change the gravity reference, so re-capture it
+			analyzer.ResetCalibration(ev.Hand)
+		}
+	}
+}
+
+// updateLinkStats copies link quality readings from the BLE layer into an
+// analyzer.
+func updateLinkStats(analyzer *analytics.Analyzer, central *ble.Central) {
+	for _, hand := range []ble.Hand{ble.LeftHand, ble.RightHand} {
+		analyzer.SetCorruptPackets(hand, central.GetCorruptPackets(hand))
+		analyzer.SetPacketLoss(hand, central.GetPacketLoss(hand))
+	}
+}
+
+// buzzGloves gives both connected gloves a short buzz, ignoring gloves whose
+// firmware takes no commands.
+func buzzGloves(central *ble.Central) {
+	for _, hand := range []ble.Hand{ble.LeftHand, ble.RightHand} {
+		if !central.IsConnected(hand) {
+			continue
+		}
+		if err := central.Buzz(hand, roundEndBuzz, 255); err != nil && !errors.Is(err, ble.ErrNoCommandChar) {
+			log.Printf("Buzz %s glove: %v", hand, err)
+		}
+	}
+}
+
+// pruneGuests removes expired guest profiles together with their sessions
+// and learned thresholds.
+func pruneGuests(profileStore *profiles.Store, store *storage.Store, analyzer *analytics.Analyzer) {
+	expired, err := profileStore.RemoveExpired(time.Now())
+	if err != nil {
+		log.Printf("Guest prune: %v", err)
+	}
+	for _, guest := range expired {
+		deleted, err := store.DeleteByAthlete(guest.ID)
+		if err != nil {
+			log.Printf("Guest prune %s: %v", guest.ID, err)
+		}
+		analyzer.ForgetAthlete(guest.ID)
+		log.Printf("Guest profile %s expired (%d sessions removed)", guest.ID, deleted)
+	}
+}
+
+// webhookClient is used for all outgoing webhook calls.
+var webhookClient = &http.Client{Timeout: 5 * time.Second}
+
+// postWebhook POSTs payload as JSON to url, logging any failure.
+func postWebhook(url string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Webhook marshal: %v", err)
+		return
+	}
+	resp, err := webhookClient.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Printf("Webhook %s: %v", url, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("Webhook %s: HTTP %d", url, resp.StatusCode)
+	}
+}
+
+// eventHandler broadcasts analytics events to WebSocket clients, performing
+// alert actions and battery webhooks on the way. side tags the events of
+// each athlete in sparring mode. Battery warnings also go to the athlete's
+// notification channels through batteryLow (nil = none). Punches are sent in
+// the units of the state (nil = metric).
+func eventHandler(hub *hub.Hub, central *ble.Central, side, batteryWebhook string, batteryLow func(hand string, low analytics.BatteryLowEvent), units func() analytics.Units) analytics.EventHandler {
+	return func(event *analytics.Event) {
+		event.Side = side
+		if punch, ok := event.Data.(analytics.PunchEvent); ok && units != nil {
+			event.Data = punch.InUnits(units())
+		}
+		if event.Type == "alert" {
+			dispatchAlert(hub, central, event)
+			return
+		}
+		if low, ok := event.Data.(analytics.BatteryLowEvent); ok {
+			log.Printf("Battery low on %s glove: %d%% (threshold %d%%)", event.Hand, low.Level, low.Threshold)
+			if batteryWebhook != "" {
+				postWebhook(batteryWebhook, event)
+			}
+			if batteryLow != nil {
+				batteryLow(event.Hand, low)
+			}
+		}
+		publishEvent(hub, event)
+	}
+}
+
+// publishEvent broadcasts an event to WebSocket clients, numbered so that
+// reconnecting clients can catch up on it.
+func publishEvent(h *hub.Hub, event *analytics.Event) {
+	err := h.Publish(func(seq uint64) (hub.Message, error) {
+		event.Seq = seq
+		data, err := json.Marshal(event)
+		return hub.Message{JSON: data, Protobuf: wire.Event(event)}, err
+	})
+	if err != nil {
+		log.Printf("JSON marshal error: %v", err)
+	}
+}
+
+// notifyBatteryLow sends battery warnings to the notification channels of
+// the athlete an analyzer's session belongs to.
+func notifyBatteryLow(analyzer *analytics.Analyzer, profileStore *profiles.Store) func(string, analytics.BatteryLowEvent) {
+	return func(hand string, low analytics.BatteryLowEvent) {
+		profile, err := profileStore.Get(analyzer.Athlete())
+		if err != nil || len(profile.Notifications) == 0 {
+			return
+		}
+		notify.Send(profile.Notifications, notify.BatteryLow(profile.ID, hand, low))
+	}
+}
+
+// dispatchAlert performs a fired alert rule's action.
+func dispatchAlert(hub *hub.Hub, central *ble.Central, event *analytics.Event) {
+	alert, ok := event.Data.(analytics.AlertEvent)
+	if !ok {
+		return
+	}
+	log.Printf("Alert: rule %d (%s %s %s %.2f) fired with value %.2f",
+		alert.Rule.ID, alert.Rule.Hand, alert.Rule.Metric, alert.Rule.Comparator, alert.Rule.Value, alert.Value)
+
+	switch alert.Rule.Action {
+	case analytics.AlertActionWS:
+		publishEvent(hub, event)
+	case analytics.AlertActionWebhook:
+		postWebhook(alert.Rule.WebhookURL, event)
+	case analytics.AlertActionBuzz:
+		// A combined rule buzzes every connected glove
+		hands := []ble.Hand{ble.LeftHand, ble.RightHand}
+		switch alert.Rule.Hand {
+		case "left":
+			hands = hands[:1]
+		case "right":
+			hands = hands[1:]
+		}
+		for _, hand := range hands {
+			if len(hands) > 1 && !central.IsConnected(hand) {
+				continue
+			}
+			if err := central.Buzz(hand, alertBuzz, 255); err != nil {
+				log.Printf("Alert: buzz %s glove for rule %d: %v", hand, alert.Rule.ID, err)
+			}
+		}
+	}
+}
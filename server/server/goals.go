@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"boxing-analytics/goals"
+	"boxing-analytics/notify"
+)
+
+// evaluateGoalsNightly runs evaluateGoals now and then every night at
+// midnight until ctx is cancelled.
+func (s *Server) evaluateGoalsNightly(ctx context.Context) {
+	for {
+		s.evaluateGoals(time.Now())
+
+		now := time.Now()
+		y, m, d := now.Date()
+		timer := time.NewTimer(time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// evaluateGoals records last week's result for every athlete who had
+// weekly targets by then, telling those who missed it, and on Sundays
+// reminds athletes who have not met this week's goal yet.
+func (s *Server) evaluateGoals(now time.Time) {
+	athletes := s.goals.Athletes()
+	if len(athletes) == 0 {
+		return
+	}
+	sessions, err := s.store.List()
+	if err != nil {
+		log.Printf("Goals: %v", err)
+		return
+	}
+
+	week := goals.WeekStart(now)
+	lastWeek := week.AddDate(0, 0, -7)
+	for _, athlete := range athletes {
+		targets, err := s.goals.Targets(athlete)
+		if err != nil {
+			continue
+		}
+		if targets.Since.Before(week) && !s.goals.Recorded(athlete, lastWeek) {
+			p := goals.ComputeProgress(sessions, athlete, lastWeek, targets)
+			if err := s.goals.Record(p); err != nil {
+				log.Printf("Goals: %v", err)
+			}
+			log.Printf("Goals: %s reached %.0f%% of the week of %s", athlete, p.Completion, p.Week)
+			if !p.Met {
+				s.notifyGoal(p, true)
+			}
+		}
+		if now.Weekday() == time.Sunday {
+			if p := goals.ComputeProgress(sessions, athlete, week, targets); !p.Met {
+				s.notifyGoal(p, false)
+			}
+		}
+	}
+}
+
+// notifyGoal sends an athlete the reminder of a weekly goal not met.
+func (s *Server) notifyGoal(p goals.Progress, weekOver bool) {
+	if profile, err := s.profiles.Get(p.Athlete); err == nil && len(profile.Notifications) > 0 {
+		go notify.Send(profile.Notifications, notify.GoalReminder(p, weekOver))
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/httpapi"
+	"boxing-analytics/journal"
+	"boxing-analytics/profiles"
+)
+
+// maxRestoreAge is how long after it started a session left unfinished by a
+// crash is still restored; older ones are dropped.
+const maxRestoreAge = 12 * time.Hour
+
+// unfinished is a session a crash left unfinished, with the analyzer it
+// belongs to.
+type unfinished struct {
+	side     string // "b" for athlete b in sparring mode
+	analyzer *analytics.Analyzer
+	journal  *journal.Journal
+	session  *journal.Session
+}
+
+// restore resumes the session on its analyzer.
+func (u *unfinished) restore(profileStore *profiles.Store) {
+	sess := u.session
+	httpapi.ApplyProfile(u.analyzer, profileStore, sess.Athlete)
+	if err := u.analyzer.SetSessionType(sess.SessionType); err != nil {
+		log.Printf("Restored session: %v", err) // no longer configured
+	}
+	u.analyzer.SetSessionInfo(sess.Info)
+	// Leave out the time the server was down, now and at earlier restores
+	downtime := sess.Downtime + time.Since(sess.LastActivity)
+	u.analyzer.RestoreSession(sess.StartedAt, downtime, sess.Paused, sess.Punches)
+	u.journal.SessionRestored()
+	log.Printf("Restored session started %s (%d punches, %s down)", sess.StartedAt.Format(time.RFC3339), len(sess.Punches),
+		downtime.Round(time.Second))
+}
+
+// discard drops the session from its journal.
+func (u *unfinished) discard() {
+	u.journal.SessionEnded()
+	log.Printf("Discarded unfinished session started %s", u.session.StartedAt.Format(time.RFC3339))
+}
+
+// recovery holds the unfinished sessions on offer with RestoreOffer. It
+// implements httpapi.Recovery.
+type recovery struct {
+	mu       sync.Mutex
+	profiles *profiles.Store
+	pending  []*unfinished
+}
+
+// Pending implements httpapi.Recovery.
+func (r *recovery) Pending() []httpapi.UnfinishedSession {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []httpapi.UnfinishedSession
+	for _, u := range r.pending {
+		out = append(out, httpapi.UnfinishedSession{
+			Side:      u.side,
+			StartedAt: u.session.StartedAt,
+			Athlete:   u.session.Athlete,
+			Punches:   len(u.session.Punches),
+		})
+	}
+	return out
+}
+
+// Resume implements httpapi.Recovery.
+func (r *recovery) Resume() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.pending {
+		u.restore(r.profiles)
+	}
+	return r.clearLocked()
+}
+
+// Discard implements httpapi.Recovery.
+func (r *recovery) Discard() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.pending {
+		u.discard()
+	}
+	return r.clearLocked()
+}
+
+// clearLocked empties the offer, reporting whether anything was on it.
+// Must be called with r.mu held.
+func (r *recovery) clearLocked() bool {
+	had := len(r.pending) > 0
+	r.pending = nil
+	return had
+}
+
+// openJournals sets up crash recovery for each athlete's analyzer.
+func (s *Server) openJournals() error {
+	s.recovery = &recovery{profiles: s.profiles}
+	if err := s.openJournal("", "journal.jsonl", s.analyzer); err != nil {
+		return err
+	}
+	if s.opponent != nil {
+		return s.openJournal("b", "journal_b.jsonl", s.opponent)
+	}
+	return nil
+}
+
+// openJournal handles the session an analyzer was running when the server
+// last went down as Config.SessionRestore says, and journals its sessions
+// to file in the data directory from then on.
+func (s *Server) openJournal(side, file string, analyzer *analytics.Analyzer) error {
+	path := filepath.Join(s.cfg.DataDir, file)
+	sess, err := journal.Load(path)
+	if err != nil {
+		return fmt.Errorf("load session journal: %w", err)
+	}
+	j, err := journal.Open(path)
+	if err != nil {
+		return fmt.Errorf("open session journal: %w", err)
+	}
+	s.journals = append(s.journals, j)
+	analyzer.SetJournal(j)
+	if sess == nil {
+		return nil
+	}
+
+	u := &unfinished{side: side, analyzer: analyzer, journal: j, session: sess}
+	switch {
+	case s.cfg.SessionRestore == RestoreOff, time.Since(sess.StartedAt) > maxRestoreAge:
+		u.discard()
+	case s.cfg.SessionRestore == RestoreOffer:
+		s.recovery.pending = append(s.recovery.pending, u)
+		log.Printf("Unfinished session started %s (%d punches) can be resumed via /api/v1/session/recovery",
+			sess.StartedAt.Format(time.RFC3339), len(sess.Punches))
+	default:
+		u.restore(s.profiles)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/hub"
+)
+
+// rawTopic is the WebSocket topic carrying the signal punch detection sees,
+// which clients opt into with /ws?topics=raw.
+const rawTopic = "raw"
+
+// rawStream passes an analyzer's raw samples to rawTopic subscribers, at
+// most one per hand every rawFrameInterval. Each carries the strongest
+// sample since the last, so a peak that crossed the threshold is never
+// thinned out.
+type rawStream struct {
+	hub  *hub.Hub
+	side string // athlete "a" or "b" in sparring mode
+
+	// Only touched by handle, which the analyzer calls with its lock held
+	hands [2]struct {
+		peak   analytics.RawSample
+		have   bool
+		sentAt time.Time
+	}
+}
+
+// handle is the analyzer's RawHandler.
+func (r *rawStream) handle(sample analytics.RawSample) {
+	h := &r.hands[0]
+	if sample.Hand == "right" {
+		h = &r.hands[1]
+	}
+	if !h.have || sample.Mag > h.peak.Mag {
+		h.peak, h.have = sample, true
+	}
+	now := time.Now()
+	if now.Sub(h.sentAt) < rawFrameInterval {
+		return
+	}
+	h.have, h.sentAt = false, now
+	if r.hub.Subscribers(rawTopic) == 0 {
+		return
+	}
+
+	peak := h.peak
+	peak.Mag = round2(peak.Mag)
+	for i := range peak.Gyro {
+		peak.Gyro[i] = round2(peak.Gyro[i])
+	}
+	data, err := json.Marshal(&analytics.Event{
+		Type:      rawTopic,
+		Hand:      peak.Hand,
+		Side:      r.side,
+		Timestamp: now.UnixMilli(),
+		Data:      peak,
+	})
+	if err != nil {
+		log.Printf("JSON marshal error: %v", err)
+		return
+	}
+	r.hub.BroadcastTopic(rawTopic, data)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// retentionPruneInterval is how often data past its retention is pruned.
+const retentionPruneInterval = time.Hour
+
+// pruneRetained runs pruneExpired now and every retentionPruneInterval
+// until ctx is cancelled. It returns at once when everything is kept
+// forever.
+func (s *Server) pruneRetained(ctx context.Context) {
+	if s.cfg.RecordingRetentionDays == 0 && s.cfg.PunchRetentionMonths == 0 {
+		return
+	}
+	ticker := time.NewTicker(retentionPruneInterval)
+	defer ticker.Stop()
+	for {
+		s.pruneExpired(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pruneExpired deletes raw recordings older than RecordingRetentionDays and
+// strips the punch detail of sessions older than PunchRetentionMonths.
+// Session summaries are kept forever.
+func (s *Server) pruneExpired(now time.Time) {
+	if days := s.cfg.RecordingRetentionDays; days > 0 {
+		deleted, err := removeOlder(s.recordingsDir, now.AddDate(0, 0, -days))
+		if err != nil {
+			log.Printf("Retention: recordings: %v", err)
+		}
+		if deleted > 0 {
+			log.Printf("Retention: deleted %d recordings older than %d days", deleted, days)
+		}
+	}
+	if months := s.cfg.PunchRetentionMonths; months > 0 {
+		stripped, err := s.store.StripDetail(now.AddDate(0, -months, 0))
+		if err != nil {
+			log.Printf("Retention: sessions: %v", err)
+		}
+		if stripped > 0 {
+			log.Printf("Retention: stripped punch detail from %d sessions older than %d months", stripped, months)
+		}
+	}
+}
+
+// removeOlder deletes the .json files in dir last modified before cutoff
+// and returns how many were deleted. A missing dir holds nothing to delete.
+func removeOlder(dir string, cutoff time.Time) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return deleted, fmt.Errorf("delete %s: %w", e.Name(), err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
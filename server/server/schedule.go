@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"boxing-analytics/notify"
+)
+
+// remindPlanned sends the reminders of planned sessions as they fall due,
+// checking every reminderInterval until ctx is cancelled.
+func (s *Server) remindPlanned(ctx context.Context) {
+	ticker := time.NewTicker(reminderInterval)
+	defer ticker.Stop()
+	for {
+		s.sendReminders(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendReminders notifies athletes of their planned sessions whose reminder
+// is due at now. Athletes without notification channels are skipped.
+func (s *Server) sendReminders(now time.Time) {
+	due, err := s.schedule.DueReminders(now)
+	if err != nil {
+		log.Printf("Schedule: %v", err)
+	}
+	for _, e := range due {
+		profile, err := s.profiles.Get(e.Athlete)
+		if err != nil || len(profile.Notifications) == 0 {
+			continue
+		}
+		var name string
+		if e.Program != "" {
+			if p, err := s.programs.Get(e.Program); err == nil {
+				name = p.Name
+			}
+		}
+		log.Printf("Schedule: reminding %s of session %s", e.Athlete, e.ID)
+		go notify.Send(profile.Notifications, notify.ScheduledSession(e, name))
+	}
+}
@@ -0,0 +1,686 @@
+// Package server wires the BLE centrals, ingest sources, analyzers, stores
+// and HTTP/WebSocket API into one analytics server. The boxing-analytics
+// command runs it from environment variables; other programs (kiosk apps,
+// test harnesses) can embed it:
+//
+//	cfg := server.DefaultConfig()
+//	cfg.Transport = bletest.NewTransport()
+//	srv, err := server.New(cfg)
+//	...
+//	err = srv.Run(ctx)
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/assets"
+	"boxing-analytics/ble"
+	"boxing-analytics/cloudsync"
+	"boxing-analytics/fleet"
+	"boxing-analytics/goals"
+	"boxing-analytics/httpapi"
+	"boxing-analytics/hub"
+	"boxing-analytics/ingest"
+	"boxing-analytics/journal"
+	"boxing-analytics/logfile"
+	"boxing-analytics/notify"
+	"boxing-analytics/profiles"
+	"boxing-analytics/programs"
+	"boxing-analytics/replay"
+	"boxing-analytics/schedule"
+	"boxing-analytics/signage"
+	"boxing-analytics/storage"
+)
+
+// ─── Constants ────────────────────────────────────────────────────────────────
+
+// Version is the server's version, reported to gloves discovering it. Set
+// it when building: go build -ldflags "-X boxing-analytics/server.Version=1.2.0"
+var Version = "dev"
+
+const (
+	defaultHTTPAddr = ":8080"
+	defaultUDPAddr  = ":5005"
+	defaultDataDir  = "data"
+
+	fleetSaveInterval = 30 // seconds between fleet registry and learned threshold writes
+
+	defaultGuestTTL    = 24 * time.Hour   // lifetime of guest profiles and their data
+	guestPruneInterval = 10 * time.Minute // how often expired guests are removed
+
+	reminderInterval = time.Minute // how often planned sessions are checked for due reminders
+
+	signageLeaderboardSize = 5 // athletes shown on signage displays
+
+	maxFillGap = 10 // longest sequence gap BLE interpolation may fill
+
+	shutdownTimeout = 5 * time.Second // grace period for HTTP requests on shutdown
+
+	stateFrameInterval = 50 * time.Millisecond // shortest time between two state broadcasts
+	rawFrameInterval   = 40 * time.Millisecond // shortest time between two raw samples of a hand
+
+	roundEndBuzz = 800 * time.Millisecond // glove buzz at the end of a round
+	alertBuzz    = 300 * time.Millisecond // glove buzz for "buzz" alert rules
+)
+
+// Config.SessionRestore policies for a session the server crashed in the
+// middle of
+const (
+	RestoreAuto  = "auto"  // resume it at startup
+	RestoreOffer = "offer" // hold it until resumed or discarded over the API
+	RestoreOff   = "off"   // discard it
+)
+
+// ─── Config ───────────────────────────────────────────────────────────────────
+
+// Config configures a Server. Start from DefaultConfig (or ConfigFromEnv).
+type Config struct {
+	HTTPAddr    string   // HTTP/WebSocket listen address
+	AccessLog   bool     // Log every HTTP request
+	UDPAddr     string   // UDP sample listen address, "" = no UDP ingest
+	SerialPorts []string // Serial ports gloves are wired to
+	SerialBaud  int
+
+	DataDir        string        // Sessions, profiles, pairings and other state
+	Gym            string        // Tags sessions recorded here
+	RecordRaw      bool          // Keep each session's raw packets for reanalysis
+	GuestTTL       time.Duration // Lifetime of guest profiles and their data
+	SignageConfig  string        // Lobby-screen push config file, "" = none
+	SessionRestore string        // Unfinished session policy (RestoreAuto, ...)
+
+	// Retention, 0 = forever. Session summaries are always kept.
+	RecordingRetentionDays int // Days raw recordings are kept
+	PunchRetentionMonths   int // Months sessions keep their per-punch detail
+
+	Sync cloudsync.Config // Upload finished sessions, zero = off
+
+	SlowClients hub.SlowClientPolicy // WebSocket clients that fall behind
+
+	// Log file, applied by the command running the server since the log
+	// output is the process's
+	Log logfile.Config
+
+	AutoThreshold     bool    // Learn per-athlete detection thresholds
+	Spectrum          bool    // FFT rhythm and ringing analysis
+	ClassifierModel   string  // Decision tree model file, "" = gyro heuristic
+	BalanceMinShare   float64 // Hand balance warning threshold, 0 = off
+	BatteryThresholds []int   // Battery warning levels, empty = off
+	BatteryWebhookURL string  // Also POST battery warnings here
+	RoundLength       time.Duration
+	RoundBuzz         bool // Buzz both gloves at the end of every round
+
+	// Detection profile of each session type a session can be started
+	// with; it must include analytics.DefaultSessionType
+	SessionTypes map[string]analytics.DetectionProfile
+
+	// Edges between the bars of the force histogram (m/s²)
+	ForceBuckets []float64
+
+	// Units of the WebSocket and session API output, zero = metric. An
+	// athlete's profile can choose their own.
+	Units analytics.Units
+
+	Devices   ble.DeviceConfig    // Glove names and GATT profile
+	Adapters  map[ble.Hand]string // Adapter per hand, e.g. "hci1"
+	FillGap   int                 // Longest sequence gap to interpolate, 0 = off
+	Bonding   bool                // Bond gloves using Passkey
+	Passkey   uint32
+	HeartRate bool // Also connect a heart-rate strap
+	DebugBLE  bool // Log every sample
+
+	// Sparring adds a second athlete ("b") with their own gloves, named
+	// OpponentLeftNames / OpponentRightNames (default FighterLink_L2 /
+	// FighterLink_R2), and analyzer.
+	Sparring           bool
+	OpponentLeftNames  []string
+	OpponentRightNames []string
+
+	Transport ble.Transport // Bluetooth stack, nil = the platform's
+	Static    fs.FS         // Dashboard served at /, nil = none
+
+	// DevProxy serves the dashboard by proxying to a front-end dev server
+	// (e.g. Vite's "http://localhost:5173") instead of Static, so dashboard
+	// changes show up without rebuilding the binary.
+	DevProxy string
+}
+
+// DefaultConfig returns the configuration the server runs with when no
+// environment variables are set.
+func DefaultConfig() Config {
+	return Config{
+		HTTPAddr:           defaultHTTPAddr,
+		AccessLog:          true,
+		UDPAddr:            defaultUDPAddr,
+		SerialBaud:         ingest.DefaultBaudRate,
+		DataDir:            defaultDataDir,
+		GuestTTL:           defaultGuestTTL,
+		SessionRestore:     RestoreAuto,
+		SlowClients:        hub.SlowClientPolicy{Drop: hub.DropNewest},
+		Log:                logfile.Config{MaxSizeMB: logfile.DefaultMaxSizeMB, Keep: logfile.DefaultKeep},
+		BalanceMinShare:    analytics.DefaultBalanceMinShare,
+		BatteryThresholds:  analytics.DefaultBatteryThresholds,
+		RoundLength:        analytics.DefaultRoundLength,
+		SessionTypes:       analytics.DefaultDetectionProfiles(),
+		ForceBuckets:       analytics.DefaultForceBuckets,
+		Devices:            ble.DefaultDeviceConfig(),
+		Adapters:           make(map[ble.Hand]string),
+		OpponentLeftNames:  []string{ble.LeftDeviceName + "2"},
+		OpponentRightNames: []string{ble.RightDeviceName + "2"},
+	}
+}
+
+// validate checks the settings New cannot pass on unchecked.
+func (cfg *Config) validate() error {
+	if _, _, err := net.SplitHostPort(cfg.HTTPAddr); err != nil {
+		return fmt.Errorf("invalid HTTP address %q", cfg.HTTPAddr)
+	}
+	if _, _, err := net.SplitHostPort(cfg.UDPAddr); cfg.UDPAddr != "" && err != nil {
+		return fmt.Errorf("invalid UDP address %q", cfg.UDPAddr)
+	}
+	if cfg.GuestTTL <= 0 {
+		return fmt.Errorf("invalid guest TTL %s", cfg.GuestTTL)
+	}
+	if err := cfg.Units.Validate(); err != nil {
+		return err
+	}
+	if cfg.RoundLength <= 0 {
+		return fmt.Errorf("invalid round length %s", cfg.RoundLength)
+	}
+	if cfg.BalanceMinShare < 0 || cfg.BalanceMinShare >= 0.5 {
+		return fmt.Errorf("invalid balance min share %v: must be between 0 and 0.5", cfg.BalanceMinShare)
+	}
+	if cfg.FillGap < 0 || cfg.FillGap > maxFillGap {
+		return fmt.Errorf("invalid fill gap %d: must be between 0 and %d", cfg.FillGap, maxFillGap)
+	}
+	if len(cfg.SerialPorts) > 0 && cfg.SerialBaud <= 0 {
+		return fmt.Errorf("invalid serial baud rate %d", cfg.SerialBaud)
+	}
+	if cfg.RecordingRetentionDays < 0 || cfg.PunchRetentionMonths < 0 {
+		return fmt.Errorf("invalid retention: must not be negative")
+	}
+	if err := cfg.SlowClients.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.Log.Validate(); err != nil {
+		return err
+	}
+	switch cfg.SessionRestore {
+	case RestoreAuto, RestoreOffer, RestoreOff:
+	default:
+		return fmt.Errorf("invalid session restore policy %q", cfg.SessionRestore)
+	}
+	if cfg.DevProxy != "" {
+		u, err := url.Parse(cfg.DevProxy)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid dev proxy URL %q", cfg.DevProxy)
+		}
+	}
+	return nil
+}
+
+// ─── Server ───────────────────────────────────────────────────────────────────
+
+// Server is a configured analytics server.
+type Server struct {
+	cfg Config
+
+	hub      *hub.Hub
+	analyzer *analytics.Analyzer
+	central  *ble.Central
+	scanner  *ble.Scanner
+	store    *storage.Store
+	profiles *profiles.Store
+	programs *programs.Store
+	schedule *schedule.Store
+	goals    *goals.Store
+	registry *fleet.Registry
+	recorder *replay.Recorder // nil unless RecordRaw
+	pusher   *signage.Pusher  // nil without a signage config
+	sources  []ingest.Source
+	assets   *assets.Handler    // nil without a dashboard to serve
+	journals []*journal.Journal // one per analyzer, for crash recovery
+	recovery *recovery          // unfinished sessions on offer
+
+	thresholds []*thresholdFile // learned thresholds, one per analyzer
+
+	// Second athlete in sparring mode, nil otherwise
+	opponent        *analytics.Analyzer
+	opponentCentral *ble.Central
+
+	recordingsDir string              // Raw session recordings
+	udp           *ingest.UDPSource   // nil without UDP ingest
+	uploader      *cloudsync.Uploader // nil without a sync URL
+	stateChanged  chan struct{}       // Signals broadcastState
+
+	mux *http.ServeMux
+}
+
+// New loads the server's state from cfg.DataDir and wires up its
+// components. Nothing runs until Run.
+func New(cfg Config) (*Server, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	s := &Server{
+		cfg:      cfg,
+		hub:      hub.New(),
+		analyzer: analytics.NewAnalyzer(),
+		mux:      http.NewServeMux(),
+
+		stateChanged: make(chan struct{}, 1),
+	}
+	if err := s.hub.SetSlowClientPolicy(cfg.SlowClients); err != nil {
+		return nil, err
+	}
+	central, calibration, err := newCentral(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.central = central
+	s.scanner = ble.NewScanner(central, ble.DefaultScanConfig())
+	analyzer := s.analyzer
+
+	if cfg.DebugBLE {
+		log.Println("BLE debug mode enabled")
+	}
+
+	s.store, err = storage.NewStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("open session store: %w", err)
+	}
+
+	recordingsDir := filepath.Join(cfg.DataDir, "recordings")
+	s.recordingsDir = recordingsDir
+	if cfg.RecordRaw {
+		if err := os.MkdirAll(recordingsDir, 0o755); err != nil {
+			return nil, fmt.Errorf("create recordings dir: %w", err)
+		}
+		s.recorder = replay.NewRecorder()
+		log.Println("Raw session recording enabled")
+	}
+
+	s.profiles, err = profiles.NewStore(filepath.Join(cfg.DataDir, "profiles.json"))
+	if err != nil {
+		return nil, fmt.Errorf("load profiles: %w", err)
+	}
+	s.programs, err = programs.NewStore(filepath.Join(cfg.DataDir, "programs.json"))
+	if err != nil {
+		return nil, fmt.Errorf("load programs: %w", err)
+	}
+	s.schedule, err = schedule.NewStore(filepath.Join(cfg.DataDir, "schedule.json"))
+	if err != nil {
+		return nil, fmt.Errorf("load schedule: %w", err)
+	}
+	s.goals, err = goals.NewStore(filepath.Join(cfg.DataDir, "goals.json"))
+	if err != nil {
+		return nil, fmt.Errorf("load goals: %w", err)
+	}
+
+	if cfg.SignageConfig != "" {
+		signageCfg, err := signage.LoadConfig(cfg.SignageConfig)
+		if err != nil {
+			return nil, fmt.Errorf("load signage config: %w", err)
+		}
+		s.pusher, err = signage.NewPusher(signageCfg, signageSource(analyzer, s.store, s.profiles, cfg.Gym))
+		if err != nil {
+			return nil, fmt.Errorf("invalid signage config: %w", err)
+		}
+	}
+
+	alertRulesPath := filepath.Join(cfg.DataDir, "alerts.json")
+	httpapi.LoadAlertRules(analyzer, alertRulesPath)
+
+	if cfg.AutoThreshold {
+		analyzer.SetAutoThreshold(true)
+		log.Println("Adaptive punch thresholds enabled")
+	}
+	if cfg.Spectrum {
+		analyzer.SetSpectrumAnalysis(true)
+		log.Println("Spectrum analysis enabled")
+	}
+	if cfg.ClassifierModel != "" {
+		tree, err := analytics.LoadDecisionTree(cfg.ClassifierModel)
+		if err != nil {
+			return nil, fmt.Errorf("load classifier model: %w", err)
+		}
+		analyzer.SetClassifier(tree)
+		log.Printf("Punch classifier: decision tree %s (%d nodes)", cfg.ClassifierModel, len(tree.Nodes))
+	}
+	if err := analyzer.SetDetectionProfiles(cfg.SessionTypes); err != nil {
+		return nil, fmt.Errorf("invalid session types: %w", err)
+	}
+	if err := analyzer.SetForceBuckets(cfg.ForceBuckets); err != nil {
+		return nil, fmt.Errorf("invalid force buckets: %w", err)
+	}
+	analyzer.SetBalanceThreshold(cfg.BalanceMinShare)
+	if err := analyzer.SetBatteryThresholds(cfg.BatteryThresholds); err != nil {
+		return nil, fmt.Errorf("invalid battery thresholds: %w", err)
+	}
+	analyzer.SetRoundLength(cfg.RoundLength)
+
+	// Inventory of every glove this server has seen
+	s.registry, err = fleet.NewRegistry(filepath.Join(cfg.DataDir, "fleet.json"))
+	if err != nil {
+		return nil, fmt.Errorf("load fleet registry: %w", err)
+	}
+
+	// Set up state broadcast to WebSocket clients
+	analyzer.SetStateHandler(s.markStateChanged)
+
+	// Discrete events (alerts, etc.) go to WebSocket clients as typed messages
+	analyzer.SetEventHandler(eventHandler(s.hub, central, "", cfg.BatteryWebhookURL, notifyBatteryLow(analyzer, s.profiles), s.eventUnits(analyzer)))
+
+	// The detection signal, for clients tuning thresholds
+	analyzer.SetRawHandler((&rawStream{hub: s.hub}).handle)
+
+	// Every ingest source (BLE gloves, UDP, serial) feeds the one analyzer
+	s.sources = []ingest.Source{ingest.NewBLESource(central)}
+	if cfg.UDPAddr != "" {
+		s.udp = ingest.NewUDPSource(cfg.UDPAddr)
+		s.udp.SetUpdateHandler(s.firmwareUpdated)
+		s.udp.SetDiscoveryInfo(ingest.DiscoveryInfo{Version: Version, HTTPPort: addrPort(cfg.HTTPAddr)})
+		s.sources = append(s.sources, s.udp)
+	}
+	for _, port := range cfg.SerialPorts {
+		s.sources = append(s.sources, ingest.NewSerialSource(port, cfg.SerialBaud))
+	}
+
+	if cfg.HeartRate {
+		central.SetHeartRateHandler(analyzer.SetHeartRate)
+		log.Println("Heart-rate strap support enabled")
+	}
+
+	// Each athlete gets their own analyzer, and the broadcast becomes a
+	// SparringState with both side by side.
+	if cfg.Sparring {
+		if err := s.setupSparring(calibration); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Sync.URL != "" {
+		target, err := cloudsync.NewTarget(cfg.Sync)
+		if err != nil {
+			return nil, err
+		}
+		uploadRecordings := ""
+		if cfg.Sync.Recordings {
+			uploadRecordings = recordingsDir
+		}
+		s.uploader, err = cloudsync.NewUploader(target, s.store, uploadRecordings, filepath.Join(cfg.DataDir, "sync.json"))
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Cloud sync to %s enabled", cfg.Sync.URL)
+	}
+
+	// Pick up a session the server crashed in the middle of
+	if err := s.loadThresholds(); err != nil {
+		return nil, err
+	}
+	if err := s.openJournals(); err != nil {
+		return nil, err
+	}
+
+	httpapi.Register(s.mux, httpapi.Deps{
+		Analyzer:       analyzer,
+		Opponent:       s.opponent,
+		Central:        central,
+		Scanner:        s.scanner,
+		Store:          s.store,
+		Profiles:       s.profiles,
+		Programs:       s.programs,
+		Schedule:       s.schedule,
+		Goals:          s.goals,
+		Fleet:          s.registry,
+		UDP:            s.udp,
+		Hub:            s.hub,
+		Recorder:       s.recorder,
+		Gym:            cfg.Gym,
+		Units:          cfg.Units,
+		RecordingsDir:  recordingsDir,
+		AlertRulesPath: alertRulesPath,
+		FirmwareDir:    filepath.Join(cfg.DataDir, "firmware"),
+		GuestTTL:       cfg.GuestTTL,
+		Version:        Version,
+		Recovery:       s.recovery,
+		SessionSaved:   s.sessionSaved,
+	})
+	s.mux.HandleFunc("/ws", s.hub.Handler(s.snapshot()...))
+	switch {
+	case cfg.DevProxy != "":
+		s.mux.Handle("/", devProxy(cfg.DevProxy))
+		log.Printf("Dashboard proxied to %s", cfg.DevProxy)
+	case cfg.Static != nil:
+		s.assets = assets.NewHandler(cfg.Static)
+		s.mux.Handle("/", s.assets)
+	}
+	return s, nil
+}
+
+// setupSparring adds the second athlete's analyzer and gloves.
+func (s *Server) setupSparring(calibration *ble.CalibrationStore) error {
+	s.opponent = analytics.NewAnalyzer()
+	s.opponent.CopySettings(s.analyzer)
+	s.opponent.SetStateHandler(s.markStateChanged)
+
+	s.opponentCentral = ble.NewCentral()
+	if s.cfg.Transport != nil {
+		s.opponentCentral.SetTransport(s.cfg.Transport)
+	}
+	s.opponentCentral.SetCalibrationStore(calibration)
+	opponentPairing, err := ble.NewPairingStore(filepath.Join(s.cfg.DataDir, "pairing_b.json"))
+	if err != nil {
+		return fmt.Errorf("load glove pairings: %w", err)
+	}
+	s.opponentCentral.SetPairingStore(opponentPairing)
+	opponentDevices := s.cfg.Devices
+	opponentDevices.LeftNames = s.cfg.OpponentLeftNames
+	opponentDevices.RightNames = s.cfg.OpponentRightNames
+	if err := s.opponentCentral.SetDeviceConfig(opponentDevices); err != nil {
+		return fmt.Errorf("invalid BLE device config for athlete b: %w", err)
+	}
+
+	// One scan covers all four gloves
+	s.central.ShareScan(s.opponentCentral)
+
+	s.analyzer.SetEventHandler(eventHandler(s.hub, s.central, "a", s.cfg.BatteryWebhookURL, notifyBatteryLow(s.analyzer, s.profiles), s.eventUnits(s.analyzer)))
+	s.opponent.SetEventHandler(eventHandler(s.hub, s.opponentCentral, "b", s.cfg.BatteryWebhookURL, notifyBatteryLow(s.opponent, s.profiles), s.eventUnits(s.opponent)))
+	s.analyzer.SetRawHandler((&rawStream{hub: s.hub, side: "a"}).handle)
+	s.opponent.SetRawHandler((&rawStream{hub: s.hub, side: "b"}).handle)
+	log.Println("Sparring mode enabled")
+	return nil
+}
+
+// Analyzer returns the (first) athlete's analyzer.
+func (s *Server) Analyzer() *analytics.Analyzer { return s.analyzer }
+
+// Central returns the (first) athlete's BLE central.
+func (s *Server) Central() *ble.Central { return s.central }
+
+// Handler returns the HTTP/WebSocket API, e.g. to serve it elsewhere than
+// Config.HTTPAddr.
+func (s *Server) Handler() http.Handler { return httpapi.Middleware(s.mux, s.cfg.AccessLog) }
+
+// Run connects the gloves and serves the API on Config.HTTPAddr until ctx
+// is cancelled, then disconnects the gloves and shuts the HTTP server down.
+func (s *Server) Run(ctx context.Context) error {
+	analyzer, central := s.analyzer, s.central
+
+	go func() {
+		pruneGuests(s.profiles, s.store, analyzer)
+		ticker := time.NewTicker(guestPruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				pruneGuests(s.profiles, s.store, analyzer)
+			}
+		}
+	}()
+
+	go s.pruneRetained(ctx)
+	go s.remindPlanned(ctx)
+	go s.evaluateGoalsNightly(ctx)
+	if s.uploader != nil {
+		go s.uploader.Run(ctx)
+	}
+
+	for _, j := range s.journals {
+		go j.Run(ctx, journal.DefaultSyncInterval)
+	}
+
+	if s.assets != nil {
+		go func() {
+			if err := s.assets.Preload(); err != nil {
+				log.Printf("Dashboard assets: %v", err)
+			}
+		}()
+	}
+
+	if s.pusher != nil {
+		s.pusher.Start()
+		defer s.pusher.Stop()
+	}
+
+	// React to connection lifecycle events as they happen
+	events, stopEvents := central.Events()
+	defer stopEvents()
+	go handleBLEEvents(events, analyzer, s.registry)
+	if s.opponentCentral != nil {
+		events, stopEvents := s.opponentCentral.Events()
+		defer stopEvents()
+		go handleBLEEvents(events, s.opponent, s.registry)
+	}
+
+	// Start ingesting before the gloves connect
+	go ingest.Run(ctx, s.handleSample, s.sources...)
+	if s.opponentCentral != nil {
+		go ingest.Run(ctx, s.opponent.ProcessSample, ingest.NewBLESource(s.opponentCentral))
+	}
+
+	// Initialize BLE adapter
+	if err := central.Enable(ctx); err != nil {
+		return fmt.Errorf("enable BLE: %w", err)
+	}
+	if s.opponentCentral != nil {
+		if err := s.opponentCentral.Enable(ctx); err != nil {
+			return fmt.Errorf("enable BLE: %w", err)
+		}
+	}
+
+	// Start scanning for gloves
+	s.scanner.Start(ctx)
+	log.Println("Scanning for FighterLink_L and FighterLink_R...")
+
+	var opponentScanner *ble.Scanner
+	if s.opponentCentral != nil {
+		opponentScanner = ble.NewScanner(s.opponentCentral, ble.DefaultScanConfig())
+		opponentScanner.Start(ctx)
+	}
+
+	go s.broadcastState(ctx)
+	go s.tick(ctx)
+
+	log.Printf("HTTP/WS server on %s", s.cfg.HTTPAddr)
+	log.Println("Dashboard: http://" + dashboardHost(s.cfg.HTTPAddr))
+	log.Println("")
+	log.Println("Waiting for glove connections...")
+
+	srv := &http.Server{Addr: s.cfg.HTTPAddr, Handler: s.Handler()}
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down...")
+		s.scanner.Stop()
+		central.DisconnectAll()
+		if opponentScanner != nil {
+			opponentScanner.Stop()
+			s.opponentCentral.DisconnectAll()
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("HTTP shutdown: %v", err)
+		}
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("HTTP listen: %w", err)
+	}
+	return nil
+}
+
+// addrPort returns the port of a listen address such as ":8080", 0 if it
+// has none.
+func addrPort(addr string) int {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// dashboardHost returns where the dashboard is opened on this machine:
+// localhost, unless the server is bound to one address.
+func dashboardHost(addr string) string {
+	host, port, _ := net.SplitHostPort(addr)
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// devProxy forwards dashboard requests, including the dev server's
+// hot-reload WebSocket, to a front-end dev server. The request's Host is
+// rewritten to the target's, as dev servers tend to reject unknown hosts
+// (e.g. a tablet opening the Pi's address). target is checked by validate.
+func devProxy(target string) http.Handler {
+	u, _ := url.Parse(target)
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(u)
+			r.SetXForwarded()
+		},
+	}
+}
+
+// sessionSaved uploads a newly stopped session and sends its athlete the
+// summary.
+func (s *Server) sessionSaved(sess *storage.Session) {
+	if s.uploader != nil {
+		s.uploader.Notify()
+	}
+	if profile, err := s.profiles.Get(sess.Athlete); err == nil && len(profile.Notifications) > 0 {
+		go notify.Send(profile.Notifications, notify.SessionSummary(sess))
+	}
+}
+
+// handleSample feeds a sample from any source into the analyzer.
+func (s *Server) handleSample(sample ingest.Sample) {
+	s.analyzer.ProcessSample(sample)
+	// Recordings keep only what the gloves sent
+	if s.recorder != nil && s.analyzer.IsActive() && !sample.Packet.Interpolated {
+		s.recorder.Add(sample.Hand, sample.Packet)
+	}
+
+	if s.cfg.DebugBLE {
+		log.Printf("%s [%s]: %s", strings.ToUpper(sample.Source), sample.Hand, sample.Packet)
+	}
+}
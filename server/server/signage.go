@@ -0,0 +1,95 @@
+package server
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/profiles"
+	"boxing-analytics/signage"
+	"boxing-analytics/storage"
+)
+
+// storedTotals caches today's per-athlete totals of the stored sessions,
+// which only change when a session is saved or deleted, or the day ends.
+type storedTotals struct {
+	day     time.Time
+	version uint64
+	loaded  bool
+	totals  map[string]signage.Leader // by athlete, Name not set
+}
+
+// get returns the totals of the sessions stored for gym that started on or
+// after today, listing the store only when it changed since the last call.
+func (c *storedTotals) get(store *storage.Store, gym string, today time.Time) map[string]signage.Leader {
+	version := store.Version()
+	if c.loaded && c.version == version && c.day.Equal(today) {
+		return c.totals
+	}
+	sessions, err := store.List()
+	if err != nil {
+		return c.totals // keep the last totals until the store reads again
+	}
+	totals := make(map[string]signage.Leader)
+	for _, sess := range sessions {
+		if sess.Athlete == "" || sess.State == nil || sess.StartedAt.Before(today) || (gym != "" && sess.Gym != gym) {
+			continue
+		}
+		l := totals[sess.Athlete]
+		l.Athlete = sess.Athlete
+		l.Punches += sess.State.Combined.TotalPunches
+		l.MaxForce = math.Max(l.MaxForce, sess.State.Combined.MaxForce)
+		totals[sess.Athlete] = l
+	}
+	c.day, c.version, c.loaded, c.totals = today, version, true, totals
+	return totals
+}
+
+// signageSource builds signage data: today's leaderboard from stored sessions
+// plus the session in progress.
+func signageSource(analyzer *analytics.Analyzer, store *storage.Store, profileStore *profiles.Store, gym string) signage.Source {
+	var stored storedTotals
+	return func() signage.Data {
+		now := time.Now()
+		live := analyzer.GetState()
+		athlete := analyzer.Athlete()
+		data := signage.Data{Time: now, Gym: gym, Live: live}
+		if live.Active {
+			data.Athlete = athlete
+		}
+
+		// Aggregate today's sessions per athlete
+		year, month, day := now.Date()
+		today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+		totals := make(map[string]signage.Leader)
+		for id, l := range stored.get(store, gym, today) {
+			totals[id] = l
+		}
+		if live.Active && athlete != "" {
+			l := totals[athlete]
+			l.Athlete = athlete
+			l.Punches += live.Combined.TotalPunches
+			l.MaxForce = math.Max(l.MaxForce, live.Combined.MaxForce)
+			totals[athlete] = l
+		}
+
+		for id, l := range totals {
+			l.Name = id
+			if profile, err := profileStore.Get(id); err == nil && profile.Name != "" {
+				l.Name = profile.Name
+			}
+			data.Leaderboard = append(data.Leaderboard, l)
+		}
+		sort.Slice(data.Leaderboard, func(i, j int) bool {
+			return data.Leaderboard[i].Punches > data.Leaderboard[j].Punches
+		})
+		if len(data.Leaderboard) > signageLeaderboardSize {
+			data.Leaderboard = data.Leaderboard[:signageLeaderboardSize]
+		}
+		if len(data.Leaderboard) > 0 {
+			data.Leader = &data.Leaderboard[0]
+		}
+		return data
+	}
+}
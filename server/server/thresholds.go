@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"boxing-analytics/analytics"
+)
+
+// thresholdFile persists the detection thresholds an analyzer learned for
+// its athletes, so that they survive a restart.
+type thresholdFile struct {
+	path     string
+	analyzer *analytics.Analyzer
+	saved    int // version of the thresholds last loaded or written
+}
+
+// load restores the thresholds saved in the file, if there is one.
+func (f *thresholdFile) load() error {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read thresholds: %w", err)
+	}
+	var thresholds []analytics.LearnedThreshold
+	if err := json.Unmarshal(data, &thresholds); err != nil {
+		return fmt.Errorf("decode thresholds: %w", err)
+	}
+	f.analyzer.LoadLearnedThresholds(thresholds)
+	_, f.saved = f.analyzer.LearnedThresholds()
+	log.Printf("Loaded learned thresholds for %d athlete and session type pairs", len(thresholds))
+	return nil
+}
+
+// save writes the thresholds if they changed since they were last loaded or
+// written.
+func (f *thresholdFile) save() {
+	thresholds, version := f.analyzer.LearnedThresholds()
+	if version == f.saved {
+		return
+	}
+	data, err := json.MarshalIndent(thresholds, "", "  ")
+	if err != nil {
+		log.Printf("Thresholds marshal: %v", err)
+		return
+	}
+	if err := os.WriteFile(f.path, data, 0o644); err != nil {
+		log.Printf("Thresholds save: %v", err)
+		return
+	}
+	f.saved = version
+}
+
+// loadThresholds restores the thresholds each athlete's analyzer learned
+// before the last restart, and keeps them in the data directory from then on.
+func (s *Server) loadThresholds() error {
+	files := []*thresholdFile{{path: filepath.Join(s.cfg.DataDir, "thresholds.json"), analyzer: s.analyzer}}
+	if s.opponent != nil {
+		files = append(files, &thresholdFile{path: filepath.Join(s.cfg.DataDir, "thresholds_b.json"), analyzer: s.opponent})
+	}
+	for _, f := range files {
+		if err := f.load(); err != nil {
+			return err
+		}
+	}
+	s.thresholds = files
+	return nil
+}
+
+// saveThresholds writes the thresholds that changed.
+func (s *Server) saveThresholds() {
+	for _, f := range s.thresholds {
+		f.save()
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+)
+
+// markStateChanged is the analyzers' state handler: it has broadcastState
+// send the new state.
+func (s *Server) markStateChanged(*analytics.SessionState) {
+	select {
+	case s.stateChanged <- struct{}{}:
+	default:
+	}
+}
+
+// broadcastState sends the session state to WebSocket clients whenever it
+// changes until ctx is cancelled. State changes with every punch; changes
+// within stateFrameInterval of a broadcast go out together in the next, so
+// the state is serialized once per frame for all clients however fast
+// punches land.
+func (s *Server) broadcastState(ctx context.Context) {
+	timer := time.NewTimer(stateFrameInterval)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stateChanged:
+		}
+
+		m, err := s.sessionMessage()
+		if err != nil {
+			log.Printf("JSON marshal error: %v", err)
+		} else {
+			s.hub.BroadcastMessage(m)
+		}
+
+		timer.Reset(stateFrameInterval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// tick broadcasts elapsed time, keeps link stats, the fleet registry and the
+// learned thresholds up to date and logs sensor data every second until ctx
+// is cancelled.
+func (s *Server) tick(ctx context.Context) {
+	analyzer, central := s.analyzer, s.central
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	// Track previous calibration state for logging state changes
+	var leftWasCalibrated, rightWasCalibrated bool
+
+	var tick int
+
+	// Last round seen, for round-end buzzes
+	lastRound := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.saveThresholds()
+			return
+		case <-ticker.C:
+		}
+
+		analyzer.BroadcastTick()
+		updateLinkStats(analyzer, central)
+		if s.opponent != nil {
+			s.opponent.BroadcastTick()
+			updateLinkStats(s.opponent, s.opponentCentral)
+		}
+
+		// Get current state for logging
+		state := analyzer.GetState()
+
+		// Buzz the gloves as each round ends
+		if state.Active && !state.Paused {
+			round := int(state.ElapsedSec / s.cfg.RoundLength.Seconds())
+			if s.cfg.RoundBuzz && round > lastRound {
+				go buzzGloves(central)
+			}
+			lastRound = round
+		} else if !state.Active {
+			lastRound = 0
+		}
+
+		// Record battery readings in the fleet registry
+		for _, hand := range []ble.Hand{ble.LeftHand, ble.RightHand} {
+			if glove := central.ConnectedGlove(hand); glove != nil {
+				hs := state.Left
+				if hand == ble.RightHand {
+					hs = state.Right
+				}
+				s.registry.UpdateBattery(glove.Address.String(), hs.Battery, hs.Charging)
+			}
+		}
+		// Wi-Fi gloves join the fleet under the identity they discovered with
+		if s.udp != nil {
+			for _, d := range s.udp.Devices() {
+				if d.Identity != "" && time.Since(d.LastSeen) < 2*time.Second {
+					s.registry.Seen(d.Identity, d.Hand)
+				}
+			}
+		}
+		tick++
+		if tick%fleetSaveInterval == 0 {
+			if err := s.registry.Save(); err != nil {
+				log.Printf("Fleet save: %v", err)
+			}
+			s.saveThresholds()
+		}
+
+		// Log left hand sensor data if connected
+		if state.Left.Connected {
+			calStr := "UNCALIBRATED"
+			if state.Left.Calibrated {
+				calStr = "calibrated"
+			}
+
+			// Log calibration state change
+			if state.Left.Calibrated && !leftWasCalibrated {
+				log.Println("Sensor: FighterLink_L calibration complete!")
+			}
+			leftWasCalibrated = state.Left.Calibrated
+
+			log.Printf("Sensor [L] [%s]: Accel(%.2f, %.2f, %.2f) m/s² | Gyro(%.1f, %.1f, %.1f) °/s | Bat=%d%%",
+				calStr,
+				state.Left.CurrentAccel[0], state.Left.CurrentAccel[1], state.Left.CurrentAccel[2],
+				state.Left.CurrentGyro[0], state.Left.CurrentGyro[1], state.Left.CurrentGyro[2],
+				state.Left.Battery)
+		}
+
+		// Log right hand sensor data if connected
+		if state.Right.Connected {
+			calStr := "UNCALIBRATED"
+			if state.Right.Calibrated {
+				calStr = "calibrated"
+			}
+
+			// Log calibration state change
+			if state.Right.Calibrated && !rightWasCalibrated {
+				log.Println("Sensor: FighterLink_R calibration complete!")
+			}
+			rightWasCalibrated = state.Right.Calibrated
+
+			log.Printf("Sensor [R] [%s]: Accel(%.2f, %.2f, %.2f) m/s² | Gyro(%.1f, %.1f, %.1f) °/s | Bat=%d%%",
+				calStr,
+				state.Right.CurrentAccel[0], state.Right.CurrentAccel[1], state.Right.CurrentAccel[2],
+				state.Right.CurrentGyro[0], state.Right.CurrentGyro[1], state.Right.CurrentGyro[2],
+				state.Right.Battery)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package signage pushes live gym activity to digital-signage displays.
+//
+// Each target receives an HTTP POST rendered from a text/template, so
+// existing lobby screens that accept simple HTTP pushes can show the current
+// leader and live output without a custom client.
+package signage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"text/template"
+	"time"
+
+	"boxing-analytics/analytics"
+)
+
+// Defaults
+const (
+	defaultInterval    = 5 * time.Second
+	defaultContentType = "application/json"
+	pushTimeout        = 5 * time.Second
+)
+
+// Target is one signage endpoint.
+type Target struct {
+	Name        string            `json:"name,omitempty"`
+	URL         string            `json:"url"`
+	Template    string            `json:"template,omitempty"` // text/template; empty = JSON of Data
+	ContentType string            `json:"content_type,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// Config lists the signage targets and how often they are updated.
+type Config struct {
+	IntervalSec float64  `json:"interval_sec"`
+	Targets     []Target `json:"targets"`
+}
+
+// Leader is one entry of the daily leaderboard.
+type Leader struct {
+	Athlete  string  `json:"athlete"`
+	Name     string  `json:"name"`
+	Punches  int     `json:"punches"`
+	MaxForce float64 `json:"max_force"`
+}
+
+// Data is what templates render.
+type Data struct {
+	Time        time.Time               `json:"time"`
+	Gym         string                  `json:"gym,omitempty"`
+	Athlete     string                  `json:"athlete,omitempty"` // athlete in the live session
+	Live        *analytics.SessionState `json:"live"`
+	Leader      *Leader                 `json:"leader,omitempty"` // today's top athlete
+	Leaderboard []Leader                `json:"leaderboard"`
+}
+
+// Source produces the data pushed on every interval.
+type Source func() Data
+
+// LoadConfig reads a signage config file.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read signage config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("decode signage config: %w", err)
+	}
+	if len(cfg.Targets) == 0 {
+		return Config{}, errors.New("signage config has no targets")
+	}
+	return cfg, nil
+}
+
+// target is a Target with its parsed template.
+type target struct {
+	Target
+	tmpl *template.Template
+}
+
+// Pusher periodically renders Data for every target and POSTs it.
+type Pusher struct {
+	targets  []target
+	interval time.Duration
+	source   Source
+	client   *http.Client
+	stop     chan struct{}
+}
+
+// NewPusher validates the config and parses all target templates.
+func NewPusher(cfg Config, source Source) (*Pusher, error) {
+	p := &Pusher{
+		interval: defaultInterval,
+		source:   source,
+		client:   &http.Client{Timeout: pushTimeout},
+		stop:     make(chan struct{}),
+	}
+	if cfg.IntervalSec > 0 {
+		p.interval = time.Duration(cfg.IntervalSec * float64(time.Second))
+	}
+
+	for i, t := range cfg.Targets {
+		if t.URL == "" {
+			return nil, fmt.Errorf("signage target %d has no url", i)
+		}
+		if t.ContentType == "" {
+			t.ContentType = defaultContentType
+		}
+		tt := target{Target: t}
+		if t.Template != "" {
+			tmpl, err := template.New(t.URL).Parse(t.Template)
+			if err != nil {
+				return nil, fmt.Errorf("signage target %s template: %w", t.URL, err)
+			}
+			tt.tmpl = tmpl
+		}
+		p.targets = append(p.targets, tt)
+	}
+	return p, nil
+}
+
+// Start launches the push loop.
+func (p *Pusher) Start() {
+	go p.run()
+}
+
+// Stop halts the push loop.
+func (p *Pusher) Stop() {
+	close(p.stop)
+}
+
+// run pushes to every target on each interval.
+func (p *Pusher) run() {
+	log.Printf("Signage: pushing to %d targets every %s", len(p.targets), p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			data := p.source()
+			for i := range p.targets {
+				if err := p.push(&p.targets[i], &data); err != nil {
+					log.Printf("Signage: %s: %v", p.targets[i].URL, err)
+				}
+			}
+		}
+	}
+}
+
+// push renders data for one target and POSTs it.
+func (p *Pusher) push(t *target, data *Data) error {
+	var body bytes.Buffer
+	if t.tmpl != nil {
+		if err := t.tmpl.Execute(&body, data); err != nil {
+			return fmt.Errorf("render: %w", err)
+		}
+	} else if err := json.NewEncoder(&body).Encode(data); err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.URL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", t.ContentType)
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/ble"
+	"boxing-analytics/ingest"
+)
+
+const (
+	simulateRate   = 100             // samples per second per glove, as the firmware streams
+	simulateStill  = 5 * time.Second // held still first so the server calibrates
+	simulateBootMS = 1000            // device timestamp of the first sample
+	simulateNoise  = 8               // raw sensor noise amplitude
+	simulateMinMS2 = 30.0            // m/s² - weakest simulated punch
+	simulateMaxMS2 = 55.0            // m/s² - hardest simulated punch
+)
+
+// simulateMaxBatch bounds the samples per datagram, well within a 1500-byte
+// MTU.
+const simulateMaxBatch = 50
+
+// simulateShape is a punch's forward acceleration, sample by sample, as a
+// fraction of its peak.
+var simulateShape = []float64{0.4, 0.75, 1, 0.8, 0.45}
+
+// simulateRotation is the peak rotation (°/s) about the glove's X and Z
+// axes for each punch type; Z points up while the glove rests.
+var simulateRotation = map[analytics.PunchType][2]float64{
+	analytics.PunchStraight: {20, 20},
+	analytics.PunchHook:     {30, 280},
+	analytics.PunchUppercut: {220, 40},
+}
+
+// simGlove generates one glove's sample stream.
+type simGlove struct {
+	hand     ble.Hand
+	seq      uint16
+	noise    int        // raw sensor noise amplitude, 0 = simulateNoise
+	force    float64    // m/s² at the punch's peak
+	rotation [2]float64 // peak rotation of the punch, see simulateRotation
+	step     int        // index into simulateShape, len(simulateShape) = no punch
+
+	pending []*ble.SensorPacket // samples waiting to fill a batch
+}
+
+// throw starts a punch on the next samples.
+func (g *simGlove) throw(punch analytics.PunchType, force float64) {
+	g.swing(force, simulateRotation[punch])
+}
+
+// swing starts a movement with any peak rotation on the next samples.
+func (g *simGlove) swing(force float64, rotation [2]float64) {
+	g.force, g.rotation, g.step = force, rotation, 0
+}
+
+// sample returns the glove's next packet: resting plus noise, with the
+// current punch's acceleration and rotation added.
+func (g *simGlove) sample(rng *rand.Rand, ts uint32) *ble.SensorPacket {
+	amplitude := g.noise
+	if amplitude == 0 {
+		amplitude = simulateNoise
+	}
+	noise := func() int16 { return int16(rng.Intn(2*amplitude+1) - amplitude) }
+	p := &ble.SensorPacket{
+		AccX: noise(), AccY: noise(), AccZ: 980 + noise(),
+		GyroX: noise(), GyroY: noise(), GyroZ: noise(),
+		Timestamp: ts,
+		Sequence:  g.seq,
+		Battery:   90,
+	}
+	if g.step < len(simulateShape) {
+		f := simulateShape[g.step]
+		p.AccX += int16(f * g.force * 100)
+		p.GyroX += int16(f * g.rotation[0] * 10)
+		p.GyroZ += int16(f * g.rotation[1] * 10)
+		g.step++
+	}
+	g.seq++
+	return p
+}
+
+// runSimulate implements the "simulate" subcommand: it streams two
+// synthetic gloves throwing random punches to a running server over UDP,
+// for trying the dashboard without hardware.
+func runSimulate(args []string) int {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	to := fs.String("to", defaultUDPTarget, "UDP address of the server")
+	interval := fs.Duration("interval", time.Second, "average time between punches")
+	duration := fs.Duration("duration", 0, "stop after this long (default: until interrupted)")
+	seed := fs.Int64("seed", 0, "random seed (default: time-based)")
+	batch := fs.Int("batch", 1, "samples per datagram, sent as a v3 batch above 1")
+	fs.Parse(args)
+	if fs.NArg() != 0 || *interval <= 0 || *duration < 0 || *batch < 1 || *batch > simulateMaxBatch {
+		fs.Usage()
+		return 2
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	conn, err := net.Dial("udp", *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "simulate: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	ctx, stop := signalContext()
+	defer stop()
+	var deadline <-chan time.Time
+	if *duration > 0 {
+		deadline = time.After(*duration)
+	}
+
+	fmt.Printf("Simulating gloves on %s; start a session to count punches\n", *to)
+
+	gloves := []*simGlove{
+		{hand: ble.LeftHand, step: len(simulateShape)},
+		{hand: ble.RightHand, step: len(simulateShape)},
+	}
+	types := []analytics.PunchType{analytics.PunchStraight, analytics.PunchHook, analytics.PunchUppercut}
+	ticker := time.NewTicker(time.Second / simulateRate)
+	defer ticker.Stop()
+	next := time.Now().Add(simulateStill)
+	for n := 0; ; n++ {
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-deadline:
+			return 0
+		case now := <-ticker.C:
+			if now.After(next) {
+				g := gloves[rng.Intn(len(gloves))]
+				punch := types[rng.Intn(len(types))]
+				force := simulateMinMS2 + rng.Float64()*(simulateMaxMS2-simulateMinMS2)
+				g.throw(punch, force)
+				fmt.Printf("%s %s %.0f m/s²\n", g.hand, punch, force)
+				next = now.Add(time.Duration((0.5 + rng.Float64()) * float64(*interval)))
+			}
+		}
+
+		ts := uint32(simulateBootMS + n*1000/simulateRate)
+		for _, g := range gloves {
+			g.pending = append(g.pending, g.sample(rng, ts))
+			if len(g.pending) < *batch {
+				continue
+			}
+			data := g.pending[0].Marshal()
+			if *batch > 1 {
+				data = ble.MarshalBatch(g.pending)
+			}
+			g.pending = g.pending[:0]
+			if err := sendDatagram(conn, ingest.MarshalDatagram(g.hand, data)); err != nil {
+				fmt.Fprintf(os.Stderr, "simulate: %v\n", err)
+				return 1
+			}
+		}
+	}
+}
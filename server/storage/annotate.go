@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/validation"
+)
+
+// Tag limits
+const (
+	maxTags   = 20
+	maxTagLen = 32
+)
+
+// Annotation changes what an athlete or coach noted about a stored session.
+// Nil fields are left as they are.
+type Annotation struct {
+	Notes *string   `json:"notes"`
+	Tags  *[]string `json:"tags"` // replaces the tags
+	RPE   *int      `json:"rpe"`  // 0 clears the rating
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty and repeated ones.
+func NormalizeTags(tags []string) ([]string, error) {
+	out := []string{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLen {
+			return nil, fmt.Errorf("tag %q is too long: at most %d characters", tag, maxTagLen)
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	if len(out) > maxTags {
+		return nil, fmt.Errorf("too many tags: at most %d", maxTags)
+	}
+	return out, nil
+}
+
+// Validate checks the rating and normalizes the tags, returning
+// validation.Errors listing every invalid field.
+func (a *Annotation) Validate() error {
+	var errs validation.Errors
+	if a.Tags != nil {
+		tags, err := NormalizeTags(*a.Tags)
+		if err != nil {
+			errs.Add("tags", err)
+		} else {
+			a.Tags = &tags
+		}
+	}
+	if a.RPE != nil && (*a.RPE < 0 || *a.RPE > 10) {
+		errs.Add("rpe", fmt.Errorf("invalid rpe %d: must be 1-10, or 0 to clear", *a.RPE))
+	}
+	return errs.Err()
+}
+
+// Annotate applies an annotation to a stored session and saves it.
+func (s *Store) Annotate(id string, a Annotation) (*Session, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+
+	sess, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Notes != nil {
+		sess.Notes = strings.TrimSpace(*a.Notes)
+	}
+	if a.Tags != nil {
+		sess.Tags = *a.Tags
+	}
+	if a.RPE != nil {
+		sess.RPE = *a.RPE
+	}
+	if err := s.Save(sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// HasTag reports whether the session is tagged with tag.
+func (sess *Session) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range sess.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary is a stored session without its per-punch stats and detail, as
+// listed by the API.
+type Summary struct {
+	ID            string    `json:"id"`
+	Athlete       string    `json:"athlete,omitempty"`
+	Type          string    `json:"type,omitempty"`
+	Gym           string    `json:"gym,omitempty"`
+	Location      string    `json:"location,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	DurationSec   float64   `json:"duration_sec"`
+	PlannedRounds int       `json:"planned_rounds,omitempty"`
+	TotalPunches  int       `json:"total_punches"`
+	AvgForce      float64   `json:"avg_force"`
+	Notes         string    `json:"notes,omitempty"`
+	Tags          []string  `json:"tags,omitempty"`
+	RPE           int       `json:"rpe,omitempty"`
+
+	Units *analytics.Units `json:"units,omitempty"` // what avg_force is in, nil = m/s²
+}
+
+// Summary returns the session's summary.
+func (sess *Session) Summary() Summary {
+	sum := Summary{
+		ID:            sess.ID,
+		Athlete:       sess.Athlete,
+		Type:          sess.Type,
+		Gym:           sess.Gym,
+		Location:      sess.Location,
+		StartedAt:     sess.StartedAt,
+		DurationSec:   sess.DurationSec,
+		PlannedRounds: sess.PlannedRounds,
+		Notes:         sess.Notes,
+		Tags:          sess.Tags,
+		RPE:           sess.RPE,
+	}
+	if sess.State != nil {
+		sum.TotalPunches = sess.State.Combined.TotalPunches
+		sum.AvgForce = round2(sess.State.Combined.AvgForce)
+	}
+	return sum
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"math"
+	"time"
+)
+
+// PatternBucket aggregates session output for one time-of-day or weekday slot.
+type PatternBucket struct {
+	Sessions     int     `json:"sessions"`
+	Punches      int     `json:"punches"`
+	TotalMinutes float64 `json:"total_minutes"`
+	AvgForce     float64 `json:"avg_force"` // m/s², weighted by punch count
+	AvgPPM       float64 `json:"ppm"`       // punches per minute across all time trained
+
+	forceSum float64
+}
+
+// Patterns holds training output grouped by hour of day and day of week.
+type Patterns struct {
+	Athlete     string            `json:"athlete,omitempty"`
+	Gym         string            `json:"gym,omitempty"`
+	Sessions    int               `json:"sessions"`
+	ByHour      [24]PatternBucket `json:"by_hour"`      // index = local hour (0-23)
+	ByWeekday   [7]PatternBucket  `json:"by_weekday"`   // index = time.Weekday (0 = Sunday)
+	PeakHour    int               `json:"peak_hour"`    // hour with the highest PPM (-1 if none)
+	PeakWeekday string            `json:"peak_weekday"` // weekday with the highest PPM
+}
+
+// ComputePatterns aggregates sessions by their local start time. Sessions not
+// matching a non-empty athlete or gym filter are ignored.
+func ComputePatterns(sessions []*Session, athlete, gym string, loc *time.Location) *Patterns {
+	if loc == nil {
+		loc = time.Local
+	}
+	p := &Patterns{Athlete: athlete, Gym: gym, PeakHour: -1}
+
+	for _, sess := range sessions {
+		if athlete != "" && sess.Athlete != athlete {
+			continue
+		}
+		if gym != "" && sess.Gym != gym {
+			continue
+		}
+		if sess.State == nil {
+			continue
+		}
+
+		start := sess.StartedAt.In(loc)
+		p.Sessions++
+		addToBucket(&p.ByHour[start.Hour()], sess)
+		addToBucket(&p.ByWeekday[start.Weekday()], sess)
+	}
+
+	bestHour := 0.0
+	for h := range p.ByHour {
+		finishBucket(&p.ByHour[h])
+		if p.ByHour[h].AvgPPM > bestHour {
+			bestHour = p.ByHour[h].AvgPPM
+			p.PeakHour = h
+		}
+	}
+	bestDay := 0.0
+	for d := range p.ByWeekday {
+		finishBucket(&p.ByWeekday[d])
+		if p.ByWeekday[d].AvgPPM > bestDay {
+			bestDay = p.ByWeekday[d].AvgPPM
+			p.PeakWeekday = time.Weekday(d).String()
+		}
+	}
+
+	return p
+}
+
+// addToBucket adds one session's totals to a bucket.
+func addToBucket(b *PatternBucket, sess *Session) {
+	combined := sess.State.Combined
+	b.Sessions++
+	b.Punches += combined.TotalPunches
+	b.TotalMinutes += sess.DurationSec / 60
+	b.forceSum += combined.AvgForce * float64(combined.TotalPunches)
+}
+
+// finishBucket derives averages once all sessions have been added.
+func finishBucket(b *PatternBucket) {
+	if b.Punches > 0 {
+		b.AvgForce = round2(b.forceSum / float64(b.Punches))
+	}
+	if b.TotalMinutes > 0 {
+		b.AvgPPM = round2(float64(b.Punches) / b.TotalMinutes)
+	}
+	b.TotalMinutes = round2(b.TotalMinutes)
+}
+
+// round2 rounds to two decimal places for display.
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"boxing-analytics/analytics"
+)
+
+// ErrNoPunches is returned for a stored session without punch events:
+// saved before they were kept, or stripped by retention.
+var ErrNoPunches = errors.New("no punch events stored for session")
+
+// Punch query page sizes
+const (
+	DefaultPunchLimit = 100
+	MaxPunchLimit     = 1000
+)
+
+// punchesExt is the extension of a session's punch events, one JSON event
+// per line next to the session file.
+const punchesExt = ".punches.jsonl"
+
+// PunchQuery filters the punch events of a stored session. Zero fields do
+// not filter.
+type PunchQuery struct {
+	Hand     string              // "left" or "right"
+	Type     analytics.PunchType // e.g. "hook"
+	MinForce float64             // in Units
+	After    int                 // cursor: only punches with a higher ID
+	Limit    int                 // page size, 0 = DefaultPunchLimit
+	Units    analytics.Units     // of MinForce and the returned punches
+}
+
+// PunchPage is a page of punch events, in the order they landed.
+type PunchPage struct {
+	Punches []analytics.PunchEvent `json:"punches"`
+	Next    int                    `json:"next,omitempty"` // cursor of the next page, 0 on the last
+}
+
+// SavePunches writes the punch events of a stored session.
+func (s *Store) SavePunches(id string, punches []analytics.PunchEvent) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp := s.punchesPath(id) + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("write punches: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for i := range punches {
+		if err := enc.Encode(&punches[i]); err != nil {
+			f.Close()
+			return fmt.Errorf("write punches: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write punches: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write punches: %w", err)
+	}
+	if err := os.Rename(tmp, s.punchesPath(id)); err != nil {
+		return fmt.Errorf("commit punches: %w", err)
+	}
+	return nil
+}
+
+// Punches returns a page of the punch events of a stored session matching
+// q. The events are read one at a time, so a page costs no more memory
+// than its size however long the session.
+func (s *Store) Punches(id string, q PunchQuery) (*PunchPage, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultPunchLimit
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, err := os.Open(s.punchesPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(s.path(id)); errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, ErrNoPunches
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read punches: %w", err)
+	}
+	defer f.Close()
+
+	page := &PunchPage{Punches: []analytics.PunchEvent{}}
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for dec.More() {
+		var p analytics.PunchEvent
+		if err := dec.Decode(&p); err != nil {
+			return nil, fmt.Errorf("decode punches %s: %w", id, err)
+		}
+		if p.ID <= q.After || (q.Hand != "" && p.Hand != q.Hand) || (q.Type != "" && p.Type != q.Type) {
+			continue
+		}
+		p = p.InUnits(q.Units)
+		if p.Force < q.MinForce {
+			continue
+		}
+		if len(page.Punches) == q.Limit {
+			page.Next = page.Punches[len(page.Punches)-1].ID
+			break
+		}
+		page.Punches = append(page.Punches, p)
+	}
+	return page, nil
+}
+
+// deletePunchesLocked removes the punch events of a session, if any.
+// Must be called with s.mu held.
+func (s *Store) deletePunchesLocked(id string) error {
+	if err := os.Remove(s.punchesPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete punches: %w", err)
+	}
+	return nil
+}
+
+// hasPunches reports whether punch events are stored for a session.
+func (s *Store) hasPunches(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, err := os.Stat(s.punchesPath(id))
+	return err == nil
+}
+
+// punchesPath returns where a session's punch events are stored.
+func (s *Store) punchesPath(id string) string {
+	return filepath.Join(s.dir, id+punchesExt)
+}
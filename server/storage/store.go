@@ -0,0 +1,336 @@
+// Package storage persists completed FighterLink sessions to disk.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"boxing-analytics/analytics"
+)
+
+// ErrNotFound is returned when a session does not exist in the store.
+var ErrNotFound = errors.New("session not found")
+
+// Session is a completed training session as stored on disk.
+type Session struct {
+	ID          string                      `json:"id"`
+	Athlete     string                      `json:"athlete,omitempty"`
+	Type        string                      `json:"type,omitempty"` // session type, e.g. "heavy_bag"
+	Gym         string                      `json:"gym,omitempty"`
+	StartedAt   time.Time                   `json:"started_at"`
+	EndedAt     time.Time                   `json:"ended_at"`
+	DurationSec float64                     `json:"duration_sec"`
+	State       *analytics.SessionState     `json:"state"`
+	Timeline    *analytics.Timeline         `json:"timeline,omitempty"`   // magnitude waveform
+	Heatmap     *analytics.Heatmap          `json:"heatmap,omitempty"`    // punches per interval
+	HeartRate   []analytics.HeartRateSample `json:"heart_rate,omitempty"` // strap readings
+
+	// Given when the session started
+	PlannedRounds int    `json:"planned_rounds,omitempty"`
+	Location      string `json:"location,omitempty"`
+
+	// Added after the session, see Annotate
+	Notes string   `json:"notes,omitempty"`
+	Tags  []string `json:"tags,omitempty"` // e.g. "sparring", "tired"
+	RPE   int      `json:"rpe,omitempty"`  // perceived exertion, 1-10 (0 = not rated)
+}
+
+// Store keeps one JSON file per session in a directory.
+type Store struct {
+	mu      sync.RWMutex
+	dir     string
+	version uint64 // bumped by every write or delete
+}
+
+// NewStore creates a Store rooted at dir, creating the directory if needed.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// sessionIDLayout formats the start time session IDs begin with.
+const sessionIDLayout = "20060102T150405.000Z"
+
+// NewSessionID derives a sortable session ID from its start time.
+func NewSessionID(startedAt time.Time) string {
+	return startedAt.UTC().Format(sessionIDLayout)
+}
+
+// isSessionID reports whether id starts like a NewSessionID, optionally
+// followed by a suffix (e.g. "-b" for athlete b in sparring mode).
+func isSessionID(id string) bool {
+	if len(id) < len(sessionIDLayout) {
+		return false
+	}
+	_, err := time.Parse(sessionIDLayout, id[:len(sessionIDLayout)])
+	return err == nil
+}
+
+// Save writes a session to disk, replacing any existing file with the same ID.
+func (s *Store) Save(sess *Session) error {
+	if sess.ID == "" {
+		sess.ID = NewSessionID(sess.StartedAt)
+	}
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Write to a temp file first so a crash never leaves a truncated session
+	tmp := s.path(sess.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write session: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(sess.ID)); err != nil {
+		return fmt.Errorf("commit session: %w", err)
+	}
+	s.version++
+	return nil
+}
+
+// Version counts the writes and deletes of sessions since the store was
+// created, so that aggregates over them can be cached until it changes.
+func (s *Store) Version() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version
+}
+
+// Get loads a single session by ID.
+func (s *Store) Get(id string) (*Session, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read session: %w", err)
+	}
+
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", id, err)
+	}
+	return &sess, nil
+}
+
+// List returns all stored sessions ordered by start time.
+func (s *Store) List() ([]*Session, error) {
+	ids, err := s.IDs()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	for _, id := range ids {
+		sess, err := s.Get(id)
+		if err != nil {
+			// Skip unreadable files rather than failing the whole listing
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+	return sessions, nil
+}
+
+// SessionQuery filters the stored sessions. Zero fields do not filter.
+type SessionQuery struct {
+	Athlete string
+	Tag     string
+	Type    string    // session type, e.g. "heavy_bag"
+	Since   time.Time // started at or after
+	Until   time.Time // started before
+	Offset  int       // matching sessions to skip, newest first
+	Limit   int       // at most this many, 0 = all
+}
+
+// Sessions returns the stored sessions matching q, newest first. Sessions
+// outside the time range are skipped by their ID without being loaded, and
+// loading stops once Limit sessions are found.
+func (s *Store) Sessions(q SessionQuery) ([]*Session, error) {
+	ids, err := s.IDs()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []*Session{}
+	offset := q.Offset
+	for i := len(ids) - 1; i >= 0; i-- {
+		if q.Limit > 0 && len(sessions) == q.Limit {
+			break
+		}
+		// IDs hold the start time to the millisecond
+		started, _ := time.Parse(sessionIDLayout, ids[i][:len(sessionIDLayout)])
+		if !q.Since.IsZero() && !started.Add(time.Millisecond).After(q.Since) {
+			break // this and every older session started before Since
+		}
+		if !q.Until.IsZero() && !started.Before(q.Until) {
+			continue
+		}
+		sess, err := s.Get(ids[i])
+		if err != nil {
+			continue // unreadable, as in List
+		}
+		switch {
+		case q.Athlete != "" && sess.Athlete != q.Athlete,
+			q.Tag != "" && !sess.HasTag(q.Tag),
+			q.Type != "" && sess.Type != q.Type,
+			!q.Since.IsZero() && sess.StartedAt.Before(q.Since),
+			!q.Until.IsZero() && !sess.StartedAt.Before(q.Until):
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, nil
+}
+
+// IDs returns the IDs of all stored sessions without loading them, in
+// (start time) order. Other files sharing the data directory are skipped.
+func (s *Store) IDs() ([]string, error) {
+	s.mu.RLock()
+	entries, err := os.ReadDir(s.dir)
+	s.mu.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		id := strings.TrimSuffix(name, ".json")
+		if e.IsDir() || id == name || !isSessionID(id) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Delete removes a stored session.
+func (s *Store) Delete(id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	s.version++
+	return s.deletePunchesLocked(id)
+}
+
+// DeleteByAthlete removes every stored session belonging to an athlete and
+// returns how many were deleted.
+func (s *Store) DeleteByAthlete(athlete string) (int, error) {
+	sessions, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, sess := range sessions {
+		if sess.Athlete != athlete {
+			continue
+		}
+		if err := s.Delete(sess.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// StripDetail drops the per-punch detail (punch events, recent punches,
+// magnitude timeline and heart-rate trace) of sessions that ended before
+// cutoff, keeping their summary stats, and returns how many were stripped.
+func (s *Store) StripDetail(cutoff time.Time) (int, error) {
+	sessions, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+	stripped := 0
+	for _, sess := range sessions {
+		if !sess.EndedAt.Before(cutoff) || !(sess.hasDetail() || s.hasPunches(sess.ID)) {
+			continue
+		}
+		if sess.State != nil {
+			for _, hand := range []*analytics.HandState{sess.State.Left, sess.State.Right} {
+				if hand != nil {
+					hand.RecentPunches = []analytics.PunchEvent{}
+				}
+			}
+		}
+		sess.Timeline = nil
+		sess.HeartRate = nil
+		if err := s.Save(sess); err != nil {
+			return stripped, err
+		}
+		s.mu.Lock()
+		err := s.deletePunchesLocked(sess.ID)
+		s.mu.Unlock()
+		if err != nil {
+			return stripped, err
+		}
+		stripped++
+	}
+	return stripped, nil
+}
+
+// hasDetail reports whether a session still holds anything StripDetail
+// removes.
+func (sess *Session) hasDetail() bool {
+	if sess.Timeline != nil || len(sess.HeartRate) > 0 {
+		return true
+	}
+	if sess.State == nil {
+		return false
+	}
+	for _, hand := range []*analytics.HandState{sess.State.Left, sess.State.Right} {
+		if hand != nil && len(hand.RecentPunches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// path returns the file path for a session ID.
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// validID rejects IDs that could escape the data directory.
+func validID(id string) bool {
+	return id != "" && !strings.ContainsAny(id, `/\`) && id != "." && id != ".."
+}
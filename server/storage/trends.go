@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTrendRolling is the number of sessions the rolling average of a
+// trend spans when none is asked for.
+const DefaultTrendRolling = 5
+
+// trendMetrics extract a metric from a stored session. ok is false when the
+// session does not have it.
+var trendMetrics = map[string]func(sess *Session) (v float64, ok bool){
+	"avg_force": func(sess *Session) (float64, bool) {
+		return sess.State.Combined.AvgForce, sess.State.Combined.TotalPunches > 0
+	},
+	"max_force": func(sess *Session) (float64, bool) {
+		return sess.State.Combined.MaxForce, sess.State.Combined.TotalPunches > 0
+	},
+	"ppm": func(sess *Session) (float64, bool) {
+		return sess.State.Combined.PunchesPerMin, sess.State.Combined.TotalPunches > 0
+	},
+	"punches": func(sess *Session) (float64, bool) {
+		return float64(sess.State.Combined.TotalPunches), true
+	},
+	"intensity": func(sess *Session) (float64, bool) {
+		return float64(sess.State.Combined.IntensityScore), true
+	},
+	"minutes": func(sess *Session) (float64, bool) {
+		return sess.DurationSec / 60, true
+	},
+	"rhythm_cv": func(sess *Session) (float64, bool) {
+		r := sess.State.Combined.Rhythm
+		return r.CV, r.Intervals > 0
+	},
+}
+
+// TrendMetrics returns the names of the metrics a trend can follow, sorted.
+func TrendMetrics() []string {
+	names := make([]string, 0, len(trendMetrics))
+	for name := range trendMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TrendPoint is one session in a trend.
+type TrendPoint struct {
+	Session   string    `json:"session"`
+	StartedAt time.Time `json:"started_at"`
+	Value     float64   `json:"value"`
+	Rolling   float64   `json:"rolling"` // average over this and the previous sessions of the rolling window
+}
+
+// Trend follows a metric across stored sessions, with a least-squares
+// line through them to tell improvement from noise.
+type Trend struct {
+	Metric     string       `json:"metric"`
+	Athlete    string       `json:"athlete,omitempty"`
+	Gym        string       `json:"gym,omitempty"`
+	WindowDays float64      `json:"window_days"`
+	Rolling    int          `json:"rolling"` // sessions per rolling average
+	Points     []TrendPoint `json:"points"`  // oldest first
+
+	// Line through value over time; SlopePerDay is nil for fewer than two sessions
+	SlopePerDay *float64 `json:"slope_per_day"`
+	Intercept   float64  `json:"intercept"` // value of the line at the first session
+	R2          float64  `json:"r2"`        // how well the line fits, 0-1
+	Change      float64  `json:"change"`    // % change of the line over the sessions
+}
+
+// ParseWindow parses a trend window: a number of days ("90d") or a Go
+// duration ("36h").
+func ParseWindow(v string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid window %q", v)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid window %q", v)
+	}
+	return d, nil
+}
+
+// ComputeTrend follows metric across the sessions started within window
+// before now. Sessions not matching a non-empty athlete or gym filter, or
+// without the metric, are ignored.
+func ComputeTrend(sessions []*Session, metric, athlete, gym string, window time.Duration, rolling int, now time.Time) (*Trend, error) {
+	value, ok := trendMetrics[metric]
+	if !ok {
+		return nil, fmt.Errorf("invalid metric %q: must be one of %s", metric, strings.Join(TrendMetrics(), ", "))
+	}
+	if rolling < 1 {
+		rolling = DefaultTrendRolling
+	}
+	t := &Trend{
+		Metric:     metric,
+		Athlete:    athlete,
+		Gym:        gym,
+		WindowDays: round2(window.Hours() / 24),
+		Rolling:    rolling,
+		Points:     []TrendPoint{},
+	}
+
+	from := now.Add(-window)
+	for _, sess := range sessions {
+		if athlete != "" && sess.Athlete != athlete {
+			continue
+		}
+		if gym != "" && sess.Gym != gym {
+			continue
+		}
+		if sess.State == nil || sess.StartedAt.Before(from) || sess.StartedAt.After(now) {
+			continue
+		}
+		if v, ok := value(sess); ok {
+			t.Points = append(t.Points, TrendPoint{Session: sess.ID, StartedAt: sess.StartedAt, Value: round2(v)})
+		}
+	}
+	sort.Slice(t.Points, func(i, j int) bool { return t.Points[i].StartedAt.Before(t.Points[j].StartedAt) })
+
+	var sum float64
+	for i := range t.Points {
+		sum += t.Points[i].Value
+		if i >= rolling {
+			sum -= t.Points[i-rolling].Value
+		}
+		t.Points[i].Rolling = round2(sum / float64(min(i+1, rolling)))
+	}
+	t.fit()
+	return t, nil
+}
+
+// fit fits the least-squares line of value over days since the first
+// session.
+func (t *Trend) fit() {
+	n := float64(len(t.Points))
+	if n < 2 {
+		return
+	}
+	start := t.Points[0].StartedAt
+	var sx, sy, sxx, sxy, syy float64
+	for _, p := range t.Points {
+		x := p.StartedAt.Sub(start).Hours() / 24
+		sx += x
+		sy += p.Value
+		sxx += x * x
+		sxy += x * p.Value
+		syy += p.Value * p.Value
+	}
+	den := n*sxx - sx*sx
+	if den == 0 {
+		return // all sessions at the same time
+	}
+	slope := (n*sxy - sx*sy) / den
+	intercept := (sy - slope*sx) / n
+
+	rounded := math.Round(slope*1000) / 1000
+	t.SlopePerDay = &rounded
+	t.Intercept = round2(intercept)
+	if v := n*syy - sy*sy; v > 0 {
+		r := (n*sxy - sx*sy) / math.Sqrt(den*v)
+		t.R2 = math.Round(r*r*1000) / 1000
+	}
+	if intercept != 0 {
+		days := t.Points[len(t.Points)-1].StartedAt.Sub(start).Hours() / 24
+		t.Change = round2(slope * days / math.Abs(intercept) * 100)
+	}
+}
@@ -0,0 +1,27 @@
+package storage
+
+import "boxing-analytics/analytics"
+
+// InUnits returns a copy of the session with its state, timeline and
+// heatmap in u.
+func (sess *Session) InUnits(u analytics.Units) *Session {
+	if u.IsMetric() {
+		return sess
+	}
+	out := *sess
+	out.State = sess.State.InUnits(u)
+	out.Timeline = sess.Timeline.InUnits(u)
+	out.Heatmap = sess.Heatmap.InUnits(u)
+	return &out
+}
+
+// InUnits returns the summary with its average force in u.
+func (sum Summary) InUnits(u analytics.Units) Summary {
+	if u.IsMetric() {
+		return sum
+	}
+	sum.AvgForce = u.ConvertAccel(sum.AvgForce)
+	units := u.Normalized()
+	sum.Units = &units
+	return sum
+}
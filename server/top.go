@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"boxing-analytics/analytics"
+	"boxing-analytics/hub"
+)
+
+const (
+	topDialTimeout   = 5 * time.Second
+	topRetryInterval = 2 * time.Second
+	topDrawInterval  = 250 * time.Millisecond // redraws at most 4 times a second
+	topFeedSize      = 8                      // punches and events listed
+)
+
+// ANSI escapes: alternate screen, cursor visibility, clear
+const (
+	ansiEnterScreen = "\x1b[?1049h\x1b[?25l"
+	ansiLeaveScreen = "\x1b[?25h\x1b[?1049l"
+	ansiClear       = "\x1b[H\x1b[2J"
+)
+
+// runTop implements the "top" subcommand: a terminal view of a running
+// server's live stats, for headless setups without a browser nearby.
+func runTop(args []string) int {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	url := fs.String("url", "ws://localhost:8080/ws", "WebSocket endpoint of the server")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	fmt.Print(ansiEnterScreen)
+	defer fmt.Print(ansiLeaveScreen)
+
+	m := &monitor{url: *url, status: "connecting"}
+	go m.drawLoop(ctx)
+	for ctx.Err() == nil {
+		err := m.follow(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		m.setStatus(fmt.Sprintf("disconnected: %v (retrying)", err))
+		select {
+		case <-ctx.Done():
+		case <-time.After(topRetryInterval):
+		}
+	}
+	return 0
+}
+
+// monitor holds what top shows: the latest state broadcast plus a feed of
+// punches and events as they arrive.
+type monitor struct {
+	url string
+
+	mu       sync.Mutex
+	status   string
+	state    *analytics.SessionState  // single athlete
+	sparring *analytics.SparringState // sparring mode, replaces state
+	counts   map[string]int           // punches seen per athlete and hand
+	punches  []string                 // newest last
+	events   []string                 // newest last
+	dirty    bool
+}
+
+// follow connects to the server and applies its messages until the
+// connection fails or ctx is cancelled.
+func (m *monitor) follow(ctx context.Context) error {
+	dialCtx, cancel := context.WithTimeout(ctx, topDialTimeout)
+	c, err := hub.Dial(dialCtx, m.url)
+	cancel()
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		c.Close()
+	}()
+
+	m.setStatus("connected")
+	for {
+		msg, err := c.Read()
+		if err != nil {
+			return err
+		}
+		m.apply(msg)
+	}
+}
+
+// apply takes in one WebSocket message.
+func (m *monitor) apply(msg []byte) {
+	var head struct {
+		Type string `json:"type"`
+		Hand string `json:"hand"`
+		Side string `json:"side"`
+	}
+	if err := json.Unmarshal(msg, &head); err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dirty = true
+	switch head.Type {
+	case "":
+		var state analytics.SessionState
+		if json.Unmarshal(msg, &state) == nil {
+			m.state, m.sparring = &state, nil
+			m.trackPunchesLocked("", &state)
+		}
+	case "sparring":
+		var sp analytics.SparringState
+		if json.Unmarshal(msg, &sp) == nil && sp.A != nil && sp.B != nil {
+			m.sparring, m.state = &sp, nil
+			m.trackPunchesLocked("a ", sp.A)
+			m.trackPunchesLocked("b ", sp.B)
+		}
+	case "device_status", "punch":
+		// The state already reports connections and punches
+	default:
+		line := time.Now().Format("15:04:05") + " " + head.Type
+		for _, s := range []string{head.Side, head.Hand} {
+			if s != "" {
+				line += " " + s
+			}
+		}
+		m.events = appendFeed(m.events, line)
+	}
+}
+
+// trackPunchesLocked adds the punches a state reports for the first time to
+// the feed. athlete prefixes the lines in sparring mode.
+// Must be called with m.mu held.
+func (m *monitor) trackPunchesLocked(athlete string, state *analytics.SessionState) {
+	if m.counts == nil {
+		m.counts = make(map[string]int)
+	}
+	for _, hand := range []*analytics.HandState{state.Left, state.Right} {
+		if hand == nil {
+			continue
+		}
+		for _, p := range hand.RecentPunches {
+			key := athlete + p.Hand
+			if hand.PunchCount < m.counts[key] {
+				m.counts[key] = 0 // New session
+			}
+			if p.Count <= m.counts[key] {
+				continue
+			}
+			m.counts[key] = p.Count
+			m.punches = appendFeed(m.punches, fmt.Sprintf("%s%-5s  %-8s  %5.1f m/s²", athlete, p.Hand, p.Type, p.Force))
+		}
+	}
+}
+
+// appendFeed appends a line, keeping the last topFeedSize.
+func appendFeed(feed []string, line string) []string {
+	feed = append(feed, line)
+	if len(feed) > topFeedSize {
+		feed = feed[len(feed)-topFeedSize:]
+	}
+	return feed
+}
+
+func (m *monitor) setStatus(status string) {
+	m.mu.Lock()
+	m.status = status
+	m.dirty = true
+	m.mu.Unlock()
+}
+
+// drawLoop redraws the screen whenever something changed.
+func (m *monitor) drawLoop(ctx context.Context) {
+	ticker := time.NewTicker(topDrawInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			if m.dirty {
+				m.dirty = false
+				os.Stdout.WriteString(ansiClear + m.renderLocked())
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// renderLocked formats the screen.
+// Must be called with m.mu held.
+func (m *monitor) renderLocked() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "smart-punch top  %s  [%s]\n\n", m.url, m.status)
+	switch {
+	case m.sparring != nil:
+		writeState(&b, "Athlete a", m.sparring.A)
+		b.WriteString("\n")
+		writeState(&b, "Athlete b", m.sparring.B)
+		c := m.sparring.Comparison
+		fmt.Fprintf(&b, "\nLeader: %s  hardest puncher: %s\n", orDash(c.Leader), orDash(c.HardestPuncher))
+	case m.state != nil:
+		writeState(&b, "", m.state)
+	default:
+		b.WriteString("Waiting for the server...\n")
+	}
+
+	b.WriteString("\nRecent punches\n")
+	for i := len(m.punches) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "  %s\n", m.punches[i])
+	}
+	if len(m.events) > 0 {
+		b.WriteString("\nEvents\n")
+		for i := len(m.events) - 1; i >= 0; i-- {
+			fmt.Fprintf(&b, "  %s\n", m.events[i])
+		}
+	}
+	b.WriteString("\nCtrl-C to quit\n")
+	return b.String()
+}
+
+// writeState formats one athlete's session state as a table.
+func writeState(b *strings.Builder, title string, s *analytics.SessionState) {
+	session := "idle"
+	if s.Active {
+		session = "active"
+		if s.Paused {
+			session = "paused"
+		}
+	}
+	elapsed := time.Duration(s.ElapsedSec) * time.Second
+	fmt.Fprintf(b, "%sSession %s  %02d:%02d", prefix(title), session, int(elapsed.Minutes()), int(elapsed.Seconds())%60)
+	if s.Stance != "" {
+		fmt.Fprintf(b, "  stance %s", s.Stance)
+	}
+	if s.HeartRate > 0 {
+		fmt.Fprintf(b, "  HR %d bpm", s.HeartRate)
+	}
+	b.WriteString("\n\n")
+
+	left, right := s.Left, s.Right
+	if left == nil || right == nil {
+		return
+	}
+	tw := tabwriter.NewWriter(b, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "\tLEFT\tRIGHT\tTOTAL\t")
+	fmt.Fprintf(tw, "connected\t%s\t%s\t\t\n", yesNo(left.Connected), yesNo(right.Connected))
+	fmt.Fprintf(tw, "battery\t%s\t%s\t\t\n", battery(left), battery(right))
+	fmt.Fprintf(tw, "packet loss\t%.1f%%\t%.1f%%\t\t\n", left.PacketLoss, right.PacketLoss)
+	fmt.Fprintf(tw, "punches\t%d\t%d\t%d\t\n", left.PunchCount, right.PunchCount, s.Combined.TotalPunches)
+	for _, t := range punchTypes(left, right) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t\n", t, left.PunchBreakdown[t], right.PunchBreakdown[t],
+			left.PunchBreakdown[t]+right.PunchBreakdown[t])
+	}
+	fmt.Fprintf(tw, "max force\t%.1f\t%.1f\t%.1f\t\n", left.MaxForce, right.MaxForce, s.Combined.MaxForce)
+	fmt.Fprintf(tw, "avg force\t%.1f\t%.1f\t%.1f\t\n", left.AvgForce, right.AvgForce, s.Combined.AvgForce)
+	fmt.Fprintf(tw, "ppm\t%.1f\t%.1f\t%.1f\t\n", left.PunchesPerMin, right.PunchesPerMin, s.Combined.PunchesPerMin)
+	tw.Flush()
+}
+
+// punchTypes returns the punch types either hand has thrown, sorted.
+func punchTypes(hands ...*analytics.HandState) []string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, h := range hands {
+		for t := range h.PunchBreakdown {
+			if !seen[t] {
+				seen[t] = true
+				types = append(types, t)
+			}
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+func battery(h *analytics.HandState) string {
+	if !h.Connected {
+		return "-"
+	}
+	if h.Charging {
+		return fmt.Sprintf("%d%% +", h.Battery)
+	}
+	return fmt.Sprintf("%d%%", h.Battery)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func prefix(title string) string {
+	if title == "" {
+		return ""
+	}
+	return title + ": "
+}
@@ -0,0 +1,37 @@
+// Package validation collects the invalid fields of a request body, so that
+// an API client learns of all of them at once rather than one per request.
+package validation
+
+import "strings"
+
+// FieldError is an invalid field. Field is its JSON name, with the index of
+// a list element as in "notifications[1]", or the name of a query parameter.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors are the invalid fields of a value, in the order they were found.
+type Errors []FieldError
+
+// Add records an invalid field.
+func (e *Errors) Add(field string, err error) {
+	*e = append(*e, FieldError{Field: field, Message: err.Error()})
+}
+
+// Err returns e as an error, nil when no field is invalid.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error joins the messages of the invalid fields.
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, f := range e {
+		messages[i] = f.Message
+	}
+	return strings.Join(messages, "; ")
+}
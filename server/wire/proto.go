@@ -0,0 +1,96 @@
+package wire
+
+import (
+	"math"
+	"sort"
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// encoder appends protobuf fields to buf. As in proto3, fields holding their
+// zero value are left out; messages are written whenever they are present.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) varint(v uint64) {
+	for v >= 0x80 {
+		e.buf = append(e.buf, byte(v)|0x80)
+		v >>= 7
+	}
+	e.buf = append(e.buf, byte(v))
+}
+
+func (e *encoder) tag(field, wireType int) {
+	e.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+// uint writes a uint32 or uint64 field.
+func (e *encoder) uint(field int, v uint64) {
+	if v != 0 {
+		e.tag(field, wireVarint)
+		e.varint(v)
+	}
+}
+
+// int writes an int32 or int64 field; negative values take ten bytes.
+func (e *encoder) int(field int, v int64) {
+	e.uint(field, uint64(v))
+}
+
+func (e *encoder) bool(field int, v bool) {
+	if v {
+		e.tag(field, wireVarint)
+		e.varint(1)
+	}
+}
+
+func (e *encoder) double(field int, v float64) {
+	bits := math.Float64bits(v)
+	if bits == 0 {
+		return
+	}
+	e.tag(field, wireFixed64)
+	e.buf = append(e.buf,
+		byte(bits), byte(bits>>8), byte(bits>>16), byte(bits>>24),
+		byte(bits>>32), byte(bits>>40), byte(bits>>48), byte(bits>>56),
+	)
+}
+
+func (e *encoder) string(field int, s string) {
+	if s != "" {
+		e.tag(field, wireBytes)
+		e.varint(uint64(len(s)))
+		e.buf = append(e.buf, s...)
+	}
+}
+
+// message writes the embedded message encode writes.
+func (e *encoder) message(field int, encode func(*encoder)) {
+	var m encoder
+	encode(&m)
+	e.tag(field, wireBytes)
+	e.varint(uint64(len(m.buf)))
+	e.buf = append(e.buf, m.buf...)
+}
+
+// counts writes a map<string, int32> field, in key order so that equal maps
+// encode the same.
+func (e *encoder) counts(field int, m map[string]int) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e.message(field, func(entry *encoder) {
+			entry.string(1, k)
+			entry.int(2, int64(m[k]))
+		})
+	}
+}
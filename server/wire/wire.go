@@ -0,0 +1,176 @@
+// Package wire encodes WebSocket broadcasts as Protocol Buffers for clients
+// that negotiate them instead of JSON, as smartpunch.proto defines: a stable,
+// compact format for native apps. The encoding is written by hand, so the
+// field numbers here must follow the .proto.
+package wire
+
+import (
+	_ "embed"
+
+	"boxing-analytics/analytics"
+)
+
+// Proto is the schema of the broadcasts, smartpunch.proto.
+//
+//go:embed smartpunch.proto
+var Proto []byte
+
+// Broadcast fields
+const (
+	broadcastSeq      = 1
+	broadcastTS       = 2
+	broadcastSide     = 3
+	broadcastHand     = 4
+	broadcastState    = 16
+	broadcastSparring = 17
+	broadcastPunch    = 18
+)
+
+// State encodes a session state broadcast.
+func State(s *analytics.SessionState) []byte {
+	var e encoder
+	e.message(broadcastState, func(e *encoder) { sessionState(e, s) })
+	return e.buf
+}
+
+// Sparring encodes the state broadcast of sparring mode.
+func Sparring(s *analytics.SparringState) []byte {
+	var e encoder
+	e.message(broadcastSparring, func(e *encoder) {
+		if s.A != nil {
+			e.message(1, func(e *encoder) { sessionState(e, s.A) })
+		}
+		if s.B != nil {
+			e.message(2, func(e *encoder) { sessionState(e, s.B) })
+		}
+		e.message(3, func(e *encoder) {
+			c := s.Comparison
+			e.double(1, c.PunchShareA)
+			e.int(2, int64(c.PunchDiff))
+			e.double(3, c.ForceRatio)
+			e.double(4, c.PPMDiff)
+			e.int(5, int64(c.IntensityDiff))
+			e.string(6, c.Leader)
+			e.string(7, c.HardestPuncher)
+		})
+	})
+	return e.buf
+}
+
+// Event encodes an event broadcast, nil for events the schema has no
+// message for: those are sent as JSON.
+func Event(ev *analytics.Event) []byte {
+	punch, ok := ev.Data.(analytics.PunchEvent)
+	if !ok {
+		return nil
+	}
+	var e encoder
+	e.uint(broadcastSeq, ev.Seq)
+	e.int(broadcastTS, ev.Timestamp)
+	e.string(broadcastSide, ev.Side)
+	e.string(broadcastHand, ev.Hand)
+	e.message(broadcastPunch, func(e *encoder) { punchEvent(e, punch) })
+	return e.buf
+}
+
+func sessionState(e *encoder, s *analytics.SessionState) {
+	e.bool(1, s.Active)
+	e.double(2, s.ElapsedSec)
+	if s.Left != nil {
+		e.message(3, func(e *encoder) { handState(e, s.Left) })
+	}
+	if s.Right != nil {
+		e.message(4, func(e *encoder) { handState(e, s.Right) })
+	}
+	e.message(5, func(e *encoder) { combinedStats(e, &s.Combined) })
+	e.bool(6, s.Paused)
+	e.string(7, s.Stance)
+	e.string(8, s.SessionType)
+	e.int(9, int64(s.HeartRate))
+	e.double(10, s.EffectiveMass)
+	if u := s.Units; u != nil {
+		e.message(11, func(e *encoder) {
+			e.string(1, u.Accel)
+			e.string(2, u.Force)
+			e.string(3, u.Mass)
+		})
+	}
+}
+
+func handState(e *encoder, h *analytics.HandState) {
+	e.bool(1, h.Connected)
+	e.bool(2, h.Calibrated)
+	e.uint(3, uint64(h.Battery))
+	e.bool(4, h.Charging)
+	e.double(5, h.PacketLoss)
+	e.int(6, int64(h.PunchCount))
+	e.int(7, int64(h.ContactCount))
+	e.int(8, int64(h.AirCount))
+	e.counts(9, h.PunchBreakdown)
+	e.double(10, h.MaxForce)
+	e.double(11, h.AvgForce)
+	e.double(12, h.MaxPower)
+	e.double(13, h.AvgPower)
+	for _, b := range h.ForceHistogram {
+		e.message(14, func(e *encoder) {
+			e.double(1, b.From)
+			e.double(2, b.To)
+			e.int(3, int64(b.Count))
+		})
+	}
+	e.double(15, h.PunchesPerMin)
+	e.message(16, func(e *encoder) { rollingRate(e, h.RollingPPM) })
+	for _, p := range h.RecentPunches {
+		e.message(17, func(e *encoder) { punchEvent(e, p) })
+	}
+	e.double(18, h.CalibrationProgress)
+	e.double(19, h.Threshold)
+	e.bool(20, h.AutoThreshold)
+	e.double(21, h.AvgRFD)
+}
+
+func combinedStats(e *encoder, c *analytics.CombinedStats) {
+	e.int(1, int64(c.TotalPunches))
+	e.double(2, c.AvgForce)
+	e.double(3, c.MaxForce)
+	e.double(4, c.AvgPower)
+	e.double(5, c.MaxPower)
+	e.double(6, c.PunchesPerMin)
+	e.double(7, c.PunchesPerSec)
+	e.int(8, int64(c.IntensityScore))
+	e.message(9, func(e *encoder) { rollingRate(e, c.RollingPPM) })
+	e.int(10, int64(c.ContactPunches))
+	e.int(11, int64(c.AirPunches))
+	e.int(12, int64(c.Combos))
+	e.int(13, int64(c.LongestCombo))
+	e.counts(14, c.ComboBreakdown)
+	e.int(15, int64(c.Flurries))
+	e.int(16, int64(c.LongestFlurry))
+	e.double(17, c.MaxBurstRate)
+}
+
+func rollingRate(e *encoder, r analytics.RollingRate) {
+	e.double(1, r.PPM10s)
+	e.double(2, r.PPM30s)
+	e.double(3, r.PPM60s)
+}
+
+func punchEvent(e *encoder, p analytics.PunchEvent) {
+	e.int(1, int64(p.ID))
+	e.string(2, p.Hand)
+	e.string(3, string(p.Type))
+	e.double(4, p.Force)
+	e.double(5, p.RotationZ)
+	e.double(6, p.RFD)
+	e.int(7, p.DurationMS)
+	e.double(8, p.Retraction)
+	e.double(9, p.Power)
+	e.message(10, func(e *encoder) {
+		e.double(1, p.Trajectory.Horizontal)
+		e.double(2, p.Trajectory.Vertical)
+		e.double(3, p.Trajectory.Elevation)
+	})
+	e.bool(11, p.Contact)
+	e.int(12, p.Timestamp)
+	e.int(13, int64(p.Count))
+}
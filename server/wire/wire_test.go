@@ -0,0 +1,378 @@
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"boxing-analytics/analytics"
+)
+
+// protoField is a field declared in smartpunch.proto.
+type protoField struct {
+	name     string
+	typ      string // scalar type or message name; the value type of a map
+	repeated bool   // repeated fields and maps
+	key      string // key type of a map, "" otherwise
+}
+
+// protoFieldRE matches a field line: [repeated] type name = number;
+var protoFieldRE = regexp.MustCompile(`^(repeated\s+)?(map<\s*(\w+)\s*,\s*(\w+)\s*>|\w+)\s+(\w+)\s*=\s*(\d+);`)
+
+// parseProto reads the messages of a schema, keyed by name and field
+// number. It understands what smartpunch.proto uses: messages, oneofs,
+// maps, repeated fields and comments.
+func parseProto(t *testing.T, schema []byte) map[string]map[int]protoField {
+	t.Helper()
+	messages := make(map[string]map[int]protoField)
+	var current string
+	depth := 0
+	scanner := bufio.NewScanner(bytes.NewReader(schema))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "message "):
+			current = strings.TrimSuffix(strings.Fields(line)[1], "{")
+			messages[current] = make(map[int]protoField)
+			depth++
+		case strings.HasPrefix(line, "oneof "):
+			depth++
+		case line == "}":
+			if depth--; depth == 0 {
+				current = ""
+			}
+		case current != "":
+			m := protoFieldRE.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			number, _ := strconv.Atoi(m[6])
+			f := protoField{name: m[5], typ: m[2], repeated: m[1] != ""}
+			if m[3] != "" {
+				f.key, f.typ, f.repeated = m[3], m[4], true
+			}
+			if _, dup := messages[current][number]; dup {
+				t.Fatalf("%s: field number %d declared twice", current, number)
+			}
+			messages[current][number] = f
+		}
+	}
+	return messages
+}
+
+// decoder decodes protobuf bytes as the messages of a parsed schema.
+type decoder struct {
+	messages map[string]map[int]protoField
+}
+
+// message decodes data as the named message into field name → value.
+// Scalars decode to float64, int64, uint64, bool or string, messages to
+// maps, maps to map[string]int64 and repeated fields to slices. Fields the
+// schema does not declare, or sent with the wrong wire type, are errors.
+func (d *decoder) message(name string, data []byte) (map[string]any, error) {
+	fields, ok := d.messages[name]
+	if !ok {
+		return nil, fmt.Errorf("no message %s in the schema", name)
+	}
+	out := make(map[string]any)
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("%s: bad tag", name)
+		}
+		data = data[n:]
+		number, wireType := int(tag>>3), int(tag&7)
+		f, ok := fields[number]
+		if !ok {
+			return nil, fmt.Errorf("%s: field %d is not in the schema", name, number)
+		}
+		if want := d.wireType(f); wireType != want {
+			return nil, fmt.Errorf("%s.%s: wire type %d, the schema says %d", name, f.name, wireType, want)
+		}
+
+		var raw []byte
+		var bits uint64
+		switch wireType {
+		case wireVarint:
+			if bits, n = binary.Uvarint(data); n <= 0 {
+				return nil, fmt.Errorf("%s.%s: bad varint", name, f.name)
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return nil, fmt.Errorf("%s.%s: short fixed64", name, f.name)
+			}
+			bits, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return nil, fmt.Errorf("%s.%s: bad length", name, f.name)
+			}
+			raw, data = data[n:n+int(size)], data[n+int(size):]
+		}
+
+		value, err := d.value(f, bits, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", name, f.name, err)
+		}
+		switch {
+		case f.key != "":
+			entry := value.(map[string]any)
+			m, _ := out[f.name].(map[string]int64)
+			if m == nil {
+				m = make(map[string]int64)
+				out[f.name] = m
+			}
+			key, _ := entry["key"].(string)
+			v, _ := entry["value"].(int64)
+			m[key] = v
+		case f.repeated:
+			list, _ := out[f.name].([]any)
+			out[f.name] = append(list, value)
+		default:
+			if _, dup := out[f.name]; dup {
+				return nil, fmt.Errorf("%s.%s: sent twice", name, f.name)
+			}
+			out[f.name] = value
+		}
+	}
+	return out, nil
+}
+
+// wireType returns the wire type a field is encoded with.
+func (d *decoder) wireType(f protoField) int {
+	if f.key != "" {
+		return wireBytes // map entry
+	}
+	switch f.typ {
+	case "double":
+		return wireFixed64
+	case "bool", "int32", "int64", "uint32", "uint64":
+		return wireVarint
+	}
+	return wireBytes // string or message
+}
+
+// value decodes one field value.
+func (d *decoder) value(f protoField, bits uint64, raw []byte) (any, error) {
+	if f.key != "" {
+		entry := map[int]protoField{1: {name: "key", typ: f.key}, 2: {name: "value", typ: f.typ}}
+		sub := &decoder{messages: map[string]map[int]protoField{"entry": entry}}
+		return sub.message("entry", raw)
+	}
+	switch f.typ {
+	case "double":
+		return math.Float64frombits(bits), nil
+	case "bool":
+		return bits != 0, nil
+	case "int32", "int64":
+		return int64(bits), nil
+	case "uint32", "uint64":
+		return bits, nil
+	case "string":
+		return string(raw), nil
+	}
+	return d.message(f.typ, raw)
+}
+
+// decodeBroadcast decodes a frame as the schema's Broadcast message.
+func decodeBroadcast(t *testing.T, frame []byte) map[string]any {
+	t.Helper()
+	d := &decoder{messages: parseProto(t, Proto)}
+	msg, err := d.message("Broadcast", frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestStateMatchesSchema(t *testing.T) {
+	punch := analytics.PunchEvent{
+		ID: 7, Hand: "left", Type: analytics.PunchHook, Force: 41.5, RotationZ: 260,
+		RFD: 812.4, DurationMS: 60, Retraction: 2.1, Power: 310,
+		Trajectory: analytics.Trajectory{Horizontal: 3.2, Vertical: -0.4, Elevation: -7},
+		Contact:    true, Timestamp: 123456, Count: 4,
+	}
+	left := &analytics.HandState{
+		Connected: true, Calibrated: true, Battery: 64, PacketLoss: 1.5,
+		PunchCount: 4, ContactCount: 3, AirCount: 1,
+		PunchBreakdown: map[string]int{"hook": 3, "jab": 1},
+		MaxForce:       41.5, AvgForce: 33.25,
+		ForceHistogram: []analytics.ForceBucket{{From: 0, To: 30, Count: 1}, {From: 30, Count: 3}},
+		PunchesPerMin:  12, RollingPPM: analytics.RollingRate{PPM10s: 18, PPM30s: 12},
+		RecentPunches: []analytics.PunchEvent{punch},
+		Threshold:     25, AvgRFD: 700,
+	}
+	state := &analytics.SessionState{
+		Active: true, ElapsedSec: 20.5, Left: left, Right: &analytics.HandState{},
+		Combined: analytics.CombinedStats{
+			TotalPunches: 4, MaxForce: 41.5, IntensityScore: 37,
+			ComboBreakdown: map[string]int{"jab-hook": 1}, LongestCombo: 2,
+		},
+		Stance: analytics.StanceOrthodox, SessionType: "heavy_bag", HeartRate: 151,
+		EffectiveMass: 4.2, Units: &analytics.Units{Accel: "g", Force: "lbf", Mass: "lb"},
+	}
+
+	msg := decodeBroadcast(t, State(state))
+	s, ok := msg["state"].(map[string]any)
+	if !ok {
+		t.Fatalf("no state in %v", msg)
+	}
+	for name, want := range map[string]any{
+		"active": true, "elapsed_sec": 20.5, "stance": "orthodox", "session_type": "heavy_bag",
+		"heart_rate": int64(151), "effective_mass": 4.2,
+	} {
+		if s[name] != want {
+			t.Errorf("state.%s = %v, want %v", name, s[name], want)
+		}
+	}
+	if u := s["units"].(map[string]any); u["accel"] != "g" || u["force"] != "lbf" || u["mass"] != "lb" {
+		t.Errorf("state.units = %v", u)
+	}
+	if _, ok := s["right"].(map[string]any); !ok {
+		t.Error("state.right missing: an empty hand is still sent")
+	}
+
+	l := s["left"].(map[string]any)
+	for name, want := range map[string]any{
+		"connected": true, "battery": uint64(64), "packet_loss": 1.5, "punch_count": int64(4),
+		"contact_punches": int64(3), "air_punches": int64(1), "avg_force": 33.25,
+		"ppm": 12.0, "threshold": 25.0, "avg_rfd": 700.0,
+	} {
+		if l[name] != want {
+			t.Errorf("left.%s = %v, want %v", name, l[name], want)
+		}
+	}
+	if b := l["punch_breakdown"].(map[string]int64); b["hook"] != 3 || b["jab"] != 1 || len(b) != 2 {
+		t.Errorf("left.punch_breakdown = %v", b)
+	}
+	if h := l["force_histogram"].([]any); len(h) != 2 || h[1].(map[string]any)["count"] != int64(3) {
+		t.Errorf("left.force_histogram = %v", h)
+	}
+	if r := l["rolling_ppm"].(map[string]any); r["ppm_10s"] != 18.0 || r["ppm_60s"] != nil {
+		t.Errorf("left.rolling_ppm = %v", r)
+	}
+	recent := l["recent_punches"].([]any)
+	if len(recent) != 1 {
+		t.Fatalf("left.recent_punches has %d punches, want 1", len(recent))
+	}
+	checkPunch(t, recent[0].(map[string]any), punch)
+
+	c := s["combined"].(map[string]any)
+	if c["total_punches"] != int64(4) || c["intensity_score"] != int64(37) || c["longest_combo"] != int64(2) {
+		t.Errorf("combined = %v", c)
+	}
+	if b := c["combo_breakdown"].(map[string]int64); b["jab-hook"] != 1 {
+		t.Errorf("combined.combo_breakdown = %v", b)
+	}
+}
+
+func TestSparringMatchesSchema(t *testing.T) {
+	state := &analytics.SparringState{
+		A: &analytics.SessionState{Active: true, Stance: analytics.StanceSouthpaw},
+		B: &analytics.SessionState{Active: true},
+		Comparison: analytics.SparringComparison{
+			PunchShareA: 0.6, PunchDiff: 4, ForceRatio: 1.1, PPMDiff: -2, IntensityDiff: 5,
+			Leader: "a", HardestPuncher: "b",
+		},
+	}
+	msg := decodeBroadcast(t, Sparring(state))
+	s, ok := msg["sparring"].(map[string]any)
+	if !ok {
+		t.Fatalf("no sparring state in %v", msg)
+	}
+	if a := s["a"].(map[string]any); a["stance"] != "southpaw" {
+		t.Errorf("sparring.a = %v", a)
+	}
+	if _, ok := s["b"].(map[string]any); !ok {
+		t.Error("sparring.b missing")
+	}
+	c := s["comparison"].(map[string]any)
+	for name, want := range map[string]any{
+		"punch_share_a": 0.6, "punch_diff": int64(4), "force_ratio": 1.1, "ppm_diff": -2.0,
+		"intensity_diff": int64(5), "leader": "a", "hardest_puncher": "b",
+	} {
+		if c[name] != want {
+			t.Errorf("comparison.%s = %v, want %v", name, c[name], want)
+		}
+	}
+}
+
+func TestEventMatchesSchema(t *testing.T) {
+	punch := analytics.PunchEvent{ID: 3, Hand: "right", Type: analytics.PunchCross, Force: 52, Timestamp: 99, Count: 2}
+	ev := &analytics.Event{Seq: 41, Type: "punch", Hand: "right", Side: "b", Timestamp: 1700000000000, Data: punch}
+
+	msg := decodeBroadcast(t, Event(ev))
+	for name, want := range map[string]any{
+		"seq": uint64(41), "ts": int64(1700000000000), "side": "b", "hand": "right",
+	} {
+		if msg[name] != want {
+			t.Errorf("broadcast.%s = %v, want %v", name, msg[name], want)
+		}
+	}
+	p, ok := msg["punch"].(map[string]any)
+	if !ok {
+		t.Fatalf("no punch in %v", msg)
+	}
+	checkPunch(t, p, punch)
+
+	if frame := Event(&analytics.Event{Type: "battery_low", Data: analytics.BatteryLowEvent{Level: 9}}); frame != nil {
+		t.Errorf("battery_low encoded as %x, want nil: it has no message in the schema", frame)
+	}
+}
+
+// checkPunch compares a decoded PunchEvent with the punch it encodes.
+func checkPunch(t *testing.T, got map[string]any, want analytics.PunchEvent) {
+	t.Helper()
+	fields := map[string]any{
+		"id": int64(want.ID), "hand": want.Hand, "type": string(want.Type), "force": want.Force,
+		"rotation_z": want.RotationZ, "rfd": want.RFD, "duration_ms": want.DurationMS,
+		"retraction": want.Retraction, "power": want.Power, "contact": want.Contact,
+		"ts": want.Timestamp, "count": int64(want.Count),
+	}
+	for name, w := range fields {
+		if isZero(w) {
+			w = nil // proto3 leaves zero values out
+		}
+		if got[name] != w {
+			t.Errorf("punch.%s = %v, want %v", name, got[name], w)
+		}
+	}
+	tr, _ := got["trajectory"].(map[string]any)
+	for name, w := range map[string]float64{
+		"horizontal": want.Trajectory.Horizontal, "vertical": want.Trajectory.Vertical, "elevation": want.Trajectory.Elevation,
+	} {
+		var g float64
+		if v, ok := tr[name]; ok {
+			g = v.(float64)
+		}
+		if g != w {
+			t.Errorf("punch.trajectory.%s = %v, want %v", name, g, w)
+		}
+	}
+}
+
+// isZero reports whether a decoded scalar is its type's zero value.
+func isZero(v any) bool {
+	switch v := v.(type) {
+	case float64:
+		return v == 0
+	case int64:
+		return v == 0
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	}
+	return false
+}